@@ -3,10 +3,14 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -15,7 +19,9 @@ import (
 	"github.com/Open-Course-Factory/ocf-worker/internal/database"
 	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
 	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
+	"github.com/Open-Course-Factory/ocf-worker/internal/validation"
 	"github.com/Open-Course-Factory/ocf-worker/internal/worker"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
 
 	"github.com/lpernett/godotenv"
 )
@@ -64,6 +70,9 @@ import (
 // @tag.name Archive
 // @tag.description Création et téléchargement d'archives de résultats
 //
+// @tag.name Themes
+// @tag.description Aperçu des thèmes Slidev avant leur adoption
+//
 // @tag.name Health
 // @tag.description Health checks et monitoring du système
 func main() {
@@ -74,6 +83,27 @@ func main() {
 
 	// Load configuration
 	cfg := config.Load()
+	if err := cfg.Upload.Validate(); err != nil {
+		log.Fatal("Invalid upload configuration:", err)
+	}
+	if err := cfg.Server.Validate(); err != nil {
+		log.Fatal("Invalid server configuration:", err)
+	}
+	if _, err := storage.ParseResultEncodings(cfg.Worker.ResultCompression); err != nil {
+		log.Fatal("Invalid RESULT_COMPRESSION:", err)
+	}
+	directoryRules, err := validation.ParseDirectoryRules(cfg.Upload.DirectoryRules)
+	if err != nil {
+		log.Fatal("Invalid SOURCE_DIRECTORY_RULES:", err)
+	}
+	contentPatterns, err := validation.ParseContentPatterns(cfg.Upload.ContentDenyPatterns, cfg.Upload.ContentDenyDefaultsDisabled)
+	if err != nil {
+		log.Fatal("Invalid CONTENT_DENY_PATTERNS or CONTENT_DENY_DEFAULTS_DISABLED:", err)
+	}
+	validationMessages, err := validation.LoadMessageCatalog(cfg.ValidationMessagesFile)
+	if err != nil {
+		log.Fatal("Invalid VALIDATION_MESSAGES_FILE:", err)
+	}
 
 	// Initialize storage
 	storageBackend, err := storage.NewStorage(cfg.Storage)
@@ -81,6 +111,13 @@ func main() {
 		log.Fatal("Failed to initialize storage:", err)
 	}
 	storageService := storage.NewStorageService(storageBackend)
+	storageService.SetUploadConcurrency(cfg.Upload.Concurrency)
+	storageService.SetArchiveReadConcurrency(cfg.ArchiveReadConcurrency)
+	storageService.SetMaxPathDepth(cfg.Upload.MaxPathDepth)
+	storageService.SetNamespace(cfg.StorageNamespace)
+	storageService.SetResultQuota(cfg.CourseResultQuota, cfg.CourseResultQuotas)
+	storageService.SetManifestVersions(cfg.ManifestVersions)
+	storageService.SetTransferWindow(cfg.StorageTransferWindow)
 
 	// Connect to database
 	db, err := database.Connect(cfg.DatabaseURL, cfg.LogLevel)
@@ -96,20 +133,74 @@ func main() {
 
 	// Initialize services
 	jobRepo := jobs.NewJobRepository(db.DB)
-	jobService := jobs.NewJobServiceImpl(jobRepo)
+	jobService := jobs.NewJobServiceImplWithConfig(jobRepo, &jobs.ServiceConfig{
+		ProgressCacheTTL: cfg.JobCacheTTL,
+	})
 
 	// Initialize worker pool
 	workerConfig := &worker.PoolConfig{
-		WorkerCount:      getWorkerCount(cfg),
-		PollInterval:     5 * time.Second,
-		JobTimeout:       cfg.JobTimeout,
-		WorkspaceBase:    getWorkspaceBase(cfg),
-		SlidevCommand:    getSlidevCommand(cfg),
-		CleanupWorkspace: true,
+		WorkerCount:               getWorkerCount(cfg),
+		PollInterval:              5 * time.Second,
+		JobTimeout:                cfg.JobTimeout,
+		WorkspaceBase:             getWorkspaceBase(cfg),
+		SlidevCommand:             getSlidevCommand(cfg),
+		CleanupWorkspace:          true,
+		NpmCacheMode:              cfg.Worker.NpmCacheMode,
+		BuildCacheMode:            cfg.Worker.BuildCacheMode,
+		BuildCacheDir:             cfg.Worker.BuildCacheDir,
+		SlideFiles:                cfg.Worker.SlideFiles,
+		LogReplayLines:            cfg.Worker.LogReplayLines,
+		LogFormat:                 cfg.Worker.LogFormat,
+		MaxBuilds:                 cfg.Worker.MaxBuilds,
+		MaxNpmProcesses:           cfg.Worker.MaxNpmProcesses,
+		BuildMemoryLimit:          cfg.Worker.BuildMemoryLimitMB << 20,
+		BuildCgroupDir:            cfg.Worker.BuildCgroupDir,
+		VersionCheckMode:          cfg.Worker.VersionCheckMode,
+		NonZeroExitMode:           cfg.Worker.NonZeroExitMode,
+		DispatchMode:              cfg.Worker.DispatchMode,
+		AffinityQueueThreshold:    cfg.Worker.AffinityQueueThreshold,
+		SourceRetention:           models.SourceRetention(cfg.Worker.SourceRetention),
+		StatsIncludeDependencies:  cfg.Worker.StatsIncludeDependencies,
+		CleanupProtectedStatuses:  cfg.Worker.CleanupProtectedStatuses,
+		StorageNamespacePerClient: cfg.StorageNamespacePerClient,
+		OrphanGracePeriod:         cfg.Worker.OrphanGracePeriod,
+		ResultCompression:         cfg.Worker.ResultCompression,
+		NpmInstallRetries:         cfg.Worker.NpmInstallRetries,
+		NpmInstallRetryBackoff:    cfg.Worker.NpmInstallRetryBackoff,
+		AllowedThemes:             cfg.Worker.AllowedThemes,
+		DeniedThemes:              cfg.Worker.DeniedThemes,
+		InstallDeckPackages:       cfg.Worker.InstallDeckPackages,
+		SourceDownloadTimeout:     cfg.Worker.SourceDownloadTimeout,
+		QueueOverflowMode:         cfg.Worker.QueueOverflowMode,
+		MaxPendingBacklog:         cfg.Worker.MaxPendingBacklog,
+		SrcIncludeCheckMode:       cfg.Worker.SrcIncludeCheckMode,
+		WorkspaceBases:            cfg.Worker.WorkspaceBases,
+		SlideCountWarning:         cfg.Worker.SlideCountWarning,
+		OutputSizeWarning:         cfg.Worker.OutputSizeWarningMB << 20,
 	}
 
 	workerPool := worker.NewWorkerPool(jobService, storageService, workerConfig)
 
+	// Initialize callback delivery
+	validationConfig := getValidationConfig(cfg)
+	validationConfig.KeyNamespaceLength = storageService.KeyNamespaceLength()
+	validationConfig.DirectoryRules = directoryRules
+	validationConfig.ContentPatterns = contentPatterns
+	validationConfig.Messages = validationMessages
+	callbackNotifier := jobs.NewCallbackNotifier(jobService, validationConfig.CallbackPolicy, &jobs.CallbackConfig{
+		MaxAttempts:    cfg.Callback.MaxAttempts,
+		Timeout:        cfg.Callback.Timeout,
+		SigningSecret:  cfg.Callback.SigningSecret,
+		SchemaVersion:  cfg.Callback.SchemaVersion,
+		RetryBaseDelay: cfg.Callback.RetryBaseDelay,
+		RetryMaxDelay:  cfg.Callback.RetryMaxDelay,
+	})
+	callbackQueue := jobs.NewCallbackQueue(jobService, callbackNotifier, &jobs.CallbackQueueConfig{
+		Workers:      cfg.Callback.Workers,
+		PollInterval: cfg.Callback.PollInterval,
+	})
+	workerPool.SetCallbackQueue(callbackQueue)
+
 	// Start cleanup service
 	cleanupService := jobs.NewCleanupService(jobService, cfg.CleanupInterval, 24*time.Hour)
 	ctx, cancel := context.WithCancel(context.Background())
@@ -117,6 +208,9 @@ func main() {
 
 	go cleanupService.Start(ctx)
 
+	// Start callback delivery, independent from the worker pool
+	callbackQueue.Start(ctx)
+
 	// Start worker pool
 	log.Printf("Starting worker pool...")
 	if err := workerPool.Start(ctx); err != nil {
@@ -124,15 +218,45 @@ func main() {
 	}
 
 	// Setup router with enhanced worker stats
-	router := api.SetupRouter(jobService, storageService, workerPool)
+	router := api.SetupRouterWithConfig(jobService, storageService, workerPool, &api.RouterConfig{
+		ValidationConfig:          validationConfig,
+		CallbackQueue:             callbackQueue,
+		MaxActiveJobsPerClient:    cfg.MaxActiveJobsPerClient,
+		PublicBaseURL:             cfg.PublicBaseURL,
+		StorageNamespacePerClient: cfg.StorageNamespacePerClient,
+		CancelOnDisconnectWindow:  cfg.CancelOnDisconnectWindow,
+		ThemePreviewRateLimit:     cfg.ThemePreviewRateLimit,
+		SLOTargets:                cfg.SLOTargets,
+		SLOWindow:                 cfg.SLOWindow,
+		ResultCachePolicy: &api.ResultCachePolicy{
+			Default: cfg.ResultCacheControl,
+			Rules:   cfg.ResultCacheControlRules,
+		},
+		UnknownJobSourcesNotFound: cfg.UnknownJobSourcesNotFound,
+		AdminToken:                cfg.AdminToken,
+		ClientAPIKeys:             cfg.ClientAPIKeys,
+		EffectiveConfig:           cfg.Effective(),
+	})
 
 	// Start server in goroutine
 	log.Printf("Starting ocf-worker on port %s", cfg.Port)
 	log.Printf("Database: connected")
 	log.Printf("Storage type: %s", cfg.Storage.Type)
 	log.Printf("Worker pool: %d workers", workerConfig.WorkerCount)
-	log.Printf("Workspace base: %s", workerConfig.WorkspaceBase)
+	if len(workerConfig.WorkspaceBases) > 0 {
+		log.Printf("Workspace bases: %s (round-robin across workers)", strings.Join(workerConfig.WorkspaceBases, ", "))
+	} else {
+		log.Printf("Workspace base: %s", workerConfig.WorkspaceBase)
+	}
 	log.Printf("Job timeout: %v", workerConfig.JobTimeout)
+	log.Printf("Upload limits: %d files, %d bytes per file, %d bytes total, %d concurrent uploads",
+		cfg.Upload.MaxFiles, cfg.Upload.MaxFileSize, cfg.Upload.MaxTotalSize, cfg.Upload.Concurrency)
+	if cfg.Callback.SigningSecret == "" {
+		log.Printf("WARNING: CALLBACK_SIGNING_SECRET not set, callbacks are sent unsigned")
+	}
+	if len(cfg.Callback.AllowedHosts) > 0 {
+		log.Printf("Callback allowed hosts: %v", cfg.Callback.AllowedHosts)
+	}
 
 	switch cfg.Storage.Type {
 	case "filesystem":
@@ -142,9 +266,22 @@ func main() {
 		log.Printf("Garage bucket: %s", cfg.Storage.Bucket)
 	}
 
+	log.Printf("HTTP timeouts: read header %v, read %v, write %v, idle %v",
+		cfg.Server.ReadHeaderTimeout, cfg.Server.ReadTimeout, cfg.Server.WriteTimeout, cfg.Server.IdleTimeout)
+	log.Printf("HTTP keep-alive: %v, TCP keep-alive: %v", cfg.Server.KeepAlive, cfg.Server.TCPKeepAlive)
+	switch {
+	case cfg.Server.TLSEnabled() && cfg.Server.HTTP2:
+		log.Printf("TLS enabled (%s), serving HTTP/2 and HTTP/1.1", cfg.Server.TLSCertFile)
+	case cfg.Server.TLSEnabled():
+		log.Printf("TLS enabled (%s), serving HTTP/1.1 only", cfg.Server.TLSCertFile)
+	default:
+		log.Printf("TLS disabled, serving HTTP/1.1 only (set TLS_CERT_FILE and TLS_KEY_FILE for HTTP/2)")
+	}
+
+	server := newHTTPServer(cfg, router)
 	serverErr := make(chan error, 1)
 	go func() {
-		serverErr <- router.Run(":" + cfg.Port)
+		serverErr <- serveHTTP(server, cfg.Server)
 	}()
 
 	// Wait for interrupt signal
@@ -163,6 +300,9 @@ func main() {
 			log.Printf("Error stopping worker pool: %v", err)
 		}
 
+		log.Println("Stopping callback queue...")
+		callbackQueue.Stop()
+
 		log.Println("Stopping cleanup service...")
 		cancel() // Stop cleanup service
 
@@ -170,6 +310,47 @@ func main() {
 	}
 }
 
+// newHTTPServer crée le serveur HTTP avec les timeouts configurés : router.Run n'en impose
+// aucun, une connexion lente garderait sinon ses ressources indéfiniment
+func newHTTPServer(cfg *config.Config, handler http.Handler) *http.Server {
+	server := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	server.SetKeepAlivesEnabled(cfg.Server.KeepAlive)
+
+	if cfg.Server.TLSEnabled() {
+		server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		if !cfg.Server.HTTP2 {
+			// Une map vide désactive la négociation de h2 par net/http
+			server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+		}
+	}
+	return server
+}
+
+// serveHTTP écoute avec les sondes TCP keep-alive configurées, en HTTPS si un certificat
+// est fourni : net/http y négocie alors HTTP/2 par ALPN
+func serveHTTP(server *http.Server, cfg *config.ServerConfig) error {
+	keepAlive := cfg.TCPKeepAlive
+	if keepAlive == 0 {
+		keepAlive = -1 // 0 vaut la période par défaut pour net.ListenConfig
+	}
+	listener, err := (&net.ListenConfig{KeepAlive: keepAlive}).Listen(context.Background(), "tcp", server.Addr)
+	if err != nil {
+		return err
+	}
+
+	if cfg.TLSEnabled() {
+		return server.ServeTLS(listener, cfg.TLSCertFile, cfg.TLSKeyFile)
+	}
+	return server.Serve(listener)
+}
+
 // getWorkerCount retourne le nombre de workers à partir de la configuration
 func getWorkerCount(cfg *config.Config) int {
 	if count := os.Getenv("WORKER_COUNT"); count != "" {
@@ -200,6 +381,27 @@ func getSlidevCommand(cfg *config.Config) string {
 	return "npx @slidev/cli" // Par défaut
 }
 
+// getValidationConfig construit la configuration de validation à partir de la configuration
+func getValidationConfig(cfg *config.Config) *validation.ValidationConfig {
+	validationConfig := validation.DefaultValidationConfig()
+	validationConfig.MaxFiles = cfg.Upload.MaxFiles
+	validationConfig.MaxFileSize = cfg.Upload.MaxFileSize
+	validationConfig.MaxTotalSize = cfg.Upload.MaxTotalSize
+	validationConfig.MaxPathDepth = cfg.Upload.MaxPathDepth
+	validationConfig.MaxArchiveExpansionRatio = cfg.Upload.MaxArchiveExpansionRatio
+	validationConfig.MaxBatchSize = cfg.MaxBatchSize
+	validationConfig.MaxMetadataSize = cfg.MaxMetadataSize
+	validationConfig.MaxMetadataDepth = cfg.MaxMetadataDepth
+	validationConfig.RejectEmptyFiles = cfg.Upload.RejectEmptyFiles
+	validationConfig.EmptyFileExtensions = make(map[string]bool, len(cfg.Upload.EmptyFileExtensions))
+	for _, ext := range cfg.Upload.EmptyFileExtensions {
+		validationConfig.EmptyFileExtensions[ext] = true
+	}
+	validationConfig.CallbackPolicy.AllowedHosts = cfg.Callback.AllowedHosts
+	validationConfig.CallbackPolicy.AllowPrivateNetworks = cfg.Callback.AllowPrivateNetworks
+	return validationConfig
+}
+
 // parseIntEnv parse une variable d'environnement en entier
 func parseIntEnv(value string) (int, error) {
 	return strconv.Atoi(value)
@@ -0,0 +1,108 @@
+// cmd/migrate-storage/main.go - Migration des objets (sources, résultats, logs) vers un autre backend
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/config"
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
+	pkgstorage "github.com/Open-Course-Factory/ocf-worker/pkg/storage"
+
+	"github.com/lpernett/godotenv"
+)
+
+// Copie tous les objets du backend configuré pour le worker (STORAGE_TYPE, STORAGE_PATH,
+// GARAGE_*) vers le backend cible (mêmes variables préfixées par TARGET_). Chaque copie est
+// vérifiée ; relancer la commande reprend une migration interrompue.
+//
+//	TARGET_STORAGE_TYPE=garage TARGET_GARAGE_ENDPOINT=http://garage:3900 ... migrate-storage
+func main() {
+	prefix := flag.String("prefix", "", "Only migrate objects under this prefix (e.g. results/)")
+	concurrency := flag.Int("concurrency", storage.DefaultMigrationConcurrency, "Number of concurrent copies")
+	overwrite := flag.Bool("overwrite", false, "Copy objects already present in the target")
+	dryRun := flag.Bool("dry-run", false, "Compare source and target without writing anything")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Info: .env file not found, using environment variables: %v", err)
+	}
+
+	sourceConfig := config.Load().Storage
+	targetConfig := config.LoadStorageConfig("TARGET_")
+	if os.Getenv("TARGET_STORAGE_TYPE") == "" {
+		log.Fatal("TARGET_STORAGE_TYPE is required (filesystem or garage)")
+	}
+	if targetConfig.Type == "filesystem" && targetConfig.BasePath == "" {
+		log.Fatal("TARGET_STORAGE_PATH is required for a filesystem target")
+	}
+	if sameBackend(sourceConfig, targetConfig) {
+		log.Fatal("Source and target storage are the same backend")
+	}
+
+	source, err := storage.NewStorage(sourceConfig)
+	if err != nil {
+		log.Fatal("Failed to initialize source storage:", err)
+	}
+	target, err := storage.NewStorage(targetConfig)
+	if err != nil {
+		log.Fatal("Failed to initialize target storage:", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("Migrating storage %s -> %s (prefix %q, concurrency %d, dry run %t)",
+		describeBackend(sourceConfig), describeBackend(targetConfig), *prefix, *concurrency, *dryRun)
+
+	report, err := storage.MigrateStorage(ctx, source, target, storage.MigrationOptions{
+		Prefix:      *prefix,
+		Concurrency: *concurrency,
+		Overwrite:   *overwrite,
+		DryRun:      *dryRun,
+		Progress: func(p storage.MigrationProgress) {
+			if p.Err != nil {
+				log.Printf("[%d/%d] %s %s: %v", p.Done, p.Total, p.Status, p.Path, p.Err)
+				return
+			}
+			log.Printf("[%d/%d] %s %s (%d bytes)", p.Done, p.Total, p.Status, p.Path, p.Size)
+		},
+	})
+	if report != nil {
+		log.Printf("Migration finished in %v: %d objects, %d copied (%d bytes), %d already present, %d failed",
+			report.Duration, report.Total, report.Copied, report.CopiedBytes, report.Skipped, len(report.Failed))
+		for _, failure := range report.Failed {
+			log.Printf("FAILED %s: %s", failure.Path, failure.Error)
+		}
+	}
+	if err != nil {
+		log.Fatalf("Migration stopped: %v (run the command again to resume)", err)
+	}
+	if len(report.Failed) > 0 {
+		log.Fatalf("%d objects were not migrated (run the command again to retry them)", len(report.Failed))
+	}
+}
+
+// sameBackend indique si deux configurations désignent le même stockage
+func sameBackend(a, b *pkgstorage.StorageConfig) bool {
+	if a.Type != b.Type {
+		return false
+	}
+	if a.Type == "filesystem" {
+		return a.BasePath == b.BasePath
+	}
+	return a.Endpoint == b.Endpoint && a.Bucket == b.Bucket
+}
+
+// describeBackend décrit un backend pour les logs, sans ses identifiants
+func describeBackend(cfg *pkgstorage.StorageConfig) string {
+	if cfg.Type == "filesystem" {
+		return fmt.Sprintf("filesystem:%s", cfg.BasePath)
+	}
+	return fmt.Sprintf("%s:%s/%s", cfg.Type, cfg.Endpoint, cfg.Bucket)
+}
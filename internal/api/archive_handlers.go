@@ -86,7 +86,7 @@ func (h *ArchiveHandlers) DownloadResultsArchive(c *gin.Context) {
 	c.Header("X-Archive-Files-Count", fmt.Sprintf("%d", len(resultFiles)))
 
 	// Créer l'archive en streaming
-	if err := h.createArchiveStream(c.Writer, courseID, resultFiles, format, compress); err != nil {
+	if err := h.createArchiveStream(c.Request.Context(), c.Writer, courseID, resultFiles, format, compress); err != nil {
 		// Headers déjà envoyés, on ne peut plus renvoyer d'erreur JSON
 		c.Header("X-Archive-Error", err.Error())
 		return
@@ -94,56 +94,74 @@ func (h *ArchiveHandlers) DownloadResultsArchive(c *gin.Context) {
 }
 
 // createArchiveStream crée une archive en streaming directement vers la réponse
-func (h *ArchiveHandlers) createArchiveStream(w io.Writer, courseID uuid.UUID, files []string, format models.ArchiveFormat, compress bool) error {
+func (h *ArchiveHandlers) createArchiveStream(ctx context.Context, w io.Writer, courseID uuid.UUID, files []string, format models.ArchiveFormat, compress bool) error {
 	switch format {
 	case models.FormatZIP:
-		return h.createZipStream(w, courseID, files, compress)
+		return h.createZipStream(ctx, w, courseID, files, compress)
 	case models.FormatTAR:
-		return h.createTarStream(w, courseID, files, compress)
+		return h.createTarStream(ctx, w, courseID, files, compress)
 	default:
 		return fmt.Errorf("unsupported format: %s", format)
 	}
 }
 
 // createZipStream crée une archive ZIP en streaming
-func (h *ArchiveHandlers) createZipStream(w io.Writer, courseID uuid.UUID, files []string, compress bool) error {
+func (h *ArchiveHandlers) createZipStream(ctx context.Context, w io.Writer, courseID uuid.UUID, files []string, compress bool) error {
 	zipWriter := zip.NewWriter(w)
 	defer zipWriter.Close()
 
-	for _, filename := range files {
-		// Télécharger le fichier depuis le storage
-		reader, err := h.storageService.DownloadResult(context.Background(), courseID, filename)
+	// Les fichiers suivants sont lus pendant la compression du fichier courant ;
+	// les entrées restent dans l'ordre de files
+	prefetcher := h.storageService.PrefetchResults(ctx, courseID, files)
+	defer prefetcher.Close()
+
+	for {
+		filename, reader, err := prefetcher.Next()
+		if err == io.EOF {
+			return nil
+		}
 		if err != nil {
-			return fmt.Errorf("failed to download file %s: %w", filename, err)
+			return err
 		}
 
-		// Créer l'entrée dans le ZIP
-		var zipFileWriter io.Writer
-		if compress {
-			zipFileWriter, err = zipWriter.Create(filename)
-		} else {
-			header := &zip.FileHeader{
-				Name:   filename,
-				Method: zip.Store, // Pas de compression
-			}
-			zipFileWriter, err = zipWriter.CreateHeader(header)
+		err = writeZipEntry(zipWriter, filename, reader, compress)
+		if closer, ok := reader.(io.Closer); ok {
+			closer.Close()
 		}
-
 		if err != nil {
-			return fmt.Errorf("failed to create zip entry for %s: %w", filename, err)
+			return err
 		}
+	}
+}
 
-		// Copier le contenu
-		if _, err := io.Copy(zipFileWriter, reader); err != nil {
-			return fmt.Errorf("failed to write file %s to archive: %w", filename, err)
+// writeZipEntry ajoute un fichier à une archive ZIP en streaming
+func writeZipEntry(zipWriter *zip.Writer, name string, reader io.Reader, compress bool) error {
+	var zipFileWriter io.Writer
+	var err error
+	if compress {
+		zipFileWriter, err = zipWriter.Create(name)
+	} else {
+		header := &zip.FileHeader{
+			Name:   name,
+			Method: zip.Store, // Pas de compression
 		}
+		zipFileWriter, err = zipWriter.CreateHeader(header)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to create zip entry for %s: %w", name, err)
+	}
+
+	// Copier le contenu
+	if _, err := io.Copy(zipFileWriter, reader); err != nil {
+		return fmt.Errorf("failed to write file %s to archive: %w", name, err)
 	}
 
 	return nil
 }
 
 // createTarStream crée une archive TAR en streaming
-func (h *ArchiveHandlers) createTarStream(w io.Writer, courseID uuid.UUID, files []string, compress bool) error {
+func (h *ArchiveHandlers) createTarStream(ctx context.Context, w io.Writer, courseID uuid.UUID, files []string, compress bool) error {
 	// TODO: Implémenter le support TAR si nécessaire
 	return fmt.Errorf("TAR format not yet implemented")
 }
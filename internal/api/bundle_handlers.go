@@ -0,0 +1,191 @@
+// internal/api/bundle_handlers.go - Export d'un job complet pour le support
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// BundleHandlers gère l'export des bundles de diagnostic des jobs
+type BundleHandlers struct {
+	jobService     jobs.JobService
+	storageService *storage.StorageService
+}
+
+// NewBundleHandlers crée un nouveau gestionnaire de bundles
+func NewBundleHandlers(jobService jobs.JobService, storageService *storage.StorageService) *BundleHandlers {
+	return &BundleHandlers{
+		jobService:     jobService,
+		storageService: storageService,
+	}
+}
+
+// DownloadJobBundle exporte un job sous forme d'archive ZIP
+// @Summary Exporter le bundle d'un job
+// @Description Produit une archive ZIP contenant les sources du job, ses logs, un fichier
+// @Description `bundle.json` de métadonnées et, avec `include_results=true`, les résultats du cours
+// @Description (ou ceux de sa destination `result_prefix`).
+// @Description
+// @Description Le bundle expose les sources : un job soumis par un client authentifié n'est
+// @Description exportable que par ce même client.
+// @Tags Jobs
+// @Produce application/zip
+// @Param id path string true "ID du job" Format(uuid)
+// @Param include_results query bool false "Inclure les résultats générés du cours" default(false)
+// @Success 200 {file} file "Bundle ZIP du job"
+// @Failure 400 {object} models.ErrorResponse "Paramètres invalides"
+// @Failure 403 {object} models.ErrorResponse "Job appartenant à un autre client"
+// @Failure 404 {object} models.ErrorResponse "Job non trouvé"
+// @Failure 500 {object} models.ErrorResponse "Erreur de stockage"
+// @Router /jobs/{id}/bundle [get]
+func (h *BundleHandlers) DownloadJobBundle(c *gin.Context) {
+	jobID := c.MustGet("validated_job_id").(uuid.UUID)
+	ctx := c.Request.Context()
+
+	includeResults, err := strconv.ParseBool(c.DefaultQuery("include_results", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "include_results must be a boolean"})
+		return
+	}
+
+	job, err := h.jobService.GetJob(ctx, jobID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+
+	if !canAccessJob(c, job) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "job belongs to another client"})
+		return
+	}
+
+	// Résultats lus à la destination du job
+	ctx = jobResultsContext(ctx, job)
+
+	metadata, generationLog, err := h.buildBundleMetadata(ctx, job, includeResults)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to prepare bundle: " + err.Error()})
+		return
+	}
+
+	timestamp := time.Now().Format("20060102-150405")
+	filename := fmt.Sprintf("job-%s-bundle-%s.zip", job.ID.String()[:8], timestamp)
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Header("X-Archive-Files-Count", fmt.Sprintf("%d", len(metadata.Sources)+len(metadata.Results)))
+
+	log.Printf("Exporting bundle for job %s (%d sources, results: %t)", job.ID, len(metadata.Sources), includeResults)
+
+	if err := h.writeBundle(ctx, c.Writer, job, metadata, generationLog); err != nil {
+		// Headers déjà envoyés, on ne peut plus renvoyer d'erreur JSON
+		log.Printf("Failed to stream bundle for job %s: %v", job.ID, err)
+		c.Header("X-Archive-Error", err.Error())
+	}
+}
+
+// buildBundleMetadata liste le contenu du bundle avant de commencer le streaming
+// et retourne le log de génération s'il existe
+func (h *BundleHandlers) buildBundleMetadata(ctx context.Context, job *models.GenerationJob, includeResults bool) (*models.JobBundleMetadata, string, error) {
+	sources, err := h.storageService.ListJobSources(ctx, job.ID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list sources: %w", err)
+	}
+
+	metadata := &models.JobBundleMetadata{
+		BundleVersion:   models.JobBundleVersion,
+		GeneratedAt:     time.Now().UTC(),
+		Job:             job.ToResponse(),
+		Sources:         sources,
+		IncludesResults: includeResults,
+	}
+
+	generationLog, err := h.storageService.GetJobLog(ctx, job.ID)
+	metadata.HasStorageLog = err == nil
+
+	if includeResults {
+		results, err := h.storageService.ListResults(ctx, job.CourseID)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to list results: %w", err)
+		}
+		metadata.Results = results
+
+		// Le manifeste du cours ne décrit pas une destination personnalisée
+		if manifest, err := h.storageService.GetResultManifest(ctx, job.CourseID); err == nil && job.ResultPrefix == "" {
+			metadata.ResultsJobID = &manifest.JobID
+		}
+	}
+
+	return metadata, generationLog, nil
+}
+
+// writeBundle écrit le bundle ZIP en streaming
+func (h *BundleHandlers) writeBundle(ctx context.Context, w io.Writer, job *models.GenerationJob, metadata *models.JobBundleMetadata, generationLog string) error {
+	zipWriter := zip.NewWriter(w)
+	defer zipWriter.Close()
+
+	metadataJSON, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode bundle metadata: %w", err)
+	}
+	if err := writeZipEntry(zipWriter, "bundle.json", bytes.NewReader(metadataJSON), true); err != nil {
+		return err
+	}
+
+	for _, source := range metadata.Sources {
+		reader, err := h.storageService.DownloadJobSource(ctx, job.ID, source)
+		if err != nil {
+			return fmt.Errorf("failed to download source %s: %w", source, err)
+		}
+		if err := writeStoredZipEntry(zipWriter, "sources/"+source, reader); err != nil {
+			return err
+		}
+	}
+
+	// Logs persistés en base (progression) et log complet du build
+	jobLog := strings.Join(job.Logs, "\n")
+	if err := writeZipEntry(zipWriter, "logs/job.log", strings.NewReader(jobLog), true); err != nil {
+		return err
+	}
+	if metadata.HasStorageLog {
+		if err := writeZipEntry(zipWriter, "logs/generation.log", strings.NewReader(generationLog), true); err != nil {
+			return err
+		}
+	}
+
+	for _, result := range metadata.Results {
+		reader, err := h.storageService.DownloadResult(ctx, job.CourseID, result)
+		if err != nil {
+			return fmt.Errorf("failed to download result %s: %w", result, err)
+		}
+		if err := writeStoredZipEntry(zipWriter, "results/"+result, reader); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeStoredZipEntry copie un fichier lu depuis le storage dans l'archive et ferme son lecteur
+func writeStoredZipEntry(zipWriter *zip.Writer, name string, reader io.Reader) error {
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	return writeZipEntry(zipWriter, name, reader, true)
+}
@@ -0,0 +1,154 @@
+// internal/api/bundle_handlers_test.go
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readZipEntries lit le contenu de chaque entrée d'une archive ZIP
+func readZipEntries(t *testing.T, body []byte) map[string]string {
+	reader, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+
+	entries := make(map[string]string)
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		entries[file.Name] = string(content)
+	}
+	return entries
+}
+
+func TestDownloadJobBundle(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService))
+	ctx := context.Background()
+
+	courseID := uuid.New()
+	job, err := jobService.CreateJob(ctx, &models.GenerationRequest{
+		JobID:      uuid.New(),
+		CourseID:   courseID,
+		SourcePath: "test/path",
+	})
+	require.NoError(t, err)
+
+	w := uploadSources(t, router, job.ID, map[string]string{
+		"slides.md":       "# Slides",
+		"assets/logo.svg": "<svg/>",
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.NoError(t, jobService.AddJobLog(ctx, job.ID, "Build started"))
+	require.NoError(t, storageService.SaveJobLog(ctx, job.ID, "ERROR: Slidev build failed\n"))
+	require.NoError(t, storageService.UploadResult(ctx, courseID, "index.html", strings.NewReader("<html></html>")))
+
+	getBundle := func(jobID uuid.UUID, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+jobID.String()+"/bundle"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("sources, logs and metadata", func(t *testing.T) {
+		w := getBundle(job.ID, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+
+		entries := readZipEntries(t, w.Body.Bytes())
+		assert.Equal(t, "# Slides", entries["sources/slides.md"])
+		assert.Equal(t, "<svg/>", entries["sources/assets/logo.svg"])
+		assert.Contains(t, entries["logs/job.log"], "Build started")
+		assert.Equal(t, "ERROR: Slidev build failed\n", entries["logs/generation.log"])
+		assert.NotContains(t, entries, "results/index.html")
+
+		var metadata models.JobBundleMetadata
+		require.NoError(t, json.Unmarshal([]byte(entries["bundle.json"]), &metadata))
+		assert.Equal(t, models.JobBundleVersion, metadata.BundleVersion)
+		assert.Equal(t, job.ID, metadata.Job.ID)
+		assert.ElementsMatch(t, []string{"slides.md", "assets/logo.svg"}, metadata.Sources)
+		assert.True(t, metadata.HasStorageLog)
+		assert.False(t, metadata.IncludesResults)
+	})
+
+	t.Run("with results", func(t *testing.T) {
+		w := getBundle(job.ID, "?include_results=true")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		entries := readZipEntries(t, w.Body.Bytes())
+		assert.Equal(t, "<html></html>", entries["results/index.html"])
+	})
+
+	t.Run("invalid include_results", func(t *testing.T) {
+		w := getBundle(job.ID, "?include_results=maybe")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unknown job", func(t *testing.T) {
+		w := getBundle(uuid.New(), "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("job of another authenticated client", func(t *testing.T) {
+		owned, err := jobService.CreateJob(ctx, &models.GenerationRequest{
+			JobID:      uuid.New(),
+			CourseID:   uuid.New(),
+			SourcePath: "test/path",
+			ClientID:   "client:alice",
+		})
+		require.NoError(t, err)
+
+		w := getBundle(owned.ID, "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestDownloadJobBundleClientAccess(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouterWithConfig(jobService, storageService, createMockWorkerPool(jobService, storageService),
+		&RouterConfig{ClientAPIKeys: map[string]string{"key-alice": "alice", "key-bob": "bob"}})
+
+	// Job soumis par un client authentifié
+	jobID := uuid.New()
+	body, err := json.Marshal(models.GenerationRequest{JobID: jobID, CourseID: uuid.New(), SourcePath: "test/path"})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/generate", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", "key-alice")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	job, err := jobService.GetJob(context.Background(), jobID)
+	require.NoError(t, err)
+	assert.Equal(t, "client:alice", job.ClientID)
+
+	getBundle := func(apiKey string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+jobID.String()+"/bundle", nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, getBundle("key-alice"))
+	assert.Equal(t, http.StatusForbidden, getBundle("key-bob"))
+	assert.Equal(t, http.StatusForbidden, getBundle(""))
+}
@@ -0,0 +1,104 @@
+// internal/api/callback_handlers.go - Gestion de la livraison des callbacks
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CallbackHandlers gère les endpoints liés aux callbacks des jobs
+type CallbackHandlers struct {
+	jobService jobs.JobService
+	queue      *jobs.CallbackQueue
+}
+
+// NewCallbackHandlers crée un nouveau gestionnaire de callbacks (queue nil = relance manuelle
+// indisponible)
+func NewCallbackHandlers(jobService jobs.JobService, queue *jobs.CallbackQueue) *CallbackHandlers {
+	return &CallbackHandlers{
+		jobService: jobService,
+		queue:      queue,
+	}
+}
+
+// RedeliverCallback relance manuellement la livraison du callback d'un job
+// @Summary Relancer le callback d'un job
+// @Description Met en file la livraison du callback de fin de job vers `callback_url` sans
+// @Description relancer le build.
+// @Description
+// @Description Utile quand le destinataire était temporairement indisponible. La livraison est
+// @Description assurée par les livreurs de callbacks, comme une relance automatique : son état est
+// @Description exposé sous `callback` par `GET /jobs/{id}`. Le nombre de tentatives est plafonné par
+// @Description `CALLBACK_MAX_ATTEMPTS` ; un échec replanifie la relance automatique suivante.
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Param id path string true "ID du job" Format(uuid)
+// @Success 202 {object} models.JobResponse "Livraison du callback mise en file"
+// @Failure 400 {object} models.ErrorResponse "Job sans callback configuré"
+// @Failure 403 {object} models.ErrorResponse "Job appartenant à un autre client"
+// @Failure 404 {object} models.ErrorResponse "Job non trouvé"
+// @Failure 409 {object} models.ErrorResponse "Job non terminé ou nombre max de tentatives atteint"
+// @Failure 500 {object} models.ErrorResponse "Erreur interne du serveur"
+// @Failure 503 {object} models.ErrorResponse "File des callbacks indisponible"
+// @Router /jobs/{id}/callback/redeliver [post]
+func (h *CallbackHandlers) RedeliverCallback(c *gin.Context) {
+	jobID := c.MustGet("validated_job_id").(uuid.UUID)
+
+	if h.queue == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "callback delivery queue is not configured",
+			"code":  "CALLBACK_QUEUE_UNAVAILABLE",
+		})
+		return
+	}
+
+	job, err := h.jobService.GetJob(c.Request.Context(), jobID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+
+	if !canAccessJob(c, job) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "job belongs to another client"})
+		return
+	}
+
+	if !job.IsTerminal() {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "job is not finished yet",
+			"status": job.Status,
+		})
+		return
+	}
+
+	err = h.queue.Redeliver(c.Request.Context(), job)
+	switch {
+	case errors.Is(err, jobs.ErrNoCallbackURL):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, jobs.ErrCallbackMaxAttempts):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    err.Error(),
+			"callback": job.ToResponse().Callback,
+		})
+		return
+	case err != nil:
+		log.Printf("Failed to queue callback redelivery for job %s: %v", job.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("Queued callback redelivery for job %s (previous attempts: %d)", job.ID, job.CallbackAttempts)
+
+	if queued, err := h.jobService.GetJob(c.Request.Context(), jobID); err == nil {
+		job = queued
+	}
+	c.JSON(http.StatusAccepted, job.ToResponse())
+}
@@ -0,0 +1,137 @@
+// internal/api/callback_handlers_test.go
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage/filesystem"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedeliverCallback(t *testing.T) {
+	storageBackend, err := filesystem.NewFilesystemStorage(t.TempDir())
+	require.NoError(t, err)
+	storageService := storage.NewStorageService(storageBackend)
+	repo := &mockJobRepository{}
+	jobService := jobs.NewJobServiceImpl(repo)
+
+	// La file n'est pas démarrée : le test vérifie la mise en file, la livraison est
+	// couverte par les tests de la file
+	notifier := jobs.NewCallbackNotifier(jobService, nil, &jobs.CallbackConfig{MaxAttempts: 2})
+	queue := jobs.NewCallbackQueue(jobService, notifier, nil)
+	router := SetupRouterWithConfig(jobService, storageService, createMockWorkerPool(jobService, storageService),
+		&RouterConfig{CallbackQueue: queue})
+
+	createJob := func(callbackURL string) uuid.UUID {
+		jobID := uuid.New()
+		reqBody := models.GenerationRequest{
+			JobID:       jobID,
+			CourseID:    uuid.New(),
+			SourcePath:  "test/path",
+			CallbackURL: callbackURL,
+		}
+		jsonBody, _ := json.Marshal(reqBody)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/generate", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		return jobID
+	}
+
+	redeliver := func(jobID uuid.UUID) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/jobs/"+jobID.String()+"/callback/redeliver", nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("job not finished", func(t *testing.T) {
+		jobID := createJob("https://hooks.example.com/webhook")
+		w := redeliver(jobID)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("job without callback", func(t *testing.T) {
+		jobID := createJob("")
+		require.NoError(t, jobService.UpdateJobStatus(context.Background(), jobID, models.StatusCompleted, 100, ""))
+
+		w := redeliver(jobID)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("redelivery is queued", func(t *testing.T) {
+		jobID := createJob("https://hooks.example.com/webhook")
+		require.NoError(t, jobService.UpdateJobStatus(context.Background(), jobID, models.StatusCompleted, 100, ""))
+
+		// Un premier échec sans relance automatique restante
+		_, err := jobService.RecordCallbackAttempt(context.Background(), jobID, errors.New("status 503"), nil)
+		require.NoError(t, err)
+
+		// La relance est confiée aux livreurs, pas envoyée par la requête
+		w := redeliver(jobID)
+		require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+		var response models.JobResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.NotNil(t, response.Callback)
+		assert.NotNil(t, response.Callback.NextAttemptAt)
+		assert.Equal(t, 1, response.Callback.Attempts)
+		assert.Equal(t, 1, queue.Depth())
+
+		// Nombre max de tentatives atteint
+		_, err = jobService.RecordCallbackAttempt(context.Background(), jobID, errors.New("status 503"), nil)
+		require.NoError(t, err)
+		w = redeliver(jobID)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("job of another authenticated client", func(t *testing.T) {
+		job, err := jobService.CreateJob(context.Background(), &models.GenerationRequest{
+			JobID:       uuid.New(),
+			CourseID:    uuid.New(),
+			SourcePath:  "test/path",
+			CallbackURL: "https://hooks.example.com/webhook",
+			ClientID:    "client:alice",
+		})
+		require.NoError(t, err)
+		require.NoError(t, jobService.UpdateJobStatus(context.Background(), job.ID, models.StatusCompleted, 100, ""))
+
+		depth := queue.Depth()
+		w := redeliver(job.ID)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, depth, queue.Depth(), "redelivery must not be queued")
+	})
+
+	t.Run("queue failure", func(t *testing.T) {
+		jobID := createJob("https://hooks.example.com/webhook")
+		require.NoError(t, jobService.UpdateJobStatus(context.Background(), jobID, models.StatusCompleted, 100, ""))
+
+		repo.scheduleCallbackErr = errors.New("database unavailable")
+		defer func() { repo.scheduleCallbackErr = nil }()
+
+		w := redeliver(jobID)
+		assert.Equal(t, http.StatusInternalServerError, w.Code, w.Body.String())
+	})
+
+	t.Run("queue not configured", func(t *testing.T) {
+		router := SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/jobs/"+uuid.New().String()+"/callback/redeliver", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), "CALLBACK_QUEUE_UNAVAILABLE")
+	})
+}
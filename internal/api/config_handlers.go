@@ -0,0 +1,40 @@
+// internal/api/config_handlers.go - Configuration effective du déploiement
+package api
+
+import (
+	"net/http"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigHandlers expose la configuration effective, secrets masqués
+type ConfigHandlers struct {
+	effective *config.EffectiveConfig
+}
+
+// NewConfigHandlers crée un gestionnaire de la configuration effective
+func NewConfigHandlers(effective *config.EffectiveConfig) *ConfigHandlers {
+	return &ConfigHandlers{effective: effective}
+}
+
+// GetConfig retourne la configuration effective du déploiement
+// @Summary Configuration effective
+// @Description Retourne la configuration résolue au démarrage (variables d'environnement,
+// @Description valeurs par défaut et détection de Docker) : storage, workers, timeouts,
+// @Description workspaces, limites d'upload et intervalles de nettoyage. Les clés et secrets
+// @Description configurés valent `[REDACTED]` et les identifiants de la base de données sont
+// @Description masqués. Réservé aux porteurs du jeton `ADMIN_TOKEN` (`Authorization: Bearer`) ;
+// @Description sans jeton configuré, l'endpoint est désactivé.
+// @Tags Monitoring
+// @Produce json
+// @Param Authorization header string true "Jeton d'administration" example(Bearer my-admin-token)
+// @Success 200 {object} config.EffectiveConfig "Configuration effective"
+// @Failure 401 {object} models.ErrorResponse "Jeton d'administration absent ou invalide"
+// @Failure 403 {object} models.ErrorResponse "Endpoints d'administration désactivés"
+// @Router /config [get]
+func (h *ConfigHandlers) GetConfig(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, h.effective)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetConfig(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	effective := &config.EffectiveConfig{
+		Storage: config.EffectiveStorageConfig{Type: "garage", SecretKey: config.RedactedValue},
+		Worker:  config.EffectiveWorkerConfig{WorkerCount: 4, WorkspaceBase: "/app/workspaces"},
+	}
+
+	request := func(router http.Handler, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/config", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	router := SetupRouterWithConfig(jobService, storageService, createMockWorkerPool(jobService, storageService), &RouterConfig{
+		AdminToken:      "admin-token",
+		EffectiveConfig: effective,
+	})
+
+	t.Run("Admin token", func(t *testing.T) {
+		w := request(router, "Bearer admin-token")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+		var response config.EffectiveConfig
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, *effective, response)
+	})
+
+	t.Run("Missing or invalid token", func(t *testing.T) {
+		for _, authorization := range []string{"", "admin-token", "Bearer wrong-token", "Basic YWRtaW4="} {
+			w := request(router, authorization)
+			assert.Equal(t, http.StatusUnauthorized, w.Code, "authorization %q", authorization)
+			assert.Contains(t, w.Body.String(), "ADMIN_UNAUTHORIZED")
+			assert.NotContains(t, w.Body.String(), "/app/workspaces")
+		}
+	})
+
+	t.Run("No admin token configured", func(t *testing.T) {
+		router := SetupRouterWithConfig(jobService, storageService, createMockWorkerPool(jobService, storageService), &RouterConfig{
+			EffectiveConfig: effective,
+		})
+		for _, authorization := range []string{"", "Bearer "} {
+			w := request(router, authorization)
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.Contains(t, w.Body.String(), "ADMIN_DISABLED")
+		}
+	})
+
+	t.Run("Route absent without configuration", func(t *testing.T) {
+		w := request(SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService)), "Bearer admin-token")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
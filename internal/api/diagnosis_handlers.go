@@ -0,0 +1,76 @@
+// internal/api/diagnosis_handlers.go - Diagnostic des jobs en échec
+package api
+
+import (
+	"net/http"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// DiagnosisHandlers gère le diagnostic des jobs en échec
+type DiagnosisHandlers struct {
+	jobService     jobs.JobService
+	storageService *storage.StorageService
+}
+
+// NewDiagnosisHandlers crée un nouveau gestionnaire de diagnostics
+func NewDiagnosisHandlers(jobService jobs.JobService, storageService *storage.StorageService) *DiagnosisHandlers {
+	return &DiagnosisHandlers{
+		jobService:     jobService,
+		storageService: storageService,
+	}
+}
+
+// GetJobDiagnosis explique l'échec d'un job
+// @Summary Diagnostiquer l'échec d'un job
+// @Description Analyse l'erreur et les logs d'un job en échec et retourne la cause reconnue
+// @Description (fichier de slides manquant, installation du thème, erreur npm, timeout, mémoire,
+// @Description validation de la sortie, storage) avec des corrections suggérées. `findings`
+// @Description liste toutes les causes reconnues, la première étant la cause principale ;
+// @Description `category` vaut `unknown` si aucune n'est reconnue.
+// @Tags Jobs
+// @Produce json
+// @Param id path string true "ID du job" Format(uuid)
+// @Success 200 {object} models.JobDiagnosis "Diagnostic du job"
+// @Failure 400 {object} models.ErrorResponse "ID de job invalide"
+// @Failure 403 {object} models.ErrorResponse "Job appartenant à un autre client"
+// @Failure 404 {object} models.ErrorResponse "Job non trouvé"
+// @Failure 409 {object} models.ErrorResponse "Job pas en échec (JOB_NOT_FAILED)"
+// @Router /jobs/{id}/diagnosis [get]
+func (h *DiagnosisHandlers) GetJobDiagnosis(c *gin.Context) {
+	jobID := c.MustGet("validated_job_id").(uuid.UUID)
+	ctx := c.Request.Context()
+
+	job, err := h.jobService.GetJob(ctx, jobID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+
+	if !canAccessJob(c, job) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "job belongs to another client"})
+		return
+	}
+
+	if job.Status != models.StatusFailed && job.Status != models.StatusTimeout {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "diagnosis is only available for failed jobs",
+			"code":   "JOB_NOT_FAILED",
+			"status": job.Status,
+		})
+		return
+	}
+
+	// Un job en échec avant le build n'a pas de log de génération
+	generationLog, err := h.storageService.GetJobLog(ctx, jobID)
+	if err != nil {
+		generationLog = ""
+	}
+
+	c.JSON(http.StatusOK, jobs.DiagnoseJob(job, generationLog))
+}
@@ -0,0 +1,77 @@
+// internal/api/diagnosis_handlers_test.go
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetJobDiagnosis(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService))
+	ctx := context.Background()
+
+	createJob := func(t *testing.T) *models.GenerationJob {
+		job, err := jobService.CreateJob(ctx, &models.GenerationRequest{
+			JobID:      uuid.New(),
+			CourseID:   uuid.New(),
+			SourcePath: "test/path",
+		})
+		require.NoError(t, err)
+		return job
+	}
+
+	getDiagnosis := func(jobID uuid.UUID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+jobID.String()+"/diagnosis", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("failed job with generation log", func(t *testing.T) {
+		job := createJob(t)
+		require.NoError(t, jobService.UpdateJobStatus(ctx, job.ID, models.StatusFailed, 50,
+			"slidev build failed: slidev build failed with exit code 1: exit status 1"))
+		require.NoError(t, storageService.SaveJobLog(ctx, job.ID, "[STDERR] npm ERR! code ERESOLVE\n"))
+
+		w := getDiagnosis(job.ID)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var diagnosis models.JobDiagnosis
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &diagnosis))
+		assert.Equal(t, job.ID.String(), diagnosis.JobID)
+		assert.Equal(t, models.DiagnosisNpmError, diagnosis.Category)
+		assert.NotEmpty(t, diagnosis.Suggestions)
+		require.Len(t, diagnosis.Findings, 2)
+		assert.Equal(t, models.DiagnosisSourceGenerationLog, diagnosis.Findings[0].Source)
+	})
+
+	t.Run("failed job without generation log", func(t *testing.T) {
+		job := createJob(t)
+		require.NoError(t, jobService.UpdateJobStatus(ctx, job.ID, models.StatusFailed, 20,
+			"failed to download sources: no slide file found (checked: [slides.md])"))
+
+		w := getDiagnosis(job.ID)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), models.DiagnosisMissingSlideFile)
+	})
+
+	t.Run("job not failed", func(t *testing.T) {
+		w := getDiagnosis(createJob(t).ID)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "JOB_NOT_FAILED")
+	})
+
+	t.Run("unknown job", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, getDiagnosis(uuid.New()).Code)
+	})
+}
@@ -1,25 +1,43 @@
 package api
 
 import (
+	"context"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"time"
 
 	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
 	"github.com/Open-Course-Factory/ocf-worker/internal/validation"
+	"github.com/Open-Course-Factory/ocf-worker/internal/worker"
 	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+// DefaultCancelOnDisconnectWindow est l'attente maximale du démarrage d'un job créé avec
+// cancel_on_disconnect quand aucune durée n'est configurée
+const DefaultCancelOnDisconnectWindow = 30 * time.Second
+
+// disconnectCancelReason est l'erreur enregistrée sur un job annulé par la déconnexion de son créateur
+const disconnectCancelReason = "cancelled: client disconnected before the job started"
+
 type Handlers struct {
-	jobService jobs.JobService
+	jobService       jobs.JobService
+	workerPool       *worker.WorkerPool
+	disconnectWindow time.Duration // Attente max du démarrage des jobs cancel_on_disconnect
 }
 
-func NewHandlers(jobService jobs.JobService) *Handlers {
+func NewHandlers(jobService jobs.JobService, workerPool *worker.WorkerPool, disconnectWindow time.Duration) *Handlers {
+	if disconnectWindow <= 0 {
+		disconnectWindow = DefaultCancelOnDisconnectWindow
+	}
+
 	return &Handlers{
-		jobService: jobService,
+		jobService:       jobService,
+		workerPool:       workerPool,
+		disconnectWindow: disconnectWindow,
 	}
 }
 
@@ -48,6 +66,10 @@ func (h *Handlers) Health(c *gin.Context) {
 // @Description
 // @Description Le job sera traité de manière asynchrone par le pool de workers.
 // @Description Utilisez l'endpoint GET /jobs/{id} pour suivre le progress.
+// @Description
+// @Description Avec `cancel_on_disconnect`, la réponse n'est envoyée qu'au démarrage du job par un
+// @Description worker ou après CANCEL_ON_DISCONNECT_WINDOW (30s par défaut). Si le client se déconnecte
+// @Description avant, le job encore pending est annulé (statut `failed`).
 // @Tags Jobs
 // @Accept json
 // @Produce json
@@ -55,15 +77,23 @@ func (h *Handlers) Health(c *gin.Context) {
 // @Success 201 {object} models.JobResponse "Job créé avec succès"
 // @Failure 400 {object} models.ErrorResponse "Erreur de validation"
 // @Failure 409 {object} models.ErrorResponse "Job avec cet ID existe déjà"
+// @Failure 429 {object} models.ErrorResponse "Nombre max de jobs actifs atteint pour ce client"
 // @Failure 500 {object} models.ErrorResponse "Erreur interne du serveur"
+// @Failure 503 {object} models.ErrorResponse "File des jobs pleine (QUEUE_FULL) ou backlog plein (QUEUE_BACKLOG_FULL)"
+// @Header 201,429 {integer} X-Client-Jobs-Limit "Nombre max de jobs actifs par client"
+// @Header 201,429 {integer} X-Client-Jobs-Active "Jobs actifs (pending + processing) du client"
 // @Router /generate [post]
 func (h *Handlers) CreateJob(c *gin.Context) {
 	// Récupérer la requête déjà validée
 	req := c.MustGet("validated_request").(models.GenerationRequest)
+	req.ClientID = c.GetString(ClientIDContextKey)
 
 	log.Printf("Creating job with ID: %s, Course ID: %s", req.JobID, req.CourseID)
 
 	job, err := h.jobService.CreateJob(c.Request.Context(), &req)
+	// Le job est en base (ou n'y sera pas) : les soumissions suivantes le comptent sans
+	// attendre le démarrage du job
+	releaseSubmission(c)
 	if err != nil {
 		log.Printf("Failed to create job: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -71,9 +101,232 @@ func (h *Handlers) CreateJob(c *gin.Context) {
 	}
 
 	log.Printf("Job created successfully: %s", job.ID)
+
+	if req.CancelOnDisconnect {
+		if job = h.awaitJobStart(c, job); job == nil {
+			return
+		}
+	}
+
 	c.JSON(http.StatusCreated, job.ToResponse())
 }
 
+// awaitJobStart garde la requête de création ouverte jusqu'à la réservation du job par un
+// worker ou la fin de la fenêtre d'attente, et retourne son état courant. Si le client se
+// déconnecte avant, le job encore pending est annulé et nil est retourné.
+// La réservation par une autre instance n'est pas signalée : la fenêtre s'écoule alors.
+func (h *Handlers) awaitJobStart(c *gin.Context, job *models.GenerationJob) *models.GenerationJob {
+	ctx := c.Request.Context()
+
+	var claimed <-chan struct{}
+	if h.workerPool != nil {
+		watch, stop := h.workerPool.WatchJobClaim(job.ID)
+		defer stop()
+		claimed = watch
+	}
+
+	// Le job a pu être réservé avant le début de la surveillance
+	if current, err := h.jobService.GetJob(ctx, job.ID); err == nil {
+		if current.Status != models.StatusPending {
+			return current
+		}
+		job = current
+	}
+
+	window := time.NewTimer(h.disconnectWindow)
+	defer window.Stop()
+
+	select {
+	case <-ctx.Done():
+		// Le contexte de la requête est terminé : l'annulation en utilise un détaché
+		cancelled, err := h.jobService.CancelPendingJob(context.WithoutCancel(ctx), job.ID, disconnectCancelReason)
+		switch {
+		case err != nil:
+			log.Printf("Failed to cancel job %s after client disconnect: %v", job.ID, err)
+		case cancelled:
+			log.Printf("Job %s cancelled: client disconnected before it started", job.ID)
+		default:
+			log.Printf("Client disconnected but job %s already started, keeping it", job.ID)
+		}
+		return nil
+	case <-claimed:
+	case <-window.C:
+	}
+
+	if current, err := h.jobService.GetJob(ctx, job.ID); err == nil {
+		return current
+	}
+	return job
+}
+
+// EstimateBuild estime la durée et la taille de sortie d'un build avant sa soumission
+// @Summary Estimer un build
+// @Description Estime la durée du build et la taille des résultats à partir des builds terminés
+// @Description comparables : même thème et taille de sources proche, puis critères élargis.
+// @Description
+// @Description Les sources peuvent être uploadées (multipart, champ `files`, rien n'est stocké) ou
+// @Description décrites en JSON (`source_file_count`, `source_size_bytes`, `theme`). Le thème est lu
+// @Description dans l'en-tête du fichier de slides uploadé.
+// @Description
+// @Description `confidence` vaut `high`, `medium`, `low` ou `none` (aucun historique exploitable,
+// @Description l'estimation est alors vide) et `sample_size` indique le nombre de builds comparés.
+// @Tags Jobs
+// @Accept json
+// @Accept multipart/form-data
+// @Produce json
+// @Param request body models.EstimateRequest false "Caractéristiques des sources (JSON)"
+// @Param files formData file false "Fichiers sources à analyser (multipart)"
+// @Param entry_file formData string false "Fichier de slides dont le thème est lu (défaut: slides.md, index.md, README.md)"
+// @Success 200 {object} models.BuildEstimate "Estimation du build"
+// @Failure 400 {object} models.ErrorResponse "Erreur de validation"
+// @Failure 500 {object} models.ErrorResponse "Erreur interne du serveur"
+// @Router /generate/estimate [post]
+func (h *Handlers) EstimateBuild(c *gin.Context) {
+	var req models.EstimateRequest
+	if files, exists := c.Get("validated_files"); exists {
+		req = estimateRequestFromUploads(files.([]*multipart.FileHeader), c.PostForm("entry_file"))
+	} else {
+		req = c.MustGet("validated_estimate_request").(models.EstimateRequest)
+	}
+
+	estimate, err := h.jobService.EstimateBuild(c.Request.Context(), &req)
+	if err != nil {
+		log.Printf("Failed to estimate build: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, estimate)
+}
+
+// estimateRequestFromUploads décrit des sources uploadées : nombre, taille totale et
+// thème du fichier de slides
+func estimateRequestFromUploads(files []*multipart.FileHeader, entryFile string) models.EstimateRequest {
+	req := models.EstimateRequest{SourceFileCount: len(files)}
+	byPath := make(map[string]*multipart.FileHeader, len(files))
+	for _, file := range files {
+		req.SourceSizeBytes += file.Size
+		byPath[file.Filename] = file
+	}
+
+	candidates := worker.DefaultSlideFiles
+	if entryFile != "" {
+		candidates = []string{entryFile}
+	}
+
+	for _, candidate := range candidates {
+		file, exists := byPath[candidate]
+		if !exists {
+			continue
+		}
+		if reader, err := file.Open(); err == nil {
+			req.Theme = jobs.DetectTheme(reader)
+			reader.Close()
+		}
+		break
+	}
+
+	return req
+}
+
+// CreateJobBatch crée plusieurs jobs de génération en une requête
+// @Summary Créer des jobs en lot
+// @Description Soumet plusieurs jobs de génération en une seule requête (max `MAX_BATCH_SIZE`).
+// @Description
+// @Description Chaque job est validé comme une requête `/generate` unique ; les jobs invalides sont
+// @Description signalés (`validation_error`) sans empêcher la création des autres. Le quota de jobs
+// @Description actifs par client s'applique au lot entier : si les jobs valides ne tiennent pas tous
+// @Description dans le quota, aucun n'est créé et la réponse 429 liste ceux qui le dépasseraient.
+// @Description
+// @Description Statut : 201 si tous les jobs sont créés, 207 si une partie seulement, 400 si aucun.
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Param request body models.BatchGenerationRequest true "Jobs à créer"
+// @Success 201 {object} models.BatchGenerationResponse "Tous les jobs ont été créés"
+// @Success 207 {object} models.BatchGenerationResponse "Une partie des jobs a été créée"
+// @Failure 400 {object} models.BatchGenerationResponse "Lot vide, trop grand ou aucun job valide"
+// @Failure 429 {object} models.ErrorResponse "Le lot dépasse le quota de jobs actifs du client"
+// @Failure 503 {object} models.ErrorResponse "Le lot ne tient pas dans la file ou le backlog des jobs"
+// @Header 201,207,429 {integer} X-Client-Jobs-Limit "Nombre max de jobs actifs par client"
+// @Header 201,207,429 {integer} X-Client-Jobs-Active "Jobs actifs (pending + processing) du client"
+// @Router /generate/batch [post]
+func (h *Handlers) CreateJobBatch(c *gin.Context) {
+	items := c.MustGet("validated_batch").([]validation.BatchItemValidation)
+	clientID := c.GetString(ClientIDContextKey)
+
+	response := models.BatchGenerationResponse{
+		Results: make([]models.BatchItemResult, 0, len(items)),
+	}
+
+	for _, item := range items {
+		result := models.BatchItemResult{
+			Index: item.Index,
+			JobID: item.Request.JobID,
+		}
+
+		if len(item.Errors) > 0 {
+			result.Status = models.BatchItemValidationError
+			result.Error = "Validation failed"
+			for _, err := range validation.LocalizedErrors(c, item.Errors) {
+				result.ValidationErrors = append(result.ValidationErrors, models.ValidationError{
+					Field:   err.Field,
+					Value:   err.Value,
+					Message: err.Message,
+					Code:    err.Code,
+				})
+			}
+			response.Failed++
+			response.Results = append(response.Results, result)
+			continue
+		}
+
+		req := item.Request
+		req.ClientID = clientID
+
+		job, err := h.jobService.CreateJob(c.Request.Context(), &req)
+		if err != nil {
+			log.Printf("Failed to create batch job %d (%s): %v", item.Index, req.JobID, err)
+			result.Status = models.BatchItemError
+			result.Error = err.Error()
+			response.Failed++
+		} else {
+			result.Status = models.BatchItemCreated
+			result.Job = job.ToResponse()
+			response.Created++
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	log.Printf("Batch submission: %d created, %d failed", response.Created, response.Failed)
+
+	status := http.StatusCreated
+	switch {
+	case response.Created == 0:
+		status = http.StatusBadRequest
+	case response.Failed > 0:
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, response)
+}
+
+// batchRequestedJobIDs retourne les IDs des jobs valides d'une soumission groupée,
+// et false si la requête n'est pas une soumission groupée
+func batchRequestedJobIDs(c *gin.Context) ([]uuid.UUID, bool) {
+	value, exists := c.Get("validated_batch")
+	if !exists {
+		return nil, false
+	}
+
+	jobIDs := []uuid.UUID{}
+	for _, item := range value.([]validation.BatchItemValidation) {
+		if len(item.Errors) == 0 {
+			jobIDs = append(jobIDs, item.Request.JobID)
+		}
+	}
+	return jobIDs, true
+}
+
 // GetJobStatus récupère le statut d'un job
 // @Summary Récupérer le statut d'un job
 // @Description Récupère les détails et le statut actuel d'un job de génération
@@ -90,6 +343,7 @@ func (h *Handlers) CreateJob(c *gin.Context) {
 // @Param id path string true "ID du job (UUID)" Format(uuid)
 // @Success 200 {object} models.JobResponse "Détails du job"
 // @Failure 400 {object} models.ErrorResponse "ID du job invalide"
+// @Failure 403 {object} models.ErrorResponse "Job appartenant à un autre client"
 // @Failure 404 {object} models.ErrorResponse "Job non trouvé"
 // @Failure 500 {object} models.ErrorResponse "Erreur interne du serveur"
 // @Router /jobs/{id} [get]
@@ -106,6 +360,11 @@ func (h *Handlers) GetJobStatus(c *gin.Context) {
 		return
 	}
 
+	if !canAccessJob(c, job) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "job belongs to another client"})
+		return
+	}
+
 	log.Printf("Job retrieved successfully: %s, status: %s", job.ID, job.Status)
 	c.JSON(http.StatusOK, job.ToResponse())
 }
@@ -116,11 +375,22 @@ func (h *Handlers) GetJobStatus(c *gin.Context) {
 // @Description
 // @Description Permet de filtrer par statut et par course_id pour retrouver facilement
 // @Description les jobs en cours ou terminés.
+// @Description
+// @Description Les metadata peuvent aussi être filtrées avec des paramètres `meta.<clé>=<valeur>`
+// @Description (ex: `?meta.author=alice`). Plusieurs filtres sont combinés (ET logique). Une valeur
+// @Description numérique ou booléenne trouve aussi le scalaire JSON (`?meta.version=2` trouve `{"version": 2}`).
+// @Description
+// @Description Les labels se filtrent avec `label=<clé>:<valeur>`, répétable (ex: `?label=env:prod&label=team:docs`).
+// @Description
+// @Description Un client authentifié ne voit que ses propres jobs ; les autres appelants ne voient
+// @Description que les jobs anonymes.
 // @Tags Jobs
 // @Accept json
 // @Produce json
 // @Param status query string false "Filtrer par statut" Enums(pending,processing,completed,failed,timeout)
 // @Param course_id query string false "Filtrer par ID de cours" Format(uuid)
+// @Param meta.key query string false "Filtrer par valeur de metadata (remplacer 'key' par le nom de la clé)"
+// @Param label query []string false "Filtrer par label clé:valeur (répétable)" collectionFormat(multi)
 // @Param limit query integer false "Nombre maximum de résultats" default(100) minimum(1) maximum(1000)
 // @Param offset query integer false "Décalage pour la pagination" default(0) minimum(0)
 // @Success 200 {object} models.JobListResponse "Liste des jobs"
@@ -132,6 +402,8 @@ func (h *Handlers) ListJobs(c *gin.Context) {
 	status := c.GetString("validated_status")
 	courseID, _ := c.Get("validated_course_id")
 	pagination := c.MustGet("validated_pagination").(validation.PaginationParams)
+	metadataFilters, _ := c.Get("validated_metadata_filters")
+	labels, _ := c.Get("validated_label_filters")
 
 	// Convertir courseID en bon type (peut être nil)
 	var courseIDPtr *uuid.UUID
@@ -139,10 +411,30 @@ func (h *Handlers) ListJobs(c *gin.Context) {
 		courseIDPtr = courseID.(*uuid.UUID)
 	}
 
-	log.Printf("Listing jobs with status: %s, course_id: %v, limit: %d, offset: %d",
-		status, courseIDPtr, pagination.Limit, pagination.Offset)
+	var metadata map[string]string
+	if metadataFilters != nil {
+		metadata = metadataFilters.(map[string]string)
+	}
+
+	var labelFilters map[string]string
+	if labels != nil {
+		labelFilters = labels.(map[string]string)
+	}
+
+	log.Printf("Listing jobs with status: %s, course_id: %v, metadata: %v, labels: %v, limit: %d, offset: %d",
+		status, courseIDPtr, metadata, labelFilters, pagination.Limit, pagination.Offset)
+
+	filters := jobs.JobFilters{
+		Status:    status,
+		CourseID:  courseIDPtr,
+		Metadata:  metadata,
+		Limit:     pagination.Limit,
+		Offset:    pagination.Offset,
+		Labels:    labelFilters,
+		VisibleTo: ClientIdentity(c),
+	}
 
-	jobs, err := h.jobService.ListJobs(c.Request.Context(), status, courseIDPtr)
+	jobs, err := h.jobService.SearchJobs(c.Request.Context(), filters)
 	if err != nil {
 		log.Printf("Failed to list jobs: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
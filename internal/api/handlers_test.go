@@ -4,15 +4,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sort"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
 	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
 	"github.com/Open-Course-Factory/ocf-worker/internal/storage/filesystem"
+	"github.com/Open-Course-Factory/ocf-worker/internal/validation"
 	"github.com/Open-Course-Factory/ocf-worker/internal/worker"
 	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
 
@@ -67,7 +72,11 @@ func setupTestServices(t *testing.T) (jobs.JobService, *storage.StorageService)
 
 // Mock simple du JobRepository pour les tests
 type mockJobRepository struct {
-	jobs map[uuid.UUID]*models.GenerationJob
+	jobs     map[uuid.UUID]*models.GenerationJob
+	profiles map[uuid.UUID]*models.GenerationProfile
+
+	// scheduleCallbackErr fait échouer la mise en file des callbacks
+	scheduleCallbackErr error
 }
 
 func (r *mockJobRepository) Create(ctx context.Context, job *models.GenerationJob) error {
@@ -94,13 +103,64 @@ func (r *mockJobRepository) List(ctx context.Context, filters jobs.JobFilters) (
 	for _, job := range r.jobs {
 		if filters.Status == "" || string(job.Status) == filters.Status {
 			if filters.CourseID == nil || job.CourseID == *filters.CourseID {
-				result = append(result, job)
+				if matchesMetadata(job.Metadata, filters.Metadata) && matchesLabels(job.Labels, filters.Labels) &&
+					visibleTo(job, filters.VisibleTo) {
+					result = append(result, job)
+				}
 			}
 		}
 	}
+
+	// Pagination comme la requête SQL
+	if filters.Offset >= len(result) {
+		return nil, nil
+	}
+	result = result[filters.Offset:]
+	if filters.Limit > 0 && filters.Limit < len(result) {
+		result = result[:filters.Limit]
+	}
 	return result, nil
 }
 
+// matchesMetadata simule le containment JSONB de PostgreSQL, scalaires compris
+func matchesMetadata(metadata models.JSON, filters map[string]string) bool {
+	for key, value := range filters {
+		actual, exists := metadata[key]
+		if !exists {
+			return false
+		}
+		switch actual.(type) {
+		case float64, bool:
+			if encoded, _ := json.Marshal(actual); string(encoded) != value {
+				return false
+			}
+		default:
+			if actual != value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// visibleTo simule le filtre de visibilité par client de la requête SQL
+func visibleTo(job *models.GenerationJob, identity string) bool {
+	if strings.HasPrefix(identity, "client:") {
+		return job.ClientID == identity
+	}
+	return identity == "" || !strings.HasPrefix(job.ClientID, "client:")
+}
+
+// matchesLabels simule le containment JSONB des labels
+func matchesLabels(labels models.StringMap, filters map[string]string) bool {
+	for key, value := range filters {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
 func (r *mockJobRepository) Update(ctx context.Context, job *models.GenerationJob) error {
 	if r.jobs == nil {
 		r.jobs = make(map[uuid.UUID]*models.GenerationJob)
@@ -125,6 +185,206 @@ func (r *mockJobRepository) UpdateStatus(ctx context.Context, id uuid.UUID, stat
 	return nil
 }
 
+func (r *mockJobRepository) ClaimPending(ctx context.Context, id uuid.UUID) (bool, error) {
+	job, exists := r.jobs[id]
+	if !exists || job.Status != models.StatusPending {
+		return false, nil
+	}
+	job.Status = models.StatusProcessing
+	job.Error = ""
+	return true, nil
+}
+
+func (r *mockJobRepository) CancelPending(ctx context.Context, id uuid.UUID, reason string) (bool, error) {
+	job, exists := r.jobs[id]
+	if !exists || job.Status != models.StatusPending {
+		return false, nil
+	}
+	job.Status = models.StatusFailed
+	job.Error = reason
+	return true, nil
+}
+
+func (r *mockJobRepository) CountActiveByClient(ctx context.Context, clientID string) (int64, error) {
+	var count int64
+	for _, job := range r.jobs {
+		if job.ClientID == clientID && job.IsActive() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *mockJobRepository) LatestBuild(ctx context.Context, courseID uuid.UUID, clientID string) (*models.GenerationJob, error) {
+	var latest *models.GenerationJob
+	for _, job := range r.jobs {
+		if job.CourseID != courseID || job.Status != models.StatusCompleted || job.ResultPrefix != "" || job.CompletedAt == nil {
+			continue
+		}
+		if strings.HasPrefix(job.ClientID, "client:") && job.ClientID != clientID {
+			continue
+		}
+		if latest == nil || job.CompletedAt.After(*latest.CompletedAt) {
+			latest = job
+		}
+	}
+	return latest, nil
+}
+
+func (r *mockJobRepository) CountByStatus(ctx context.Context, status models.JobStatus) (int64, error) {
+	var count int64
+	for _, job := range r.jobs {
+		if job.Status == status {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *mockJobRepository) ListPending(ctx context.Context, limit int) ([]*models.GenerationJob, error) {
+	var pending []*models.GenerationJob
+	for _, job := range r.jobs {
+		if job.Status == models.StatusPending {
+			pending = append(pending, job)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].CreatedAt.Before(pending[j].CreatedAt) })
+	if len(pending) > limit {
+		pending = pending[:limit]
+	}
+	return pending, nil
+}
+
+func (r *mockJobRepository) AggregateBuildStats(ctx context.Context, filters jobs.BuildStatsFilters) (*jobs.BuildStatsAggregate, error) {
+	aggregate := &jobs.BuildStatsAggregate{}
+	for _, job := range r.jobs {
+		if job.Status != models.StatusCompleted || job.StartedAt == nil || job.CompletedAt == nil ||
+			(filters.Theme != "" && job.Theme != filters.Theme) ||
+			(filters.MinSourceSize > 0 && job.SourceSizeBytes < filters.MinSourceSize) ||
+			(filters.MaxSourceSize > 0 && job.SourceSizeBytes > filters.MaxSourceSize) {
+			continue
+		}
+		aggregate.Count++
+		aggregate.AvgDurationSeconds += job.CompletedAt.Sub(*job.StartedAt).Seconds()
+		aggregate.AvgSourceSizeBytes += float64(job.SourceSizeBytes)
+		aggregate.AvgResultSizeBytes += float64(job.ResultSizeBytes)
+	}
+
+	if aggregate.Count > 0 {
+		aggregate.AvgDurationSeconds /= float64(aggregate.Count)
+		aggregate.AvgSourceSizeBytes /= float64(aggregate.Count)
+		aggregate.AvgResultSizeBytes /= float64(aggregate.Count)
+	}
+	return aggregate, nil
+}
+
+func (r *mockJobRepository) AggregateLatency(ctx context.Context, filters jobs.LatencyFilters) (*jobs.LatencyAggregate, error) {
+	aggregate := &jobs.LatencyAggregate{WithinTargets: make([]int64, len(filters.Targets))}
+	var latencies []float64
+	for _, job := range r.jobs {
+		if job.CompletedAt == nil || job.CompletedAt.Before(filters.Since) ||
+			(filters.CourseID != nil && job.CourseID != *filters.CourseID) ||
+			(job.Status != models.StatusCompleted && job.Status != models.StatusFailed && job.Status != models.StatusTimeout) {
+			continue
+		}
+		aggregate.Finished++
+		if job.Status != models.StatusCompleted {
+			continue
+		}
+		aggregate.Completed++
+		latency := job.CompletedAt.Sub(job.CreatedAt).Seconds()
+		latencies = append(latencies, latency)
+		for i, target := range filters.Targets {
+			if latency <= target.Seconds() {
+				aggregate.WithinTargets[i]++
+			}
+		}
+	}
+
+	// Interpolation linéaire, comme percentile_cont
+	sort.Float64s(latencies)
+	percentile := func(p float64) float64 {
+		if len(latencies) == 0 {
+			return 0
+		}
+		position := p * float64(len(latencies)-1)
+		lower := int(position)
+		if lower+1 >= len(latencies) {
+			return latencies[lower]
+		}
+		return latencies[lower] + (position-float64(lower))*(latencies[lower+1]-latencies[lower])
+	}
+	aggregate.P50Seconds = percentile(0.5)
+	aggregate.P95Seconds = percentile(0.95)
+	aggregate.P99Seconds = percentile(0.99)
+	return aggregate, nil
+}
+
+func (r *mockJobRepository) ScheduleCallback(ctx context.Context, id uuid.UUID, at *time.Time) error {
+	job, exists := r.jobs[id]
+	if !exists {
+		return gorm.ErrRecordNotFound
+	}
+	if r.scheduleCallbackErr != nil {
+		return r.scheduleCallbackErr
+	}
+	job.CallbackNextAttemptAt = at
+	return nil
+}
+
+func (r *mockJobRepository) ListDueCallbacks(ctx context.Context, now time.Time, limit int) ([]*models.GenerationJob, error) {
+	return nil, nil
+}
+
+func (r *mockJobRepository) ClaimCallback(ctx context.Context, id uuid.UUID, now, until time.Time) (bool, error) {
+	return false, nil
+}
+
+func (r *mockJobRepository) RecordCallbackAttempt(ctx context.Context, id uuid.UUID, attempt jobs.CallbackAttempt) error {
+	job, exists := r.jobs[id]
+	if !exists {
+		return gorm.ErrRecordNotFound
+	}
+	job.CallbackAttempts++
+	job.CallbackLastAttemptAt = &attempt.At
+	job.CallbackDelivered = attempt.Delivered
+	job.CallbackLastError = attempt.LastError
+	job.CallbackNextAttemptAt = attempt.RetryAt
+	return nil
+}
+
+func (r *mockJobRepository) CountPendingCallbacks(ctx context.Context) (int64, error) {
+	var count int64
+	for _, job := range r.jobs {
+		if job.CallbackNextAttemptAt != nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *mockJobRepository) GetProfile(ctx context.Context, courseID uuid.UUID) (*models.GenerationProfile, error) {
+	profile, exists := r.profiles[courseID]
+	if !exists {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return profile, nil
+}
+
+func (r *mockJobRepository) SaveProfile(ctx context.Context, profile *models.GenerationProfile) error {
+	if r.profiles == nil {
+		r.profiles = make(map[uuid.UUID]*models.GenerationProfile)
+	}
+	r.profiles[profile.CourseID] = profile
+	return nil
+}
+
+func (r *mockJobRepository) DeleteProfile(ctx context.Context, courseID uuid.UUID) (bool, error) {
+	_, exists := r.profiles[courseID]
+	delete(r.profiles, courseID)
+	return exists, nil
+}
+
 func (r *mockJobRepository) DeleteOldJobs(ctx context.Context, olderThan time.Time) (int64, error) {
 	// Pour les tests, on ne supprime rien
 	return 0, nil
@@ -221,6 +481,38 @@ func TestInvalidJobID(t *testing.T) {
 	assert.Equal(t, 400, w.Code)
 }
 
+func TestValidationErrorLocale(t *testing.T) {
+	router := setupTestRouter(t)
+
+	request := func(t *testing.T, acceptLanguage string) (*httptest.ResponseRecorder, validation.ValidationError) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/jobs/invalid-uuid", nil)
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response struct {
+			ValidationErrors []validation.ValidationError `json:"validation_errors"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.ValidationErrors, 1)
+		return w, response.ValidationErrors[0]
+	}
+
+	w, english := request(t, "")
+	assert.Equal(t, "en", w.Header().Get("Content-Language"))
+	assert.Equal(t, "INVALID_UUID", english.Code)
+	assert.Equal(t, "job ID must be a valid UUID", english.Message)
+
+	w, french := request(t, "fr-CA,fr;q=0.9")
+	assert.Equal(t, "fr", w.Header().Get("Content-Language"))
+	assert.Equal(t, "INVALID_UUID", french.Code)
+	assert.Equal(t, english.Field, french.Field)
+	assert.Equal(t, "job_id doit être un UUID valide", french.Message)
+}
+
 func TestStorageInfoEndpoint(t *testing.T) {
 	router := setupTestRouter(t)
 
@@ -240,6 +532,13 @@ func TestStorageInfoEndpoint(t *testing.T) {
 	assert.True(t, ok)
 	assert.Contains(t, endpoints, "upload_sources")
 	assert.Contains(t, endpoints, "download_source")
+
+	// Octets transférés depuis le démarrage
+	transfer, ok := response["transfer"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Contains(t, transfer, "uploaded_bytes")
+	assert.Contains(t, transfer, "downloaded_bytes")
+	assert.NotContains(t, transfer, "window")
 }
 
 func TestListJobs(t *testing.T) {
@@ -281,6 +580,191 @@ func TestListJobs(t *testing.T) {
 	assert.Len(t, jobs, 3)
 }
 
+func TestListJobsPagination(t *testing.T) {
+	router := setupTestRouter(t)
+
+	for i := 0; i < 3; i++ {
+		reqBody := models.GenerationRequest{
+			JobID:      uuid.New(),
+			CourseID:   uuid.New(),
+			SourcePath: "test/path",
+		}
+
+		jsonBody, _ := json.Marshal(reqBody)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/generate", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, 201, w.Code)
+	}
+
+	tests := []struct {
+		name          string
+		queryParams   string
+		expectedCount int
+	}{
+		{"limit", "?limit=2", 2},
+		{"last page", "?limit=2&offset=2", 1},
+		{"offset past the end", "?offset=3", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/api/v1/jobs"+tt.queryParams, nil)
+			router.ServeHTTP(w, req)
+			require.Equal(t, 200, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+			jobs, ok := response["jobs"].([]interface{})
+			assert.True(t, ok)
+			assert.Len(t, jobs, tt.expectedCount)
+		})
+	}
+}
+
+func TestListJobsMetadataFilter(t *testing.T) {
+	router := setupTestRouter(t)
+
+	authors := []string{"alice", "bob", "alice"}
+	for _, author := range authors {
+		reqBody := models.GenerationRequest{
+			JobID:      uuid.New(),
+			CourseID:   uuid.New(),
+			SourcePath: "test/path",
+			Metadata:   map[string]interface{}{"author": author, "lang": "fr", "version": 2, "draft": author == "bob"},
+		}
+
+		jsonBody, _ := json.Marshal(reqBody)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/generate", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, 201, w.Code)
+	}
+
+	tests := []struct {
+		name           string
+		queryParams    string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{"single filter", "?meta.author=alice", 200, 2},
+		{"combined filters", "?meta.author=bob&meta.lang=fr", 200, 1},
+		{"no match", "?meta.author=carol", 200, 0},
+		{"number value", "?meta.version=2", 200, 3},
+		{"boolean value", "?meta.draft=true", 200, 1},
+		{"invalid key", "?meta.auth%20or=alice", 400, 0},
+		{"empty key", "?meta.=alice", 400, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/api/v1/jobs"+tt.queryParams, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != 200 {
+				return
+			}
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+			jobs, ok := response["jobs"].([]interface{})
+			assert.True(t, ok)
+			assert.Len(t, jobs, tt.expectedCount)
+		})
+	}
+}
+
+func TestListJobsLabelFilter(t *testing.T) {
+	router := setupTestRouter(t)
+
+	jobLabels := []map[string]string{
+		{"env": "prod", "team": "docs"},
+		{"env": "staging", "team": "docs"},
+		{"env": "prod"},
+	}
+	for _, labels := range jobLabels {
+		reqBody := models.GenerationRequest{
+			JobID:      uuid.New(),
+			CourseID:   uuid.New(),
+			SourcePath: "test/path",
+			Labels:     labels,
+		}
+
+		jsonBody, _ := json.Marshal(reqBody)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/generate", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, 201, w.Code, w.Body.String())
+
+		var created models.JobResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, labels, created.Labels)
+	}
+
+	tests := []struct {
+		name           string
+		queryParams    string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{"single label", "?label=env:prod", 200, 2},
+		{"combined labels", "?label=env:prod&label=team:docs", 200, 1},
+		{"no match", "?label=env:dev", 200, 0},
+		{"missing value", "?label=env", 400, 0},
+		{"invalid key", "?label=Env%20X:prod", 400, 0},
+		{"conflicting values", "?label=env:prod&label=env:staging", 400, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/api/v1/jobs"+tt.queryParams, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != 200 {
+				return
+			}
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+			jobs, ok := response["jobs"].([]interface{})
+			assert.True(t, ok)
+			assert.Len(t, jobs, tt.expectedCount)
+		})
+	}
+
+	t.Run("invalid labels rejected at creation", func(t *testing.T) {
+		reqBody := models.GenerationRequest{
+			JobID:      uuid.New(),
+			CourseID:   uuid.New(),
+			SourcePath: "test/path",
+			Labels:     map[string]string{"env": "prod:eu"},
+		}
+
+		jsonBody, _ := json.Marshal(reqBody)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/generate", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, 400, w.Code)
+		assert.Contains(t, w.Body.String(), "INVALID_LABEL_VALUE")
+	})
+}
+
 func TestCreateJobValidation(t *testing.T) {
 	router := setupTestRouter(t)
 
@@ -443,3 +927,1000 @@ func TestListJobsValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestGetWorkspaceInfo(t *testing.T) {
+	router := setupTestRouter(t)
+
+	t.Run("workspace not found", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/worker/workspaces/"+uuid.New().String(), nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("dependencies reported separately", func(t *testing.T) {
+		jobID := uuid.New()
+		workspace, err := worker.NewWorkspace(os.TempDir(), jobID)
+		require.NoError(t, err)
+		defer workspace.Cleanup()
+
+		require.NoError(t, workspace.WriteFile("slides.md", strings.NewReader(strings.Repeat("s", 100))))
+		require.NoError(t, workspace.WriteFile("dist/index.html", strings.NewReader(strings.Repeat("d", 200))))
+		require.NoError(t, workspace.WriteFile("node_modules/@slidev/cli/index.js", strings.NewReader(strings.Repeat("n", 5000))))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/worker/workspaces/"+jobID.String(), nil)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response models.WorkspaceInfoResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		usage := response.Usage.DiskUsage
+		assert.Equal(t, int64(300), usage.TotalBytes)
+		assert.Equal(t, int64(100), usage.SourceBytes)
+		assert.Equal(t, int64(200), usage.DistBytes)
+		assert.Equal(t, int64(5000), usage.DependencyBytes)
+		assert.Equal(t, 2, response.Workspace.FileCount)
+		assert.NotContains(t, response.Workspace.Files, "node_modules/@slidev/cli/index.js")
+	})
+}
+
+func TestListWorkspaces(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	workspaceBase := t.TempDir()
+	router := SetupRouter(jobService, storageService, worker.NewWorkerPool(jobService, storageService, &worker.PoolConfig{
+		WorkerCount:   1,
+		PollInterval:  time.Second,
+		JobTimeout:    30 * time.Second,
+		WorkspaceBase: workspaceBase,
+	}))
+
+	built, idle := uuid.New(), uuid.New()
+	for _, jobID := range []uuid.UUID{built, idle} {
+		workspace, err := worker.NewWorkspace(workspaceBase, jobID)
+		require.NoError(t, err)
+		require.NoError(t, workspace.WriteFile("slides.md", strings.NewReader("# Cours")))
+		if jobID == built {
+			require.NoError(t, workspace.WriteFile("dist/index.html", strings.NewReader("<html></html>")))
+		}
+	}
+
+	list := func(query string) (int, models.WorkspaceListResponse) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/worker/workspaces"+query, nil))
+
+		var response models.WorkspaceListResponse
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		}
+		return w.Code, response
+	}
+
+	t.Run("All workspaces", func(t *testing.T) {
+		code, response := list("")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 2, response.Count)
+		assert.Equal(t, 2, response.Summary.TotalWorkspaces)
+		assert.Equal(t, 2, response.Summary.IdleWorkspaces)
+		assert.Equal(t, 0, response.Summary.ActiveWorkspaces)
+	})
+
+	t.Run("Filter by status", func(t *testing.T) {
+		code, response := list("?status=completed")
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, response.Workspaces, 1)
+		assert.Equal(t, built.String(), response.Workspaces[0].JobID)
+		assert.Equal(t, "completed", response.Workspaces[0].Status)
+		assert.Equal(t, 2, response.Summary.TotalWorkspaces)
+
+		code, response = list("?status=idle")
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, response.Workspaces, 1)
+		assert.Equal(t, idle.String(), response.Workspaces[0].JobID)
+
+		code, _ = list("?status=stopped")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		code, response := list("?limit=1&offset=1")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 1, response.Count)
+		assert.Equal(t, 2, response.TotalCount)
+		assert.Equal(t, 2, response.Page)
+
+		code, response = list("?offset=10")
+		require.Equal(t, http.StatusOK, code)
+		assert.Empty(t, response.Workspaces)
+	})
+}
+
+func TestClientJobQuota(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouterWithConfig(jobService, storageService, createMockWorkerPool(jobService, storageService),
+		&RouterConfig{MaxActiveJobsPerClient: 2})
+
+	submit := func(remoteAddr string) *httptest.ResponseRecorder {
+		reqBody := models.GenerationRequest{
+			JobID:      uuid.New(),
+			CourseID:   uuid.New(),
+			SourcePath: "test/path",
+		}
+		jsonBody, _ := json.Marshal(reqBody)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/generate", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = remoteAddr
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	var firstJob models.JobResponse
+	w := submit("192.0.2.10:1234")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &firstJob))
+	assert.Equal(t, "2", w.Header().Get("X-Client-Jobs-Limit"))
+	assert.Equal(t, "1", w.Header().Get("X-Client-Jobs-Active"))
+
+	w = submit("192.0.2.10:1234")
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-Client-Jobs-Active"))
+
+	t.Run("client at cap is rejected", func(t *testing.T) {
+		w := submit("192.0.2.10:1234")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Contains(t, w.Body.String(), "CLIENT_JOB_QUOTA_EXCEEDED")
+		assert.Equal(t, "2", w.Header().Get("X-Client-Jobs-Active"))
+	})
+
+	t.Run("other clients are not affected", func(t *testing.T) {
+		w := submit("198.51.100.20:1234")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "1", w.Header().Get("X-Client-Jobs-Active"))
+	})
+
+	t.Run("finished jobs free the quota", func(t *testing.T) {
+		require.NoError(t, jobService.UpdateJobStatus(context.Background(), firstJob.ID, models.StatusCompleted, 100, ""))
+
+		w := submit("192.0.2.10:1234")
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+}
+
+func TestQueueAdmission(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	workerPool := worker.NewWorkerPool(jobService, storageService, &worker.PoolConfig{
+		WorkerCount:       1,
+		WorkspaceBase:     os.TempDir(),
+		QueueOverflowMode: worker.QueueOverflowReject,
+	})
+	router := SetupRouterWithConfig(jobService, storageService, workerPool, &RouterConfig{})
+
+	submit := func() *httptest.ResponseRecorder {
+		reqBody := models.GenerationRequest{
+			JobID:      uuid.New(),
+			CourseID:   uuid.New(),
+			SourcePath: "test/path",
+		}
+		jsonBody, _ := json.Marshal(reqBody)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/generate", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// La file d'un worker contient 2 jobs
+	require.Equal(t, http.StatusCreated, submit().Code)
+	require.Equal(t, http.StatusCreated, submit().Code)
+
+	w := submit()
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "QUEUE_FULL", response["code"])
+	assert.Equal(t, float64(2), response["queue_capacity"])
+
+	t.Run("persist mode accepts up to the backlog cap", func(t *testing.T) {
+		jobService, storageService := setupTestServices(t)
+		workerPool := worker.NewWorkerPool(jobService, storageService, &worker.PoolConfig{
+			WorkerCount:       1,
+			WorkspaceBase:     os.TempDir(),
+			MaxPendingBacklog: 3,
+		})
+		router = SetupRouterWithConfig(jobService, storageService, workerPool, &RouterConfig{})
+
+		for i := 0; i < 3; i++ {
+			require.Equal(t, http.StatusCreated, submit().Code)
+		}
+
+		w := submit()
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), "QUEUE_BACKLOG_FULL")
+	})
+}
+
+func TestWorkerMaintenance(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	workerPool := createMockWorkerPool(jobService, storageService)
+	router := SetupRouterWithConfig(jobService, storageService, workerPool, &RouterConfig{AdminToken: "admin-token"})
+
+	toggle := func(body, authorization string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/worker/maintenance", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	submit := func() *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(models.GenerationRequest{JobID: uuid.New(), CourseID: uuid.New(), SourcePath: "test/path"})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/generate", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+	health := func() map[string]interface{} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/worker/health", nil)
+		router.ServeHTTP(w, req)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	t.Run("Requires the admin token", func(t *testing.T) {
+		w := toggle(`{"enabled": true}`, "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.False(t, workerPool.Maintenance().Enabled)
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		w := toggle(`{"message": "upgrade"}`, "Bearer admin-token")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "REQUIRED")
+
+		w = toggle(`{"enabled": true, "message": "`+strings.Repeat("a", 501)+`"}`, "Bearer admin-token")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "MESSAGE_TOO_LONG")
+
+		// La limite compte les caractères, et la valeur rapportée reste de l'UTF-8 valide
+		w = toggle(`{"enabled": false, "message": "`+strings.Repeat("é", 500)+`"}`, "Bearer admin-token")
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = toggle(`{"enabled": true, "message": "`+strings.Repeat("é", 501)+`"}`, "Bearer admin-token")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.True(t, utf8.Valid(w.Body.Bytes()))
+		assert.Contains(t, w.Body.String(), strings.Repeat("é", 50)+"...")
+		assert.False(t, workerPool.Maintenance().Enabled)
+	})
+
+	t.Run("Enabled maintenance rejects submissions", func(t *testing.T) {
+		w := toggle(`{"enabled": true, "message": "Upgrade to 1.4, back at 14:00"}`, "Bearer admin-token")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var status models.MaintenanceStatus
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+		assert.True(t, status.Enabled)
+		assert.True(t, status.Drained)
+		assert.NotNil(t, status.Since)
+
+		w = submit()
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "MAINTENANCE", response["code"])
+		assert.Equal(t, "Upgrade to 1.4, back at 14:00", response["message"])
+
+		// Pool non démarré : unhealthy l'emporte, la maintenance reste signalée
+		report := health()
+		assert.Equal(t, "unhealthy", report["status"])
+		maintenance, ok := report["maintenance"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, true, maintenance["drained"])
+	})
+
+	t.Run("Disabled maintenance accepts submissions", func(t *testing.T) {
+		w := toggle(`{"enabled": false}`, "Bearer admin-token")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		assert.Equal(t, http.StatusCreated, submit().Code)
+		assert.NotContains(t, health(), "maintenance")
+	})
+}
+
+func TestWorkerHealthStatus(t *testing.T) {
+	healthy := worker.PoolStats{Running: true, QueueCapacity: 10, Workers: []worker.WorkerStats{{Status: "idle"}}}
+	maintenance := models.MaintenanceStatus{Enabled: true}
+
+	status, issues := workerHealthStatus(healthy, models.MaintenanceStatus{})
+	assert.Equal(t, "healthy", status)
+	assert.Empty(t, issues)
+
+	status, issues = workerHealthStatus(healthy, maintenance)
+	assert.Equal(t, "maintenance", status)
+	assert.Equal(t, []string{"maintenance mode enabled"}, issues)
+
+	// La maintenance ne masque pas un problème réel
+	degraded := healthy
+	degraded.Workers = []worker.WorkerStats{{Status: "stopped"}}
+	status, issues = workerHealthStatus(degraded, maintenance)
+	assert.Equal(t, "degraded", status)
+	assert.Equal(t, []string{"1 workers stopped", "maintenance mode enabled"}, issues)
+}
+
+func TestWorkerQueue(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouterWithConfig(jobService, storageService, createMockWorkerPool(jobService, storageService),
+		&RouterConfig{AdminToken: "admin-token"})
+
+	get := func(query, authorization string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/worker/queue"+query, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Requires the admin token", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, get("", "").Code)
+		assert.Equal(t, http.StatusUnauthorized, get("", "Bearer wrong-token").Code)
+	})
+
+	t.Run("Empty queue", func(t *testing.T) {
+		w := get("?limit=10", "Bearer admin-token")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var snapshot models.QueueSnapshot
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &snapshot))
+		assert.Empty(t, snapshot.Jobs)
+		assert.Zero(t, snapshot.TotalCount)
+		assert.Equal(t, 10, snapshot.Limit)
+	})
+
+	t.Run("Invalid pagination", func(t *testing.T) {
+		w := get("?limit=5000", "Bearer admin-token")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "LIMIT_TOO_LARGE")
+	})
+}
+
+func TestCleanupOldWorkspacesRequiresAdminToken(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	workerPool := worker.NewWorkerPool(jobService, storageService, &worker.PoolConfig{
+		WorkerCount:   1,
+		PollInterval:  time.Second,
+		JobTimeout:    30 * time.Second,
+		WorkspaceBase: t.TempDir(),
+	})
+
+	cleanup := func(router *gin.Engine, authorization string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/worker/workspaces/cleanup?max_age_hours=8760", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	router := SetupRouterWithConfig(jobService, storageService, workerPool, &RouterConfig{AdminToken: "admin-token"})
+	assert.Equal(t, http.StatusUnauthorized, cleanup(router, ""))
+	assert.Equal(t, http.StatusUnauthorized, cleanup(router, "Bearer wrong-token"))
+	assert.Equal(t, http.StatusOK, cleanup(router, "Bearer admin-token"))
+
+	// Sans ADMIN_TOKEN, le nettoyage est désactivé
+	router = SetupRouterWithConfig(jobService, storageService, workerPool, &RouterConfig{})
+	assert.Equal(t, http.StatusForbidden, cleanup(router, ""))
+}
+
+func TestCreateJobBatch(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	validationConfig := validation.DefaultValidationConfig()
+	validationConfig.MaxBatchSize = 3
+	router := SetupRouterWithConfig(jobService, storageService, createMockWorkerPool(jobService, storageService),
+		&RouterConfig{ValidationConfig: validationConfig, MaxActiveJobsPerClient: 3})
+
+	newRequest := func() models.GenerationRequest {
+		return models.GenerationRequest{
+			JobID:      uuid.New(),
+			CourseID:   uuid.New(),
+			SourcePath: "test/path",
+		}
+	}
+
+	submitBatch := func(remoteAddr string, jobs ...models.GenerationRequest) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(models.BatchGenerationRequest{Jobs: jobs})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/generate/batch", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = remoteAddr
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("all jobs created", func(t *testing.T) {
+		first, second := newRequest(), newRequest()
+
+		w := submitBatch("192.0.2.30:1234", first, second)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Equal(t, "2", w.Header().Get("X-Client-Jobs-Active"))
+
+		var response models.BatchGenerationResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.Created)
+		require.Len(t, response.Results, 2)
+		assert.Equal(t, models.BatchItemCreated, response.Results[0].Status)
+		assert.Equal(t, first.JobID, response.Results[0].Job.ID)
+
+		job, err := jobService.GetJob(context.Background(), second.JobID)
+		require.NoError(t, err)
+		assert.Equal(t, "ip:192.0.2.30", job.ClientID)
+	})
+
+	t.Run("invalid items are reported without blocking the others", func(t *testing.T) {
+		valid := newRequest()
+		traversal := newRequest()
+		traversal.SourcePath = "../etc"
+		duplicate := valid
+
+		w := submitBatch("192.0.2.31:1234", valid, traversal, duplicate)
+		require.Equal(t, http.StatusMultiStatus, w.Code, w.Body.String())
+
+		var response models.BatchGenerationResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Created)
+		assert.Equal(t, 2, response.Failed)
+		assert.Equal(t, models.BatchItemValidationError, response.Results[1].Status)
+		assert.Equal(t, "PATH_TRAVERSAL", response.Results[1].ValidationErrors[0].Code)
+		assert.Equal(t, "jobs[1].source_path", response.Results[1].ValidationErrors[0].Field)
+		assert.Equal(t, "DUPLICATE_JOB_ID", response.Results[2].ValidationErrors[0].Code)
+	})
+
+	t.Run("no valid job", func(t *testing.T) {
+		missing := newRequest()
+		missing.CourseID = uuid.Nil
+
+		w := submitBatch("192.0.2.32:1234", missing)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "REQUIRED")
+	})
+
+	t.Run("batch size limits", func(t *testing.T) {
+		w := submitBatch("192.0.2.33:1234")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "EMPTY_BATCH")
+
+		w = submitBatch("192.0.2.33:1234", newRequest(), newRequest(), newRequest(), newRequest())
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "BATCH_TOO_LARGE")
+	})
+
+	t.Run("quota is applied to the whole batch", func(t *testing.T) {
+		w := submitBatch("192.0.2.34:1234", newRequest(), newRequest())
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		third, fourth := newRequest(), newRequest()
+		w = submitBatch("192.0.2.34:1234", third, fourth)
+		require.Equal(t, http.StatusTooManyRequests, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(1), response["available_slots"])
+		assert.Equal(t, []interface{}{fourth.JobID.String()}, response["exceeding_job_ids"])
+
+		// Rien n'a été créé
+		_, err := jobService.GetJob(context.Background(), third.JobID)
+		assert.Error(t, err)
+	})
+}
+
+func TestClientIdentity(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/api/v1/generate", nil)
+	c.Request.RemoteAddr = "192.0.2.10:1234"
+
+	assert.Equal(t, "ip:192.0.2.10", ClientIdentity(c))
+
+	// L'identité authentifiée prime sur l'IP
+	c.Set(AuthenticatedClientKey, "course-platform")
+	assert.Equal(t, "client:course-platform", ClientIdentity(c))
+}
+
+func TestClientAuthMiddleware(t *testing.T) {
+	router := gin.New()
+	router.Use(ClientAuthMiddleware(map[string]string{"secret-key": "course-platform"}))
+	router.GET("/whoami", func(c *gin.Context) {
+		c.String(http.StatusOK, ClientIdentity(c))
+	})
+
+	call := func(key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		req.RemoteAddr = "192.0.2.10:1234"
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := call("secret-key")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "client:course-platform", w.Body.String())
+
+	w = call("")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ip:192.0.2.10", w.Body.String())
+
+	w = call("wrong-key")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "CLIENT_UNAUTHORIZED")
+}
+
+func TestJobClientAccess(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouterWithConfig(jobService, storageService, createMockWorkerPool(jobService, storageService),
+		&RouterConfig{ClientAPIKeys: map[string]string{"key-alice": "alice", "key-bob": "bob"}})
+
+	// Un job par client authentifié et un job anonyme
+	submit := func(apiKey string) uuid.UUID {
+		jobID := uuid.New()
+		body, err := json.Marshal(models.GenerationRequest{JobID: jobID, CourseID: uuid.New(), SourcePath: "test/path"})
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/generate", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		return jobID
+	}
+	aliceJob := submit("key-alice")
+	bobJob := submit("key-bob")
+	anonymousJob := submit("")
+
+	get := func(path, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("job status", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, get("/api/v1/jobs/"+aliceJob.String(), "key-alice").Code)
+		assert.Equal(t, http.StatusForbidden, get("/api/v1/jobs/"+aliceJob.String(), "key-bob").Code)
+		assert.Equal(t, http.StatusForbidden, get("/api/v1/jobs/"+aliceJob.String(), "").Code)
+		assert.Equal(t, http.StatusOK, get("/api/v1/jobs/"+anonymousJob.String(), "key-bob").Code)
+	})
+
+	t.Run("job list", func(t *testing.T) {
+		listed := func(apiKey string) []uuid.UUID {
+			w := get("/api/v1/jobs", apiKey)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			var response struct {
+				Jobs []models.JobResponse `json:"jobs"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			ids := make([]uuid.UUID, 0, len(response.Jobs))
+			for _, job := range response.Jobs {
+				ids = append(ids, job.ID)
+			}
+			return ids
+		}
+
+		assert.ElementsMatch(t, []uuid.UUID{aliceJob}, listed("key-alice"))
+		assert.ElementsMatch(t, []uuid.UUID{bobJob}, listed("key-bob"))
+		assert.ElementsMatch(t, []uuid.UUID{anonymousJob}, listed(""))
+	})
+}
+
+func TestStorageNamespacePerClient(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouterWithConfig(jobService, storageService, createMockWorkerPool(jobService, storageService),
+		&RouterConfig{
+			StorageNamespacePerClient: true,
+			ClientAPIKeys:             map[string]string{"key-alice": "alice"},
+		})
+	ctx := context.Background()
+
+	// Job soumis par un client authentifié : son identité est enregistrée sur le job
+	jobID := uuid.New()
+	body, err := json.Marshal(models.GenerationRequest{JobID: jobID, CourseID: uuid.New(), SourcePath: "test/path"})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/generate", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", "key-alice")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	job, err := jobService.GetJob(ctx, jobID)
+	require.NoError(t, err)
+	require.Equal(t, "client:alice", job.ClientID)
+
+	// Sources uploadées par le même client
+	form := &bytes.Buffer{}
+	writer := multipart.NewWriter(form)
+	part, err := writer.CreateFormFile("files", "slides.md")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("# Hello"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/storage/jobs/"+jobID.String()+"/sources", form)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("X-API-Key", "key-alice")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	// Les fichiers sont écrits sous le namespace du client enregistré sur le job, celui
+	// qu'utilise le worker, et pas dans le namespace commun
+	clientCtx := storage.WithNamespace(ctx, storage.ClientNamespace(job.ClientID))
+	sources, err := storageService.ListJobSources(clientCtx, jobID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"slides.md"}, sources)
+
+	_, err = storageService.DownloadJobSource(ctx, jobID, "slides.md")
+	assert.Error(t, err)
+}
+
+func TestClientJobQuotaReservesPendingCreations(t *testing.T) {
+	jobService, _ := setupTestServices(t)
+
+	entered := make(chan struct{})
+	proceed := make(chan struct{})
+	router := gin.New()
+	router.POST("/generate", ClientJobQuotaMiddleware(jobService, 1), func(c *gin.Context) {
+		entered <- struct{}{}
+		<-proceed
+		c.Status(http.StatusCreated)
+	})
+
+	submit := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/generate", nil)
+		req.RemoteAddr = "192.0.2.10:1234"
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- submit() }()
+	<-entered
+
+	// Le premier job n'est pas encore en base : sa réservation suffit à refuser le second,
+	// sans attendre la fin du premier handler
+	w := submit()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	close(proceed)
+	assert.Equal(t, http.StatusCreated, (<-first).Code)
+
+	// La réservation est libérée avec le handler
+	go func() { <-entered }()
+	assert.Equal(t, http.StatusCreated, submit().Code)
+}
+
+func TestReleaseSubmissionBeforeWaiting(t *testing.T) {
+	jobService, _ := setupTestServices(t)
+
+	entered := make(chan struct{})
+	proceed := make(chan struct{})
+	router := gin.New()
+	router.POST("/generate", ClientJobQuotaMiddleware(jobService, 2), func(c *gin.Context) {
+		_, err := jobService.CreateJob(c.Request.Context(), &models.GenerationRequest{
+			JobID:      uuid.New(),
+			CourseID:   uuid.New(),
+			SourcePath: "courses/pending",
+			ClientID:   c.GetString(ClientIDContextKey),
+		})
+		require.NoError(t, err)
+		// Comme CreateJob avec cancel_on_disconnect : libérer puis attendre le démarrage
+		releaseSubmission(c)
+		entered <- struct{}{}
+		<-proceed
+		c.Status(http.StatusCreated)
+	})
+
+	submit := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/generate", nil)
+		req.RemoteAddr = "192.0.2.10:1234"
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- submit() }()
+	<-entered
+
+	// Le premier job est compté une seule fois (en base) pendant son attente
+	second := make(chan *httptest.ResponseRecorder, 1)
+	go func() { second <- submit() }()
+	select {
+	case <-entered:
+	case w := <-second:
+		close(proceed)
+		<-first
+		t.Fatalf("second submission rejected while the first waits: %d %s", w.Code, w.Body.String())
+	}
+	close(proceed)
+
+	assert.Equal(t, http.StatusCreated, (<-first).Code)
+	w := <-second
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-Client-Jobs-Active"))
+}
+
+func TestPurgeWorkspaceNodeModules(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouterWithConfig(jobService, storageService, createMockWorkerPool(jobService, storageService),
+		&RouterConfig{AdminToken: "admin-token"})
+
+	purgeWithToken := func(jobID uuid.UUID, authorization string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/worker/workspaces/"+jobID.String()+"/node_modules", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	purge := func(jobID uuid.UUID) *httptest.ResponseRecorder {
+		return purgeWithToken(jobID, "Bearer admin-token")
+	}
+
+	t.Run("requires the admin token", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, purgeWithToken(uuid.New(), "").Code)
+		assert.Equal(t, http.StatusUnauthorized, purgeWithToken(uuid.New(), "Bearer wrong-token").Code)
+	})
+
+	t.Run("workspace not found", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, purge(uuid.New()).Code)
+	})
+
+	t.Run("removes only node_modules", func(t *testing.T) {
+		jobID := uuid.New()
+		workspace, err := worker.NewWorkspace(os.TempDir(), jobID)
+		require.NoError(t, err)
+		defer workspace.Cleanup()
+
+		require.NoError(t, workspace.WriteFile("slides.md", strings.NewReader("# Slides")))
+		require.NoError(t, workspace.WriteFile("dist/index.html", strings.NewReader("<html></html>")))
+		require.NoError(t, workspace.WriteFile("node_modules/@slidev/cli/index.js", strings.NewReader(strings.Repeat("n", 3000))))
+		require.NoError(t, workspace.WriteFile("node_modules/sass/sass.js", strings.NewReader(strings.Repeat("n", 1000))))
+
+		w := purge(jobID)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response models.WorkspacePurgeResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, int64(4000), response.BytesFreed)
+
+		assert.False(t, workspace.DirExists("node_modules"))
+		assert.True(t, workspace.FileExists("slides.md"))
+		assert.True(t, workspace.FileExists("dist/index.html"))
+
+		// Une seconde purge ne libère plus rien
+		w = purge(jobID)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, int64(0), response.BytesFreed)
+	})
+}
+
+func TestEstimateBuildEndpoint(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService))
+	ctx := context.Background()
+
+	// Historique : builds "seriph" d'environ 1 Ko de sources
+	for i := 0; i < 4; i++ {
+		job, err := jobService.CreateJob(ctx, &models.GenerationRequest{
+			JobID:      uuid.New(),
+			CourseID:   uuid.New(),
+			SourcePath: "test/path",
+		})
+		require.NoError(t, err)
+
+		started := time.Now().Add(-2 * time.Minute)
+		completed := started.Add(90 * time.Second)
+		job.Status = models.StatusCompleted
+		job.StartedAt = &started
+		job.CompletedAt = &completed
+		job.SourceFileCount = 2
+		job.SourceSizeBytes = 1000
+		job.ResultSizeBytes = 8000
+		job.Theme = "seriph"
+	}
+
+	estimate := func(t *testing.T, contentType string, body *bytes.Buffer) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/generate/estimate", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Dry analysis", func(t *testing.T) {
+		body, _ := json.Marshal(models.EstimateRequest{SourceFileCount: 2, SourceSizeBytes: 1200, Theme: "seriph"})
+		w := estimate(t, "application/json", bytes.NewBuffer(body))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response models.BuildEstimate
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "theme_and_size", response.Basis)
+		assert.Equal(t, models.EstimateConfidenceMedium, response.Confidence)
+		assert.Equal(t, int64(4), response.SampleSize)
+		assert.InDelta(t, 90, response.EstimatedDurationSeconds, 0.5)
+		assert.Equal(t, int64(8000), response.EstimatedResultSizeBytes)
+	})
+
+	t.Run("Uploaded sources", func(t *testing.T) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		files := map[string]string{
+			"slides.md":  "---\ntheme: seriph\n---\n# Hello\n" + strings.Repeat("x", 500),
+			"styles.css": strings.Repeat("y", 500),
+		}
+		for name, content := range files {
+			part, err := writer.CreateFormFile("files", name)
+			require.NoError(t, err)
+			_, err = part.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, writer.Close())
+
+		w := estimate(t, writer.FormDataContentType(), body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response models.BuildEstimate
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.SourceFileCount)
+		assert.Equal(t, "seriph", response.Theme)
+		assert.Equal(t, "theme_and_size", response.Basis)
+	})
+
+	t.Run("Unknown characteristics", func(t *testing.T) {
+		body, _ := json.Marshal(models.EstimateRequest{SourceFileCount: 200, SourceSizeBytes: 50_000_000, Theme: "apple-basic"})
+		w := estimate(t, "application/json", bytes.NewBuffer(body))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response models.BuildEstimate
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "all", response.Basis)
+		assert.Equal(t, models.EstimateConfidenceLow, response.Confidence)
+	})
+
+	t.Run("Invalid request", func(t *testing.T) {
+		body, _ := json.Marshal(models.EstimateRequest{SourceFileCount: 0, SourceSizeBytes: 1000})
+		w := estimate(t, "application/json", bytes.NewBuffer(body))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "INVALID_SOURCE_FILE_COUNT")
+	})
+}
+
+func TestCreateJobCancelOnDisconnect(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	ctx := context.Background()
+
+	createJob := func(router *gin.Engine, reqCtx context.Context) (*httptest.ResponseRecorder, uuid.UUID) {
+		reqBody := models.GenerationRequest{
+			JobID:              uuid.New(),
+			CourseID:           uuid.New(),
+			SourcePath:         "courses/pending",
+			CancelOnDisconnect: true,
+		}
+		jsonBody, _ := json.Marshal(reqBody)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequestWithContext(reqCtx, http.MethodPost, "/api/v1/generate", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w, reqBody.JobID
+	}
+
+	t.Run("pending job is cancelled when the client disconnects", func(t *testing.T) {
+		router := SetupRouterWithConfig(jobService, storageService, createMockWorkerPool(jobService, storageService),
+			&RouterConfig{CancelOnDisconnectWindow: 5 * time.Second})
+
+		reqCtx, disconnect := context.WithCancel(ctx)
+		timer := time.AfterFunc(20*time.Millisecond, disconnect)
+		defer timer.Stop()
+
+		start := time.Now()
+		_, jobID := createJob(router, reqCtx)
+		assert.Less(t, time.Since(start), 5*time.Second)
+
+		job, err := jobService.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusFailed, job.Status)
+		assert.Equal(t, disconnectCancelReason, job.Error)
+	})
+
+	t.Run("job is kept once the window has elapsed", func(t *testing.T) {
+		router := SetupRouterWithConfig(jobService, storageService, createMockWorkerPool(jobService, storageService),
+			&RouterConfig{CancelOnDisconnectWindow: 20 * time.Millisecond})
+
+		w, jobID := createJob(router, ctx)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response models.JobResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, jobID, response.ID)
+		assert.Equal(t, models.StatusPending, response.Status)
+	})
+
+	t.Run("started job is not cancelled", func(t *testing.T) {
+		job, err := jobService.CreateJob(ctx, &models.GenerationRequest{
+			JobID:      uuid.New(),
+			CourseID:   uuid.New(),
+			SourcePath: "courses/started",
+		})
+		require.NoError(t, err)
+		claimed, err := jobService.ClaimJob(ctx, job.ID)
+		require.NoError(t, err)
+		require.True(t, claimed)
+
+		cancelled, err := jobService.CancelPendingJob(ctx, job.ID, disconnectCancelReason)
+		require.NoError(t, err)
+		assert.False(t, cancelled)
+
+		job, err = jobService.GetJob(ctx, job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusProcessing, job.Status)
+	})
+}
+
+func TestCreateJobBuildFlags(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService))
+
+	submit := func(flags []string) *httptest.ResponseRecorder {
+		courseID := uuid.New()
+		jsonBody, _ := json.Marshal(models.GenerationRequest{
+			JobID:      uuid.New(),
+			CourseID:   courseID,
+			SourcePath: "courses/" + courseID.String(),
+			BuildFlags: flags,
+		})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/generate", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("allowed flags are stored", func(t *testing.T) {
+		w := submit([]string{"--download", "--base=/cours/"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response models.JobResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, []string{"--download", "--base=/cours/"}, response.BuildFlags)
+	})
+
+	t.Run("disallowed flag is rejected", func(t *testing.T) {
+		w := submit([]string{"--download", "--watch"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "BUILD_FLAG_NOT_ALLOWED")
+		assert.Contains(t, w.Body.String(), "--watch")
+	})
+}
@@ -0,0 +1,147 @@
+// internal/api/log_stream_handlers.go - Suivi en direct des logs de build (SSE)
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
+	"github.com/Open-Course-Factory/ocf-worker/internal/worker"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// logStreamKeepAlive est l'intervalle des commentaires SSE envoyés pour garder la connexion
+// ouverte à travers les proxies ; le statut du job est revérifié à chaque fois
+const logStreamKeepAlive = 15 * time.Second
+
+// LogStreamHandlers gère les flux de logs en direct
+type LogStreamHandlers struct {
+	jobService jobs.JobService
+	workerPool *worker.WorkerPool
+}
+
+// NewLogStreamHandlers crée un nouveau gestionnaire de flux de logs
+func NewLogStreamHandlers(jobService jobs.JobService, workerPool *worker.WorkerPool) *LogStreamHandlers {
+	return &LogStreamHandlers{
+		jobService: jobService,
+		workerPool: workerPool,
+	}
+}
+
+// StreamJobLogs diffuse les logs de build d'un job en Server-Sent Events
+// @Summary Suivre les logs d'un job en direct
+// @Description Flux SSE des logs de build. À la connexion, les dernières lignes du build
+// @Description (`LOG_STREAM_REPLAY_LINES`, 100 par défaut) sont rejouées, puis les nouvelles
+// @Description lignes sont envoyées au fil de l'eau.
+// @Description
+// @Description Événements : `log` (une ligne de log) puis `end` (statut final du job) à la fin
+// @Description du build. Pour un job déjà terminé, seul `end` est envoyé : les logs complets
+// @Description sont disponibles sur `/storage/jobs/{job_id}/logs`.
+// @Tags Jobs
+// @Produce text/event-stream
+// @Param id path string true "ID du job" Format(uuid)
+// @Success 200 {string} string "Flux SSE"
+// @Failure 400 {object} models.ErrorResponse "ID de job invalide"
+// @Failure 403 {object} models.ErrorResponse "Job appartenant à un autre client"
+// @Failure 404 {object} models.ErrorResponse "Job non trouvé"
+// @Router /jobs/{id}/logs/stream [get]
+func (h *LogStreamHandlers) StreamJobLogs(c *gin.Context) {
+	jobID := c.MustGet("validated_job_id").(uuid.UUID)
+	ctx := c.Request.Context()
+
+	job, err := h.jobService.GetJob(ctx, jobID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+
+	if !canAccessJob(c, job) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "job belongs to another client"})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	if c.Request.ProtoMajor == 1 {
+		// En-tête propre à HTTP/1.x, interdit en HTTP/2 où le flux est multiplexé
+		c.Header("Connection", "keep-alive")
+	}
+	c.Header("X-Accel-Buffering", "no")
+
+	// Le flux dure autant que le build : lever les timeouts de lecture et d'écriture
+	// du serveur (HTTP_READ_TIMEOUT, HTTP_WRITE_TIMEOUT), les keep-alive détectent
+	// les clients partis
+	rc := http.NewResponseController(c.Writer)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	if job.IsTerminal() {
+		h.sendEnd(c, job)
+		return
+	}
+
+	replay, lines, unsubscribe := h.workerPool.SubscribeJobLogs(jobID)
+	defer unsubscribe()
+
+	// Le job a pu se terminer entre la lecture du statut et l'abonnement
+	if job, err = h.jobService.GetJob(ctx, jobID); err == nil && job.IsTerminal() {
+		h.sendEnd(c, job)
+		return
+	}
+
+	for _, line := range replay {
+		c.SSEvent("log", line)
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(logStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case line, open := <-lines:
+			if !open {
+				h.sendFinalStatus(ctx, c, jobID)
+				return
+			}
+			c.SSEvent("log", line)
+			c.Writer.Flush()
+
+		case <-keepAlive.C:
+			if job, err := h.jobService.GetJob(ctx, jobID); err == nil && job.IsTerminal() {
+				h.sendEnd(c, job)
+				return
+			}
+			_, _ = c.Writer.WriteString(": keep-alive\n\n")
+			c.Writer.Flush()
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sendFinalStatus recharge le job pour envoyer son statut final
+func (h *LogStreamHandlers) sendFinalStatus(ctx context.Context, c *gin.Context, jobID uuid.UUID) {
+	job, err := h.jobService.GetJob(ctx, jobID)
+	if err != nil {
+		c.SSEvent("end", gin.H{"job_id": jobID})
+		c.Writer.Flush()
+		return
+	}
+	h.sendEnd(c, job)
+}
+
+// sendEnd signale la fin du flux avec le statut du job
+func (h *LogStreamHandlers) sendEnd(c *gin.Context, job *models.GenerationJob) {
+	c.SSEvent("end", gin.H{
+		"job_id": job.ID,
+		"status": job.Status,
+		"error":  job.Error,
+	})
+	c.Writer.Flush()
+}
@@ -0,0 +1,98 @@
+// internal/api/log_stream_handlers_test.go
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamJobLogs(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService))
+	ctx := context.Background()
+
+	createJob := func(clientID string) *models.GenerationJob {
+		job, err := jobService.CreateJob(ctx, &models.GenerationRequest{
+			JobID:      uuid.New(),
+			CourseID:   uuid.New(),
+			SourcePath: "test/path",
+			ClientID:   clientID,
+		})
+		require.NoError(t, err)
+		return job
+	}
+
+	streamLogs := func(reqCtx context.Context, jobID uuid.UUID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+jobID.String()+"/logs/stream", nil).WithContext(reqCtx)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("terminated job only sends end", func(t *testing.T) {
+		job := createJob("")
+		require.NoError(t, jobService.UpdateJobStatus(ctx, job.ID, models.StatusFailed, 50, "build failed"))
+
+		w := streamLogs(ctx, job.ID)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/event-stream")
+		assert.Contains(t, w.Body.String(), "event:end")
+		assert.Contains(t, w.Body.String(), `"status":"failed"`)
+		assert.NotContains(t, w.Body.String(), "event:log")
+	})
+
+	t.Run("active job streams until the client disconnects", func(t *testing.T) {
+		job := createJob("")
+
+		reqCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		w := streamLogs(reqCtx, job.ID)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/event-stream")
+		assert.NotContains(t, w.Body.String(), "event:end")
+	})
+
+	t.Run("HTTP/2 stream omits connection headers", func(t *testing.T) {
+		job := createJob("")
+		require.NoError(t, jobService.UpdateJobStatus(ctx, job.ID, models.StatusCompleted, 100, ""))
+
+		server := httptest.NewUnstartedServer(router)
+		server.EnableHTTP2 = true
+		server.StartTLS()
+		defer server.Close()
+
+		resp, err := server.Client().Get(server.URL + "/api/v1/jobs/" + job.ID.String() + "/logs/stream")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, 2, resp.ProtoMajor)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Connection"))
+		assert.Contains(t, resp.Header.Get("Content-Type"), "text/event-stream")
+
+		// En HTTP/1.1, le keep-alive reste annoncé
+		w := streamLogs(ctx, job.ID)
+		assert.Equal(t, "keep-alive", w.Header().Get("Connection"))
+	})
+
+	t.Run("unknown job", func(t *testing.T) {
+		w := streamLogs(ctx, uuid.New())
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("job of another authenticated client", func(t *testing.T) {
+		job := createJob("client:alice")
+		w := streamLogs(ctx, job.ID)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -1,13 +1,24 @@
 package api
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
 	"github.com/Open-Course-Factory/ocf-worker/internal/validation"
+	"github.com/Open-Course-Factory/ocf-worker/internal/worker"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // ValidationMiddleware injecte l'APIValidator dans le contexte
@@ -89,6 +100,287 @@ func RateLimitMiddleware(requestsPerMinute int) gin.HandlerFunc {
 	}
 }
 
+// AuthenticatedClientKey est la clé de contexte où ClientAuthMiddleware dépose l'identité du client
+const AuthenticatedClientKey = "authenticated_client"
+
+// ClientAuthMiddleware authentifie les clients porteurs d'une clé d'API (X-API-Key) déclarée
+// dans keys (clé -> nom du client). Une requête sans clé reste anonyme et identifiée par son
+// IP ; une clé inconnue est refusée.
+func ClientAuthMiddleware(keys map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader("X-API-Key")
+		if provided == "" {
+			c.Next()
+			return
+		}
+
+		client := ""
+		for key, name := range keys {
+			// Toutes les clés sont comparées pour ne pas révéler laquelle est proche
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(key)) == 1 {
+				client = name
+			}
+		}
+		if client == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "invalid API key",
+				"code":  "CLIENT_UNAUTHORIZED",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set(AuthenticatedClientKey, client)
+		c.Next()
+	}
+}
+
+// ClientIDContextKey est la clé de contexte de l'identité utilisée pour les quotas
+const ClientIDContextKey = "client_id"
+
+// ClientIdentity retourne l'identité du client : l'identité authentifiée si
+// disponible, sinon l'adresse IP
+func ClientIdentity(c *gin.Context) string {
+	if client := c.GetString(AuthenticatedClientKey); client != "" {
+		return "client:" + client
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// canAccessJob vérifie qu'un job soumis par un client authentifié n'est lu que par ce client
+func canAccessJob(c *gin.Context, job *models.GenerationJob) bool {
+	if !strings.HasPrefix(job.ClientID, "client:") {
+		return true
+	}
+	return ClientIdentity(c) == job.ClientID
+}
+
+// StorageNamespaceMiddleware isole les fichiers de chaque client authentifié dans
+// son propre namespace de stockage. Les clients anonymes gardent le namespace commun.
+func StorageNamespaceMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if namespace := storage.ClientNamespace(ClientIdentity(c)); namespace != "" {
+			c.Request = c.Request.WithContext(storage.WithNamespace(c.Request.Context(), namespace))
+		}
+		c.Next()
+	}
+}
+
+// ClientJobQuotaMiddleware limite le nombre de jobs actifs (pending + processing) par client.
+// Une limite <= 0 désactive le quota, l'identité du client est tout de même enregistrée.
+// Une soumission groupée est acceptée ou refusée en entier : tous ses jobs valides
+// doivent tenir dans le quota. La même instance doit protéger toutes les routes de création.
+func ClientJobQuotaMiddleware(jobService jobs.JobService, maxActiveJobs int) gin.HandlerFunc {
+	// Seuls comptage et réservation sont sérialisés : les jobs réservés, pas encore en base,
+	// comptent dans le quota de leur client jusqu'à leur création
+	var mu sync.Mutex
+	reserved := make(map[string]int)
+
+	return func(c *gin.Context) {
+		clientID := ClientIdentity(c)
+		c.Set(ClientIDContextKey, clientID)
+
+		if maxActiveJobs <= 0 {
+			c.Next()
+			return
+		}
+
+		mu.Lock()
+		active, err := jobService.CountActiveJobsByClient(c.Request.Context(), clientID)
+		if err != nil {
+			mu.Unlock()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		active += reserved[clientID]
+
+		c.Header("X-Client-Jobs-Limit", strconv.Itoa(maxActiveJobs))
+
+		batchJobIDs, isBatch := batchRequestedJobIDs(c)
+		requested := 1
+		if isBatch {
+			requested = len(batchJobIDs)
+		}
+
+		if active+requested > maxActiveJobs {
+			mu.Unlock()
+			c.Header("X-Client-Jobs-Active", strconv.Itoa(active))
+			response := gin.H{
+				"error":       "Too many active jobs for this client",
+				"code":        "CLIENT_JOB_QUOTA_EXCEEDED",
+				"active_jobs": active,
+				"max_jobs":    maxActiveJobs,
+			}
+			if isBatch {
+				available := max(maxActiveJobs-active, 0)
+				response["requested_jobs"] = requested
+				response["available_slots"] = available
+				response["exceeding_job_ids"] = batchJobIDs[available:]
+			}
+			c.JSON(http.StatusTooManyRequests, response)
+			c.Abort()
+			return
+		}
+
+		reserved[clientID] += requested
+		mu.Unlock()
+
+		release := sync.OnceFunc(func() {
+			mu.Lock()
+			defer mu.Unlock()
+			if reserved[clientID] -= requested; reserved[clientID] <= 0 {
+				delete(reserved, clientID)
+			}
+		})
+		holdSubmission(c, release)
+		defer release()
+
+		// Le header est écrit avant la réponse du handler : compter les jobs en cours de création
+		c.Header("X-Client-Jobs-Active", strconv.Itoa(active+requested))
+		c.Next()
+	}
+}
+
+// submissionReleasesKey est la clé de contexte des réservations (admission, quota) d'une soumission
+const submissionReleasesKey = "submission_releases"
+
+// holdSubmission rattache la libération d'une réservation à la soumission en cours
+func holdSubmission(c *gin.Context, release func()) {
+	releases, _ := c.Get(submissionReleasesKey)
+	list, _ := releases.([]func())
+	c.Set(submissionReleasesKey, append(list, release))
+}
+
+// releaseSubmission libère les réservations de la soumission. Le handler l'appelle dès que ses
+// jobs sont enregistrés en base, où les soumissions suivantes les comptent ; les middlewares
+// la rappellent après le handler (les libérations sont idempotentes).
+func releaseSubmission(c *gin.Context) {
+	releases, _ := c.Get(submissionReleasesKey)
+	list, _ := releases.([]func())
+	for _, release := range list {
+		release()
+	}
+}
+
+// QueueAdmissionMiddleware refuse les soumissions quand la file du worker ne peut plus les
+// accepter : file en mémoire pleine en mode reject, backlog des jobs pending plein en mode
+// persist, ou instance en maintenance. Une soumission groupée est acceptée ou refusée en entier.
+// Les jobs admis sont réservés jusqu'à leur création, sans bloquer les autres soumissions.
+func QueueAdmissionMiddleware(workerPool *worker.WorkerPool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if workerPool == nil {
+			c.Next()
+			return
+		}
+
+		requested := 1
+		if batchJobIDs, isBatch := batchRequestedJobIDs(c); isBatch {
+			requested = len(batchJobIDs)
+		}
+
+		release, err := workerPool.ReserveAdmission(c.Request.Context(), requested)
+		if err == nil {
+			holdSubmission(c, release)
+			defer release()
+			c.Next()
+			return
+		}
+
+		code := ""
+		switch {
+		case errors.Is(err, worker.ErrMaintenance):
+			maintenance := workerPool.Maintenance()
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":             err.Error(),
+				"code":              "MAINTENANCE",
+				"message":           maintenance.Message,
+				"maintenance_since": maintenance.Since,
+			})
+			c.Abort()
+			return
+		case errors.Is(err, worker.ErrQueueFull):
+			code = "QUEUE_FULL"
+		case errors.Is(err, worker.ErrBacklogFull):
+			code = "QUEUE_BACKLOG_FULL"
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		stats := workerPool.GetStats()
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":            err.Error(),
+			"code":             code,
+			"requested_jobs":   requested,
+			"queue_size":       stats.QueueSize,
+			"queue_capacity":   stats.QueueCapacity,
+			"backlog_size":     stats.BacklogSize,
+			"backlog_capacity": stats.BacklogCapacity,
+		})
+		c.Abort()
+	}
+}
+
+// JobResultsMiddleware charge le job de la route (validated_job) et place ses résultats à leur
+// destination dans le contexte de la requête : result_prefix du job ou résultats du cours
+func JobResultsMiddleware(jobService jobs.JobService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		job, err := jobService.GetJob(c.Request.Context(), c.MustGet("validated_job_id").(uuid.UUID))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "job not found", "code": "JOB_NOT_FOUND"})
+			c.Abort()
+			return
+		}
+		if !canAccessJob(c, job) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "job belongs to another client"})
+			c.Abort()
+			return
+		}
+
+		c.Set("validated_job", job)
+		c.Request = c.Request.WithContext(jobResultsContext(c.Request.Context(), job))
+		c.Next()
+	}
+}
+
+// jobResultsContext retourne un contexte dont les opérations sur les résultats portent sur
+// la destination du job
+func jobResultsContext(ctx context.Context, job *models.GenerationJob) context.Context {
+	if job.ResultPrefix == "" {
+		return ctx
+	}
+	return storage.WithResultPrefix(ctx, job.ResultPrefix)
+}
+
+// AdminTokenMiddleware réserve une route aux porteurs du jeton d'administration
+// (Authorization: Bearer <jeton>). Sans jeton configuré, la route est désactivée.
+func AdminTokenMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "admin endpoints are disabled, set ADMIN_TOKEN to enable them",
+				"code":  "ADMIN_DISABLED",
+			})
+			c.Abort()
+			return
+		}
+
+		provided, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "missing or invalid admin token",
+				"code":  "ADMIN_UNAUTHORIZED",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 // SecurityHeadersMiddleware ajoute des headers de sécurité
 func SecurityHeadersMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
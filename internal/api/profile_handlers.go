@@ -0,0 +1,111 @@
+// internal/api/profile_handlers.go - Profils de génération des cours
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ProfileHandlers gère les profils de génération des cours
+type ProfileHandlers struct {
+	jobService jobs.JobService
+}
+
+// NewProfileHandlers crée un gestionnaire de profils de génération
+func NewProfileHandlers(jobService jobs.JobService) *ProfileHandlers {
+	return &ProfileHandlers{jobService: jobService}
+}
+
+// GetProfile retourne le profil de génération d'un cours
+// @Summary Profil de génération d'un cours
+// @Description Retourne les options de build par défaut appliquées aux jobs du cours.
+// @Tags Jobs
+// @Produce json
+// @Param course_id path string true "ID du cours" Format(uuid)
+// @Success 200 {object} models.GenerationProfile "Profil de génération"
+// @Failure 400 {object} models.ErrorResponse "ID de cours invalide"
+// @Failure 404 {object} models.ErrorResponse "Aucun profil pour ce cours"
+// @Failure 500 {object} models.ErrorResponse "Erreur interne du serveur"
+// @Router /courses/{course_id}/profile [get]
+func (h *ProfileHandlers) GetProfile(c *gin.Context) {
+	courseID := c.MustGet("validated_course_id").(uuid.UUID)
+
+	profile, err := h.jobService.GetGenerationProfile(c.Request.Context(), courseID)
+	if err != nil {
+		if jobs.IsProfileNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "generation profile not found", "code": "PROFILE_NOT_FOUND"})
+			return
+		}
+		log.Printf("Failed to get generation profile of course %s: %v", courseID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get generation profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// SetProfile crée ou remplace le profil de génération d'un cours
+// @Summary Définir le profil de génération d'un cours
+// @Description Enregistre les options de build par défaut du cours (fichier d'entrée, dossier
+// @Description de sortie, options Slidev dont `--base`, thèmes, options booléennes, sort des
+// @Description sources). Au traitement d'un job du cours, chaque option absente de la requête
+// @Description de génération est reprise du profil : la requête l'emporte, les `build_flags`
+// @Description sont fusionnés par nom et une option booléenne activée par le profil ne peut
+// @Description pas être désactivée par la requête. Le profil remplace entièrement le précédent ;
+// @Description ses champs sont validés comme ceux d'une requête de génération.
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Param course_id path string true "ID du cours" Format(uuid)
+// @Param profile body models.GenerationProfileRequest true "Options de build par défaut"
+// @Success 200 {object} models.GenerationProfile "Profil enregistré"
+// @Failure 400 {object} models.ErrorResponse "ID de cours ou options invalides"
+// @Failure 500 {object} models.ErrorResponse "Erreur interne du serveur"
+// @Router /courses/{course_id}/profile [put]
+func (h *ProfileHandlers) SetProfile(c *gin.Context) {
+	courseID := c.MustGet("validated_course_id").(uuid.UUID)
+	req := c.MustGet("validated_profile_request").(models.GenerationProfileRequest)
+
+	profile, err := h.jobService.SetGenerationProfile(c.Request.Context(), courseID, &req)
+	if err != nil {
+		log.Printf("Failed to save generation profile of course %s: %v", courseID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save generation profile"})
+		return
+	}
+
+	log.Printf("Generation profile of course %s saved", courseID)
+	c.JSON(http.StatusOK, profile)
+}
+
+// DeleteProfile supprime le profil de génération d'un cours
+// @Summary Supprimer le profil de génération d'un cours
+// @Description Les jobs suivants du cours sont construits avec les seules options de leur requête.
+// @Tags Jobs
+// @Param course_id path string true "ID du cours" Format(uuid)
+// @Success 204 "Profil supprimé"
+// @Failure 400 {object} models.ErrorResponse "ID de cours invalide"
+// @Failure 404 {object} models.ErrorResponse "Aucun profil pour ce cours"
+// @Failure 500 {object} models.ErrorResponse "Erreur interne du serveur"
+// @Router /courses/{course_id}/profile [delete]
+func (h *ProfileHandlers) DeleteProfile(c *gin.Context) {
+	courseID := c.MustGet("validated_course_id").(uuid.UUID)
+
+	deleted, err := h.jobService.DeleteGenerationProfile(c.Request.Context(), courseID)
+	if err != nil {
+		log.Printf("Failed to delete generation profile of course %s: %v", courseID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete generation profile"})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "generation profile not found", "code": "PROFILE_NOT_FOUND"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerationProfileEndpoints(t *testing.T) {
+	router := setupTestRouter(t)
+	courseID := uuid.New()
+	profileURL := "/api/v1/courses/" + courseID.String() + "/profile"
+
+	do := func(t *testing.T, method, url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("No profile yet", func(t *testing.T) {
+		w := do(t, http.MethodGet, profileURL, "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "PROFILE_NOT_FOUND")
+	})
+
+	t.Run("Set and get", func(t *testing.T) {
+		w := do(t, http.MethodPut, profileURL,
+			`{"entry_file":"cours/slides.md","build_flags":["--base=/cours/intro/"],"themes":["seriph"],"compress_results":true}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = do(t, http.MethodGet, profileURL, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var profile models.GenerationProfile
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &profile))
+		assert.Equal(t, courseID, profile.CourseID)
+		assert.Equal(t, "cours/slides.md", profile.EntryFile)
+		assert.Equal(t, models.StringSlice{"--base=/cours/intro/"}, profile.BuildFlags)
+		assert.Equal(t, models.StringSlice{"seriph"}, profile.Themes)
+		assert.True(t, profile.CompressResults)
+		assert.False(t, profile.CreatedAt.IsZero())
+	})
+
+	t.Run("Fields are validated like a generation request", func(t *testing.T) {
+		w := do(t, http.MethodPut, profileURL,
+			`{"entry_file":"../secret.md","build_flags":["--open"],"source_retention":"delete"}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		body := w.Body.String()
+		assert.Contains(t, body, "BUILD_FLAG_NOT_ALLOWED")
+		assert.Contains(t, body, "source_retention")
+		assert.Contains(t, body, "entry_file")
+
+		// Le profil enregistré n'est pas modifié
+		w = do(t, http.MethodGet, profileURL, "")
+		assert.Contains(t, w.Body.String(), "cours/slides.md")
+	})
+
+	t.Run("Invalid course ID", func(t *testing.T) {
+		w := do(t, http.MethodGet, "/api/v1/courses/not-a-uuid/profile", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, do(t, http.MethodDelete, profileURL, "").Code)
+		assert.Equal(t, http.StatusNotFound, do(t, http.MethodGet, profileURL, "").Code)
+		assert.Equal(t, http.StatusNotFound, do(t, http.MethodDelete, profileURL, "").Code)
+	})
+}
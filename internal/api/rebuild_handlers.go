@@ -0,0 +1,98 @@
+// internal/api/rebuild_handlers.go - Détection des changements de sources entre deux builds
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RebuildHandlers indique aux pipelines CI si un cours doit être reconstruit
+type RebuildHandlers struct {
+	jobService     jobs.JobService
+	storageService *storage.StorageService
+}
+
+// NewRebuildHandlers crée un nouveau gestionnaire de détection de changements
+func NewRebuildHandlers(jobService jobs.JobService, storageService *storage.StorageService) *RebuildHandlers {
+	return &RebuildHandlers{
+		jobService:     jobService,
+		storageService: storageService,
+	}
+}
+
+// ShouldRebuild compare l'empreinte des sources d'un cours à celle de son dernier build réussi
+// @Summary Détecter si les sources d'un cours ont changé
+// @Description Compare l'empreinte des sources fournie à celle enregistrée par le dernier build
+// @Description réussi du cours (hors builds publiés sous un `result_prefix`). L'empreinte est le
+// @Description SHA-256 des lignes `<chemin>\n<empreinte du fichier>\n` triées par chemin, au
+// @Description format `sha256:<hex>` : elle se calcule à partir des `checksums` retournés par
+// @Description l'upload des sources. `should_rebuild` vaut false seulement si l'empreinte est
+// @Description identique et que les résultats du cours existent toujours ; `reason` explique la décision.
+// @Description Seules les sources sont comparées : un changement des options de build (thème,
+// @Description `build_flags`, profil de génération) ne déclenche pas de rebuild.
+// @Tags Jobs
+// @Produce json
+// @Param course_id query string true "ID du cours" Format(uuid)
+// @Param source_hash query string true "Empreinte des sources (sha256:<hex>)"
+// @Success 200 {object} models.RebuildCheck "Décision de rebuild"
+// @Failure 400 {object} models.ErrorResponse "Paramètres invalides"
+// @Failure 500 {object} models.ErrorResponse "Erreur interne du serveur"
+// @Router /jobs/should-rebuild [get]
+func (h *RebuildHandlers) ShouldRebuild(c *gin.Context) {
+	courseID := c.MustGet("validated_course_id").(uuid.UUID)
+	sourceHash := c.MustGet("validated_source_hash").(string)
+	ctx := c.Request.Context()
+
+	lastBuild, err := h.jobService.GetLatestBuild(ctx, courseID, ClientIdentity(c))
+	if err != nil {
+		log.Printf("Failed to find last build of course %s: %v", courseID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	results, err := h.storageService.ListResults(ctx, courseID)
+	if err != nil {
+		log.Printf("Failed to list results of course %s: %v", courseID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	check := models.RebuildCheck{
+		CourseID:     courseID,
+		SourceHash:   sourceHash,
+		ResultsExist: len(results) > 0,
+	}
+
+	if lastBuild != nil && !canAccessJob(c, lastBuild) {
+		lastBuild = nil
+	}
+
+	if lastBuild != nil {
+		check.LastJobID = &lastBuild.ID
+		check.LastBuildAt = lastBuild.CompletedAt
+		check.LastSourceHash = lastBuild.SourceHash
+	}
+
+	switch {
+	case lastBuild == nil:
+		check.Reason = models.RebuildReasonNoPreviousBuild
+	case lastBuild.SourceHash == "":
+		check.Reason = models.RebuildReasonUnknownSourceHash
+	case lastBuild.SourceHash != sourceHash:
+		check.Reason = models.RebuildReasonSourcesChanged
+	case !check.ResultsExist:
+		check.Reason = models.RebuildReasonResultsMissing
+	default:
+		check.Reason = models.RebuildReasonUpToDate
+	}
+	check.ShouldRebuild = check.Reason != models.RebuildReasonUpToDate
+
+	c.JSON(http.StatusOK, check)
+}
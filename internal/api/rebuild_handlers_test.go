@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldRebuildEndpoint(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService))
+	ctx := context.Background()
+
+	sourceHash := storage.SourceHash(map[string]string{"slides.md": "sha256:a"})
+	changedHash := storage.SourceHash(map[string]string{"slides.md": "sha256:b"})
+
+	// Crée un build réussi du cours avec l'empreinte de ses sources
+	completeBuild := func(t *testing.T, courseID uuid.UUID, hash string) *models.GenerationJob {
+		job, err := jobService.CreateJob(ctx, &models.GenerationRequest{
+			JobID:      uuid.New(),
+			CourseID:   courseID,
+			SourcePath: "test/path",
+		})
+		require.NoError(t, err)
+
+		completed := time.Now().Add(-time.Hour)
+		job.Status = models.StatusCompleted
+		job.CompletedAt = &completed
+		job.SourceHash = hash
+		return job
+	}
+
+	check := func(t *testing.T, courseID uuid.UUID, hash string) models.RebuildCheck {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet,
+			"/api/v1/jobs/should-rebuild?course_id="+courseID.String()+"&source_hash="+hash, nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response models.RebuildCheck
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, courseID, response.CourseID)
+		return response
+	}
+
+	t.Run("No previous build", func(t *testing.T) {
+		response := check(t, uuid.New(), sourceHash)
+		assert.True(t, response.ShouldRebuild)
+		assert.Equal(t, models.RebuildReasonNoPreviousBuild, response.Reason)
+		assert.Nil(t, response.LastJobID)
+		assert.False(t, response.ResultsExist)
+	})
+
+	t.Run("Sources unchanged", func(t *testing.T) {
+		courseID := uuid.New()
+		job := completeBuild(t, courseID, sourceHash)
+		require.NoError(t, storageService.UploadResult(ctx, courseID, "index.html", strings.NewReader("<html></html>")))
+
+		response := check(t, courseID, sourceHash)
+		assert.False(t, response.ShouldRebuild)
+		assert.Equal(t, models.RebuildReasonUpToDate, response.Reason)
+		require.NotNil(t, response.LastJobID)
+		assert.Equal(t, job.ID, *response.LastJobID)
+		require.NotNil(t, response.LastBuildAt)
+		assert.WithinDuration(t, *job.CompletedAt, *response.LastBuildAt, time.Second)
+		assert.Equal(t, sourceHash, response.LastSourceHash)
+		assert.True(t, response.ResultsExist)
+
+		// Une empreinte en majuscules désigne les mêmes sources
+		assert.False(t, check(t, courseID, strings.ToUpper(sourceHash)).ShouldRebuild)
+	})
+
+	t.Run("Sources changed", func(t *testing.T) {
+		courseID := uuid.New()
+		completeBuild(t, courseID, sourceHash)
+		require.NoError(t, storageService.UploadResult(ctx, courseID, "index.html", strings.NewReader("<html></html>")))
+
+		response := check(t, courseID, changedHash)
+		assert.True(t, response.ShouldRebuild)
+		assert.Equal(t, models.RebuildReasonSourcesChanged, response.Reason)
+		assert.Equal(t, sourceHash, response.LastSourceHash)
+	})
+
+	t.Run("Results missing", func(t *testing.T) {
+		courseID := uuid.New()
+		completeBuild(t, courseID, sourceHash)
+
+		response := check(t, courseID, sourceHash)
+		assert.True(t, response.ShouldRebuild)
+		assert.Equal(t, models.RebuildReasonResultsMissing, response.Reason)
+		assert.False(t, response.ResultsExist)
+	})
+
+	t.Run("Build without recorded source hash", func(t *testing.T) {
+		courseID := uuid.New()
+		completeBuild(t, courseID, "")
+
+		response := check(t, courseID, sourceHash)
+		assert.True(t, response.ShouldRebuild)
+		assert.Equal(t, models.RebuildReasonUnknownSourceHash, response.Reason)
+	})
+
+	t.Run("Latest build published in the course results wins", func(t *testing.T) {
+		courseID := uuid.New()
+		completeBuild(t, courseID, changedHash)
+		latest := completeBuild(t, courseID, sourceHash)
+		recent := time.Now().Add(-time.Minute)
+		latest.CompletedAt = &recent
+		published := completeBuild(t, courseID, changedHash)
+		published.CompletedAt = &recent
+		published.ResultPrefix = "preview"
+		require.NoError(t, storageService.UploadResult(ctx, courseID, "index.html", strings.NewReader("<html></html>")))
+
+		response := check(t, courseID, sourceHash)
+		assert.False(t, response.ShouldRebuild)
+		require.NotNil(t, response.LastJobID)
+		assert.Equal(t, latest.ID, *response.LastJobID)
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		for _, query := range []string{
+			"?source_hash=" + sourceHash,
+			"?course_id=" + uuid.New().String(),
+			"?course_id=not-a-uuid&source_hash=" + sourceHash,
+			"?course_id=" + uuid.New().String() + "&source_hash=md5:abc",
+		} {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/should-rebuild"+query, nil))
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+			if strings.Contains(query, "md5") {
+				assert.Contains(t, w.Body.String(), "INVALID_SOURCE_HASH")
+			}
+		}
+	})
+}
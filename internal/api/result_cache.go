@@ -0,0 +1,52 @@
+// internal/api/result_cache.go - En-têtes Cache-Control des résultats servis
+package api
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// DefaultResultCacheControl est la politique de cache par défaut des résultats : courte,
+// un nouveau build remplaçant les fichiers sous la même URL
+const DefaultResultCacheControl = "max-age=60"
+
+// ResultCacheHashedAssets désigne, dans les règles de cache, les assets dont le nom
+// contient l'empreinte du contenu (assets/index-B2x_k9Qa.js) : une URL ne change jamais
+// de contenu, ils peuvent être gardés longtemps
+const ResultCacheHashedAssets = "hashed"
+
+// hashedAssetPattern reconnaît le nom d'un asset empreinté par Vite ([name]-[hash].ext)
+var hashedAssetPattern = regexp.MustCompile(`-[A-Za-z0-9_-]{8}\.[A-Za-z0-9]+$`)
+
+// ResultCachePolicy associe les résultats servis à leurs directives Cache-Control
+type ResultCachePolicy struct {
+	// Default s'applique aux fichiers sans règle (vide = DefaultResultCacheControl)
+	Default string
+	// Rules associe une extension (".html") ou ResultCacheHashedAssets à ses directives
+	Rules map[string]string
+}
+
+// CacheControl retourne les directives d'un fichier de résultat : règle des assets
+// empreintés, puis règle de l'extension, puis politique par défaut
+func (p *ResultCachePolicy) CacheControl(filePath string) string {
+	if p == nil {
+		return DefaultResultCacheControl
+	}
+
+	if directives, exists := p.Rules[ResultCacheHashedAssets]; exists && isHashedAsset(filePath) {
+		return directives
+	}
+	if directives, exists := p.Rules[strings.ToLower(path.Ext(filePath))]; exists {
+		return directives
+	}
+	if p.Default != "" {
+		return p.Default
+	}
+	return DefaultResultCacheControl
+}
+
+// isHashedAsset indique si un résultat est un asset empreinté du dossier assets/ de Vite
+func isHashedAsset(filePath string) bool {
+	return strings.HasPrefix(filePath, "assets/") && hashedAssetPattern.MatchString(path.Base(filePath))
+}
@@ -2,30 +2,76 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"time"
 
+	"github.com/Open-Course-Factory/ocf-worker/internal/config"
 	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
 	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
 	"github.com/Open-Course-Factory/ocf-worker/internal/validation"
 	"github.com/Open-Course-Factory/ocf-worker/internal/worker"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-
-	_ "github.com/Open-Course-Factory/ocf-worker/pkg/models"
 )
 
+// RouterConfig contient la configuration optionnelle du routeur
+type RouterConfig struct {
+	ValidationConfig *validation.ValidationConfig
+	// CallbackQueue livre les relances manuelles de callbacks (nil = relance manuelle indisponible)
+	CallbackQueue *jobs.CallbackQueue
+	// MaxActiveJobsPerClient limite les jobs pending + processing par client (0 = illimité)
+	MaxActiveJobsPerClient int
+	// PublicBaseURL préfixe les URLs de téléchargement des résultats (vide = chemins relatifs)
+	PublicBaseURL string
+	// StorageNamespacePerClient isole le stockage de chaque client authentifié dans son namespace
+	StorageNamespacePerClient bool
+	// CancelOnDisconnectWindow borne l'attente du démarrage des jobs créés avec cancel_on_disconnect
+	// (0 = DefaultCancelOnDisconnectWindow)
+	CancelOnDisconnectWindow time.Duration
+	// ThemePreviewRateLimit limite les aperçus de thèmes par minute et par client
+	// (0 = DefaultThemePreviewRateLimit)
+	ThemePreviewRateLimit int
+	// SLOTargets sont les objectifs de latence suivis par GET /jobs/slo (vide = jobs.DefaultSLOTargets)
+	SLOTargets []time.Duration
+	// SLOWindow est la fenêtre par défaut de GET /jobs/slo (0 = jobs.DefaultSLOWindow)
+	SLOWindow time.Duration
+	// ResultCachePolicy fixe le Cache-Control des résultats servis (nil = DefaultResultCacheControl)
+	ResultCachePolicy *ResultCachePolicy
+	// UnknownJobSourcesNotFound fait répondre 404 au listing des sources d'un job inconnu sans
+	// sources, au lieu d'une liste vide
+	UnknownJobSourcesNotFound bool
+	// AdminToken protège les endpoints d'administration (vide = endpoints désactivés)
+	AdminToken string
+	// ClientAPIKeys associe les clés d'API client (X-API-Key) aux noms des clients (vide = clients anonymes)
+	ClientAPIKeys map[string]string
+	// EffectiveConfig est la configuration exposée par GET /config (nil = route absente)
+	EffectiveConfig *config.EffectiveConfig
+}
+
 // SetupRouter configure le routeur standard (rétrocompatibilité)
 func SetupRouter(jobService jobs.JobService, storageService *storage.StorageService, workerPool *worker.WorkerPool) *gin.Engine {
+	return SetupRouterWithConfig(jobService, storageService, workerPool, &RouterConfig{})
+}
+
+// SetupRouterWithConfig configure le routeur avec une configuration personnalisée
+func SetupRouterWithConfig(jobService jobs.JobService, storageService *storage.StorageService, workerPool *worker.WorkerPool, routerConfig *RouterConfig) *gin.Engine {
+	validationConfig := routerConfig.ValidationConfig
+	if validationConfig == nil {
+		validationConfig = validation.DefaultValidationConfig()
+	}
+
 	r := gin.Default()
 
 	// Middleware pour CORS et logs
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -34,8 +80,9 @@ func SetupRouter(jobService jobs.JobService, storageService *storage.StorageServ
 		c.Next()
 	})
 
-	validationConfig := validation.DefaultValidationConfig()
-	apiValidator := validation.NewAPIValidator(validationConfig)
+	validationService := validation.NewValidationService(validationConfig)
+	apiValidator := validation.NewAPIValidatorWithService(validationService)
+	validationService.SetFilePathResolver(apiValidator)
 
 	r.Use(SecurityHeadersMiddleware())
 	r.Use(ValidationMiddleware(apiValidator))
@@ -43,43 +90,144 @@ func SetupRouter(jobService jobs.JobService, storageService *storage.StorageServ
 	r.Use(RateLimitMiddleware(60))
 
 	// Handlers
-	jobHandlers := NewHandlers(jobService)
-	storageHandlers := NewStorageHandlers(storageService)
+	jobHandlers := NewHandlers(jobService, workerPool, routerConfig.CancelOnDisconnectWindow)
+	callbackHandlers := NewCallbackHandlers(jobService, routerConfig.CallbackQueue)
+	storageHandlers := NewStorageHandlers(storageService, routerConfig.PublicBaseURL, validationConfig, routerConfig.ResultCachePolicy)
+	if routerConfig.UnknownJobSourcesNotFound {
+		storageHandlers.SetUnknownJobSourcesNotFound(jobService)
+	}
 	workerHandlers := NewWorkerHandlers(workerPool)
 	archiveHandlers := NewArchiveHandlers(storageService)
+	bundleHandlers := NewBundleHandlers(jobService, storageService)
+	diagnosisHandlers := NewDiagnosisHandlers(jobService, storageService)
+	logStreamHandlers := NewLogStreamHandlers(jobService, workerPool)
+	themeHandlers := NewThemeHandlers(workerPool)
+	sloHandlers := NewSLOHandlers(jobService, routerConfig.SLOTargets, routerConfig.SLOWindow)
+	profileHandlers := NewProfileHandlers(jobService)
+	rebuildHandlers := NewRebuildHandlers(jobService, storageService)
+
+	themePreviewRateLimit := routerConfig.ThemePreviewRateLimit
+	if themePreviewRateLimit <= 0 {
+		themePreviewRateLimit = DefaultThemePreviewRateLimit
+	}
 
 	api := r.Group("/api/v1")
+	api.Use(ClientAuthMiddleware(routerConfig.ClientAPIKeys))
+	if routerConfig.StorageNamespacePerClient {
+		api.Use(StorageNamespaceMiddleware())
+	}
 	{
 		// Routes principales
 		api.GET("/health", jobHandlers.Health)
-		// Routes des jobs
+		if routerConfig.EffectiveConfig != nil {
+			configHandlers := NewConfigHandlers(routerConfig.EffectiveConfig)
+			api.GET("/config", AdminTokenMiddleware(routerConfig.AdminToken), configHandlers.GetConfig)
+		}
+		// Routes des jobs : le quota et l'admission sont partagés entre soumissions unitaires et groupées
+		jobQuota := ClientJobQuotaMiddleware(jobService, routerConfig.MaxActiveJobsPerClient)
+		queueAdmission := QueueAdmissionMiddleware(workerPool)
 		api.POST("/generate",
+			jobQuota,
 			validation.ParseGenerationRequest(),
 			validation.ValidateRequest(validation.ValidateGenerationRequest),
+			queueAdmission,
 			jobHandlers.CreateJob)
+		api.POST("/generate/batch",
+			validation.ParseBatchGenerationRequest(),
+			validation.ValidateRequest(validation.ValidateBatchGenerationRequest),
+			jobQuota,
+			queueAdmission,
+			jobHandlers.CreateJobBatch)
+		api.POST("/generate/estimate",
+			validation.ValidateRequest(validation.ValidateEstimateRequest),
+			jobHandlers.EstimateBuild)
+		api.GET("/jobs/slo",
+			validation.ValidateRequest(validation.ValidateSLOParams),
+			sloHandlers.GetLatencySLO)
+		api.GET("/jobs/should-rebuild",
+			validation.ValidateRequest(validation.ValidateRebuildCheckParams),
+			rebuildHandlers.ShouldRebuild)
 		api.GET("/jobs/:id",
 			validation.ValidateRequest(validation.ValidateJobIDParam("id")),
 			jobHandlers.GetJobStatus)
+		api.POST("/jobs/:id/callback/redeliver",
+			validation.ValidateRequest(validation.ValidateJobIDParam("id")),
+			callbackHandlers.RedeliverCallback)
+		api.GET("/jobs/:id/bundle",
+			validation.ValidateRequest(validation.ValidateJobIDParam("id")),
+			bundleHandlers.DownloadJobBundle)
+		api.GET("/jobs/:id/diagnosis",
+			validation.ValidateRequest(validation.ValidateJobIDParam("id")),
+			diagnosisHandlers.GetJobDiagnosis)
+		api.GET("/jobs/:id/logs/stream",
+			validation.ValidateRequest(validation.ValidateJobIDParam("id")),
+			logStreamHandlers.StreamJobLogs)
 		api.GET("/jobs",
 			validation.ValidateRequest(validation.ValidateListJobsParams),
 			jobHandlers.ListJobs)
 
+		// Profils de génération : options de build par défaut des jobs d'un cours
+		api.GET("/courses/:course_id/profile",
+			validation.ValidateRequest(validation.ValidateCourseIDParam("course_id")),
+			profileHandlers.GetProfile)
+		api.PUT("/courses/:course_id/profile",
+			validation.ValidateRequest(
+				validation.ValidateCourseIDParam("course_id"),
+				validation.ValidateGenerationProfileRequest,
+			),
+			profileHandlers.SetProfile)
+		api.DELETE("/courses/:course_id/profile",
+			validation.ValidateRequest(validation.ValidateCourseIDParam("course_id")),
+			profileHandlers.DeleteProfile)
+
 		// Routes du storage
 		storage := api.Group("/storage")
 		{
 			storage.GET("/info", storageHandlers.GetStorageInfo)
+			storage.GET("/limits", storageHandlers.GetUploadLimits)
 
 			storage.POST("/jobs/:job_id/sources",
 				validation.ValidateRequest(
 					validation.ValidateJobIDParam("job_id"),
+					validation.ValidateOverwritePolicyParam,
+					validation.ValidateExpectedFileCountHeader,
+					validation.ValidateUploadSessionQuery,
 					validation.ValidateFileUpload,
 				),
 				storageHandlers.UploadJobSources)
 
+			storage.POST("/jobs/:job_id/sources/archive",
+				validation.ValidateRequest(
+					validation.ValidateJobIDParam("job_id"),
+					validation.ValidateOverwritePolicyParam,
+					validation.ValidateSourceArchiveUpload,
+				),
+				storageHandlers.UploadJobSourceArchive)
+
+			storage.POST("/jobs/:job_id/sources/upload-sessions",
+				validation.ValidateRequest(validation.ValidateJobIDParam("job_id")),
+				storageHandlers.CreateUploadSession)
+
+			storage.GET("/upload-sessions/:session_id",
+				validation.ValidateRequest(validation.ValidateUploadSessionIDParam("session_id")),
+				storageHandlers.GetUploadSession)
+
 			storage.GET("/jobs/:job_id/sources",
 				validation.ValidateRequest(validation.ValidateJobIDParam("job_id")),
 				storageHandlers.ListJobSources)
 
+			storage.GET("/jobs/:job_id/sources/summary",
+				validation.ValidateRequest(validation.ValidateJobIDParam("job_id")),
+				storageHandlers.GetJobSourceSummary)
+
+			storage.GET("/jobs/:job_id/sources/validate",
+				validation.ValidateRequest(validation.ValidateJobIDParam("job_id")),
+				storageHandlers.ValidateJobSources)
+
+			storage.POST("/jobs/:job_id/sources/repair",
+				validation.ValidateRequest(validation.ValidateJobIDParam("job_id")),
+				storageHandlers.RepairJobSources)
+
 			storage.GET("/jobs/:job_id/sources/:filename",
 				validation.ValidateRequest(
 					validation.ValidateJobIDParam("job_id"),
@@ -87,6 +235,19 @@ func SetupRouter(jobService jobs.JobService, storageService *storage.StorageServ
 				),
 				storageHandlers.DownloadJobSource)
 
+			storage.GET("/jobs/:job_id/results",
+				validation.ValidateRequest(validation.ValidateJobIDParam("job_id")),
+				JobResultsMiddleware(jobService),
+				storageHandlers.ListJobResults)
+
+			storage.GET("/jobs/:job_id/results/*filepath",
+				validation.ValidateRequest(
+					validation.ValidateJobIDParam("job_id"),
+					validation.ValidateViewPathParam("filepath"),
+				),
+				JobResultsMiddleware(jobService),
+				storageHandlers.DownloadJobResult)
+
 			storage.GET("/courses/:course_id/results",
 				validation.ValidateRequest(validation.ValidateCourseIDParam("course_id")),
 				storageHandlers.ListResults)
@@ -98,15 +259,55 @@ func SetupRouter(jobService jobs.JobService, storageService *storage.StorageServ
 				),
 				storageHandlers.DownloadResult)
 
+			storage.GET("/courses/:course_id/view/*filepath",
+				validation.ValidateRequest(
+					validation.ValidateCourseIDParam("course_id"),
+					validation.ValidateViewPathParam("filepath"),
+				),
+				storageHandlers.ViewResult)
+
+			storage.GET("/courses/:course_id/manifest",
+				validation.ValidateRequest(validation.ValidateCourseIDParam("course_id")),
+				storageHandlers.GetResultManifest)
+
+			storage.GET("/courses/:course_id/usage",
+				validation.ValidateRequest(validation.ValidateCourseIDParam("course_id")),
+				storageHandlers.GetCourseResultUsage)
+
+			storage.GET("/courses/:course_id/diff",
+				validation.ValidateRequest(
+					validation.ValidateCourseIDParam("course_id"),
+					validation.ValidateResultDiffParams,
+				),
+				storageHandlers.GetResultDiff)
+
 			storage.GET("/jobs/:job_id/logs",
-				validation.ValidateRequest(validation.ValidateJobIDParam("job_id")),
+				validation.ValidateRequest(
+					validation.ValidateJobIDParam("job_id"),
+					validation.ValidateLogLevelParam,
+					validation.ValidateLogFormatParam,
+				),
 				storageHandlers.GetJobLogs)
 		}
 
+		// Aperçu des thèmes : chaque génération coûte un build
+		api.POST("/themes/:theme/preview",
+			RateLimitMiddleware(themePreviewRateLimit),
+			validation.ValidateRequest(validation.ValidateThemePreviewParams),
+			themeHandlers.PreviewTheme)
+
 		workerAPI := api.Group("/worker")
 		{
 			workerAPI.GET("/stats", workerHandlers.GetWorkerStats)
 			workerAPI.GET("/health", workerHandlers.GetWorkerHealth)
+			workerAPI.POST("/maintenance",
+				AdminTokenMiddleware(routerConfig.AdminToken),
+				validation.ValidateRequest(validation.ValidateMaintenanceRequest),
+				workerHandlers.SetMaintenance)
+			workerAPI.GET("/queue",
+				AdminTokenMiddleware(routerConfig.AdminToken),
+				validation.ValidateRequest(validation.ValidatePaginationParams),
+				workerHandlers.GetQueue)
 
 			// Routes avec validation
 			workerAPI.GET("/workspaces",
@@ -121,7 +322,13 @@ func SetupRouter(jobService jobs.JobService, storageService *storage.StorageServ
 				validation.ValidateRequest(validation.ValidateJobIDParam("job_id")),
 				workerHandlers.CleanupWorkspace)
 
+			workerAPI.DELETE("/workspaces/:job_id/node_modules",
+				AdminTokenMiddleware(routerConfig.AdminToken),
+				validation.ValidateRequest(validation.ValidateJobIDParam("job_id")),
+				workerHandlers.PurgeWorkspaceNodeModules)
+
 			workerAPI.POST("/workspaces/cleanup",
+				AdminTokenMiddleware(routerConfig.AdminToken),
 				validation.ValidateRequest(validation.ValidateWorkspaceCleanupParams),
 				workerHandlers.CleanupOldWorkspaces)
 		}
@@ -186,6 +393,11 @@ func (h *WorkerHandlers) GetWorkerStats(c *gin.Context) {
 // @Description
 // @Description Vérifie que le pool de workers fonctionne correctement,
 // @Description que les workers ne sont pas bloqués, et que la queue n'est pas saturée.
+// @Description
+// @Description En maintenance, `maintenance` détaille le drainage (`drained` passe à true quand
+// @Description plus aucun job n'est en cours) et `issues` le signale. `status` vaut `maintenance`
+// @Description (code 200) si l'instance n'a pas d'autre problème ; sinon il reste `degraded` ou
+// @Description `unhealthy`, la maintenance ne masquant pas un problème réel.
 // @Tags Worker
 // @Accept json
 // @Produce json
@@ -195,7 +407,42 @@ func (h *WorkerHandlers) GetWorkerStats(c *gin.Context) {
 // @Router /worker/health [get]
 func (h *WorkerHandlers) GetWorkerHealth(c *gin.Context) {
 	stats := h.workerPool.GetStats()
+	maintenance := h.workerPool.Maintenance()
+	status, issues := workerHealthStatus(stats, maintenance)
 
+	response := gin.H{
+		"status": status,
+		"worker_pool": gin.H{
+			"running":      stats.Running,
+			"worker_count": stats.WorkerCount,
+			"queue_size":   stats.QueueSize,
+			"queue_usage":  float64(stats.QueueSize) / float64(stats.QueueCapacity) * 100,
+			"backlog_size": stats.BacklogSize,
+		},
+	}
+
+	if len(issues) > 0 {
+		response["issues"] = issues
+	}
+	if maintenance.Enabled {
+		response["maintenance"] = maintenance
+	}
+
+	statusCode := http.StatusOK
+	switch status {
+	case "unhealthy":
+		statusCode = http.StatusServiceUnavailable
+	case "degraded":
+		statusCode = http.StatusOK // Toujours 200 mais avec des warnings
+	}
+
+	c.JSON(statusCode, response)
+}
+
+// workerHealthStatus détermine l'état de santé du pool et ses problèmes. La maintenance est
+// volontaire : elle n'est pas une erreur et ne masque pas un problème réel, seule une instance
+// sans problème est signalée "maintenance" (le détail du drainage est toujours retourné).
+func workerHealthStatus(stats worker.PoolStats, maintenance models.MaintenanceStatus) (string, []string) {
 	status := "healthy"
 	issues := []string{}
 
@@ -209,6 +456,11 @@ func (h *WorkerHandlers) GetWorkerHealth(c *gin.Context) {
 		issues = append(issues, "job queue is full")
 	}
 
+	if stats.BacklogCapacity > 0 && stats.BacklogSize >= stats.BacklogCapacity {
+		status = "degraded"
+		issues = append(issues, "pending job backlog is full")
+	}
+
 	// Vérifier si des workers sont bloqués
 	stuckWorkers := 0
 	for _, worker := range stats.Workers {
@@ -222,34 +474,71 @@ func (h *WorkerHandlers) GetWorkerHealth(c *gin.Context) {
 		issues = append(issues, fmt.Sprintf("%d workers stopped", stuckWorkers))
 	}
 
-	response := gin.H{
-		"status": status,
-		"worker_pool": gin.H{
-			"running":      stats.Running,
-			"worker_count": stats.WorkerCount,
-			"queue_size":   stats.QueueSize,
-			"queue_usage":  float64(stats.QueueSize) / float64(stats.QueueCapacity) * 100,
-		},
+	if maintenance.Enabled {
+		issues = append(issues, "maintenance mode enabled")
+		if status == "healthy" {
+			status = "maintenance"
+		}
 	}
 
-	if len(issues) > 0 {
-		response["issues"] = issues
-	}
+	return status, issues
+}
 
-	statusCode := http.StatusOK
-	switch status {
-	case "unhealthy":
-		statusCode = http.StatusServiceUnavailable
-	case "degraded":
-		statusCode = http.StatusOK // Toujours 200 mais avec des warnings
-	}
+// SetMaintenance active ou désactive le mode maintenance de l'instance
+// @Summary Mode maintenance
+// @Description Met l'instance en maintenance avant une mise à jour, sans interrompre les builds :
+// @Description les nouvelles soumissions (`POST /generate`, `POST /generate/batch`) sont refusées
+// @Description en 503 avec le code `MAINTENANCE` et le message configuré, aucun job n'est plus
+// @Description sorti de la file, et les jobs en cours se terminent. Les jobs en attente restent
+// @Description pending jusqu'à la sortie du mode maintenance.
+// @Description
+// @Description L'état est gardé en mémoire jusqu'à l'arrêt du processus. Réservé aux porteurs
+// @Description du jeton `ADMIN_TOKEN` (`Authorization: Bearer`).
+// @Tags Worker
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Jeton d'administration" example(Bearer my-admin-token)
+// @Param request body models.MaintenanceRequest true "Activation et message du mode maintenance"
+// @Success 200 {object} models.MaintenanceStatus "État du mode maintenance"
+// @Failure 400 {object} models.ErrorResponse "Requête invalide (enabled manquant, message trop long)"
+// @Failure 401 {object} models.ErrorResponse "Jeton d'administration absent ou invalide"
+// @Failure 403 {object} models.ErrorResponse "Endpoints d'administration désactivés"
+// @Router /worker/maintenance [post]
+func (h *WorkerHandlers) SetMaintenance(c *gin.Context) {
+	req := c.MustGet("validated_maintenance_request").(models.MaintenanceRequest)
+	c.JSON(http.StatusOK, h.workerPool.SetMaintenance(*req.Enabled, req.Message))
+}
 
-	c.JSON(statusCode, response)
+// GetQueue retourne le contenu de la file de jobs de l'instance
+// @Summary Contenu de la file de jobs
+// @Description Liste les jobs de la file en mémoire de l'instance, pas encore pris par un worker,
+// @Description dans l'ordre de mise en file (`position`), avec leur cours et leur date de mise en file.
+// @Description La file n'est pas consommée par la lecture.
+// @Description
+// @Description `backlog` compte les jobs pending en base qui n'ont pas encore trouvé de place dans
+// @Description la file (au dernier relevé). Réservé aux porteurs du jeton `ADMIN_TOKEN`
+// @Description (`Authorization: Bearer`).
+// @Tags Worker
+// @Produce json
+// @Param Authorization header string true "Jeton d'administration" example(Bearer my-admin-token)
+// @Param limit query integer false "Nombre maximum de jobs retournés" default(100) minimum(1) maximum(1000)
+// @Param offset query integer false "Décalage pour la pagination" default(0) minimum(0)
+// @Success 200 {object} models.QueueSnapshot "Jobs en file"
+// @Failure 400 {object} models.ErrorResponse "Paramètres de pagination invalides"
+// @Failure 401 {object} models.ErrorResponse "Jeton d'administration absent ou invalide"
+// @Failure 403 {object} models.ErrorResponse "Endpoints d'administration désactivés"
+// @Router /worker/queue [get]
+func (h *WorkerHandlers) GetQueue(c *gin.Context) {
+	pagination := c.MustGet("validated_pagination").(validation.PaginationParams)
+	c.JSON(http.StatusOK, h.workerPool.QueueSnapshot(pagination.Offset, pagination.Limit))
 }
 
-// ListWorkspaces liste tous les workspaces actifs
-// @Summary Lister les workspaces actifs
-// @Description Liste tous les workspaces de jobs en cours ou récents
+// ListWorkspaces liste les workspaces de l'instance
+// @Summary Lister les workspaces
+// @Description Liste les workspaces de jobs de tous les répertoires de base de l'instance, du
+// @Description plus récemment modifié au plus ancien. `status` vaut `active` si un worker construit
+// @Description le job, `completed` si le workspace contient un build (dist/), `idle` sinon ;
+// @Description `summary` porte sur l'ensemble des workspaces, quel que soit le filtre.
 // @Description
 // @Description Permet de surveiller l'utilisation des ressources et identifier
 // @Description les workspaces qui peuvent nécessiter un nettoyage.
@@ -257,19 +546,107 @@ func (h *WorkerHandlers) GetWorkerHealth(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param status query string false "Filtrer par statut" Enums(active,idle,completed)
-// @Param limit query integer false "Nombre maximum de résultats" default(50) minimum(1) maximum(500)
+// @Param limit query integer false "Nombre maximum de résultats" default(100) minimum(0) maximum(1000)
 // @Param offset query integer false "Décalage pour la pagination" default(0) minimum(0)
 // @Success 200 {object} models.WorkspaceListResponse "Liste des workspaces"
 // @Failure 400 {object} models.ErrorResponse "Paramètres de requête invalides"
 // @Failure 500 {object} models.ErrorResponse "Erreur interne du serveur"
 // @Router /worker/workspaces [get]
 func (h *WorkerHandlers) ListWorkspaces(c *gin.Context) {
-	// Cette fonctionnalité nécessiterait un workspace manager global
-	// Pour l'instant, on retourne une réponse basique
-	c.JSON(http.StatusOK, gin.H{
-		"message":    "Workspace listing not yet implemented",
-		"workspaces": []gin.H{},
+	params := c.MustGet("validated_workspace_list_params").(validation.WorkspaceListParams)
+
+	infos, err := h.workerPool.ListWorkspaces()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModifiedAt.After(infos[j].ModifiedAt)
 	})
+
+	activeJobs := make(map[string]bool)
+	for _, workerStats := range h.workerPool.GetStats().Workers {
+		if workerStats.CurrentJobID != "" {
+			activeJobs[workerStats.CurrentJobID] = true
+		}
+	}
+
+	var summary models.WorkspacesSummary
+	var totalFiles int
+	matching := []models.WorkspaceInfo{}
+	for _, info := range infos {
+		status := workspaceStatus(info, activeJobs[info.JobID])
+
+		summary.TotalWorkspaces++
+		summary.TotalSizeBytes += info.SizeBytes
+		totalFiles += info.FileCount
+		if status == "active" {
+			summary.ActiveWorkspaces++
+		} else {
+			summary.IdleWorkspaces++
+		}
+
+		if params.Status == "" || params.Status == status {
+			workspace := toWorkspaceModel(info)
+			workspace.Status = status
+			matching = append(matching, workspace)
+		}
+	}
+	summary.TotalSizeMB = int(summary.TotalSizeBytes / (1024 * 1024))
+	if summary.TotalWorkspaces > 0 {
+		summary.AverageFilesPerWS = totalFiles / summary.TotalWorkspaces
+	}
+
+	pagination := params.Pagination
+	// Une limite nulle retourne tous les workspaces à partir de offset
+	start, end := pagination.Offset, len(matching)
+	if start > len(matching) {
+		start = len(matching)
+	}
+	if pagination.Limit > 0 && start+pagination.Limit < end {
+		end = start + pagination.Limit
+	}
+
+	response := models.WorkspaceListResponse{
+		Workspaces: matching[start:end],
+		Count:      end - start,
+		TotalCount: len(matching),
+		Summary:    summary,
+	}
+	if pagination.Limit > 0 {
+		response.Page = pagination.Offset/pagination.Limit + 1
+		response.PageSize = pagination.Limit
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// workspaceStatus retourne l'état d'un workspace : active pendant le build de son job,
+// completed une fois un build produit, idle sinon
+func workspaceStatus(info worker.WorkspaceInfo, building bool) string {
+	switch {
+	case building:
+		return "active"
+	case info.DistExists:
+		return "completed"
+	default:
+		return "idle"
+	}
+}
+
+// toWorkspaceModel convertit les informations d'un workspace au format de l'API
+func toWorkspaceModel(info worker.WorkspaceInfo) models.WorkspaceInfo {
+	return models.WorkspaceInfo{
+		JobID:         info.JobID,
+		Path:          info.Path,
+		DistPath:      info.DistPath,
+		Exists:        info.Exists,
+		SizeBytes:     info.SizeBytes,
+		FileCount:     info.FileCount,
+		Files:         info.Files,
+		DistExists:    info.DistExists,
+		DistFileCount: info.DistFileCount,
+		DistFiles:     info.DistFiles,
+	}
 }
 
 // GetWorkspaceInfo retourne les informations détaillées d'un workspace
@@ -291,11 +668,57 @@ func (h *WorkerHandlers) GetWorkspaceInfo(c *gin.Context) {
 	// Récupérer l'ID déjà validé
 	jobID := c.MustGet("validated_job_id").(uuid.UUID)
 
-	// Plus de validation inline - directement la logique métier
-	c.JSON(http.StatusOK, gin.H{
-		"job_id":  jobID.String(),
-		"message": "Workspace info not yet implemented",
-	})
+	info, err := h.workerPool.GetWorkspaceInfo(jobID)
+	if errors.Is(err, worker.ErrWorkspaceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "workspace not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, buildWorkspaceInfoResponse(info))
+}
+
+// buildWorkspaceInfoResponse construit la réponse détaillée d'un workspace
+func buildWorkspaceInfoResponse(info worker.WorkspaceInfo) models.WorkspaceInfoResponse {
+	const mb = 1024 * 1024
+
+	// Les dépendances ne sont comptées dans SizeBytes que si la configuration le demande
+	otherBytes := info.SizeBytes - info.SourceBytes - info.DistBytes
+
+	status := workspaceStatus(info, false)
+
+	return models.WorkspaceInfoResponse{
+		Workspace: toWorkspaceModel(info),
+		Usage: models.WorkspaceUsage{
+			DiskUsage: models.StorageUsage{
+				TotalBytes:      info.SizeBytes,
+				TotalMB:         float64(info.SizeBytes) / mb,
+				SourceBytes:     info.SourceBytes,
+				SourceMB:        float64(info.SourceBytes) / mb,
+				DistBytes:       info.DistBytes,
+				DistMB:          float64(info.DistBytes) / mb,
+				OtherBytes:      otherBytes,
+				OtherMB:         float64(otherBytes) / mb,
+				DependencyBytes: info.DependencyBytes,
+				DependencyMB:    float64(info.DependencyBytes) / mb,
+			},
+			FileDistribution: models.FileDistribution{
+				TotalFiles:  info.FileCount,
+				SourceFiles: info.FileCount - info.DistFileCount,
+				DistFiles:   info.DistFileCount,
+			},
+			BuildArtifacts: models.BuildArtifacts{
+				HasDist: info.DistExists,
+			},
+		},
+		Activity: models.WorkspaceActivity{
+			Status:       status,
+			LastActivity: info.ModifiedAt,
+			AgeDuration:  time.Since(info.ModifiedAt).Round(time.Second).String(),
+		},
+	}
 }
 
 // CleanupWorkspace supprime un workspace spécifique
@@ -325,28 +748,77 @@ func (h *WorkerHandlers) CleanupWorkspace(c *gin.Context) {
 	})
 }
 
+// PurgeWorkspaceNodeModules supprime node_modules d'un workspace conservé
+// @Summary Purger les dépendances d'un workspace
+// @Description Supprime uniquement `node_modules` d'un workspace pour libérer de l'espace disque
+// @Description
+// @Description Les sources, le répertoire dist et les logs sont conservés pour le debug.
+// @Description Réservé aux porteurs du jeton `ADMIN_TOKEN` (`Authorization: Bearer`).
+// @Tags Worker
+// @Accept json
+// @Produce json
+// @Param job_id path string true "ID du job associé au workspace" Format(uuid)
+// @Success 200 {object} models.WorkspacePurgeResponse "Dépendances supprimées"
+// @Failure 400 {object} models.ErrorResponse "ID du job invalide"
+// @Failure 401 {object} models.ErrorResponse "Jeton d'administration absent ou invalide"
+// @Failure 403 {object} models.ErrorResponse "Endpoints d'administration désactivés"
+// @Failure 404 {object} models.ErrorResponse "Workspace non trouvé"
+// @Failure 500 {object} models.ErrorResponse "Erreur de suppression"
+// @Router /worker/workspaces/{job_id}/node_modules [delete]
+func (h *WorkerHandlers) PurgeWorkspaceNodeModules(c *gin.Context) {
+	jobID := c.MustGet("validated_job_id").(uuid.UUID)
+
+	freed, err := h.workerPool.PurgeWorkspaceNodeModules(jobID)
+	if errors.Is(err, worker.ErrWorkspaceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "workspace not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.WorkspacePurgeResponse{
+		JobID:        jobID.String(),
+		Directory:    "node_modules",
+		BytesFreed:   freed,
+		BytesFreedMB: float64(freed) / (1024 * 1024),
+	})
+}
+
 // CleanupOldWorkspaces supprime les workspaces anciens
 // @Summary Nettoyage automatique des anciens workspaces
 // @Description Supprime tous les workspaces plus anciens que l'âge spécifié
 // @Description
 // @Description Opération de maintenance pour libérer l'espace disque.
-// @Description Par défaut, supprime les workspaces de plus de 24 heures.
+// @Description Par défaut, supprime les workspaces de plus de 24 heures. Les workspaces des
+// @Description jobs en file ou en cours sont conservés quel que soit leur âge
+// @Description (`WORKSPACE_CLEANUP_PROTECTED_STATUSES`). Réservé aux porteurs du jeton
+// @Description `ADMIN_TOKEN` (`Authorization: Bearer`).
 // @Tags Worker
 // @Accept json
 // @Produce json
+// @Param Authorization header string true "Jeton d'administration" example(Bearer my-admin-token)
 // @Param max_age_hours query integer false "Âge maximum en heures" default(24) minimum(1) maximum(8760)
 // @Success 200 {object} models.WorkspaceCleanupBatchResponse "Nettoyage terminé"
 // @Failure 400 {object} models.ErrorResponse "Paramètres invalides"
+// @Failure 401 {object} models.ErrorResponse "Jeton d'administration absent ou invalide"
+// @Failure 403 {object} models.ErrorResponse "Endpoints d'administration désactivés"
 // @Failure 500 {object} models.ErrorResponse "Erreur de nettoyage"
 // @Router /worker/workspaces/cleanup [post]
 func (h *WorkerHandlers) CleanupOldWorkspaces(c *gin.Context) {
 	// Récupérer les paramètres déjà validés
 	params := c.MustGet("validated_workspace_cleanup_params").(validation.WorkspaceCleanupParams)
 
-	// Plus de validation inline - directement la logique métier
+	maxAge := time.Duration(params.MaxAgeHours) * time.Hour
+	cleaned, err := h.workerPool.CleanupOldWorkspaces(c.Request.Context(), maxAge)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message":       "Old workspace cleanup simulated (not yet implemented)",
+		"message":       "Old workspaces cleaned up",
 		"max_age_hours": params.MaxAgeHours,
-		"cleaned_count": 0,
+		"cleaned_count": cleaned,
 	})
 }
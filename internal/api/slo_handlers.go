@@ -0,0 +1,74 @@
+// internal/api/slo_handlers.go - Suivi de la latence des jobs par rapport aux objectifs
+package api
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SLOHandlers gère le suivi de la latence des jobs
+type SLOHandlers struct {
+	jobService jobs.JobService
+	targets    []time.Duration
+	window     time.Duration
+}
+
+// NewSLOHandlers crée un gestionnaire de suivi de latence avec les objectifs et la fenêtre
+// par défaut configurés (vides = jobs.DefaultSLOTargets et jobs.DefaultSLOWindow)
+func NewSLOHandlers(jobService jobs.JobService, targets []time.Duration, window time.Duration) *SLOHandlers {
+	if len(targets) == 0 {
+		targets = jobs.DefaultSLOTargets()
+	}
+	if window <= 0 {
+		window = jobs.DefaultSLOWindow
+	}
+
+	return &SLOHandlers{
+		jobService: jobService,
+		targets:    targets,
+		window:     window,
+	}
+}
+
+// GetLatencySLO retourne la latence des jobs terminés sur une fenêtre glissante
+// @Summary Suivi de la latence des jobs (SLO)
+// @Description Mesure la latence des jobs terminés sur une fenêtre glissante (24h par défaut) :
+// @Description de la création du job à sa fin, attente en file comprise. `latency_seconds`
+// @Description donne les percentiles p50, p95 et p99 des jobs réussis ; `targets` donne, pour
+// @Description chaque objectif configuré (`SLO_TARGETS`), la part des jobs terminés ayant
+// @Description réussi en moins de cette durée. Les jobs en échec ou en timeout comptent comme
+// @Description hors objectif.
+// @Tags Jobs
+// @Produce json
+// @Param window query string false "Fenêtre glissante, durée Go (défaut: SLO_WINDOW, max 720h)" example(168h)
+// @Param course_id query string false "Limiter aux jobs d'un cours" Format(uuid)
+// @Success 200 {object} models.LatencySLO "Latence des jobs"
+// @Failure 400 {object} models.ErrorResponse "Paramètres invalides"
+// @Failure 500 {object} models.ErrorResponse "Erreur interne du serveur"
+// @Router /jobs/slo [get]
+func (h *SLOHandlers) GetLatencySLO(c *gin.Context) {
+	window := c.MustGet("validated_slo_window").(time.Duration)
+	if window <= 0 {
+		window = h.window
+	}
+	courseID := c.MustGet("validated_course_id").(*uuid.UUID)
+
+	slo, err := h.jobService.GetLatencySLO(c.Request.Context(), jobs.SLOFilters{
+		Window:   window,
+		CourseID: courseID,
+		Targets:  h.targets,
+	})
+	if err != nil {
+		log.Printf("Failed to compute job latency SLO: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, slo)
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencySLOEndpoint(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouterWithConfig(jobService, storageService, createMockWorkerPool(jobService, storageService), &RouterConfig{
+		SLOTargets: []time.Duration{time.Minute, 5 * time.Minute},
+	})
+	ctx := context.Background()
+	courseID := uuid.New()
+
+	// Jobs terminés il y a une heure : 30s et 3min de latence pour le cours, 10min pour un autre
+	for _, tc := range []struct {
+		course  uuid.UUID
+		latency time.Duration
+	}{{courseID, 30 * time.Second}, {courseID, 3 * time.Minute}, {uuid.New(), 10 * time.Minute}} {
+		job, err := jobService.CreateJob(ctx, &models.GenerationRequest{
+			JobID:      uuid.New(),
+			CourseID:   tc.course,
+			SourcePath: "test/path",
+		})
+		require.NoError(t, err)
+
+		completed := time.Now().Add(-time.Hour)
+		job.Status = models.StatusCompleted
+		job.CreatedAt = completed.Add(-tc.latency)
+		job.CompletedAt = &completed
+	}
+
+	get := func(t *testing.T, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/slo"+query, nil))
+		return w
+	}
+
+	t.Run("All courses", func(t *testing.T) {
+		w := get(t, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response models.LatencySLO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "24h0m0s", response.Window)
+		assert.Equal(t, int64(3), response.FinishedJobs)
+		require.Len(t, response.Targets, 2)
+		assert.Equal(t, "1m0s", response.Targets[0].Target)
+		assert.Equal(t, int64(1), response.Targets[0].WithinTarget)
+		assert.Equal(t, int64(2), response.Targets[1].WithinTarget)
+		assert.Equal(t, 0.6667, response.Targets[1].Ratio)
+	})
+
+	t.Run("Single course", func(t *testing.T) {
+		w := get(t, "?course_id="+courseID.String()+"&window=2h")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response models.LatencySLO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "2h0m0s", response.Window)
+		assert.Equal(t, int64(2), response.FinishedJobs)
+		assert.Equal(t, 1.0, response.Targets[1].Ratio)
+		assert.InDelta(t, 105, response.Latency.P50, 0.1)
+	})
+
+	t.Run("Window excluding every job", func(t *testing.T) {
+		w := get(t, "?window=30m")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response models.LatencySLO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Zero(t, response.FinishedJobs)
+	})
+
+	for name, query := range map[string]string{
+		"Invalid window":  "?window=yesterday",
+		"Negative window": "?window=-1h",
+		"Window too long": "?window=1000h",
+		"Invalid course":  "?course_id=not-a-uuid",
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := get(t, query)
+			assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		})
+	}
+}
@@ -1,16 +1,26 @@
 package api
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
 	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
 	"github.com/Open-Course-Factory/ocf-worker/internal/validation"
-	_ "github.com/Open-Course-Factory/ocf-worker/pkg/models"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -18,14 +28,37 @@ import (
 
 type StorageHandlers struct {
 	storageService *storage.StorageService
+	publicBaseURL  string          // Préfixe des URLs de résultats (vide = chemins relatifs)
+	uploadSessions *UploadSessions // Avancement des uploads suivis par une session
+
+	validationConfig *validation.ValidationConfig // Limites appliquées à l'upload des sources
+	cachePolicy      *ResultCachePolicy           // Cache-Control des résultats servis
+
+	// sourcesJobService distingue un job inconnu d'un job sans sources au listing
+	// (nil = liste vide dans les deux cas)
+	sourcesJobService jobs.JobService
 }
 
-func NewStorageHandlers(storageService *storage.StorageService) *StorageHandlers {
+func NewStorageHandlers(storageService *storage.StorageService, publicBaseURL string, validationConfig *validation.ValidationConfig, cachePolicy *ResultCachePolicy) *StorageHandlers {
+	if validationConfig == nil {
+		validationConfig = validation.DefaultValidationConfig()
+	}
+
 	return &StorageHandlers{
-		storageService: storageService,
+		storageService:   storageService,
+		publicBaseURL:    strings.TrimSuffix(publicBaseURL, "/"),
+		uploadSessions:   NewUploadSessions(DefaultUploadSessionTTL),
+		validationConfig: validationConfig,
+		cachePolicy:      cachePolicy,
 	}
 }
 
+// SetUnknownJobSourcesNotFound fait répondre 404 au listing des sources d'un job inconnu
+// qui n'en a aucune ; un job existant sans sources (supprimées) garde une liste vide
+func (h *StorageHandlers) SetUnknownJobSourcesNotFound(jobService jobs.JobService) {
+	h.sourcesJobService = jobService
+}
+
 // UploadJobSources upload des fichiers sources pour un job
 // @Summary Upload des fichiers sources
 // @Description Upload des fichiers sources (slides.md, CSS, images, etc.) pour un job de génération
@@ -43,8 +76,13 @@ func NewStorageHandlers(storageService *storage.StorageService) *StorageHandlers
 // @Produce json
 // @Param job_id path string true "ID du job" Format(uuid)
 // @Param files formData file true "Fichiers à uploader (multiple autorisé)"
-// @Success 201 {object} models.FileUploadResponse "Fichiers uploadés avec succès"
-// @Failure 400 {object} models.ErrorResponse "Erreur de validation (taille, type, etc.)"
+// @Param overwrite query string false "Traitement des fichiers déjà présents" Enums(replace, skip-existing, error-on-existing) default(replace)
+// @Param X-Expected-File-Count header int false "Nombre de fichiers envoyés : l'upload est refusé (FILE_COUNT_MISMATCH) si le serveur en reçoit un autre nombre"
+// @Param upload_session query string false "Session de suivi de l'avancement (POST .../sources/upload-sessions)" Format(uuid)
+// @Success 201 {object} models.FileUploadResponse "Fichiers uploadés avec succès, avec leur empreinte SHA-256"
+// @Failure 400 {object} models.ErrorResponse "Erreur de validation (taille, type, etc.), upload tronqué ou session d'un autre job"
+// @Failure 404 {object} models.ErrorResponse "Session d'upload inconnue ou expirée"
+// @Failure 409 {object} models.ErrorResponse "Fichiers déjà présents (overwrite=error-on-existing) ou session déjà utilisée"
 // @Failure 413 {object} models.ErrorResponse "Fichier trop volumineux"
 // @Failure 500 {object} models.ErrorResponse "Erreur de stockage"
 // @Router /storage/jobs/{job_id}/sources [post]
@@ -52,6 +90,18 @@ func (h *StorageHandlers) UploadJobSources(c *gin.Context) {
 	// Récupérer les données déjà validées
 	jobID := c.MustGet("validated_job_id").(uuid.UUID)
 	files := c.MustGet("validated_files").([]*multipart.FileHeader)
+	policy := c.MustGet("validated_overwrite_policy").(models.OverwritePolicy)
+	expectedCount := c.MustGet("validated_expected_file_count").(int)
+	sessionID := c.MustGet("validated_upload_session_id").(uuid.UUID)
+
+	// Suivi de l'avancement : la session se termine avec le statut de la réponse
+	if sessionID != uuid.Nil {
+		if err := h.uploadSessions.Claim(sessionID, jobID); err != nil {
+			c.JSON(uploadSessionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		defer func() { h.uploadSessions.Finish(sessionID, c.Writer.Status()) }()
+	}
 
 	// Récupérer le validator pour le traitement des chemins
 	validator := validation.GetValidator(c)
@@ -73,12 +123,18 @@ func (h *StorageHandlers) UploadJobSources(c *gin.Context) {
 		// Valider le chemin complet
 		pathValidation := validator.ValidateFilePath(sanitizedPath)
 		if !pathValidation.Valid {
-			for _, err := range pathValidation.Errors {
+			for _, err := range validation.LocalizedErrors(c, pathValidation.Errors) {
 				uploadErrors = append(uploadErrors, fmt.Sprintf("File %s: %s", originalPath, err.Message))
 			}
 			continue
 		}
 
+		// La clé complète (namespaces, variante compressée du résultat) doit tenir dans le backend
+		if err := h.storageService.CheckJobSourceKey(c.Request.Context(), jobID, sanitizedPath); err != nil {
+			uploadErrors = append(uploadErrors, fmt.Sprintf("File %s: %v", originalPath, err))
+			continue
+		}
+
 		// Validation supplémentaire du contenu
 		file, err := fileHeader.Open()
 		if err != nil {
@@ -93,7 +149,7 @@ func (h *StorageHandlers) UploadJobSources(c *gin.Context) {
 
 		contentValidation := validator.ValidateContentSafety(content[:n], sanitizedPath)
 		if !contentValidation.Valid {
-			for _, err := range contentValidation.Errors {
+			for _, err := range validation.LocalizedErrors(c, contentValidation.Errors) {
 				uploadErrors = append(uploadErrors, fmt.Sprintf("File %s: %s", originalPath, err.Message))
 			}
 			continue
@@ -118,20 +174,273 @@ func (h *StorageHandlers) UploadJobSources(c *gin.Context) {
 		return
 	}
 
+	// Upload tronqué (parts perdues par un proxy ou le client) : ne rien écrire
+	if expectedCount > 0 && len(processedFiles) != expectedCount {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":          "uploaded file count does not match " + validation.ExpectedFileCountHeader,
+			"code":           "FILE_COUNT_MISMATCH",
+			"expected_count": expectedCount,
+			"received_count": len(processedFiles),
+		})
+		return
+	}
+
+	// Appliquer la politique d'écrasement avant toute écriture
+	var skipped []string
+	if policy != models.OverwriteReplace {
+		existing, err := h.existingJobSources(c.Request.Context(), jobID, extractFilePaths(processedFiles))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if len(existing) > 0 && policy == models.OverwriteErrorOnExisting {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":          "some files already exist",
+				"existing_files": existing,
+			})
+			return
+		}
+
+		processedFiles, skipped = excludeFiles(processedFiles, existing)
+	}
+
+	// Compter les fichiers écrits pour la session de suivi
+	var progress func(file *multipart.FileHeader, err error)
+	if sessionID != uuid.Nil {
+		var totalBytes int64
+		for _, fileHeader := range processedFiles {
+			totalBytes += fileHeader.Size
+		}
+		h.uploadSessions.Begin(sessionID, len(processedFiles), totalBytes)
+		progress = func(file *multipart.FileHeader, err error) {
+			h.uploadSessions.FileStored(sessionID, file.Size, err)
+		}
+	}
+
 	// Upload les fichiers avec leurs chemins préservés
-	if err := h.storageService.UploadJobSources(c.Request.Context(), jobID, processedFiles); err != nil {
+	checksums, err := h.storageService.UploadJobSourcesWithProgress(c.Request.Context(), jobID, processedFiles, progress)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{
+		"message":   "files uploaded successfully with directory structure preserved",
+		"job_id":    jobID,
+		"count":     len(processedFiles),
+		"files":     extractFilePaths(processedFiles),
+		"skipped":   skipped,
+		"overwrite": policy,
+		"checksums": checksums,
+	}
+	if sessionID != uuid.Nil {
+		response["upload_session"] = sessionID
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// UploadJobSourceArchive upload les fichiers sources d'un job depuis une archive
+// @Summary Upload des sources en archive
+// @Description Extrait une archive `.zip`, `.tar.gz` ou `.tgz` (champ `archive`) dans les sources
+// @Description du job, en conservant ses dossiers. Chaque fichier extrait passe les mêmes
+// @Description validations qu'un upload de fichiers (chemin, type, taille, contenu) ; rien n'est
+// @Description écrit si un fichier est refusé.
+// @Description
+// @Description Contre les bombes de décompression, la taille décompressée est bornée par
+// @Description `MAX_UPLOAD_TOTAL_SIZE` et par `MAX_ARCHIVE_EXPANSION_RATIO` fois la taille de
+// @Description l'archive (ARCHIVE_EXPANSION_TOO_LARGE). Les liens et les chemins sortant de
+// @Description l'archive sont refusés ; les dossiers et les métadonnées macOS sont ignorés.
+// @Tags Storage
+// @Accept multipart/form-data
+// @Produce json
+// @Param job_id path string true "ID du job" Format(uuid)
+// @Param archive formData file true "Archive des sources (.zip, .tar.gz, .tgz)"
+// @Param overwrite query string false "Traitement des fichiers déjà présents" Enums(replace, skip-existing, error-on-existing) default(replace)
+// @Success 201 {object} models.FileUploadResponse "Fichiers extraits et uploadés, avec leur empreinte SHA-256"
+// @Failure 400 {object} models.ErrorResponse "Archive invalide, limite dépassée ou fichier refusé"
+// @Failure 409 {object} models.ErrorResponse "Fichiers déjà présents (overwrite=error-on-existing)"
+// @Failure 500 {object} models.ErrorResponse "Erreur de stockage"
+// @Router /storage/jobs/{job_id}/sources/archive [post]
+func (h *StorageHandlers) UploadJobSourceArchive(c *gin.Context) {
+	jobID := c.MustGet("validated_job_id").(uuid.UUID)
+	entries := c.MustGet("validated_archive_entries").([]*validation.ArchiveEntry)
+	policy := c.MustGet("validated_overwrite_policy").(models.OverwritePolicy)
+
+	validator := validation.GetValidator(c)
+	if validator == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Validation service unavailable"})
+		return
+	}
+
+	// Validation du contenu, comme pour un upload de fichiers
+	var uploadErrors []string
+	for _, entry := range entries {
+		content := entry.Content[:min(int64(len(entry.Content)), 1024*1024)] // Max 1MB pour validation
+		contentValidation := validator.ValidateContentSafety(content, entry.Path)
+		for _, err := range validation.LocalizedErrors(c, contentValidation.Errors) {
+			uploadErrors = append(uploadErrors, fmt.Sprintf("File %s: %s", entry.Path, err.Message))
+		}
+		if err := h.storageService.CheckJobSourceKey(c.Request.Context(), jobID, entry.Path); err != nil {
+			uploadErrors = append(uploadErrors, fmt.Sprintf("File %s: %v", entry.Path, err))
+		}
+	}
+
+	if len(uploadErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "File validation failed",
+			"validation_errors": uploadErrors,
+			"total_count":       len(entries),
+		})
+		return
+	}
+
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		paths = append(paths, entry.Path)
+	}
+
+	// Appliquer la politique d'écrasement avant toute écriture
+	var skipped []string
+	if policy != models.OverwriteReplace {
+		existing, err := h.existingJobSources(c.Request.Context(), jobID, paths)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if len(existing) > 0 && policy == models.OverwriteErrorOnExisting {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":          "some files already exist",
+				"existing_files": existing,
+			})
+			return
+		}
+
+		if len(existing) > 0 {
+			skipped = existing
+			entries = slices.DeleteFunc(entries, func(entry *validation.ArchiveEntry) bool {
+				return slices.Contains(existing, entry.Path)
+			})
+			paths = slices.DeleteFunc(paths, func(path string) bool { return slices.Contains(existing, path) })
+		}
+	}
+
+	checksums, err := h.storageService.UploadJobSourceEntries(c.Request.Context(), jobID, entries)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "files uploaded successfully with directory structure preserved",
-		"job_id":  jobID,
-		"count":   len(processedFiles),
-		"files":   extractFilePaths(processedFiles),
+		"message":   "archive extracted successfully with directory structure preserved",
+		"job_id":    jobID,
+		"count":     len(entries),
+		"files":     paths,
+		"skipped":   skipped,
+		"overwrite": policy,
+		"checksums": checksums,
 	})
 }
 
+// uploadSessionErrorStatus associe une erreur de session d'upload à son statut HTTP
+func uploadSessionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrUploadSessionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrUploadSessionUsed):
+		return http.StatusConflict
+	case errors.Is(err, ErrUploadSessionLimit):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// CreateUploadSession ouvre une session de suivi pour le prochain upload de sources d'un job
+// @Summary Créer une session de suivi d'upload
+// @Description Retourne un identifiant à passer à l'upload des sources (`?upload_session=`) pour
+// @Description suivre, pendant l'upload, le nombre de fichiers déjà écrits dans le storage
+// @Description (`GET /storage/upload-sessions/{session_id}`). Une session sert à un seul upload ;
+// @Description elle expire après 15 minutes sans activité tant que l'upload n'est pas terminé,
+// @Description 10 minutes après sa fin sinon. Un job a au plus 5 sessions ouvertes.
+// @Tags Storage
+// @Produce json
+// @Param job_id path string true "ID du job" Format(uuid)
+// @Success 201 {object} models.UploadProgress "Session créée (status pending)"
+// @Failure 400 {object} models.ErrorResponse "ID de job invalide"
+// @Failure 429 {object} models.ErrorResponse "Trop de sessions ouvertes pour le job"
+// @Router /storage/jobs/{job_id}/sources/upload-sessions [post]
+func (h *StorageHandlers) CreateUploadSession(c *gin.Context) {
+	jobID := c.MustGet("validated_job_id").(uuid.UUID)
+
+	session, err := h.uploadSessions.Create(jobID)
+	if err != nil {
+		c.JSON(uploadSessionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, session)
+}
+
+// GetUploadSession retourne l'avancement d'un upload suivi par une session
+// @Summary Avancement d'un upload
+// @Description Nombre de fichiers de l'upload déjà écrits dans le storage (stored_files sur
+// @Description total_files) et état de l'upload : pending (pas encore reçu), uploading,
+// @Description completed ou failed. total_files est connu une fois le corps de la requête reçu et validé.
+// @Tags Storage
+// @Produce json
+// @Param session_id path string true "ID de la session d'upload" Format(uuid)
+// @Success 200 {object} models.UploadProgress "Avancement de l'upload"
+// @Failure 400 {object} models.ErrorResponse "ID de session invalide"
+// @Failure 404 {object} models.ErrorResponse "Session inconnue ou expirée"
+// @Router /storage/upload-sessions/{session_id} [get]
+func (h *StorageHandlers) GetUploadSession(c *gin.Context) {
+	sessionID := c.MustGet("validated_upload_session_id").(uuid.UUID)
+
+	progress, exists := h.uploadSessions.Get(sessionID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrUploadSessionNotFound.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// existingJobSources retourne les chemins des fichiers déjà présents dans les sources du job
+func (h *StorageHandlers) existingJobSources(ctx context.Context, jobID uuid.UUID, paths []string) ([]string, error) {
+	var existing []string
+	for _, path := range paths {
+		exists, err := h.storageService.JobSourceExists(ctx, jobID, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check existing file %s: %w", path, err)
+		}
+		if exists {
+			existing = append(existing, path)
+		}
+	}
+	return existing, nil
+}
+
+// excludeFiles retire des fichiers à uploader ceux dont le chemin est listé
+func excludeFiles(files []*multipart.FileHeader, excluded []string) ([]*multipart.FileHeader, []string) {
+	if len(excluded) == 0 {
+		return files, nil
+	}
+
+	skip := make(map[string]bool, len(excluded))
+	for _, path := range excluded {
+		skip[path] = true
+	}
+
+	var kept []*multipart.FileHeader
+	for _, file := range files {
+		if !skip[file.Filename] {
+			kept = append(kept, file)
+		}
+	}
+	return kept, excluded
+}
+
 func extractFilePaths(files []*multipart.FileHeader) []string {
 	var paths []string
 	for _, file := range files {
@@ -142,14 +451,21 @@ func extractFilePaths(files []*multipart.FileHeader) []string {
 
 // ListJobSources liste les fichiers sources d'un job
 // @Summary Lister les fichiers sources
-// @Description Liste tous les fichiers sources uploadés pour un job donné
+// @Description Liste tous les fichiers sources uploadés pour un job donné.
+// @Description
+// @Description Les sources sont uploadées avant la création du job : des sources présentes sont
+// @Description listées même si le job n'existe pas encore. Sans aucune source, la réponse est
+// @Description par défaut `200` avec une liste vide. Avec `UNKNOWN_JOB_SOURCES_NOT_FOUND=true`,
+// @Description un job inconnu sans sources répond `404` (`SOURCES_NOT_FOUND`) ; un job existant
+// @Description dont les sources ont été supprimées garde une liste vide.
 // @Tags Storage
 // @Accept json
 // @Produce json
 // @Param job_id path string true "ID du job" Format(uuid)
+// @Param checksum query bool false "Inclure l'empreinte SHA-256 de chaque fichier (format list)" default(false)
 // @Success 200 {object} models.FileListResponse "Liste des fichiers sources"
 // @Failure 400 {object} models.ErrorResponse "ID du job invalide"
-// @Failure 404 {object} models.ErrorResponse "Job non trouvé ou aucun fichier"
+// @Failure 404 {object} models.ErrorResponse "Job inconnu sans aucune source (UNKNOWN_JOB_SOURCES_NOT_FOUND)"
 // @Failure 500 {object} models.ErrorResponse "Erreur de stockage"
 // @Router /storage/jobs/{job_id}/sources [get]
 func (h *StorageHandlers) ListJobSources(c *gin.Context) {
@@ -158,6 +474,12 @@ func (h *StorageHandlers) ListJobSources(c *gin.Context) {
 	// Paramètre optionnel pour le format de réponse
 	format := c.DefaultQuery("format", "list") // "list" ou "tree"
 
+	withChecksums, err := strconv.ParseBool(c.DefaultQuery("checksum", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "checksum must be a boolean"})
+		return
+	}
+
 	switch format {
 	case "tree":
 		// Retourner un arbre organisé par dossiers
@@ -166,6 +488,9 @@ func (h *StorageHandlers) ListJobSources(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		if len(tree) == 0 && !h.sourcesListable(c, jobID) {
+			return
+		}
 
 		c.JSON(http.StatusOK, gin.H{
 			"job_id": jobID,
@@ -180,14 +505,220 @@ func (h *StorageHandlers) ListJobSources(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		if len(files) == 0 && !h.sourcesListable(c, jobID) {
+			return
+		}
 
-		c.JSON(http.StatusOK, gin.H{
+		response := gin.H{
 			"job_id": jobID,
 			"format": "list",
 			"files":  files,
 			"count":  len(files),
+		}
+
+		if withChecksums {
+			checksums := make(map[string]string, len(files))
+			for _, file := range files {
+				checksum, err := h.storageService.JobSourceChecksum(c.Request.Context(), jobID, file)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+				checksums[file] = checksum
+			}
+			response["checksums"] = checksums
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// sourcesListable vérifie qu'une liste de sources vide peut être retournée : sinon la
+// réponse d'erreur est écrite (job inconnu, ou erreur de la base)
+func (h *StorageHandlers) sourcesListable(c *gin.Context, jobID uuid.UUID) bool {
+	if h.sourcesJobService == nil {
+		return true
+	}
+
+	_, err := h.sourcesJobService.GetJob(c.Request.Context(), jobID)
+	switch {
+	case err == nil:
+		return true
+	case jobs.IsJobNotFound(err):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "no sources uploaded for this job",
+			"code":  "SOURCES_NOT_FOUND",
 		})
+	default:
+		log.Printf("Failed to check job %s for sources listing: %v", jobID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check job"})
 	}
+	return false
+}
+
+// GetJobSourceSummary résume les fichiers sources d'un job
+// @Summary Résumé des fichiers sources
+// @Description Retourne le nombre de fichiers sources d'un job, leur taille totale et leur
+// @Description répartition par extension, sans télécharger leur contenu.
+// @Description
+// @Description Permet de vérifier qu'un upload est complet avant de créer le job de génération.
+// @Tags Storage
+// @Accept json
+// @Produce json
+// @Param job_id path string true "ID du job" Format(uuid)
+// @Success 200 {object} models.SourceSummary "Résumé des fichiers sources"
+// @Failure 400 {object} models.ErrorResponse "ID du job invalide"
+// @Failure 404 {object} models.ErrorResponse "Aucun fichier source pour ce job"
+// @Failure 500 {object} models.ErrorResponse "Erreur de stockage"
+// @Router /storage/jobs/{job_id}/sources/summary [get]
+func (h *StorageHandlers) GetJobSourceSummary(c *gin.Context) {
+	jobID := c.MustGet("validated_job_id").(uuid.UUID)
+
+	summary, err := h.storageService.SummarizeJobSources(c.Request.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, storage.ErrJobSourcesNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("no sources found for job %s", jobID)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// ValidateJobSources vérifie l'arborescence des sources d'un job sans la modifier
+// @Summary Vérifier les sources
+// @Description Parcourt les fichiers sources stockés d'un job et signale les entrées problématiques :
+// @Description - `invalid_path` : chemin refusé par la validation des uploads (modification manuelle du storage) ;
+// @Description - `orphaned` : entrée sans fichier (marqueur de dossier laissé par un client S3).
+// @Description
+// @Description La vérification ne modifie pas le storage : la réparation passe par
+// @Description `POST /storage/jobs/{job_id}/sources/repair`.
+// @Tags Storage
+// @Accept json
+// @Produce json
+// @Param job_id path string true "ID du job" Format(uuid)
+// @Success 200 {object} models.SourceTreeReport "Rapport de vérification"
+// @Failure 400 {object} models.ErrorResponse "Paramètres invalides"
+// @Failure 404 {object} models.ErrorResponse "Aucun fichier source pour ce job"
+// @Failure 500 {object} models.ErrorResponse "Erreur de stockage"
+// @Router /storage/jobs/{job_id}/sources/validate [get]
+func (h *StorageHandlers) ValidateJobSources(c *gin.Context) {
+	if _, ok := c.GetQuery("repair"); ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "repair is not supported on GET, use POST /sources/repair"})
+		return
+	}
+	h.checkJobSources(c, false)
+}
+
+// RepairJobSources répare l'arborescence des sources d'un job
+// @Summary Réparer les sources
+// @Description Applique les corrections du rapport de `GET /storage/jobs/{job_id}/sources/validate` :
+// @Description les chemins invalides sont renommés vers leur version sanitisée (`suggested_path`) et
+// @Description les entrées orphelines supprimées. Un renommage qui écraserait un fichier existant
+// @Description n'est pas appliqué (`skipped`).
+// @Tags Storage
+// @Accept json
+// @Produce json
+// @Param job_id path string true "ID du job" Format(uuid)
+// @Success 200 {object} models.SourceTreeReport "Rapport de réparation"
+// @Failure 400 {object} models.ErrorResponse "Paramètres invalides"
+// @Failure 404 {object} models.ErrorResponse "Aucun fichier source pour ce job"
+// @Failure 500 {object} models.ErrorResponse "Erreur de stockage"
+// @Router /storage/jobs/{job_id}/sources/repair [post]
+func (h *StorageHandlers) RepairJobSources(c *gin.Context) {
+	h.checkJobSources(c, true)
+}
+
+// checkJobSources vérifie les sources d'un job et, si repair, applique les corrections
+func (h *StorageHandlers) checkJobSources(c *gin.Context, repair bool) {
+	jobID := c.MustGet("validated_job_id").(uuid.UUID)
+
+	validator := validation.GetValidator(c)
+	if validator == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Validation service unavailable"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	files, err := h.storageService.ListJobSources(ctx, jobID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(files) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("no sources found for job %s", jobID)})
+		return
+	}
+	sort.Strings(files)
+
+	existing := make(map[string]bool, len(files))
+	for _, file := range files {
+		existing[file] = true
+	}
+
+	report := &models.SourceTreeReport{
+		JobID:   jobID.String(),
+		Checked: len(files),
+		Repair:  repair,
+		Issues:  []models.SourceTreeIssue{},
+	}
+
+	for _, file := range files {
+		var issue models.SourceTreeIssue
+
+		if strings.HasSuffix(file, "/") {
+			issue = models.SourceTreeIssue{Path: file, Type: models.SourceIssueOrphaned}
+			if repair {
+				if err := h.storageService.DeleteJobSource(ctx, jobID, file); err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to delete %s: %v", file, err)})
+					return
+				}
+				issue.Action = models.SourceRepairDeleted
+			}
+		} else {
+			result := validator.ValidateFilePath(file)
+			if result.Valid {
+				continue
+			}
+
+			issue = models.SourceTreeIssue{Path: file, Type: models.SourceIssueInvalidPath}
+			for _, validationErr := range result.Errors {
+				issue.Errors = append(issue.Errors, validationErr.Message)
+			}
+
+			suggested := validator.SanitizeFilePath(file)
+			if suggested != file && validator.ValidateFilePath(suggested).Valid {
+				issue.SuggestedPath = suggested
+			}
+
+			if repair {
+				switch {
+				case issue.SuggestedPath == "":
+					issue.Action, issue.Reason = models.SourceRepairSkipped, "path cannot be sanitized"
+				case existing[suggested]:
+					issue.Action, issue.Reason = models.SourceRepairSkipped, "suggested path already exists"
+				default:
+					if err := h.storageService.MoveJobSource(ctx, jobID, file, suggested); err != nil {
+						c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to rename %s: %v", file, err)})
+						return
+					}
+					existing[suggested] = true
+					delete(existing, file)
+					issue.Action = models.SourceRepairRenamed
+				}
+			}
+		}
+
+		if issue.Action == models.SourceRepairRenamed || issue.Action == models.SourceRepairDeleted {
+			report.Repaired++
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+
+	report.Valid = report.Repaired == len(report.Issues)
+	c.JSON(http.StatusOK, report)
 }
 
 // DownloadJobSource télécharge un fichier source spécifique
@@ -202,9 +733,12 @@ func (h *StorageHandlers) ListJobSources(c *gin.Context) {
 // @Param job_id path string true "ID du job" Format(uuid)
 // @Param filename path string true "Nom du fichier à télécharger"
 // @Param filepath query string false "Chemin spécifique du fichier (optionnel)"
+// @Param checksum query bool false "Retourner l'empreinte SHA-256 du fichier dans l'en-tête X-Checksum-SHA256" default(false)
 // @Success 200 {file} file "Contenu du fichier"
 // @Header 200 {string} Content-Type "Type MIME du fichier"
+// @Header 200 {string} Content-Encoding "gzip si la variante précompressée est servie (selon Accept-Encoding)"
 // @Header 200 {string} Content-Disposition "attachment; filename=..."
+// @Header 200 {string} X-Checksum-SHA256 "Empreinte du fichier (sha256:<hex>), si checksum=true"
 // @Failure 400 {object} models.ErrorResponse "Paramètres invalides"
 // @Failure 404 {object} models.ErrorResponse "Fichier non trouvé"
 // @Failure 500 {object} models.ErrorResponse "Erreur de stockage"
@@ -217,6 +751,12 @@ func (h *StorageHandlers) DownloadJobSource(c *gin.Context) {
 	// Récupérer le paramètre de requête filepath (optionnel)
 	filePath := c.Query("filepath")
 
+	withChecksum, err := strconv.ParseBool(c.DefaultQuery("checksum", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "checksum must be a boolean"})
+		return
+	}
+
 	// Déterminer le chemin final à utiliser
 	var finalPath string
 	if filePath != "" {
@@ -233,6 +773,17 @@ func (h *StorageHandlers) DownloadJobSource(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
 		return
 	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	var checksum string
+	if withChecksum {
+		if checksum, err = h.storageService.JobSourceChecksum(c.Request.Context(), jobID, finalPath); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
 	// Déterminer le content type basé sur l'extension
 	contentType := determineContentType(finalPath)
@@ -244,6 +795,10 @@ func (h *StorageHandlers) DownloadJobSource(c *gin.Context) {
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", displayName))
 	c.Header("X-File-Path", finalPath) // Header customisé pour indiquer le chemin complet
 
+	if checksum != "" {
+		c.Header("X-Checksum-SHA256", checksum)
+	}
+
 	c.DataFromReader(http.StatusOK, -1, contentType, reader, nil)
 }
 
@@ -251,21 +806,30 @@ func (h *StorageHandlers) DownloadJobSource(c *gin.Context) {
 func determineContentType(filename string) string {
 	ext := strings.ToLower(filepath.Ext(filename))
 	contentTypes := map[string]string{
-		".md":   "text/markdown",
-		".css":  "text/css",
-		".js":   "application/javascript",
-		".ts":   "application/javascript",
-		".vue":  "application/javascript",
-		".json": "application/json",
-		".png":  "image/png",
-		".jpg":  "image/jpeg",
-		".jpeg": "image/jpeg",
-		".gif":  "image/gif",
-		".svg":  "image/svg+xml",
-		".html": "text/html",
-		".txt":  "text/plain",
-		".yml":  "text/yaml",
-		".yaml": "text/yaml",
+		".md":      "text/markdown",
+		".css":     "text/css",
+		".scss":    "text/x-scss",
+		".sass":    "text/x-sass",
+		".less":    "text/x-less",
+		".postcss": "text/css",
+		".js":      "application/javascript",
+		".ts":      "application/javascript",
+		".vue":     "application/javascript",
+		".json":    "application/json",
+		".png":     "image/png",
+		".jpg":     "image/jpeg",
+		".jpeg":    "image/jpeg",
+		".gif":     "image/gif",
+		".svg":     "image/svg+xml",
+		".ico":     "image/x-icon",
+		".woff":    "font/woff",
+		".woff2":   "font/woff2",
+		".ttf":     "font/ttf",
+		".eot":     "application/vnd.ms-fontobject",
+		".html":    "text/html",
+		".txt":     "text/plain",
+		".yml":     "text/yaml",
+		".yaml":    "text/yaml",
 	}
 
 	if contentType, exists := contentTypes[ext]; exists {
@@ -295,6 +859,8 @@ func min(a, b int64) int64 {
 // @Param filename path string true "Nom du fichier à télécharger"
 // @Success 200 {file} file "Contenu du fichier généré"
 // @Header 200 {string} Content-Type "Type MIME du fichier"
+// @Header 200 {string} Content-Encoding "gzip si la variante précompressée est servie (selon Accept-Encoding)"
+// @Header 200 {string} Cache-Control "Politique de cache du type de fichier (RESULT_CACHE_CONTROL_RULES)"
 // @Failure 400 {object} models.ErrorResponse "Paramètres invalides"
 // @Failure 404 {object} models.ErrorResponse "Fichier non trouvé"
 // @Failure 500 {object} models.ErrorResponse "Erreur de stockage"
@@ -313,11 +879,16 @@ func (h *StorageHandlers) DownloadResult(c *gin.Context) {
 		return
 	}
 
-	reader, err := h.storageService.DownloadResult(c.Request.Context(), courseID, filename)
+	reader, encoding, err := h.storageService.DownloadResultEncoded(c.Request.Context(), courseID, filename, c.GetHeader("Accept-Encoding"))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
 		return
 	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	setResultEncodingHeaders(c, filename, encoding)
+	c.Header("Cache-Control", h.cachePolicy.CacheControl(filename))
 
 	contentType := "application/octet-stream"
 	ext := filepath.Ext(filename)
@@ -334,6 +905,104 @@ func (h *StorageHandlers) DownloadResult(c *gin.Context) {
 	c.DataFromReader(http.StatusOK, -1, contentType, reader, nil)
 }
 
+// viewResultCSP isole les cours prévisualisés dans une origine opaque : leurs scripts
+// s'exécutent mais ne peuvent ni lire les cookies et le stockage de l'API, ni l'appeler en
+// tant que celle-ci
+const viewResultCSP = "sandbox allow-scripts allow-forms allow-modals allow-popups " +
+	"allow-popups-to-escape-sandbox allow-presentation allow-downloads"
+
+// ViewResult sert un fichier de résultat pour prévisualiser un cours dans le navigateur
+// @Summary Prévisualiser un cours généré
+// @Description Sert les fichiers de résultat en ligne (sans téléchargement forcé) avec leur type MIME,
+// @Description pour consulter un cours directement depuis le worker. Un chemin vide ou un dossier sert
+// @Description son `index.html` ; un dossier demandé sans slash final est redirigé (`301`) vers
+// @Description `dossier/`. Une route sans extension introuvable (`/1`, `/presenter/2`) sert
+// @Description `index.html` (routage de l'app Slidev), sauf si le job a été créé avec la metadata
+// @Description `spa_fallback: false`.
+// @Description
+// @Description Slidev référence ses assets en chemins absolus : construire le cours avec
+// @Description `build_flags: ["--base=/api/v1/storage/courses/{course_id}/view/"]` pour que les assets
+// @Description soient servis par cette route.
+// @Description
+// @Description Les réponses portent une CSP `sandbox` (sans `allow-same-origin`) : le cours s'exécute
+// @Description dans une origine opaque, isolée de l'API.
+// @Tags Storage
+// @Produce text/html
+// @Produce text/css
+// @Produce application/javascript
+// @Produce application/octet-stream
+// @Param course_id path string true "ID du cours" Format(uuid)
+// @Param filepath path string true "Chemin du fichier dans les résultats (ex: assets/index.js)"
+// @Success 200 {file} file "Contenu du fichier"
+// @Header 200 {string} Content-Type "Type MIME du fichier"
+// @Header 200 {string} Cache-Control "Politique de cache du type de fichier (RESULT_CACHE_CONTROL_RULES)"
+// @Header 200 {string} Content-Security-Policy "Sandbox isolant le cours de l'origine de l'API"
+// @Failure 301 {string} string "Dossier demandé sans slash final, redirigé vers dossier/"
+// @Failure 400 {object} models.ErrorResponse "Paramètres invalides"
+// @Failure 404 {object} models.ErrorResponse "Fichier non trouvé"
+// @Router /storage/courses/{course_id}/view/{filepath} [get]
+func (h *StorageHandlers) ViewResult(c *gin.Context) {
+	courseID := c.MustGet("validated_course_id").(uuid.UUID)
+	filePath := c.MustGet("validated_filepath").(string)
+
+	acceptEncoding := c.GetHeader("Accept-Encoding")
+
+	// Dossier demandé sans slash final : rediriger avant le repli sur l'app, pour que les
+	// chemins relatifs de son index.html se résolvent dans le dossier
+	if filepath.Ext(filePath) == "" {
+		if exists, _ := h.storageService.ResultExists(c.Request.Context(), courseID, filePath+"/index.html"); exists {
+			location := c.Request.URL.Path + "/"
+			if c.Request.URL.RawQuery != "" {
+				location += "?" + c.Request.URL.RawQuery
+			}
+			c.Redirect(http.StatusMovedPermanently, location)
+			return
+		}
+	}
+
+	reader, encoding, err := h.storageService.DownloadResultEncoded(c.Request.Context(), courseID, filePath, acceptEncoding)
+	if err != nil && filepath.Ext(filePath) == "" && h.spaFallbackEnabled(c.Request.Context(), courseID) {
+		// Route de l'app Slidev (/1, /presenter/2) : le routage se fait côté client
+		filePath = "index.html"
+		reader, encoding, err = h.storageService.DownloadResultEncoded(c.Request.Context(), courseID, filePath, acceptEncoding)
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+		return
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	setResultEncodingHeaders(c, filePath, encoding)
+	c.Header("Cache-Control", h.cachePolicy.CacheControl(filePath))
+
+	contentType := determineContentType(filePath)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Security-Policy", viewResultCSP)
+	c.DataFromReader(http.StatusOK, -1, contentType, reader, nil)
+}
+
+// setResultEncodingHeaders signale une variante précompressée servie, et que la réponse
+// d'un résultat compressible dépend de Accept-Encoding pour les caches intermédiaires
+func setResultEncodingHeaders(c *gin.Context, filename, encoding string) {
+	if storage.IsCompressibleResult(filename) {
+		c.Header("Vary", "Accept-Encoding")
+	}
+	if encoding != "" {
+		c.Header("Content-Encoding", encoding)
+	}
+}
+
+// spaFallbackEnabled lit dans le manifeste du cours si le repli sur index.html est actif
+func (h *StorageHandlers) spaFallbackEnabled(ctx context.Context, courseID uuid.UUID) bool {
+	manifest, err := h.storageService.GetResultManifest(ctx, courseID)
+	if err != nil {
+		// Cours construit avant les manifestes : comportement par défaut
+		return errors.Is(err, storage.ErrManifestNotFound)
+	}
+	return manifest.SPAFallbackEnabled()
+}
+
 // ListResults liste les fichiers de résultat d'un cours
 // @Summary Lister les résultats générés
 // @Description Liste tous les fichiers générés (HTML, CSS, JS, assets) pour un cours
@@ -342,6 +1011,9 @@ func (h *StorageHandlers) DownloadResult(c *gin.Context) {
 // @Description - `index.html` - Page principale de la présentation
 // @Description - `assets/` - Ressources (CSS, JS, images)
 // @Description - Autres fichiers générés par Slidev
+// @Description
+// @Description `urls` associe chaque fichier à son URL de téléchargement : URL présignée pour le
+// @Description stockage S3, sinon route de l'API préfixée par `PUBLIC_BASE_URL` si configurée.
 // @Tags Storage
 // @Accept json
 // @Produce json
@@ -365,21 +1037,262 @@ func (h *StorageHandlers) ListResults(c *gin.Context) {
 		return
 	}
 
+	urls := make(map[string]string, len(files))
+	for _, file := range files {
+		urls[file] = h.resultURL(c.Request.Context(), courseResultsRoute(courseID), courseID, file)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"course_id": courseID,
 		"files":     files,
+		"urls":      urls,
+	})
+}
+
+// courseResultsRoute retourne la route de l'API des résultats d'un cours
+func courseResultsRoute(courseID uuid.UUID) string {
+	return fmt.Sprintf("/api/v1/storage/courses/%s/results", courseID)
+}
+
+// jobResultsRoute retourne la route de l'API des résultats d'un job
+func jobResultsRoute(jobID uuid.UUID) string {
+	return fmt.Sprintf("/api/v1/storage/jobs/%s/results", jobID)
+}
+
+// ListJobResults liste les résultats du build d'un job à leur destination
+// @Summary Lister les résultats d'un job
+// @Description Liste les fichiers générés par un job, là où il les a écrits : sous sa
+// @Description destination personnalisée (`result_prefix`) ou, à défaut, dans les résultats de
+// @Description son cours. `urls` associe chaque fichier à son URL de téléchargement.
+// @Tags Storage
+// @Produce json
+// @Param job_id path string true "ID du job" Format(uuid)
+// @Success 200 {object} models.FileListResponse "Liste des fichiers de résultat"
+// @Failure 400 {object} models.ErrorResponse "ID du job invalide"
+// @Failure 403 {object} models.ErrorResponse "Job appartenant à un autre client"
+// @Failure 404 {object} models.ErrorResponse "Job non trouvé"
+// @Failure 500 {object} models.ErrorResponse "Erreur de stockage"
+// @Router /storage/jobs/{job_id}/results [get]
+func (h *StorageHandlers) ListJobResults(c *gin.Context) {
+	job := c.MustGet("validated_job").(*models.GenerationJob)
+
+	files, err := h.storageService.ListResults(c.Request.Context(), job.CourseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	urls := make(map[string]string, len(files))
+	for _, file := range files {
+		urls[file] = h.resultURL(c.Request.Context(), jobResultsRoute(job.ID), job.CourseID, file)
+	}
+
+	c.JSON(http.StatusOK, models.FileListResponse{
+		JobID:        job.ID.String(),
+		CourseID:     job.CourseID.String(),
+		Files:        files,
+		Count:        len(files),
+		URLs:         urls,
+		ResultPrefix: job.ResultPrefix,
 	})
 }
 
+// DownloadJobResult sert un fichier de résultat d'un job depuis sa destination
+// @Summary Télécharger un résultat d'un job
+// @Description Sert un fichier généré par un job depuis sa destination personnalisée
+// @Description (`result_prefix`) ou, à défaut, depuis les résultats de son cours, avec son type
+// @Description MIME. Un chemin vide ou un dossier sert son `index.html`.
+// @Tags Storage
+// @Produce text/html
+// @Produce text/css
+// @Produce application/javascript
+// @Produce application/octet-stream
+// @Param job_id path string true "ID du job" Format(uuid)
+// @Param filepath path string true "Chemin du fichier dans les résultats (ex: assets/index.js)"
+// @Success 200 {file} file "Contenu du fichier"
+// @Header 200 {string} Content-Type "Type MIME du fichier"
+// @Header 200 {string} Cache-Control "Politique de cache du type de fichier (RESULT_CACHE_CONTROL_RULES)"
+// @Failure 400 {object} models.ErrorResponse "Paramètres invalides"
+// @Failure 403 {object} models.ErrorResponse "Job appartenant à un autre client"
+// @Failure 404 {object} models.ErrorResponse "Job ou fichier non trouvé"
+// @Router /storage/jobs/{job_id}/results/{filepath} [get]
+func (h *StorageHandlers) DownloadJobResult(c *gin.Context) {
+	job := c.MustGet("validated_job").(*models.GenerationJob)
+	filePath := c.MustGet("validated_filepath").(string)
+
+	reader, encoding, err := h.storageService.DownloadResultEncoded(c.Request.Context(), job.CourseID, filePath, c.GetHeader("Accept-Encoding"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+		return
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	setResultEncodingHeaders(c, filePath, encoding)
+	c.Header("Cache-Control", h.cachePolicy.CacheControl(filePath))
+
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, -1, determineContentType(filePath), reader, nil)
+}
+
+// resultURL retourne l'URL de téléchargement d'un résultat. Une URL absolue du backend
+// (présignée S3) est utilisée telle quelle ; sinon on renvoie la route de téléchargement
+// de l'API (route), préfixée par l'URL publique si elle est configurée.
+func (h *StorageHandlers) resultURL(ctx context.Context, route string, courseID uuid.UUID, filename string) string {
+	if backendURL, err := h.storageService.GetResultURL(ctx, courseID, filename); err == nil {
+		if parsed, err := url.Parse(backendURL); err == nil && parsed.IsAbs() {
+			return backendURL
+		}
+	}
+
+	segments := strings.Split(filename, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return fmt.Sprintf("%s%s/%s", h.publicBaseURL, route, strings.Join(segments, "/"))
+}
+
+// GetResultManifest retourne le manifeste des fichiers générés d'un cours
+// @Summary Manifeste des résultats générés
+// @Description Retourne la liste des artefacts générés pour un cours avec leur taille, type MIME,
+// @Description empreinte SHA-256 et le job qui les a produits.
+// @Description
+// @Description Permet aux consommateurs (CDN, frontends) de ne synchroniser que les fichiers modifiés.
+// @Description Chaque entrée porte son URL de téléchargement (`url`), calculée à chaque requête.
+// @Tags Storage
+// @Accept json
+// @Produce json
+// @Param course_id path string true "ID du cours" Format(uuid)
+// @Success 200 {object} models.ResultManifest "Manifeste des résultats"
+// @Failure 400 {object} models.ErrorResponse "ID du cours invalide"
+// @Failure 404 {object} models.ErrorResponse "Cours jamais généré"
+// @Failure 500 {object} models.ErrorResponse "Erreur de stockage"
+// @Router /storage/courses/{course_id}/manifest [get]
+func (h *StorageHandlers) GetResultManifest(c *gin.Context) {
+	courseID := c.MustGet("validated_course_id").(uuid.UUID)
+
+	manifest, err := h.storageService.GetResultManifest(c.Request.Context(), courseID)
+	if err != nil {
+		if errors.Is(err, storage.ErrManifestNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "course has never been built"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for i := range manifest.Files {
+		manifest.Files[i].URL = h.resultURL(c.Request.Context(), courseResultsRoute(courseID), courseID, manifest.Files[i].Path)
+	}
+
+	c.JSON(http.StatusOK, manifest)
+}
+
+// GetCourseResultUsage retourne l'espace occupé par les résultats d'un cours
+// @Summary Espace occupé par les résultats d'un cours
+// @Description Mesure la taille des résultats stockés d'un cours (variantes précompressées comprises)
+// @Description et la compare au quota du cours (`quota_bytes`, 0 = sans limite). Un build qui ferait
+// @Description dépasser le quota échoue avant l'upload de ses résultats.
+// @Tags Storage
+// @Accept json
+// @Produce json
+// @Param course_id path string true "ID du cours" Format(uuid)
+// @Success 200 {object} models.CourseResultUsage "Espace occupé et quota"
+// @Failure 400 {object} models.ErrorResponse "ID du cours invalide"
+// @Failure 500 {object} models.ErrorResponse "Erreur de stockage"
+// @Router /storage/courses/{course_id}/usage [get]
+func (h *StorageHandlers) GetCourseResultUsage(c *gin.Context) {
+	courseID := c.MustGet("validated_course_id").(uuid.UUID)
+
+	usage, err := h.storageService.GetResultUsage(c.Request.Context(), courseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// GetResultDiff compare les résultats de deux builds d'un cours
+// @Summary Différences entre deux builds
+// @Description Liste les fichiers ajoutés, supprimés et modifiés (par chemin et empreinte) entre
+// @Description deux builds d'un cours, avec l'évolution de leur taille.
+// @Description
+// @Description Une version est l'ID du job de build ou `latest` pour le dernier build.
+// @Description Seuls les builds dont le manifeste a été versionné peuvent être comparés.
+// @Tags Storage
+// @Accept json
+// @Produce json
+// @Param course_id path string true "ID du cours" Format(uuid)
+// @Param from query string true "Version de départ (ID du job de build ou latest)"
+// @Param to query string false "Version d'arrivée (ID du job de build ou latest)" default(latest)
+// @Success 200 {object} models.ResultDiff "Différences entre les deux builds"
+// @Failure 400 {object} models.ErrorResponse "Paramètres invalides"
+// @Failure 404 {object} models.ErrorResponse "Version introuvable"
+// @Failure 500 {object} models.ErrorResponse "Erreur de stockage"
+// @Router /storage/courses/{course_id}/diff [get]
+func (h *StorageHandlers) GetResultDiff(c *gin.Context) {
+	courseID := c.MustGet("validated_course_id").(uuid.UUID)
+	from := c.MustGet("validated_diff_from").(uuid.UUID)
+	to := c.MustGet("validated_diff_to").(uuid.UUID)
+
+	manifests := make([]*models.ResultManifest, 0, 2)
+	for _, version := range []uuid.UUID{from, to} {
+		manifest, err := h.resultManifestVersion(c.Request.Context(), courseID, version)
+		if err != nil {
+			if errors.Is(err, storage.ErrManifestNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("result version %s not found", versionName(version))})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	c.JSON(http.StatusOK, models.DiffManifests(manifests[0], manifests[1]))
+}
+
+// resultManifestVersion retourne le manifeste d'un build (uuid.Nil = dernier build)
+func (h *StorageHandlers) resultManifestVersion(ctx context.Context, courseID, version uuid.UUID) (*models.ResultManifest, error) {
+	if version == uuid.Nil {
+		return h.storageService.GetResultManifest(ctx, courseID)
+	}
+	return h.storageService.GetResultManifestVersion(ctx, courseID, version)
+}
+
+// versionName retourne le nom d'une version de résultats tel que demandé par le client
+func versionName(version uuid.UUID) string {
+	if version == uuid.Nil {
+		return validation.ResultVersionLatest
+	}
+	return version.String()
+}
+
 // GetJobLogs récupère les logs d'exécution d'un job
 // @Summary Récupérer les logs d'un job
 // @Description Récupère les logs détaillés d'exécution d'un job (build Slidev, erreurs, etc.)
+// @Description
+// @Description `level=error` ne garde que les lignes d'erreur, `level=warning` les erreurs et avertissements.
+// @Description
+// @Description `format=jsonl` retourne une ligne JSON par ligne de log (`{"ts":...,"stream":"STDOUT","msg":...}`),
+// @Description `format=text` le format texte ; par défaut, les logs sont retournés dans leur format de stockage (`LOG_FORMAT`).
+// @Description
+// @Description Les logs sont stockés compressés en gzip. Avec `Accept-Encoding: gzip`, ils sont
+// @Description retournés tels que stockés (`Content-Encoding: gzip`) ; après conversion ou filtrage,
+// @Description ou pour des logs antérieurs à la compression, ceux de plus de 1 Ko sont compressés
+// @Description à la volée. Les autres clients reçoivent les logs décompressés.
 // @Tags Storage
 // @Accept json
 // @Produce text/plain
+// @Produce application/x-ndjson
 // @Param job_id path string true "ID du job" Format(uuid)
-// @Success 200 {string} string "Logs du job (format texte)"
-// @Header 200 {string} Content-Type "text/plain"
+// @Param level query string false "Niveau minimal des lignes retournées" Enums(all, warning, error) default(all)
+// @Param format query string false "Format des logs retournés" Enums(text, jsonl)
+// @Success 200 {string} string "Logs du job (texte ou JSON lines)"
+// @Header 200 {string} Content-Type "text/plain ou application/x-ndjson"
+// @Header 200 {string} Content-Encoding "gzip si le client l'accepte (Accept-Encoding)"
 // @Failure 400 {object} models.ErrorResponse "ID du job invalide"
 // @Failure 404 {object} models.ErrorResponse "Logs non trouvés"
 // @Failure 500 {object} models.ErrorResponse "Erreur de stockage"
@@ -392,19 +1305,91 @@ func (h *StorageHandlers) GetJobLogs(c *gin.Context) {
 		return
 	}
 
-	logs, err := h.storageService.GetJobLog(c.Request.Context(), jobID)
+	requestedFormat := c.GetString("validated_log_format")
+	level := c.GetString("validated_log_level")
+	acceptsGzip := storage.AcceptsEncoding(c.GetHeader("Accept-Encoding"), "gzip")
+	c.Header("Vary", "Accept-Encoding")
+
+	// Logs complets dans leur format de stockage : servir les octets stockés, sans décompression
+	if acceptsGzip && (level == "" || level == storage.LogLevelAll) {
+		compressed, storedFormat, err := h.storageService.GetCompressedJobLog(c.Request.Context(), jobID)
+		if err == nil && (requestedFormat == "" || requestedFormat == storedFormat) {
+			c.Header("Content-Encoding", "gzip")
+			c.Data(http.StatusOK, jobLogsContentType(storedFormat), compressed)
+			return
+		}
+	}
+
+	logs, storedFormat, err := h.storageService.GetJobLogWithFormat(c.Request.Context(), jobID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "logs not found"})
 		return
 	}
 
-	c.Header("Content-Type", "text/plain")
+	format := storedFormat
+	if requestedFormat != "" {
+		format = requestedFormat
+	}
+	logs = storage.ConvertJobLogs(logs, storedFormat, format)
+	logs = storage.FilterJobLogs(logs, level)
+
+	contentType := jobLogsContentType(format)
+	c.Header("Content-Type", contentType)
+
+	if len(logs) >= storage.MinCompressedResultSize && acceptsGzip {
+		compressed, err := gzipJobLogs(logs)
+		if err == nil {
+			c.Header("Content-Encoding", "gzip")
+			c.Data(http.StatusOK, contentType, compressed)
+			return
+		}
+		log.Printf("Job %s: serving uncompressed logs: %v", jobID, err)
+	}
 	c.String(http.StatusOK, logs)
 }
 
+// jobLogsContentType retourne le type MIME des logs dans un format
+func jobLogsContentType(format string) string {
+	if format == storage.LogFormatJSONL {
+		return "application/x-ndjson"
+	}
+	return "text/plain"
+}
+
+// gzipJobLogs compresse à la volée les logs convertis, filtrés ou non compressés d'un job
+func gzipJobLogs(logs string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(writer, logs); err != nil {
+		writer.Close()
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GetUploadLimits retourne les types de fichiers et les limites de l'upload des sources
+// @Summary Types de fichiers et limites d'upload
+// @Description Retourne les extensions et types MIME acceptés, les tailles maximales par
+// @Description fichier et par upload, le nombre maximum de fichiers, la profondeur et la
+// @Description longueur maximales des chemins, ainsi que les règles par dossier. Les valeurs
+// @Description reflètent la configuration de validation du serveur : un client peut les
+// @Description appliquer avant l'upload.
+// @Tags Storage
+// @Produce json
+// @Success 200 {object} models.UploadLimits "Types de fichiers et limites"
+// @Router /storage/limits [get]
+func (h *StorageHandlers) GetUploadLimits(c *gin.Context) {
+	c.JSON(http.StatusOK, h.validationConfig.UploadLimits())
+}
+
 // GetStorageInfo retourne des informations sur le système de stockage
 // @Summary Informations sur le stockage
-// @Description Retourne les informations de configuration et l'état du système de stockage
+// @Description Retourne les informations de configuration et l'état du système de stockage,
+// @Description ainsi que la latence par opération du backend (upload, download, list, delete)
+// @Description et les octets des sources et résultats uploadés et téléchargés (transfer).
 // @Tags Storage
 // @Accept json
 // @Produce json
@@ -412,7 +1397,7 @@ func (h *StorageHandlers) GetJobLogs(c *gin.Context) {
 // @Failure 500 {object} models.ErrorResponse "Erreur interne du serveur"
 // @Router /storage/info [get]
 func (h *StorageHandlers) GetStorageInfo(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
+	info := gin.H{
 		"storage_type": "configured",
 		"endpoints": gin.H{
 			"upload_sources":  "/api/v1/storage/jobs/{job_id}/sources",
@@ -422,5 +1407,16 @@ func (h *StorageHandlers) GetStorageInfo(c *gin.Context) {
 			"download_result": "/api/v1/storage/courses/{course_id}/results/{filename}",
 			"get_logs":        "/api/v1/storage/jobs/{job_id}/logs",
 		},
-	})
+	}
+
+	// Latence du backend depuis le démarrage
+	if operations, threshold, ok := h.storageService.OperationStats(); ok {
+		info["operations"] = operations
+		info["slow_threshold"] = threshold.String()
+	}
+
+	// Octets des sources et résultats transférés
+	info["transfer"] = h.storageService.TransferStats()
+
+	c.JSON(http.StatusOK, info)
 }
@@ -0,0 +1,1521 @@
+// internal/api/storage_handlers_test.go
+package api
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
+	"github.com/Open-Course-Factory/ocf-worker/internal/validation"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestRouterWithValidation crée un routeur de test avec une configuration de validation donnée
+func setupTestRouterWithValidation(t *testing.T, validationConfig *validation.ValidationConfig) *gin.Engine {
+	jobService, storageService := setupTestServices(t)
+	mockWorkerPool := createMockWorkerPool(jobService, storageService)
+
+	return SetupRouterWithConfig(jobService, storageService, mockWorkerPool, &RouterConfig{
+		ValidationConfig: validationConfig,
+	})
+}
+
+// uploadSources envoie des fichiers sources en multipart pour un job
+func uploadSources(t *testing.T, router *gin.Engine, jobID uuid.UUID, files map[string]string) *httptest.ResponseRecorder {
+	return uploadSourcesWithQuery(t, router, jobID, "", files)
+}
+
+// uploadSourcesWithQuery envoie des fichiers sources avec des paramètres de requête
+func uploadSourcesWithQuery(t *testing.T, router *gin.Engine, jobID uuid.UUID, query string, files map[string]string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	for filePath, content := range files {
+		part, err := writer.CreateFormFile("files", filePath)
+		require.NoError(t, err)
+
+		_, err = part.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	target := "/api/v1/storage/jobs/" + jobID.String() + "/sources"
+	if query != "" {
+		target += "?" + query
+	}
+	req := httptest.NewRequest(http.MethodPost, target, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestUploadJobSourcesFileLimit(t *testing.T) {
+	files := make(map[string]string)
+	for i := 0; i < 25; i++ {
+		files[fmt.Sprintf("images/slide-%02d.md", i)] = fmt.Sprintf("# Slide %d", i)
+	}
+
+	t.Run("rejected when above limit", func(t *testing.T) {
+		config := validation.DefaultValidationConfig()
+		config.MaxFiles = 20
+		router := setupTestRouterWithValidation(t, config)
+
+		w := uploadSources(t, router, uuid.New(), files)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "TOO_MANY_FILES")
+	})
+
+	t.Run("accepted when limit is raised", func(t *testing.T) {
+		config := validation.DefaultValidationConfig()
+		config.MaxFiles = 30
+		router := setupTestRouterWithValidation(t, config)
+
+		w := uploadSources(t, router, uuid.New(), files)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(25), response["count"])
+	})
+
+	t.Run("rejected when total size exceeds limit", func(t *testing.T) {
+		config := validation.DefaultValidationConfig()
+		config.MaxTotalSize = 100
+		router := setupTestRouterWithValidation(t, config)
+
+		w := uploadSources(t, router, uuid.New(), files)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "TOTAL_SIZE_TOO_LARGE")
+	})
+}
+
+func TestUploadJobSourcesEmptyFiles(t *testing.T) {
+	files := map[string]string{"slides.md": "# Slides", "theme.css": ""}
+
+	t.Run("accepted by default", func(t *testing.T) {
+		w := uploadSources(t, setupTestRouter(t), uuid.New(), files)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	})
+
+	t.Run("rejected when configured", func(t *testing.T) {
+		config := validation.DefaultValidationConfig()
+		config.RejectEmptyFiles = true
+
+		w := uploadSources(t, setupTestRouterWithValidation(t, config), uuid.New(), files)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "EMPTY_FILE")
+
+		config.EmptyFileExtensions = map[string]bool{".css": true}
+		w = uploadSources(t, setupTestRouterWithValidation(t, config), uuid.New(), files)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	})
+}
+
+func TestUploadJobSourcesDuplicatePaths(t *testing.T) {
+	router := setupTestRouter(t)
+
+	t.Run("same filename in different directories", func(t *testing.T) {
+		w := uploadSources(t, router, uuid.New(), map[string]string{
+			"docs/index.md": "# Docs",
+			"src/index.md":  "# Src",
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(2), response["count"])
+	})
+
+	t.Run("same full path twice", func(t *testing.T) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		for _, content := range []string{"# First", "# Second"} {
+			part, err := writer.CreateFormFile("files", "docs/index.md")
+			require.NoError(t, err)
+			_, err = part.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest(http.MethodPost,
+			"/api/v1/storage/jobs/"+uuid.New().String()+"/sources", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "DUPLICATE_FILENAME")
+	})
+}
+
+func TestUploadJobSourcesExpectedFileCount(t *testing.T) {
+	router := setupTestRouter(t)
+	files := map[string]string{
+		"slides.md":        "# Slides",
+		"styles/theme.css": "body { color: red; }",
+	}
+
+	upload := func(jobID uuid.UUID, expected string) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		for filePath, content := range files {
+			part, err := writer.CreateFormFile("files", filePath)
+			require.NoError(t, err)
+			_, err = part.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/storage/jobs/"+jobID.String()+"/sources", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		if expected != "" {
+			req.Header.Set("X-Expected-File-Count", expected)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	listSources := func(jobID uuid.UUID) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/storage/jobs/"+jobID.String()+"/sources", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response models.FileListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Count
+	}
+
+	t.Run("Matching count", func(t *testing.T) {
+		jobID := uuid.New()
+		w := upload(jobID, "2")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Equal(t, 2, listSources(jobID))
+	})
+
+	t.Run("Truncated upload", func(t *testing.T) {
+		jobID := uuid.New()
+		w := upload(jobID, "3")
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "FILE_COUNT_MISMATCH", response["code"])
+		assert.Equal(t, float64(3), response["expected_count"])
+		assert.Equal(t, float64(2), response["received_count"])
+		assert.Zero(t, listSources(jobID), "nothing written")
+	})
+
+	t.Run("Invalid header", func(t *testing.T) {
+		for _, expected := range []string{"two", "0", "-1"} {
+			w := upload(uuid.New(), expected)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), "INVALID_EXPECTED_FILE_COUNT")
+		}
+	})
+
+	t.Run("Header is optional", func(t *testing.T) {
+		w := upload(uuid.New(), "")
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+}
+
+func TestUploadJobSourcesOverwritePolicy(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService))
+	ctx := context.Background()
+
+	// Chaque cas part d'un job contenant déjà slides.md
+	setup := func(t *testing.T) uuid.UUID {
+		jobID := uuid.New()
+		w := uploadSources(t, router, jobID, map[string]string{"slides.md": "# Original"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		return jobID
+	}
+
+	readSource := func(t *testing.T, jobID uuid.UUID, filename string) string {
+		reader, err := storageService.DownloadJobSource(ctx, jobID, filename)
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	update := map[string]string{
+		"slides.md":        "# Updated",
+		"styles/theme.css": "body { color: red; }",
+	}
+
+	t.Run("replace by default", func(t *testing.T) {
+		jobID := setup(t)
+
+		w := uploadSources(t, router, jobID, update)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response models.FileUploadResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "replace", response.Overwrite)
+		assert.Equal(t, 2, response.Count)
+		assert.Empty(t, response.Skipped)
+		assert.Equal(t, "# Updated", readSource(t, jobID, "slides.md"))
+	})
+
+	t.Run("skip-existing keeps existing files", func(t *testing.T) {
+		jobID := setup(t)
+
+		w := uploadSourcesWithQuery(t, router, jobID, "overwrite=skip-existing", update)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response models.FileUploadResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, []string{"styles/theme.css"}, response.Files)
+		assert.Equal(t, []string{"slides.md"}, response.Skipped)
+		assert.Equal(t, "# Original", readSource(t, jobID, "slides.md"))
+		assert.Equal(t, "body { color: red; }", readSource(t, jobID, "styles/theme.css"))
+	})
+
+	t.Run("error-on-existing rejects the whole upload", func(t *testing.T) {
+		jobID := setup(t)
+
+		w := uploadSourcesWithQuery(t, router, jobID, "overwrite=error-on-existing", update)
+		require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "slides.md")
+
+		assert.Equal(t, "# Original", readSource(t, jobID, "slides.md"))
+		_, err := storageService.DownloadJobSource(ctx, jobID, "styles/theme.css")
+		assert.Error(t, err, "no file is written when the upload is rejected")
+	})
+
+	t.Run("error-on-existing accepts new files", func(t *testing.T) {
+		w := uploadSourcesWithQuery(t, router, uuid.New(), "overwrite=error-on-existing", update)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	})
+
+	t.Run("invalid policy", func(t *testing.T) {
+		w := uploadSourcesWithQuery(t, router, uuid.New(), "overwrite=merge", update)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "INVALID_OVERWRITE_POLICY")
+	})
+}
+
+// uploadSourceArchive envoie une archive de sources pour un job
+func uploadSourceArchive(t *testing.T, router *gin.Engine, jobID uuid.UUID, query, filename string, archive []byte) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("archive", filename)
+	require.NoError(t, err)
+	_, err = part.Write(archive)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	target := "/api/v1/storage/jobs/" + jobID.String() + "/sources/archive"
+	if query != "" {
+		target += "?" + query
+	}
+	req := httptest.NewRequest(http.MethodPost, target, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestUploadJobSourceArchive(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService))
+	ctx := context.Background()
+
+	zipArchive := func(t *testing.T, files map[string]string) []byte {
+		var buffer bytes.Buffer
+		writer := zip.NewWriter(&buffer)
+		for name, content := range files {
+			entry, err := writer.Create(name)
+			require.NoError(t, err)
+			_, err = entry.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, writer.Close())
+		return buffer.Bytes()
+	}
+
+	readSource := func(t *testing.T, jobID uuid.UUID, filename string) string {
+		reader, err := storageService.DownloadJobSource(ctx, jobID, filename)
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("Zip archive", func(t *testing.T) {
+		jobID := uuid.New()
+		w := uploadSourceArchive(t, router, jobID, "", "course.zip", zipArchive(t, map[string]string{
+			"slides.md":        "# Slides",
+			"styles/":          "",
+			"styles/theme.css": "body { color: red; }",
+		}))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response models.FileUploadResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.Count)
+		assert.ElementsMatch(t, []string{"slides.md", "styles/theme.css"}, response.Files)
+		assert.Len(t, response.Checksums, 2)
+		assert.Equal(t, "body { color: red; }", readSource(t, jobID, "styles/theme.css"))
+
+		checksum, err := storageService.JobSourceChecksum(ctx, jobID, "slides.md")
+		require.NoError(t, err)
+		assert.Equal(t, response.Checksums["slides.md"], checksum)
+	})
+
+	t.Run("Tar.gz archive", func(t *testing.T) {
+		var buffer bytes.Buffer
+		gzipWriter := gzip.NewWriter(&buffer)
+		tarWriter := tar.NewWriter(gzipWriter)
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "slides.md", Mode: 0o644, Size: 8}))
+		_, err := tarWriter.Write([]byte("# Slides"))
+		require.NoError(t, err)
+		require.NoError(t, tarWriter.Close())
+		require.NoError(t, gzipWriter.Close())
+
+		jobID := uuid.New()
+		w := uploadSourceArchive(t, router, jobID, "", "course.tar.gz", buffer.Bytes())
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Equal(t, "# Slides", readSource(t, jobID, "slides.md"))
+	})
+
+	t.Run("Extracted files are validated like uploaded files", func(t *testing.T) {
+		jobID := uuid.New()
+
+		w := uploadSourceArchive(t, router, jobID, "", "course.zip", zipArchive(t, map[string]string{
+			"slides.md":  "# Slides",
+			"../evil.md": "# Evil",
+		}))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "PATH_TRAVERSAL")
+
+		w = uploadSourceArchive(t, router, jobID, "", "course.zip", zipArchive(t, map[string]string{
+			"slides.md": "# Slides",
+			"tool.exe":  "MZ",
+		}))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "tool.exe")
+
+		w = uploadSourceArchive(t, router, jobID, "", "course.zip", zipArchive(t, map[string]string{
+			"slides.md":  "# Slides",
+			"index.html": "<script>alert(1)</script>",
+		}))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "File validation failed")
+
+		// Rien n'est écrit quand un fichier est refusé
+		_, err := storageService.DownloadJobSource(ctx, jobID, "slides.md")
+		assert.Error(t, err)
+	})
+
+	t.Run("Overwrite policy", func(t *testing.T) {
+		jobID := uuid.New()
+		w := uploadSources(t, router, jobID, map[string]string{"slides.md": "# Original"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		archive := zipArchive(t, map[string]string{"slides.md": "# Updated", "notes.md": "# Notes"})
+
+		w = uploadSourceArchive(t, router, jobID, "overwrite=error-on-existing", "course.zip", archive)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = uploadSourceArchive(t, router, jobID, "overwrite=skip-existing", "course.zip", archive)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response models.FileUploadResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, []string{"notes.md"}, response.Files)
+		assert.Equal(t, []string{"slides.md"}, response.Skipped)
+		assert.Equal(t, "# Original", readSource(t, jobID, "slides.md"))
+	})
+
+	t.Run("Invalid archives", func(t *testing.T) {
+		bomb := zipArchive(t, map[string]string{"slides.md": strings.Repeat("0", 1024*1024)})
+		w := uploadSourceArchive(t, router, uuid.New(), "", "bomb.zip", bomb)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "ARCHIVE_EXPANSION_TOO_LARGE")
+
+		w = uploadSourceArchive(t, router, uuid.New(), "", "course.rar", []byte("Rar!"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "UNSUPPORTED_ARCHIVE_FORMAT")
+
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		require.NoError(t, writer.WriteField("name", "course"))
+		require.NoError(t, writer.Close())
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/storage/jobs/"+uuid.NewString()+"/sources/archive", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "ARCHIVE_REQUIRED")
+	})
+}
+
+func TestUploadSessionProgress(t *testing.T) {
+	router := setupTestRouter(t)
+	jobID := uuid.New()
+	files := map[string]string{
+		"slides.md":        "# Slides",
+		"styles/theme.css": "body { color: red; }",
+	}
+
+	createSession := func(t *testing.T, jobID uuid.UUID) models.UploadProgress {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/storage/jobs/"+jobID.String()+"/sources/upload-sessions", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var session models.UploadProgress
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &session))
+		return session
+	}
+
+	getSession := func(t *testing.T, sessionID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/storage/upload-sessions/"+sessionID, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	session := createSession(t, jobID)
+	assert.Equal(t, models.UploadSessionPending, session.Status)
+	assert.Equal(t, jobID.String(), session.JobID)
+
+	t.Run("upload reports stored files", func(t *testing.T) {
+		w := uploadSourcesWithQuery(t, router, jobID, "upload_session="+session.SessionID, files)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response models.FileUploadResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, session.SessionID, response.UploadSession)
+
+		w = getSession(t, session.SessionID)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var progress models.UploadProgress
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &progress))
+		assert.Equal(t, models.UploadSessionCompleted, progress.Status)
+		assert.Equal(t, 2, progress.TotalFiles)
+		assert.Equal(t, 2, progress.StoredFiles)
+		assert.Zero(t, progress.FailedFiles)
+		assert.Equal(t, int64(len("# Slides")+len("body { color: red; }")), progress.StoredBytes)
+		assert.NotNil(t, progress.CompletedAt)
+	})
+
+	t.Run("session is single use", func(t *testing.T) {
+		w := uploadSourcesWithQuery(t, router, jobID, "upload_session="+session.SessionID, files)
+		assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	})
+
+	t.Run("session of another job", func(t *testing.T) {
+		other := createSession(t, uuid.New())
+		w := uploadSourcesWithQuery(t, router, jobID, "upload_session="+other.SessionID, files)
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+
+	t.Run("rejected upload fails the session", func(t *testing.T) {
+		failing := createSession(t, jobID)
+		w := uploadSourcesWithQuery(t, router, jobID, "upload_session="+failing.SessionID+"&overwrite=error-on-existing", files)
+		require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+		var progress models.UploadProgress
+		require.NoError(t, json.Unmarshal(getSession(t, failing.SessionID).Body.Bytes(), &progress))
+		assert.Equal(t, models.UploadSessionFailed, progress.Status)
+		assert.Contains(t, progress.Error, "409")
+	})
+
+	t.Run("unknown and invalid sessions", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, getSession(t, uuid.NewString()).Code)
+		assert.Equal(t, http.StatusBadRequest, getSession(t, "not-a-uuid").Code)
+
+		w := uploadSourcesWithQuery(t, router, jobID, "upload_session="+uuid.NewString(), files)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = uploadSourcesWithQuery(t, router, jobID, "upload_session=abc", files)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "INVALID_UPLOAD_SESSION")
+	})
+}
+
+func TestJobSourceChecksums(t *testing.T) {
+	router := setupTestRouter(t)
+	jobID := uuid.New()
+
+	// sha256("# Slides")
+	slidesChecksum := "sha256:3c9603b97dd9e0e026143ff466843dc97c2b2064e0ae50ae86a168bbb874eecd"
+
+	w := uploadSources(t, router, jobID, map[string]string{
+		"slides.md":        "# Slides",
+		"styles/theme.css": "body { color: red; }",
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var uploaded models.FileUploadResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &uploaded))
+	require.Len(t, uploaded.Checksums, 2)
+	assert.Equal(t, slidesChecksum, uploaded.Checksums["slides.md"])
+
+	get := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/storage/jobs/"+jobID.String()+target, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("list without checksums by default", func(t *testing.T) {
+		w := get("/sources")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response models.FileListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Empty(t, response.Checksums)
+	})
+
+	t.Run("list with checksums", func(t *testing.T) {
+		w := get("/sources?checksum=true")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response models.FileListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, uploaded.Checksums, response.Checksums)
+	})
+
+	t.Run("download with checksum header", func(t *testing.T) {
+		w := get("/sources/slides.md?checksum=true")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, slidesChecksum, w.Header().Get("X-Checksum-SHA256"))
+		assert.Equal(t, "# Slides", w.Body.String())
+
+		w = get("/sources/slides.md")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("X-Checksum-SHA256"))
+	})
+
+	t.Run("invalid checksum parameter", func(t *testing.T) {
+		w := get("/sources/slides.md?checksum=maybe")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestGetJobSourceSummary(t *testing.T) {
+	router := setupTestRouter(t)
+	jobID := uuid.New()
+
+	get := func(jobID uuid.UUID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/storage/jobs/"+jobID.String()+"/sources/summary", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := uploadSources(t, router, jobID, map[string]string{
+		"slides.md":         "# Slides",
+		"pages/intro.MD":    "# Intro",
+		"styles/theme.css":  "body { color: red; }",
+		"public/robots.txt": "User-agent: *",
+		"LICENSE":           "MIT",
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	t.Run("Summary by extension", func(t *testing.T) {
+		w := get(jobID)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var summary models.SourceSummary
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+		assert.Equal(t, jobID.String(), summary.JobID)
+		assert.Equal(t, 5, summary.Count)
+		assert.Equal(t, int64(8+7+20+13+3), summary.TotalSize)
+		assert.Equal(t, map[string]models.ExtensionSummary{
+			".md":  {Count: 2, Size: 15},
+			".css": {Count: 1, Size: 20},
+			".txt": {Count: 1, Size: 13},
+			"none": {Count: 1, Size: 3},
+		}, summary.Extensions)
+	})
+
+	t.Run("No sources", func(t *testing.T) {
+		w := get(uuid.New())
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "no sources found")
+	})
+}
+
+func TestValidateJobSources(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService))
+	ctx := context.Background()
+
+	// Sources modifiées directement dans le storage, sans passer par la validation
+	jobID := uuid.New()
+	for filePath, content := range map[string]string{
+		"slides.md":         "# Slides",
+		"notes:draft.md":    "# Draft",
+		"pages/a?b.md":      "# A?B",
+		"pages/a_b.md":      "# A_B",
+		"styles/theme.css":  "body {}",
+		"public/robots.txt": "User-agent: *",
+	} {
+		require.NoError(t, storageService.UploadJobSourceWithPath(ctx, jobID, filePath, strings.NewReader(content)))
+	}
+
+	validate := func(jobID uuid.UUID, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/storage/jobs/"+jobID.String()+"/sources/validate"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	repair := func(jobID uuid.UUID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/storage/jobs/"+jobID.String()+"/sources/repair", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) models.SourceTreeReport {
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var report models.SourceTreeReport
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		return report
+	}
+
+	t.Run("Report without repair", func(t *testing.T) {
+		report := decode(t, validate(jobID, ""))
+		assert.Equal(t, 6, report.Checked)
+		assert.False(t, report.Valid)
+		assert.False(t, report.Repair)
+		require.Len(t, report.Issues, 2)
+
+		assert.Equal(t, "notes:draft.md", report.Issues[0].Path)
+		assert.Equal(t, models.SourceIssueInvalidPath, report.Issues[0].Type)
+		assert.Equal(t, "notes_draft.md", report.Issues[0].SuggestedPath)
+		assert.NotEmpty(t, report.Issues[0].Errors)
+		assert.Empty(t, report.Issues[0].Action)
+
+		assert.Equal(t, "pages/a?b.md", report.Issues[1].Path)
+		assert.Equal(t, "pages/a_b.md", report.Issues[1].SuggestedPath)
+	})
+
+	t.Run("Repair renames without overwriting", func(t *testing.T) {
+		report := decode(t, repair(jobID))
+		assert.True(t, report.Repair)
+		assert.Equal(t, 1, report.Repaired)
+		assert.False(t, report.Valid)
+		require.Len(t, report.Issues, 2)
+		assert.Equal(t, models.SourceRepairRenamed, report.Issues[0].Action)
+		assert.Equal(t, models.SourceRepairSkipped, report.Issues[1].Action)
+		assert.Equal(t, "suggested path already exists", report.Issues[1].Reason)
+
+		reader, err := storageService.DownloadJobSource(ctx, jobID, "notes_draft.md")
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "# Draft", string(content))
+
+		checksum, err := storageService.JobSourceChecksum(ctx, jobID, "notes_draft.md")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(checksum, "sha256:"))
+
+		exists, err := storageService.JobSourceExists(ctx, jobID, "notes:draft.md")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		// Le conflit reste signalé aux vérifications suivantes
+		report = decode(t, validate(jobID, ""))
+		require.Len(t, report.Issues, 1)
+		assert.Equal(t, "pages/a?b.md", report.Issues[0].Path)
+	})
+
+	t.Run("Valid tree", func(t *testing.T) {
+		jobID := uuid.New()
+		require.NoError(t, storageService.UploadJobSourceWithPath(ctx, jobID, "slides.md", strings.NewReader("# Slides")))
+
+		report := decode(t, repair(jobID))
+		assert.True(t, report.Valid)
+		assert.Empty(t, report.Issues)
+	})
+
+	t.Run("No sources", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, validate(uuid.New(), "").Code)
+	})
+
+	t.Run("GET never repairs", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, validate(jobID, "?repair=true").Code)
+
+		exists, err := storageService.JobSourceExists(ctx, jobID, "pages/a?b.md")
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+}
+
+func TestUploadStylePreprocessorSources(t *testing.T) {
+	router := setupTestRouter(t)
+	jobID := uuid.New()
+
+	files := map[string]string{
+		"styles/theme.scss":   "$primary: #333;\nbody { color: $primary; }",
+		"styles/legacy.sass":  "$primary: #333\nbody\n  color: $primary",
+		"styles/extra.less":   "@primary: #333;\nbody { color: @primary; }",
+		"styles/base.postcss": "body { margin: 0; }",
+	}
+
+	w := uploadSources(t, router, jobID, files)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	expectedTypes := map[string]string{
+		"theme.scss":   "text/x-scss",
+		"legacy.sass":  "text/x-sass",
+		"extra.less":   "text/x-less",
+		"base.postcss": "text/css",
+	}
+
+	for fileName, contentType := range expectedTypes {
+		t.Run(fileName, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet,
+				"/api/v1/storage/jobs/"+jobID.String()+"/sources/"+fileName+"?filepath=styles/", nil)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, contentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, files["styles/"+fileName], w.Body.String())
+		})
+	}
+}
+
+func TestGetResultManifest(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService))
+
+	t.Run("course never built", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/storage/courses/"+uuid.New().String()+"/manifest", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("manifest available after build", func(t *testing.T) {
+		courseID := uuid.New()
+		jobID := uuid.New()
+		manifest := &models.ResultManifest{
+			CourseID:  courseID,
+			JobID:     jobID,
+			FileCount: 1,
+			TotalSize: 42,
+			Files: []models.ManifestEntry{{
+				Path:        "index.html",
+				Size:        42,
+				ContentType: "text/html; charset=utf-8",
+				Hash:        "sha256:abc",
+				JobID:       jobID,
+			}},
+		}
+		require.NoError(t, storageService.SaveResultManifest(context.Background(), manifest))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/storage/courses/"+courseID.String()+"/manifest", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response models.ResultManifest
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, courseID, response.CourseID)
+		require.Len(t, response.Files, 1)
+		assert.Equal(t, "index.html", response.Files[0].Path)
+		assert.Equal(t, jobID, response.Files[0].JobID)
+		assert.Equal(t, "/api/v1/storage/courses/"+courseID.String()+"/results/index.html", response.Files[0].URL)
+	})
+
+	t.Run("invalid course id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/storage/courses/not-a-uuid/manifest", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestGetCourseResultUsage(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService))
+
+	courseID := uuid.New()
+	storageService.SetResultQuota(400, nil)
+	require.NoError(t, storageService.UploadResult(context.Background(), courseID, "index.html", strings.NewReader(strings.Repeat("x", 100))))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/storage/courses/"+courseID.String()+"/usage", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var usage models.CourseResultUsage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &usage))
+	assert.Equal(t, courseID.String(), usage.CourseID)
+	assert.Equal(t, 1, usage.FileCount)
+	assert.Equal(t, int64(100), usage.UsedBytes)
+	assert.Equal(t, int64(400), usage.QuotaBytes)
+	assert.InDelta(t, 25.0, usage.UsagePercent, 0.01)
+}
+
+func TestGetResultDiff(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService))
+	ctx := context.Background()
+
+	courseID := uuid.New()
+	firstBuild := uuid.New()
+	secondBuild := uuid.New()
+
+	require.NoError(t, storageService.SaveResultManifest(ctx, &models.ResultManifest{
+		CourseID:  courseID,
+		JobID:     firstBuild,
+		TotalSize: 160,
+		Files: []models.ManifestEntry{
+			{Path: "index.html", Size: 100, Hash: "sha256:index-v1"},
+			{Path: "assets/old.js", Size: 50, Hash: "sha256:old"},
+			{Path: "favicon.ico", Size: 10, Hash: "sha256:icon"},
+		},
+	}))
+	require.NoError(t, storageService.SaveResultManifest(ctx, &models.ResultManifest{
+		CourseID:  courseID,
+		JobID:     secondBuild,
+		TotalSize: 200,
+		Files: []models.ManifestEntry{
+			{Path: "index.html", Size: 120, Hash: "sha256:index-v2"},
+			{Path: "assets/new.js", Size: 70, Hash: "sha256:new"},
+			{Path: "favicon.ico", Size: 10, Hash: "sha256:icon"},
+		},
+	}))
+
+	getDiff := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/storage/courses/"+courseID.String()+"/diff?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("diff between two builds", func(t *testing.T) {
+		w := getDiff("from=" + firstBuild.String() + "&to=" + secondBuild.String())
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var diff models.ResultDiff
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &diff))
+		assert.Equal(t, courseID, diff.CourseID)
+		assert.Equal(t, firstBuild, diff.From)
+		assert.Equal(t, secondBuild, diff.To)
+		assert.Equal(t, []models.FileChange{{Path: "assets/new.js", NewHash: "sha256:new", NewSize: 70, SizeDelta: 70}}, diff.Added)
+		assert.Equal(t, []models.FileChange{{Path: "assets/old.js", OldHash: "sha256:old", OldSize: 50, SizeDelta: -50}}, diff.Removed)
+		assert.Equal(t, []models.FileChange{{
+			Path: "index.html", OldHash: "sha256:index-v1", NewHash: "sha256:index-v2",
+			OldSize: 100, NewSize: 120, SizeDelta: 20,
+		}}, diff.Changed)
+		assert.Equal(t, 1, diff.UnchangedCount)
+		assert.Equal(t, int64(40), diff.SizeDelta)
+	})
+
+	t.Run("to defaults to latest", func(t *testing.T) {
+		w := getDiff("from=" + firstBuild.String())
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var diff models.ResultDiff
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &diff))
+		assert.Equal(t, secondBuild, diff.To)
+		assert.Len(t, diff.Changed, 1)
+	})
+
+	t.Run("same version", func(t *testing.T) {
+		w := getDiff("from=latest&to=" + secondBuild.String())
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var diff models.ResultDiff
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &diff))
+		assert.Empty(t, diff.Added)
+		assert.Empty(t, diff.Removed)
+		assert.Empty(t, diff.Changed)
+		assert.Equal(t, 3, diff.UnchangedCount)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		unknown := uuid.New()
+		w := getDiff("from=" + unknown.String())
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), unknown.String())
+	})
+
+	t.Run("course never built", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/storage/courses/"+uuid.New().String()+"/diff?from="+firstBuild.String(), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid versions", func(t *testing.T) {
+		for _, query := range []string{"", "to=latest", "from=v1", "from=latest&to=" + uuid.Nil.String()} {
+			w := getDiff(query)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
+
+func TestListResultsURLs(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	ctx := context.Background()
+
+	courseID := uuid.New()
+	require.NoError(t, storageService.UploadResult(ctx, courseID, "index.html", bytes.NewReader([]byte("<html>"))))
+	require.NoError(t, storageService.UploadResult(ctx, courseID, "assets/main file.js", bytes.NewReader([]byte("x"))))
+
+	listResults := func(router *gin.Engine) models.FileListResponse {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/storage/courses/"+courseID.String()+"/results", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response models.FileListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	resultsPath := "/api/v1/storage/courses/" + courseID.String() + "/results/"
+
+	t.Run("relative paths without base URL", func(t *testing.T) {
+		router := SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService))
+
+		response := listResults(router)
+		assert.Equal(t, resultsPath+"index.html", response.URLs["index.html"])
+		assert.Equal(t, resultsPath+"assets/main%20file.js", response.URLs["assets/main file.js"])
+	})
+
+	t.Run("absolute URLs with base URL", func(t *testing.T) {
+		router := SetupRouterWithConfig(jobService, storageService, createMockWorkerPool(jobService, storageService), &RouterConfig{
+			PublicBaseURL: "https://worker.example.com/",
+		})
+
+		response := listResults(router)
+		assert.Equal(t, "https://worker.example.com"+resultsPath+"index.html", response.URLs["index.html"])
+	})
+}
+
+func TestJobResults(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService))
+	ctx := context.Background()
+	courseID := uuid.New()
+
+	createJob := func(t *testing.T, resultPrefix string) *models.GenerationJob {
+		job, err := jobService.CreateJob(ctx, &models.GenerationRequest{
+			JobID:        uuid.New(),
+			CourseID:     courseID,
+			SourcePath:   "sources/",
+			ResultPrefix: resultPrefix,
+		})
+		require.NoError(t, err)
+		return job
+	}
+
+	get := func(t *testing.T, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	require.NoError(t, storageService.UploadResult(ctx, courseID, "index.html", strings.NewReader("course build")))
+
+	custom := createJob(t, "acme/intro-go/v3")
+	prefixed := storage.WithResultPrefix(ctx, custom.ResultPrefix)
+	require.NoError(t, storageService.UploadResult(prefixed, courseID, "index.html", strings.NewReader("custom build")))
+	require.NoError(t, storageService.UploadResult(prefixed, courseID, "assets/slidev/app.js", strings.NewReader("run()")))
+
+	t.Run("Custom destination", func(t *testing.T) {
+		w := get(t, "/api/v1/storage/jobs/"+custom.ID.String()+"/results")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response models.FileListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "acme/intro-go/v3", response.ResultPrefix)
+		assert.ElementsMatch(t, []string{"index.html", "assets/slidev/app.js"}, response.Files)
+		assert.Equal(t, "/api/v1/storage/jobs/"+custom.ID.String()+"/results/assets/slidev/app.js", response.URLs["assets/slidev/app.js"])
+
+		w = get(t, response.URLs["assets/slidev/app.js"])
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "run()", w.Body.String())
+
+		w = get(t, "/api/v1/storage/jobs/"+custom.ID.String()+"/results/")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "custom build", w.Body.String())
+
+		// Les résultats du cours ne sont pas modifiés
+		w = get(t, "/api/v1/storage/courses/"+courseID.String()+"/results/index.html")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "course build", w.Body.String())
+	})
+
+	t.Run("Default destination", func(t *testing.T) {
+		job := createJob(t, "")
+		w := get(t, "/api/v1/storage/jobs/"+job.ID.String()+"/results/index.html")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "course build", w.Body.String())
+	})
+
+	t.Run("Missing file or job", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get(t, "/api/v1/storage/jobs/"+custom.ID.String()+"/results/missing.js").Code)
+		assert.Equal(t, http.StatusNotFound, get(t, "/api/v1/storage/jobs/"+uuid.NewString()+"/results").Code)
+		assert.Equal(t, http.StatusBadRequest, get(t, "/api/v1/storage/jobs/"+custom.ID.String()+"/results/../secrets.txt").Code)
+	})
+
+	t.Run("Job response", func(t *testing.T) {
+		response := custom.ToResponse()
+		assert.Equal(t, "acme/intro-go/v3", response.ResultPrefix)
+		assert.Equal(t, "/api/v1/storage/jobs/"+custom.ID.String()+"/results", response.ResultsURL)
+	})
+}
+
+func TestViewResult(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService))
+	ctx := context.Background()
+
+	courseID := uuid.New()
+	require.NoError(t, storageService.UploadResult(ctx, courseID, "index.html", bytes.NewReader([]byte("<html>course</html>"))))
+	require.NoError(t, storageService.UploadResult(ctx, courseID, "assets/index.js", bytes.NewReader([]byte("console.log(1)"))))
+
+	viewPath := "/api/v1/storage/courses/" + courseID.String() + "/view/"
+	view := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("html served inline", func(t *testing.T) {
+		w := view(viewPath + "index.html")
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "text/html", w.Header().Get("Content-Type"))
+		assert.Empty(t, w.Header().Get("Content-Disposition"))
+		assert.Equal(t, "<html>course</html>", w.Body.String())
+
+		// Le cours est isolé de l'origine de l'API
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		assert.True(t, strings.HasPrefix(w.Header().Get("Content-Security-Policy"), "sandbox "))
+		assert.NotContains(t, w.Header().Get("Content-Security-Policy"), "allow-same-origin")
+	})
+
+	t.Run("nested asset", func(t *testing.T) {
+		w := view(viewPath + "assets/index.js")
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "application/javascript", w.Header().Get("Content-Type"))
+		assert.Equal(t, "console.log(1)", w.Body.String())
+	})
+
+	t.Run("root and slide routes serve index.html", func(t *testing.T) {
+		for _, path := range []string{viewPath, viewPath + "3", viewPath + "presenter/3"} {
+			w := view(path)
+
+			require.Equal(t, http.StatusOK, w.Code, path)
+			assert.Equal(t, "text/html", w.Header().Get("Content-Type"), path)
+			assert.Equal(t, "<html>course</html>", w.Body.String(), path)
+		}
+	})
+
+	t.Run("directory serves its index.html", func(t *testing.T) {
+		require.NoError(t, storageService.UploadResult(ctx, courseID, "guide/index.html", bytes.NewReader([]byte("<html>guide</html>"))))
+
+		w := view(viewPath + "guide/")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "<html>guide</html>", w.Body.String())
+
+		// Sans slash final, redirection vers le dossier plutôt que repli sur l'app
+		w = view(viewPath + "guide?theme=dark")
+		require.Equal(t, http.StatusMovedPermanently, w.Code, w.Body.String())
+		assert.Equal(t, viewPath+"guide/?theme=dark", w.Header().Get("Location"))
+	})
+
+	t.Run("SPA fallback disabled by course metadata", func(t *testing.T) {
+		otherCourseID := uuid.New()
+		require.NoError(t, storageService.UploadResult(ctx, otherCourseID, "index.html", bytes.NewReader([]byte("<html>other</html>"))))
+		require.NoError(t, storageService.SaveResultManifest(ctx, &models.ResultManifest{
+			CourseID:    otherCourseID,
+			SPAFallback: models.SPAFallbackFromMetadata(models.JSON{models.MetadataSPAFallback: false}),
+		}))
+		otherViewPath := "/api/v1/storage/courses/" + otherCourseID.String() + "/view/"
+
+		assert.Equal(t, http.StatusNotFound, view(otherViewPath+"3").Code)
+		assert.Equal(t, http.StatusOK, view(otherViewPath).Code, "index still served for the root")
+	})
+
+	t.Run("compressed variant served when accepted", func(t *testing.T) {
+		var compressed bytes.Buffer
+		gzipWriter := gzip.NewWriter(&compressed)
+		_, err := gzipWriter.Write([]byte("console.log(1)"))
+		require.NoError(t, err)
+		require.NoError(t, gzipWriter.Close())
+		require.NoError(t, storageService.UploadResult(ctx, courseID, "assets/index.js.gz", bytes.NewReader(compressed.Bytes())))
+
+		req := httptest.NewRequest(http.MethodGet, viewPath+"assets/index.js", nil)
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		assert.Equal(t, "application/javascript", w.Header().Get("Content-Type"))
+		assert.Equal(t, compressed.Bytes(), w.Body.Bytes())
+
+		// Sans Accept-Encoding, l'original est servi
+		w = view(viewPath + "assets/index.js")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "console.log(1)", w.Body.String())
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		w := view(viewPath + "assets/missing.css")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("path traversal rejected", func(t *testing.T) {
+		for _, path := range []string{viewPath + "assets/%2E%2E/%2E%2E/secret.html", viewPath + "..%2Fsecret.html"} {
+			w := view(path)
+			assert.Equal(t, http.StatusBadRequest, w.Code, path)
+		}
+	})
+
+	t.Run("invalid course id", func(t *testing.T) {
+		w := view("/api/v1/storage/courses/not-a-uuid/view/index.html")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestGetJobLogsLevelFilter(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService))
+
+	jobID := uuid.New()
+	logs := "[10:00:01] STDOUT: Building slides...\n" +
+		"WARNING: Package installation failed: timeout\n" +
+		"ERROR: Slidev build failed: exit status 1\n"
+	require.NoError(t, storageService.SaveJobLog(context.Background(), jobID, logs))
+
+	getLogs := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/storage/jobs/"+jobID.String()+"/logs"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("full logs by default", func(t *testing.T) {
+		w := getLogs("")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, logs, w.Body.String())
+	})
+
+	t.Run("errors only", func(t *testing.T) {
+		w := getLogs("?level=error")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "ERROR: Slidev build failed: exit status 1\n", w.Body.String())
+	})
+
+	t.Run("invalid level", func(t *testing.T) {
+		w := getLogs("?level=debug")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "INVALID_LOG_LEVEL")
+	})
+
+	t.Run("json lines on demand", func(t *testing.T) {
+		w := getLogs("?format=jsonl&level=error")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		assert.Equal(t, `{"msg":"ERROR: Slidev build failed: exit status 1"}`+"\n", w.Body.String())
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		w := getLogs("?format=xml")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "INVALID_LOG_FORMAT")
+	})
+}
+
+func TestGetJobLogsStoredAsJSONL(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService))
+
+	jobID := uuid.New()
+	lines := []string{"[10:00:01] STDOUT: Building slides...", "ERROR: Slidev build failed"}
+	require.NoError(t, storageService.SaveJobLogLines(context.Background(), jobID, lines, storage.LogFormatJSONL))
+
+	getLogs := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/storage/jobs/"+jobID.String()+"/logs"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := getLogs("")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"ts":"10:00:01","stream":"STDOUT","msg":"Building slides..."}`+"\n"+`{"msg":"ERROR: Slidev build failed"}`+"\n", w.Body.String())
+
+	w = getLogs("?format=text")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "[10:00:01] STDOUT: Building slides...\nERROR: Slidev build failed\n", w.Body.String())
+}
+
+func TestGetJobLogsGzip(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouter(jobService, storageService, createMockWorkerPool(jobService, storageService))
+
+	jobID := uuid.New()
+	logs := strings.Repeat("[10:00:01] STDOUT: Building slides...\n", 100) + "ERROR: Slidev build failed\n"
+	require.NoError(t, storageService.SaveJobLog(context.Background(), jobID, logs))
+
+	getLogs := func(query, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/storage/jobs/"+jobID.String()+"/logs"+query, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	gunzip := func(t *testing.T, body []byte) string {
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("gzip accepted", func(t *testing.T) {
+		w := getLogs("", "gzip, deflate")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		assert.Less(t, w.Body.Len(), len(logs))
+		assert.Equal(t, logs, gunzip(t, w.Body.Bytes()))
+	})
+
+	t.Run("plain logs without gzip", func(t *testing.T) {
+		for _, acceptEncoding := range []string{"", "br", "gzip;q=0"} {
+			w := getLogs("", acceptEncoding)
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Empty(t, w.Header().Get("Content-Encoding"), acceptEncoding)
+			assert.Equal(t, logs, w.Body.String(), acceptEncoding)
+		}
+	})
+
+	t.Run("filtered logs below the threshold stay plain", func(t *testing.T) {
+		w := getLogs("?level=error", "gzip")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "ERROR: Slidev build failed\n", w.Body.String())
+	})
+
+	t.Run("stored gzip is served as is", func(t *testing.T) {
+		stored, _, err := storageService.GetCompressedJobLog(context.Background(), jobID)
+		require.NoError(t, err)
+
+		w := getLogs("?format=text&level=all", "gzip")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, stored, w.Body.Bytes())
+	})
+
+	t.Run("converted logs are compressed", func(t *testing.T) {
+		w := getLogs("?format=jsonl", "gzip")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		assert.Contains(t, gunzip(t, w.Body.Bytes()), `{"msg":"ERROR: Slidev build failed"}`)
+	})
+}
+
+func TestGetUploadLimits(t *testing.T) {
+	get := func(t *testing.T, router *gin.Engine) models.UploadLimits {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/storage/limits", nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var limits models.UploadLimits
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &limits))
+		return limits
+	}
+
+	t.Run("Default configuration", func(t *testing.T) {
+		defaults := validation.DefaultValidationConfig()
+		limits := get(t, setupTestRouterWithValidation(t, nil))
+
+		assert.Len(t, limits.AllowedExtensions, len(defaults.AllowedExtensions))
+		assert.Contains(t, limits.AllowedExtensions, ".md")
+		assert.IsIncreasing(t, limits.AllowedExtensions)
+		assert.Contains(t, limits.AllowedMimeTypes, "text/markdown")
+		assert.Equal(t, defaults.MaxFileSize, limits.MaxFileSize)
+		assert.Equal(t, defaults.MaxTotalSize, limits.MaxTotalSize)
+		assert.Equal(t, defaults.MaxFiles, limits.MaxFiles)
+		assert.Equal(t, validation.DefaultMaxPathDepth, limits.MaxPathDepth)
+		assert.Equal(t, validation.DefaultMaxArchiveExpansionRatio, limits.MaxArchiveExpansionRatio)
+		assert.Nil(t, limits.DirectoryRules)
+	})
+
+	t.Run("Active configuration", func(t *testing.T) {
+		config := validation.DefaultValidationConfig()
+		config.MaxFiles = 20
+		config.MaxFileSize = 1024
+		config.MaxPathDepth = 4
+		config.AllowedExtensions = map[string]bool{".md": true, ".png": true, ".exe": false}
+		rules, err := validation.ParseDirectoryRules(map[string]string{"assets": "binary", "data": ".tsv|.csv"})
+		require.NoError(t, err)
+		config.DirectoryRules = rules
+
+		limits := get(t, setupTestRouterWithValidation(t, config))
+		assert.Equal(t, []string{".md", ".png"}, limits.AllowedExtensions)
+		assert.Equal(t, 20, limits.MaxFiles)
+		assert.Equal(t, int64(1024), limits.MaxFileSize)
+		assert.Equal(t, 4, limits.MaxPathDepth)
+		assert.Equal(t, map[string]string{"assets": "binary", "data": ".csv|.tsv"}, limits.DirectoryRules)
+	})
+}
+
+func TestResultCacheControl(t *testing.T) {
+	t.Run("policy", func(t *testing.T) {
+		var defaultPolicy *ResultCachePolicy
+		assert.Equal(t, DefaultResultCacheControl, defaultPolicy.CacheControl("index.html"))
+
+		policy := &ResultCachePolicy{
+			Default: "max-age=300",
+			Rules: map[string]string{
+				".html":                 "no-cache",
+				".js":                   "max-age=3600",
+				ResultCacheHashedAssets: "public, max-age=31536000, immutable",
+			},
+		}
+		for filePath, expected := range map[string]string{
+			"index.html":                 "no-cache",
+			"guide/INDEX.HTML":           "no-cache",
+			"assets/index-B2x_k9Qa.js":   "public, max-age=31536000, immutable",
+			"assets/slidev-Dq-4_bZ1.css": "public, max-age=31536000, immutable",
+			"assets/index.js":            "max-age=3600",
+			"scripts/app-B2x_k9Qa.js":    "max-age=3600",
+			"assets/logo.png":            "max-age=300",
+		} {
+			assert.Equal(t, expected, policy.CacheControl(filePath), filePath)
+		}
+	})
+
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouterWithConfig(jobService, storageService, createMockWorkerPool(jobService, storageService), &RouterConfig{
+		ResultCachePolicy: &ResultCachePolicy{Rules: map[string]string{
+			".html":                 "no-cache",
+			ResultCacheHashedAssets: "public, max-age=31536000, immutable",
+		}},
+	})
+	ctx := context.Background()
+
+	courseID := uuid.New()
+	require.NoError(t, storageService.UploadResult(ctx, courseID, "index.html", bytes.NewReader([]byte("<html>course</html>"))))
+	require.NoError(t, storageService.UploadResult(ctx, courseID, "assets/index-B2x_k9Qa.js", bytes.NewReader([]byte("console.log(1)"))))
+	require.NoError(t, storageService.UploadResult(ctx, courseID, "assets/logo.png", bytes.NewReader([]byte("png"))))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/storage/courses/"+courseID.String()+path, nil))
+		return w
+	}
+
+	for path, expected := range map[string]string{
+		"/view/index.html":               "no-cache",
+		"/view/2":                        "no-cache",
+		"/view/assets/index-B2x_k9Qa.js": "public, max-age=31536000, immutable",
+		"/view/assets/logo.png":          DefaultResultCacheControl,
+		"/results/index.html":            "no-cache",
+	} {
+		w := get(path)
+		require.Equal(t, http.StatusOK, w.Code, path)
+		assert.Equal(t, expected, w.Header().Get("Cache-Control"), path)
+	}
+
+	w := get("/view/assets/missing.js")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("Cache-Control"))
+}
+
+func TestListJobSourcesUnknownJob(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	workerPool := createMockWorkerPool(jobService, storageService)
+	ctx := context.Background()
+
+	list := func(router *gin.Engine, jobID uuid.UUID, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/storage/jobs/"+jobID.String()+"/sources"+query, nil))
+		return w
+	}
+
+	existingJob, err := jobService.CreateJob(ctx, &models.GenerationRequest{
+		JobID:      uuid.New(),
+		CourseID:   uuid.New(),
+		SourcePath: "test/path",
+	})
+	require.NoError(t, err)
+
+	t.Run("empty list by default", func(t *testing.T) {
+		router := SetupRouter(jobService, storageService, workerPool)
+
+		w := list(router, uuid.New(), "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"count":0`)
+	})
+
+	router := SetupRouterWithConfig(jobService, storageService, workerPool, &RouterConfig{UnknownJobSourcesNotFound: true})
+
+	t.Run("unknown job without sources", func(t *testing.T) {
+		for _, query := range []string{"", "?format=tree"} {
+			w := list(router, uuid.New(), query)
+			assert.Equal(t, http.StatusNotFound, w.Code, query)
+			assert.Contains(t, w.Body.String(), "SOURCES_NOT_FOUND", query)
+		}
+	})
+
+	t.Run("existing job without sources", func(t *testing.T) {
+		w := list(router, existingJob.ID, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"count":0`)
+	})
+
+	t.Run("sources uploaded before the job is created", func(t *testing.T) {
+		jobID := uuid.New()
+		require.NoError(t, storageService.UploadJobSource(ctx, jobID, "slides.md", strings.NewReader("# Cours")))
+
+		w := list(router, jobID, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"count":1`)
+	})
+}
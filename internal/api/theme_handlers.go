@@ -0,0 +1,93 @@
+// internal/api/theme_handlers.go - Aperçu des thèmes Slidev
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/worker"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultThemePreviewRateLimit est le nombre d'aperçus de thèmes par minute et par client
+// quand aucune limite n'est configurée
+const DefaultThemePreviewRateLimit = 5
+
+// themePreviewContentTypes associe les formats d'aperçu à leur type MIME
+var themePreviewContentTypes = map[string]string{
+	worker.ThemePreviewPNG: "image/png",
+	worker.ThemePreviewPDF: "application/pdf",
+}
+
+// ThemeHandlers gère les aperçus de thèmes
+type ThemeHandlers struct {
+	workerPool *worker.WorkerPool
+}
+
+// NewThemeHandlers crée un nouveau gestionnaire d'aperçus de thèmes
+func NewThemeHandlers(workerPool *worker.WorkerPool) *ThemeHandlers {
+	return &ThemeHandlers{
+		workerPool: workerPool,
+	}
+}
+
+// PreviewTheme génère l'aperçu de la première slide d'un deck d'exemple avec un thème
+// @Summary Aperçu d'un thème
+// @Description Installe le thème (via le cache NPM des builds), construit un petit deck d'exemple
+// @Description et retourne sa première slide en PNG ou en PDF, pour choisir un thème avant de l'adopter.
+// @Description
+// @Description Le thème est un nom Slidev (`seriph` → `@slidev/theme-seriph`, `penguin` → `slidev-theme-penguin`)
+// @Description ou un paquet non scopé. Sans `version`, la dernière version publiée est utilisée.
+// @Description Les aperçus sont mis en cache par version : seul le premier appel paie le build.
+// @Description
+// @Description La génération occupe un créneau de build : l'endpoint est limité par client
+// @Description (`THEME_PREVIEW_RATE_LIMIT` par minute, 5 par défaut).
+// @Tags Themes
+// @Produce image/png
+// @Produce application/pdf
+// @Param theme path string true "Nom du thème" example(seriph)
+// @Param version query string false "Version exacte du thème (défaut: dernière version)" example(0.25.0)
+// @Param format query string false "Format de l'aperçu" Enums(png, pdf) default(png)
+// @Success 200 {file} binary "Aperçu de la première slide"
+// @Header 200 {string} X-Theme-Package "Paquet npm du thème"
+// @Header 200 {string} X-Theme-Version "Version du thème utilisée"
+// @Header 200 {string} X-Preview-Cache "hit si l'aperçu vient du cache, miss sinon"
+// @Failure 400 {object} models.ErrorResponse "Paramètres invalides"
+// @Failure 403 {object} models.ErrorResponse "Thème interdit par la politique du worker (ALLOWED_THEMES, DENIED_THEMES)"
+// @Failure 404 {object} models.ErrorResponse "Thème ou version introuvable sur npm"
+// @Failure 429 {object} models.ErrorResponse "Trop d'aperçus demandés"
+// @Failure 500 {object} models.ErrorResponse "Échec de l'installation ou de l'export"
+// @Router /themes/{theme}/preview [post]
+func (h *ThemeHandlers) PreviewTheme(c *gin.Context) {
+	theme := c.MustGet("validated_theme").(string)
+	version := c.MustGet("validated_theme_version").(string)
+	format := c.MustGet("validated_preview_format").(string)
+
+	preview, err := h.workerPool.PreviewTheme(c.Request.Context(), theme, version, format)
+	if err != nil {
+		if errors.Is(err, worker.ErrThemeNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, worker.ErrThemeNotAllowed) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "THEME_NOT_ALLOWED"})
+			return
+		}
+		log.Printf("Theme preview failed for %s: %v", theme, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("theme preview failed: %v", err)})
+		return
+	}
+
+	cacheStatus := "miss"
+	if preview.Cached {
+		cacheStatus = "hit"
+	}
+
+	c.Header("X-Theme-Package", preview.Package)
+	c.Header("X-Theme-Version", preview.Version)
+	c.Header("X-Preview-Cache", cacheStatus)
+	c.Data(http.StatusOK, themePreviewContentTypes[preview.Format], preview.Data)
+}
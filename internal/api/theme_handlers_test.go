@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewThemeValidation(t *testing.T) {
+	router := setupTestRouter(t)
+
+	tests := []struct {
+		name string
+		path string
+		code string
+	}{
+		{"Leading dot", "/api/v1/themes/.seriph/preview", "INVALID_THEME"},
+		{"Uppercase theme", "/api/v1/themes/Seriph/preview", "INVALID_THEME"},
+		{"Version range", "/api/v1/themes/seriph/preview?version=^1.0.0", "INVALID_VERSION"},
+		{"Unknown format", "/api/v1/themes/seriph/preview?format=gif", "INVALID_FORMAT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), tt.code)
+		})
+	}
+}
+
+func TestPreviewThemeRateLimit(t *testing.T) {
+	jobService, storageService := setupTestServices(t)
+	router := SetupRouterWithConfig(jobService, storageService, createMockWorkerPool(jobService, storageService),
+		&RouterConfig{ThemePreviewRateLimit: 2})
+
+	// Les requêtes invalides comptent aussi : la limite passe avant la validation
+	codes := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/themes/seriph/preview?format=gif", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+	}
+
+	assert.Equal(t, []int{http.StatusBadRequest, http.StatusBadRequest, http.StatusTooManyRequests}, codes)
+}
@@ -0,0 +1,191 @@
+// internal/api/upload_sessions.go - Suivi de l'avancement des uploads de sources
+package api
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultUploadSessionTTL est la durée de conservation d'une session après la fin de son upload
+	DefaultUploadSessionTTL = 10 * time.Minute
+	// uploadSessionIdleTTL est la durée au bout de laquelle une session sans activité expire,
+	// que son upload ne soit pas arrivé ou qu'il n'avance plus
+	uploadSessionIdleTTL = 15 * time.Minute
+	// maxUploadSessionsPerJob est le nombre de sessions ouvertes (pending ou uploading) par job
+	maxUploadSessionsPerJob = 5
+	// maxUploadSessions borne le nombre total de sessions gardées en mémoire
+	maxUploadSessions = 10000
+)
+
+var (
+	// ErrUploadSessionNotFound est retournée pour une session inconnue ou expirée
+	ErrUploadSessionNotFound = errors.New("upload session not found or expired")
+	// ErrUploadSessionJobMismatch est retournée quand la session a été créée pour un autre job
+	ErrUploadSessionJobMismatch = errors.New("upload session belongs to another job")
+	// ErrUploadSessionUsed est retournée quand un upload a déjà utilisé la session
+	ErrUploadSessionUsed = errors.New("upload session already used")
+	// ErrUploadSessionLimit est retournée quand le job ou l'instance a trop de sessions ouvertes
+	ErrUploadSessionLimit = errors.New("too many open upload sessions")
+)
+
+// UploadSessions garde en mémoire l'avancement des uploads de sources. Une session est créée
+// avant l'upload, qui la référence ; le client suit l'écriture des fichiers en l'interrogeant.
+// Les sessions expirent ttl après la fin de leur upload, ou après uploadSessionIdleTTL sans
+// activité tant que l'upload n'est pas terminé. Le nombre de sessions ouvertes par job est borné.
+type UploadSessions struct {
+	mu       sync.Mutex
+	sessions map[uuid.UUID]*models.UploadProgress
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+// NewUploadSessions crée un registre de sessions d'upload (ttl <= 0 = DefaultUploadSessionTTL)
+func NewUploadSessions(ttl time.Duration) *UploadSessions {
+	if ttl <= 0 {
+		ttl = DefaultUploadSessionTTL
+	}
+	return &UploadSessions{
+		sessions: make(map[uuid.UUID]*models.UploadProgress),
+		ttl:      ttl,
+		now:      time.Now,
+	}
+}
+
+// Create ouvre une session de suivi pour le prochain upload de sources d'un job
+func (us *UploadSessions) Create(jobID uuid.UUID) (models.UploadProgress, error) {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	us.expireLocked()
+
+	if len(us.sessions) >= maxUploadSessions {
+		return models.UploadProgress{}, ErrUploadSessionLimit
+	}
+	open := 0
+	for _, session := range us.sessions {
+		if session.JobID == jobID.String() && session.CompletedAt == nil {
+			open++
+		}
+	}
+	if open >= maxUploadSessionsPerJob {
+		return models.UploadProgress{}, ErrUploadSessionLimit
+	}
+
+	now := us.now()
+	session := &models.UploadProgress{
+		SessionID: uuid.NewString(),
+		JobID:     jobID.String(),
+		Status:    models.UploadSessionPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+		ExpiresAt: now.Add(uploadSessionIdleTTL),
+	}
+	us.sessions[uuid.MustParse(session.SessionID)] = session
+	return *session, nil
+}
+
+// Get retourne l'avancement d'une session
+func (us *UploadSessions) Get(sessionID uuid.UUID) (models.UploadProgress, bool) {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	us.expireLocked()
+
+	session, exists := us.sessions[sessionID]
+	if !exists {
+		return models.UploadProgress{}, false
+	}
+	return *session, true
+}
+
+// Claim réserve une session pending pour l'upload d'un job
+func (us *UploadSessions) Claim(sessionID, jobID uuid.UUID) error {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	us.expireLocked()
+
+	session, exists := us.sessions[sessionID]
+	switch {
+	case !exists:
+		return ErrUploadSessionNotFound
+	case session.JobID != jobID.String():
+		return ErrUploadSessionJobMismatch
+	case session.Status != models.UploadSessionPending:
+		return ErrUploadSessionUsed
+	}
+
+	now := us.now()
+	session.Status = models.UploadSessionUploading
+	session.UpdatedAt = now
+	session.ExpiresAt = now.Add(uploadSessionIdleTTL)
+	return nil
+}
+
+// Begin enregistre le nombre et la taille des fichiers à écrire
+func (us *UploadSessions) Begin(sessionID uuid.UUID, totalFiles int, totalBytes int64) {
+	us.update(sessionID, func(session *models.UploadProgress) {
+		session.TotalFiles = totalFiles
+		session.TotalBytes = totalBytes
+	})
+}
+
+// FileStored compte un fichier écrit (err == nil) ou en échec. Seuls les octets des fichiers
+// écrits sont comptés, et les rapports arrivés après la fin de l'upload sont ignorés.
+func (us *UploadSessions) FileStored(sessionID uuid.UUID, size int64, err error) {
+	us.update(sessionID, func(session *models.UploadProgress) {
+		if session.Status != models.UploadSessionUploading {
+			return
+		}
+		if err != nil {
+			session.FailedFiles++
+			return
+		}
+		session.StoredFiles++
+		session.StoredBytes += size
+	})
+}
+
+// Finish termine l'upload d'une session selon le statut HTTP de sa réponse ; la session
+// expire ttl plus tard
+func (us *UploadSessions) Finish(sessionID uuid.UUID, httpStatus int) {
+	us.update(sessionID, func(session *models.UploadProgress) {
+		now := us.now()
+		session.Status = models.UploadSessionCompleted
+		if httpStatus >= 400 {
+			session.Status = models.UploadSessionFailed
+			session.Error = fmt.Sprintf("upload rejected with status %d", httpStatus)
+		}
+		session.CompletedAt = &now
+		session.ExpiresAt = now.Add(us.ttl)
+	})
+}
+
+// update modifie une session existante sous verrou ; une session dont l'upload est en cours
+// reste ouverte uploadSessionIdleTTL après sa dernière activité
+func (us *UploadSessions) update(sessionID uuid.UUID, apply func(session *models.UploadProgress)) {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	if session, exists := us.sessions[sessionID]; exists {
+		apply(session)
+		session.UpdatedAt = us.now()
+		if session.Status == models.UploadSessionUploading {
+			session.ExpiresAt = session.UpdatedAt.Add(uploadSessionIdleTTL)
+		}
+	}
+}
+
+// expireLocked oublie les sessions expirées (verrou tenu)
+func (us *UploadSessions) expireLocked() {
+	now := us.now()
+	for id, session := range us.sessions {
+		if now.After(session.ExpiresAt) {
+			delete(us.sessions, id)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadSessionsExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	sessions := NewUploadSessions(time.Minute)
+	sessions.now = func() time.Time { return now }
+
+	jobID := uuid.New()
+
+	t.Run("pending session expires when unused", func(t *testing.T) {
+		session, err := sessions.Create(jobID)
+		require.NoError(t, err)
+		sessionID := uuid.MustParse(session.SessionID)
+
+		now = now.Add(uploadSessionIdleTTL - time.Second)
+		_, exists := sessions.Get(sessionID)
+		assert.True(t, exists)
+
+		now = now.Add(2 * time.Second)
+		_, exists = sessions.Get(sessionID)
+		assert.False(t, exists)
+		assert.ErrorIs(t, sessions.Claim(sessionID, jobID), ErrUploadSessionNotFound)
+	})
+
+	t.Run("uploading session stays open while it progresses", func(t *testing.T) {
+		session, err := sessions.Create(jobID)
+		require.NoError(t, err)
+		sessionID := uuid.MustParse(session.SessionID)
+		require.NoError(t, sessions.Claim(sessionID, jobID))
+		sessions.Begin(sessionID, 2, 30)
+
+		now = now.Add(uploadSessionIdleTTL - time.Second)
+		sessions.FileStored(sessionID, 10, nil)
+		now = now.Add(uploadSessionIdleTTL - time.Second)
+		sessions.FileStored(sessionID, 20, errors.New("storage unavailable"))
+
+		progress, exists := sessions.Get(sessionID)
+		require.True(t, exists)
+		assert.Equal(t, models.UploadSessionUploading, progress.Status)
+		assert.Equal(t, 1, progress.StoredFiles)
+		assert.Equal(t, 1, progress.FailedFiles)
+		assert.Equal(t, int64(10), progress.StoredBytes, "only stored files count")
+
+		sessions.Finish(sessionID, 500)
+		sessions.FileStored(sessionID, 20, nil)
+		progress, _ = sessions.Get(sessionID)
+		assert.Equal(t, models.UploadSessionFailed, progress.Status)
+		assert.Equal(t, int64(10), progress.StoredBytes, "reports after the end are ignored")
+
+		now = now.Add(time.Minute + time.Second)
+		_, exists = sessions.Get(sessionID)
+		assert.False(t, exists, "finished session expires after the ttl")
+	})
+
+	t.Run("idle uploading session expires", func(t *testing.T) {
+		session, err := sessions.Create(jobID)
+		require.NoError(t, err)
+		sessionID := uuid.MustParse(session.SessionID)
+		require.NoError(t, sessions.Claim(sessionID, jobID))
+
+		now = now.Add(uploadSessionIdleTTL + time.Second)
+		_, exists := sessions.Get(sessionID)
+		assert.False(t, exists)
+	})
+}
+
+func TestUploadSessionsLimit(t *testing.T) {
+	sessions := NewUploadSessions(time.Minute)
+	jobID := uuid.New()
+
+	var first models.UploadProgress
+	for i := 0; i < maxUploadSessionsPerJob; i++ {
+		session, err := sessions.Create(jobID)
+		require.NoError(t, err)
+		if i == 0 {
+			first = session
+		}
+	}
+
+	_, err := sessions.Create(jobID)
+	assert.ErrorIs(t, err, ErrUploadSessionLimit)
+
+	_, err = sessions.Create(uuid.New())
+	assert.NoError(t, err, "the limit applies per job")
+
+	// Une session terminée libère une place
+	firstID := uuid.MustParse(first.SessionID)
+	require.NoError(t, sessions.Claim(firstID, jobID))
+	sessions.Finish(firstID, 201)
+	_, err = sessions.Create(jobID)
+	assert.NoError(t, err)
+}
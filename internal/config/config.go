@@ -2,11 +2,19 @@
 package config
 
 import (
+	"fmt"
+	"log"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Open-Course-Factory/ocf-worker/internal/validation"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
 	"github.com/Open-Course-Factory/ocf-worker/pkg/storage"
+
+	"github.com/google/uuid"
 )
 
 type Config struct {
@@ -18,20 +26,262 @@ type Config struct {
 	Environment     string
 	Storage         *storage.StorageConfig
 	Worker          *WorkerConfig
+	Callback        *CallbackConfig
+	Upload          *UploadConfig
+	Server          *ServerConfig
+
+	// MaxActiveJobsPerClient limite les jobs pending + processing par client (0 = illimité)
+	MaxActiveJobsPerClient int
+
+	// MaxBatchSize limite le nombre de jobs par soumission groupée
+	MaxBatchSize int
+
+	// MaxMetadataSize borne la taille des métadonnées d'un job sérialisées en JSON (octets) ;
+	// MaxMetadataDepth leur profondeur d'imbrication
+	MaxMetadataSize  int
+	MaxMetadataDepth int
+
+	// JobCacheTTL est la durée de vie des jobs actifs dans le cache mémoire (0 = désactivé)
+	JobCacheTTL time.Duration
+
+	// PublicBaseURL préfixe les URLs de téléchargement des résultats (vide = chemins relatifs)
+	PublicBaseURL string
+
+	// StorageNamespace préfixe toutes les clés de stockage du déploiement (vide = aucun)
+	StorageNamespace string
+
+	// StorageNamespacePerClient isole le stockage de chaque client authentifié dans son namespace
+	StorageNamespacePerClient bool
+
+	// CancelOnDisconnectWindow est la durée maximale pendant laquelle une création de job
+	// avec cancel_on_disconnect attend son démarrage
+	CancelOnDisconnectWindow time.Duration
+
+	// ThemePreviewRateLimit limite les aperçus de thèmes par minute et par client
+	ThemePreviewRateLimit int
+
+	// ArchiveReadConcurrency est le nombre de résultats lus en avance pendant la création
+	// d'une archive (0 = lectures séquentielles)
+	ArchiveReadConcurrency int
+
+	// StorageTransferWindow remet à zéro les compteurs d'octets transférés à chaque fenêtre
+	// (0 = cumul depuis le démarrage)
+	StorageTransferWindow time.Duration
+
+	// CourseResultQuota borne la taille des résultats stockés d'un cours en octets (0 = illimité) ;
+	// CourseResultQuotas la remplace pour certains cours
+	CourseResultQuota  int64
+	CourseResultQuotas map[uuid.UUID]int64
+
+	// ManifestVersions est le nombre de versions de manifeste conservées par cours (0 = toutes)
+	ManifestVersions int
+
+	// SLOTargets sont les objectifs de latence des jobs suivis (vide = objectifs par défaut) ;
+	// SLOWindow la fenêtre glissante par défaut du suivi
+	SLOTargets []time.Duration
+	SLOWindow  time.Duration
+
+	// ResultCacheControl est le Cache-Control par défaut des résultats servis ;
+	// ResultCacheControlRules le remplace par extension (".html") ou pour les assets
+	// empreintés ("hashed")
+	ResultCacheControl      string
+	ResultCacheControlRules map[string]string
+
+	// UnknownJobSourcesNotFound fait répondre 404 au listing des sources d'un job inconnu
+	// sans sources (défaut: liste vide)
+	UnknownJobSourcesNotFound bool
+
+	// AdminToken protège les endpoints d'administration (vide = endpoints désactivés)
+	AdminToken string
+
+	// ClientAPIKeys associe chaque clé d'API client (header X-API-Key) au nom du client
+	// authentifié (vide = tous les clients sont identifiés par leur IP)
+	ClientAPIKeys map[string]string
+
+	// ValidationMessagesFile est un catalogue JSON de messages d'erreur de validation par
+	// langue et par code, qui complète les catalogues intégrés (vide = aucun)
+	ValidationMessagesFile string
 }
 
 type WorkerConfig struct {
-	WorkerCount      int
-	PollInterval     time.Duration
-	WorkspaceBase    string
+	WorkerCount   int
+	PollInterval  time.Duration
+	WorkspaceBase string
+	// WorkspaceBases répartit les workspaces des workers sur plusieurs répertoires (vide = WorkspaceBase)
+	WorkspaceBases   []string
 	SlidevCommand    string
 	CleanupWorkspace bool
 	MaxWorkspaceAge  time.Duration
+	NpmCacheMode     string
+	BuildCacheMode   string
+	BuildCacheDir    string
+	SlideFiles       []string
+	LogReplayLines   int
+	LogFormat        string // Format des logs de job stockés: text ou jsonl
+	MaxBuilds        int    // Builds Slidev simultanés (0 = un par cœur)
+	MaxNpmProcesses  int    // Installations npm simultanées, tous jobs confondus (0 = une par cœur)
+	DispatchMode     string
+	// BuildMemoryLimitMB borne la mémoire des processus npm/Slidev d'un job (0 = sans limite, Linux)
+	BuildMemoryLimitMB int64
+	BuildCgroupDir     string
+	// VersionCheckMode : "warn", "strict" ou "off" pour les versions Node/Slidev exigées par les cours
+	VersionCheckMode string
+	// SrcIncludeCheckMode : "warn", "strict" ou "off" pour les slides importées (src:) absentes ou circulaires
+	SrcIncludeCheckMode string
+	// NonZeroExitMode : "strict" ou "validate-output" pour un slidev build sorti en erreur
+	NonZeroExitMode string
+	// SourceRetention : "keep" ou "delete-on-success" pour les sources d'un build réussi
+	SourceRetention string
+	// AffinityQueueThreshold : jobs en attente chez le worker affin avant repli sur un worker inactif
+	AffinityQueueThreshold int
+	// StatsIncludeDependencies compte node_modules et .npm-cache dans la taille des workspaces
+	StatsIncludeDependencies bool
+	// CleanupProtectedStatuses : statuts de job dont le nettoyage des anciens workspaces garde le workspace
+	CleanupProtectedStatuses []models.JobStatus
+	// OrphanGracePeriod : ancienneté d'un job pending au démarrage pour le remettre en file
+	OrphanGracePeriod time.Duration
+	// ResultCompression : encodages de précompression des résultats HTML/CSS/JS ("gzip"), vide = à la demande du job.
+	// Un encodage non supporté (comme "br") empêche le démarrage.
+	ResultCompression []string
+	// NpmInstallRetries : relances d'une installation npm après un échec transitoire du registre
+	NpmInstallRetries      int
+	NpmInstallRetryBackoff time.Duration
+	// AllowedThemes / DeniedThemes : thèmes Slidev installables par les builds (vide = tous)
+	AllowedThemes []string
+	DeniedThemes  []string
+	// InstallDeckPackages : installer les addons et paquets importés par le deck absents de node_modules
+	InstallDeckPackages bool
+	// SourceDownloadTimeout : durée max du téléchargement des sources d'un job (0 = JOB_TIMEOUT seul)
+	SourceDownloadTimeout time.Duration
+	// QueueOverflowMode : "persist" (jobs gardés pending en base quand la file est pleine) ou "reject"
+	QueueOverflowMode string
+	// MaxPendingBacklog : jobs pending en base hors de la file en mémoire, mode persist (0 = illimité)
+	MaxPendingBacklog int
+	// SlideCountWarning / OutputSizeWarningMB : seuils d'avertissement de taille d'un deck (0 = désactivé)
+	SlideCountWarning   int
+	OutputSizeWarningMB int64
+}
+
+// CallbackConfig contient la politique de sécurité des URLs de callback
+type CallbackConfig struct {
+	AllowedHosts         []string      // Hôtes autorisés (vide = tous), supporte "*.example.com"
+	AllowPrivateNetworks bool          // Autoriser les IPs privées (RFC1918), loopback et link-local
+	MaxAttempts          int           // Nombre max de tentatives de livraison par job
+	Timeout              time.Duration // Timeout d'une tentative de livraison
+	SigningSecret        string        // Secret HMAC de signature des payloads (vide = non signés)
+	SchemaVersion        string        // Format des payloads : "0" ancien JobResponse (défaut), "1" enveloppe
+	RetryBaseDelay       time.Duration // Délai avant la première relance, doublé à chaque relance
+	RetryMaxDelay        time.Duration // Délai max entre deux relances
+	Workers              int           // Livraisons de callbacks simultanées
+	PollInterval         time.Duration // Intervalle de relevé des livraisons échues
+}
+
+// ServerConfig contient les timeouts du serveur HTTP (0 = sans timeout). ReadTimeout et
+// WriteTimeout couvrent toute la requête ou la réponse : ils doivent laisser le temps aux
+// uploads de sources et aux téléchargements de résultats sur une connexion lente.
+type ServerConfig struct {
+	ReadHeaderTimeout time.Duration // Lecture des en-têtes (défaut: 10s), protège des connexions lentes
+	ReadTimeout       time.Duration // Lecture de la requête, corps compris (défaut: 10m), hors flux de logs en direct
+	WriteTimeout      time.Duration // Écriture de la réponse (défaut: 10m), hors flux de logs en direct
+	IdleTimeout       time.Duration // Connexion keep-alive inactive (défaut: 2m)
+
+	// KeepAlive réutilise les connexions HTTP/1.1 entre requêtes (défaut: true) ;
+	// TCPKeepAlive est la période des sondes TCP des connexions ouvertes (défaut: 30s, 0 = désactivées)
+	KeepAlive    bool
+	TCPKeepAlive time.Duration
+
+	// TLSCertFile et TLSKeyFile activent HTTPS, et avec lui HTTP/2 négocié par ALPN :
+	// les assets d'un cours sont alors multiplexés sur une seule connexion
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// HTTP2 autorise la négociation de HTTP/2 sur TLS (défaut: true)
+	HTTP2 bool
+}
+
+// TLSEnabled indique si le serveur écoute en HTTPS
+func (s *ServerConfig) TLSEnabled() bool {
+	return s.TLSCertFile != "" && s.TLSKeyFile != ""
+}
+
+// Validate vérifie la cohérence de la configuration du serveur HTTP
+func (s *ServerConfig) Validate() error {
+	if (s.TLSCertFile == "") != (s.TLSKeyFile == "") {
+		return fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	return nil
+}
+
+// UploadConfig contient les limites d'upload des fichiers sources
+type UploadConfig struct {
+	MaxFiles     int   // Nombre max de fichiers par upload (défaut: 100)
+	MaxFileSize  int64 // Taille max par fichier en bytes (défaut: 10MB)
+	MaxTotalSize int64 // Taille max totale par upload en bytes (défaut: 50MB)
+	Concurrency  int   // Nombre d'uploads simultanés vers le storage par requête (défaut: 4)
+	MaxPathDepth int   // Profondeur max des chemins de fichiers (défaut: validation.DefaultMaxPathDepth)
+
+	// MaxArchiveExpansionRatio borne la taille décompressée d'une archive de sources à ce
+	// multiple de sa taille (défaut: validation.DefaultMaxArchiveExpansionRatio)
+	MaxArchiveExpansionRatio int
+
+	// DirectoryRules associe un dossier des sources à sa politique de validation :
+	// "deny", "binary" ou des extensions supplémentaires (".csv|.tsv")
+	DirectoryRules map[string]string
+
+	RejectEmptyFiles    bool     // Refuser les fichiers vides (défaut: false, acceptés)
+	EmptyFileExtensions []string // Extensions acceptées vides malgré RejectEmptyFiles (".gitkeep")
+
+	// ContentDenyPatterns ajoute des expressions régulières refusées dans le contenu des
+	// fichiers, par extension ; ContentDenyDefaultsDisabled retire les motifs intégrés de
+	// certaines extensions. Compilés au démarrage par validation.ParseContentPatterns.
+	ContentDenyPatterns         map[string][]string
+	ContentDenyDefaultsDisabled []string
+}
+
+// Validate vérifie la cohérence des limites d'upload
+func (u *UploadConfig) Validate() error {
+	if u.MaxFiles <= 0 {
+		return fmt.Errorf("MAX_UPLOAD_FILES must be positive, got %d", u.MaxFiles)
+	}
+	if u.MaxFileSize <= 0 {
+		return fmt.Errorf("MAX_UPLOAD_FILE_SIZE must be positive, got %d", u.MaxFileSize)
+	}
+	if u.Concurrency <= 0 {
+		return fmt.Errorf("UPLOAD_CONCURRENCY must be positive, got %d", u.Concurrency)
+	}
+	if u.MaxPathDepth <= 0 {
+		return fmt.Errorf("MAX_PATH_DEPTH must be positive, got %d", u.MaxPathDepth)
+	}
+	if u.MaxArchiveExpansionRatio <= 0 {
+		return fmt.Errorf("MAX_ARCHIVE_EXPANSION_RATIO must be positive, got %d", u.MaxArchiveExpansionRatio)
+	}
+	if u.MaxTotalSize < u.MaxFileSize {
+		return fmt.Errorf("MAX_UPLOAD_TOTAL_SIZE (%d) must be greater than or equal to MAX_UPLOAD_FILE_SIZE (%d)",
+			u.MaxTotalSize, u.MaxFileSize)
+	}
+	return nil
 }
 
 func Load() *Config {
 	timeout, _ := time.ParseDuration(getEnv("JOB_TIMEOUT", "30m"))
 	cleanup, _ := time.ParseDuration(getEnv("CLEANUP_INTERVAL", "1h"))
+	callbackTimeout, _ := time.ParseDuration(getEnv("CALLBACK_TIMEOUT", "10s"))
+	slowOpThreshold, _ := time.ParseDuration(getEnv("STORAGE_SLOW_OP_THRESHOLD", "1s"))
+	presignExpiry, _ := time.ParseDuration(getEnv("STORAGE_PRESIGN_EXPIRY", "1h"))
+	jobCacheTTL, err := time.ParseDuration(getEnv("JOB_CACHE_TTL", "2s"))
+	if err != nil {
+		log.Printf("Invalid JOB_CACHE_TTL, using default 2s: %v", err)
+		jobCacheTTL = 2 * time.Second
+	}
+	cancelOnDisconnectWindow, err := time.ParseDuration(getEnv("CANCEL_ON_DISCONNECT_WINDOW", "30s"))
+	if err != nil {
+		log.Printf("Invalid CANCEL_ON_DISCONNECT_WINDOW, using default 30s: %v", err)
+		cancelOnDisconnectWindow = 30 * time.Second
+	}
+
+	storageConfig := LoadStorageConfig("")
+	storageConfig.SlowOpThreshold = slowOpThreshold
+	storageConfig.PresignExpiry = presignExpiry
 
 	return &Config{
 		Port:            getEnv("PORT", "8081"),
@@ -40,17 +290,182 @@ func Load() *Config {
 		CleanupInterval: cleanup,
 		LogLevel:        getEnv("LOG_LEVEL", "info"),
 		Environment:     getEnv("ENVIRONMENT", "development"),
-		Storage: &storage.StorageConfig{
-			Type:         getEnv("STORAGE_TYPE", "filesystem"),
-			BasePath:     getStorageBasePath(),
-			UsePathStyle: getEnvBool("GARAGE_USE_PATH_STYLE", false),
-			Endpoint:     getEnv("GARAGE_ENDPOINT", ""),
-			AccessKey:    getEnv("GARAGE_ACCESS_KEY", ""),
-			SecretKey:    getEnv("GARAGE_SECRET_KEY", ""),
-			Bucket:       getEnv("GARAGE_BUCKET", "ocf-courses"),
-			Region:       getEnv("GARAGE_REGION", "us-east-1"),
+		Storage:         storageConfig,
+		Worker:          loadWorkerConfig(),
+		Callback: &CallbackConfig{
+			AllowedHosts:         getEnvList("CALLBACK_ALLOWED_HOSTS"),
+			AllowPrivateNetworks: getEnvBool("CALLBACK_ALLOW_PRIVATE_NETWORKS", false),
+			MaxAttempts:          getEnvInt("CALLBACK_MAX_ATTEMPTS", 5),
+			Timeout:              callbackTimeout,
+			SigningSecret:        getEnv("CALLBACK_SIGNING_SECRET", ""),
+			SchemaVersion:        getCallbackSchemaVersion(),
+			RetryBaseDelay:       getEnvDuration("CALLBACK_RETRY_BASE_DELAY", 30*time.Second),
+			RetryMaxDelay:        getEnvDuration("CALLBACK_RETRY_MAX_DELAY", 30*time.Minute),
+			Workers:              getEnvInt("CALLBACK_WORKERS", 2),
+			PollInterval:         getEnvDuration("CALLBACK_POLL_INTERVAL", 5*time.Second),
+		},
+		Upload: &UploadConfig{
+			MaxFiles:                    getEnvInt("MAX_UPLOAD_FILES", 100),
+			MaxFileSize:                 getEnvInt64("MAX_UPLOAD_FILE_SIZE", 10*1024*1024),
+			MaxTotalSize:                getEnvInt64("MAX_UPLOAD_TOTAL_SIZE", 50*1024*1024),
+			Concurrency:                 getEnvInt("UPLOAD_CONCURRENCY", 4),
+			MaxPathDepth:                getEnvInt("MAX_PATH_DEPTH", validation.DefaultMaxPathDepth),
+			MaxArchiveExpansionRatio:    getEnvInt("MAX_ARCHIVE_EXPANSION_RATIO", validation.DefaultMaxArchiveExpansionRatio),
+			DirectoryRules:              getDirectoryRules(),
+			ContentDenyPatterns:         getContentDenyPatterns(),
+			ContentDenyDefaultsDisabled: getEnvList("CONTENT_DENY_DEFAULTS_DISABLED"),
+			RejectEmptyFiles:            getEnvBool("REJECT_EMPTY_FILES", false),
+			EmptyFileExtensions:         getEmptyFileExtensions(),
 		},
-		Worker: loadWorkerConfig(),
+		Server: &ServerConfig{
+			ReadHeaderTimeout: getEnvDuration("HTTP_READ_HEADER_TIMEOUT", 10*time.Second),
+			ReadTimeout:       getEnvDuration("HTTP_READ_TIMEOUT", 10*time.Minute),
+			WriteTimeout:      getEnvDuration("HTTP_WRITE_TIMEOUT", 10*time.Minute),
+			IdleTimeout:       getEnvDuration("HTTP_IDLE_TIMEOUT", 2*time.Minute),
+			KeepAlive:         getEnvBool("HTTP_KEEP_ALIVE", true),
+			TCPKeepAlive:      getEnvDuration("HTTP_TCP_KEEP_ALIVE", 30*time.Second),
+			TLSCertFile:       getEnv("TLS_CERT_FILE", ""),
+			TLSKeyFile:        getEnv("TLS_KEY_FILE", ""),
+			HTTP2:             getEnvBool("HTTP2_ENABLED", true),
+		},
+		MaxActiveJobsPerClient:    getEnvInt("MAX_ACTIVE_JOBS_PER_CLIENT", 0),
+		MaxBatchSize:              getEnvInt("MAX_BATCH_SIZE", 50),
+		MaxMetadataSize:           getEnvInt("MAX_METADATA_SIZE", 64*1024),
+		MaxMetadataDepth:          getEnvInt("MAX_METADATA_DEPTH", 5),
+		JobCacheTTL:               jobCacheTTL,
+		PublicBaseURL:             strings.TrimSuffix(getEnv("PUBLIC_BASE_URL", ""), "/"),
+		StorageNamespace:          getEnv("STORAGE_NAMESPACE", ""),
+		StorageNamespacePerClient: getEnvBool("STORAGE_NAMESPACE_PER_CLIENT", false),
+		CancelOnDisconnectWindow:  cancelOnDisconnectWindow,
+		ThemePreviewRateLimit:     getEnvInt("THEME_PREVIEW_RATE_LIMIT", 5),
+		ArchiveReadConcurrency:    getEnvInt("ARCHIVE_READ_CONCURRENCY", 4),
+		StorageTransferWindow:     getEnvDuration("STORAGE_TRANSFER_WINDOW", 0),
+		CourseResultQuota:         getEnvInt64("COURSE_RESULT_QUOTA", 0),
+		CourseResultQuotas:        getCourseResultQuotas(),
+		ManifestVersions:          getEnvInt("MANIFEST_VERSIONS", 20),
+		SLOTargets:                getSLOTargets(),
+		SLOWindow:                 getEnvDuration("SLO_WINDOW", 24*time.Hour),
+		ResultCacheControl:        getEnv("RESULT_CACHE_CONTROL", "max-age=60"),
+		ResultCacheControlRules:   getResultCacheControlRules(),
+		UnknownJobSourcesNotFound: getEnvBool("UNKNOWN_JOB_SOURCES_NOT_FOUND", false),
+		AdminToken:                getEnv("ADMIN_TOKEN", ""),
+		ClientAPIKeys:             getClientAPIKeys(),
+		ValidationMessagesFile:    getEnv("VALIDATION_MESSAGES_FILE", ""),
+	}
+}
+
+// getResultCacheControlRules lit les directives Cache-Control par type de résultat,
+// séparées par ";" car les directives contiennent des virgules
+// (".html=no-cache;hashed=public, max-age=31536000, immutable")
+func getResultCacheControlRules() map[string]string {
+	value := os.Getenv("RESULT_CACHE_CONTROL_RULES")
+	if value == "" {
+		return nil
+	}
+
+	rules := make(map[string]string)
+	for _, entry := range strings.Split(value, ";") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		key, directives, _ := strings.Cut(entry, "=")
+		key, directives = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(directives)
+		if key == "" || directives == "" || strings.ContainsAny(directives, "\r\n") {
+			log.Printf("Invalid rule in RESULT_CACHE_CONTROL_RULES %q, ignoring it", entry)
+			continue
+		}
+		if key != "hashed" && !strings.HasPrefix(key, ".") {
+			key = "." + key
+		}
+		rules[key] = directives
+	}
+	return rules
+}
+
+// getClientAPIKeys lit les clés d'API des clients ("client=clé,..."), indexées par clé
+func getClientAPIKeys() map[string]string {
+	entries := getEnvList("CLIENT_API_KEYS")
+	if len(entries) == 0 {
+		return nil
+	}
+
+	keys := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		client, key, _ := strings.Cut(entry, "=")
+		client, key = strings.TrimSpace(client), strings.TrimSpace(key)
+		if client == "" || key == "" {
+			log.Printf("Invalid entry in CLIENT_API_KEYS for client %q, ignoring it", client)
+			continue
+		}
+		if _, exists := keys[key]; exists {
+			log.Printf("Duplicate key in CLIENT_API_KEYS for client %q, ignoring it", client)
+			continue
+		}
+		keys[key] = client
+	}
+	return keys
+}
+
+// getSLOTargets lit les objectifs de latence des jobs ("2m,5m,10m"), triés et sans doublon
+func getSLOTargets() []time.Duration {
+	var targets []time.Duration
+	for _, entry := range getEnvList("SLO_TARGETS") {
+		target, err := time.ParseDuration(entry)
+		if err != nil || target <= 0 {
+			log.Printf("Invalid target in SLO_TARGETS %q, ignoring it", entry)
+			continue
+		}
+		if !slices.Contains(targets, target) {
+			targets = append(targets, target)
+		}
+	}
+	slices.Sort(targets)
+	return targets
+}
+
+// getCourseResultQuotas lit les quotas de résultats par cours ("course_id=octets,...")
+func getCourseResultQuotas() map[uuid.UUID]int64 {
+	entries := getEnvList("COURSE_RESULT_QUOTAS")
+	if len(entries) == 0 {
+		return nil
+	}
+
+	quotas := make(map[uuid.UUID]int64, len(entries))
+	for _, entry := range entries {
+		course, value, _ := strings.Cut(entry, "=")
+		courseID, err := uuid.Parse(strings.TrimSpace(course))
+		if err != nil {
+			log.Printf("Invalid course in COURSE_RESULT_QUOTAS %q, ignoring it", entry)
+			continue
+		}
+		quota, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil || quota < 0 {
+			log.Printf("Invalid quota in COURSE_RESULT_QUOTAS %q, ignoring it", entry)
+			continue
+		}
+		quotas[courseID] = quota
+	}
+	return quotas
+}
+
+// LoadStorageConfig lit la configuration d'un backend de storage (STORAGE_TYPE, STORAGE_PATH,
+// GARAGE_*) dans les variables d'environnement préfixées par prefix : "" pour le backend du
+// worker, "TARGET_" pour la cible d'une migration
+func LoadStorageConfig(prefix string) *storage.StorageConfig {
+	basePath := os.Getenv(prefix + "STORAGE_PATH")
+	if basePath == "" && prefix == "" {
+		basePath = getStorageBasePath()
+	}
+
+	return &storage.StorageConfig{
+		Type:         getEnv(prefix+"STORAGE_TYPE", "filesystem"),
+		BasePath:     basePath,
+		UsePathStyle: getEnvBool(prefix+"GARAGE_USE_PATH_STYLE", false),
+		Endpoint:     getEnv(prefix+"GARAGE_ENDPOINT", ""),
+		AccessKey:    getEnv(prefix+"GARAGE_ACCESS_KEY", ""),
+		SecretKey:    getEnv(prefix+"GARAGE_SECRET_KEY", ""),
+		Bucket:       getEnv(prefix+"GARAGE_BUCKET", "ocf-courses"),
+		Region:       getEnv(prefix+"GARAGE_REGION", "us-east-1"),
 	}
 }
 
@@ -102,15 +517,245 @@ func dockerFileExists() bool {
 func loadWorkerConfig() *WorkerConfig {
 	pollInterval, _ := time.ParseDuration(getEnv("WORKER_POLL_INTERVAL", "5s"))
 	maxWorkspaceAge, _ := time.ParseDuration(getEnv("MAX_WORKSPACE_AGE", "24h"))
+	npmInstallRetryBackoff, _ := time.ParseDuration(getEnv("NPM_INSTALL_RETRY_BACKOFF", "2s"))
+	sourceDownloadTimeout, _ := time.ParseDuration(getEnv("SOURCE_DOWNLOAD_TIMEOUT", "5m"))
 
 	return &WorkerConfig{
-		WorkerCount:      getEnvInt("WORKER_COUNT", 3),
-		PollInterval:     pollInterval,
-		WorkspaceBase:    getWorkspaceBasePath(),
-		SlidevCommand:    getEnv("SLIDEV_COMMAND", "npx @slidev/cli"),
-		CleanupWorkspace: getEnvBool("CLEANUP_WORKSPACE", true),
-		MaxWorkspaceAge:  maxWorkspaceAge,
+		WorkerCount:              getEnvInt("WORKER_COUNT", 3),
+		PollInterval:             pollInterval,
+		WorkspaceBase:            getWorkspaceBasePath(),
+		WorkspaceBases:           getEnvList("WORKSPACE_BASES"),
+		SlidevCommand:            getEnv("SLIDEV_COMMAND", "npx @slidev/cli"),
+		CleanupWorkspace:         getEnvBool("CLEANUP_WORKSPACE", true),
+		MaxWorkspaceAge:          maxWorkspaceAge,
+		NpmCacheMode:             getNpmCacheMode(),
+		BuildCacheMode:           getBuildCacheMode(),
+		BuildCacheDir:            getEnv("BUILD_CACHE_DIR", "/tmp/ocf-build-cache"),
+		SlideFiles:               getSlideFiles(),
+		LogReplayLines:           getEnvInt("LOG_STREAM_REPLAY_LINES", 100),
+		LogFormat:                getLogFormat(),
+		MaxBuilds:                getEnvInt("MAX_CONCURRENT_BUILDS", 0),
+		MaxNpmProcesses:          getEnvInt("MAX_NPM_PROCESSES", 0),
+		DispatchMode:             getDispatchMode(),
+		BuildMemoryLimitMB:       getEnvInt64("BUILD_MEMORY_LIMIT_MB", 0),
+		BuildCgroupDir:           getEnv("BUILD_CGROUP_DIR", "/sys/fs/cgroup/ocf-worker"),
+		VersionCheckMode:         getVersionCheckMode(),
+		SrcIncludeCheckMode:      getSrcIncludeCheckMode(),
+		NonZeroExitMode:          getNonZeroExitMode(),
+		SourceRetention:          getSourceRetention(),
+		AffinityQueueThreshold:   getEnvInt("WORKER_AFFINITY_QUEUE_THRESHOLD", 1),
+		StatsIncludeDependencies: getEnvBool("WORKSPACE_STATS_INCLUDE_DEPENDENCIES", false),
+		CleanupProtectedStatuses: getCleanupProtectedStatuses(),
+		OrphanGracePeriod:        getEnvDuration("ORPHAN_GRACE_PERIOD", 30*time.Second),
+		ResultCompression:        getResultCompression(),
+		NpmInstallRetries:        getEnvInt("NPM_INSTALL_RETRIES", 2),
+		NpmInstallRetryBackoff:   npmInstallRetryBackoff,
+		AllowedThemes:            getEnvList("ALLOWED_THEMES"),
+		DeniedThemes:             getEnvList("DENIED_THEMES"),
+		InstallDeckPackages:      getEnvBool("INSTALL_DECK_PACKAGES", false),
+		SourceDownloadTimeout:    sourceDownloadTimeout,
+		QueueOverflowMode:        getQueueOverflowMode(),
+		MaxPendingBacklog:        getEnvInt("MAX_PENDING_BACKLOG", 0),
+		SlideCountWarning:        getEnvInt("SLIDE_COUNT_WARNING_THRESHOLD", 200),
+		OutputSizeWarningMB:      getEnvInt64("OUTPUT_SIZE_WARNING_THRESHOLD_MB", 100),
+	}
+}
+
+// getResultCompression retourne les encodages de précompression des résultats, en minuscules
+func getResultCompression() []string {
+	encodings := getEnvList("RESULT_COMPRESSION")
+	for i, encoding := range encodings {
+		encodings[i] = strings.ToLower(encoding)
+	}
+	return encodings
+}
+
+// getSlideFiles retourne les fichiers de slides candidats, par ordre de priorité
+func getSlideFiles() []string {
+	if files := getEnvList("SLIDE_FILES"); len(files) > 0 {
+		return files
+	}
+	return []string{"slides.md", "index.md", "README.md"}
+}
+
+// getBuildCacheMode retourne le mode de cache Vite ("none" par défaut, "course" ou "shared")
+func getBuildCacheMode() string {
+	mode := strings.ToLower(getEnv("BUILD_CACHE_MODE", "none"))
+	if mode != "none" && mode != "course" && mode != "shared" {
+		log.Printf("Invalid BUILD_CACHE_MODE %q, build cache disabled", mode)
+		return "none"
+	}
+	return mode
+}
+
+// getDispatchMode retourne le mode de répartition des jobs ("shared" par défaut, ou "course")
+func getDispatchMode() string {
+	mode := strings.ToLower(getEnv("WORKER_DISPATCH_MODE", "shared"))
+	if mode != "shared" && mode != "course" {
+		log.Printf("Invalid WORKER_DISPATCH_MODE %q, falling back to shared queue", mode)
+		return "shared"
+	}
+	return mode
+}
+
+// getQueueOverflowMode retourne le sort des jobs soumis quand la file est pleine ("persist" par défaut, ou "reject")
+func getQueueOverflowMode() string {
+	mode := strings.ToLower(getEnv("QUEUE_OVERFLOW_MODE", "persist"))
+	if mode != "persist" && mode != "reject" {
+		log.Printf("Invalid QUEUE_OVERFLOW_MODE %q, falling back to persist", mode)
+		return "persist"
+	}
+	return mode
+}
+
+// getVersionCheckMode retourne le mode de vérification des versions ("warn" par défaut, "strict" ou "off")
+func getVersionCheckMode() string {
+	mode := strings.ToLower(getEnv("VERSION_CHECK_MODE", "warn"))
+	if mode != "warn" && mode != "strict" && mode != "off" {
+		log.Printf("Invalid VERSION_CHECK_MODE %q, falling back to warn", mode)
+		return "warn"
+	}
+	return mode
+}
+
+// getCallbackSchemaVersion retourne le format des payloads de callback ("0" par défaut,
+// l'ancien JobResponse, ou "1", l'enveloppe versionnée)
+func getCallbackSchemaVersion() string {
+	version := getEnv("CALLBACK_SCHEMA_VERSION", models.WebhookSchemaVersionLegacy)
+	if version != models.WebhookSchemaVersionLegacy && version != models.WebhookSchemaVersion {
+		log.Printf("Invalid CALLBACK_SCHEMA_VERSION %q, falling back to %s", version, models.WebhookSchemaVersionLegacy)
+		return models.WebhookSchemaVersionLegacy
+	}
+	return version
+}
+
+// getSrcIncludeCheckMode retourne le mode de vérification des slides importées ("warn" par
+// défaut, "strict" ou "off")
+func getSrcIncludeCheckMode() string {
+	mode := strings.ToLower(getEnv("SRC_INCLUDE_CHECK_MODE", "warn"))
+	if mode != "warn" && mode != "strict" && mode != "off" {
+		log.Printf("Invalid SRC_INCLUDE_CHECK_MODE %q, falling back to warn", mode)
+		return "warn"
+	}
+	return mode
+}
+
+// getNonZeroExitMode retourne le traitement d'un slidev build sorti en erreur ("strict" par
+// défaut ou "validate-output")
+func getNonZeroExitMode() string {
+	mode := strings.ToLower(getEnv("SLIDEV_NONZERO_EXIT_MODE", "strict"))
+	if mode != "strict" && mode != "validate-output" {
+		log.Printf("Invalid SLIDEV_NONZERO_EXIT_MODE %q, falling back to strict", mode)
+		return "strict"
+	}
+	return mode
+}
+
+// getSourceRetention retourne le sort des sources après un build réussi ("keep" par défaut
+// ou "delete-on-success")
+func getSourceRetention() string {
+	retention := strings.ToLower(getEnv("SOURCE_RETENTION", "keep"))
+	if retention != "keep" && retention != "delete-on-success" {
+		log.Printf("Invalid SOURCE_RETENTION %q, falling back to keep", retention)
+		return "keep"
+	}
+	return retention
+}
+
+// getDirectoryRules lit les politiques de validation par dossier des sources
+// ("assets=binary,scripts=deny,data=.csv|.tsv")
+func getDirectoryRules() map[string]string {
+	entries := getEnvList("SOURCE_DIRECTORY_RULES")
+	if len(entries) == 0 {
+		return nil
+	}
+
+	rules := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		directory, policy, found := strings.Cut(entry, "=")
+		directory, policy = strings.TrimSpace(directory), strings.TrimSpace(policy)
+		if !found || directory == "" || policy == "" {
+			log.Printf("Invalid rule in SOURCE_DIRECTORY_RULES %q, ignoring it", entry)
+			continue
+		}
+		rules[directory] = policy
+	}
+	return rules
+}
+
+// getContentDenyPatterns lit les motifs de contenu refusés par extension, séparés par ";"
+// car les expressions régulières contiennent des virgules (".js=fetch\(;.js=import\(").
+// Une entrée sans extension est gardée sous "" pour être refusée au démarrage.
+func getContentDenyPatterns() map[string][]string {
+	value := os.Getenv("CONTENT_DENY_PATTERNS")
+	if value == "" {
+		return nil
+	}
+
+	patterns := make(map[string][]string)
+	for _, entry := range strings.Split(value, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		ext, pattern, found := strings.Cut(entry, "=")
+		if !found {
+			ext, pattern = "", entry
+		}
+		ext = strings.TrimSpace(ext)
+		patterns[ext] = append(patterns[ext], strings.TrimSpace(pattern))
+	}
+	return patterns
+}
+
+// getEmptyFileExtensions lit les extensions acceptées vides, normalisées (".css")
+func getEmptyFileExtensions() []string {
+	var extensions []string
+	for _, ext := range getEnvList("EMPTY_FILE_EXTENSIONS") {
+		extensions = append(extensions, "."+strings.TrimPrefix(strings.ToLower(ext), "."))
+	}
+	return extensions
+}
+
+// getCleanupProtectedStatuses lit les statuts de job dont le workspace survit au nettoyage
+// ("pending,processing" par défaut) ; les statuts inconnus sont ignorés
+func getCleanupProtectedStatuses() []models.JobStatus {
+	entries := getEnvList("WORKSPACE_CLEANUP_PROTECTED_STATUSES")
+	if len(entries) == 0 {
+		return []models.JobStatus{models.StatusPending, models.StatusProcessing}
+	}
+
+	known := []models.JobStatus{models.StatusPending, models.StatusProcessing,
+		models.StatusCompleted, models.StatusFailed, models.StatusTimeout}
+	var statuses []models.JobStatus
+	for _, entry := range entries {
+		status := models.JobStatus(strings.ToLower(entry))
+		if !slices.Contains(known, status) {
+			log.Printf("Invalid status in WORKSPACE_CLEANUP_PROTECTED_STATUSES %q, ignoring it", entry)
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// getNpmCacheMode retourne le mode de cache NPM ("shared" par défaut, ou "workspace")
+func getNpmCacheMode() string {
+	mode := strings.ToLower(getEnv("NPM_CACHE_MODE", "shared"))
+	if mode != "shared" && mode != "workspace" {
+		log.Printf("Invalid NPM_CACHE_MODE %q, falling back to shared cache", mode)
+		return "shared"
+	}
+	return mode
+}
+
+// getLogFormat retourne le format de stockage des logs de job (text par défaut)
+func getLogFormat() string {
+	format := strings.ToLower(getEnv("LOG_FORMAT", "text"))
+	if format != "text" && format != "jsonl" {
+		log.Printf("Invalid LOG_FORMAT %q, falling back to text logs", format)
+		return "text"
 	}
+	return format
 }
 
 // getWorkspaceBasePath détermine le répertoire de base pour les workspaces
@@ -144,6 +789,15 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvInt64(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
@@ -152,3 +806,30 @@ func getEnvBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+// getEnvDuration lit une durée ("30s", "10m") ; une valeur invalide ou négative donne la valeur par défaut
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil && duration >= 0 {
+			return duration
+		}
+		log.Printf("Invalid %s %q, using default %v", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
+// getEnvList lit une liste séparée par des virgules
+func getEnvList(key string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -2,10 +2,14 @@
 package config
 
 import (
+	"encoding/json"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -14,6 +18,7 @@ func TestConfigLoad(t *testing.T) {
 	envVars := []string{
 		"STORAGE_PATH", "DOCKER_CONTAINER", "ENVIRONMENT",
 		"PORT", "STORAGE_TYPE", "JOB_TIMEOUT", "WORKSPACE_BASE",
+		"MAX_METADATA_SIZE", "MAX_METADATA_DEPTH",
 	}
 
 	oldValues := make(map[string]string)
@@ -45,10 +50,22 @@ func TestConfigLoad(t *testing.T) {
 	assert.Equal(t, time.Hour, cfg.CleanupInterval)
 	assert.Equal(t, "info", cfg.LogLevel)
 	assert.Equal(t, "development", cfg.Environment)
+	assert.Equal(t, 30*time.Second, cfg.CancelOnDisconnectWindow)
+	assert.Equal(t, 5, cfg.ThemePreviewRateLimit)
+	assert.Equal(t, 4, cfg.ArchiveReadConcurrency)
+	assert.Zero(t, cfg.CourseResultQuota)
+	assert.Empty(t, cfg.CourseResultQuotas)
+	assert.Equal(t, 20, cfg.ManifestVersions)
+	assert.Equal(t, 64*1024, cfg.MaxMetadataSize)
+	assert.Equal(t, 5, cfg.MaxMetadataDepth)
 
 	// Vérifier la config worker
 	assert.Equal(t, 3, cfg.Worker.WorkerCount)
 	assert.Equal(t, 5*time.Second, cfg.Worker.PollInterval)
+	assert.Equal(t, 30*time.Second, cfg.Worker.OrphanGracePeriod)
+	assert.Equal(t, 2, cfg.Worker.NpmInstallRetries)
+	assert.Equal(t, 2*time.Second, cfg.Worker.NpmInstallRetryBackoff)
+	assert.Equal(t, 5*time.Minute, cfg.Worker.SourceDownloadTimeout)
 	assert.Equal(t, "/tmp/ocf-worker", cfg.Worker.WorkspaceBase)
 	assert.Equal(t, "npx @slidev/cli", cfg.Worker.SlidevCommand)
 }
@@ -178,12 +195,14 @@ func TestConfigWithEnvVars(t *testing.T) {
 func TestStorageConfig(t *testing.T) {
 	// Test spécifique pour la configuration storage
 	envVars := map[string]string{
-		"STORAGE_TYPE":      "garage",
-		"GARAGE_ENDPOINT":   "https://s3.garage.com",
-		"GARAGE_ACCESS_KEY": "test-access",
-		"GARAGE_SECRET_KEY": "test-secret",
-		"GARAGE_BUCKET":     "test-bucket",
-		"GARAGE_REGION":     "eu-west-1",
+		"STORAGE_TYPE":                 "garage",
+		"GARAGE_ENDPOINT":              "https://s3.garage.com",
+		"GARAGE_ACCESS_KEY":            "test-access",
+		"GARAGE_SECRET_KEY":            "test-secret",
+		"GARAGE_BUCKET":                "test-bucket",
+		"GARAGE_REGION":                "eu-west-1",
+		"STORAGE_NAMESPACE":            "/staging/",
+		"STORAGE_NAMESPACE_PER_CLIENT": "true",
 	}
 
 	oldValues := make(map[string]string)
@@ -210,15 +229,55 @@ func TestStorageConfig(t *testing.T) {
 	assert.Equal(t, "test-secret", cfg.Storage.SecretKey)
 	assert.Equal(t, "test-bucket", cfg.Storage.Bucket)
 	assert.Equal(t, "eu-west-1", cfg.Storage.Region)
+	assert.Equal(t, "/staging/", cfg.StorageNamespace)
+	assert.True(t, cfg.StorageNamespacePerClient)
+}
+
+func TestLoadTargetStorageConfig(t *testing.T) {
+	t.Setenv("STORAGE_TYPE", "filesystem")
+	t.Setenv("TARGET_STORAGE_TYPE", "garage")
+	t.Setenv("TARGET_GARAGE_ENDPOINT", "https://s3.garage.com")
+	t.Setenv("TARGET_GARAGE_BUCKET", "target-bucket")
+	t.Setenv("TARGET_GARAGE_USE_PATH_STYLE", "true")
+
+	target := LoadStorageConfig("TARGET_")
+	assert.Equal(t, "garage", target.Type)
+	assert.Equal(t, "https://s3.garage.com", target.Endpoint)
+	assert.Equal(t, "target-bucket", target.Bucket)
+	assert.Equal(t, "us-east-1", target.Region)
+	assert.True(t, target.UsePathStyle)
+	assert.Empty(t, target.BasePath, "a target path is never guessed")
+
+	assert.Equal(t, "filesystem", Load().Storage.Type)
 }
 
 func TestWorkerConfig(t *testing.T) {
 	// Test spécifique pour la configuration worker
 	envVars := map[string]string{
-		"WORKER_COUNT":         "5",
-		"WORKER_POLL_INTERVAL": "2s",
-		"SLIDEV_COMMAND":       "yarn slidev",
-		"CLEANUP_WORKSPACE":    "false",
+		"WORKER_COUNT":                     "5",
+		"WORKER_POLL_INTERVAL":             "2s",
+		"SLIDEV_COMMAND":                   "yarn slidev",
+		"CLEANUP_WORKSPACE":                "false",
+		"NPM_CACHE_MODE":                   "workspace",
+		"SLIDE_FILES":                      "deck.md, slides.md",
+		"LOG_STREAM_REPLAY_LINES":          "250",
+		"LOG_FORMAT":                       "JSONL",
+		"WORKER_DISPATCH_MODE":             "course",
+		"MAX_CONCURRENT_BUILDS":            "2",
+		"MAX_NPM_PROCESSES":                "3",
+		"BUILD_MEMORY_LIMIT_MB":            "1536",
+		"VERSION_CHECK_MODE":               "STRICT",
+		"SRC_INCLUDE_CHECK_MODE":           "Strict",
+		"ORPHAN_GRACE_PERIOD":              "2m",
+		"RESULT_COMPRESSION":               "GZIP",
+		"ALLOWED_THEMES":                   "default, seriph",
+		"DENIED_THEMES":                    "slidev-theme-penguin",
+		"QUEUE_OVERFLOW_MODE":              "Reject",
+		"MAX_PENDING_BACKLOG":              "500",
+		"SOURCE_DOWNLOAD_TIMEOUT":          "90s",
+		"WORKSPACE_BASES":                  "/mnt/disk1/ws, /mnt/disk2/ws",
+		"SLIDE_COUNT_WARNING_THRESHOLD":    "300",
+		"OUTPUT_SIZE_WARNING_THRESHOLD_MB": "0",
 	}
 
 	oldValues := make(map[string]string)
@@ -243,6 +302,35 @@ func TestWorkerConfig(t *testing.T) {
 	assert.Equal(t, 2*time.Second, cfg.Worker.PollInterval)
 	assert.Equal(t, "yarn slidev", cfg.Worker.SlidevCommand)
 	assert.False(t, cfg.Worker.CleanupWorkspace)
+	assert.Equal(t, "workspace", cfg.Worker.NpmCacheMode)
+	assert.Equal(t, []string{"deck.md", "slides.md"}, cfg.Worker.SlideFiles)
+	assert.Equal(t, 250, cfg.Worker.LogReplayLines)
+	assert.Equal(t, "jsonl", cfg.Worker.LogFormat)
+	assert.Equal(t, "course", cfg.Worker.DispatchMode)
+	assert.Equal(t, 1, cfg.Worker.AffinityQueueThreshold)
+	assert.Equal(t, 2, cfg.Worker.MaxBuilds)
+	assert.Equal(t, 3, cfg.Worker.MaxNpmProcesses)
+	assert.Equal(t, int64(1536), cfg.Worker.BuildMemoryLimitMB)
+	assert.Equal(t, "/sys/fs/cgroup/ocf-worker", cfg.Worker.BuildCgroupDir)
+	assert.Equal(t, "strict", cfg.Worker.VersionCheckMode)
+	assert.Equal(t, "strict", cfg.Worker.SrcIncludeCheckMode)
+	assert.Equal(t, 2*time.Minute, cfg.Worker.OrphanGracePeriod)
+	assert.Equal(t, []string{"gzip"}, cfg.Worker.ResultCompression)
+	assert.Equal(t, []string{"default", "seriph"}, cfg.Worker.AllowedThemes)
+	assert.Equal(t, []string{"slidev-theme-penguin"}, cfg.Worker.DeniedThemes)
+	assert.Equal(t, "reject", cfg.Worker.QueueOverflowMode)
+	assert.Equal(t, 500, cfg.Worker.MaxPendingBacklog)
+	assert.Equal(t, 90*time.Second, cfg.Worker.SourceDownloadTimeout)
+	assert.Equal(t, 300, cfg.Worker.SlideCountWarning)
+	assert.Zero(t, cfg.Worker.OutputSizeWarningMB)
+	assert.Equal(t, []string{"/mnt/disk1/ws", "/mnt/disk2/ws"}, cfg.Worker.WorkspaceBases)
+
+	// Une valeur inconnue retombe sur le cache partagé
+	os.Setenv("NPM_CACHE_MODE", "invalid")
+	assert.Equal(t, "shared", Load().Worker.NpmCacheMode)
+
+	os.Setenv("QUEUE_OVERFLOW_MODE", "drop")
+	assert.Equal(t, "persist", Load().Worker.QueueOverflowMode)
 }
 
 // Test pour vérifier la fonction de détection Docker
@@ -266,3 +354,420 @@ func TestDockerDetection(t *testing.T) {
 	result := isDockerEnvironment()
 	t.Logf("Docker detection result: %v", result)
 }
+
+func TestConfigLoadCallbackPolicy(t *testing.T) {
+	oldHosts := os.Getenv("CALLBACK_ALLOWED_HOSTS")
+	oldPrivate := os.Getenv("CALLBACK_ALLOW_PRIVATE_NETWORKS")
+	defer func() {
+		os.Setenv("CALLBACK_ALLOWED_HOSTS", oldHosts)
+		os.Setenv("CALLBACK_ALLOW_PRIVATE_NETWORKS", oldPrivate)
+	}()
+
+	// Valeurs par défaut : pas d'allowlist, réseaux privés bloqués
+	os.Unsetenv("CALLBACK_ALLOWED_HOSTS")
+	os.Unsetenv("CALLBACK_ALLOW_PRIVATE_NETWORKS")
+
+	cfg := Load()
+	assert.Empty(t, cfg.Callback.AllowedHosts)
+	assert.False(t, cfg.Callback.AllowPrivateNetworks)
+
+	os.Setenv("CALLBACK_ALLOWED_HOSTS", "api.example.com, *.hooks.example.org,")
+	os.Setenv("CALLBACK_ALLOW_PRIVATE_NETWORKS", "true")
+
+	cfg = Load()
+	assert.Equal(t, []string{"api.example.com", "*.hooks.example.org"}, cfg.Callback.AllowedHosts)
+	assert.True(t, cfg.Callback.AllowPrivateNetworks)
+}
+
+func TestConfigLoadCallbackDelivery(t *testing.T) {
+	cfg := Load()
+	assert.Equal(t, 30*time.Second, cfg.Callback.RetryBaseDelay)
+	assert.Equal(t, 30*time.Minute, cfg.Callback.RetryMaxDelay)
+	assert.Equal(t, 2, cfg.Callback.Workers)
+	assert.Equal(t, 5*time.Second, cfg.Callback.PollInterval)
+
+	t.Setenv("CALLBACK_RETRY_BASE_DELAY", "10s")
+	t.Setenv("CALLBACK_RETRY_MAX_DELAY", "5m")
+	t.Setenv("CALLBACK_WORKERS", "4")
+	t.Setenv("CALLBACK_POLL_INTERVAL", "1s")
+
+	cfg = Load()
+	assert.Equal(t, 10*time.Second, cfg.Callback.RetryBaseDelay)
+	assert.Equal(t, 5*time.Minute, cfg.Callback.RetryMaxDelay)
+	assert.Equal(t, 4, cfg.Callback.Workers)
+	assert.Equal(t, time.Second, cfg.Callback.PollInterval)
+}
+
+func TestConfigLoadUploadLimits(t *testing.T) {
+	envVars := []string{"MAX_UPLOAD_FILES", "MAX_UPLOAD_FILE_SIZE", "MAX_UPLOAD_TOTAL_SIZE", "UPLOAD_CONCURRENCY", "MAX_PATH_DEPTH",
+		"MAX_ARCHIVE_EXPANSION_RATIO"}
+
+	oldValues := make(map[string]string)
+	for _, key := range envVars {
+		oldValues[key] = os.Getenv(key)
+		os.Unsetenv(key)
+	}
+
+	defer func() {
+		for key, value := range oldValues {
+			if value != "" {
+				os.Setenv(key, value)
+			} else {
+				os.Unsetenv(key)
+			}
+		}
+	}()
+
+	// Valeurs par défaut
+	cfg := Load()
+	assert.Equal(t, 100, cfg.Upload.MaxFiles)
+	assert.Equal(t, int64(10*1024*1024), cfg.Upload.MaxFileSize)
+	assert.Equal(t, int64(50*1024*1024), cfg.Upload.MaxTotalSize)
+	assert.Equal(t, 4, cfg.Upload.Concurrency)
+	assert.Equal(t, 10, cfg.Upload.MaxPathDepth)
+	assert.Equal(t, 100, cfg.Upload.MaxArchiveExpansionRatio)
+	assert.NoError(t, cfg.Upload.Validate())
+
+	// Valeurs personnalisées
+	os.Setenv("MAX_UPLOAD_FILES", "250")
+	os.Setenv("MAX_UPLOAD_FILE_SIZE", "20971520")
+	os.Setenv("MAX_UPLOAD_TOTAL_SIZE", "209715200")
+	os.Setenv("UPLOAD_CONCURRENCY", "8")
+	os.Setenv("MAX_PATH_DEPTH", "16")
+	os.Setenv("MAX_ARCHIVE_EXPANSION_RATIO", "20")
+
+	cfg = Load()
+	assert.Equal(t, 20, cfg.Upload.MaxArchiveExpansionRatio)
+	assert.Equal(t, 8, cfg.Upload.Concurrency)
+	assert.Equal(t, 16, cfg.Upload.MaxPathDepth)
+	assert.Equal(t, 250, cfg.Upload.MaxFiles)
+	assert.Equal(t, int64(20*1024*1024), cfg.Upload.MaxFileSize)
+	assert.Equal(t, int64(200*1024*1024), cfg.Upload.MaxTotalSize)
+	assert.NoError(t, cfg.Upload.Validate())
+
+	// Taille totale inférieure à la taille par fichier
+	os.Setenv("MAX_UPLOAD_TOTAL_SIZE", "1048576")
+	cfg = Load()
+	assert.Error(t, cfg.Upload.Validate())
+
+	// Nombre de fichiers invalide
+	os.Setenv("MAX_UPLOAD_TOTAL_SIZE", "209715200")
+	os.Setenv("MAX_UPLOAD_FILES", "0")
+	cfg = Load()
+	assert.Error(t, cfg.Upload.Validate())
+
+	// Rapport d'expansion des archives invalide
+	os.Setenv("MAX_UPLOAD_FILES", "250")
+	os.Setenv("MAX_ARCHIVE_EXPANSION_RATIO", "0")
+	cfg = Load()
+	assert.Error(t, cfg.Upload.Validate())
+}
+
+func TestConfigLoadServerTimeouts(t *testing.T) {
+	envVars := []string{"HTTP_READ_HEADER_TIMEOUT", "HTTP_READ_TIMEOUT", "HTTP_WRITE_TIMEOUT", "HTTP_IDLE_TIMEOUT"}
+
+	oldValues := make(map[string]string)
+	for _, key := range envVars {
+		oldValues[key] = os.Getenv(key)
+		os.Unsetenv(key)
+	}
+
+	defer func() {
+		for key, value := range oldValues {
+			if value != "" {
+				os.Setenv(key, value)
+			} else {
+				os.Unsetenv(key)
+			}
+		}
+	}()
+
+	// Valeurs par défaut
+	cfg := Load()
+	assert.Equal(t, 10*time.Second, cfg.Server.ReadHeaderTimeout)
+	assert.Equal(t, 10*time.Minute, cfg.Server.ReadTimeout)
+	assert.Equal(t, 10*time.Minute, cfg.Server.WriteTimeout)
+	assert.Equal(t, 2*time.Minute, cfg.Server.IdleTimeout)
+
+	// Valeurs personnalisées, 0 désactive un timeout, une valeur invalide garde le défaut
+	os.Setenv("HTTP_READ_HEADER_TIMEOUT", "5s")
+	os.Setenv("HTTP_READ_TIMEOUT", "30m")
+	os.Setenv("HTTP_WRITE_TIMEOUT", "0")
+	os.Setenv("HTTP_IDLE_TIMEOUT", "soon")
+
+	cfg = Load()
+	assert.Equal(t, 5*time.Second, cfg.Server.ReadHeaderTimeout)
+	assert.Equal(t, 30*time.Minute, cfg.Server.ReadTimeout)
+	assert.Zero(t, cfg.Server.WriteTimeout)
+	assert.Equal(t, 2*time.Minute, cfg.Server.IdleTimeout)
+}
+
+func TestConfigLoadServerTLS(t *testing.T) {
+	envVars := []string{"TLS_CERT_FILE", "TLS_KEY_FILE", "HTTP2_ENABLED", "HTTP_KEEP_ALIVE", "HTTP_TCP_KEEP_ALIVE"}
+
+	oldValues := make(map[string]string)
+	for _, key := range envVars {
+		oldValues[key] = os.Getenv(key)
+		os.Unsetenv(key)
+	}
+
+	defer func() {
+		for key, value := range oldValues {
+			if value != "" {
+				os.Setenv(key, value)
+			} else {
+				os.Unsetenv(key)
+			}
+		}
+	}()
+
+	// Valeurs par défaut : HTTP/1.1 en clair, keep-alive actif
+	cfg := Load()
+	assert.False(t, cfg.Server.TLSEnabled())
+	assert.True(t, cfg.Server.HTTP2)
+	assert.True(t, cfg.Server.KeepAlive)
+	assert.Equal(t, 30*time.Second, cfg.Server.TCPKeepAlive)
+	assert.NoError(t, cfg.Server.Validate())
+	assert.False(t, cfg.Effective().Server.HTTP2)
+
+	os.Setenv("TLS_CERT_FILE", "/etc/ocf-worker/tls/cert.pem")
+	os.Setenv("TLS_KEY_FILE", "/etc/ocf-worker/tls/key.pem")
+	os.Setenv("HTTP_KEEP_ALIVE", "false")
+	os.Setenv("HTTP_TCP_KEEP_ALIVE", "0")
+
+	cfg = Load()
+	assert.True(t, cfg.Server.TLSEnabled())
+	assert.False(t, cfg.Server.KeepAlive)
+	assert.Zero(t, cfg.Server.TCPKeepAlive)
+	assert.NoError(t, cfg.Server.Validate())
+
+	effective := cfg.Effective().Server
+	assert.True(t, effective.TLS)
+	assert.True(t, effective.HTTP2)
+	assert.Equal(t, "/etc/ocf-worker/tls/cert.pem", effective.TLSCertFile)
+
+	os.Setenv("HTTP2_ENABLED", "false")
+	assert.False(t, Load().Effective().Server.HTTP2)
+
+	// Un certificat sans clé est refusé
+	os.Unsetenv("TLS_KEY_FILE")
+	cfg = Load()
+	assert.False(t, cfg.Server.TLSEnabled())
+	assert.Error(t, cfg.Server.Validate())
+}
+
+func TestConfigLoadCourseResultQuotas(t *testing.T) {
+	courseID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	unlimitedID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440001")
+
+	t.Setenv("COURSE_RESULT_QUOTA", "104857600")
+	t.Setenv("COURSE_RESULT_QUOTAS", courseID.String()+"=524288000, "+unlimitedID.String()+"=0, not-a-course=10, "+uuid.NewString()+"=lots")
+
+	cfg := Load()
+	assert.Equal(t, int64(100*1024*1024), cfg.CourseResultQuota)
+	assert.Equal(t, map[uuid.UUID]int64{courseID: 500 * 1024 * 1024, unlimitedID: 0}, cfg.CourseResultQuotas)
+}
+
+func TestConfigLoadClientAPIKeys(t *testing.T) {
+	t.Setenv("CLIENT_API_KEYS", "")
+	assert.Nil(t, Load().ClientAPIKeys)
+
+	// Entrées incomplètes et clés en double ignorées
+	t.Setenv("CLIENT_API_KEYS", "course-platform=key-1, lms=key-2, incomplete, =key-3, other=key-1")
+
+	cfg := Load()
+	assert.Equal(t, map[string]string{"key-1": "course-platform", "key-2": "lms"}, cfg.ClientAPIKeys)
+
+	// Seuls les noms des clients sont exposés
+	effective := cfg.Effective()
+	assert.Equal(t, []string{"course-platform", "lms"}, effective.Server.AuthenticatedClients)
+	encoded, err := json.Marshal(effective)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(encoded), "key-1")
+}
+
+func TestConfigLoadSLO(t *testing.T) {
+	t.Setenv("SLO_TARGETS", "")
+	t.Setenv("SLO_WINDOW", "")
+
+	cfg := Load()
+	assert.Empty(t, cfg.SLOTargets)
+	assert.Equal(t, 24*time.Hour, cfg.SLOWindow)
+
+	// Objectifs triés et dédoublonnés, entrées invalides ignorées
+	t.Setenv("SLO_TARGETS", "5m, 90s, soon, -1m, 5m, 30m")
+	t.Setenv("SLO_WINDOW", "168h")
+
+	cfg = Load()
+	assert.Equal(t, []time.Duration{90 * time.Second, 5 * time.Minute, 30 * time.Minute}, cfg.SLOTargets)
+	assert.Equal(t, 168*time.Hour, cfg.SLOWindow)
+}
+
+func TestConfigLoadCleanupProtectedStatuses(t *testing.T) {
+	t.Setenv("WORKSPACE_CLEANUP_PROTECTED_STATUSES", "")
+	cfg := Load()
+	assert.Equal(t, []models.JobStatus{models.StatusPending, models.StatusProcessing}, cfg.Worker.CleanupProtectedStatuses)
+
+	t.Setenv("WORKSPACE_CLEANUP_PROTECTED_STATUSES", "processing, FAILED, archived")
+	cfg = Load()
+	assert.Equal(t, []models.JobStatus{models.StatusProcessing, models.StatusFailed}, cfg.Worker.CleanupProtectedStatuses)
+}
+
+func TestConfigLoadDirectoryRules(t *testing.T) {
+	t.Setenv("SOURCE_DIRECTORY_RULES", "")
+	cfg := Load()
+	assert.Nil(t, cfg.Upload.DirectoryRules)
+
+	t.Setenv("SOURCE_DIRECTORY_RULES", "assets=binary, scripts = deny,data=.csv|.tsv,broken,empty=")
+	cfg = Load()
+	assert.Equal(t, map[string]string{
+		"assets":  "binary",
+		"scripts": "deny",
+		"data":    ".csv|.tsv",
+	}, cfg.Upload.DirectoryRules)
+}
+
+func TestConfigLoadEmptyFiles(t *testing.T) {
+	t.Setenv("REJECT_EMPTY_FILES", "")
+	t.Setenv("EMPTY_FILE_EXTENSIONS", "")
+	cfg := Load()
+	assert.False(t, cfg.Upload.RejectEmptyFiles)
+	assert.Nil(t, cfg.Upload.EmptyFileExtensions)
+
+	t.Setenv("REJECT_EMPTY_FILES", "true")
+	t.Setenv("EMPTY_FILE_EXTENSIONS", "gitkeep, .CSS")
+	cfg = Load()
+	assert.True(t, cfg.Upload.RejectEmptyFiles)
+	assert.Equal(t, []string{".gitkeep", ".css"}, cfg.Upload.EmptyFileExtensions)
+}
+
+func TestConfigLoadResultCacheControl(t *testing.T) {
+	t.Setenv("RESULT_CACHE_CONTROL", "")
+	t.Setenv("RESULT_CACHE_CONTROL_RULES", "")
+	cfg := Load()
+	assert.Equal(t, "max-age=60", cfg.ResultCacheControl)
+	assert.Nil(t, cfg.ResultCacheControlRules)
+
+	t.Setenv("RESULT_CACHE_CONTROL", "no-store")
+	t.Setenv("RESULT_CACHE_CONTROL_RULES", ".HTML=no-cache; hashed=public, max-age=31536000, immutable;css=max-age=3600;broken;")
+	cfg = Load()
+	assert.Equal(t, "no-store", cfg.ResultCacheControl)
+	assert.Equal(t, map[string]string{
+		".html":  "no-cache",
+		"hashed": "public, max-age=31536000, immutable",
+		".css":   "max-age=3600",
+	}, cfg.ResultCacheControlRules)
+}
+
+func TestConfigLoadUnknownJobSourcesNotFound(t *testing.T) {
+	t.Setenv("UNKNOWN_JOB_SOURCES_NOT_FOUND", "")
+	assert.False(t, Load().UnknownJobSourcesNotFound)
+
+	t.Setenv("UNKNOWN_JOB_SOURCES_NOT_FOUND", "true")
+	assert.True(t, Load().UnknownJobSourcesNotFound)
+}
+
+func TestConfigLoadNonZeroExitMode(t *testing.T) {
+	assert.Equal(t, "strict", Load().Worker.NonZeroExitMode)
+
+	t.Setenv("SLIDEV_NONZERO_EXIT_MODE", "validate-output")
+	assert.Equal(t, "validate-output", Load().Worker.NonZeroExitMode)
+
+	// Une valeur inconnue retombe sur le mode strict
+	t.Setenv("SLIDEV_NONZERO_EXIT_MODE", "ignore")
+	assert.Equal(t, "strict", Load().Worker.NonZeroExitMode)
+}
+
+func TestConfigLoadSourceRetention(t *testing.T) {
+	assert.Equal(t, "keep", Load().Worker.SourceRetention)
+
+	t.Setenv("SOURCE_RETENTION", "delete-on-success")
+	assert.Equal(t, "delete-on-success", Load().Worker.SourceRetention)
+
+	// Une valeur inconnue conserve les sources
+	t.Setenv("SOURCE_RETENTION", "delete")
+	assert.Equal(t, "keep", Load().Worker.SourceRetention)
+}
+
+func TestConfigLoadStorageTransferWindow(t *testing.T) {
+	assert.Zero(t, Load().StorageTransferWindow)
+
+	t.Setenv("STORAGE_TRANSFER_WINDOW", "1h")
+	assert.Equal(t, time.Hour, Load().StorageTransferWindow)
+}
+
+func TestConfigLoadContentDenyPatterns(t *testing.T) {
+	cfg := Load()
+	assert.Nil(t, cfg.Upload.ContentDenyPatterns)
+	assert.Empty(t, cfg.Upload.ContentDenyDefaultsDisabled)
+
+	t.Setenv("CONTENT_DENY_PATTERNS", `.js=\bfetch\(; .js=x{1,3}; .md=<iframe; import\(`)
+	t.Setenv("CONTENT_DENY_DEFAULTS_DISABLED", ".html")
+	cfg = Load()
+	assert.Equal(t, map[string][]string{
+		".js": {`\bfetch\(`, `x{1,3}`},
+		".md": {"<iframe"},
+		"":    {`import\(`},
+	}, cfg.Upload.ContentDenyPatterns)
+	assert.Equal(t, []string{".html"}, cfg.Upload.ContentDenyDefaultsDisabled)
+}
+
+func TestConfigEffective(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://ocf:db-password@db:5432/ocf?sslmode=disable")
+	t.Setenv("GARAGE_ACCESS_KEY", "GK-access-key")
+	t.Setenv("GARAGE_SECRET_KEY", "garage-secret-key")
+	t.Setenv("CALLBACK_SIGNING_SECRET", "callback-signing-secret")
+	t.Setenv("ADMIN_TOKEN", "admin-token")
+	t.Setenv("STORAGE_PATH", "/data/storage")
+	t.Setenv("WORKER_COUNT", "7")
+	t.Setenv("JOB_TIMEOUT", "45m")
+
+	effective := Load().Effective()
+
+	// Les valeurs résolues par Load sont exposées
+	assert.Equal(t, "/data/storage", effective.Storage.BasePath)
+	assert.Equal(t, 7, effective.Worker.WorkerCount)
+	assert.Equal(t, "45m0s", effective.Jobs.Timeout)
+	assert.Equal(t, "1h0m0s", effective.Jobs.CleanupInterval)
+	assert.Equal(t, 100, effective.Upload.MaxFiles)
+
+	// Les secrets non
+	assert.Equal(t, RedactedValue, effective.Storage.AccessKey)
+	assert.Equal(t, RedactedValue, effective.Storage.SecretKey)
+	assert.Equal(t, RedactedValue, effective.Callback.SigningSecret)
+	assert.Equal(t, "postgres://ocf:[REDACTED]@db:5432/ocf?sslmode=disable", effective.Database.URL)
+
+	encoded, err := json.Marshal(effective)
+	assert.NoError(t, err)
+	for _, secret := range []string{"db-password", "GK-access-key", "garage-secret-key", "callback-signing-secret", "admin-token"} {
+		assert.NotContains(t, string(encoded), secret)
+	}
+
+	// Un secret non configuré reste vide
+	t.Setenv("CALLBACK_SIGNING_SECRET", "")
+	assert.Empty(t, Load().Effective().Callback.SigningSecret)
+}
+
+func TestRedactDatabaseURL(t *testing.T) {
+	testCases := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{"empty", "", ""},
+		{"password", "postgres://ocf:secret@db:5432/ocf", "postgres://ocf:[REDACTED]@db:5432/ocf"},
+		{"user without password", "postgres://ocf@db/ocf", "postgres://ocf@db/ocf"},
+		{"query password", "postgres://db/ocf?password=secret&sslmode=disable", "postgres://db/ocf?password=[REDACTED]&sslmode=disable"},
+		{"key value", "host=db user=ocf password=secret dbname=ocf", "host=db user=ocf password=[REDACTED] dbname=ocf"},
+		{"quoted key value", "host=db password='my secret' dbname=ocf", "host=db password=[REDACTED] dbname=ocf"},
+		{"unparsable", "postgres://ocf:secret@db:port/ocf", RedactedValue},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			redacted := RedactDatabaseURL(tc.url)
+			assert.Equal(t, tc.expected, redacted)
+			assert.NotContains(t, redacted, "secret")
+		})
+	}
+}
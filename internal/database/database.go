@@ -56,6 +56,10 @@ func (db *DB) Migrate() error {
 		return fmt.Errorf("failed to migrate GenerationJob: %w", err)
 	}
 
+	if err := db.AutoMigrate(&models.GenerationProfile{}); err != nil {
+		return fmt.Errorf("failed to migrate GenerationProfile: %w", err)
+	}
+
 	log.Println("Database migrations completed")
 	return nil
 }
@@ -0,0 +1,131 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordJobAttempt(t *testing.T) {
+	ctx := context.Background()
+
+	newJob := func(t *testing.T, service JobService) uuid.UUID {
+		job, err := service.CreateJob(ctx, &models.GenerationRequest{
+			JobID:      uuid.New(),
+			CourseID:   uuid.New(),
+			SourcePath: "test/path",
+		})
+		require.NoError(t, err)
+		return job.ID
+	}
+
+	t.Run("Final status reflects the latest attempt", func(t *testing.T) {
+		service := NewJobServiceImplWithConfig(newCountingRepository(), &ServiceConfig{ProgressCacheTTL: time.Minute})
+		jobID := newJob(t, service)
+
+		// Première tentative en échec
+		claimed, err := service.ClaimJob(ctx, jobID)
+		require.NoError(t, err)
+		require.True(t, claimed)
+		require.NoError(t, service.UpdateJobStatus(ctx, jobID, models.StatusFailed, 0, "slidev build failed"))
+		require.NoError(t, service.RecordJobAttempt(ctx, jobID, models.JobAttempt{
+			Status: models.StatusFailed, Error: "slidev build failed", DurationMs: 1200,
+		}))
+
+		// Le job est remis en file puis réussit
+		require.NoError(t, service.UpdateJobStatus(ctx, jobID, models.StatusPending, 0, ""))
+		claimed, err = service.ClaimJob(ctx, jobID)
+		require.NoError(t, err)
+		require.True(t, claimed)
+
+		job, err := service.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusProcessing, job.Status)
+		assert.Empty(t, job.Error, "the previous attempt error must not leak into the new attempt")
+
+		require.NoError(t, service.UpdateJobStatus(ctx, jobID, models.StatusCompleted, 100, ""))
+		require.NoError(t, service.RecordJobAttempt(ctx, jobID, models.JobAttempt{
+			Status: models.StatusCompleted, DurationMs: 800,
+		}))
+
+		job, err = service.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		response := job.ToResponse()
+		assert.Equal(t, models.StatusCompleted, response.Status)
+		assert.Empty(t, response.Error)
+		assert.Equal(t, 2, response.AttemptCount)
+		require.Len(t, response.Attempts, 2)
+		assert.Equal(t, 1, response.Attempts[0].Number)
+		assert.Equal(t, models.StatusFailed, response.Attempts[0].Status)
+		assert.Equal(t, "slidev build failed", response.Attempts[0].Error)
+		assert.Equal(t, int64(1200), response.Attempts[0].DurationMs)
+		assert.False(t, response.Attempts[0].StartedAt.IsZero(), "the attempt starts when the job is claimed")
+		assert.Equal(t, 2, response.Attempts[1].Number)
+		assert.Equal(t, models.StatusCompleted, response.Attempts[1].Status)
+		assert.Equal(t, int64(800), response.Attempts[1].DurationMs)
+	})
+
+	t.Run("Attempt recorded when claimed", func(t *testing.T) {
+		service := NewJobServiceImpl(newCountingRepository())
+		jobID := newJob(t, service)
+
+		claimed, err := service.ClaimJob(ctx, jobID)
+		require.NoError(t, err)
+		require.True(t, claimed)
+
+		job, err := service.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, job.AttemptCount)
+		require.Len(t, job.Attempts, 1)
+		assert.Equal(t, models.StatusProcessing, job.Attempts[0].Status)
+
+		// Le worker s'arrête sans clôturer la tentative : le job est réservé à nouveau
+		require.NoError(t, service.UpdateJobStatus(ctx, jobID, models.StatusPending, 0, ""))
+		claimed, err = service.ClaimJob(ctx, jobID)
+		require.NoError(t, err)
+		require.True(t, claimed)
+
+		job, err = service.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, job.AttemptCount)
+		require.Len(t, job.Attempts, 2)
+		assert.Equal(t, models.StatusFailed, job.Attempts[0].Status)
+		assert.Equal(t, models.InterruptedAttemptError, job.Attempts[0].Error)
+		assert.Equal(t, models.StatusProcessing, job.Attempts[1].Status)
+
+		// La fin du traitement clôture la tentative en cours sans en ajouter
+		completedAt := time.Now()
+		require.NoError(t, service.RecordJobAttempt(ctx, jobID, models.JobAttempt{
+			Status: models.StatusCompleted, CompletedAt: completedAt,
+		}))
+		job, err = service.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, job.AttemptCount)
+		require.Len(t, job.Attempts, 2)
+		assert.Equal(t, 2, job.Attempts[1].Number)
+		assert.Equal(t, models.StatusCompleted, job.Attempts[1].Status)
+		assert.Equal(t, completedAt, job.Attempts[1].CompletedAt)
+	})
+
+	t.Run("History is capped", func(t *testing.T) {
+		service := NewJobServiceImpl(newCountingRepository())
+		jobID := newJob(t, service)
+
+		for i := 0; i < models.MaxJobAttemptHistory+3; i++ {
+			require.NoError(t, service.RecordJobAttempt(ctx, jobID, models.JobAttempt{Status: models.StatusFailed}))
+		}
+
+		job, err := service.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, models.MaxJobAttemptHistory+3, job.AttemptCount)
+		require.Len(t, job.Attempts, models.MaxJobAttemptHistory)
+		assert.Equal(t, 4, job.Attempts[0].Number)
+		assert.Equal(t, models.MaxJobAttemptHistory+3, job.Attempts[len(job.Attempts)-1].Number)
+	})
+}
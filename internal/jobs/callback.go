@@ -0,0 +1,206 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/validation"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/webhook"
+)
+
+var (
+	// ErrNoCallbackURL est retournée quand le job n'a pas de callback configuré
+	ErrNoCallbackURL = errors.New("job has no callback URL")
+	// ErrCallbackMaxAttempts est retournée quand le nombre max de tentatives est atteint
+	ErrCallbackMaxAttempts = errors.New("maximum callback delivery attempts reached")
+)
+
+// maxCallbackRedirects borne les redirections suivies par un callback
+const maxCallbackRedirects = 3
+
+// CallbackConfig contient la configuration de livraison des callbacks
+type CallbackConfig struct {
+	MaxAttempts int           // Nombre max de tentatives par job
+	Timeout     time.Duration // Timeout d'une tentative
+
+	// SigningSecret signe les payloads en HMAC-SHA256 (vide = callbacks non signés)
+	SigningSecret string
+
+	// SchemaVersion choisit le format des payloads : models.WebhookSchemaVersion pour
+	// l'enveloppe versionnée et ses événements non terminaux, l'ancien JobResponse sinon
+	SchemaVersion string
+
+	// RetryBaseDelay est le délai avant la première relance d'une livraison échouée, doublé
+	// à chaque relance jusqu'à RetryMaxDelay (défauts: DefaultCallbackRetryBaseDelay et
+	// DefaultCallbackRetryMaxDelay)
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+const (
+	// DefaultCallbackRetryBaseDelay est le délai par défaut avant la première relance
+	DefaultCallbackRetryBaseDelay = 30 * time.Second
+	// DefaultCallbackRetryMaxDelay borne par défaut le délai entre deux relances
+	DefaultCallbackRetryMaxDelay = 30 * time.Minute
+)
+
+// DefaultCallbackConfig retourne la configuration par défaut des callbacks
+func DefaultCallbackConfig() *CallbackConfig {
+	return &CallbackConfig{
+		MaxAttempts:    5,
+		Timeout:        10 * time.Second,
+		RetryBaseDelay: DefaultCallbackRetryBaseDelay,
+		RetryMaxDelay:  DefaultCallbackRetryMaxDelay,
+	}
+}
+
+// CallbackNotifier envoie les callbacks de fin de job et persiste l'état de livraison
+type CallbackNotifier struct {
+	jobService JobService
+	policy     *validation.CallbackPolicy
+	config     *CallbackConfig
+	client     *http.Client
+}
+
+// NewCallbackNotifier crée un nouveau notifier de callbacks
+func NewCallbackNotifier(jobService JobService, policy *validation.CallbackPolicy, config *CallbackConfig) *CallbackNotifier {
+	if policy == nil {
+		policy = validation.DefaultCallbackPolicy()
+	}
+	if config == nil {
+		config = DefaultCallbackConfig()
+	}
+
+	return &CallbackNotifier{
+		jobService: jobService,
+		policy:     policy,
+		config:     config,
+		client: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: policy.Transport(),
+			// Chaque redirection est soumise à la même politique que l'URL de départ
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxCallbackRedirects {
+					return fmt.Errorf("stopped after %d callback redirects", len(via))
+				}
+				return policy.CheckCallbackURL(req.Context(), req.URL.String())
+			},
+		},
+	}
+}
+
+// Notify envoie le callback d'un job et enregistre la tentative. Un échec est replanifié
+// dans la file des callbacks tant qu'il reste des tentatives.
+func (n *CallbackNotifier) Notify(ctx context.Context, job *models.GenerationJob) (*models.GenerationJob, error) {
+	if job.CallbackURL == "" {
+		return job, ErrNoCallbackURL
+	}
+
+	if job.CallbackAttempts >= n.config.MaxAttempts {
+		return job, ErrCallbackMaxAttempts
+	}
+
+	deliveryErr := n.send(ctx, job)
+
+	var retryAt *time.Time
+	if attempt := job.CallbackAttempts + 1; deliveryErr != nil && attempt < n.config.MaxAttempts {
+		next := time.Now().Add(n.RetryDelay(attempt))
+		retryAt = &next
+	}
+
+	updated, err := n.jobService.RecordCallbackAttempt(ctx, job.ID, deliveryErr, retryAt)
+	if err != nil {
+		log.Printf("CallbackNotifier: failed to record callback attempt for job %s: %v", job.ID, err)
+		return job, err
+	}
+
+	return updated, deliveryErr
+}
+
+// RetryDelay retourne le délai avant la relance qui suit l'échec de la tentative attempt
+// (1 pour la première) : RetryBaseDelay doublé à chaque tentative, borné par RetryMaxDelay
+func (n *CallbackNotifier) RetryDelay(attempt int) time.Duration {
+	delay, maxDelay := n.config.RetryBaseDelay, n.config.RetryMaxDelay
+	if delay <= 0 {
+		delay = DefaultCallbackRetryBaseDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultCallbackRetryMaxDelay
+	}
+
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+// SendsEvents indique si les événements non terminaux (job.started) sont envoyés : l'ancien
+// format ne connaît que le callback de fin de job
+func (n *CallbackNotifier) SendsEvents() bool {
+	return n.config.SchemaVersion == models.WebhookSchemaVersion
+}
+
+// NotifyStarted envoie l'événement job.started d'un job réservé. L'envoi est unique : il
+// n'est ni enregistré sur le job ni relancé, contrairement au callback de fin de job.
+func (n *CallbackNotifier) NotifyStarted(ctx context.Context, job *models.GenerationJob, startedAt time.Time) error {
+	if job.CallbackURL == "" {
+		return ErrNoCallbackURL
+	}
+	if !n.SendsEvents() {
+		return nil
+	}
+	return n.post(ctx, job.CallbackURL, models.NewJobStartedPayload(job, startedAt))
+}
+
+// send effectue la requête HTTP du callback de fin de job
+func (n *CallbackNotifier) send(ctx context.Context, job *models.GenerationJob) error {
+	return n.post(ctx, job.CallbackURL, models.NewWebhookPayload(job))
+}
+
+// post envoie un payload au format configuré
+func (n *CallbackNotifier) post(ctx context.Context, callbackURL string, payload *models.WebhookPayload) error {
+	// Résoudre et vérifier l'hôte juste avant l'envoi (protection SSRF)
+	if err := n.policy.CheckCallbackURL(ctx, callbackURL); err != nil {
+		return err
+	}
+
+	var content any = payload
+	if !n.SendsEvents() {
+		// Ancien format : le job seul, annoncé comme tel par X-OCF-Schema-Version
+		payload.SchemaVersion = models.WebhookSchemaVersionLegacy
+		content = payload.Job
+	}
+	body, err := json.Marshal(content)
+	if err != nil {
+		return fmt.Errorf("failed to encode callback payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create callback request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ocf-worker")
+	webhook.SetHeaders(req.Header, []byte(n.config.SigningSecret), payload, body)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("callback request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,281 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// CallbackQueueConfig contient la configuration des livreurs de callbacks
+type CallbackQueueConfig struct {
+	Workers      int           // Livraisons simultanées (défaut: 2)
+	PollInterval time.Duration // Intervalle de relevé des livraisons échues (défaut: 5s)
+	BatchSize    int           // Livraisons réservées par relevé (défaut: 20)
+}
+
+// DefaultCallbackQueueConfig retourne la configuration par défaut des livreurs de callbacks
+func DefaultCallbackQueueConfig() *CallbackQueueConfig {
+	return &CallbackQueueConfig{
+		Workers:      2,
+		PollInterval: 5 * time.Second,
+		BatchSize:    20,
+	}
+}
+
+// minCallbackLease est la durée minimale de réservation d'une livraison par une instance
+const minCallbackLease = time.Minute
+
+// CallbackQueue livre les callbacks de fin de job depuis une file persistée en base :
+// l'échéance de la prochaine tentative est stockée sur le job, une livraison en attente
+// survit donc à un redémarrage. Les livreurs sont indépendants des workers de build, qu'un
+// destinataire lent ou indisponible ne bloque pas.
+type CallbackQueue struct {
+	jobService JobService
+	notifier   *CallbackNotifier
+	config     *CallbackQueueConfig
+	lease      time.Duration
+	deliveries chan *models.GenerationJob
+	slots      chan struct{} // Un jeton par livreur occupé
+	wake       chan struct{}
+	depth      atomic.Int64 // Livraisons en attente au dernier relevé
+	stopCh     chan struct{}
+	wg         sync.WaitGroup
+	running    bool
+	mu         sync.Mutex
+}
+
+// NewCallbackQueue crée la file de livraison des callbacks
+func NewCallbackQueue(jobService JobService, notifier *CallbackNotifier, config *CallbackQueueConfig) *CallbackQueue {
+	defaults := DefaultCallbackQueueConfig()
+	if config == nil {
+		config = defaults
+	}
+	resolved := *config
+	if resolved.Workers <= 0 {
+		resolved.Workers = defaults.Workers
+	}
+	if resolved.PollInterval <= 0 {
+		resolved.PollInterval = defaults.PollInterval
+	}
+	if resolved.BatchSize <= 0 {
+		resolved.BatchSize = defaults.BatchSize
+	}
+
+	return &CallbackQueue{
+		jobService: jobService,
+		notifier:   notifier,
+		config:     &resolved,
+		// Une livraison réservée n'est reprise qu'après le timeout de sa tentative
+		lease:      max(minCallbackLease, 2*notifier.config.Timeout),
+		deliveries: make(chan *models.GenerationJob, resolved.Workers),
+		slots:      make(chan struct{}, resolved.Workers),
+		wake:       make(chan struct{}, 1),
+		stopCh:     make(chan struct{}),
+	}
+}
+
+// Enqueue met en file la livraison du callback d'un job terminé
+func (q *CallbackQueue) Enqueue(ctx context.Context, jobID uuid.UUID) error {
+	now := time.Now()
+	if err := q.jobService.ScheduleCallback(ctx, jobID, &now); err != nil {
+		return err
+	}
+	q.depth.Add(1)
+
+	// Réveiller le relevé sans attendre le prochain intervalle
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Redeliver met en file une relance manuelle du callback d'un job terminé. La livraison
+// passe par les livreurs, comme une relance automatique.
+func (q *CallbackQueue) Redeliver(ctx context.Context, job *models.GenerationJob) error {
+	if job.CallbackURL == "" {
+		return ErrNoCallbackURL
+	}
+	if job.CallbackAttempts >= q.notifier.config.MaxAttempts {
+		return ErrCallbackMaxAttempts
+	}
+	return q.Enqueue(ctx, job.ID)
+}
+
+// NotifyStarted envoie en arrière-plan l'événement job.started d'un job réservé, sans
+// retarder son build. Une seule tentative : l'échec est journalisé, pas relancé.
+func (q *CallbackQueue) NotifyStarted(job *models.GenerationJob) {
+	if job.CallbackURL == "" || !q.notifier.SendsEvents() {
+		return
+	}
+
+	// Copie : le worker continue de modifier le job pendant l'envoi
+	claimed, startedAt := *job, time.Now()
+	go func() {
+		// Le client HTTP du notifier borne la tentative à son timeout
+		if err := q.notifier.NotifyStarted(context.Background(), &claimed, startedAt); err != nil {
+			log.Printf("CallbackQueue: failed to send started event for job %s: %v", job.ID, err)
+		}
+	}()
+}
+
+// Depth retourne le nombre de livraisons en attente, échues ou planifiées
+func (q *CallbackQueue) Depth() int {
+	return int(q.depth.Load())
+}
+
+// Start démarre le relevé des livraisons échues et les livreurs
+func (q *CallbackQueue) Start(ctx context.Context) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.running {
+		return
+	}
+
+	for i := 0; i < q.config.Workers; i++ {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			q.runDeliveries(ctx)
+		}()
+	}
+
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		q.runPoller(ctx)
+	}()
+
+	q.running = true
+	log.Printf("Callback queue started (%d workers, interval: %v)", q.config.Workers, q.config.PollInterval)
+}
+
+// Stop arrête la file après les livraisons en cours. Les livraisons réservées mais non
+// envoyées sont reprises à l'expiration de leur réservation.
+func (q *CallbackQueue) Stop() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.running {
+		return
+	}
+
+	close(q.stopCh)
+	q.wg.Wait()
+	q.running = false
+	log.Println("Callback queue stopped")
+}
+
+// runPoller relève les livraisons échues à chaque intervalle ou mise en file
+func (q *CallbackQueue) runPoller(ctx context.Context) {
+	ticker := time.NewTicker(q.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		q.dispatchDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.stopCh:
+			return
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+// dispatchDue réserve autant de livraisons échues que de livreurs libres et les leur confie :
+// une livraison réservée est envoyée aussitôt, avant l'expiration de sa réservation
+func (q *CallbackQueue) dispatchDue(ctx context.Context) {
+	if depth, err := q.jobService.CountPendingCallbacks(ctx); err != nil {
+		log.Printf("CallbackQueue: failed to count pending callbacks: %v", err)
+	} else {
+		q.depth.Store(int64(depth))
+	}
+
+	for {
+		free := q.acquireSlots(q.config.BatchSize)
+		if free == 0 {
+			// Les livreurs réveilleront le relevé en se libérant
+			return
+		}
+
+		due, err := q.jobService.ClaimDueCallbacks(ctx, free, q.lease)
+		if err != nil {
+			log.Printf("CallbackQueue: failed to claim due callbacks: %v", err)
+		}
+
+		for range free - len(due) {
+			<-q.slots
+		}
+		for _, job := range due {
+			q.deliveries <- job
+		}
+
+		if err != nil || len(due) < free {
+			return
+		}
+	}
+}
+
+// acquireSlots réserve jusqu'à n livreurs libres et retourne le nombre réservé
+func (q *CallbackQueue) acquireSlots(n int) int {
+	acquired := 0
+	for acquired < n {
+		select {
+		case q.slots <- struct{}{}:
+			acquired++
+		default:
+			return acquired
+		}
+	}
+	return acquired
+}
+
+// runDeliveries livre les callbacks confiés par le relevé
+func (q *CallbackQueue) runDeliveries(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.stopCh:
+			return
+		case job := <-q.deliveries:
+			q.deliver(ctx, job)
+			<-q.slots
+
+			// Un livreur libre peut prendre les livraisons échues restantes
+			select {
+			case q.wake <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// deliver envoie le callback d'un job ; un échec est replanifié par le notifier
+func (q *CallbackQueue) deliver(ctx context.Context, job *models.GenerationJob) {
+	updated, err := q.notifier.Notify(ctx, job)
+	switch {
+	case errors.Is(err, ErrNoCallbackURL), errors.Is(err, ErrCallbackMaxAttempts):
+		// Plus rien à livrer : retirer le job de la file
+		if err := q.jobService.ScheduleCallback(ctx, job.ID, nil); err != nil {
+			log.Printf("CallbackQueue: failed to dequeue callback for job %s: %v", job.ID, err)
+		}
+	case err != nil && updated.CallbackNextAttemptAt != nil:
+		log.Printf("CallbackQueue: callback delivery failed for job %s (attempt %d), retrying at %s: %v",
+			job.ID, updated.CallbackAttempts, updated.CallbackNextAttemptAt.Format(time.RFC3339), err)
+	case err != nil:
+		log.Printf("CallbackQueue: callback delivery failed for job %s (attempt %d), giving up: %v",
+			job.ID, updated.CallbackAttempts, err)
+	}
+}
@@ -0,0 +1,321 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/validation"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/webhook"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// callbackReceiver est un destinataire de callbacks qui échoue un nombre donné de fois
+type callbackReceiver struct {
+	mu       sync.Mutex
+	failures int
+	arrivals []time.Time
+}
+
+func (r *callbackReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.arrivals = append(r.arrivals, time.Now())
+	if len(r.arrivals) <= r.failures {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (r *callbackReceiver) received() []time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]time.Time(nil), r.arrivals...)
+}
+
+func TestCallbackRetryDelay(t *testing.T) {
+	notifier := NewCallbackNotifier(nil, nil, &CallbackConfig{
+		RetryBaseDelay: time.Second,
+		RetryMaxDelay:  10 * time.Second,
+	})
+	var delays []time.Duration
+	for attempt := 1; attempt <= 6; attempt++ {
+		delays = append(delays, notifier.RetryDelay(attempt))
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second,
+		10 * time.Second, 10 * time.Second}, delays)
+
+	// Sans délais configurés, les délais par défaut s'appliquent
+	notifier = NewCallbackNotifier(nil, nil, &CallbackConfig{MaxAttempts: 5})
+	assert.Equal(t, DefaultCallbackRetryBaseDelay, notifier.RetryDelay(1))
+	assert.Equal(t, DefaultCallbackRetryMaxDelay, notifier.RetryDelay(20))
+}
+
+func TestCallbackQueue(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T, receiver *callbackReceiver) (JobService, *CallbackNotifier, uuid.UUID) {
+		server := httptest.NewServer(receiver)
+		t.Cleanup(server.Close)
+
+		service := NewJobServiceImpl(newCountingRepository())
+		job, err := service.CreateJob(ctx, &models.GenerationRequest{
+			JobID:       uuid.New(),
+			CourseID:    uuid.New(),
+			SourcePath:  "test/path",
+			CallbackURL: server.URL + "/webhook",
+		})
+		require.NoError(t, err)
+		require.NoError(t, service.UpdateJobStatus(ctx, job.ID, models.StatusCompleted, 100, ""))
+
+		// Le récepteur de test écoute en loopback
+		policy := validation.DefaultCallbackPolicy()
+		policy.AllowPrivateNetworks = true
+		notifier := NewCallbackNotifier(service, policy, &CallbackConfig{
+			MaxAttempts:    3,
+			Timeout:        time.Second,
+			RetryBaseDelay: 100 * time.Millisecond,
+			RetryMaxDelay:  time.Second,
+		})
+		return service, notifier, job.ID
+	}
+
+	start := func(t *testing.T, service JobService, notifier *CallbackNotifier) *CallbackQueue {
+		queue := NewCallbackQueue(service, notifier, &CallbackQueueConfig{Workers: 2, PollInterval: 20 * time.Millisecond})
+		queue.Start(ctx)
+		t.Cleanup(queue.Stop)
+		return queue
+	}
+
+	t.Run("Retries with backoff until delivered", func(t *testing.T) {
+		receiver := &callbackReceiver{failures: 2}
+		service, notifier, jobID := setup(t, receiver)
+		queue := start(t, service, notifier)
+
+		require.NoError(t, queue.Enqueue(ctx, jobID))
+		require.Eventually(t, func() bool {
+			job, err := service.GetJob(ctx, jobID)
+			return err == nil && job.CallbackDelivered
+		}, 5*time.Second, 10*time.Millisecond)
+
+		job, err := service.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, 3, job.CallbackAttempts)
+		assert.Nil(t, job.CallbackNextAttemptAt)
+		assert.Empty(t, job.CallbackLastError)
+
+		// Le délai double entre deux relances
+		arrivals := receiver.received()
+		require.Len(t, arrivals, 3)
+		assert.GreaterOrEqual(t, arrivals[1].Sub(arrivals[0]), 100*time.Millisecond)
+		assert.GreaterOrEqual(t, arrivals[2].Sub(arrivals[1]), 200*time.Millisecond)
+
+		require.Eventually(t, func() bool { return queue.Depth() == 0 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Gives up after the maximum attempts", func(t *testing.T) {
+		receiver := &callbackReceiver{failures: 10}
+		service, notifier, jobID := setup(t, receiver)
+		queue := start(t, service, notifier)
+
+		require.NoError(t, queue.Enqueue(ctx, jobID))
+		require.Eventually(t, func() bool {
+			job, err := service.GetJob(ctx, jobID)
+			return err == nil && job.CallbackAttempts == 3 && job.CallbackNextAttemptAt == nil
+		}, 5*time.Second, 10*time.Millisecond)
+
+		job, err := service.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		assert.False(t, job.CallbackDelivered)
+		assert.Equal(t, "callback returned status 503", job.CallbackLastError)
+
+		// Plus aucune tentative après la dernière
+		time.Sleep(300 * time.Millisecond)
+		assert.Len(t, receiver.received(), 3)
+		assert.Equal(t, 0, queue.Depth())
+	})
+
+	t.Run("Pending deliveries survive a restart", func(t *testing.T) {
+		receiver := &callbackReceiver{}
+		service, notifier, jobID := setup(t, receiver)
+
+		// Mise en file par une instance arrêtée avant la livraison
+		stopped := NewCallbackQueue(service, notifier, nil)
+		require.NoError(t, stopped.Enqueue(ctx, jobID))
+		job, err := service.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		require.NotNil(t, job.CallbackNextAttemptAt)
+
+		count, err := service.CountPendingCallbacks(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		start(t, service, notifier)
+		require.Eventually(t, func() bool {
+			job, err := service.GetJob(ctx, jobID)
+			return err == nil && job.CallbackDelivered
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Len(t, receiver.received(), 1)
+	})
+
+	t.Run("Claims only what idle workers can send", func(t *testing.T) {
+		service, notifier, jobID := setup(t, &callbackReceiver{})
+		ids := []uuid.UUID{jobID}
+		for range 2 {
+			job, err := service.CreateJob(ctx, &models.GenerationRequest{
+				JobID:       uuid.New(),
+				CourseID:    uuid.New(),
+				SourcePath:  "test/path",
+				CallbackURL: "https://hooks.example.com/webhook",
+			})
+			require.NoError(t, err)
+			ids = append(ids, job.ID)
+		}
+
+		// Livreurs non démarrés : un seul livreur libre, une seule réservation
+		queue := NewCallbackQueue(service, notifier, &CallbackQueueConfig{Workers: 1})
+		for _, id := range ids {
+			require.NoError(t, queue.Enqueue(ctx, id))
+		}
+		queue.dispatchDue(ctx)
+		assert.Len(t, queue.deliveries, 1)
+
+		// Les autres livraisons restent échues, disponibles pour une autre instance
+		claimed, err := service.ClaimDueCallbacks(ctx, 10, time.Minute)
+		require.NoError(t, err)
+		assert.Len(t, claimed, 2)
+	})
+
+	t.Run("Claimed deliveries are leased", func(t *testing.T) {
+		service, _, jobID := setup(t, &callbackReceiver{})
+
+		past := time.Now().Add(-time.Second)
+		require.NoError(t, service.ScheduleCallback(ctx, jobID, &past))
+
+		claimed, err := service.ClaimDueCallbacks(ctx, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		assert.Equal(t, jobID, claimed[0].ID)
+
+		// Une autre instance ne réserve pas la même livraison
+		claimed, err = service.ClaimDueCallbacks(ctx, 10, time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, claimed)
+
+		// Une relance future n'est pas encore échue
+		future := time.Now().Add(time.Hour)
+		_, err = service.RecordCallbackAttempt(ctx, jobID, assert.AnError, &future)
+		require.NoError(t, err)
+		claimed, err = service.ClaimDueCallbacks(ctx, 10, time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, claimed)
+
+		job, err := service.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		assert.WithinDuration(t, future, *job.CallbackNextAttemptAt, time.Millisecond)
+		assert.Equal(t, job.CallbackNextAttemptAt, job.ToResponse().Callback.NextAttemptAt)
+	})
+}
+
+func TestCallbackRedirects(t *testing.T) {
+	ctx := context.Background()
+
+	var received sync.WaitGroup
+	received.Add(1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/webhook", http.StatusTemporaryRedirect)
+		case "/escape":
+			// Même serveur, mais sous un nom d'hôte hors allowlist
+			http.Redirect(w, r, strings.Replace("http://"+r.Host+"/webhook", "127.0.0.1", "localhost", 1),
+				http.StatusTemporaryRedirect)
+		default:
+			received.Done()
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	policy := validation.DefaultCallbackPolicy()
+	policy.AllowPrivateNetworks = true
+	policy.AllowedHosts = []string{"127.0.0.1"}
+	notifier := NewCallbackNotifier(nil, policy, nil)
+
+	job := &models.GenerationJob{ID: uuid.New(), CallbackURL: server.URL + "/moved"}
+	require.NoError(t, notifier.send(ctx, job))
+	received.Wait()
+
+	// Une redirection vers un hôte refusé par la politique n'est pas suivie
+	job.CallbackURL = server.URL + "/escape"
+	err := notifier.send(ctx, job)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "allowed hosts")
+}
+
+func TestCallbackPayloadFormat(t *testing.T) {
+	ctx := context.Background()
+
+	type delivery struct {
+		header http.Header
+		body   map[string]any
+	}
+	deliveries := make(chan delivery, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		deliveries <- delivery{header: r.Header, body: body}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	policy := validation.DefaultCallbackPolicy()
+	policy.AllowPrivateNetworks = true
+	job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New(), Status: models.StatusCompleted,
+		CallbackURL: server.URL + "/webhook"}
+
+	t.Run("Legacy body by default", func(t *testing.T) {
+		notifier := NewCallbackNotifier(nil, policy, nil)
+		require.NoError(t, notifier.send(ctx, job))
+
+		received := <-deliveries
+		assert.Equal(t, models.WebhookSchemaVersionLegacy, received.header.Get(webhook.HeaderSchemaVersion))
+		assert.Equal(t, job.ID.String(), received.body["id"])
+		assert.NotContains(t, received.body, "schema_version")
+
+		// L'ancien format n'a pas d'événement de démarrage
+		require.NoError(t, notifier.NotifyStarted(ctx, job, time.Now()))
+		assert.Empty(t, deliveries)
+	})
+
+	t.Run("Envelope with started event", func(t *testing.T) {
+		notifier := NewCallbackNotifier(nil, policy, &CallbackConfig{
+			MaxAttempts:   5,
+			Timeout:       10 * time.Second,
+			SchemaVersion: models.WebhookSchemaVersion,
+		})
+
+		require.NoError(t, notifier.NotifyStarted(ctx, job, time.Now()))
+		received := <-deliveries
+		assert.Equal(t, string(models.WebhookEventJobStarted), received.header.Get(webhook.HeaderEvent))
+		assert.Equal(t, models.WebhookSchemaVersion, received.body["schema_version"])
+		assert.Equal(t, string(models.WebhookEventJobStarted), received.body["event_type"])
+		assert.Equal(t, string(models.StatusProcessing), received.body["job"].(map[string]any)["status"])
+
+		require.NoError(t, notifier.send(ctx, job))
+		received = <-deliveries
+		assert.Equal(t, string(models.WebhookEventJobCompleted), received.body["event_type"])
+		assert.Equal(t, job.ID.String(), received.body["job_id"])
+	})
+}
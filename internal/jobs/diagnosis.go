@@ -0,0 +1,273 @@
+// internal/jobs/diagnosis.go - Diagnostic des jobs en échec à partir de leurs logs
+package jobs
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+)
+
+// maxEvidenceLength borne la ligne de log retournée comme preuve d'un diagnostic
+const maxEvidenceLength = 300
+
+// failureSignature associe des messages émis par le worker, Slidev ou npm à une cause d'échec
+type failureSignature struct {
+	category    string
+	summary     string
+	suggestions []string
+	patterns    []*regexp.Regexp
+}
+
+// failureSignatures sont testées par ordre de priorité : la première reconnue est la cause
+// principale. Un timeout ou un manque de mémoire provoque souvent d'autres erreurs en cascade.
+var failureSignatures = []failureSignature{
+	{
+		category: models.DiagnosisDownloadTimeout,
+		summary:  "The sources could not be downloaded before the download timeout",
+		suggestions: []string{
+			"Reduce the size of the sources (large images or videos)",
+			"Check the storage backend latency with GET /api/v1/storage/info",
+			"Raise SOURCE_DOWNLOAD_TIMEOUT on the worker",
+		},
+		patterns: signaturePatterns(
+			`source download timed out`,
+		),
+	},
+	{
+		category: models.DiagnosisTimeout,
+		summary:  "The build did not finish before the job timeout",
+		suggestions: []string{
+			"Reduce the size of the course (number of slides, large images or videos)",
+			"Raise JOB_TIMEOUT on the worker if large builds are expected",
+			"Retry the job: a busy worker or a slow npm registry can delay the build",
+		},
+		patterns: signaturePatterns(
+			`slidev build timeout or cancelled`,
+			`context deadline exceeded`,
+			`cancelled while waiting for a build slot`,
+		),
+	},
+	{
+		category: models.DiagnosisOutOfMemory,
+		summary:  "The build ran out of memory",
+		suggestions: []string{
+			"Reduce the size of the course or split it into several decks",
+			"Raise BUILD_MEMORY_LIMIT_MB on the worker",
+		},
+		patterns: signaturePatterns(
+			`out of memory: .* exceeded the \d+ MB memory limit`,
+			`JavaScript heap out of memory`,
+		),
+	},
+	{
+		category: models.DiagnosisMissingSlideFile,
+		summary:  "No slide file was found in the job sources",
+		suggestions: []string{
+			"Upload a slides.md file at the root of the sources",
+			"Set entry_file in the generation request to the path of the deck to build",
+			"Check that the upload succeeded with GET /api/v1/storage/jobs/{job_id}/sources",
+		},
+		patterns: signaturePatterns(
+			`no slide file found`,
+			`entry file .* not found in sources`,
+			`no source files found for job`,
+		),
+	},
+	{
+		category: models.DiagnosisBrokenInclude,
+		summary:  "A slide imported with src: is missing from the sources or imports itself",
+		suggestions: []string{
+			"Upload the imported Markdown files with the deck, keeping their relative paths",
+			"Fix the src: path in the slide frontmatter: it is relative to the file that declares it",
+			"Remove the import that closes the cycle between the listed files",
+		},
+		patterns: signaturePatterns(
+			`broken src includes`,
+			`src include .* not found in sources`,
+			`circular src include`,
+		),
+	},
+	{
+		category: models.DiagnosisThemeInstall,
+		summary:  "The Slidev theme could not be installed or loaded",
+		suggestions: []string{
+			"Check the theme name in the slides frontmatter (theme: seriph for @slidev/theme-seriph)",
+			"Use a theme published on npm, or bundle a local theme with the sources",
+			"Preview the theme with POST /api/v1/themes/{theme}/preview before building the course",
+			"If the theme is not allowed, use one permitted by the worker theme policy (ALLOWED_THEMES, DENIED_THEMES)",
+		},
+		patterns: signaturePatterns(
+			`theme not allowed`,
+			`failed to install theme`,
+			`theme .*not found`,
+			`(?:cannot|can't) find (?:module|package) ['"]?(?:@slidev/theme-|slidev-theme-)`,
+			`failed to auto-install packages`,
+		),
+	},
+	{
+		category: models.DiagnosisNpmError,
+		summary:  "npm failed to install the course dependencies",
+		suggestions: []string{
+			"Check the dependencies declared in package.json (names and versions)",
+			"Retry the job if the npm registry was unavailable",
+			"Remove package-lock.json from the sources if it pins unavailable versions",
+		},
+		patterns: signaturePatterns(
+			`npm ERR!`,
+			`npm error`,
+			`npm install failed`,
+			`dependency installation failed`,
+			`failed to install packages`,
+			`\bE(?:RESOLVE|TARGET|404|NOTFOUND|AI_AGAIN)\b`,
+		),
+	},
+	{
+		category: models.DiagnosisOutputValidation,
+		summary:  "Slidev finished but did not produce a usable site",
+		suggestions: []string{
+			"Check the build output in the generation log for Vite or Markdown errors",
+			"Make sure the deck does not override the build output directory (dist, or output_dir of the request)",
+		},
+		patterns: signaturePatterns(
+			`slidev output validation failed`,
+			`(?:dist|output) directory not found`,
+			`required output file not found`,
+			`index\.html is too small`,
+			`no result files generated`,
+		),
+	},
+	{
+		category: models.DiagnosisResultQuota,
+		summary:  "The results would exceed the course storage quota",
+		suggestions: []string{
+			"Check the course usage with GET /api/v1/storage/courses/{course_id}/usage",
+			"Reduce the size of the course (large images or videos, theme variants)",
+			"Raise the course quota with COURSE_RESULT_QUOTAS on the worker",
+		},
+		patterns: signaturePatterns(
+			`result quota exceeded`,
+		),
+	},
+	{
+		category: models.DiagnosisStorageError,
+		summary:  "The worker could not read the sources or write the results",
+		suggestions: []string{
+			"Retry the job: storage errors are often transient",
+			"Check the storage backend health with GET /api/v1/storage/info",
+		},
+		patterns: signaturePatterns(
+			`failed to download source`,
+			`failed to upload result`,
+			`failed to save result manifest`,
+			`failed to list (?:source|result) files`,
+		),
+	},
+	{
+		category: models.DiagnosisDefaultSlideFile,
+		summary:  "The sources had no slide file: the worker built a placeholder deck instead",
+		suggestions: []string{
+			"Upload the deck as slides.md at the root of the sources, or another name listed in SLIDE_FILES",
+			"Set entry_file in the generation request to the path of the deck to build",
+			"Check that the upload succeeded with GET /api/v1/storage/jobs/{job_id}/sources",
+		},
+		patterns: signaturePatterns(
+			`sources contain no slide file, default .* created`,
+		),
+	},
+	{
+		category: models.DiagnosisBuildError,
+		summary:  "The Slidev build failed",
+		suggestions: []string{
+			"Read the generation log for the Slidev or Vite error",
+			"Build the deck locally with npx @slidev/cli build to reproduce the error",
+		},
+		patterns: signaturePatterns(
+			`slidev build failed`,
+		),
+	},
+}
+
+// signaturePatterns compile des motifs insensibles à la casse
+func signaturePatterns(patterns ...string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		compiled[i] = regexp.MustCompile(`(?i)` + pattern)
+	}
+	return compiled
+}
+
+// DiagnoseJob analyse l'erreur, les logs d'avancement et le log de génération d'un job
+// pour reconnaître les causes d'échec connues. La cause principale est celle de plus haute
+// priorité ; chaque cause reconnue est rapportée une fois, avec la première ligne qui l'a révélée.
+func DiagnoseJob(job *models.GenerationJob, generationLog string) *models.JobDiagnosis {
+	diagnosis := &models.JobDiagnosis{
+		JobID:    job.ID.String(),
+		Status:   job.Status,
+		Error:    job.Error,
+		Findings: []models.DiagnosisFinding{},
+	}
+
+	sources := []struct {
+		name  string
+		lines []string
+	}{
+		{models.DiagnosisSourceError, strings.Split(job.Error, "\n")},
+		{models.DiagnosisSourceJobLogs, job.Logs},
+		{models.DiagnosisSourceGenerationLog, strings.Split(generationLog, "\n")},
+	}
+
+	for _, signature := range failureSignatures {
+	search:
+		for _, source := range sources {
+			for _, line := range source.lines {
+				if !signature.matches(line) {
+					continue
+				}
+				diagnosis.Findings = append(diagnosis.Findings, models.DiagnosisFinding{
+					Category:    signature.category,
+					Summary:     signature.summary,
+					Suggestions: signature.suggestions,
+					Evidence:    evidence(line),
+					Source:      source.name,
+				})
+				break search
+			}
+		}
+	}
+
+	if len(diagnosis.Findings) == 0 {
+		diagnosis.Category = models.DiagnosisUnknown
+		diagnosis.Summary = "The failure does not match a known cause"
+		diagnosis.Suggestions = []string{
+			"Read the job logs with GET /api/v1/storage/jobs/{job_id}/logs",
+			"Export the job with GET /api/v1/jobs/{id}/bundle to share it with support",
+		}
+		return diagnosis
+	}
+
+	primary := diagnosis.Findings[0]
+	diagnosis.Category = primary.Category
+	diagnosis.Summary = primary.Summary
+	diagnosis.Suggestions = primary.Suggestions
+	return diagnosis
+}
+
+// matches indique si une ligne porte la signature
+func (s *failureSignature) matches(line string) bool {
+	for _, pattern := range s.patterns {
+		if pattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// evidence nettoie et tronque une ligne de log retournée comme preuve
+func evidence(line string) string {
+	line = strings.TrimSpace(line)
+	if len(line) > maxEvidenceLength {
+		line = strings.ToValidUTF8(line[:maxEvidenceLength], "") + "..."
+	}
+	return line
+}
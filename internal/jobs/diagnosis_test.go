@@ -0,0 +1,147 @@
+package jobs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnoseJob(t *testing.T) {
+	tests := []struct {
+		name          string
+		jobError      string
+		logs          []string
+		generationLog string
+		category      string
+		source        string
+	}{
+		{
+			name:     "Missing slide file",
+			jobError: "failed to download sources: no slide file found (checked: [slides.md presentation.md])",
+			category: models.DiagnosisMissingSlideFile,
+			source:   models.DiagnosisSourceError,
+		},
+		{
+			name:     "Broken src include",
+			jobError: "slidev build failed: broken src includes: circular src include: slides.md -> pages/a.md -> slides.md",
+			category: models.DiagnosisBrokenInclude,
+			source:   models.DiagnosisSourceError,
+		},
+		{
+			name:          "Theme install failure",
+			jobError:      "slidev build failed: slidev build failed with exit code 1: exit status 1",
+			generationLog: "[STDOUT] Preparing build\n[STDERR] Error: theme seriph-typo not found\n",
+			category:      models.DiagnosisThemeInstall,
+			source:        models.DiagnosisSourceGenerationLog,
+		},
+		{
+			name:          "npm error",
+			jobError:      "slidev build failed: slidev build failed with exit code 1: exit status 1",
+			generationLog: "[STDERR] npm ERR! code ETARGET\n[STDERR] npm ERR! notarget No matching version found for vue@99.0.0\n",
+			category:      models.DiagnosisNpmError,
+			source:        models.DiagnosisSourceGenerationLog,
+		},
+		{
+			name:     "Timeout",
+			jobError: "slidev build failed: slidev build timeout or cancelled",
+			category: models.DiagnosisTimeout,
+			source:   models.DiagnosisSourceError,
+		},
+		{
+			name:     "Output validation failure",
+			jobError: "slidev build failed: slidev output validation failed: dist directory not found: /workspace/dist (tried alternatives: [build])",
+			category: models.DiagnosisOutputValidation,
+			source:   models.DiagnosisSourceError,
+		},
+		{
+			name:     "Source download timeout",
+			jobError: "failed to download sources: source download timed out after 5m0s (3/12 files, 1048576 bytes downloaded): context deadline exceeded",
+			category: models.DiagnosisDownloadTimeout,
+			source:   models.DiagnosisSourceError,
+		},
+		{
+			name:     "Result quota",
+			jobError: "failed to upload results: result quota exceeded for course 550e8400-e29b-41d4-a716-446655440000: 900 bytes stored + 500 bytes from this build exceed the 1000 bytes quota",
+			category: models.DiagnosisResultQuota,
+			source:   models.DiagnosisSourceError,
+		},
+		{
+			name:     "Storage error",
+			jobError: "failed to upload results: failed to upload result file index.html: connection refused",
+			category: models.DiagnosisStorageError,
+			source:   models.DiagnosisSourceError,
+		},
+		{
+			name:     "Out of memory in job logs",
+			jobError: "slidev build failed: slidev build failed with exit code 137: signal: killed",
+			logs:     []string{"[2026-01-01T10:00:00Z] out of memory: slidev build exceeded the 512 MB memory limit, reduce the course size or raise BUILD_MEMORY_LIMIT_MB"},
+			category: models.DiagnosisOutOfMemory,
+			source:   models.DiagnosisSourceJobLogs,
+		},
+		{
+			name:     "Default slide file injected",
+			jobError: "slidev build failed: slidev build failed with exit code 1: exit status 1",
+			logs:     []string{"[2026-01-01T10:00:00Z] Environment: sources contain no slide file, default slides.md created with placeholder content"},
+			category: models.DiagnosisDefaultSlideFile,
+			source:   models.DiagnosisSourceJobLogs,
+		},
+		{
+			name:     "Generic build failure",
+			jobError: "slidev build failed: slidev build failed with exit code 1: exit status 1",
+			category: models.DiagnosisBuildError,
+			source:   models.DiagnosisSourceError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &models.GenerationJob{ID: uuid.New(), Status: models.StatusFailed, Error: tt.jobError, Logs: tt.logs}
+
+			diagnosis := DiagnoseJob(job, tt.generationLog)
+			assert.Equal(t, tt.category, diagnosis.Category)
+			assert.NotEmpty(t, diagnosis.Summary)
+			assert.NotEmpty(t, diagnosis.Suggestions)
+			require.NotEmpty(t, diagnosis.Findings)
+			assert.Equal(t, tt.source, diagnosis.Findings[0].Source)
+			assert.NotEmpty(t, diagnosis.Findings[0].Evidence)
+		})
+	}
+
+	t.Run("All recognized causes, primary first", func(t *testing.T) {
+		job := &models.GenerationJob{
+			ID:     uuid.New(),
+			Status: models.StatusFailed,
+			Error:  "slidev build failed: slidev build failed with exit code 1: exit status 1",
+		}
+
+		diagnosis := DiagnoseJob(job, "[STDERR] npm error code E404\n[STDERR] Error: theme acme not found\n")
+		var categories []string
+		for _, finding := range diagnosis.Findings {
+			categories = append(categories, finding.Category)
+		}
+		assert.Equal(t, []string{models.DiagnosisThemeInstall, models.DiagnosisNpmError, models.DiagnosisBuildError}, categories)
+		assert.Equal(t, "[STDERR] Error: theme acme not found", diagnosis.Findings[0].Evidence)
+	})
+
+	t.Run("Unknown failure", func(t *testing.T) {
+		job := &models.GenerationJob{ID: uuid.New(), Status: models.StatusFailed, Error: "something unexpected"}
+
+		diagnosis := DiagnoseJob(job, "")
+		assert.Equal(t, models.DiagnosisUnknown, diagnosis.Category)
+		assert.Empty(t, diagnosis.Findings)
+		assert.NotEmpty(t, diagnosis.Suggestions)
+	})
+
+	t.Run("Long evidence is truncated", func(t *testing.T) {
+		job := &models.GenerationJob{ID: uuid.New(), Status: models.StatusFailed, Error: "npm ERR! " + strings.Repeat("x", 1000)}
+
+		diagnosis := DiagnoseJob(job, "")
+		require.NotEmpty(t, diagnosis.Findings)
+		assert.Len(t, diagnosis.Findings[0].Evidence, maxEvidenceLength+len("..."))
+	})
+}
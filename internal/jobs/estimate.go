@@ -0,0 +1,145 @@
+// internal/jobs/estimate.go - Estimation de la durée et de la taille d'un build
+package jobs
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+)
+
+const (
+	// estimateHistoryLimit est le nombre de builds récents pris en compte par niveau
+	estimateHistoryLimit = 500
+	// minEstimateSamples est le nombre de builds comparables requis pour retenir un niveau
+	minEstimateSamples = 3
+	// highConfidenceSamples est le nombre de builds comparables donnant une confiance élevée
+	highConfidenceSamples = 10
+	// maxThemeLength correspond à la taille de la colonne theme
+	maxThemeLength = 255
+)
+
+// DefaultTheme est le thème utilisé par Slidev quand les slides n'en déclarent pas
+const DefaultTheme = "default"
+
+// estimateTier est un niveau de comparaison, du plus précis au plus large
+type estimateTier struct {
+	basis      string
+	byTheme    bool
+	bySize     bool
+	confidence func(count int64) string
+}
+
+var estimateTiers = []estimateTier{
+	{basis: "theme_and_size", byTheme: true, bySize: true, confidence: func(count int64) string {
+		if count >= highConfidenceSamples {
+			return models.EstimateConfidenceHigh
+		}
+		return models.EstimateConfidenceMedium
+	}},
+	{basis: "size", bySize: true, confidence: func(count int64) string {
+		if count >= highConfidenceSamples {
+			return models.EstimateConfidenceMedium
+		}
+		return models.EstimateConfidenceLow
+	}},
+	{basis: "theme", byTheme: true, confidence: func(int64) string { return models.EstimateConfidenceLow }},
+	{basis: "all", confidence: func(int64) string { return models.EstimateConfidenceLow }},
+}
+
+// EstimateBuild estime la durée et la taille de sortie d'un build à partir des builds
+// terminés comparables : même thème et taille de sources proche (de moitié au double),
+// puis taille seule, thème seul et enfin tous les builds. Le premier niveau ayant assez
+// de builds est retenu, la confiance dépend du niveau et du nombre de builds.
+func (s *jobServiceImpl) EstimateBuild(ctx context.Context, req *models.EstimateRequest) (*models.BuildEstimate, error) {
+	ctx, span := s.tracer.Start(ctx, "JobService.EstimateBuild")
+	defer span.End()
+
+	estimate := &models.BuildEstimate{
+		SourceFileCount: req.SourceFileCount,
+		SourceSizeBytes: req.SourceSizeBytes,
+		Theme:           req.Theme,
+		Basis:           "none",
+		Confidence:      models.EstimateConfidenceNone,
+	}
+
+	for _, tier := range estimateTiers {
+		if (tier.byTheme && req.Theme == "") || (tier.bySize && req.SourceSizeBytes <= 0) {
+			continue
+		}
+
+		filters := BuildStatsFilters{Limit: estimateHistoryLimit}
+		if tier.byTheme {
+			filters.Theme = req.Theme
+		}
+		if tier.bySize {
+			filters.MinSourceSize = req.SourceSizeBytes / 2
+			filters.MaxSourceSize = req.SourceSizeBytes * 2
+		}
+
+		aggregate, err := s.repo.AggregateBuildStats(ctx, filters)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to aggregate build stats: %w", err)
+		}
+		if aggregate.Count < minEstimateSamples {
+			continue
+		}
+
+		resultSize := aggregate.AvgResultSizeBytes
+		if !tier.bySize && req.SourceSizeBytes > 0 && aggregate.AvgSourceSizeBytes > 0 {
+			// Builds de tailles diverses : ramener la sortie moyenne à la taille des sources
+			resultSize *= float64(req.SourceSizeBytes) / aggregate.AvgSourceSizeBytes
+		}
+
+		duration := time.Duration(aggregate.AvgDurationSeconds * float64(time.Second)).Round(time.Second)
+		estimate.EstimatedDurationSeconds = math.Round(aggregate.AvgDurationSeconds*10) / 10
+		estimate.EstimatedDuration = duration.String()
+		estimate.EstimatedResultSizeBytes = int64(math.Round(resultSize))
+		estimate.SampleSize = aggregate.Count
+		estimate.Basis = tier.basis
+		estimate.Confidence = tier.confidence(aggregate.Count)
+		break
+	}
+
+	return estimate, nil
+}
+
+// DetectTheme lit le thème déclaré dans l'en-tête YAML d'un fichier de slides.
+// Des slides sans en-tête ou sans clé theme utilisent DefaultTheme.
+func DetectTheme(slides io.Reader) string {
+	scanner := bufio.NewScanner(slides)
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "---" {
+		return DefaultTheme
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "---" {
+			break
+		}
+
+		value, found := strings.CutPrefix(line, "theme:")
+		if !found {
+			continue
+		}
+		if comment := strings.Index(value, " #"); comment >= 0 {
+			value = value[:comment]
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		if value == "" {
+			return DefaultTheme
+		}
+		if len(value) > maxThemeLength {
+			value = value[:maxThemeLength]
+		}
+		return value
+	}
+
+	return DefaultTheme
+}
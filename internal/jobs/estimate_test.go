@@ -0,0 +1,120 @@
+package jobs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectTheme(t *testing.T) {
+	tests := []struct {
+		name     string
+		slides   string
+		expected string
+	}{
+		{"Declared theme", "---\ntitle: Cours\ntheme: seriph\n---\n# Hello", "seriph"},
+		{"Quoted theme with comment", "---\ntheme: '@org/slidev-theme-ocf' # thème maison\n---\n", "@org/slidev-theme-ocf"},
+		{"No theme key", "---\ntitle: Cours\n---\ntheme: ignored\n", DefaultTheme},
+		{"No headmatter", "# Hello\ntheme: ignored\n", DefaultTheme},
+		{"Empty theme", "---\ntheme:\n---\n", DefaultTheme},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DetectTheme(strings.NewReader(tt.slides)))
+		})
+	}
+}
+
+func TestEstimateBuild(t *testing.T) {
+	ctx := context.Background()
+
+	// addBuilds enregistre des builds terminés de durée, taille de sources et de sortie données
+	addBuilds := func(repo *countingRepository, count int, theme string, duration time.Duration, sourceSize, resultSize int64) {
+		for i := 0; i < count; i++ {
+			started := time.Now().Add(-time.Hour)
+			completed := started.Add(duration)
+			job := &models.GenerationJob{
+				ID:              uuid.New(),
+				Status:          models.StatusCompleted,
+				StartedAt:       &started,
+				CompletedAt:     &completed,
+				SourceSizeBytes: sourceSize,
+				ResultSizeBytes: resultSize,
+				Theme:           theme,
+			}
+			require.NoError(t, repo.Create(ctx, job))
+		}
+	}
+
+	t.Run("No history", func(t *testing.T) {
+		service := NewJobServiceImpl(newCountingRepository())
+		estimate, err := service.EstimateBuild(ctx, &models.EstimateRequest{SourceFileCount: 3, SourceSizeBytes: 1000, Theme: "seriph"})
+		require.NoError(t, err)
+
+		assert.Equal(t, models.EstimateConfidenceNone, estimate.Confidence)
+		assert.Equal(t, "none", estimate.Basis)
+		assert.Zero(t, estimate.SampleSize)
+		assert.Zero(t, estimate.EstimatedDurationSeconds)
+	})
+
+	t.Run("Same theme and size", func(t *testing.T) {
+		repo := newCountingRepository()
+		addBuilds(repo, 10, "seriph", 60*time.Second, 1000, 5000)
+		addBuilds(repo, 10, "default", 10*time.Second, 1000, 2000)
+		service := NewJobServiceImpl(repo)
+
+		estimate, err := service.EstimateBuild(ctx, &models.EstimateRequest{SourceFileCount: 3, SourceSizeBytes: 1200, Theme: "seriph"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "theme_and_size", estimate.Basis)
+		assert.Equal(t, models.EstimateConfidenceHigh, estimate.Confidence)
+		assert.Equal(t, int64(10), estimate.SampleSize)
+		assert.InDelta(t, 60, estimate.EstimatedDurationSeconds, 0.1)
+		assert.Equal(t, "1m0s", estimate.EstimatedDuration)
+		assert.Equal(t, int64(5000), estimate.EstimatedResultSizeBytes)
+	})
+
+	t.Run("Falls back to size when the theme is unknown", func(t *testing.T) {
+		repo := newCountingRepository()
+		addBuilds(repo, 4, "default", 20*time.Second, 1000, 2000)
+		service := NewJobServiceImpl(repo)
+
+		estimate, err := service.EstimateBuild(ctx, &models.EstimateRequest{SourceFileCount: 3, SourceSizeBytes: 1000, Theme: "seriph"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "size", estimate.Basis)
+		assert.Equal(t, models.EstimateConfidenceLow, estimate.Confidence)
+		assert.Equal(t, int64(2000), estimate.EstimatedResultSizeBytes)
+	})
+
+	t.Run("Scales output size when no build has a similar size", func(t *testing.T) {
+		repo := newCountingRepository()
+		addBuilds(repo, 5, "seriph", 30*time.Second, 1000, 4000)
+		service := NewJobServiceImpl(repo)
+
+		estimate, err := service.EstimateBuild(ctx, &models.EstimateRequest{SourceFileCount: 30, SourceSizeBytes: 10000, Theme: "seriph"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "theme", estimate.Basis)
+		assert.Equal(t, models.EstimateConfidenceLow, estimate.Confidence)
+		assert.Equal(t, int64(40000), estimate.EstimatedResultSizeBytes)
+	})
+
+	t.Run("Too few comparable builds", func(t *testing.T) {
+		repo := newCountingRepository()
+		addBuilds(repo, minEstimateSamples-1, "seriph", 30*time.Second, 1000, 4000)
+		service := NewJobServiceImpl(repo)
+
+		estimate, err := service.EstimateBuild(ctx, &models.EstimateRequest{SourceFileCount: 3, SourceSizeBytes: 1000})
+		require.NoError(t, err)
+		assert.Equal(t, models.EstimateConfidenceNone, estimate.Confidence)
+	})
+}
@@ -0,0 +1,88 @@
+// internal/jobs/profile.go - Profils de génération des cours (options de build par défaut)
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IsProfileNotFound indique si une erreur de lecture signifie que le cours n'a pas de profil
+func IsProfileNotFound(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound)
+}
+
+// GetGenerationProfile retourne le profil de génération d'un cours
+func (s *jobServiceImpl) GetGenerationProfile(ctx context.Context, courseID uuid.UUID) (*models.GenerationProfile, error) {
+	ctx, span := s.tracer.Start(ctx, "JobService.GetGenerationProfile")
+	defer span.End()
+
+	profile, err := s.repo.GetProfile(ctx, courseID)
+	if err != nil {
+		if !IsProfileNotFound(err) {
+			span.RecordError(err)
+		}
+		return nil, fmt.Errorf("failed to get generation profile: %w", err)
+	}
+
+	return profile, nil
+}
+
+// SetGenerationProfile crée ou remplace le profil de génération d'un cours
+func (s *jobServiceImpl) SetGenerationProfile(ctx context.Context, courseID uuid.UUID, req *models.GenerationProfileRequest) (*models.GenerationProfile, error) {
+	ctx, span := s.tracer.Start(ctx, "JobService.SetGenerationProfile")
+	defer span.End()
+
+	now := time.Now()
+	profile := &models.GenerationProfile{
+		CourseID:        courseID,
+		EntryFile:       req.EntryFile,
+		OutputDir:       req.OutputDir,
+		BuildFlags:      models.StringSlice(req.BuildFlags),
+		Themes:          models.StringSlice(req.Themes),
+		CheckLinks:      req.CheckLinks,
+		CompressResults: req.CompressResults,
+		Thumbnail:       req.Thumbnail,
+		SourceRetention: req.SourceRetention,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	// Un profil remplacé garde sa date de création
+	existing, err := s.repo.GetProfile(ctx, courseID)
+	switch {
+	case err == nil:
+		profile.CreatedAt = existing.CreatedAt
+	case !IsProfileNotFound(err):
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get generation profile: %w", err)
+	}
+
+	if err := s.repo.SaveProfile(ctx, profile); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to save generation profile: %w", err)
+	}
+
+	return profile, nil
+}
+
+// DeleteGenerationProfile supprime le profil de génération d'un cours ; retourne false si le
+// cours n'en avait pas
+func (s *jobServiceImpl) DeleteGenerationProfile(ctx context.Context, courseID uuid.UUID) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "JobService.DeleteGenerationProfile")
+	defer span.End()
+
+	deleted, err := s.repo.DeleteProfile(ctx, courseID)
+	if err != nil {
+		span.RecordError(err)
+		return false, fmt.Errorf("failed to delete generation profile: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,216 @@
+package jobs
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// DefaultProgressCacheTTL est la durée de vie par défaut d'un job actif en cache
+const DefaultProgressCacheTTL = 2 * time.Second
+
+// progressCache garde en mémoire les jobs actifs pour servir le polling sans requête DB.
+// Il est porté par le JobService : le worker et l'API d'un même processus partagent donc
+// la même instance et voient les mêmes mises à jour.
+type progressCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[uuid.UUID]*progressEntry
+}
+
+// progressEntry est un job en cache avec son expiration
+type progressEntry struct {
+	job       *models.GenerationJob
+	expiresAt time.Time
+}
+
+// newProgressCache crée un cache de progression (ttl <= 0 = désactivé)
+func newProgressCache(ttl time.Duration) *progressCache {
+	return &progressCache{
+		ttl:     ttl,
+		entries: make(map[uuid.UUID]*progressEntry),
+	}
+}
+
+// enabled indique si le cache est actif
+func (c *progressCache) enabled() bool {
+	return c.ttl > 0
+}
+
+// get retourne une copie du job en cache s'il n'a pas expiré
+func (c *progressCache) get(id uuid.UUID) (*models.GenerationJob, bool) {
+	if !c.enabled() {
+		return nil, false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, exists := c.entries[id]
+	if !exists || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+
+	return cloneJob(entry.job), true
+}
+
+// store met en cache un job actif, ou l'invalide s'il est terminé
+func (c *progressCache) store(job *models.GenerationJob) {
+	if !c.enabled() {
+		return
+	}
+
+	if job.IsTerminal() {
+		c.invalidate(job.ID)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[job.ID] = &progressEntry{
+		job:       cloneJob(job),
+		expiresAt: time.Now().Add(c.ttl),
+	}
+	c.evictExpiredLocked()
+}
+
+// updateStatus applique une mise à jour de statut à un job déjà en cache
+func (c *progressCache) updateStatus(id uuid.UUID, status models.JobStatus, progress int, errorMsg string) {
+	if !c.enabled() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Les états terminaux sont relus depuis la DB (completed_at, logs finaux...)
+	if status == models.StatusCompleted || status == models.StatusFailed || status == models.StatusTimeout {
+		delete(c.entries, id)
+		return
+	}
+
+	entry, exists := c.entries[id]
+	if !exists {
+		return
+	}
+
+	// Même règles que jobRepository.UpdateStatus
+	now := time.Now()
+	job := entry.job
+	job.Status = status
+	job.Progress = progress
+	job.UpdatedAt = now
+	if errorMsg != "" {
+		job.Error = errorMsg
+	}
+	if status == models.StatusProcessing {
+		job.StartedAt = &now
+	}
+
+	entry.expiresAt = now.Add(c.ttl)
+}
+
+// invalidate retire un job du cache
+func (c *progressCache) invalidate(id uuid.UUID) {
+	if !c.enabled() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, id)
+}
+
+// evictExpiredLocked purge les entrées expirées (appelé sous verrou)
+func (c *progressCache) evictExpiredLocked() {
+	now := time.Now()
+	for id, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, id)
+		}
+	}
+}
+
+// cloneJob copie un job en profondeur pour que les appelants ne modifient pas l'entrée en cache
+func cloneJob(job *models.GenerationJob) *models.GenerationJob {
+	clone := *job
+	clone.Logs = cloneStringSlice(job.Logs)
+	clone.EntryPoints = cloneStringSlice(job.EntryPoints)
+	clone.NpmPackages = cloneStringSlice(job.NpmPackages)
+	clone.BuildFlags = cloneStringSlice(job.BuildFlags)
+	clone.Themes = cloneStringSlice(job.Themes)
+	clone.Warnings = cloneStringSlice(job.Warnings)
+	clone.Attempts = slices.Clone(job.Attempts)
+
+	if job.Metadata != nil {
+		clone.Metadata = cloneJSONValue(map[string]interface{}(job.Metadata)).(map[string]interface{})
+	}
+
+	if job.Labels != nil {
+		clone.Labels = make(models.StringMap, len(job.Labels))
+		for key, value := range job.Labels {
+			clone.Labels[key] = value
+		}
+	}
+
+	if job.ThemeResults != nil {
+		clone.ThemeResults = make(models.ThemeBuildResults, len(job.ThemeResults))
+		for i, result := range job.ThemeResults {
+			result.EntryPoints = slices.Clone(result.EntryPoints)
+			clone.ThemeResults[i] = result
+		}
+	}
+
+	clone.CheckLinks = clonePointer(job.CheckLinks)
+	clone.CompressResults = clonePointer(job.CompressResults)
+	clone.Thumbnail = clonePointer(job.Thumbnail)
+	clone.Preparation = clonePointer(job.Preparation)
+	clone.StartedAt = clonePointer(job.StartedAt)
+	clone.CompletedAt = clonePointer(job.CompletedAt)
+	clone.CallbackLastAttemptAt = clonePointer(job.CallbackLastAttemptAt)
+	clone.CallbackNextAttemptAt = clonePointer(job.CallbackNextAttemptAt)
+
+	return &clone
+}
+
+// cloneStringSlice copie un StringSlice en conservant la distinction nil / vide
+func cloneStringSlice(values models.StringSlice) models.StringSlice {
+	if values == nil {
+		return nil
+	}
+	return append(models.StringSlice{}, values...)
+}
+
+// clonePointer copie la valeur pointée (nil reste nil)
+func clonePointer[T any](value *T) *T {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
+
+// cloneJSONValue copie une valeur JSON décodée, objets et tableaux imbriqués compris
+func cloneJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = cloneJSONValue(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = cloneJSONValue(item)
+		}
+		return copied
+	default:
+		return value
+	}
+}
@@ -0,0 +1,478 @@
+package jobs
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// countingRepository est un repository en mémoire qui compte les lectures
+type countingRepository struct {
+	mu       sync.Mutex
+	jobs     map[uuid.UUID]models.GenerationJob
+	profiles map[uuid.UUID]models.GenerationProfile
+	reads    atomic.Int32
+}
+
+func newCountingRepository() *countingRepository {
+	return &countingRepository{
+		jobs:     make(map[uuid.UUID]models.GenerationJob),
+		profiles: make(map[uuid.UUID]models.GenerationProfile),
+	}
+}
+
+func (r *countingRepository) Create(ctx context.Context, job *models.GenerationJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+func (r *countingRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.GenerationJob, error) {
+	r.reads.Add(1)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, exists := r.jobs[id]
+	if !exists {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &job, nil
+}
+
+func (r *countingRepository) List(ctx context.Context, filters JobFilters) ([]*models.GenerationJob, error) {
+	return nil, nil
+}
+
+func (r *countingRepository) Update(ctx context.Context, job *models.GenerationJob) error {
+	return r.Create(ctx, job)
+}
+
+func (r *countingRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status models.JobStatus, progress int, errorMsg string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, exists := r.jobs[id]
+	if !exists {
+		return gorm.ErrRecordNotFound
+	}
+	job.Status = status
+	job.Progress = progress
+	if errorMsg != "" {
+		job.Error = errorMsg
+	}
+	r.jobs[id] = job
+	return nil
+}
+
+func (r *countingRepository) ClaimPending(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, exists := r.jobs[id]
+	if !exists || job.Status != models.StatusPending {
+		return false, nil
+	}
+	job.Status = models.StatusProcessing
+	job.Error = ""
+	r.jobs[id] = job
+	return true, nil
+}
+
+func (r *countingRepository) CancelPending(ctx context.Context, id uuid.UUID, reason string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, exists := r.jobs[id]
+	if !exists || job.Status != models.StatusPending {
+		return false, nil
+	}
+	job.Status = models.StatusFailed
+	job.Error = reason
+	r.jobs[id] = job
+	return true, nil
+}
+
+func (r *countingRepository) DeleteOldJobs(ctx context.Context, olderThan time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *countingRepository) CountActiveByClient(ctx context.Context, clientID string) (int64, error) {
+	return 0, nil
+}
+
+func (r *countingRepository) LatestBuild(ctx context.Context, courseID uuid.UUID, clientID string) (*models.GenerationJob, error) {
+	return nil, nil
+}
+
+func (r *countingRepository) CountByStatus(ctx context.Context, status models.JobStatus) (int64, error) {
+	return 0, nil
+}
+
+func (r *countingRepository) ListPending(ctx context.Context, limit int) ([]*models.GenerationJob, error) {
+	return nil, nil
+}
+
+func (r *countingRepository) ScheduleCallback(ctx context.Context, id uuid.UUID, at *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, exists := r.jobs[id]
+	if !exists {
+		return gorm.ErrRecordNotFound
+	}
+	job.CallbackNextAttemptAt = at
+	r.jobs[id] = job
+	return nil
+}
+
+func (r *countingRepository) ListDueCallbacks(ctx context.Context, now time.Time, limit int) ([]*models.GenerationJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []*models.GenerationJob
+	for _, job := range r.jobs {
+		if job.CallbackNextAttemptAt != nil && !job.CallbackNextAttemptAt.After(now) {
+			due = append(due, &job)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].CallbackNextAttemptAt.Before(*due[j].CallbackNextAttemptAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (r *countingRepository) ClaimCallback(ctx context.Context, id uuid.UUID, now, until time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, exists := r.jobs[id]
+	if !exists || job.CallbackNextAttemptAt == nil || job.CallbackNextAttemptAt.After(now) {
+		return false, nil
+	}
+	job.CallbackNextAttemptAt = &until
+	r.jobs[id] = job
+	return true, nil
+}
+
+func (r *countingRepository) RecordCallbackAttempt(ctx context.Context, id uuid.UUID, attempt CallbackAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, exists := r.jobs[id]
+	if !exists {
+		return gorm.ErrRecordNotFound
+	}
+	job.CallbackAttempts++
+	job.CallbackLastAttemptAt = &attempt.At
+	job.CallbackDelivered = attempt.Delivered
+	job.CallbackLastError = attempt.LastError
+	job.CallbackNextAttemptAt = attempt.RetryAt
+	r.jobs[id] = job
+	return nil
+}
+
+func (r *countingRepository) CountPendingCallbacks(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, job := range r.jobs {
+		if job.CallbackNextAttemptAt != nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *countingRepository) GetProfile(ctx context.Context, courseID uuid.UUID) (*models.GenerationProfile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	profile, exists := r.profiles[courseID]
+	if !exists {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &profile, nil
+}
+
+func (r *countingRepository) SaveProfile(ctx context.Context, profile *models.GenerationProfile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.profiles[profile.CourseID] = *profile
+	return nil
+}
+
+func (r *countingRepository) DeleteProfile(ctx context.Context, courseID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, exists := r.profiles[courseID]
+	delete(r.profiles, courseID)
+	return exists, nil
+}
+
+func (r *countingRepository) AggregateLatency(ctx context.Context, filters LatencyFilters) (*LatencyAggregate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	aggregate := &LatencyAggregate{WithinTargets: make([]int64, len(filters.Targets))}
+	var latencies []float64
+	for _, job := range r.jobs {
+		if job.CompletedAt == nil || job.CompletedAt.Before(filters.Since) ||
+			(filters.CourseID != nil && job.CourseID != *filters.CourseID) ||
+			(job.Status != models.StatusCompleted && job.Status != models.StatusFailed && job.Status != models.StatusTimeout) {
+			continue
+		}
+		aggregate.Finished++
+		if job.Status != models.StatusCompleted {
+			continue
+		}
+		aggregate.Completed++
+		latency := job.CompletedAt.Sub(job.CreatedAt).Seconds()
+		latencies = append(latencies, latency)
+		for i, target := range filters.Targets {
+			if latency <= target.Seconds() {
+				aggregate.WithinTargets[i]++
+			}
+		}
+	}
+
+	// Interpolation linéaire, comme percentile_cont
+	sort.Float64s(latencies)
+	percentile := func(p float64) float64 {
+		if len(latencies) == 0 {
+			return 0
+		}
+		position := p * float64(len(latencies)-1)
+		lower := int(position)
+		if lower+1 >= len(latencies) {
+			return latencies[lower]
+		}
+		return latencies[lower] + (position-float64(lower))*(latencies[lower+1]-latencies[lower])
+	}
+	aggregate.P50Seconds = percentile(0.5)
+	aggregate.P95Seconds = percentile(0.95)
+	aggregate.P99Seconds = percentile(0.99)
+	return aggregate, nil
+}
+
+func (r *countingRepository) AggregateBuildStats(ctx context.Context, filters BuildStatsFilters) (*BuildStatsAggregate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	aggregate := &BuildStatsAggregate{}
+	for _, job := range r.jobs {
+		if job.Status != models.StatusCompleted || job.StartedAt == nil || job.CompletedAt == nil || job.SourceSizeBytes <= 0 ||
+			(filters.Theme != "" && job.Theme != filters.Theme) ||
+			(filters.MinSourceSize > 0 && job.SourceSizeBytes < filters.MinSourceSize) ||
+			(filters.MaxSourceSize > 0 && job.SourceSizeBytes > filters.MaxSourceSize) {
+			continue
+		}
+		aggregate.Count++
+		aggregate.AvgDurationSeconds += job.CompletedAt.Sub(*job.StartedAt).Seconds()
+		aggregate.AvgSourceSizeBytes += float64(job.SourceSizeBytes)
+		aggregate.AvgResultSizeBytes += float64(job.ResultSizeBytes)
+	}
+
+	if aggregate.Count > 0 {
+		aggregate.AvgDurationSeconds /= float64(aggregate.Count)
+		aggregate.AvgSourceSizeBytes /= float64(aggregate.Count)
+		aggregate.AvgResultSizeBytes /= float64(aggregate.Count)
+	}
+	return aggregate, nil
+}
+
+func TestProgressCache(t *testing.T) {
+	ctx := context.Background()
+
+	newJob := func(t *testing.T, service JobService) uuid.UUID {
+		job, err := service.CreateJob(ctx, &models.GenerationRequest{
+			JobID:      uuid.New(),
+			CourseID:   uuid.New(),
+			SourcePath: "test/path",
+		})
+		require.NoError(t, err)
+		return job.ID
+	}
+
+	t.Run("Active jobs served from memory", func(t *testing.T) {
+		repo := newCountingRepository()
+		service := NewJobServiceImplWithConfig(repo, &ServiceConfig{ProgressCacheTTL: time.Minute})
+		jobID := newJob(t, service)
+
+		for i := 0; i < 10; i++ {
+			_, err := service.GetJob(ctx, jobID)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(1), repo.reads.Load())
+
+		// Les mises à jour de progression sont visibles sans relecture
+		require.NoError(t, service.UpdateJobStatus(ctx, jobID, models.StatusProcessing, 40, ""))
+		job, err := service.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusProcessing, job.Status)
+		assert.Equal(t, 40, job.Progress)
+		assert.NotNil(t, job.StartedAt)
+		assert.Equal(t, int32(1), repo.reads.Load())
+	})
+
+	t.Run("Terminal state invalidates", func(t *testing.T) {
+		repo := newCountingRepository()
+		service := NewJobServiceImplWithConfig(repo, &ServiceConfig{ProgressCacheTTL: time.Minute})
+		jobID := newJob(t, service)
+
+		_, err := service.GetJob(ctx, jobID)
+		require.NoError(t, err)
+
+		require.NoError(t, service.UpdateJobStatus(ctx, jobID, models.StatusCompleted, 100, ""))
+		job, err := service.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusCompleted, job.Status)
+		assert.Equal(t, int32(2), repo.reads.Load())
+
+		// Un job terminé n'est jamais mis en cache
+		_, err = service.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), repo.reads.Load())
+	})
+
+	t.Run("Entries expire", func(t *testing.T) {
+		repo := newCountingRepository()
+		service := NewJobServiceImplWithConfig(repo, &ServiceConfig{ProgressCacheTTL: 10 * time.Millisecond})
+		jobID := newJob(t, service)
+
+		_, err := service.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+
+		_, err = service.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), repo.reads.Load())
+	})
+
+	t.Run("Returned jobs are copies", func(t *testing.T) {
+		service := NewJobServiceImplWithConfig(newCountingRepository(), &ServiceConfig{ProgressCacheTTL: time.Minute})
+		jobID := newJob(t, service)
+
+		require.NoError(t, service.AddJobLog(ctx, jobID, "first"))
+		job, err := service.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		require.Len(t, job.Logs, 1)
+
+		job.Logs[0] = "modified"
+		job.Progress = 99
+
+		cached, err := service.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		assert.Contains(t, cached.Logs[0], "first")
+		assert.Equal(t, 0, cached.Progress)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		repo := newCountingRepository()
+		service := NewJobServiceImplWithConfig(repo, &ServiceConfig{})
+		jobID := newJob(t, service)
+
+		for i := 0; i < 3; i++ {
+			_, err := service.GetJob(ctx, jobID)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(3), repo.reads.Load())
+	})
+
+	t.Run("Concurrent pollers and worker", func(t *testing.T) {
+		service := NewJobServiceImplWithConfig(newCountingRepository(), &ServiceConfig{ProgressCacheTTL: time.Minute})
+		jobID := newJob(t, service)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					job, err := service.GetJob(ctx, jobID)
+					assert.NoError(t, err)
+					assert.LessOrEqual(t, job.Progress, 100)
+				}
+			}()
+		}
+
+		for progress := 0; progress <= 100; progress += 10 {
+			require.NoError(t, service.UpdateJobStatus(ctx, jobID, models.StatusProcessing, progress, ""))
+		}
+		wg.Wait()
+
+		job, err := service.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, 100, job.Progress)
+	})
+}
+
+func TestProgressCacheReturnsDeepCopies(t *testing.T) {
+	jobID := uuid.New()
+	started := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+	enabled := true
+
+	// Job dont tous les champs slice, map et pointeur sont renseignés
+	newJob := func() *models.GenerationJob {
+		flag := enabled
+		startedAt := started
+		lastAttemptAt := started.Add(time.Minute)
+		nextAttemptAt := started.Add(2 * time.Minute)
+		return &models.GenerationJob{
+			ID:                    jobID,
+			Status:                models.StatusProcessing,
+			EntryPoints:           models.StringSlice{"index.html"},
+			CheckLinks:            &flag,
+			NpmPackages:           models.StringSlice{"@slidev/theme-seriph"},
+			Logs:                  models.StringSlice{"started"},
+			Metadata:              models.JSON{"author": "alice", "tags": []interface{}{"a"}, "nested": map[string]interface{}{"k": "v"}},
+			StartedAt:             &startedAt,
+			CallbackLastAttemptAt: &lastAttemptAt,
+			CallbackNextAttemptAt: &nextAttemptAt,
+			BuildFlags:            models.StringSlice{"--base"},
+			Labels:                models.StringMap{"env": "prod"},
+			CompressResults:       &flag,
+			Themes:                models.StringSlice{"seriph"},
+			ThemeResults:          models.ThemeBuildResults{{Theme: "seriph", EntryPoints: []string{"theme-seriph/index.html"}}},
+			Thumbnail:             &flag,
+			Attempts:              models.JobAttempts{{Number: 1, Status: models.StatusProcessing, StartedAt: started}},
+			Warnings:              models.StringSlice{"deck too long"},
+			Preparation:           &models.JobPreparation{SlideFile: "slides.md"},
+		}
+	}
+
+	cache := newProgressCache(time.Minute)
+	cache.store(newJob())
+
+	job, ok := cache.get(jobID)
+	require.True(t, ok)
+
+	job.EntryPoints[0] = "modified"
+	*job.CheckLinks = false
+	job.NpmPackages[0] = "modified"
+	job.Logs[0] = "modified"
+	job.Metadata["author"] = "bob"
+	job.Metadata["tags"].([]interface{})[0] = "modified"
+	job.Metadata["nested"].(map[string]interface{})["k"] = "modified"
+	*job.StartedAt = time.Time{}
+	*job.CallbackLastAttemptAt = time.Time{}
+	*job.CallbackNextAttemptAt = time.Time{}
+	job.BuildFlags[0] = "modified"
+	job.Labels["env"] = "modified"
+	*job.CompressResults = false
+	job.Themes[0] = "modified"
+	job.ThemeResults[0].Theme = "modified"
+	job.ThemeResults[0].EntryPoints[0] = "modified"
+	*job.Thumbnail = false
+	job.Attempts[0].Status = models.StatusFailed
+	job.Warnings[0] = "modified"
+	job.Preparation.SlideFile = "modified"
+
+	cached, ok := cache.get(jobID)
+	require.True(t, ok)
+	assert.Equal(t, newJob(), cached)
+}
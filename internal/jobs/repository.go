@@ -2,6 +2,10 @@ package jobs
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
@@ -16,14 +20,87 @@ type JobRepository interface {
 	List(ctx context.Context, filters JobFilters) ([]*models.GenerationJob, error)
 	Update(ctx context.Context, job *models.GenerationJob) error
 	UpdateStatus(ctx context.Context, id uuid.UUID, status models.JobStatus, progress int, errorMsg string) error
+	ClaimPending(ctx context.Context, id uuid.UUID) (bool, error)
+	CancelPending(ctx context.Context, id uuid.UUID, reason string) (bool, error)
 	DeleteOldJobs(ctx context.Context, olderThan time.Time) (int64, error)
+	CountActiveByClient(ctx context.Context, clientID string) (int64, error)
+	CountByStatus(ctx context.Context, status models.JobStatus) (int64, error)
+	ListPending(ctx context.Context, limit int) ([]*models.GenerationJob, error)
+	LatestBuild(ctx context.Context, courseID uuid.UUID, clientID string) (*models.GenerationJob, error)
+	AggregateBuildStats(ctx context.Context, filters BuildStatsFilters) (*BuildStatsAggregate, error)
+	AggregateLatency(ctx context.Context, filters LatencyFilters) (*LatencyAggregate, error)
+	ScheduleCallback(ctx context.Context, id uuid.UUID, at *time.Time) error
+	ListDueCallbacks(ctx context.Context, now time.Time, limit int) ([]*models.GenerationJob, error)
+	ClaimCallback(ctx context.Context, id uuid.UUID, now, until time.Time) (bool, error)
+	RecordCallbackAttempt(ctx context.Context, id uuid.UUID, attempt CallbackAttempt) error
+	CountPendingCallbacks(ctx context.Context) (int64, error)
+	GetProfile(ctx context.Context, courseID uuid.UUID) (*models.GenerationProfile, error)
+	SaveProfile(ctx context.Context, profile *models.GenerationProfile) error
+	DeleteProfile(ctx context.Context, courseID uuid.UUID) (bool, error)
 }
 
 type JobFilters struct {
 	Status   string
 	CourseID *uuid.UUID
+	// Metadata filtre les jobs dont les metadata contiennent toutes les paires clé/valeur ;
+	// une valeur numérique ou booléenne correspond aussi au scalaire JSON ("2" trouve 2)
+	Metadata map[string]string
 	Limit    int
 	Offset   int
+
+	// Labels filtre les jobs portant tous ces labels
+	Labels map[string]string
+	// VisibleTo restreint aux jobs lisibles par cette identité (vide = tous) : ses
+	// propres jobs pour un client authentifié, les jobs anonymes sinon
+	VisibleTo string
+}
+
+// CallbackAttempt décrit le résultat d'une tentative de livraison de callback
+type CallbackAttempt struct {
+	At        time.Time
+	Delivered bool
+	LastError string     // vide si livré
+	RetryAt   *time.Time // prochaine relance (nil = plus de relance)
+}
+
+// BuildStatsFilters sélectionne les builds terminés comparables à un cours
+type BuildStatsFilters struct {
+	Theme         string // vide = tous les thèmes
+	MinSourceSize int64  // 0 = pas de borne
+	MaxSourceSize int64  // 0 = pas de borne
+	Limit         int    // nombre de builds récents pris en compte
+}
+
+// BuildStatsAggregate contient les moyennes des builds sélectionnés
+type BuildStatsAggregate struct {
+	Count              int64
+	AvgDurationSeconds float64
+	AvgSourceSizeBytes float64
+	AvgResultSizeBytes float64
+}
+
+// LatencyFilters sélectionne les jobs terminés dont la latence est agrégée
+type LatencyFilters struct {
+	Since    time.Time       // jobs terminés depuis
+	CourseID *uuid.UUID      // nil = tous les cours
+	Targets  []time.Duration // objectifs de latence comptés
+}
+
+// LatencyAggregate contient la latence (création → fin) des jobs sélectionnés. Les
+// percentiles portent sur les jobs réussis ; WithinTargets[i] compte les jobs réussis
+// en moins de Targets[i].
+type LatencyAggregate struct {
+	Finished      int64
+	Completed     int64
+	P50Seconds    float64
+	P95Seconds    float64
+	P99Seconds    float64
+	WithinTargets []int64
+}
+
+// IsJobNotFound indique si une erreur de lecture signifie que le job n'existe pas (ou plus)
+func IsJobNotFound(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound)
 }
 
 type jobRepository struct {
@@ -63,6 +140,38 @@ func (r *jobRepository) List(ctx context.Context, filters JobFilters) ([]*models
 		query = query.Where("course_id = ?", *filters.CourseID)
 	}
 
+	for key, value := range filters.Metadata {
+		// Containment JSONB servi par l'index GIN idx_generation_jobs_metadata :
+		// metadata @> '{"key":"value"}', ou '{"key":2}' pour un scalaire
+		conditions := make([]string, 0, 2)
+		var args []interface{}
+		for _, candidate := range metadataCandidates(value) {
+			containment, err := json.Marshal(map[string]interface{}{key: candidate})
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode metadata filters: %w", err)
+			}
+			conditions = append(conditions, "metadata @> ?::jsonb")
+			args = append(args, string(containment))
+		}
+		query = query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+	}
+
+	if len(filters.Labels) > 0 {
+		// Containment JSONB servi par l'index GIN idx_generation_jobs_labels
+		containment, err := json.Marshal(filters.Labels)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode label filters: %w", err)
+		}
+		query = query.Where("labels @> ?::jsonb", string(containment))
+	}
+
+	switch {
+	case strings.HasPrefix(filters.VisibleTo, "client:"):
+		query = query.Where("client_id = ?", filters.VisibleTo)
+	case filters.VisibleTo != "":
+		query = query.Where("client_id NOT LIKE 'client:%'")
+	}
+
 	if filters.Limit > 0 {
 		query = query.Limit(filters.Limit)
 	}
@@ -77,6 +186,27 @@ func (r *jobRepository) List(ctx context.Context, filters JobFilters) ([]*models
 	return jobs, err
 }
 
+// metadataCandidates retourne les valeurs JSON recherchées pour un filtre de metadata : la
+// chaîne, et le nombre ou booléen qu'elle représente le cas échéant
+func metadataCandidates(value string) []interface{} {
+	candidates := []interface{}{value}
+	if !json.Valid([]byte(value)) {
+		return candidates
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return candidates
+	}
+	switch decoded.(type) {
+	case json.Number, bool:
+		candidates = append(candidates, decoded)
+	}
+	return candidates
+}
+
 func (r *jobRepository) Update(ctx context.Context, job *models.GenerationJob) error {
 	job.UpdatedAt = time.Now()
 	return r.db.WithContext(ctx).Save(job).Error
@@ -104,6 +234,40 @@ func (r *jobRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status m
 	return r.db.WithContext(ctx).Model(&models.GenerationJob{}).Where("id = ?", id).Updates(updates).Error
 }
 
+// ClaimPending passe un job de pending à processing par une mise à jour conditionnelle.
+// Retourne false si le job n'était plus pending (déjà pris par un autre worker).
+// L'erreur et la fin d'une tentative précédente sont effacées : elles restent dans l'historique.
+func (r *jobRepository) ClaimPending(ctx context.Context, id uuid.UUID) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&models.GenerationJob{}).
+		Where("id = ? AND status = ?", id, models.StatusPending).
+		Updates(map[string]interface{}{
+			"status":       models.StatusProcessing,
+			"error":        "",
+			"started_at":   now,
+			"completed_at": nil,
+			"updated_at":   now,
+		})
+
+	return result.RowsAffected == 1, result.Error
+}
+
+// CancelPending passe un job encore pending à failed par une mise à jour conditionnelle.
+// Retourne false si un worker l'a déjà réservé : il n'est alors pas annulé.
+func (r *jobRepository) CancelPending(ctx context.Context, id uuid.UUID, reason string) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&models.GenerationJob{}).
+		Where("id = ? AND status = ?", id, models.StatusPending).
+		Updates(map[string]interface{}{
+			"status":       models.StatusFailed,
+			"error":        reason,
+			"completed_at": now,
+			"updated_at":   now,
+		})
+
+	return result.RowsAffected == 1, result.Error
+}
+
 func (r *jobRepository) DeleteOldJobs(ctx context.Context, olderThan time.Time) (int64, error) {
 	result := r.db.WithContext(ctx).Where("created_at < ? AND status IN ?", olderThan,
 		[]models.JobStatus{models.StatusCompleted, models.StatusFailed, models.StatusTimeout}).
@@ -111,3 +275,209 @@ func (r *jobRepository) DeleteOldJobs(ctx context.Context, olderThan time.Time)
 
 	return result.RowsAffected, result.Error
 }
+
+func (r *jobRepository) CountActiveByClient(ctx context.Context, clientID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.GenerationJob{}).
+		Where("client_id = ? AND status IN ?", clientID,
+			[]models.JobStatus{models.StatusPending, models.StatusProcessing}).
+		Count(&count).Error
+
+	return count, err
+}
+
+func (r *jobRepository) CountByStatus(ctx context.Context, status models.JobStatus) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.GenerationJob{}).
+		Where("status = ?", status).
+		Count(&count).Error
+
+	return count, err
+}
+
+// ListPending retourne les limit plus anciens jobs pending, dans leur ordre de soumission
+func (r *jobRepository) ListPending(ctx context.Context, limit int) ([]*models.GenerationJob, error) {
+	var jobs []*models.GenerationJob
+	err := r.db.WithContext(ctx).
+		Where("status = ?", models.StatusPending).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&jobs).Error
+
+	return jobs, err
+}
+
+// LatestBuild retourne le dernier build réussi d'un cours publié dans ses résultats (hors
+// result_prefix) et visible par clientID : jobs anonymes ou du client. Seules les colonnes
+// utiles à la comparaison sont chargées ; nil si le cours n'a aucun build.
+func (r *jobRepository) LatestBuild(ctx context.Context, courseID uuid.UUID, clientID string) (*models.GenerationJob, error) {
+	var jobs []*models.GenerationJob
+	err := r.db.WithContext(ctx).
+		Select("id", "course_id", "client_id", "status", "completed_at", "source_hash", "result_prefix").
+		Where("course_id = ? AND status = ? AND result_prefix = ''", courseID, models.StatusCompleted).
+		Where("client_id NOT LIKE 'client:%' OR client_id = ?", clientID).
+		Order("completed_at DESC").
+		Limit(1).
+		Find(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return jobs[0], nil
+}
+
+// ScheduleCallback planifie la livraison du callback d'un job à at (nil = retirée de la file)
+func (r *jobRepository) ScheduleCallback(ctx context.Context, id uuid.UUID, at *time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.GenerationJob{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"callback_next_attempt_at": at,
+			"updated_at":               time.Now(),
+		}).Error
+}
+
+// ListDueCallbacks retourne les jobs dont la livraison du callback est échue, les plus
+// anciennes d'abord
+func (r *jobRepository) ListDueCallbacks(ctx context.Context, now time.Time, limit int) ([]*models.GenerationJob, error) {
+	var jobs []*models.GenerationJob
+	err := r.db.WithContext(ctx).
+		Where("callback_next_attempt_at <= ?", now).
+		Order("callback_next_attempt_at ASC").
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// ClaimCallback réserve la livraison échue du callback d'un job jusqu'à until par une mise à
+// jour conditionnelle. Retourne false si une autre instance l'a déjà réservée.
+func (r *jobRepository) ClaimCallback(ctx context.Context, id uuid.UUID, now, until time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.GenerationJob{}).
+		Where("id = ? AND callback_next_attempt_at <= ?", id, now).
+		Update("callback_next_attempt_at", until)
+
+	return result.RowsAffected == 1, result.Error
+}
+
+// RecordCallbackAttempt enregistre une tentative de livraison. Le compteur de tentatives est
+// incrémenté en base pour ne perdre aucune tentative concurrente.
+func (r *jobRepository) RecordCallbackAttempt(ctx context.Context, id uuid.UUID, attempt CallbackAttempt) error {
+	result := r.db.WithContext(ctx).Model(&models.GenerationJob{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"callback_attempts":        gorm.Expr("callback_attempts + 1"),
+			"callback_last_attempt_at": attempt.At,
+			"callback_delivered":       attempt.Delivered,
+			"callback_last_error":      attempt.LastError,
+			"callback_next_attempt_at": attempt.RetryAt,
+			"updated_at":               time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *jobRepository) CountPendingCallbacks(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.GenerationJob{}).
+		Where("callback_next_attempt_at IS NOT NULL").
+		Count(&count).Error
+
+	return count, err
+}
+
+// GetProfile retourne le profil de génération d'un cours (gorm.ErrRecordNotFound si absent)
+func (r *jobRepository) GetProfile(ctx context.Context, courseID uuid.UUID) (*models.GenerationProfile, error) {
+	var profile models.GenerationProfile
+	err := r.db.WithContext(ctx).Where("course_id = ?", courseID).First(&profile).Error
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// SaveProfile crée ou remplace le profil de génération d'un cours
+func (r *jobRepository) SaveProfile(ctx context.Context, profile *models.GenerationProfile) error {
+	return r.db.WithContext(ctx).Save(profile).Error
+}
+
+// DeleteProfile supprime le profil de génération d'un cours ; retourne false s'il n'existait pas
+func (r *jobRepository) DeleteProfile(ctx context.Context, courseID uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Where("course_id = ?", courseID).Delete(&models.GenerationProfile{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *jobRepository) AggregateBuildStats(ctx context.Context, filters BuildStatsFilters) (*BuildStatsAggregate, error) {
+	recent := r.db.WithContext(ctx).Model(&models.GenerationJob{}).
+		Select("started_at, completed_at, source_size_bytes, result_size_bytes").
+		Where("status = ? AND started_at IS NOT NULL AND completed_at IS NOT NULL AND source_size_bytes > 0",
+			models.StatusCompleted)
+
+	if filters.Theme != "" {
+		recent = recent.Where("theme = ?", filters.Theme)
+	}
+	if filters.MinSourceSize > 0 {
+		recent = recent.Where("source_size_bytes >= ?", filters.MinSourceSize)
+	}
+	if filters.MaxSourceSize > 0 {
+		recent = recent.Where("source_size_bytes <= ?", filters.MaxSourceSize)
+	}
+
+	recent = recent.Order("completed_at DESC")
+	if filters.Limit > 0 {
+		recent = recent.Limit(filters.Limit)
+	}
+
+	var aggregate BuildStatsAggregate
+	err := r.db.WithContext(ctx).Table("(?) AS recent", recent).
+		Select(`COUNT(*) AS count,
+			COALESCE(AVG(EXTRACT(EPOCH FROM completed_at - started_at)), 0) AS avg_duration_seconds,
+			COALESCE(AVG(source_size_bytes), 0) AS avg_source_size_bytes,
+			COALESCE(AVG(result_size_bytes), 0) AS avg_result_size_bytes`).
+		Scan(&aggregate).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &aggregate, nil
+}
+
+func (r *jobRepository) AggregateLatency(ctx context.Context, filters LatencyFilters) (*LatencyAggregate, error) {
+	finished := r.db.WithContext(ctx).Model(&models.GenerationJob{}).
+		Select("status, EXTRACT(EPOCH FROM completed_at - created_at) AS latency").
+		Where("completed_at >= ? AND status IN ?", filters.Since,
+			[]models.JobStatus{models.StatusCompleted, models.StatusFailed, models.StatusTimeout})
+	if filters.CourseID != nil {
+		finished = finished.Where("course_id = ?", *filters.CourseID)
+	}
+
+	// Une colonne par objectif, dans l'ordre de filters.Targets
+	columns := []string{
+		"COUNT(*)",
+		"COUNT(*) FILTER (WHERE status = ?)",
+		"COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY latency) FILTER (WHERE status = ?), 0)",
+		"COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY latency) FILTER (WHERE status = ?), 0)",
+		"COALESCE(percentile_cont(0.99) WITHIN GROUP (ORDER BY latency) FILTER (WHERE status = ?), 0)",
+	}
+	args := []interface{}{models.StatusCompleted, models.StatusCompleted, models.StatusCompleted, models.StatusCompleted}
+	for _, target := range filters.Targets {
+		columns = append(columns, "COUNT(*) FILTER (WHERE status = ? AND latency <= ?)")
+		args = append(args, models.StatusCompleted, target.Seconds())
+	}
+
+	aggregate := &LatencyAggregate{WithinTargets: make([]int64, len(filters.Targets))}
+	dest := []interface{}{&aggregate.Finished, &aggregate.Completed,
+		&aggregate.P50Seconds, &aggregate.P95Seconds, &aggregate.P99Seconds}
+	for i := range aggregate.WithinTargets {
+		dest = append(dest, &aggregate.WithinTargets[i])
+	}
+
+	row := r.db.WithContext(ctx).Table("(?) AS finished", finished).
+		Select(strings.Join(columns, ", "), args...).
+		Row()
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	return aggregate, nil
+}
@@ -0,0 +1,30 @@
+// internal/jobs/repository_test.go
+package jobs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataCandidates(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected []interface{}
+	}{
+		{"alice", []interface{}{"alice"}},
+		{"2", []interface{}{"2", json.Number("2")}},
+		{"1.5", []interface{}{"1.5", json.Number("1.5")}},
+		{"true", []interface{}{"true", true}},
+		{`"quoted"`, []interface{}{`"quoted"`}},
+		{"null", []interface{}{"null"}},
+		{"2 3", []interface{}{"2 3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.expected, metadataCandidates(tt.value))
+		})
+	}
+}
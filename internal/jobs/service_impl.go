@@ -10,18 +10,42 @@ import (
 
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
 type jobServiceImpl struct {
 	repo   JobRepository
 	tracer trace.Tracer
+	cache  *progressCache
+}
+
+// ServiceConfig contient la configuration du service de jobs
+type ServiceConfig struct {
+	ProgressCacheTTL time.Duration // Durée de vie des jobs actifs en cache mémoire (0 = désactivé)
+}
+
+// DefaultServiceConfig retourne la configuration par défaut du service de jobs
+func DefaultServiceConfig() *ServiceConfig {
+	return &ServiceConfig{
+		ProgressCacheTTL: DefaultProgressCacheTTL,
+	}
 }
 
 func NewJobServiceImpl(repo JobRepository) JobService {
+	return NewJobServiceImplWithConfig(repo, nil)
+}
+
+// NewJobServiceImplWithConfig crée un service de jobs avec une configuration donnée
+func NewJobServiceImplWithConfig(repo JobRepository, config *ServiceConfig) JobService {
+	if config == nil {
+		config = DefaultServiceConfig()
+	}
+
 	return &jobServiceImpl{
 		repo:   repo,
 		tracer: otel.Tracer("github.com/Open-Course-Factory/ocf-worker/jobs"),
+		cache:  newProgressCache(config.ProgressCacheTTL),
 	}
 }
 
@@ -38,15 +62,27 @@ func (s *jobServiceImpl) CreateJob(ctx context.Context, req *models.GenerationRe
 	}
 
 	job := &models.GenerationJob{
-		ID:          req.JobID,
-		CourseID:    req.CourseID,
-		Status:      models.StatusPending,
-		Progress:    0,
-		SourcePath:  req.SourcePath,
-		CallbackURL: req.CallbackURL,
-		Metadata:    metadata,
-		Logs:        models.StringSlice{}, // Initialiser avec un slice vide
-		NpmPackages: req.Packages,
+		ID:              req.JobID,
+		CourseID:        req.CourseID,
+		Status:          models.StatusPending,
+		Progress:        0,
+		SourcePath:      req.SourcePath,
+		EntryFile:       req.EntryFile,
+		OutputDir:       req.OutputDir,
+		CheckLinks:      req.CheckLinks,
+		CallbackURL:     req.CallbackURL,
+		Metadata:        metadata,
+		ClientID:        req.ClientID,
+		Logs:            models.StringSlice{}, // Initialiser avec un slice vide
+		NpmPackages:     req.Packages,
+		ForceRebuild:    req.ForceRebuild,
+		BuildFlags:      req.BuildFlags,
+		Labels:          models.StringMap(req.Labels),
+		CompressResults: req.CompressResults,
+		Themes:          req.Themes,
+		Thumbnail:       req.Thumbnail,
+		SourceRetention: req.SourceRetention,
+		ResultPrefix:    req.ResultPrefix,
 	}
 
 	if err := s.repo.Create(ctx, job); err != nil {
@@ -63,6 +99,12 @@ func (s *jobServiceImpl) GetJob(ctx context.Context, id uuid.UUID) (*models.Gene
 	ctx, span := s.tracer.Start(ctx, "JobService.GetJob")
 	defer span.End()
 
+	// Les jobs en cours sont servis depuis la mémoire pendant le polling
+	if job, ok := s.cache.get(id); ok {
+		span.SetAttributes(attribute.Bool("job.cache_hit", true))
+		return job, nil
+	}
+
 	log.Printf("JobService.GetJob: Retrieving job with ID %s", id)
 
 	job, err := s.repo.GetByID(ctx, id)
@@ -72,6 +114,8 @@ func (s *jobServiceImpl) GetJob(ctx context.Context, id uuid.UUID) (*models.Gene
 		return nil, fmt.Errorf("failed to get job %s: %w", id, err)
 	}
 
+	s.cache.store(job)
+
 	log.Printf("JobService.GetJob: Job %s retrieved successfully, status: %s", job.ID, job.Status)
 	return job, nil
 }
@@ -99,6 +143,80 @@ func (s *jobServiceImpl) ListJobs(ctx context.Context, status string, courseID *
 	return jobs, nil
 }
 
+func (s *jobServiceImpl) SearchJobs(ctx context.Context, filters JobFilters) ([]*models.GenerationJob, error) {
+	ctx, span := s.tracer.Start(ctx, "JobService.SearchJobs")
+	defer span.End()
+
+	log.Printf("JobService.SearchJobs: Searching jobs with status=%s, courseID=%v, metadata=%v",
+		filters.Status, filters.CourseID, filters.Metadata)
+
+	if filters.Limit <= 0 {
+		filters.Limit = 100 // Default limit
+	}
+
+	jobs, err := s.repo.List(ctx, filters)
+	if err != nil {
+		span.RecordError(err)
+		log.Printf("JobService.SearchJobs: Failed to search jobs: %v", err)
+		return nil, fmt.Errorf("failed to search jobs: %w", err)
+	}
+
+	log.Printf("JobService.SearchJobs: Retrieved %d jobs", len(jobs))
+	return jobs, nil
+}
+
+func (s *jobServiceImpl) CountActiveJobsByClient(ctx context.Context, clientID string) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "JobService.CountActiveJobsByClient")
+	defer span.End()
+
+	count, err := s.repo.CountActiveByClient(ctx, clientID)
+	if err != nil {
+		span.RecordError(err)
+		return 0, fmt.Errorf("failed to count active jobs for client: %w", err)
+	}
+
+	return int(count), nil
+}
+
+func (s *jobServiceImpl) GetLatestBuild(ctx context.Context, courseID uuid.UUID, clientID string) (*models.GenerationJob, error) {
+	ctx, span := s.tracer.Start(ctx, "JobService.GetLatestBuild")
+	defer span.End()
+
+	job, err := s.repo.LatestBuild(ctx, courseID, clientID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get latest build of course %s: %w", courseID, err)
+	}
+
+	return job, nil
+}
+
+func (s *jobServiceImpl) CountPendingJobs(ctx context.Context) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "JobService.CountPendingJobs")
+	defer span.End()
+
+	count, err := s.repo.CountByStatus(ctx, models.StatusPending)
+	if err != nil {
+		span.RecordError(err)
+		return 0, fmt.Errorf("failed to count pending jobs: %w", err)
+	}
+
+	return int(count), nil
+}
+
+func (s *jobServiceImpl) ListPendingJobs(ctx context.Context, limit int) ([]*models.GenerationJob, error) {
+	ctx, span := s.tracer.Start(ctx, "JobService.ListPendingJobs")
+	defer span.End()
+
+	jobs, err := s.repo.ListPending(ctx, limit)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to list pending jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
 func (s *jobServiceImpl) UpdateJobStatus(ctx context.Context, id uuid.UUID, status models.JobStatus, progress int, errorMsg string) error {
 	ctx, span := s.tracer.Start(ctx, "JobService.UpdateJobStatus")
 	defer span.End()
@@ -110,11 +228,75 @@ func (s *jobServiceImpl) UpdateJobStatus(ctx context.Context, id uuid.UUID, stat
 		log.Printf("JobService.UpdateJobStatus: Failed to update job status: %v", err)
 		return fmt.Errorf("failed to update job status: %w", err)
 	}
+	s.cache.updateStatus(id, status, progress, errorMsg)
 
 	log.Printf("JobService.UpdateJobStatus: Job %s status updated successfully", id)
 	return nil
 }
 
+// ClaimJob réserve un job pending pour un worker en le passant à processing.
+// Retourne false si un autre worker l'a déjà pris : il ne doit pas être traité.
+func (s *jobServiceImpl) ClaimJob(ctx context.Context, id uuid.UUID) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "JobService.ClaimJob")
+	defer span.End()
+
+	claimed, err := s.repo.ClaimPending(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return false, fmt.Errorf("failed to claim job: %w", err)
+	}
+	if claimed {
+		// L'erreur d'une tentative précédente a été effacée : relire le job
+		s.cache.invalidate(id)
+
+		// La tentative est visible dès la réservation, même si le worker s'arrête ensuite
+		if err := s.openJobAttempt(ctx, id); err != nil {
+			span.RecordError(err)
+			log.Printf("JobService.ClaimJob: failed to open attempt for job %s: %v", id, err)
+		}
+	}
+
+	return claimed, nil
+}
+
+// openJobAttempt ajoute une tentative en cours à l'historique d'un job réservé
+func (s *jobServiceImpl) openJobAttempt(ctx context.Context, id uuid.UUID) error {
+	job, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get job for attempt history: %w", err)
+	}
+
+	now := time.Now()
+	job.AttemptCount++
+	job.Attempts = job.Attempts.Open(job.AttemptCount, now)
+	job.UpdatedAt = now
+
+	if err := s.repo.Update(ctx, job); err != nil {
+		return fmt.Errorf("failed to update job attempt history: %w", err)
+	}
+	s.cache.store(job)
+
+	return nil
+}
+
+// CancelPendingJob annule un job qu'aucun worker n'a encore réservé en le passant à failed.
+// Retourne false si le job n'était plus pending : son traitement continue.
+func (s *jobServiceImpl) CancelPendingJob(ctx context.Context, id uuid.UUID, reason string) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "JobService.CancelPendingJob")
+	defer span.End()
+
+	cancelled, err := s.repo.CancelPending(ctx, id, reason)
+	if err != nil {
+		span.RecordError(err)
+		return false, fmt.Errorf("failed to cancel job: %w", err)
+	}
+	if cancelled {
+		s.cache.updateStatus(id, models.StatusFailed, 0, reason)
+	}
+
+	return cancelled, nil
+}
+
 func (s *jobServiceImpl) AddJobLog(ctx context.Context, id uuid.UUID, logEntry string) error {
 	ctx, span := s.tracer.Start(ctx, "JobService.AddJobLog")
 	defer span.End()
@@ -136,10 +318,280 @@ func (s *jobServiceImpl) AddJobLog(ctx context.Context, id uuid.UUID, logEntry s
 		span.RecordError(err)
 		return fmt.Errorf("failed to update job logs: %w", err)
 	}
+	s.cache.store(job)
 
 	return nil
 }
 
+func (s *jobServiceImpl) SetJobEntryPoints(ctx context.Context, id uuid.UUID, entryPoints []string) error {
+	ctx, span := s.tracer.Start(ctx, "JobService.SetJobEntryPoints")
+	defer span.End()
+
+	job, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to get job for entry points: %w", err)
+	}
+
+	job.EntryPoints = models.StringSlice(entryPoints)
+	job.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, job); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to update job entry points: %w", err)
+	}
+	s.cache.store(job)
+
+	log.Printf("JobService.SetJobEntryPoints: Job %s has %d entry points: %v", id, len(entryPoints), entryPoints)
+	return nil
+}
+
+func (s *jobServiceImpl) SetJobThumbnail(ctx context.Context, id uuid.UUID, path string) error {
+	ctx, span := s.tracer.Start(ctx, "JobService.SetJobThumbnail")
+	defer span.End()
+
+	job, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to get job for thumbnail: %w", err)
+	}
+
+	job.ThumbnailPath = path
+	job.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, job); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to update job thumbnail: %w", err)
+	}
+	s.cache.store(job)
+
+	return nil
+}
+
+func (s *jobServiceImpl) SetJobOptions(ctx context.Context, id uuid.UUID, options *models.GenerationJob) error {
+	ctx, span := s.tracer.Start(ctx, "JobService.SetJobOptions")
+	defer span.End()
+
+	job, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to get job for options: %w", err)
+	}
+
+	job.EntryFile = options.EntryFile
+	job.OutputDir = options.OutputDir
+	job.BuildFlags = options.BuildFlags
+	job.Themes = options.Themes
+	job.CheckLinks = options.CheckLinks
+	job.CompressResults = options.CompressResults
+	job.Thumbnail = options.Thumbnail
+	job.SourceRetention = options.SourceRetention
+	job.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, job); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to update job options: %w", err)
+	}
+	s.cache.store(job)
+
+	return nil
+}
+
+func (s *jobServiceImpl) SetJobBuildStats(ctx context.Context, id uuid.UUID, stats *models.BuildStats) error {
+	ctx, span := s.tracer.Start(ctx, "JobService.SetJobBuildStats")
+	defer span.End()
+
+	job, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to get job for build stats: %w", err)
+	}
+
+	job.SourceFileCount = stats.SourceFileCount
+	job.SourceSizeBytes = stats.SourceSizeBytes
+	job.ResultSizeBytes = stats.ResultSizeBytes
+	job.Theme = stats.Theme
+	job.SlideCount = stats.SlideCount
+	job.Warnings = stats.Warnings
+	job.SourceHash = stats.SourceHash
+	job.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, job); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to update job build stats: %w", err)
+	}
+	s.cache.store(job)
+
+	return nil
+}
+
+func (s *jobServiceImpl) SetJobPreparation(ctx context.Context, id uuid.UUID, preparation *models.JobPreparation) error {
+	ctx, span := s.tracer.Start(ctx, "JobService.SetJobPreparation")
+	defer span.End()
+
+	job, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to get job for preparation: %w", err)
+	}
+
+	job.Preparation = preparation
+	job.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, job); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to update job preparation: %w", err)
+	}
+	s.cache.store(job)
+
+	return nil
+}
+
+func (s *jobServiceImpl) SetJobThemeResults(ctx context.Context, id uuid.UUID, results []models.ThemeBuildResult) error {
+	ctx, span := s.tracer.Start(ctx, "JobService.SetJobThemeResults")
+	defer span.End()
+
+	job, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to get job for theme results: %w", err)
+	}
+
+	job.ThemeResults = models.ThemeBuildResults(results)
+	job.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, job); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to update job theme results: %w", err)
+	}
+	s.cache.store(job)
+
+	return nil
+}
+
+// RecordJobAttempt clôture la tentative ouverte à la réservation du job avec son résultat
+func (s *jobServiceImpl) RecordJobAttempt(ctx context.Context, id uuid.UUID, attempt models.JobAttempt) error {
+	ctx, span := s.tracer.Start(ctx, "JobService.RecordJobAttempt")
+	defer span.End()
+
+	job, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to get job for attempt history: %w", err)
+	}
+
+	// Clôturer la tentative ouverte à la réservation, ou l'ajouter si elle est absente
+	attempts, closed := job.Attempts.Close(attempt)
+	if !closed {
+		job.AttemptCount++
+		attempt.Number = job.AttemptCount
+		attempts = job.Attempts.Append(attempt)
+	}
+	job.Attempts = attempts
+	job.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, job); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to update job attempt history: %w", err)
+	}
+	s.cache.store(job)
+
+	return nil
+}
+
+// RecordCallbackAttempt enregistre une tentative de livraison du callback d'un job. Un échec
+// est replanifié à retryAt (nil = plus de relance) ; une livraison réussie quitte la file.
+func (s *jobServiceImpl) RecordCallbackAttempt(ctx context.Context, id uuid.UUID, deliveryErr error, retryAt *time.Time) (*models.GenerationJob, error) {
+	ctx, span := s.tracer.Start(ctx, "JobService.RecordCallbackAttempt")
+	defer span.End()
+
+	attempt := CallbackAttempt{At: time.Now(), Delivered: deliveryErr == nil}
+	if deliveryErr != nil {
+		attempt.LastError = deliveryErr.Error()
+		attempt.RetryAt = retryAt
+	}
+
+	if err := s.repo.RecordCallbackAttempt(ctx, id, attempt); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to update callback state: %w", err)
+	}
+
+	job, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get job for callback tracking: %w", err)
+	}
+	s.cache.store(job)
+
+	if deliveryErr != nil {
+		log.Printf("JobService.RecordCallbackAttempt: Callback for job %s failed (attempt %d): %v",
+			id, job.CallbackAttempts, deliveryErr)
+	} else {
+		log.Printf("JobService.RecordCallbackAttempt: Callback for job %s delivered (attempt %d)",
+			id, job.CallbackAttempts)
+	}
+
+	return job, nil
+}
+
+// ScheduleCallback met en file la livraison du callback d'un job à partir de at, ou la
+// retire de la file (nil)
+func (s *jobServiceImpl) ScheduleCallback(ctx context.Context, id uuid.UUID, at *time.Time) error {
+	ctx, span := s.tracer.Start(ctx, "JobService.ScheduleCallback")
+	defer span.End()
+
+	if err := s.repo.ScheduleCallback(ctx, id, at); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to schedule callback: %w", err)
+	}
+	s.cache.invalidate(id)
+
+	return nil
+}
+
+// ClaimDueCallbacks réserve au plus limit livraisons de callback échues pendant lease : une
+// livraison réservée n'est reprise par une autre instance qu'à l'expiration de la réservation
+func (s *jobServiceImpl) ClaimDueCallbacks(ctx context.Context, limit int, lease time.Duration) ([]*models.GenerationJob, error) {
+	ctx, span := s.tracer.Start(ctx, "JobService.ClaimDueCallbacks")
+	defer span.End()
+
+	now := time.Now()
+	due, err := s.repo.ListDueCallbacks(ctx, now, limit)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to list due callbacks: %w", err)
+	}
+
+	until := now.Add(lease)
+	claimed := make([]*models.GenerationJob, 0, len(due))
+	for _, job := range due {
+		ok, err := s.repo.ClaimCallback(ctx, job.ID, now, until)
+		if err != nil {
+			span.RecordError(err)
+			return claimed, fmt.Errorf("failed to claim callback: %w", err)
+		}
+		if ok {
+			job.CallbackNextAttemptAt = &until
+			claimed = append(claimed, job)
+		}
+	}
+
+	return claimed, nil
+}
+
+func (s *jobServiceImpl) CountPendingCallbacks(ctx context.Context) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "JobService.CountPendingCallbacks")
+	defer span.End()
+
+	count, err := s.repo.CountPendingCallbacks(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return 0, fmt.Errorf("failed to count pending callbacks: %w", err)
+	}
+
+	return int(count), nil
+}
+
 func (s *jobServiceImpl) CleanupOldJobs(ctx context.Context, maxAge time.Duration) (int64, error) {
 	ctx, span := s.tracer.Start(ctx, "JobService.CleanupOldJobs")
 	defer span.End()
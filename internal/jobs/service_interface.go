@@ -13,7 +13,30 @@ type JobService interface {
 	CreateJob(ctx context.Context, req *models.GenerationRequest) (*models.GenerationJob, error)
 	GetJob(ctx context.Context, id uuid.UUID) (*models.GenerationJob, error)
 	ListJobs(ctx context.Context, status string, courseID *uuid.UUID) ([]*models.GenerationJob, error)
+	SearchJobs(ctx context.Context, filters JobFilters) ([]*models.GenerationJob, error)
+	CountActiveJobsByClient(ctx context.Context, clientID string) (int, error)
+	CountPendingJobs(ctx context.Context) (int, error)
+	ListPendingJobs(ctx context.Context, limit int) ([]*models.GenerationJob, error)
+	GetLatestBuild(ctx context.Context, courseID uuid.UUID, clientID string) (*models.GenerationJob, error)
 	UpdateJobStatus(ctx context.Context, id uuid.UUID, status models.JobStatus, progress int, errorMsg string) error
+	ClaimJob(ctx context.Context, id uuid.UUID) (bool, error)
+	CancelPendingJob(ctx context.Context, id uuid.UUID, reason string) (bool, error)
 	AddJobLog(ctx context.Context, id uuid.UUID, logEntry string) error
+	SetJobEntryPoints(ctx context.Context, id uuid.UUID, entryPoints []string) error
+	SetJobThumbnail(ctx context.Context, id uuid.UUID, path string) error
+	SetJobOptions(ctx context.Context, id uuid.UUID, options *models.GenerationJob) error
+	SetJobBuildStats(ctx context.Context, id uuid.UUID, stats *models.BuildStats) error
+	SetJobPreparation(ctx context.Context, id uuid.UUID, preparation *models.JobPreparation) error
+	SetJobThemeResults(ctx context.Context, id uuid.UUID, results []models.ThemeBuildResult) error
+	RecordJobAttempt(ctx context.Context, id uuid.UUID, attempt models.JobAttempt) error
+	EstimateBuild(ctx context.Context, req *models.EstimateRequest) (*models.BuildEstimate, error)
+	GetLatencySLO(ctx context.Context, filters SLOFilters) (*models.LatencySLO, error)
+	RecordCallbackAttempt(ctx context.Context, id uuid.UUID, deliveryErr error, retryAt *time.Time) (*models.GenerationJob, error)
+	ScheduleCallback(ctx context.Context, id uuid.UUID, at *time.Time) error
+	ClaimDueCallbacks(ctx context.Context, limit int, lease time.Duration) ([]*models.GenerationJob, error)
+	CountPendingCallbacks(ctx context.Context) (int, error)
+	GetGenerationProfile(ctx context.Context, courseID uuid.UUID) (*models.GenerationProfile, error)
+	SetGenerationProfile(ctx context.Context, courseID uuid.UUID, req *models.GenerationProfileRequest) (*models.GenerationProfile, error)
+	DeleteGenerationProfile(ctx context.Context, courseID uuid.UUID) (bool, error)
 	CleanupOldJobs(ctx context.Context, maxAge time.Duration) (int64, error)
 }
@@ -0,0 +1,88 @@
+// internal/jobs/slo.go - Suivi de la latence des jobs par rapport aux objectifs (SLO)
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// DefaultSLOWindow est la fenêtre glissante par défaut du suivi de latence
+const DefaultSLOWindow = 24 * time.Hour
+
+// DefaultSLOTargets retourne les objectifs de latence par défaut
+func DefaultSLOTargets() []time.Duration {
+	return []time.Duration{2 * time.Minute, 5 * time.Minute, 10 * time.Minute}
+}
+
+// SLOFilters décrit le calcul de latence demandé
+type SLOFilters struct {
+	Window   time.Duration   // 0 = DefaultSLOWindow
+	CourseID *uuid.UUID      // nil = tous les cours
+	Targets  []time.Duration // vide = DefaultSLOTargets
+}
+
+// GetLatencySLO calcule la latence des jobs terminés sur la fenêtre : percentiles des
+// jobs réussis et part des jobs terminés (réussis, en échec ou en timeout) ayant réussi
+// sous chaque objectif. La latence va de la création du job à sa fin, attente comprise.
+func (s *jobServiceImpl) GetLatencySLO(ctx context.Context, filters SLOFilters) (*models.LatencySLO, error) {
+	ctx, span := s.tracer.Start(ctx, "JobService.GetLatencySLO")
+	defer span.End()
+
+	window := filters.Window
+	if window <= 0 {
+		window = DefaultSLOWindow
+	}
+	targets := filters.Targets
+	if len(targets) == 0 {
+		targets = DefaultSLOTargets()
+	}
+
+	since := time.Now().Add(-window).UTC()
+	aggregate, err := s.repo.AggregateLatency(ctx, LatencyFilters{
+		Since:    since,
+		CourseID: filters.CourseID,
+		Targets:  targets,
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to aggregate job latency: %w", err)
+	}
+
+	slo := &models.LatencySLO{
+		Window:        window.String(),
+		WindowSeconds: window.Seconds(),
+		Since:         since,
+		CourseID:      filters.CourseID,
+		FinishedJobs:  aggregate.Finished,
+		CompletedJobs: aggregate.Completed,
+		FailedJobs:    aggregate.Finished - aggregate.Completed,
+		Latency: models.LatencySummary{
+			P50: math.Round(aggregate.P50Seconds*10) / 10,
+			P95: math.Round(aggregate.P95Seconds*10) / 10,
+			P99: math.Round(aggregate.P99Seconds*10) / 10,
+		},
+		Targets: make([]models.LatencyTarget, len(targets)),
+	}
+
+	for i, target := range targets {
+		within := aggregate.WithinTargets[i]
+		ratio := 0.0
+		if aggregate.Finished > 0 {
+			ratio = math.Round(float64(within)/float64(aggregate.Finished)*10000) / 10000
+		}
+		slo.Targets[i] = models.LatencyTarget{
+			Target:        target.String(),
+			TargetSeconds: target.Seconds(),
+			WithinTarget:  within,
+			Ratio:         ratio,
+		}
+	}
+
+	return slo, nil
+}
@@ -0,0 +1,92 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLatencySLO(t *testing.T) {
+	ctx := context.Background()
+	courseID := uuid.New()
+
+	// addJob enregistre un job terminé il y a finishedAgo, après latency depuis sa création
+	addJob := func(repo *countingRepository, course uuid.UUID, status models.JobStatus, latency, finishedAgo time.Duration) {
+		completed := time.Now().Add(-finishedAgo)
+		require.NoError(t, repo.Create(ctx, &models.GenerationJob{
+			ID:          uuid.New(),
+			CourseID:    course,
+			Status:      status,
+			CreatedAt:   completed.Add(-latency),
+			CompletedAt: &completed,
+		}))
+	}
+
+	repo := newCountingRepository()
+	for _, latency := range []time.Duration{30 * time.Second, 60 * time.Second, 90 * time.Second, 4 * time.Minute} {
+		addJob(repo, courseID, models.StatusCompleted, latency, time.Hour)
+	}
+	addJob(repo, courseID, models.StatusFailed, 10*time.Second, time.Hour)
+	addJob(repo, uuid.New(), models.StatusCompleted, 20*time.Minute, time.Hour)
+	// Hors fenêtre par défaut
+	addJob(repo, courseID, models.StatusTimeout, 30*time.Minute, 48*time.Hour)
+	// En cours : ni terminé ni compté
+	require.NoError(t, repo.Create(ctx, &models.GenerationJob{ID: uuid.New(), CourseID: courseID, Status: models.StatusProcessing}))
+
+	service := NewJobServiceImpl(repo)
+
+	t.Run("Default window and targets", func(t *testing.T) {
+		slo, err := service.GetLatencySLO(ctx, SLOFilters{})
+		require.NoError(t, err)
+
+		assert.Equal(t, "24h0m0s", slo.Window)
+		assert.Equal(t, int64(6), slo.FinishedJobs)
+		assert.Equal(t, int64(5), slo.CompletedJobs)
+		assert.Equal(t, int64(1), slo.FailedJobs)
+		assert.InDelta(t, 90, slo.Latency.P50, 0.1)
+
+		require.Len(t, slo.Targets, 3)
+		assert.Equal(t, "2m0s", slo.Targets[0].Target)
+		assert.Equal(t, int64(3), slo.Targets[0].WithinTarget)
+		assert.Equal(t, 0.5, slo.Targets[0].Ratio)
+		assert.Equal(t, int64(4), slo.Targets[1].WithinTarget, "5m")
+		assert.Equal(t, int64(4), slo.Targets[2].WithinTarget, "10m")
+	})
+
+	t.Run("Course scope, window and targets", func(t *testing.T) {
+		slo, err := service.GetLatencySLO(ctx, SLOFilters{
+			Window:   72 * time.Hour,
+			CourseID: &courseID,
+			Targets:  []time.Duration{time.Minute, 45 * time.Minute},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, &courseID, slo.CourseID)
+		assert.Equal(t, int64(6), slo.FinishedJobs, "the timed out job is in the 72h window")
+		assert.Equal(t, int64(4), slo.CompletedJobs)
+		assert.InDelta(t, 75, slo.Latency.P50, 0.1)
+		assert.InDelta(t, 217.5, slo.Latency.P95, 0.1)
+
+		require.Len(t, slo.Targets, 2)
+		assert.Equal(t, int64(2), slo.Targets[0].WithinTarget)
+		assert.Equal(t, 0.3333, slo.Targets[0].Ratio)
+		assert.Equal(t, int64(4), slo.Targets[1].WithinTarget, "failed and timed out jobs miss every target")
+	})
+
+	t.Run("No finished job", func(t *testing.T) {
+		slo, err := NewJobServiceImpl(newCountingRepository()).GetLatencySLO(ctx, SLOFilters{})
+		require.NoError(t, err)
+
+		assert.Zero(t, slo.FinishedJobs)
+		assert.Zero(t, slo.Latency.P99)
+		for _, target := range slo.Targets {
+			assert.Zero(t, target.Ratio)
+		}
+	})
+}
@@ -0,0 +1,146 @@
+// internal/storage/compression.go - Variantes précompressées des résultats
+package storage
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// MinCompressedResultSize est la taille en dessous de laquelle un résultat n'est pas
+// précompressé : le gain ne compense pas l'en-tête de compression
+const MinCompressedResultSize = 1024
+
+// ResultEncoding est un encodage de compression des résultats, stocké à côté de
+// l'original sous le même nom suivi de Suffix (index.html.gz)
+type ResultEncoding struct {
+	Name      string // Valeur de Content-Encoding et Accept-Encoding
+	Suffix    string // Extension ajoutée au fichier compressé
+	newWriter func(io.Writer) (io.WriteCloser, error)
+}
+
+// Compress écrit le contenu compressé de src dans dst
+func (e *ResultEncoding) Compress(dst io.Writer, src io.Reader) error {
+	writer, err := e.newWriter(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(writer, src); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// resultEncodings liste les encodages disponibles, par ordre de préférence au service
+var resultEncodings = []*ResultEncoding{
+	{
+		Name:   "gzip",
+		Suffix: ".gz",
+		newWriter: func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriterLevel(w, gzip.BestCompression)
+		},
+	},
+}
+
+// DefaultResultEncoding est l'encodage utilisé quand un job demande la compression sans
+// qu'aucun encodage ne soit configuré
+const DefaultResultEncoding = "gzip"
+
+// ResultEncodingByName retourne un encodage disponible par son nom
+func ResultEncodingByName(name string) (*ResultEncoding, bool) {
+	for _, encoding := range resultEncodings {
+		if encoding.Name == name {
+			return encoding, true
+		}
+	}
+	return nil, false
+}
+
+// ParseResultEncodings retourne les encodages configurés, ou une erreur pour le premier
+// encodage non supporté
+func ParseResultEncodings(names []string) ([]*ResultEncoding, error) {
+	encodings := make([]*ResultEncoding, 0, len(names))
+	for _, name := range names {
+		encoding, ok := ResultEncodingByName(name)
+		if !ok {
+			return nil, fmt.Errorf("unsupported result encoding %q", name)
+		}
+		encodings = append(encodings, encoding)
+	}
+	return encodings, nil
+}
+
+// ResultVariantOriginal retourne le chemin de l'original d'une variante compressée
+// (assets/index.js.gz → assets/index.js)
+func ResultVariantOriginal(path string) (string, bool) {
+	for _, encoding := range resultEncodings {
+		if original, found := strings.CutSuffix(path, encoding.Suffix); found && original != "" {
+			return original, true
+		}
+	}
+	return "", false
+}
+
+// compressibleResultExtensions sont les types texte dont la compression est utile
+var compressibleResultExtensions = map[string]bool{
+	".html": true, ".css": true, ".js": true, ".mjs": true,
+	".json": true, ".svg": true, ".txt": true, ".xml": true,
+}
+
+// IsCompressibleResult indique si un résultat est précompressé au build
+func IsCompressibleResult(filename string) bool {
+	return compressibleResultExtensions[strings.ToLower(filepath.Ext(filename))]
+}
+
+// AcceptsEncoding indique si un en-tête Accept-Encoding accepte un encodage (q > 0).
+// Une mention explicite de l'encodage l'emporte sur le joker "*".
+func AcceptsEncoding(acceptEncoding, name string) bool {
+	wildcard := false
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.TrimSpace(coding)
+
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if value, found := strings.CutPrefix(strings.TrimSpace(param), "q="); found {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+
+		if strings.EqualFold(coding, name) {
+			return quality > 0
+		}
+		if coding == "*" {
+			wildcard = quality > 0
+		}
+	}
+	return wildcard
+}
+
+// DownloadResultEncoded télécharge la variante précompressée d'un résultat acceptée par
+// le client (Accept-Encoding) si elle existe, sinon l'original. L'encodage retourné est
+// vide pour l'original.
+func (s *StorageService) DownloadResultEncoded(ctx context.Context, courseID uuid.UUID, filename, acceptEncoding string) (io.Reader, string, error) {
+	if acceptEncoding != "" && IsCompressibleResult(filename) {
+		for _, encoding := range resultEncodings {
+			if !AcceptsEncoding(acceptEncoding, encoding.Name) {
+				continue
+			}
+			if reader, err := s.DownloadResult(ctx, courseID, filename+encoding.Suffix); err == nil {
+				return reader, encoding.Name, nil
+			}
+		}
+	}
+
+	reader, err := s.DownloadResult(ctx, courseID, filename)
+	return reader, "", err
+}
@@ -0,0 +1,100 @@
+// internal/storage/compression_test.go
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptsEncoding(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected bool
+	}{
+		{"gzip", true},
+		{"deflate, gzip;q=0.8", true},
+		{"GZIP", true},
+		{"gzip;q=0", false},
+		{"br, deflate", false},
+		{"*", true},
+		{"*, gzip;q=0", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			assert.Equal(t, tt.expected, AcceptsEncoding(tt.header, "gzip"))
+		})
+	}
+}
+
+func TestParseResultEncodings(t *testing.T) {
+	encodings, err := ParseResultEncodings([]string{"gzip"})
+	require.NoError(t, err)
+	require.Len(t, encodings, 1)
+	assert.Equal(t, ".gz", encodings[0].Suffix)
+
+	// brotli n'a pas d'encodeur : la valeur est refusée plutôt qu'ignorée
+	_, err = ParseResultEncodings([]string{"gzip", "br"})
+	assert.ErrorContains(t, err, `"br"`)
+}
+
+func TestDownloadResultEncoded(t *testing.T) {
+	ctx := context.Background()
+	service := NewStorageService(newMemoryStorage(0))
+	courseID := uuid.New()
+	page := strings.Repeat("<p>slide</p>", 100)
+
+	encoding, ok := ResultEncodingByName("gzip")
+	require.True(t, ok)
+	var compressed bytes.Buffer
+	require.NoError(t, encoding.Compress(&compressed, strings.NewReader(page)))
+
+	require.NoError(t, service.UploadResult(ctx, courseID, "index.html", strings.NewReader(page)))
+	require.NoError(t, service.UploadResult(ctx, courseID, "index.html.gz", bytes.NewReader(compressed.Bytes())))
+	require.NoError(t, service.UploadResult(ctx, courseID, "assets/app.js", strings.NewReader("console.log(1)")))
+
+	t.Run("Compressed variant when accepted", func(t *testing.T) {
+		reader, used, err := service.DownloadResultEncoded(ctx, courseID, "index.html", "gzip, deflate")
+		require.NoError(t, err)
+		assert.Equal(t, "gzip", used)
+
+		gzipReader, err := gzip.NewReader(reader)
+		require.NoError(t, err)
+		content, err := io.ReadAll(gzipReader)
+		require.NoError(t, err)
+		assert.Equal(t, page, string(content))
+	})
+
+	t.Run("Original when not accepted", func(t *testing.T) {
+		reader, used, err := service.DownloadResultEncoded(ctx, courseID, "index.html", "")
+		require.NoError(t, err)
+		assert.Empty(t, used)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, page, string(content))
+	})
+
+	t.Run("Original when no variant exists", func(t *testing.T) {
+		_, used, err := service.DownloadResultEncoded(ctx, courseID, "assets/app.js", "gzip")
+		require.NoError(t, err)
+		assert.Empty(t, used)
+	})
+
+	t.Run("Variant original", func(t *testing.T) {
+		original, ok := ResultVariantOriginal("assets/app.js.gz")
+		assert.True(t, ok)
+		assert.Equal(t, "assets/app.js", original)
+
+		_, ok = ResultVariantOriginal("assets/app.js")
+		assert.False(t, ok)
+	})
+}
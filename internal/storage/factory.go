@@ -8,14 +8,24 @@ import (
 	"github.com/Open-Course-Factory/ocf-worker/pkg/storage"
 )
 
-// NewStorage crée une nouvelle instance de storage basée sur la configuration
+// NewStorage crée une nouvelle instance de storage basée sur la configuration.
+// Le backend est instrumenté pour mesurer la latence de ses opérations.
 func NewStorage(config *storage.StorageConfig) (storage.Storage, error) {
+	var backend storage.Storage
+	var err error
+
 	switch config.Type {
 	case "filesystem":
-		return filesystem.NewFilesystemStorage(config.BasePath)
+		backend, err = filesystem.NewFilesystemStorage(config.BasePath)
 	case "garage":
-		return garage.NewGarageStorage(config)
+		backend, err = garage.NewGarageStorage(config)
 	default:
 		return nil, fmt.Errorf("unknown storage type: %s", config.Type)
 	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewInstrumentedStorage(backend, config.Type, config.SlowOpThreshold), nil
 }
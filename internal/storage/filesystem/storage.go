@@ -2,6 +2,7 @@ package filesystem
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -11,6 +12,9 @@ import (
 	"github.com/Open-Course-Factory/ocf-worker/pkg/storage"
 )
 
+// metadataDir contient les métadonnées des objets, un fichier JSON par objet, hors des listings
+const metadataDir = ".metadata"
+
 type filesystemStorage struct {
 	basePath string
 }
@@ -47,6 +51,71 @@ func (fs *filesystemStorage) Upload(ctx context.Context, path string, data io.Re
 		return fmt.Errorf("failed to write data to %s: %w", fullPath, err)
 	}
 
+	// Les métadonnées d'un contenu précédent ne s'appliquent plus
+	return fs.removeMetadata(path)
+}
+
+// UploadWithMetadata écrit le fichier puis ses métadonnées dans metadataDir
+func (fs *filesystemStorage) UploadWithMetadata(ctx context.Context, path string, data io.Reader, size int64, metadata map[string]string) error {
+	if err := fs.Upload(ctx, path, data); err != nil {
+		return err
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata for %s: %w", path, err)
+	}
+
+	metadataPath := fs.metadataPath(path)
+	if err := os.MkdirAll(filepath.Dir(metadataPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directories for %s: %w", metadataPath, err)
+	}
+	if err := os.WriteFile(metadataPath, encoded, 0644); err != nil {
+		return fmt.Errorf("failed to write metadata %s: %w", metadataPath, err)
+	}
+
+	return nil
+}
+
+// GetMetadata lit les métadonnées d'un fichier (vide si elles n'ont pas été fournies)
+func (fs *filesystemStorage) GetMetadata(ctx context.Context, path string) (map[string]string, error) {
+	exists, err := fs.Exists(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+
+	encoded, err := os.ReadFile(fs.metadataPath(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read metadata of %s: %w", path, err)
+	}
+
+	metadata := map[string]string{}
+	if err := json.Unmarshal(encoded, &metadata); err != nil {
+		return nil, fmt.Errorf("invalid metadata for %s: %w", path, err)
+	}
+
+	return metadata, nil
+}
+
+// metadataPath retourne le chemin du fichier de métadonnées d'un objet
+func (fs *filesystemStorage) metadataPath(path string) string {
+	return filepath.Join(fs.basePath, metadataDir, path+".json")
+}
+
+// removeMetadata supprime les métadonnées d'un objet s'il en a
+func (fs *filesystemStorage) removeMetadata(path string) error {
+	if err := os.Remove(fs.metadataPath(path)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete metadata of %s: %w", path, err)
+	}
 	return nil
 }
 
@@ -64,6 +133,21 @@ func (fs *filesystemStorage) Download(ctx context.Context, path string) (io.Read
 	return file, nil
 }
 
+// Stat retourne la taille d'un fichier sans le lire
+func (fs *filesystemStorage) Stat(ctx context.Context, path string) (*storage.ObjectInfo, error) {
+	fullPath := filepath.Join(fs.basePath, path)
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file not found: %s", path)
+		}
+		return nil, fmt.Errorf("failed to stat file %s: %w", fullPath, err)
+	}
+
+	return &storage.ObjectInfo{Size: info.Size()}, nil
+}
+
 func (fs *filesystemStorage) Exists(ctx context.Context, path string) (bool, error) {
 	fullPath := filepath.Join(fs.basePath, path)
 
@@ -83,12 +167,12 @@ func (fs *filesystemStorage) Delete(ctx context.Context, path string) error {
 
 	if err := os.Remove(fullPath); err != nil {
 		if os.IsNotExist(err) {
-			return nil // Already deleted, no error
+			return fs.removeMetadata(path) // Already deleted, no error
 		}
 		return fmt.Errorf("failed to delete file %s: %w", fullPath, err)
 	}
 
-	return nil
+	return fs.removeMetadata(path)
 }
 
 func (fs *filesystemStorage) List(ctx context.Context, prefix string) ([]string, error) {
@@ -101,6 +185,10 @@ func (fs *filesystemStorage) List(ctx context.Context, prefix string) ([]string,
 			return err
 		}
 
+		if info.IsDir() && path == filepath.Join(fs.basePath, metadataDir) {
+			return filepath.SkipDir
+		}
+
 		if !info.IsDir() && strings.HasPrefix(path, fullPrefix) {
 			// Retourner le chemin relatif au basePath
 			relPath, err := filepath.Rel(fs.basePath, path)
@@ -3,9 +3,11 @@ package filesystem
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	pkgstorage "github.com/Open-Course-Factory/ocf-worker/pkg/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -46,6 +48,18 @@ func TestFilesystemStorage(t *testing.T) {
 		assert.Equal(t, testData, string(buf))
 	})
 
+	t.Run("Stat file", func(t *testing.T) {
+		err := storage.Upload(ctx, "stat/file.txt", strings.NewReader("12345"))
+		require.NoError(t, err)
+
+		info, err := storage.(*filesystemStorage).Stat(ctx, "stat/file.txt")
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), info.Size)
+
+		_, err = storage.(*filesystemStorage).Stat(ctx, "stat/missing.txt")
+		assert.Error(t, err)
+	})
+
 	t.Run("List files", func(t *testing.T) {
 		// Upload plusieurs fichiers
 		files := map[string]string{
@@ -99,4 +113,37 @@ func TestFilesystemStorage(t *testing.T) {
 		assert.NoError(t, err)
 		assert.False(t, exists)
 	})
+
+	t.Run("Metadata", func(t *testing.T) {
+		testPath := "sources/job3/slides.md"
+		metadata := map[string]string{"sha256": "sha256:abc"}
+
+		err := pkgstorage.UploadWithMetadata(ctx, storage, testPath, strings.NewReader("# Slides"), 8, metadata)
+		require.NoError(t, err)
+
+		stored, err := pkgstorage.GetMetadata(ctx, storage, testPath)
+		require.NoError(t, err)
+		assert.Equal(t, metadata, stored)
+
+		// Les métadonnées n'apparaissent pas dans les listings
+		files, err := storage.List(ctx, "")
+		require.NoError(t, err)
+		for _, file := range files {
+			assert.False(t, strings.HasPrefix(file, metadataDir), file)
+		}
+
+		// Un nouvel upload sans métadonnées les invalide
+		require.NoError(t, storage.Upload(ctx, testPath, strings.NewReader("# Other")))
+		stored, err = pkgstorage.GetMetadata(ctx, storage, testPath)
+		require.NoError(t, err)
+		assert.Empty(t, stored)
+
+		require.NoError(t, pkgstorage.UploadWithMetadata(ctx, storage, testPath, strings.NewReader("# Slides"), 8, metadata))
+		require.NoError(t, storage.Delete(ctx, testPath))
+		_, err = os.Stat(filepath.Join(tempDir, metadataDir, testPath+".json"))
+		assert.True(t, os.IsNotExist(err))
+
+		_, err = pkgstorage.GetMetadata(ctx, storage, testPath)
+		assert.Error(t, err)
+	})
 }
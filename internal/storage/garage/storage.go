@@ -1,12 +1,14 @@
 package garage
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -17,9 +19,21 @@ import (
 	"github.com/Open-Course-Factory/ocf-worker/pkg/storage"
 )
 
+// DefaultPresignExpiry est la durée de validité par défaut des URLs présignées
+const DefaultPresignExpiry = time.Hour
+
+const (
+	// DefaultMultipartThreshold est la taille à partir de laquelle UploadSized passe en multipart
+	DefaultMultipartThreshold = 64 << 20
+	// multipartPartSize est la taille des parts envoyées (minimum S3 : 5 Mo sauf la dernière)
+	multipartPartSize = 16 << 20
+)
+
 type garageStorage struct {
-	client *s3.Client
-	bucket string
+	client             *s3.Client
+	bucket             string
+	presignExpiry      time.Duration
+	multipartThreshold int64
 }
 
 // NewGarageStorage crée une nouvelle instance de storage Garage S3-compatible
@@ -56,9 +70,16 @@ func NewGarageStorage(cfg *storage.StorageConfig) (storage.Storage, error) {
 		o.UsePathStyle = cfg.UsePathStyle // true Important pour Garage/MinIO
 	})
 
+	presignExpiry := cfg.PresignExpiry
+	if presignExpiry <= 0 {
+		presignExpiry = DefaultPresignExpiry
+	}
+
 	garage := &garageStorage{
-		client: client,
-		bucket: cfg.Bucket,
+		client:             client,
+		bucket:             cfg.Bucket,
+		presignExpiry:      presignExpiry,
+		multipartThreshold: DefaultMultipartThreshold,
 	}
 
 	// Vérifier que le bucket existe (optionnel)
@@ -108,6 +129,117 @@ func (g *garageStorage) Upload(ctx context.Context, path string, data io.Reader)
 	return nil
 }
 
+// UploadSized envoie les petits fichiers en un seul PutObject avec Content-Length, et
+// les fichiers au-delà du seuil en multipart, une part en mémoire à la fois
+func (g *garageStorage) UploadSized(ctx context.Context, path string, data io.Reader, size int64) error {
+	return g.UploadWithMetadata(ctx, path, data, size, nil)
+}
+
+// UploadWithMetadata se comporte comme UploadSized et stocke les métadonnées en x-amz-meta-*
+func (g *garageStorage) UploadWithMetadata(ctx context.Context, path string, data io.Reader, size int64, metadata map[string]string) error {
+	key := strings.TrimPrefix(path, "/")
+
+	if size > g.multipartThreshold {
+		return g.uploadMultipart(ctx, key, data, size, metadata)
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(g.bucket),
+		Key:         aws.String(key),
+		Body:        data,
+		ContentType: aws.String(getContentType(key)),
+		Metadata:    metadata,
+	}
+	if size >= 0 {
+		input.ContentLength = aws.Int64(size)
+	}
+
+	if _, err := g.client.PutObject(ctx, input); err != nil {
+		return fmt.Errorf("failed to upload object %s to bucket %s: %w", key, g.bucket, err)
+	}
+
+	return nil
+}
+
+// GetMetadata retourne les métadonnées utilisateur d'un objet (HeadObject)
+func (g *garageStorage) GetMetadata(ctx context.Context, path string) (map[string]string, error) {
+	key := strings.TrimPrefix(path, "/")
+
+	result, err := g.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(g.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata of object %s: %w", key, err)
+	}
+
+	return result.Metadata, nil
+}
+
+// uploadMultipart envoie un objet en plusieurs parts et annule l'upload en cas d'erreur
+func (g *garageStorage) uploadMultipart(ctx context.Context, key string, data io.Reader, size int64, metadata map[string]string) error {
+	created, err := g.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(g.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(getContentType(key)),
+		Metadata:    metadata,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start multipart upload of %s: %w", key, err)
+	}
+
+	parts, err := g.uploadParts(ctx, key, created.UploadId, data, size)
+	if err == nil {
+		_, err = g.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+			Bucket:          aws.String(g.bucket),
+			Key:             aws.String(key),
+			UploadId:        created.UploadId,
+			MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+		})
+	}
+	if err != nil {
+		// Libérer les parts déjà stockées, sans écraser l'erreur d'origine
+		_, _ = g.client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(g.bucket),
+			Key:      aws.String(key),
+			UploadId: created.UploadId,
+		})
+		return fmt.Errorf("failed to upload object %s to bucket %s: %w", key, g.bucket, err)
+	}
+
+	return nil
+}
+
+// uploadParts lit et envoie les parts successives de l'objet
+func (g *garageStorage) uploadParts(ctx context.Context, key string, uploadID *string, data io.Reader, size int64) ([]types.CompletedPart, error) {
+	buffer := make([]byte, min(size, multipartPartSize))
+	var parts []types.CompletedPart
+
+	for remaining, number := size, int32(1); remaining > 0; number++ {
+		chunk := buffer[:min(remaining, int64(len(buffer)))]
+		if _, err := io.ReadFull(data, chunk); err != nil {
+			return nil, fmt.Errorf("failed to read part %d: %w", number, err)
+		}
+
+		part, err := g.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:        aws.String(g.bucket),
+			Key:           aws.String(key),
+			UploadId:      uploadID,
+			PartNumber:    aws.Int32(number),
+			Body:          bytes.NewReader(chunk),
+			ContentLength: aws.Int64(int64(len(chunk))),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload part %d: %w", number, err)
+		}
+
+		parts = append(parts, types.CompletedPart{ETag: part.ETag, PartNumber: aws.Int32(number)})
+		remaining -= int64(len(chunk))
+	}
+
+	return parts, nil
+}
+
 func (g *garageStorage) Download(ctx context.Context, path string) (io.Reader, error) {
 	key := strings.TrimPrefix(path, "/")
 
@@ -122,6 +254,21 @@ func (g *garageStorage) Download(ctx context.Context, path string) (io.Reader, e
 	return result.Body, nil
 }
 
+// Stat retourne la taille d'un objet sans le télécharger (HeadObject)
+func (g *garageStorage) Stat(ctx context.Context, path string) (*storage.ObjectInfo, error) {
+	key := strings.TrimPrefix(path, "/")
+
+	result, err := g.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(g.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat object %s: %w", key, err)
+	}
+
+	return &storage.ObjectInfo{Size: aws.ToInt64(result.ContentLength)}, nil
+}
+
 func (g *garageStorage) Exists(ctx context.Context, path string) (bool, error) {
 	key := strings.TrimPrefix(path, "/")
 
@@ -184,14 +331,14 @@ func (g *garageStorage) List(ctx context.Context, prefix string) ([]string, erro
 func (g *garageStorage) GetURL(ctx context.Context, path string) (string, error) {
 	key := strings.TrimPrefix(path, "/")
 
-	// Générer une URL présignée valide pour 1 heure
+	// Générer une URL présignée valide pendant presignExpiry
 	presigner := s3.NewPresignClient(g.client)
 
 	request, err := presigner.PresignGetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(g.bucket),
 		Key:    aws.String(key),
 	}, func(opts *s3.PresignOptions) {
-		opts.Expires = 3600 // 1 heure
+		opts.Expires = g.presignExpiry
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to generate presigned URL for %s: %w", key, err)
@@ -206,8 +353,14 @@ func getContentType(filename string) string {
 	switch ext {
 	case ".md":
 		return "text/markdown"
-	case ".css":
+	case ".css", ".postcss":
 		return "text/css"
+	case ".scss":
+		return "text/x-scss"
+	case ".sass":
+		return "text/x-sass"
+	case ".less":
+		return "text/x-less"
 	case ".js":
 		return "application/javascript"
 	case ".json":
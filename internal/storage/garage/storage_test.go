@@ -3,6 +3,7 @@ package garage
 
 import (
 	"context"
+	"io"
 	"os"
 	"strings"
 	"testing"
@@ -82,6 +83,26 @@ func TestGarageStorageIntegration(t *testing.T) {
 		assert.Equal(t, testData, string(buf))
 	})
 
+	t.Run("UploadSized single and multipart", func(t *testing.T) {
+		garage := storage.(*garageStorage)
+		garage.multipartThreshold = 1 << 20
+
+		small := strings.Repeat("s", 1024)
+		require.NoError(t, garage.UploadSized(ctx, "test/sized/small.txt", strings.NewReader(small), int64(len(small))))
+
+		// 6 Mo au-delà du seuil abaissé : envoyé en multipart
+		large := strings.Repeat("l", 6<<20)
+		require.NoError(t, garage.UploadSized(ctx, "test/sized/large.txt", strings.NewReader(large), int64(len(large))))
+
+		for path, expected := range map[string]string{"test/sized/small.txt": small, "test/sized/large.txt": large} {
+			reader, err := garage.Download(ctx, path)
+			require.NoError(t, err)
+			content, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, len(expected), len(content), path)
+		}
+	})
+
 	t.Run("List files with prefix", func(t *testing.T) {
 		// Upload plusieurs fichiers
 		files := map[string]string{
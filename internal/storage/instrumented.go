@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/storage"
+)
+
+// DefaultSlowOpThreshold est la durée au-delà de laquelle une opération de stockage est loguée
+const DefaultSlowOpThreshold = time.Second
+
+// Opérations de stockage instrumentées
+const (
+	OpUpload   = "upload"
+	OpDownload = "download"
+	OpList     = "list"
+	OpDelete   = "delete"
+)
+
+// operationStats accumule les mesures d'une opération
+type operationStats struct {
+	count     int64
+	errors    int64
+	slowCount int64
+	total     time.Duration
+	max       time.Duration
+}
+
+// InstrumentedStorage mesure la latence des opérations d'un backend de stockage
+// et logue celles qui dépassent le seuil configuré.
+type InstrumentedStorage struct {
+	backend       storage.Storage
+	backendType   string
+	slowThreshold time.Duration
+
+	mu    sync.Mutex
+	stats map[string]*operationStats
+}
+
+// NewInstrumentedStorage enveloppe un backend de stockage (seuil <= 0 = DefaultSlowOpThreshold)
+func NewInstrumentedStorage(backend storage.Storage, backendType string, slowThreshold time.Duration) *InstrumentedStorage {
+	if slowThreshold <= 0 {
+		slowThreshold = DefaultSlowOpThreshold
+	}
+
+	return &InstrumentedStorage{
+		backend:       backend,
+		backendType:   backendType,
+		slowThreshold: slowThreshold,
+		stats:         make(map[string]*operationStats),
+	}
+}
+
+// record enregistre la durée d'une opération et logue les opérations lentes
+func (s *InstrumentedStorage) record(operation, path string, start time.Time, err error) {
+	duration := time.Since(start)
+	slow := duration >= s.slowThreshold
+
+	s.mu.Lock()
+	stats, exists := s.stats[operation]
+	if !exists {
+		stats = &operationStats{}
+		s.stats[operation] = stats
+	}
+	stats.count++
+	stats.total += duration
+	if duration > stats.max {
+		stats.max = duration
+	}
+	if err != nil {
+		stats.errors++
+	}
+	if slow {
+		stats.slowCount++
+	}
+	s.mu.Unlock()
+
+	if slow {
+		log.Printf("Storage: slow %s operation on %s backend: %s took %v (threshold %v)",
+			operation, s.backendType, path, duration, s.slowThreshold)
+	}
+}
+
+// Upload délègue au backend en mesurant la durée
+func (s *InstrumentedStorage) Upload(ctx context.Context, path string, data io.Reader) error {
+	start := time.Now()
+	err := s.backend.Upload(ctx, path, data)
+	s.record(OpUpload, path, start, err)
+	return err
+}
+
+// UploadSized délègue au backend en mesurant la durée, avec Upload si la taille n'y sert pas
+func (s *InstrumentedStorage) UploadSized(ctx context.Context, path string, data io.Reader, size int64) error {
+	start := time.Now()
+	err := storage.UploadWithSize(ctx, s.backend, path, data, size)
+	s.record(OpUpload, path, start, err)
+	return err
+}
+
+// UploadWithMetadata délègue au backend en mesurant la durée, sans métadonnées s'il ne les conserve pas
+func (s *InstrumentedStorage) UploadWithMetadata(ctx context.Context, path string, data io.Reader, size int64, metadata map[string]string) error {
+	start := time.Now()
+	err := storage.UploadWithMetadata(ctx, s.backend, path, data, size, metadata)
+	s.record(OpUpload, path, start, err)
+	return err
+}
+
+// GetMetadata délègue au backend
+func (s *InstrumentedStorage) GetMetadata(ctx context.Context, path string) (map[string]string, error) {
+	return storage.GetMetadata(ctx, s.backend, path)
+}
+
+// Download délègue au backend en mesurant la durée.
+// Pour les backends qui streament le contenu, seule l'ouverture est mesurée.
+func (s *InstrumentedStorage) Download(ctx context.Context, path string) (io.Reader, error) {
+	start := time.Now()
+	reader, err := s.backend.Download(ctx, path)
+	s.record(OpDownload, path, start, err)
+	return reader, err
+}
+
+// Stat délègue au backend, qui peut télécharger l'objet s'il ne sait pas le décrire
+func (s *InstrumentedStorage) Stat(ctx context.Context, path string) (*storage.ObjectInfo, error) {
+	return storage.Stat(ctx, s.backend, path)
+}
+
+// Exists délègue au backend
+func (s *InstrumentedStorage) Exists(ctx context.Context, path string) (bool, error) {
+	return s.backend.Exists(ctx, path)
+}
+
+// Delete délègue au backend en mesurant la durée
+func (s *InstrumentedStorage) Delete(ctx context.Context, path string) error {
+	start := time.Now()
+	err := s.backend.Delete(ctx, path)
+	s.record(OpDelete, path, start, err)
+	return err
+}
+
+// List délègue au backend en mesurant la durée
+func (s *InstrumentedStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	start := time.Now()
+	files, err := s.backend.List(ctx, prefix)
+	s.record(OpList, prefix, start, err)
+	return files, err
+}
+
+// GetURL délègue au backend
+func (s *InstrumentedStorage) GetURL(ctx context.Context, path string) (string, error) {
+	return s.backend.GetURL(ctx, path)
+}
+
+// SlowThreshold retourne le seuil de log des opérations lentes
+func (s *InstrumentedStorage) SlowThreshold() time.Duration {
+	return s.slowThreshold
+}
+
+// OperationStats retourne les mesures cumulées par opération
+func (s *InstrumentedStorage) OperationStats() map[string]models.StorageOperationStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make(map[string]models.StorageOperationStats, len(s.stats))
+	for operation, stats := range s.stats {
+		var average float64
+		if stats.count > 0 {
+			average = durationMs(stats.total) / float64(stats.count)
+		}
+
+		result[operation] = models.StorageOperationStats{
+			Count:         stats.count,
+			Errors:        stats.errors,
+			SlowCount:     stats.slowCount,
+			AverageTimeMs: average,
+			MaxTimeMs:     durationMs(stats.max),
+		}
+	}
+
+	return result
+}
+
+// durationMs convertit une durée en millisecondes
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
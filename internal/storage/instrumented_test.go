@@ -0,0 +1,81 @@
+// internal/storage/instrumented_test.go
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstrumentedStorage(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Delegates and records", func(t *testing.T) {
+		backend := newMemoryStorage(0)
+		instrumented := NewInstrumentedStorage(backend, "memory", time.Hour)
+
+		require.NoError(t, instrumented.Upload(ctx, "sources/a.md", strings.NewReader("# A")))
+		require.NoError(t, instrumented.Upload(ctx, "sources/b.md", strings.NewReader("# B")))
+		assert.Equal(t, []byte("# A"), backend.files["sources/a.md"])
+
+		reader, err := instrumented.Download(ctx, "sources/a.md")
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "# A", string(content))
+
+		_, err = instrumented.Download(ctx, "sources/missing.md")
+		assert.Error(t, err)
+
+		files, err := instrumented.List(ctx, "sources/")
+		require.NoError(t, err)
+		assert.Len(t, files, 2)
+
+		require.NoError(t, instrumented.Delete(ctx, "sources/b.md"))
+		exists, err := instrumented.Exists(ctx, "sources/b.md")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		stats := instrumented.OperationStats()
+		assert.Equal(t, int64(2), stats[OpUpload].Count)
+		assert.Equal(t, int64(2), stats[OpDownload].Count)
+		assert.Equal(t, int64(1), stats[OpDownload].Errors)
+		assert.Equal(t, int64(1), stats[OpList].Count)
+		assert.Equal(t, int64(1), stats[OpDelete].Count)
+		assert.Zero(t, stats[OpUpload].SlowCount)
+	})
+
+	t.Run("Counts slow operations", func(t *testing.T) {
+		instrumented := NewInstrumentedStorage(newMemoryStorage(5*time.Millisecond), "memory", time.Millisecond)
+
+		require.NoError(t, instrumented.Upload(ctx, "results/index.html", strings.NewReader("<html>")))
+
+		stats := instrumented.OperationStats()[OpUpload]
+		assert.Equal(t, int64(1), stats.SlowCount)
+		assert.GreaterOrEqual(t, stats.MaxTimeMs, 5.0)
+		assert.GreaterOrEqual(t, stats.AverageTimeMs, 5.0)
+	})
+
+	t.Run("Applied by NewStorage", func(t *testing.T) {
+		backend, err := NewStorage(&storage.StorageConfig{Type: "filesystem", BasePath: t.TempDir()})
+		require.NoError(t, err)
+
+		instrumented, ok := backend.(*InstrumentedStorage)
+		require.True(t, ok)
+		assert.Equal(t, DefaultSlowOpThreshold, instrumented.SlowThreshold())
+
+		service := NewStorageService(backend)
+		require.NoError(t, backend.Upload(ctx, "logs/job.log", strings.NewReader("ok")))
+
+		operations, _, ok := service.OperationStats()
+		require.True(t, ok)
+		assert.Equal(t, int64(1), operations[OpUpload].Count)
+	})
+}
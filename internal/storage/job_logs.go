@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Formats de stockage des logs de job
+const (
+	// LogFormatText stocke les lignes telles que capturées ("[time] STREAM: message")
+	LogFormatText = "text"
+	// LogFormatJSONL stocke une ligne JSON par ligne de log, pour les agrégateurs de logs
+	LogFormatJSONL = "jsonl"
+)
+
+// LogEntry est une ligne de log au format JSON lines
+type LogEntry struct {
+	Timestamp string `json:"ts,omitempty"`
+	Stream    string `json:"stream,omitempty"`
+	Message   string `json:"msg"`
+}
+
+// logTimestampPrefix reconnaît le timestamp "[15:04:05] " ajouté par la capture de sortie
+var logTimestampPrefix = regexp.MustCompile(`^\[(\d[^\]]*)\]\s*`)
+
+// Niveaux de filtrage des logs de job
+const (
+	LogLevelAll     = "all"
+	LogLevelWarning = "warning"
+	LogLevelError   = "error"
+)
+
+// errorMarkers et warningMarkers sont les préfixes émis par le worker, Slidev et npm
+var (
+	errorMarkers   = []string{"ERROR", "npm ERR!", "npm error"}
+	warningMarkers = []string{"WARNING", "WARN", "npm WARN", "npm warn"}
+)
+
+// logStreams sont les flux préfixés par la capture de sortie des commandes
+var logStreams = []string{"STDOUT", "STDERR"}
+
+// logLineMessage extrait le message d'une ligne "[time] STREAM: message".
+// Le timestamp et le flux sont optionnels : une ligne sans préfixe est retournée telle quelle.
+func logLineMessage(line string) string {
+	message := strings.TrimSpace(line)
+
+	// Ligne JSON lines : le message est déjà séparé
+	if strings.HasPrefix(message, "{") {
+		var entry LogEntry
+		if err := json.Unmarshal([]byte(message), &entry); err == nil {
+			return strings.TrimSpace(entry.Message)
+		}
+	}
+
+	if strings.HasPrefix(message, "[") {
+		if end := strings.Index(message, "]"); end > 0 {
+			message = strings.TrimSpace(message[end+1:])
+		}
+	}
+
+	for _, stream := range logStreams {
+		if rest, found := strings.CutPrefix(message, stream+":"); found {
+			return strings.TrimSpace(rest)
+		}
+	}
+
+	return message
+}
+
+// logLineLevel retourne le niveau d'une ligne de log (error, warning, ou all si aucun marqueur)
+func logLineLevel(line string) string {
+	message := logLineMessage(line)
+
+	for _, marker := range errorMarkers {
+		if strings.HasPrefix(message, marker) {
+			return LogLevelError
+		}
+	}
+	for _, marker := range warningMarkers {
+		if strings.HasPrefix(message, marker) {
+			return LogLevelWarning
+		}
+	}
+
+	return LogLevelAll
+}
+
+// FilterJobLogs ne garde que les lignes du niveau demandé ou plus grave.
+// "warning" inclut les erreurs ; "all" (ou vide) retourne les logs complets.
+func FilterJobLogs(content, level string) string {
+	if level == "" || level == LogLevelAll {
+		return content
+	}
+
+	var filtered []string
+	for _, line := range strings.Split(content, "\n") {
+		switch logLineLevel(line) {
+		case LogLevelError:
+			filtered = append(filtered, line)
+		case LogLevelWarning:
+			if level == LogLevelWarning {
+				filtered = append(filtered, line)
+			}
+		}
+	}
+
+	if len(filtered) == 0 {
+		return ""
+	}
+	return strings.Join(filtered, "\n") + "\n"
+}
+
+// ParseLogLine découpe une ligne "[time] STREAM: message" ; le timestamp et le flux sont
+// optionnels, une ligne sans préfixe devient le message
+func ParseLogLine(line string) LogEntry {
+	var entry LogEntry
+	message := line
+
+	if match := logTimestampPrefix.FindStringSubmatch(message); match != nil {
+		entry.Timestamp = match[1]
+		message = message[len(match[0]):]
+	}
+	for _, stream := range logStreams {
+		if rest, found := strings.CutPrefix(message, stream+": "); found {
+			entry.Stream = stream
+			message = rest
+			break
+		}
+	}
+
+	entry.Message = message
+	return entry
+}
+
+// String retourne la ligne au format texte
+func (e LogEntry) String() string {
+	var line strings.Builder
+	if e.Timestamp != "" {
+		line.WriteString("[" + e.Timestamp + "] ")
+	}
+	if e.Stream != "" {
+		line.WriteString(e.Stream + ": ")
+	}
+	line.WriteString(e.Message)
+	return line.String()
+}
+
+// FormatJobLogs assemble les lignes capturées d'un job dans le format de stockage demandé
+func FormatJobLogs(lines []string, format string) string {
+	var content strings.Builder
+	for _, line := range lines {
+		if format == LogFormatJSONL {
+			line = marshalLogEntry(ParseLogLine(line))
+		}
+		content.WriteString(line + "\n")
+	}
+	return content.String()
+}
+
+// ConvertJobLogs convertit des logs stockés d'un format à l'autre
+func ConvertJobLogs(content, from, to string) string {
+	if from == to || content == "" {
+		return content
+	}
+
+	var converted strings.Builder
+	for _, line := range strings.SplitAfter(content, "\n") {
+		text := strings.TrimSuffix(line, "\n")
+		if text == "" && line == "" {
+			continue
+		}
+
+		if to == LogFormatJSONL {
+			text = marshalLogEntry(ParseLogLine(text))
+		} else {
+			var entry LogEntry
+			if err := json.Unmarshal([]byte(text), &entry); err == nil {
+				text = entry.String()
+			}
+		}
+		converted.WriteString(text + "\n")
+	}
+	return converted.String()
+}
+
+// marshalLogEntry retourne une ligne JSON lines (l'encodage d'une chaîne ne peut pas échouer)
+func marshalLogEntry(entry LogEntry) string {
+	data, _ := json.Marshal(entry)
+	return string(data)
+}
@@ -0,0 +1,147 @@
+// internal/storage/job_logs_test.go
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterJobLogs(t *testing.T) {
+	logs := "[10:00:01] STDOUT: Building slides...\n" +
+		"[10:00:02] STDERR: npm WARN deprecated glob@7.2.3\n" +
+		"WARNING: Package installation failed: timeout\n" +
+		"[10:00:03] STDERR: ERROR reading output: broken pipe\n" +
+		"ERROR: Slidev build failed: exit status 1\n" +
+		"[2025-01-15 10:00:04] Build ERROR in slides.md\n" +
+		"[broken prefix without end\n" +
+		"\n"
+
+	t.Run("All by default", func(t *testing.T) {
+		assert.Equal(t, logs, FilterJobLogs(logs, ""))
+		assert.Equal(t, logs, FilterJobLogs(logs, LogLevelAll))
+	})
+
+	t.Run("Errors only", func(t *testing.T) {
+		assert.Equal(t,
+			"[10:00:03] STDERR: ERROR reading output: broken pipe\n"+
+				"ERROR: Slidev build failed: exit status 1\n",
+			FilterJobLogs(logs, LogLevelError))
+	})
+
+	t.Run("Warnings include errors", func(t *testing.T) {
+		assert.Equal(t,
+			"[10:00:02] STDERR: npm WARN deprecated glob@7.2.3\n"+
+				"WARNING: Package installation failed: timeout\n"+
+				"[10:00:03] STDERR: ERROR reading output: broken pipe\n"+
+				"ERROR: Slidev build failed: exit status 1\n",
+			FilterJobLogs(logs, LogLevelWarning))
+	})
+
+	t.Run("No matching lines", func(t *testing.T) {
+		assert.Empty(t, FilterJobLogs("[10:00:01] STDOUT: done\n", LogLevelError))
+	})
+}
+
+func TestJobLogsJSONL(t *testing.T) {
+	lines := []string{
+		"[10:00:01] STDOUT: Building slides...",
+		"[10:00:02] STDERR: npm WARN deprecated glob@7.2.3",
+		"ERROR: Slidev build failed: \"exit status 1\"",
+		"[vite] building for production",
+	}
+	jsonl := `{"ts":"10:00:01","stream":"STDOUT","msg":"Building slides..."}` + "\n" +
+		`{"ts":"10:00:02","stream":"STDERR","msg":"npm WARN deprecated glob@7.2.3"}` + "\n" +
+		`{"msg":"ERROR: Slidev build failed: \"exit status 1\""}` + "\n" +
+		`{"msg":"[vite] building for production"}` + "\n"
+
+	t.Run("Format", func(t *testing.T) {
+		assert.Equal(t, jsonl, FormatJobLogs(lines, LogFormatJSONL))
+		assert.Equal(t, lines[0]+"\n"+lines[1]+"\n"+lines[2]+"\n"+lines[3]+"\n", FormatJobLogs(lines, LogFormatText))
+	})
+
+	t.Run("Conversion round trip", func(t *testing.T) {
+		text := FormatJobLogs(lines, LogFormatText)
+		assert.Equal(t, text, ConvertJobLogs(jsonl, LogFormatJSONL, LogFormatText))
+		assert.Equal(t, jsonl, ConvertJobLogs(text, LogFormatText, LogFormatJSONL))
+		assert.Equal(t, jsonl, ConvertJobLogs(jsonl, LogFormatJSONL, LogFormatJSONL))
+	})
+
+	t.Run("Level filter", func(t *testing.T) {
+		assert.Equal(t, `{"msg":"ERROR: Slidev build failed: \"exit status 1\""}`+"\n", FilterJobLogs(jsonl, LogLevelError))
+	})
+
+	t.Run("Storage keeps the format", func(t *testing.T) {
+		ctx := context.Background()
+		service := NewStorageService(newMemoryStorage(0))
+		jobID := uuid.New()
+
+		require.NoError(t, service.SaveJobLog(ctx, jobID, "stale text log\n"))
+		require.NoError(t, service.SaveJobLogLines(ctx, jobID, lines, LogFormatJSONL))
+
+		content, format, err := service.GetJobLogWithFormat(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, LogFormatJSONL, format)
+		assert.Equal(t, jsonl, content)
+
+		text, err := service.GetJobLog(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, FormatJobLogs(lines, LogFormatText), text)
+
+		require.NoError(t, service.CleanupJob(ctx, jobID))
+		_, _, err = service.GetJobLogWithFormat(ctx, jobID)
+		assert.Error(t, err)
+	})
+}
+
+func TestJobLogsCompressed(t *testing.T) {
+	ctx := context.Background()
+	backend := newMemoryStorage(0)
+	service := NewStorageService(backend)
+	logs := strings.Repeat("[10:00:01] STDOUT: Building slides...\n", 100)
+
+	t.Run("Stored gzipped", func(t *testing.T) {
+		jobID := uuid.New()
+		require.NoError(t, service.SaveJobLog(ctx, jobID, logs))
+
+		compressed, format, err := service.GetCompressedJobLog(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, LogFormatText, format)
+		assert.Less(t, len(compressed), len(logs))
+
+		reader, err := gzip.NewReader(bytes.NewReader(compressed))
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, logs, string(content))
+
+		text, err := service.GetJobLog(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, logs, text)
+	})
+
+	t.Run("Legacy uncompressed logs stay readable", func(t *testing.T) {
+		jobID := uuid.New()
+		require.NoError(t, backend.Upload(ctx, "logs/"+jobID.String()+"/generation.log", strings.NewReader(logs)))
+
+		content, err := service.GetJobLog(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, logs, content)
+
+		_, _, err = service.GetCompressedJobLog(ctx, jobID)
+		assert.Error(t, err, "legacy logs have no stored gzip")
+
+		// Un nouveau build remplace les logs non compressés
+		require.NoError(t, service.SaveJobLog(ctx, jobID, "rebuilt\n"))
+		exists, err := backend.Exists(ctx, "logs/"+jobID.String()+"/generation.log")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
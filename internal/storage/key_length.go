@@ -0,0 +1,37 @@
+// internal/storage/key_length.go - Limite de longueur des clés d'objets
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/validation"
+	"github.com/google/uuid"
+)
+
+// ErrStorageKeyTooLong est retournée quand une clé d'objet complète (namespaces, préfixes,
+// dossier de thème, suffixe de variante compressée) dépasse la limite du backend
+var ErrStorageKeyTooLong = errors.New("storage key too long")
+
+// checkKeyLength vérifie qu'une clé complète tient dans validation.MaxStorageKeyLength
+func checkKeyLength(key string) error {
+	if len(key) > validation.MaxStorageKeyLength {
+		return fmt.Errorf("%w: %d bytes, backend limit is %d", ErrStorageKeyTooLong, len(key), validation.MaxStorageKeyLength)
+	}
+	return nil
+}
+
+// CheckJobSourceKey vérifie avant l'upload qu'un fichier source tient dans la limite du
+// backend avec les namespaces du contexte, y compris sa copie dans les résultats du cours
+// (results/{course_id}/ a la longueur de sources/{job_id}/) et sa variante compressée.
+// La destination personnalisée et le dossier de thème d'un job sont vérifiés à l'écriture
+// de chaque résultat.
+func (s *StorageService) CheckJobSourceKey(ctx context.Context, jobID uuid.UUID, filePath string) error {
+	key := s.key(ctx, "sources/%s/%s", jobID.String(), filePath)
+	if length := len(key) + validation.CompressedVariantSuffixLength; length > validation.MaxStorageKeyLength {
+		return fmt.Errorf("%w: %d bytes with the compressed variant suffix, backend limit is %d",
+			ErrStorageKeyTooLong, length, validation.MaxStorageKeyLength)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// internal/storage/manifest_versions.go - Rétention des versions de manifestes par cours
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultManifestVersions est le nombre de versions de manifeste conservées par cours par défaut
+const DefaultManifestVersions = 20
+
+// SetManifestVersions configure le nombre de versions de manifeste conservées par cours
+// (0 = toutes). Les plus anciennes sont supprimées à chaque nouveau build.
+func (s *StorageService) SetManifestVersions(keep int) {
+	s.manifestVersions = max(keep, 0)
+}
+
+// pruneManifestVersions supprime les versions de manifeste d'un cours au-delà des
+// manifestVersions plus récentes, et retourne le nombre de versions supprimées
+func (s *StorageService) pruneManifestVersions(ctx context.Context, courseID uuid.UUID) (int, error) {
+	if s.manifestVersions <= 0 {
+		return 0, nil
+	}
+
+	prefix := s.key(ctx, "manifests/%s/versions/", courseID.String())
+	files, err := s.storage.List(ctx, prefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list manifest versions: %w", err)
+	}
+	if len(files) <= s.manifestVersions {
+		return 0, nil
+	}
+
+	type version struct {
+		path        string
+		generatedAt time.Time
+	}
+	versions := make([]version, 0, len(files))
+	for _, file := range files {
+		if !strings.HasPrefix(file, prefix) || !strings.HasSuffix(file, ".json") {
+			continue
+		}
+		// Une version illisible est datée du zéro : elle part en premier
+		var generatedAt time.Time
+		if manifest, err := s.readManifest(ctx, file); err == nil {
+			generatedAt = manifest.GeneratedAt
+		}
+		versions = append(versions, version{path: file, generatedAt: generatedAt})
+	}
+	if len(versions) <= s.manifestVersions {
+		return 0, nil
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].generatedAt.After(versions[j].generatedAt)
+	})
+
+	removed := 0
+	for _, stale := range versions[s.manifestVersions:] {
+		if err := s.storage.Delete(ctx, stale.path); err != nil {
+			return removed, fmt.Errorf("failed to delete manifest version %s: %w", stale.path, err)
+		}
+		removed++
+	}
+
+	log.Printf("Removed %d old manifest versions of course %s", removed, courseID)
+	return removed, nil
+}
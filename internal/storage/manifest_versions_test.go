@@ -0,0 +1,74 @@
+// internal/storage/manifest_versions_test.go
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestVersionsRetention(t *testing.T) {
+	ctx := context.Background()
+	courseID := uuid.New()
+	otherCourseID := uuid.New()
+	service := NewStorageService(newMemoryStorage(0))
+	service.SetManifestVersions(3)
+
+	// Cinq builds successifs du cours
+	start := time.Now().Add(-time.Hour)
+	var jobIDs []uuid.UUID
+	for i := 0; i < 5; i++ {
+		jobID := uuid.New()
+		jobIDs = append(jobIDs, jobID)
+		require.NoError(t, service.SaveResultManifest(ctx, &models.ResultManifest{
+			CourseID:    courseID,
+			JobID:       jobID,
+			GeneratedAt: start.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+	other := &models.ResultManifest{CourseID: otherCourseID, JobID: uuid.New(), GeneratedAt: start}
+	require.NoError(t, service.SaveResultManifest(ctx, other))
+
+	// Seules les trois versions les plus récentes restent
+	for i, jobID := range jobIDs {
+		_, err := service.GetResultManifestVersion(ctx, courseID, jobID)
+		if i < 2 {
+			assert.True(t, errors.Is(err, ErrManifestNotFound), "version %d should be pruned", i)
+		} else {
+			assert.NoError(t, err, "version %d should be kept", i)
+		}
+	}
+
+	// Le manifeste courant et les versions des autres cours ne sont pas touchés
+	latest, err := service.GetResultManifest(ctx, courseID)
+	require.NoError(t, err)
+	assert.Equal(t, jobIDs[4], latest.JobID)
+	_, err = service.GetResultManifestVersion(ctx, otherCourseID, other.JobID)
+	assert.NoError(t, err)
+
+	t.Run("Unlimited", func(t *testing.T) {
+		service := NewStorageService(newMemoryStorage(0))
+		service.SetManifestVersions(0)
+		for i := 0; i < 4; i++ {
+			require.NoError(t, service.SaveResultManifest(ctx, &models.ResultManifest{
+				CourseID:    courseID,
+				JobID:       uuid.New(),
+				GeneratedAt: start.Add(time.Duration(i) * time.Minute),
+			}))
+		}
+
+		removed, err := service.pruneManifestVersions(ctx, courseID)
+		require.NoError(t, err)
+		assert.Zero(t, removed)
+		files, err := service.storage.List(ctx, "manifests/"+courseID.String()+"/versions/")
+		require.NoError(t, err)
+		assert.Len(t, files, 4)
+	})
+}
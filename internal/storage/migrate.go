@@ -0,0 +1,222 @@
+// internal/storage/migrate.go - Copie des objets d'un backend de storage vers un autre
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/storage"
+)
+
+// DefaultMigrationConcurrency est le nombre de copies simultanées par défaut d'une migration
+const DefaultMigrationConcurrency = 4
+
+// Statuts d'un objet traité par une migration
+const (
+	MigrationCopied  = "copied"  // Copié puis vérifié dans la cible
+	MigrationSkipped = "skipped" // Déjà présent dans la cible avec la même taille
+	MigrationFailed  = "failed"  // Copie ou vérification en échec
+)
+
+// MigrationOptions configure une migration entre deux backends
+type MigrationOptions struct {
+	Prefix      string // Limite la migration aux objets de ce préfixe ("" = tous)
+	Concurrency int    // Copies simultanées (0 = DefaultMigrationConcurrency)
+	Overwrite   bool   // Recopie aussi les objets déjà présents dans la cible
+	DryRun      bool   // Compare source et cible sans rien écrire
+
+	// Progress est appelée après chaque objet traité (appels sérialisés)
+	Progress func(MigrationProgress)
+}
+
+// MigrationProgress décrit l'avancement d'une migration après le traitement d'un objet
+type MigrationProgress struct {
+	Path   string
+	Status string // MigrationCopied, MigrationSkipped ou MigrationFailed
+	Size   int64
+	Err    error
+	Done   int // Objets traités
+	Total  int // Objets à traiter
+}
+
+// MigrationFailure est un objet dont la copie a échoué
+type MigrationFailure struct {
+	Path  string
+	Error string
+}
+
+// MigrationReport est le bilan d'une migration
+type MigrationReport struct {
+	Total       int
+	Copied      int
+	Skipped     int
+	CopiedBytes int64
+	Failed      []MigrationFailure
+	Duration    time.Duration
+}
+
+// MigrateStorage copie tous les objets de source vers target, métadonnées comprises quand
+// les deux backends les supportent. Chaque copie est vérifiée (existence et taille dans la
+// cible). Les objets déjà présents dans la cible avec la même taille sont ignorés : relancer
+// une migration interrompue reprend là où elle s'était arrêtée. Les échecs par objet sont
+// rapportés dans le bilan ; l'erreur retournée concerne le listage ou l'annulation.
+func MigrateStorage(ctx context.Context, source, target storage.Storage, options MigrationOptions) (*MigrationReport, error) {
+	startTime := time.Now()
+
+	paths, err := source.List(ctx, options.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source objects: %w", err)
+	}
+	paths = migratablePaths(paths)
+
+	concurrency := options.Concurrency
+	if concurrency < 1 {
+		concurrency = DefaultMigrationConcurrency
+	}
+
+	report := &MigrationReport{Total: len(paths)}
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	// Le sémaphore est pris avant de lancer la copie : au plus concurrency goroutines
+	// existent à la fois, quel que soit le nombre d'objets
+	for _, path := range paths {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			status, size, err := migrateObject(ctx, source, target, path, options)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch status {
+			case MigrationCopied:
+				report.Copied++
+				report.CopiedBytes += size
+			case MigrationSkipped:
+				report.Skipped++
+			default:
+				report.Failed = append(report.Failed, MigrationFailure{Path: path, Error: err.Error()})
+			}
+			if options.Progress != nil {
+				options.Progress(MigrationProgress{
+					Path:   path,
+					Status: status,
+					Size:   size,
+					Err:    err,
+					Done:   report.Copied + report.Skipped + len(report.Failed),
+					Total:  report.Total,
+				})
+			}
+		}(path)
+	}
+
+	wg.Wait()
+	sort.Slice(report.Failed, func(i, j int) bool { return report.Failed[i].Path < report.Failed[j].Path })
+	report.Duration = time.Since(startTime)
+
+	if err := ctx.Err(); err != nil {
+		return report, fmt.Errorf("migration interrupted: %w", err)
+	}
+	return report, nil
+}
+
+// migratablePaths trie les objets à copier et retire les marqueurs de dossier S3 (clés en
+// "/"), sans contenu et qu'un backend filesystem ne peut pas représenter
+func migratablePaths(paths []string) []string {
+	result := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if path == "" || strings.HasSuffix(path, "/") {
+			continue
+		}
+		result = append(result, path)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// migrateObject copie un objet s'il n'est pas déjà dans la cible et vérifie la copie
+func migrateObject(ctx context.Context, source, target storage.Storage, path string, options MigrationOptions) (string, int64, error) {
+	info, err := storage.Stat(ctx, source, path)
+	if err != nil {
+		return MigrationFailed, 0, fmt.Errorf("failed to stat source object: %w", err)
+	}
+
+	if !options.Overwrite {
+		if present, err := hasObject(ctx, target, path, info.Size); err != nil {
+			return MigrationFailed, info.Size, err
+		} else if present {
+			return MigrationSkipped, info.Size, nil
+		}
+	}
+
+	if options.DryRun {
+		return MigrationCopied, info.Size, nil
+	}
+
+	metadata, err := storage.GetMetadata(ctx, source, path)
+	if err != nil && !errors.Is(err, storage.ErrMetadataNotSupported) {
+		return MigrationFailed, info.Size, fmt.Errorf("failed to read source metadata: %w", err)
+	}
+
+	reader, err := source.Download(ctx, path)
+	if err != nil {
+		return MigrationFailed, info.Size, fmt.Errorf("failed to download source object: %w", err)
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	if len(metadata) > 0 {
+		err = storage.UploadWithMetadata(ctx, target, path, reader, info.Size, metadata)
+	} else {
+		err = storage.UploadWithSize(ctx, target, path, reader, info.Size)
+	}
+	if err != nil {
+		return MigrationFailed, info.Size, fmt.Errorf("failed to upload to target: %w", err)
+	}
+
+	// Vérifier la copie avant de la compter
+	present, err := hasObject(ctx, target, path, info.Size)
+	if err != nil {
+		return MigrationFailed, info.Size, fmt.Errorf("failed to verify copy: %w", err)
+	}
+	if !present {
+		return MigrationFailed, info.Size, fmt.Errorf("copy verification failed: object missing or size differs from source (%d bytes)", info.Size)
+	}
+
+	return MigrationCopied, info.Size, nil
+}
+
+// hasObject indique si la cible contient l'objet avec la taille attendue
+func hasObject(ctx context.Context, target storage.Storage, path string, size int64) (bool, error) {
+	exists, err := target.Exists(ctx, path)
+	if err != nil {
+		return false, fmt.Errorf("failed to check target object: %w", err)
+	}
+	if !exists {
+		return false, nil
+	}
+
+	info, err := storage.Stat(ctx, target, path)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat target object: %w", err)
+	}
+	return info.Size == size, nil
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage/filesystem"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateStorage(t *testing.T) {
+	ctx := context.Background()
+	objects := map[string]string{
+		"sources/job-1/slides.md":         "# Cours",
+		"sources/job-1/assets/logo.svg":   "<svg></svg>",
+		"results/course-1/index.html":     "<html>cours</html>",
+		"logs/job-1.log":                  "build ok\n",
+		"results/course-1/assets/app.js":  "console.log(1)",
+		"results/course-1/manifest.json":  "{}",
+		"sources/job-2/slides.md":         "# Autre cours",
+		"sources/job-2/pages/chapter1.md": "## Chapitre 1",
+	}
+
+	newSource := func(t *testing.T) storage.Storage {
+		source, err := filesystem.NewFilesystemStorage(t.TempDir())
+		require.NoError(t, err)
+		for path, content := range objects {
+			require.NoError(t, source.Upload(ctx, path, strings.NewReader(content)))
+		}
+		require.NoError(t, storage.UploadWithMetadata(ctx, source, "sources/job-1/slides.md",
+			strings.NewReader("# Cours"), 7, map[string]string{ChecksumMetadataKey: "sha256:abc"}))
+		return source
+	}
+
+	read := func(t *testing.T, s storage.Storage, path string) string {
+		reader, err := s.Download(ctx, path)
+		require.NoError(t, err)
+		if closer, ok := reader.(io.Closer); ok {
+			defer closer.Close()
+		}
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("copies and verifies all objects", func(t *testing.T) {
+		source := newSource(t)
+		target, err := filesystem.NewFilesystemStorage(t.TempDir())
+		require.NoError(t, err)
+
+		var progress []MigrationProgress
+		report, err := MigrateStorage(ctx, source, target, MigrationOptions{
+			Concurrency: 3,
+			Progress:    func(p MigrationProgress) { progress = append(progress, p) },
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, len(objects), report.Total)
+		assert.Equal(t, len(objects), report.Copied)
+		assert.Empty(t, report.Failed)
+		require.Len(t, progress, len(objects))
+		assert.Equal(t, len(objects), progress[len(progress)-1].Done)
+
+		for path, content := range objects {
+			assert.Equal(t, content, read(t, target, path), path)
+		}
+
+		metadata, err := storage.GetMetadata(ctx, target, "sources/job-1/slides.md")
+		require.NoError(t, err)
+		assert.Equal(t, "sha256:abc", metadata[ChecksumMetadataKey])
+	})
+
+	t.Run("resumes by skipping verified objects", func(t *testing.T) {
+		source := newSource(t)
+		target, err := filesystem.NewFilesystemStorage(t.TempDir())
+		require.NoError(t, err)
+
+		// Migration interrompue : une partie des objets est déjà copiée, un autre est tronqué
+		require.NoError(t, target.Upload(ctx, "logs/job-1.log", strings.NewReader("build ok\n")))
+		require.NoError(t, target.Upload(ctx, "sources/job-2/slides.md", strings.NewReader("# Autre")))
+
+		report, err := MigrateStorage(ctx, source, target, MigrationOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Skipped)
+		assert.Equal(t, len(objects)-1, report.Copied)
+		assert.Equal(t, "# Autre cours", read(t, target, "sources/job-2/slides.md"))
+
+		report, err = MigrateStorage(ctx, source, target, MigrationOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, len(objects), report.Skipped)
+		assert.Zero(t, report.Copied)
+	})
+
+	t.Run("reports failed copies", func(t *testing.T) {
+		source := newSource(t)
+		target := newMemoryStorage(0)
+		target.failPath = "index.html"
+
+		report, err := MigrateStorage(ctx, source, target, MigrationOptions{Prefix: "results/"})
+		require.NoError(t, err)
+		assert.Equal(t, 3, report.Total)
+		assert.Equal(t, 2, report.Copied)
+		require.Len(t, report.Failed, 1)
+		assert.Equal(t, "results/course-1/index.html", report.Failed[0].Path)
+		assert.Contains(t, report.Failed[0].Error, "failed to upload to target")
+	})
+
+	t.Run("dry run writes nothing", func(t *testing.T) {
+		source := newSource(t)
+		target := newMemoryStorage(0)
+
+		report, err := MigrateStorage(ctx, source, target, MigrationOptions{DryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, len(objects), report.Copied)
+		assert.Empty(t, target.files)
+	})
+
+	t.Run("bounds the number of goroutines", func(t *testing.T) {
+		source := newMemoryStorage(0)
+		for i := 0; i < 500; i++ {
+			require.NoError(t, source.Upload(ctx, fmt.Sprintf("results/course-1/file-%d.txt", i), strings.NewReader("x")))
+		}
+		target := newMemoryStorage(0)
+
+		baseline := runtime.NumGoroutine()
+		peak := 0
+		report, err := MigrateStorage(ctx, source, target, MigrationOptions{
+			Concurrency: 2,
+			Progress:    func(MigrationProgress) { peak = max(peak, runtime.NumGoroutine()) },
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 500, report.Copied)
+		assert.LessOrEqual(t, peak, baseline+2, "one goroutine per concurrent copy")
+	})
+}
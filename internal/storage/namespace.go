@@ -0,0 +1,53 @@
+// internal/storage/namespace.go - Isolation des clés de stockage par tenant
+package storage
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// namespaceContextKey est la clé de contexte du namespace du tenant courant
+type namespaceContextKey struct{}
+
+// WithNamespace retourne un contexte dont les opérations de stockage sont isolées
+// sous le namespace donné (vide = aucun namespace de tenant)
+func WithNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, namespaceContextKey{}, cleanNamespace(namespace))
+}
+
+// NamespaceFromContext retourne le namespace de tenant porté par le contexte
+func NamespaceFromContext(ctx context.Context) string {
+	namespace, _ := ctx.Value(namespaceContextKey{}).(string)
+	return namespace
+}
+
+// ClientNamespace retourne le namespace d'un client authentifié ("client:<nom>"), ou une
+// chaîne vide pour un client anonyme. Le nom est échappé pour qu'aucun client ne puisse
+// sortir de son namespace ni partager celui d'un autre.
+func ClientNamespace(clientID string) string {
+	name, found := strings.CutPrefix(clientID, "client:")
+	if !found || name == "" {
+		return ""
+	}
+
+	escaped := url.PathEscape(name)
+	if escaped == "." || escaped == ".." {
+		escaped = strings.ReplaceAll(escaped, ".", "%2E")
+	}
+	return "tenants/" + escaped
+}
+
+// cleanNamespace normalise un namespace en chemin relatif sans "/" final
+func cleanNamespace(namespace string) string {
+	namespace = strings.Trim(namespace, "/")
+	if namespace == "" {
+		return ""
+	}
+	cleaned := path.Clean(namespace)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return ""
+	}
+	return cleaned
+}
@@ -0,0 +1,150 @@
+// internal/storage/namespace_test.go
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientNamespace(t *testing.T) {
+	tests := []struct {
+		clientID string
+		expected string
+	}{
+		{"client:alice", "tenants/alice"},
+		{"client:team/alice", "tenants/team%2Falice"},
+		{"client:..", "tenants/%2E%2E"},
+		{"client:", ""},
+		{"ip:10.0.0.1", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.clientID, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ClientNamespace(tt.clientID))
+		})
+	}
+}
+
+func TestWithNamespace(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, NamespaceFromContext(ctx))
+	assert.Equal(t, "tenants/alice", NamespaceFromContext(WithNamespace(ctx, "/tenants/alice/")))
+	assert.Empty(t, NamespaceFromContext(WithNamespace(ctx, "../outside")))
+}
+
+func TestStorageNamespaceIsolation(t *testing.T) {
+	ctx := context.Background()
+	backend := newMemoryStorage(0)
+	jobID := uuid.New()
+	courseID := uuid.New()
+
+	upload := func(t *testing.T, ctx context.Context, service *StorageService, content string) {
+		require.NoError(t, service.UploadJobSource(ctx, jobID, "slides.md", strings.NewReader(content)))
+		require.NoError(t, service.UploadResult(ctx, courseID, "index.html", strings.NewReader(content)))
+		require.NoError(t, service.SaveJobLog(ctx, jobID, content))
+	}
+
+	download := func(t *testing.T, ctx context.Context, service *StorageService) string {
+		reader, err := service.DownloadJobSource(ctx, jobID, "slides.md")
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("Deployment namespaces", func(t *testing.T) {
+		tenantA := NewStorageService(backend)
+		tenantA.SetNamespace("tenant-a")
+		tenantB := NewStorageService(backend)
+		tenantB.SetNamespace("tenant-b")
+
+		upload(t, ctx, tenantA, "content a")
+
+		sources, err := tenantB.ListJobSources(ctx, jobID)
+		require.NoError(t, err)
+		assert.Empty(t, sources)
+		results, err := tenantB.ListResults(ctx, courseID)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+		_, err = tenantB.DownloadJobSource(ctx, jobID, "slides.md")
+		assert.Error(t, err)
+
+		upload(t, ctx, tenantB, "content b")
+		assert.Equal(t, "content a", download(t, ctx, tenantA))
+		assert.Equal(t, "content b", download(t, ctx, tenantB))
+
+		exists, err := backend.Exists(ctx, "tenant-a/sources/"+jobID.String()+"/slides.md")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		// Le nettoyage d'un namespace ne touche pas l'autre
+		require.NoError(t, tenantB.CleanupJob(ctx, jobID))
+		sources, err = tenantB.ListJobSources(ctx, jobID)
+		require.NoError(t, err)
+		assert.Empty(t, sources)
+
+		sources, err = tenantA.ListJobSources(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"slides.md"}, sources)
+		log, err := tenantA.GetJobLog(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, "content a", log)
+	})
+
+	t.Run("Client namespaces from context", func(t *testing.T) {
+		service := NewStorageService(backend)
+		service.SetNamespace("shared")
+		alice := WithNamespace(ctx, ClientNamespace("client:alice"))
+		bob := WithNamespace(ctx, ClientNamespace("client:bob"))
+
+		upload(t, alice, service, "alice")
+
+		sources, err := service.ListJobSources(bob, jobID)
+		require.NoError(t, err)
+		assert.Empty(t, sources)
+		sources, err = service.ListJobSources(ctx, jobID)
+		require.NoError(t, err)
+		assert.Empty(t, sources, "deployment namespace does not list tenant files")
+
+		upload(t, bob, service, "bob")
+		require.NoError(t, service.CleanupJob(bob, jobID))
+
+		assert.Equal(t, "alice", download(t, alice, service))
+		exists, err := backend.Exists(ctx, "shared/tenants/alice/sources/"+jobID.String()+"/slides.md")
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("Full key length", func(t *testing.T) {
+		service := NewStorageService(backend)
+		// Clé de source à la limite sans namespace : 45 + 976 + ".gz" = 1024 octets
+		path := strings.Repeat("a", 200) + "/" + strings.Repeat("b", 775)
+		require.NoError(t, service.CheckJobSourceKey(ctx, jobID, path))
+
+		// Le namespace du client allonge la clé
+		clientCtx := WithNamespace(ctx, ClientNamespace("client:alice"))
+		err := service.CheckJobSourceKey(clientCtx, jobID, path)
+		require.ErrorIs(t, err, ErrStorageKeyTooLong)
+		require.ErrorIs(t, service.UploadJobSource(clientCtx, jobID, path+strings.Repeat("c", 10), strings.NewReader("x")), ErrStorageKeyTooLong)
+
+		// Destination personnalisée et dossier de thème sont vérifiés à l'écriture du résultat
+		publishedCtx := WithResultPrefix(ctx, "acme/intro-go/v3")
+		require.ErrorIs(t, service.UploadResult(publishedCtx, courseID, "theme-seriph/"+path+".br", strings.NewReader("x")), ErrStorageKeyTooLong)
+		require.NoError(t, service.UploadResult(publishedCtx, courseID, "theme-seriph/index.html.br", strings.NewReader("x")))
+	})
+
+	t.Run("Key namespace length", func(t *testing.T) {
+		service := NewStorageService(backend)
+		assert.Zero(t, service.KeyNamespaceLength())
+
+		service.SetNamespace("/staging/")
+		assert.Equal(t, len("staging/"), service.KeyNamespaceLength())
+	})
+}
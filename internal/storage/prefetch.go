@@ -0,0 +1,133 @@
+// internal/storage/prefetch.go - Lecture anticipée des résultats pour les archives
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+)
+
+// DefaultArchiveReadConcurrency est le nombre de fichiers de résultat lus en avance par défaut
+const DefaultArchiveReadConcurrency = 4
+
+// SetArchiveReadConcurrency configure le nombre de fichiers de résultat lus en avance pendant
+// la création d'une archive (0 = lectures séquentielles, à la demande)
+func (s *StorageService) SetArchiveReadConcurrency(concurrency int) {
+	if concurrency < 0 {
+		concurrency = 0
+	}
+	s.archiveReadConcurrency = concurrency
+}
+
+// ResultPrefetcher restitue les fichiers de résultat d'un cours dans l'ordre demandé, en
+// lisant les suivants pendant que l'appelant traite le fichier courant : la latence du
+// backend (S3) se superpose à la compression. Les fichiers lus en avance sont gardés en
+// mémoire, au plus concurrency à la fois en plus du fichier courant.
+type ResultPrefetcher struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	service  *StorageService
+	courseID uuid.UUID
+	files    []string
+	next     int
+
+	slots     []chan prefetchedResult // Un canal par fichier, rempli par sa lecture (nil = séquentiel)
+	semaphore chan struct{}           // Lectures en cours ou en attente du consommateur
+}
+
+// prefetchedResult est le contenu lu en avance d'un fichier de résultat
+type prefetchedResult struct {
+	content []byte
+	err     error
+}
+
+// PrefetchResults prépare la lecture ordonnée de fichiers de résultat d'un cours.
+// Close doit être appelé pour arrêter les lectures en avance.
+func (s *StorageService) PrefetchResults(ctx context.Context, courseID uuid.UUID, files []string) *ResultPrefetcher {
+	ctx, cancel := context.WithCancel(ctx)
+	prefetcher := &ResultPrefetcher{
+		ctx:      ctx,
+		cancel:   cancel,
+		service:  s,
+		courseID: courseID,
+		files:    files,
+	}
+
+	if s.archiveReadConcurrency > 0 {
+		prefetcher.slots = make([]chan prefetchedResult, len(files))
+		for i := range prefetcher.slots {
+			prefetcher.slots[i] = make(chan prefetchedResult, 1)
+		}
+		prefetcher.semaphore = make(chan struct{}, s.archiveReadConcurrency)
+		go prefetcher.prefetch()
+	}
+
+	return prefetcher
+}
+
+// prefetch lance la lecture des fichiers dans l'ordre, dans la limite de la concurrence
+func (p *ResultPrefetcher) prefetch() {
+	for i, filename := range p.files {
+		select {
+		case p.semaphore <- struct{}{}:
+		case <-p.ctx.Done():
+			return
+		}
+
+		go func(slot chan<- prefetchedResult, filename string) {
+			content, err := p.read(filename)
+			slot <- prefetchedResult{content: content, err: err}
+		}(p.slots[i], filename)
+	}
+}
+
+// read lit entièrement un fichier de résultat
+func (p *ResultPrefetcher) read(filename string) ([]byte, error) {
+	reader, err := p.service.DownloadResult(p.ctx, p.courseID, filename)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	return io.ReadAll(reader)
+}
+
+// Next retourne le fichier suivant dans l'ordre demandé, io.EOF après le dernier.
+// Le reader retourné est à fermer par l'appelant s'il implémente io.Closer.
+func (p *ResultPrefetcher) Next() (string, io.Reader, error) {
+	if p.next >= len(p.files) {
+		return "", nil, io.EOF
+	}
+	index := p.next
+	filename := p.files[index]
+	p.next++
+
+	if p.slots == nil {
+		reader, err := p.service.DownloadResult(p.ctx, p.courseID, filename)
+		if err != nil {
+			return filename, nil, fmt.Errorf("failed to download file %s: %w", filename, err)
+		}
+		return filename, reader, nil
+	}
+
+	select {
+	case result := <-p.slots[index]:
+		// Le fichier passe au consommateur : une nouvelle lecture peut commencer
+		<-p.semaphore
+		if result.err != nil {
+			return filename, nil, fmt.Errorf("failed to download file %s: %w", filename, result.err)
+		}
+		return filename, bytes.NewReader(result.content), nil
+	case <-p.ctx.Done():
+		return filename, nil, p.ctx.Err()
+	}
+}
+
+// Close arrête les lectures en avance restantes
+func (p *ResultPrefetcher) Close() {
+	p.cancel()
+}
@@ -0,0 +1,135 @@
+// internal/storage/prefetch_test.go
+package storage
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jitterStorage ajoute une latence aléatoire aux téléchargements pour désordonner les lectures
+type jitterStorage struct {
+	*memoryStorage
+}
+
+func (j *jitterStorage) Download(ctx context.Context, path string) (io.Reader, error) {
+	time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+	return j.memoryStorage.Download(ctx, path)
+}
+
+// uploadResults crée count fichiers de résultat et retourne leurs noms dans l'ordre
+func uploadResults(t testing.TB, service *StorageService, courseID uuid.UUID, count, size int) []string {
+	files := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		filename := fmt.Sprintf("assets/file%03d.js", i)
+		content := fmt.Sprintf("// %s\n", filename) + strings.Repeat("const x = 1;\n", size/13)
+		require.NoError(t, service.UploadResult(context.Background(), courseID, filename, strings.NewReader(content)))
+		files = append(files, filename)
+	}
+	return files
+}
+
+func TestPrefetchResults(t *testing.T) {
+	ctx := context.Background()
+
+	for _, concurrency := range []int{0, 1, 4} {
+		t.Run(fmt.Sprintf("Keeps order with concurrency %d", concurrency), func(t *testing.T) {
+			service := NewStorageService(&jitterStorage{memoryStorage: newMemoryStorage(0)})
+			service.SetArchiveReadConcurrency(concurrency)
+			courseID := uuid.New()
+			files := uploadResults(t, service, courseID, 20, 64)
+
+			prefetcher := service.PrefetchResults(ctx, courseID, files)
+			defer prefetcher.Close()
+
+			for _, expected := range files {
+				filename, reader, err := prefetcher.Next()
+				require.NoError(t, err)
+				assert.Equal(t, expected, filename)
+
+				content, err := io.ReadAll(reader)
+				require.NoError(t, err)
+				assert.True(t, strings.HasPrefix(string(content), "// "+expected+"\n"))
+			}
+
+			_, _, err := prefetcher.Next()
+			assert.Equal(t, io.EOF, err)
+		})
+	}
+
+	t.Run("Reports missing files in order", func(t *testing.T) {
+		service := NewStorageService(newMemoryStorage(0))
+		courseID := uuid.New()
+		files := uploadResults(t, service, courseID, 3, 64)
+		files = append(files[:1], append([]string{"missing.js"}, files[1:]...)...)
+
+		prefetcher := service.PrefetchResults(ctx, courseID, files)
+		defer prefetcher.Close()
+
+		filename, _, err := prefetcher.Next()
+		require.NoError(t, err)
+		assert.Equal(t, files[0], filename)
+
+		filename, _, err = prefetcher.Next()
+		require.Error(t, err)
+		assert.Equal(t, "missing.js", filename)
+		assert.Contains(t, err.Error(), "failed to download file missing.js")
+	})
+
+	t.Run("Negative concurrency reads sequentially", func(t *testing.T) {
+		service := NewStorageService(newMemoryStorage(0))
+		service.SetArchiveReadConcurrency(-1)
+		assert.Zero(t, service.archiveReadConcurrency)
+	})
+}
+
+// BenchmarkArchiveResultReads compare la construction d'une archive avec lectures
+// séquentielles (concurrency-0) et avec lectures anticipées
+func BenchmarkArchiveResultReads(b *testing.B) {
+	for _, concurrency := range []int{0, 1, 4, 8} {
+		b.Run(fmt.Sprintf("concurrency-%d", concurrency), func(b *testing.B) {
+			// Latence simulée d'un backend distant (S3/Garage)
+			service := NewStorageService(newMemoryStorage(time.Millisecond))
+			service.SetArchiveReadConcurrency(concurrency)
+			courseID := uuid.New()
+			files := uploadResults(b, service, courseID, 200, 16*1024)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				zipWriter := zip.NewWriter(io.Discard)
+				prefetcher := service.PrefetchResults(context.Background(), courseID, files)
+
+				for {
+					filename, reader, err := prefetcher.Next()
+					if err == io.EOF {
+						break
+					}
+					if err != nil {
+						b.Fatal(err)
+					}
+					entry, err := zipWriter.Create(filename)
+					if err != nil {
+						b.Fatal(err)
+					}
+					if _, err := io.Copy(entry, reader); err != nil {
+						b.Fatal(err)
+					}
+				}
+
+				prefetcher.Close()
+				if err := zipWriter.Close(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
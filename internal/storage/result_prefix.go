@@ -0,0 +1,47 @@
+// internal/storage/result_prefix.go - Destination personnalisée des résultats d'un job
+package storage
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// ResultPrefixRoot est le dossier des destinations personnalisées (result_prefix), séparé de
+// results/ pour qu'une destination ne puisse pas recouvrir les résultats d'un cours. Chaque
+// cours a son propre dossier : published/{course_id}/<prefix>/.
+const ResultPrefixRoot = "published"
+
+// resultPrefixContextKey est la clé de contexte de la destination des résultats
+type resultPrefixContextKey struct{}
+
+// WithResultPrefix retourne un contexte dont les opérations sur les résultats portent sur
+// published/{course_id}/<prefix>/ au lieu de results/{course_id}/ (vide = destination par défaut)
+func WithResultPrefix(ctx context.Context, prefix string) context.Context {
+	return context.WithValue(ctx, resultPrefixContextKey{}, cleanNamespace(prefix))
+}
+
+// ResultPrefixFromContext retourne la destination personnalisée portée par le contexte
+func ResultPrefixFromContext(ctx context.Context) string {
+	prefix, _ := ctx.Value(resultPrefixContextKey{}).(string)
+	return prefix
+}
+
+// resultsPrefix retourne le préfixe de stockage des résultats d'un cours, ou de la
+// destination personnalisée du contexte, toujours sous le dossier du cours
+func (s *StorageService) resultsPrefix(ctx context.Context, courseID uuid.UUID) string {
+	if prefix := ResultPrefixFromContext(ctx); prefix != "" {
+		return s.publishedPrefix(ctx, courseID) + prefix + "/"
+	}
+	return s.key(ctx, "results/%s/", courseID.String())
+}
+
+// publishedPrefix retourne le dossier des destinations personnalisées d'un cours
+func (s *StorageService) publishedPrefix(ctx context.Context, courseID uuid.UUID) string {
+	return s.key(ctx, "%s/%s/", ResultPrefixRoot, courseID.String())
+}
+
+// resultKey retourne la clé de stockage d'un résultat
+func (s *StorageService) resultKey(ctx context.Context, courseID uuid.UUID, filename string) string {
+	return s.resultsPrefix(ctx, courseID) + filename
+}
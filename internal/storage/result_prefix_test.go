@@ -0,0 +1,94 @@
+// internal/storage/result_prefix_test.go
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithResultPrefix(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, ResultPrefixFromContext(ctx))
+	assert.Equal(t, "acme/intro/v3", ResultPrefixFromContext(WithResultPrefix(ctx, "/acme/intro/v3/")))
+	assert.Empty(t, ResultPrefixFromContext(WithResultPrefix(ctx, "../outside")))
+}
+
+func TestResultPrefixDestination(t *testing.T) {
+	ctx := context.Background()
+	backend := newMemoryStorage(0)
+	service := NewStorageService(backend)
+	service.SetNamespace("staging")
+	courseID := uuid.New()
+	prefixed := WithResultPrefix(WithNamespace(ctx, ClientNamespace("client:alice")), "acme/intro/v3")
+
+	require.NoError(t, service.UploadResult(ctx, courseID, "index.html", strings.NewReader("course")))
+	require.NoError(t, service.UploadResult(prefixed, courseID, "index.html", strings.NewReader("custom")))
+	require.NoError(t, service.UploadResult(prefixed, courseID, "assets/app.js", strings.NewReader("run()")))
+
+	// Les résultats sont écrits sous published/{course_id}/<prefix>/, dans les namespaces
+	exists, err := backend.Exists(ctx, "staging/tenants/alice/published/"+courseID.String()+"/acme/intro/v3/assets/app.js")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	results, err := service.ListResults(prefixed, courseID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"index.html", "assets/app.js"}, results)
+
+	results, err = service.ListResults(ctx, courseID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"index.html"}, results, "course results are untouched")
+
+	reader, err := service.DownloadResult(prefixed, courseID, "index.html")
+	require.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "custom", string(content))
+
+	t.Run("Manifest of a custom destination", func(t *testing.T) {
+		course := &models.ResultManifest{CourseID: courseID, JobID: uuid.New()}
+		require.NoError(t, service.SaveResultManifest(ctx, course))
+
+		custom := &models.ResultManifest{CourseID: courseID, JobID: uuid.New()}
+		require.NoError(t, service.SaveResultManifest(prefixed, custom))
+		assert.Equal(t, "acme/intro/v3", custom.ResultPrefix)
+
+		// Le manifeste du cours décrit toujours results/{course_id}/
+		current, err := service.GetResultManifest(ctx, courseID)
+		require.NoError(t, err)
+		assert.Equal(t, course.JobID, current.JobID)
+		assert.Empty(t, current.ResultPrefix)
+
+		version, err := service.GetResultManifestVersion(prefixed, courseID, custom.JobID)
+		require.NoError(t, err)
+		assert.Equal(t, "acme/intro/v3", version.ResultPrefix)
+	})
+
+	t.Run("Same prefix in another course", func(t *testing.T) {
+		otherID := uuid.New()
+		require.NoError(t, service.UploadResult(prefixed, otherID, "index.html", strings.NewReader("other")))
+
+		// Chaque cours a sa propre destination : l'autre cours ne remplace ni ne voit rien
+		reader, err := service.DownloadResult(prefixed, courseID, "index.html")
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "custom", string(content))
+
+		results, err := service.ListResults(prefixed, otherID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"index.html"}, results)
+
+		require.NoError(t, service.DeleteResult(prefixed, otherID, "index.html"))
+		results, err = service.ListResults(prefixed, courseID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"index.html", "assets/app.js"}, results)
+	})
+}
@@ -0,0 +1,122 @@
+// internal/storage/result_quota.go - Quota de stockage des résultats par cours
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/storage"
+
+	"github.com/google/uuid"
+)
+
+// ErrResultQuotaExceeded est retournée quand un build dépasserait le quota de résultats de son cours
+var ErrResultQuotaExceeded = errors.New("result quota exceeded")
+
+// SetResultQuota configure la taille max des résultats d'un cours en octets (0 = sans limite).
+// perCourse remplace ce quota pour certains cours, 0 les laissant sans limite.
+func (s *StorageService) SetResultQuota(quota int64, perCourse map[uuid.UUID]int64) {
+	s.resultQuota = max(quota, 0)
+	s.courseResultQuotas = perCourse
+}
+
+// ResultQuota retourne le quota de résultats d'un cours en octets (0 = sans limite)
+func (s *StorageService) ResultQuota(courseID uuid.UUID) int64 {
+	if quota, exists := s.courseResultQuotas[courseID]; exists {
+		return max(quota, 0)
+	}
+	return s.resultQuota
+}
+
+// GetResultUsage mesure l'espace occupé par les résultats d'un cours (List + Stat), ses
+// destinations personnalisées (result_prefix) comprises
+func (s *StorageService) GetResultUsage(ctx context.Context, courseID uuid.UUID) (*models.CourseResultUsage, error) {
+	sizes, err := s.resultSizes(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &models.CourseResultUsage{
+		CourseID:   courseID.String(),
+		FileCount:  len(sizes),
+		QuotaBytes: s.ResultQuota(courseID),
+	}
+	for _, size := range sizes {
+		usage.UsedBytes += size
+	}
+	if usage.QuotaBytes > 0 {
+		usage.UsagePercent = float64(usage.UsedBytes) / float64(usage.QuotaBytes) * 100
+	}
+	return usage, nil
+}
+
+// CheckResultQuota vérifie, avant leur upload, que les fichiers d'un build (chemin relatif à
+// la destination du contexte -> taille, variantes compressées comprises) tiennent dans le
+// quota du cours avec tous ses résultats déjà stockés, destinations personnalisées et
+// variantes comprises. Les résultats que le build remplace, et leurs variantes, ne sont
+// comptés qu'une fois.
+func (s *StorageService) CheckResultQuota(ctx context.Context, courseID uuid.UUID, files map[string]int64) error {
+	quota := s.ResultQuota(courseID)
+	if quota <= 0 {
+		return nil
+	}
+
+	sizes, err := s.resultSizes(ctx, courseID)
+	if err != nil {
+		return fmt.Errorf("failed to measure course results: %w", err)
+	}
+
+	replaced := make(map[string]bool, len(files))
+	for path := range files {
+		key := s.resultKey(ctx, courseID, path)
+		replaced[key] = true
+		for _, encoding := range resultEncodings {
+			replaced[key+encoding.Suffix] = true
+		}
+	}
+
+	var stored, incoming int64
+	for key, size := range sizes {
+		if !replaced[key] {
+			stored += size
+		}
+	}
+	for _, size := range files {
+		incoming += size
+	}
+
+	if stored+incoming > quota {
+		return fmt.Errorf("%w for course %s: %d bytes stored + %d bytes from this build exceed the %d bytes quota",
+			ErrResultQuotaExceeded, courseID, stored, incoming, quota)
+	}
+	return nil
+}
+
+// resultSizes retourne la taille de chaque résultat stocké d'un cours, indexée par clé de
+// stockage : résultats du cours et de toutes ses destinations personnalisées
+func (s *StorageService) resultSizes(ctx context.Context, courseID uuid.UUID) (map[string]int64, error) {
+	sizes := make(map[string]int64)
+	for _, prefix := range []string{
+		s.key(ctx, "results/%s/", courseID.String()),
+		s.publishedPrefix(ctx, courseID),
+	} {
+		keys, err := s.storage.List(ctx, prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			info, err := storage.Stat(ctx, s.storage, key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to stat result %s: %w", strings.TrimPrefix(key, prefix), err)
+			}
+			sizes[key] = info.Size
+		}
+	}
+	return sizes, nil
+}
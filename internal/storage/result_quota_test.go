@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultQuota(t *testing.T) {
+	ctx := context.Background()
+	courseID := uuid.New()
+	otherCourseID := uuid.New()
+
+	service := NewStorageService(newMemoryStorage(0))
+	require.NoError(t, service.UploadResult(ctx, courseID, "index.html", strings.NewReader(strings.Repeat("x", 600))))
+	require.NoError(t, service.UploadResult(ctx, courseID, "assets/app.js", strings.NewReader(strings.Repeat("x", 200))))
+
+	t.Run("usage without quota", func(t *testing.T) {
+		usage, err := service.GetResultUsage(ctx, courseID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, usage.FileCount)
+		assert.Equal(t, int64(800), usage.UsedBytes)
+		assert.Zero(t, usage.QuotaBytes)
+		assert.Zero(t, usage.UsagePercent)
+
+		assert.NoError(t, service.CheckResultQuota(ctx, courseID, map[string]int64{"big.mp4": 1 << 30}))
+	})
+
+	service.SetResultQuota(1000, nil)
+
+	t.Run("replaced results are counted once", func(t *testing.T) {
+		usage, err := service.GetResultUsage(ctx, courseID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1000), usage.QuotaBytes)
+		assert.InDelta(t, 80.0, usage.UsagePercent, 0.01)
+
+		assert.NoError(t, service.CheckResultQuota(ctx, courseID, map[string]int64{"index.html": 700, "logo.png": 100}))
+	})
+
+	t.Run("build exceeding the quota is refused", func(t *testing.T) {
+		err := service.CheckResultQuota(ctx, courseID, map[string]int64{"index.html": 700, "logo.png": 101})
+		require.ErrorIs(t, err, ErrResultQuotaExceeded)
+		assert.Contains(t, err.Error(), "200 bytes stored + 801 bytes from this build exceed the 1000 bytes quota")
+	})
+
+	t.Run("per-course override", func(t *testing.T) {
+		service.SetResultQuota(1000, map[uuid.UUID]int64{courseID: 0, otherCourseID: 100})
+
+		assert.Zero(t, service.ResultQuota(courseID))
+		assert.NoError(t, service.CheckResultQuota(ctx, courseID, map[string]int64{"big.mp4": 1 << 30}))
+		assert.ErrorIs(t, service.CheckResultQuota(ctx, otherCourseID, map[string]int64{"index.html": 101}), ErrResultQuotaExceeded)
+		assert.Equal(t, int64(1000), service.ResultQuota(uuid.New()))
+	})
+}
+
+func TestResultQuotaCountsCompressedVariants(t *testing.T) {
+	ctx := context.Background()
+	courseID := uuid.New()
+
+	service := NewStorageService(newMemoryStorage(0))
+
+	require.NoError(t, service.UploadResult(ctx, courseID, "index.html", strings.NewReader(strings.Repeat("x", 600))))
+	require.NoError(t, service.UploadResult(ctx, courseID, "index.html.gz", strings.NewReader(strings.Repeat("x", 300))))
+	require.NoError(t, service.UploadResult(ctx, courseID, "app.js.gz", strings.NewReader(strings.Repeat("x", 50))))
+
+	usage, err := service.GetResultUsage(ctx, courseID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(950), usage.UsedBytes)
+
+	service.SetResultQuota(1000, nil)
+
+	// index.html et sa variante sont remplacés : seule app.js.gz reste comptée
+	assert.NoError(t, service.CheckResultQuota(ctx, courseID, map[string]int64{"index.html": 700, "index.html.gz": 250}))
+
+	err = service.CheckResultQuota(ctx, courseID, map[string]int64{"index.html": 700, "index.html.gz": 251})
+	require.ErrorIs(t, err, ErrResultQuotaExceeded)
+	assert.Contains(t, err.Error(), "50 bytes stored + 951 bytes from this build")
+}
+
+func TestResultQuotaCountsCustomDestinations(t *testing.T) {
+	ctx := context.Background()
+	courseID := uuid.New()
+	v1 := WithResultPrefix(ctx, "acme/intro/v1")
+	v2 := WithResultPrefix(ctx, "acme/intro/v2")
+
+	service := NewStorageService(newMemoryStorage(0))
+	service.SetResultQuota(1000, nil)
+	require.NoError(t, service.UploadResult(ctx, courseID, "index.html", strings.NewReader(strings.Repeat("x", 300))))
+	require.NoError(t, service.UploadResult(v1, courseID, "index.html", strings.NewReader(strings.Repeat("x", 400))))
+
+	usage, err := service.GetResultUsage(ctx, courseID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, usage.FileCount)
+	assert.Equal(t, int64(700), usage.UsedBytes)
+
+	// Publier sous un nouveau préfixe ne contourne pas le quota du cours
+	err = service.CheckResultQuota(v2, courseID, map[string]int64{"index.html": 301})
+	require.ErrorIs(t, err, ErrResultQuotaExceeded)
+	assert.Contains(t, err.Error(), "700 bytes stored + 301 bytes")
+
+	// Republier au même préfixe remplace ses fichiers
+	assert.NoError(t, service.CheckResultQuota(v1, courseID, map[string]int64{"index.html": 700}))
+
+	// Les destinations d'un autre cours ne comptent pas
+	usage, err = service.GetResultUsage(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.Zero(t, usage.UsedBytes)
+}
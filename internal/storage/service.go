@@ -1,72 +1,366 @@
 package storage
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"mime/multipart"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/Open-Course-Factory/ocf-worker/internal/validation"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
 	"github.com/Open-Course-Factory/ocf-worker/pkg/storage"
 	"github.com/google/uuid"
 )
 
+// ErrManifestNotFound est retournée quand un cours n'a jamais été généré
+var ErrManifestNotFound = errors.New("result manifest not found")
+
+// ErrJobSourcesNotFound est retournée quand aucune source n'a été uploadée pour un job
+var ErrJobSourcesNotFound = errors.New("no sources found for job")
+
+// ChecksumMetadataKey est la métadonnée portant l'empreinte SHA-256 d'un fichier source
+const ChecksumMetadataKey = "sha256"
+
+// DefaultUploadConcurrency est le nombre d'uploads simultanés par requête par défaut
+const DefaultUploadConcurrency = 4
+
 type StorageService struct {
-	storage storage.Storage
+	storage           storage.Storage
+	uploadConcurrency int
+	namespace         string // Préfixe de toutes les clés du déploiement (vide = aucun)
+	maxPathDepth      int    // Profondeur max des chemins de fichiers (alignée sur la validation)
+
+	// archiveReadConcurrency est le nombre de résultats lus en avance pour les archives (0 = séquentiel)
+	archiveReadConcurrency int
+
+	// resultQuota borne la taille des résultats d'un cours (0 = sans limite) ;
+	// courseResultQuotas la remplace pour certains cours
+	resultQuota        int64
+	courseResultQuotas map[uuid.UUID]int64
+
+	// manifestVersions est le nombre de versions de manifeste conservées par cours (0 = toutes)
+	manifestVersions int
+
+	// transfers compte les octets des sources et résultats uploadés et téléchargés
+	transfers *TransferCounter
 }
 
 func NewStorageService(storage storage.Storage) *StorageService {
 	return &StorageService{
-		storage: storage,
+		storage:                storage,
+		uploadConcurrency:      DefaultUploadConcurrency,
+		maxPathDepth:           validation.DefaultMaxPathDepth,
+		archiveReadConcurrency: DefaultArchiveReadConcurrency,
+		manifestVersions:       DefaultManifestVersions,
+		transfers:              NewTransferCounter(0),
+	}
+}
+
+// SetMaxPathDepth configure la profondeur max des chemins acceptés par ValidateFile.
+// Elle doit être la même que ValidationConfig.MaxPathDepth.
+func (s *StorageService) SetMaxPathDepth(depth int) {
+	if depth <= 0 {
+		depth = validation.DefaultMaxPathDepth
+	}
+	s.maxPathDepth = depth
+}
+
+// SetNamespace préfixe toutes les clés de stockage, pour partager un backend entre
+// plusieurs déploiements. Un namespace de tenant porté par le contexte s'y ajoute.
+func (s *StorageService) SetNamespace(namespace string) {
+	s.namespace = cleanNamespace(namespace)
+}
+
+// KeyNamespaceLength retourne la longueur du namespace de déploiement ajouté devant chaque
+// clé, séparateur compris (0 sans namespace). Le namespace d'un tenant n'y est pas compté.
+func (s *StorageService) KeyNamespaceLength() int {
+	if s.namespace == "" {
+		return 0
+	}
+	return len(s.namespace) + 1
+}
+
+// key construit la clé de stockage d'un objet dans le namespace du déploiement et du tenant
+func (s *StorageService) key(ctx context.Context, format string, args ...any) string {
+	key := fmt.Sprintf(format, args...)
+	if tenant := NamespaceFromContext(ctx); tenant != "" {
+		key = tenant + "/" + key
+	}
+	if s.namespace != "" {
+		key = s.namespace + "/" + key
+	}
+	return key
+}
+
+// OperationStats retourne la latence par opération du backend s'il est instrumenté
+func (s *StorageService) OperationStats() (map[string]models.StorageOperationStats, time.Duration, bool) {
+	instrumented, ok := s.storage.(*InstrumentedStorage)
+	if !ok {
+		return nil, 0, false
+	}
+	return instrumented.OperationStats(), instrumented.SlowThreshold(), true
+}
+
+// SetTransferWindow remet à zéro les compteurs de transferts à chaque fenêtre écoulée
+// (0 = cumul depuis le démarrage). Les compteurs courants sont perdus.
+func (s *StorageService) SetTransferWindow(window time.Duration) {
+	s.transfers = NewTransferCounter(window)
+}
+
+// TransferStats retourne les octets des sources et résultats uploadés et téléchargés
+func (s *StorageService) TransferStats() *models.StorageTransferStats {
+	return s.transfers.Stats()
+}
+
+// countUpload compte les octets d'un contenu au fil de son upload
+func (s *StorageService) countUpload(content io.Reader) io.Reader {
+	return countTransfer(content, s.transfers.AddUploaded)
+}
+
+// countDownload compte les octets d'un téléchargement au fil de sa lecture par l'appelant
+func (s *StorageService) countDownload(reader io.Reader, err error) (io.Reader, error) {
+	if err != nil {
+		return nil, err
 	}
+	return countTransfer(reader, s.transfers.AddDownloaded), nil
 }
 
-// UploadJobSources upload les fichiers source pour un job
-func (s *StorageService) UploadJobSources(ctx context.Context, jobID uuid.UUID, files []*multipart.FileHeader) error {
-	for _, fileHeader := range files {
-		file, err := fileHeader.Open()
+// SetUploadConcurrency configure le nombre d'uploads simultanés par requête
+func (s *StorageService) SetUploadConcurrency(concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	s.uploadConcurrency = concurrency
+}
+
+// UploadJobSources upload les fichiers source pour un job et retourne l'empreinte SHA-256
+// de chaque fichier par chemin, stockée avec l'objet.
+// Les fichiers sont uploadés en parallèle (concurrence bornée) et toutes les erreurs sont agrégées.
+func (s *StorageService) UploadJobSources(ctx context.Context, jobID uuid.UUID, files []*multipart.FileHeader) (map[string]string, error) {
+	return s.UploadJobSourcesWithProgress(ctx, jobID, files, nil)
+}
+
+// UploadJobSourcesWithProgress est UploadJobSources avec un rapport d'avancement : progress
+// est appelée après l'écriture (ou l'échec) de chaque fichier, depuis les goroutines d'upload.
+func (s *StorageService) UploadJobSourcesWithProgress(ctx context.Context, jobID uuid.UUID, files []*multipart.FileHeader,
+	progress func(file *multipart.FileHeader, err error)) (map[string]string, error) {
+	return s.uploadJobSourcesConcurrently(len(files), func(i int) (string, string, error) {
+		checksum, err := s.uploadJobSourceFile(ctx, jobID, files[i])
+		if progress != nil {
+			progress(files[i], err)
+		}
+		return files[i].Filename, checksum, err
+	})
+}
+
+// UploadJobSourceEntries upload les fichiers extraits d'une archive de sources et retourne
+// l'empreinte SHA-256 de chaque fichier par chemin, comme UploadJobSources
+func (s *StorageService) UploadJobSourceEntries(ctx context.Context, jobID uuid.UUID, entries []*validation.ArchiveEntry) (map[string]string, error) {
+	return s.uploadJobSourcesConcurrently(len(entries), func(i int) (string, string, error) {
+		entry := entries[i]
+		checksum, err := Checksum(bytes.NewReader(entry.Content))
 		if err != nil {
-			return fmt.Errorf("failed to open file %s: %w", fileHeader.Filename, err)
+			return entry.Path, "", err
+		}
+
+		storagePath := s.key(ctx, "sources/%s/%s", jobID.String(), entry.Path)
+		if err := checkKeyLength(storagePath); err != nil {
+			return entry.Path, "", fmt.Errorf("failed to upload file %s: %w", entry.Path, err)
+		}
+		metadata := map[string]string{ChecksumMetadataKey: checksum}
+		if err := storage.UploadWithMetadata(ctx, s.storage, storagePath, s.countUpload(bytes.NewReader(entry.Content)),
+			int64(len(entry.Content)), metadata); err != nil {
+			return entry.Path, "", fmt.Errorf("failed to upload file %s: %w", entry.Path, err)
 		}
-		defer file.Close()
+		return entry.Path, checksum, nil
+	})
+}
+
+// uploadJobSourcesConcurrently exécute count uploads de fichiers sources en parallèle
+// (concurrence bornée), agrège les empreintes par chemin et toutes les erreurs
+func (s *StorageService) uploadJobSourcesConcurrently(count int, upload func(i int) (string, string, error)) (map[string]string, error) {
+	concurrency := s.uploadConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var uploadErrors []error
+	checksums := make(map[string]string, count)
+
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			filePath, checksum, err := upload(i)
+			mu.Lock()
+			if err != nil {
+				uploadErrors = append(uploadErrors, err)
+			} else {
+				checksums[filePath] = checksum
+			}
+			mu.Unlock()
+		}(i)
+	}
 
-		// Extraire le chemin complet du fichier (peut inclure des dossiers)
-		filePath := fileHeader.Filename
+	wg.Wait()
+	return checksums, errors.Join(uploadErrors...)
+}
 
-		// Construire le chemin complet: sources/{job_id}/{filepath}
-		// Note: filePath peut maintenant contenir des dossiers comme "assets/images/logo.png"
-		storagePath := fmt.Sprintf("sources/%s/%s", jobID.String(), filePath)
+// uploadJobSourceFile calcule l'empreinte d'un fichier multipart puis l'upload avec
+// (chaque lecture ouvre son propre reader)
+func (s *StorageService) uploadJobSourceFile(ctx context.Context, jobID uuid.UUID, fileHeader *multipart.FileHeader) (string, error) {
+	// Extraire le chemin complet du fichier (peut inclure des dossiers)
+	filePath := fileHeader.Filename
 
-		if err := s.storage.Upload(ctx, storagePath, file); err != nil {
-			return fmt.Errorf("failed to upload file %s: %w", filePath, err)
-		}
+	checksum, err := checksumMultipartFile(fileHeader)
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	// Construire le chemin complet: sources/{job_id}/{filepath}
+	// Note: filePath peut maintenant contenir des dossiers comme "assets/images/logo.png"
+	storagePath := s.key(ctx, "sources/%s/%s", jobID.String(), filePath)
+	if err := checkKeyLength(storagePath); err != nil {
+		return "", fmt.Errorf("failed to upload file %s: %w", filePath, err)
+	}
+
+	metadata := map[string]string{ChecksumMetadataKey: checksum}
+	if err := storage.UploadWithMetadata(ctx, s.storage, storagePath, s.countUpload(file), fileHeader.Size, metadata); err != nil {
+		return "", fmt.Errorf("failed to upload file %s: %w", filePath, err)
+	}
+
+	return checksum, nil
+}
+
+// checksumMultipartFile calcule l'empreinte d'un fichier multipart
+func checksumMultipartFile(fileHeader *multipart.FileHeader) (string, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open file %s: %w", fileHeader.Filename, err)
+	}
+	defer file.Close()
+
+	checksum, err := Checksum(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", fileHeader.Filename, err)
+	}
+	return checksum, nil
+}
+
+// Checksum retourne l'empreinte SHA-256 d'un contenu au format "sha256:<hex>"
+func Checksum(content io.Reader) (string, error) {
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, content); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// SourceHash retourne l'empreinte d'un ensemble de sources au format "sha256:<hex>", à
+// partir de l'empreinte de chaque fichier (chemin -> Checksum). C'est le SHA-256 des lignes
+// "<chemin>\n<empreinte>\n" triées par chemin : un client la recalcule à partir des
+// "checksums" retournés par l'upload, indépendamment de l'ordre des fichiers.
+func SourceHash(checksums map[string]string) string {
+	paths := make([]string, 0, len(checksums))
+	for path := range checksums {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	hasher := sha256.New()
+	for _, path := range paths {
+		fmt.Fprintf(hasher, "%s\n%s\n", path, checksums[path])
+	}
+	return "sha256:" + hex.EncodeToString(hasher.Sum(nil))
+}
+
+// JobSourceChecksum retourne l'empreinte SHA-256 d'un fichier source. Elle est lue dans
+// les métadonnées de l'objet, ou calculée sur son contenu pour les fichiers stockés sans
+// (backend sans métadonnées, fichiers uploadés avant le calcul des empreintes).
+func (s *StorageService) JobSourceChecksum(ctx context.Context, jobID uuid.UUID, filePath string) (string, error) {
+	storagePath := s.key(ctx, "sources/%s/%s", jobID.String(), filePath)
+
+	metadata, err := storage.GetMetadata(ctx, s.storage, storagePath)
+	if err != nil && !errors.Is(err, storage.ErrMetadataNotSupported) {
+		return "", err
+	}
+	if checksum := metadata[ChecksumMetadataKey]; checksum != "" {
+		return checksum, nil
+	}
+
+	reader, err := s.storage.Download(ctx, storagePath)
+	if err != nil {
+		return "", err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	checksum, err := Checksum(reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
+	return checksum, nil
+}
+
+// JobSourceExists indique si un fichier source existe déjà pour un job
+func (s *StorageService) JobSourceExists(ctx context.Context, jobID uuid.UUID, filePath string) (bool, error) {
+	storagePath := s.key(ctx, "sources/%s/%s", jobID.String(), filePath)
+	return s.storage.Exists(ctx, storagePath)
 }
 
 // UploadJobSourceWithPath upload un fichier source avec un chemin explicite
 func (s *StorageService) UploadJobSourceWithPath(ctx context.Context, jobID uuid.UUID, filePath string, content io.Reader) error {
-	storagePath := fmt.Sprintf("sources/%s/%s", jobID.String(), filePath)
-	return s.storage.Upload(ctx, storagePath, content)
+	storagePath := s.key(ctx, "sources/%s/%s", jobID.String(), filePath)
+	if err := checkKeyLength(storagePath); err != nil {
+		return err
+	}
+	return s.storage.Upload(ctx, storagePath, s.countUpload(content))
 }
 
 // UploadJobSource upload un fichier source unique
 func (s *StorageService) UploadJobSource(ctx context.Context, jobID uuid.UUID, filename string, content io.Reader) error {
-	path := fmt.Sprintf("sources/%s/%s", jobID.String(), filename)
-	return s.storage.Upload(ctx, path, content)
+	path := s.key(ctx, "sources/%s/%s", jobID.String(), filename)
+	if err := checkKeyLength(path); err != nil {
+		return err
+	}
+	return s.storage.Upload(ctx, path, s.countUpload(content))
 }
 
 // DownloadJobSource télécharge un fichier source
 func (s *StorageService) DownloadJobSource(ctx context.Context, jobID uuid.UUID, filename string) (io.Reader, error) {
-	path := fmt.Sprintf("sources/%s/%s", jobID.String(), filename)
-	return s.storage.Download(ctx, path)
+	path := s.key(ctx, "sources/%s/%s", jobID.String(), filename)
+	return s.countDownload(s.storage.Download(ctx, path))
 }
 
 // ListJobSources liste les fichiers source d'un job avec leurs chemins complets
 func (s *StorageService) ListJobSources(ctx context.Context, jobID uuid.UUID) ([]string, error) {
-	prefix := fmt.Sprintf("sources/%s/", jobID.String())
+	prefix := s.key(ctx, "sources/%s/", jobID.String())
 	files, err := s.storage.List(ctx, prefix)
 	if err != nil {
 		return nil, err
@@ -86,6 +380,102 @@ func (s *StorageService) ListJobSources(ctx context.Context, jobID uuid.UUID) ([
 	return filePaths, nil
 }
 
+// SummarizeJobSources retourne le nombre et la taille des fichiers sources d'un job, par
+// extension, sans les télécharger quand le backend sait décrire un objet
+func (s *StorageService) SummarizeJobSources(ctx context.Context, jobID uuid.UUID) (*models.SourceSummary, error) {
+	files, err := s.ListJobSources(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, ErrJobSourcesNotFound
+	}
+
+	summary := &models.SourceSummary{
+		JobID:      jobID.String(),
+		Count:      len(files),
+		Extensions: make(map[string]models.ExtensionSummary),
+	}
+
+	for _, file := range files {
+		info, err := storage.Stat(ctx, s.storage, s.key(ctx, "sources/%s/%s", jobID.String(), file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat source %s: %w", file, err)
+		}
+
+		extension := strings.ToLower(filepath.Ext(file))
+		if extension == "" {
+			extension = "none"
+		}
+
+		extensionSummary := summary.Extensions[extension]
+		extensionSummary.Count++
+		extensionSummary.Size += info.Size
+		summary.Extensions[extension] = extensionSummary
+		summary.TotalSize += info.Size
+	}
+
+	return summary, nil
+}
+
+// DeleteJobSource supprime un fichier source d'un job
+func (s *StorageService) DeleteJobSource(ctx context.Context, jobID uuid.UUID, filePath string) error {
+	path := s.key(ctx, "sources/%s/%s", jobID.String(), filePath)
+	return s.storage.Delete(ctx, path)
+}
+
+// DeleteJobSources supprime tous les fichiers sources d'un job et retourne le nombre de
+// fichiers supprimés ; les échecs de suppression sont agrégés
+func (s *StorageService) DeleteJobSources(ctx context.Context, jobID uuid.UUID) (int, error) {
+	sources, err := s.ListJobSources(ctx, jobID)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	var deleteErrors []error
+	for _, filename := range sources {
+		if err := s.DeleteJobSource(ctx, jobID, filename); err != nil {
+			deleteErrors = append(deleteErrors, fmt.Errorf("failed to delete source %s: %w", filename, err))
+			continue
+		}
+		deleted++
+	}
+	return deleted, errors.Join(deleteErrors...)
+}
+
+// MoveJobSource renomme un fichier source d'un job en recalculant son empreinte
+func (s *StorageService) MoveJobSource(ctx context.Context, jobID uuid.UUID, from, to string) error {
+	reader, err := s.DownloadJobSource(ctx, jobID, from)
+	if err != nil {
+		return err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", from, err)
+	}
+
+	checksum, err := Checksum(bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+
+	target := s.key(ctx, "sources/%s/%s", jobID.String(), to)
+	if err := checkKeyLength(target); err != nil {
+		return fmt.Errorf("failed to upload file %s: %w", to, err)
+	}
+	metadata := map[string]string{ChecksumMetadataKey: checksum}
+	if err := storage.UploadWithMetadata(ctx, s.storage, target, bytes.NewReader(content), int64(len(content)), metadata); err != nil {
+		return fmt.Errorf("failed to upload file %s: %w", to, err)
+	}
+
+	return s.DeleteJobSource(ctx, jobID, from)
+}
+
 // GetJobSourceTree retourne l'arbre des fichiers sources organisé par dossiers
 func (s *StorageService) GetJobSourceTree(ctx context.Context, jobID uuid.UUID) (map[string][]string, error) {
 	files, err := s.ListJobSources(ctx, jobID)
@@ -109,25 +499,48 @@ func (s *StorageService) GetJobSourceTree(ctx context.Context, jobID uuid.UUID)
 
 // UploadResult upload le résultat généré pour un cours
 func (s *StorageService) UploadResult(ctx context.Context, courseID uuid.UUID, filename string, content io.Reader) error {
-	path := fmt.Sprintf("results/%s/%s", courseID.String(), filename)
-	return s.storage.Upload(ctx, path, content)
+	path := s.resultKey(ctx, courseID, filename)
+	if err := checkKeyLength(path); err != nil {
+		return fmt.Errorf("failed to upload result %s: %w", filename, err)
+	}
+	return s.storage.Upload(ctx, path, s.countUpload(content))
+}
+
+// UploadResultSized upload un résultat dont la taille est connue
+func (s *StorageService) UploadResultSized(ctx context.Context, courseID uuid.UUID, filename string, content io.Reader, size int64) error {
+	path := s.resultKey(ctx, courseID, filename)
+	if err := checkKeyLength(path); err != nil {
+		return fmt.Errorf("failed to upload result %s: %w", filename, err)
+	}
+	return storage.UploadWithSize(ctx, s.storage, path, s.countUpload(content), size)
 }
 
 // DownloadResult télécharge un résultat généré
 func (s *StorageService) DownloadResult(ctx context.Context, courseID uuid.UUID, filename string) (io.Reader, error) {
-	path := fmt.Sprintf("results/%s/%s", courseID.String(), filename)
-	return s.storage.Download(ctx, path)
+	path := s.resultKey(ctx, courseID, filename)
+	return s.countDownload(s.storage.Download(ctx, path))
+}
+
+// ResultExists indique si un fichier de résultat existe pour un cours
+func (s *StorageService) ResultExists(ctx context.Context, courseID uuid.UUID, filename string) (bool, error) {
+	return s.storage.Exists(ctx, s.resultKey(ctx, courseID, filename))
+}
+
+// DeleteResult supprime un fichier de résultat d'un cours
+func (s *StorageService) DeleteResult(ctx context.Context, courseID uuid.UUID, filename string) error {
+	path := s.resultKey(ctx, courseID, filename)
+	return s.storage.Delete(ctx, path)
 }
 
 // GetResultURL retourne l'URL d'accès à un résultat
 func (s *StorageService) GetResultURL(ctx context.Context, courseID uuid.UUID, filename string) (string, error) {
-	path := fmt.Sprintf("results/%s/%s", courseID.String(), filename)
+	path := s.resultKey(ctx, courseID, filename)
 	return s.storage.GetURL(ctx, path)
 }
 
 // ListResults liste les fichiers de résultat d'un cours
 func (s *StorageService) ListResults(ctx context.Context, courseID uuid.UUID) ([]string, error) {
-	prefix := fmt.Sprintf("results/%s/", courseID.String())
+	prefix := s.resultsPrefix(ctx, courseID)
 	files, err := s.storage.List(ctx, prefix)
 	if err != nil {
 		return nil, err
@@ -146,45 +559,251 @@ func (s *StorageService) ListResults(ctx context.Context, courseID uuid.UUID) ([
 	return filenames, nil
 }
 
+// SaveResultManifest sauvegarde le manifeste des résultats d'un cours
+// Il est stocké hors du préfixe results/ pour ne pas apparaître dans les listings et archives.
+// Le build d'une destination personnalisée ne garde que sa version : le manifeste du cours
+// continue de décrire results/{course_id}/.
+func (s *StorageService) SaveResultManifest(ctx context.Context, manifest *models.ResultManifest) error {
+	manifest.ResultPrefix = ResultPrefixFromContext(ctx)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	// Version conservée par build pour comparer les builds successifs
+	versionPath := s.key(ctx, "manifests/%s/versions/%s.json", manifest.CourseID.String(), manifest.JobID.String())
+	if err := storage.UploadWithSize(ctx, s.storage, versionPath, bytes.NewReader(data), int64(len(data))); err != nil {
+		return fmt.Errorf("failed to save manifest version: %w", err)
+	}
+	if _, err := s.pruneManifestVersions(ctx, manifest.CourseID); err != nil {
+		log.Printf("Failed to prune manifest versions of course %s: %v", manifest.CourseID, err)
+	}
+	if manifest.ResultPrefix != "" {
+		return nil
+	}
+
+	path := s.key(ctx, "manifests/%s/manifest.json", manifest.CourseID.String())
+	return storage.UploadWithSize(ctx, s.storage, path, bytes.NewReader(data), int64(len(data)))
+}
+
+// GetResultManifest récupère le manifeste des résultats d'un cours
+func (s *StorageService) GetResultManifest(ctx context.Context, courseID uuid.UUID) (*models.ResultManifest, error) {
+	return s.readManifest(ctx, s.key(ctx, "manifests/%s/manifest.json", courseID.String()))
+}
+
+// GetResultManifestVersion récupère le manifeste d'un build d'un cours, identifié par son job
+func (s *StorageService) GetResultManifestVersion(ctx context.Context, courseID, jobID uuid.UUID) (*models.ResultManifest, error) {
+	return s.readManifest(ctx, s.key(ctx, "manifests/%s/versions/%s.json", courseID.String(), jobID.String()))
+}
+
+// readManifest lit un manifeste stocké (ErrManifestNotFound s'il n'existe pas)
+func (s *StorageService) readManifest(ctx context.Context, path string) (*models.ResultManifest, error) {
+	exists, err := s.storage.Exists(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrManifestNotFound
+	}
+
+	reader, err := s.storage.Download(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	var manifest models.ResultManifest
+	if err := json.NewDecoder(reader).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+
+	return &manifest, nil
+}
+
 // SaveJobLog sauvegarde les logs d'un job
 func (s *StorageService) SaveJobLog(ctx context.Context, jobID uuid.UUID, logContent string) error {
-	path := fmt.Sprintf("logs/%s/generation.log", jobID.String())
-	return s.storage.Upload(ctx, path, strings.NewReader(logContent))
+	return s.saveJobLog(ctx, jobID, logContent, LogFormatText)
+}
+
+// SaveJobLogLines sauvegarde les lignes de log d'un job au format demandé (LogFormatText
+// ou LogFormatJSONL). Le format est porté par le nom du fichier de logs.
+func (s *StorageService) SaveJobLogLines(ctx context.Context, jobID uuid.UUID, lines []string, format string) error {
+	if format != LogFormatJSONL {
+		format = LogFormatText
+	}
+	return s.saveJobLog(ctx, jobID, FormatJobLogs(lines, format), format)
 }
 
-// GetJobLog récupère les logs d'un job
+// saveJobLog écrit les logs d'un job compressés en gzip et supprime ceux d'un build
+// précédent : autre format, ou logs non compressés écrits avant la compression
+func (s *StorageService) saveJobLog(ctx context.Context, jobID uuid.UUID, logContent, format string) error {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := io.WriteString(writer, logContent); err != nil {
+		return fmt.Errorf("failed to compress job log: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to compress job log: %w", err)
+	}
+
+	path := s.jobLogPath(ctx, jobID, format) + jobLogCompressedSuffix
+	if err := storage.UploadWithSize(ctx, s.storage, path, &compressed, int64(compressed.Len())); err != nil {
+		return err
+	}
+
+	stale := LogFormatJSONL
+	if format == LogFormatJSONL {
+		stale = LogFormatText
+	}
+	// Ignorer les erreurs (fichiers absents)
+	s.storage.Delete(ctx, s.jobLogPath(ctx, jobID, format))
+	s.storage.Delete(ctx, s.jobLogPath(ctx, jobID, stale))
+	s.storage.Delete(ctx, s.jobLogPath(ctx, jobID, stale)+jobLogCompressedSuffix)
+	return nil
+}
+
+// GetJobLog récupère les logs d'un job au format texte, quel que soit leur format de stockage
 func (s *StorageService) GetJobLog(ctx context.Context, jobID uuid.UUID) (string, error) {
-	path := fmt.Sprintf("logs/%s/generation.log", jobID.String())
+	content, format, err := s.GetJobLogWithFormat(ctx, jobID)
+	if err != nil {
+		return "", err
+	}
+	return ConvertJobLogs(content, format, LogFormatText), nil
+}
+
+// GetJobLogWithFormat récupère les logs d'un job tels que stockés, décompressés, avec leur format
+func (s *StorageService) GetJobLogWithFormat(ctx context.Context, jobID uuid.UUID) (string, string, error) {
+	var firstErr error
+	for _, format := range []string{LogFormatText, LogFormatJSONL} {
+		path := s.jobLogPath(ctx, jobID, format)
+		content, err := s.readJobLog(ctx, path+jobLogCompressedSuffix, true)
+		if err != nil {
+			// Logs écrits avant la compression
+			content, err = s.readJobLog(ctx, path, false)
+		}
+		if err == nil {
+			return content, format, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return "", "", firstErr
+}
+
+// GetCompressedJobLog retourne les logs d'un job tels que stockés, compressés en gzip, avec
+// leur format. Les logs écrits avant la compression retournent une erreur.
+func (s *StorageService) GetCompressedJobLog(ctx context.Context, jobID uuid.UUID) ([]byte, string, error) {
+	var firstErr error
+	for _, format := range []string{LogFormatText, LogFormatJSONL} {
+		reader, err := s.storage.Download(ctx, s.jobLogPath(ctx, jobID, format)+jobLogCompressedSuffix)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		content, err := io.ReadAll(reader)
+		if closer, ok := reader.(io.Closer); ok {
+			closer.Close()
+		}
+		return content, format, err
+	}
+	return nil, "", firstErr
+}
+
+// jobLogCompressedSuffix est le suffixe des logs stockés compressés en gzip
+const jobLogCompressedSuffix = ".gz"
 
+// maxJobLogSize borne la taille des logs lus (décompressés)
+const maxJobLogSize = 1024 * 1024
+
+// jobLogPath retourne la clé du fichier de logs non compressé d'un job dans un format
+func (s *StorageService) jobLogPath(ctx context.Context, jobID uuid.UUID, format string) string {
+	if format == LogFormatJSONL {
+		return s.key(ctx, "logs/%s/generation.jsonl", jobID.String())
+	}
+	return s.key(ctx, "logs/%s/generation.log", jobID.String())
+}
+
+// readJobLog lit un fichier de logs, compressé en gzip ou non
+func (s *StorageService) readJobLog(ctx context.Context, path string, compressed bool) (string, error) {
 	reader, err := s.storage.Download(ctx, path)
 	if err != nil {
 		return "", err
 	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
 
-	buf := make([]byte, 1024*1024) // 1MB max pour les logs
-	n, err := reader.Read(buf)
-	if err != nil && err != io.EOF {
+	if compressed {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress job log: %w", err)
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	content, err := io.ReadAll(io.LimitReader(reader, maxJobLogSize))
+	if err != nil {
 		return "", err
 	}
+	return string(content), nil
+}
+
+// ErrThemePreviewNotFound est retournée quand l'aperçu d'une version de thème n'a jamais été généré
+var ErrThemePreviewNotFound = errors.New("theme preview not found")
+
+// themePreviewKey retourne la clé de l'aperçu d'un thème. Les aperçus ne dépendent que du
+// paquet npm : ils sont partagés par tous les clients, hors namespace client.
+func (s *StorageService) themePreviewKey(themePackage, version, format string) string {
+	return s.key(context.Background(), "themes/%s/%s/preview.%s", themePackage, version, format)
+}
+
+// SaveThemePreview met en cache l'aperçu d'une version de thème
+func (s *StorageService) SaveThemePreview(ctx context.Context, themePackage, version, format string, data []byte) error {
+	path := s.themePreviewKey(themePackage, version, format)
+	return storage.UploadWithSize(ctx, s.storage, path, bytes.NewReader(data), int64(len(data)))
+}
+
+// GetThemePreview retourne l'aperçu en cache d'une version de thème (ErrThemePreviewNotFound s'il n'existe pas)
+func (s *StorageService) GetThemePreview(ctx context.Context, themePackage, version, format string) ([]byte, error) {
+	path := s.themePreviewKey(themePackage, version, format)
+
+	exists, err := s.storage.Exists(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrThemePreviewNotFound
+	}
 
-	return string(buf[:n]), nil
+	reader, err := s.storage.Download(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	return io.ReadAll(reader)
 }
 
 // CleanupJob supprime tous les fichiers liés à un job
 func (s *StorageService) CleanupJob(ctx context.Context, jobID uuid.UUID) error {
-	// Lister et supprimer les sources
-	sources, err := s.ListJobSources(ctx, jobID)
-	if err == nil {
-		for _, filename := range sources {
-			path := fmt.Sprintf("sources/%s/%s", jobID.String(), filename)
-			s.storage.Delete(ctx, path) // Ignorer les erreurs de suppression
-		}
+	// Supprimer les sources
+	s.DeleteJobSources(ctx, jobID) // Ignorer les erreurs de suppression
+
+	// Supprimer les logs, dans les deux formats, compressés ou non
+	for _, format := range []string{LogFormatText, LogFormatJSONL} {
+		path := s.jobLogPath(ctx, jobID, format)
+		s.storage.Delete(ctx, path) // Ignorer les erreurs
+		s.storage.Delete(ctx, path+jobLogCompressedSuffix)
 	}
 
-	// Supprimer les logs
-	logPath := fmt.Sprintf("logs/%s/generation.log", jobID.String())
-	s.storage.Delete(ctx, logPath) // Ignorer les erreurs
-
 	return nil
 }
 
@@ -200,8 +819,8 @@ func (s *StorageService) ValidateFile(filePath string) error {
 
 	// Vérifier la profondeur
 	segments := strings.Split(strings.Trim(normalizedPath, "/"), "/")
-	if len(segments) > 10 {
-		return fmt.Errorf("path too deep (max 10 levels): %s", filePath)
+	if len(segments) > s.maxPathDepth {
+		return fmt.Errorf("path too deep (max %d levels): %s", s.maxPathDepth, filePath)
 	}
 
 	// Valider chaque segment
@@ -219,6 +838,7 @@ func (s *StorageService) ValidateFile(filePath string) error {
 				".svg": true, ".woff": true, ".woff2": true, ".ttf": true,
 				".eot": true, ".ico": true, ".txt": true, ".yml": true,
 				".yaml": true, ".html": true, ".vue": true, ".ts": true,
+				".scss": true, ".sass": true, ".less": true, ".postcss": true,
 			}
 
 			if ext != "" && !allowedExts[ext] {
@@ -0,0 +1,322 @@
+// internal/storage/service_test.go
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStorage est un backend en mémoire avec latence simulée
+type memoryStorage struct {
+	mu       sync.Mutex
+	files    map[string][]byte
+	latency  time.Duration
+	failPath string
+}
+
+func newMemoryStorage(latency time.Duration) *memoryStorage {
+	return &memoryStorage{files: make(map[string][]byte), latency: latency}
+}
+
+func (m *memoryStorage) Upload(ctx context.Context, path string, data io.Reader) error {
+	time.Sleep(m.latency)
+
+	if m.failPath != "" && strings.HasSuffix(path, m.failPath) {
+		return fmt.Errorf("simulated upload failure")
+	}
+
+	content, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[path] = content
+	return nil
+}
+
+func (m *memoryStorage) Download(ctx context.Context, path string) (io.Reader, error) {
+	time.Sleep(m.latency)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	content, exists := m.files[path]
+	if !exists {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+	return bytes.NewReader(content), nil
+}
+
+func (m *memoryStorage) Exists(ctx context.Context, path string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, exists := m.files[path]
+	return exists, nil
+}
+
+func (m *memoryStorage) Delete(ctx context.Context, path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, path)
+	return nil
+}
+
+func (m *memoryStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var files []string
+	for path := range m.files {
+		if strings.HasPrefix(path, prefix) {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
+
+func (m *memoryStorage) GetURL(ctx context.Context, path string) (string, error) {
+	return "/" + path, nil
+}
+
+// createFileHeaders construit de vrais FileHeader multipart à partir d'un formulaire encodé
+func createFileHeaders(t testing.TB, files map[string]string) []*multipart.FileHeader {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	for filePath, content := range files {
+		part, err := writer.CreateFormFile("files", filePath)
+		require.NoError(t, err)
+		_, err = part.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	reader := multipart.NewReader(body, writer.Boundary())
+	form, err := reader.ReadForm(32 << 20)
+	require.NoError(t, err)
+
+	// Le parsing multipart ne garde que le nom de base : restaurer le chemin complet
+	headers := form.File["files"]
+	for _, header := range headers {
+		for filePath := range files {
+			if strings.HasSuffix(filePath, header.Filename) {
+				header.Filename = filePath
+			}
+		}
+	}
+
+	return headers
+}
+
+func TestUploadJobSourcesConcurrent(t *testing.T) {
+	files := make(map[string]string)
+	for i := 0; i < 20; i++ {
+		files[fmt.Sprintf("dir%d/file%02d.md", i%3, i)] = fmt.Sprintf("# Content %d", i)
+	}
+
+	t.Run("Preserves paths and content", func(t *testing.T) {
+		backend := newMemoryStorage(0)
+		service := NewStorageService(backend)
+		service.SetUploadConcurrency(4)
+
+		jobID := uuid.New()
+		_, err := service.UploadJobSources(context.Background(), jobID, createFileHeaders(t, files))
+		require.NoError(t, err)
+
+		for filePath, content := range files {
+			reader, err := service.DownloadJobSource(context.Background(), jobID, filePath)
+			require.NoError(t, err)
+
+			data, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, content, string(data))
+		}
+	})
+
+	t.Run("Aggregates errors", func(t *testing.T) {
+		backend := newMemoryStorage(0)
+		backend.failPath = "file05.md"
+		service := NewStorageService(backend)
+
+		checksums, err := service.UploadJobSources(context.Background(), uuid.New(), createFileHeaders(t, files))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "file05.md")
+		assert.Len(t, checksums, len(files)-1)
+
+		// Les autres fichiers doivent avoir été uploadés
+		assert.Len(t, backend.files, len(files)-1)
+	})
+}
+
+// sizedMemoryStorage enregistre les tailles annoncées via UploadSized
+type sizedMemoryStorage struct {
+	*memoryStorage
+	sizes map[string]int64
+}
+
+func (m *sizedMemoryStorage) UploadSized(ctx context.Context, path string, data io.Reader, size int64) error {
+	m.mu.Lock()
+	m.sizes[path] = size
+	m.mu.Unlock()
+	return m.Upload(ctx, path, data)
+}
+
+func TestUploadSized(t *testing.T) {
+	ctx := context.Background()
+	files := map[string]string{
+		"slides.md":        "# Slides",
+		"styles/theme.css": "body { color: red; }",
+	}
+
+	t.Run("Known sizes reach the backend", func(t *testing.T) {
+		backend := &sizedMemoryStorage{memoryStorage: newMemoryStorage(0), sizes: make(map[string]int64)}
+		service := NewStorageService(NewInstrumentedStorage(backend, "memory", time.Hour))
+
+		jobID := uuid.New()
+		_, err := service.UploadJobSources(ctx, jobID, createFileHeaders(t, files))
+		require.NoError(t, err)
+		for filePath, content := range files {
+			assert.Equal(t, int64(len(content)), backend.sizes["sources/"+jobID.String()+"/"+filePath])
+		}
+
+		courseID := uuid.New()
+		require.NoError(t, service.UploadResultSized(ctx, courseID, "index.html", strings.NewReader("<html>"), 6))
+		assert.Equal(t, int64(6), backend.sizes["results/"+courseID.String()+"/index.html"])
+	})
+
+	t.Run("Falls back to Upload", func(t *testing.T) {
+		backend := newMemoryStorage(0)
+		service := NewStorageService(NewInstrumentedStorage(backend, "memory", time.Hour))
+
+		courseID := uuid.New()
+		require.NoError(t, service.UploadResultSized(ctx, courseID, "index.html", strings.NewReader("<html>"), 6))
+		assert.Equal(t, []byte("<html>"), backend.files["results/"+courseID.String()+"/index.html"])
+	})
+}
+
+// metadataMemoryStorage conserve les métadonnées passées à UploadWithMetadata
+type metadataMemoryStorage struct {
+	*memoryStorage
+	metadata map[string]map[string]string
+}
+
+func (m *metadataMemoryStorage) UploadWithMetadata(ctx context.Context, path string, data io.Reader, size int64, metadata map[string]string) error {
+	m.mu.Lock()
+	m.metadata[path] = metadata
+	m.mu.Unlock()
+	return m.Upload(ctx, path, data)
+}
+
+func (m *metadataMemoryStorage) GetMetadata(ctx context.Context, path string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.files[path]; !exists {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+	return m.metadata[path], nil
+}
+
+func TestJobSourceChecksums(t *testing.T) {
+	ctx := context.Background()
+	files := map[string]string{
+		"slides.md":        "# Slides",
+		"styles/theme.css": "body { color: red; }",
+	}
+	// sha256("# Slides")
+	expected := "sha256:3c9603b97dd9e0e026143ff466843dc97c2b2064e0ae50ae86a168bbb874eecd"
+
+	t.Run("Stored as object metadata", func(t *testing.T) {
+		backend := &metadataMemoryStorage{memoryStorage: newMemoryStorage(0), metadata: make(map[string]map[string]string)}
+		service := NewStorageService(NewInstrumentedStorage(backend, "memory", time.Hour))
+
+		jobID := uuid.New()
+		checksums, err := service.UploadJobSources(ctx, jobID, createFileHeaders(t, files))
+		require.NoError(t, err)
+		require.Len(t, checksums, len(files))
+		assert.Equal(t, expected, checksums["slides.md"])
+		assert.Equal(t, expected, backend.metadata["sources/"+jobID.String()+"/slides.md"][ChecksumMetadataKey])
+
+		// L'empreinte est lue dans les métadonnées sans relire le contenu
+		backend.metadata["sources/"+jobID.String()+"/slides.md"][ChecksumMetadataKey] = "sha256:stored"
+		checksum, err := service.JobSourceChecksum(ctx, jobID, "slides.md")
+		require.NoError(t, err)
+		assert.Equal(t, "sha256:stored", checksum)
+
+		_, err = service.JobSourceChecksum(ctx, jobID, "missing.md")
+		assert.Error(t, err)
+	})
+
+	t.Run("Computed when the backend has no metadata", func(t *testing.T) {
+		service := NewStorageService(newMemoryStorage(0))
+
+		jobID := uuid.New()
+		checksums, err := service.UploadJobSources(ctx, jobID, createFileHeaders(t, files))
+		require.NoError(t, err)
+
+		checksum, err := service.JobSourceChecksum(ctx, jobID, "slides.md")
+		require.NoError(t, err)
+		assert.Equal(t, checksums["slides.md"], checksum)
+
+		_, err = service.JobSourceChecksum(ctx, jobID, "missing.md")
+		assert.Error(t, err)
+	})
+}
+
+func TestSourceHash(t *testing.T) {
+	checksums := map[string]string{
+		"styles/theme.css": "sha256:b",
+		"slides.md":        "sha256:a",
+	}
+	// sha256("slides.md\nsha256:a\nstyles/theme.css\nsha256:b\n")
+	assert.Equal(t, "sha256:3b541e75236ae1efd0e453a90d0d435b44bdd8548c6603af2b7e13fa2c6c073b", SourceHash(checksums))
+
+	// Un fichier modifié, renommé ou ajouté change l'empreinte
+	assert.NotEqual(t, SourceHash(checksums), SourceHash(map[string]string{"slides.md": "sha256:a", "styles/theme.css": "sha256:c"}))
+	assert.NotEqual(t, SourceHash(checksums), SourceHash(map[string]string{"slides.md": "sha256:a", "theme.css": "sha256:b"}))
+	assert.NotEqual(t, SourceHash(checksums), SourceHash(map[string]string{"slides.md": "sha256:a"}))
+}
+
+func BenchmarkUploadJobSources(b *testing.B) {
+	files := make(map[string]string)
+	for i := 0; i < 50; i++ {
+		files[fmt.Sprintf("assets/image%02d.png", i)] = strings.Repeat("x", 4096)
+	}
+	headers := createFileHeaders(b, files)
+
+	for _, concurrency := range []int{1, 4, 8} {
+		b.Run(fmt.Sprintf("concurrency-%d", concurrency), func(b *testing.B) {
+			// Latence simulée d'un backend distant (S3/Garage)
+			service := NewStorageService(newMemoryStorage(time.Millisecond))
+			service.SetUploadConcurrency(concurrency)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := service.UploadJobSources(context.Background(), uuid.New(), headers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateFileMaxPathDepth(t *testing.T) {
+	service := NewStorageService(newMemoryStorage(0))
+	deepPath := strings.Repeat("dir/", 10) + "slides.md"
+
+	assert.NoError(t, service.ValidateFile(strings.Repeat("dir/", 9)+"slides.md"))
+	assert.Error(t, service.ValidateFile(deepPath), "depth 11 rejected at the default")
+
+	service.SetMaxPathDepth(12)
+	assert.NoError(t, service.ValidateFile(deepPath))
+}
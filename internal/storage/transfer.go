@@ -0,0 +1,161 @@
+// internal/storage/transfer.go - Comptage des octets transférés vers et depuis le stockage
+package storage
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+)
+
+// TransferCounter compte les octets uploadés et téléchargés, depuis le démarrage ou par
+// fenêtre : à la fin d'une fenêtre, les compteurs repartent de zéro et la fenêtre écoulée
+// reste consultable. Les compteurs sont atomiques, sans verrou sur le chemin des transferts.
+type TransferCounter struct {
+	window time.Duration // Durée d'une fenêtre (0 = cumul depuis le démarrage)
+
+	uploaded    atomic.Int64
+	downloaded  atomic.Int64
+	windowStart atomic.Int64 // Début de la fenêtre courante (UnixNano)
+
+	mu       sync.Mutex
+	previous *models.StorageTransferStats
+}
+
+// NewTransferCounter crée un compteur de transferts (window <= 0 = cumul depuis le démarrage)
+func NewTransferCounter(window time.Duration) *TransferCounter {
+	counter := &TransferCounter{window: max(window, 0)}
+	counter.windowStart.Store(time.Now().UnixNano())
+	return counter
+}
+
+// AddUploaded ajoute des octets uploadés
+func (t *TransferCounter) AddUploaded(n int64) {
+	t.rotate(time.Now())
+	t.uploaded.Add(n)
+}
+
+// AddDownloaded ajoute des octets téléchargés
+func (t *TransferCounter) AddDownloaded(n int64) {
+	t.rotate(time.Now())
+	t.downloaded.Add(n)
+}
+
+// Stats retourne les octets transférés dans la fenêtre courante et la fenêtre précédente
+func (t *TransferCounter) Stats() *models.StorageTransferStats {
+	t.rotate(time.Now())
+
+	stats := &models.StorageTransferStats{
+		UploadedBytes:   t.uploaded.Load(),
+		DownloadedBytes: t.downloaded.Load(),
+		Since:           time.Unix(0, t.windowStart.Load()),
+	}
+	if t.window > 0 {
+		stats.Window = t.window.String()
+
+		t.mu.Lock()
+		stats.Previous = t.previous
+		t.mu.Unlock()
+	}
+	return stats
+}
+
+// rotate termine la fenêtre courante si elle est écoulée. Les fenêtres restent alignées
+// sur le démarrage ; une fenêtre précédente sans transfert est rapportée à zéro.
+func (t *TransferCounter) rotate(now time.Time) {
+	if t.window <= 0 || now.UnixNano()-t.windowStart.Load() < int64(t.window) {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	start := t.windowStart.Load()
+	elapsed := now.UnixNano() - start
+	if elapsed < int64(t.window) {
+		return // Déjà terminée par un autre transfert
+	}
+
+	newStart := start + elapsed/int64(t.window)*int64(t.window)
+	uploaded, downloaded := t.uploaded.Swap(0), t.downloaded.Swap(0)
+	if newStart-start > int64(t.window) {
+		uploaded, downloaded = 0, 0
+	}
+	until := time.Unix(0, newStart)
+	t.previous = &models.StorageTransferStats{
+		UploadedBytes:   uploaded,
+		DownloadedBytes: downloaded,
+		Since:           until.Add(-t.window),
+		Until:           &until,
+	}
+	t.windowStart.Store(newStart)
+}
+
+// countingReader compte les octets lus d'un contenu en transit, sans le mettre en mémoire.
+// Un contenu relu après Seek (nouvelle tentative d'un upload) n'est compté qu'une fois.
+type countingReader struct {
+	reader  io.Reader
+	count   func(int64)
+	offset  int64
+	counted int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.offset += int64(n)
+	if r.offset > r.counted {
+		r.count(r.offset - r.counted)
+		r.counted = r.offset
+	}
+	return n, err
+}
+
+// countingReadSeeker garde Seek, dont le SDK S3 a besoin pour signer et rejouer un upload
+type countingReadSeeker struct {
+	*countingReader
+}
+
+func (r countingReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	position, err := r.reader.(io.Seeker).Seek(offset, whence)
+	if err == nil {
+		r.offset = position
+	}
+	return position, err
+}
+
+// countingReadCloser garde Close, appelé par les lecteurs des téléchargements
+type countingReadCloser struct {
+	*countingReader
+}
+
+func (r countingReadCloser) Close() error {
+	return r.reader.(io.Closer).Close()
+}
+
+// countingReadSeekCloser garde Seek et Close (fichiers du backend filesystem, uploads multipart)
+type countingReadSeekCloser struct {
+	countingReadSeeker
+}
+
+func (r countingReadSeekCloser) Close() error {
+	return r.reader.(io.Closer).Close()
+}
+
+// countTransfer enveloppe un contenu pour compter les octets lus, en conservant Seek et Close
+func countTransfer(reader io.Reader, count func(int64)) io.Reader {
+	counting := &countingReader{reader: reader, count: count}
+
+	_, seeker := reader.(io.Seeker)
+	_, closer := reader.(io.Closer)
+	switch {
+	case seeker && closer:
+		return countingReadSeekCloser{countingReadSeeker{counting}}
+	case seeker:
+		return countingReadSeeker{counting}
+	case closer:
+		return countingReadCloser{counting}
+	}
+	return counting
+}
@@ -0,0 +1,134 @@
+// internal/storage/transfer_test.go
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closingReader signale la fermeture d'un téléchargement
+type closingReader struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closingReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestTransferCounting(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Uploads and downloads", func(t *testing.T) {
+		service := NewStorageService(newMemoryStorage(0))
+		jobID, courseID := uuid.New(), uuid.New()
+
+		_, err := service.UploadJobSources(ctx, jobID, createFileHeaders(t, map[string]string{"slides.md": "# Slides"}))
+		require.NoError(t, err)
+		require.NoError(t, service.UploadResult(ctx, courseID, "index.html", strings.NewReader("<html></html>")))
+		require.NoError(t, service.UploadResultSized(ctx, courseID, "app.js", strings.NewReader("run()"), 5))
+
+		reader, err := service.DownloadResult(ctx, courseID, "index.html")
+		require.NoError(t, err)
+		partial := make([]byte, 6)
+		_, err = io.ReadFull(reader, partial)
+		require.NoError(t, err)
+
+		// Seuls les octets lus sont comptés
+		stats := service.TransferStats()
+		assert.Equal(t, int64(8+13+5), stats.UploadedBytes)
+		assert.Equal(t, int64(6), stats.DownloadedBytes)
+		assert.Empty(t, stats.Window)
+
+		_, err = io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, int64(13), service.TransferStats().DownloadedBytes)
+
+		_, err = service.DownloadResult(ctx, courseID, "missing.html")
+		assert.Error(t, err)
+	})
+
+	t.Run("Concurrent transfers", func(t *testing.T) {
+		service := NewStorageService(newMemoryStorage(0))
+		courseID := uuid.New()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, service.UploadResult(ctx, courseID, uuid.NewString(), strings.NewReader("0123456789")))
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int64(200), service.TransferStats().UploadedBytes)
+	})
+
+	t.Run("Keeps Seek and Close", func(t *testing.T) {
+		var counted int64
+		count := func(n int64) { counted += n }
+
+		seeker, ok := countTransfer(strings.NewReader("0123456789"), count).(io.ReadSeeker)
+		require.True(t, ok)
+		_, err := io.ReadAll(seeker)
+		require.NoError(t, err)
+
+		// Un contenu relu après Seek n'est compté qu'une fois
+		_, err = seeker.Seek(0, io.SeekStart)
+		require.NoError(t, err)
+		_, err = io.ReadAll(seeker)
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), counted)
+
+		download := &closingReader{Reader: strings.NewReader("abc")}
+		closer, ok := countTransfer(download, count).(io.ReadCloser)
+		require.True(t, ok)
+		_, isSeeker := closer.(io.Seeker)
+		assert.False(t, isSeeker)
+		require.NoError(t, closer.Close())
+		assert.True(t, download.closed)
+	})
+}
+
+func TestTransferCounterWindow(t *testing.T) {
+	counter := NewTransferCounter(time.Hour)
+	start := time.Unix(0, counter.windowStart.Load())
+
+	counter.AddUploaded(100)
+	counter.AddDownloaded(40)
+
+	stats := counter.Stats()
+	assert.Equal(t, "1h0m0s", stats.Window)
+	assert.Equal(t, int64(100), stats.UploadedBytes)
+	assert.Nil(t, stats.Previous)
+
+	// Fin de la première fenêtre : les compteurs repartent de zéro
+	counter.rotate(start.Add(90 * time.Minute))
+	counter.uploaded.Add(7)
+	stats = counter.Stats()
+	assert.Equal(t, int64(7), stats.UploadedBytes)
+	assert.Zero(t, stats.DownloadedBytes)
+	assert.True(t, stats.Since.Equal(start.Add(time.Hour)))
+	require.NotNil(t, stats.Previous)
+	assert.Equal(t, int64(100), stats.Previous.UploadedBytes)
+	assert.Equal(t, int64(40), stats.Previous.DownloadedBytes)
+	assert.True(t, stats.Previous.Since.Equal(start))
+	assert.True(t, stats.Previous.Until.Equal(start.Add(time.Hour)))
+
+	// Plusieurs fenêtres sans transfert : la fenêtre précédente est vide
+	counter.rotate(start.Add(5 * time.Hour))
+	stats = counter.Stats()
+	assert.Zero(t, stats.UploadedBytes)
+	assert.Zero(t, stats.Previous.UploadedBytes)
+	assert.True(t, stats.Since.Equal(start.Add(5*time.Hour)))
+}
@@ -27,11 +27,28 @@ type PaginationParams struct {
 }
 
 type ListJobsParams struct {
-	Status     string           `json:"status"`
-	CourseID   *uuid.UUID       `json:"course_id,omitempty"`
-	Pagination PaginationParams `json:"pagination"`
+	Status     string            `json:"status"`
+	CourseID   *uuid.UUID        `json:"course_id,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Pagination PaginationParams  `json:"pagination"`
 }
 
+// MetadataFilterPrefix est le préfixe des query params de filtrage par metadata (ex: ?meta.author=alice)
+const MetadataFilterPrefix = "meta."
+
+// maxMetadataFilters limite le nombre de filtres metadata par requête
+const maxMetadataFilters = 10
+
+// metadataKeyRegex restreint les clés de metadata filtrables
+var metadataKeyRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,100}$`)
+
+// LabelFilterParam est le query param de filtrage par label (ex: ?label=env:prod, répétable)
+const LabelFilterParam = "label"
+
+// maxLabelFilters limite le nombre de filtres label par requête
+const maxLabelFilters = 10
+
 // WorkspaceListParams contient les paramètres validés pour lister les workspaces
 type WorkspaceListParams struct {
 	Status     string           `json:"status"`
@@ -45,9 +62,25 @@ type WorkspaceCleanupParams struct {
 
 // NewAPIValidator crée un nouveau validateur d'API
 func NewAPIValidator(config *ValidationConfig) *APIValidator {
+	return NewAPIValidatorWithService(NewValidationService(config))
+}
+
+// NewAPIValidatorWithService crée un validateur d'API au-dessus d'un service de validation existant
+func NewAPIValidatorWithService(validationService *ValidationService) *APIValidator {
 	return &APIValidator{
-		validationService: NewValidationService(config),
+		validationService: validationService,
+	}
+}
+
+// LocalizeErrors traduit les messages d'erreurs dans la langue négociée avec un en-tête
+// Accept-Language, retournée avec les erreurs traduites
+func (av *APIValidator) LocalizeErrors(errors []*ValidationError, acceptLanguage string) (string, []*ValidationError) {
+	messages := av.validationService.config.Messages
+	if messages == nil {
+		messages = DefaultMessageCatalog()
 	}
+	locale := messages.Negotiate(acceptLanguage)
+	return locale, messages.Localize(errors, locale)
 }
 
 // ValidateGenerationRequest valide une requête de génération
@@ -75,6 +108,55 @@ func (av *APIValidator) ValidateGenerationRequest(req *models.GenerationRequest)
 		result.Errors = append(result.Errors, sourcePathResult.Errors...)
 	}
 
+	// Valider le fichier d'entrée
+	entryFileResult := av.validationService.ValidateEntryFile(req.EntryFile)
+	if !entryFileResult.Valid {
+		result.Valid = false
+		result.Errors = append(result.Errors, entryFileResult.Errors...)
+	}
+
+	// Valider le dossier de sortie du build
+	outputDirResult := av.validationService.ValidateOutputDir(req.OutputDir)
+	if !outputDirResult.Valid {
+		result.Valid = false
+		result.Errors = append(result.Errors, outputDirResult.Errors...)
+	}
+
+	// Valider le sort des sources après le build
+	sourceRetentionResult := av.validationService.ValidateSourceRetention(req.SourceRetention)
+	if !sourceRetentionResult.Valid {
+		result.Valid = false
+		result.Errors = append(result.Errors, sourceRetentionResult.Errors...)
+	}
+
+	// Valider la destination personnalisée des résultats
+	resultPrefixResult := av.validationService.ValidateResultPrefix(req.ResultPrefix)
+	if !resultPrefixResult.Valid {
+		result.Valid = false
+		result.Errors = append(result.Errors, resultPrefixResult.Errors...)
+	}
+
+	// Valider les options de build Slidev
+	buildFlagsResult := av.validationService.ValidateBuildFlags(req.BuildFlags)
+	if !buildFlagsResult.Valid {
+		result.Valid = false
+		result.Errors = append(result.Errors, buildFlagsResult.Errors...)
+	}
+
+	// Valider les thèmes du mode matrice
+	themesResult := av.validationService.ValidateThemes(req.Themes)
+	if !themesResult.Valid {
+		result.Valid = false
+		result.Errors = append(result.Errors, themesResult.Errors...)
+	}
+
+	// Valider les labels
+	labelsResult := av.validationService.ValidateLabels(req.Labels)
+	if !labelsResult.Valid {
+		result.Valid = false
+		result.Errors = append(result.Errors, labelsResult.Errors...)
+	}
+
 	// Valider Callback URL
 	callbackResult := av.validationService.ValidateCallbackURL(req.CallbackURL)
 	if !callbackResult.Valid {
@@ -92,11 +174,41 @@ func (av *APIValidator) ValidateGenerationRequest(req *models.GenerationRequest)
 	return result
 }
 
+// ValidateGenerationProfileRequest valide les options de build par défaut d'un cours
+func (av *APIValidator) ValidateGenerationProfileRequest(req *models.GenerationProfileRequest) *ValidationResult {
+	result := &ValidationResult{Valid: true}
+
+	for _, fieldResult := range []*ValidationResult{
+		av.validationService.ValidateEntryFile(req.EntryFile),
+		av.validationService.ValidateOutputDir(req.OutputDir),
+		av.validationService.ValidateSourceRetention(req.SourceRetention),
+		av.validationService.ValidateBuildFlags(req.BuildFlags),
+		av.validationService.ValidateThemes(req.Themes),
+	} {
+		if !fieldResult.Valid {
+			result.Valid = false
+			result.Errors = append(result.Errors, fieldResult.Errors...)
+		}
+	}
+
+	return result
+}
+
 // ValidateFileUpload valide un upload de fichiers
 func (av *APIValidator) ValidateFileUpload(files []*multipart.FileHeader) *ValidationResult {
 	return av.validationService.ValidateFiles(files)
 }
 
+// ValidateUploadLimits valide les limites globales d'un upload (nombre et taille totale)
+func (av *APIValidator) ValidateUploadLimits(files []*multipart.FileHeader) *ValidationResult {
+	return av.validationService.ValidateUploadLimits(files)
+}
+
+// ExtractSourceArchive extrait les fichiers d'une archive de sources en appliquant les limites d'upload
+func (av *APIValidator) ExtractSourceArchive(header *multipart.FileHeader) ([]*ArchiveEntry, *ValidationResult) {
+	return av.validationService.ExtractSourceArchive(header)
+}
+
 // ValidateJobIDParam valide un paramètre job_id depuis l'URL
 func (av *APIValidator) ValidateJobIDParam(jobIDStr string) (uuid.UUID, *ValidationResult) {
 	result := av.validationService.ValidateJobID(jobIDStr)
@@ -232,43 +344,28 @@ func (av *APIValidator) ValidateContentSafety(content []byte, filename string) *
 		result.AddError("content", filename, "content too large", "CONTENT_TOO_LARGE")
 	}
 
+	// Règle du dossier : refus, ou contenu binaire admis quelle que soit l'extension
+	directory, rule := av.validationService.config.directoryRule(filename)
+	if rule != nil && rule.Deny {
+		result.AddError("content", filename, fmt.Sprintf("files are not allowed in directory %s", directory), "DIRECTORY_NOT_ALLOWED")
+		return result
+	}
+
 	// Vérifications spécifiques par type de fichier
 	ext := strings.ToLower(filepath.Ext(filename))
 
-	switch ext {
-	case ".js":
-		// Vérifier les scripts potentiellement malveillants
-		contentStr := string(content)
-		if strings.Contains(contentStr, "eval(") ||
-			strings.Contains(contentStr, "Function(") ||
-			strings.Contains(contentStr, "setTimeout(") ||
-			strings.Contains(contentStr, "setInterval(") {
-			result.AddError("content", filename,
-				"JavaScript content contains potentially dangerous functions",
-				"DANGEROUS_JS_CONTENT")
-		}
-
-	case ".html":
-		// Vérifier les balises script
-		contentStr := string(content)
-		if strings.Contains(strings.ToLower(contentStr), "<script") {
-			result.AddError("content", filename,
-				"HTML content contains script tags",
-				"SCRIPT_TAGS_NOT_ALLOWED")
-		}
-
-	case ".md":
-		// Vérifier les liens externes suspects
-		contentStr := string(content)
-		if strings.Contains(contentStr, "javascript:") {
-			result.AddError("content", filename,
-				"Markdown content contains javascript: links",
-				"JAVASCRIPT_LINKS_NOT_ALLOWED")
+	// Motifs refusés pour l'extension (scripts malveillants, balises script, liens javascript:)
+	for _, pattern := range av.validationService.config.ContentPatterns[ext] {
+		if pattern.Pattern.Match(content) {
+			result.AddError("content", filename, pattern.Message, pattern.Code)
 		}
 	}
 
-	// Vérifier qu'il n'y a pas de caractères de contrôle dangereux
-	if ext != ".png" && ext != ".jpg" && ext != ".jpeg" && ext != ".gif" {
+	// Vérifier qu'il n'y a pas de caractères de contrôle dangereux. Comme le type MIME, cette
+	// vérification du type de contenu ne s'applique pas à un dossier binaire ; les motifs
+	// refusés, oui.
+	binary := rule != nil && rule.Binary
+	if !binary && ext != ".png" && ext != ".jpg" && ext != ".jpeg" && ext != ".gif" {
 		for i, b := range content {
 			if b < 32 && b != 9 && b != 10 && b != 13 { // Permettre tab, LF, CR
 				result.AddError("content", filename,
@@ -351,7 +448,7 @@ func (av *APIValidator) ValidatePaginationParams(limitStr, offsetStr string) (*P
 }
 
 // ValidateListJobsParams valide tous les paramètres pour ListJobs
-func (av *APIValidator) ValidateListJobsParams(statusParam, courseIDParam, limitParam, offsetParam string) (*ListJobsParams, *ValidationResult) {
+func (av *APIValidator) ValidateListJobsParams(statusParam, courseIDParam, limitParam, offsetParam string, metadataParams map[string]string, labelParams []string) (*ListJobsParams, *ValidationResult) {
 	result := &ValidationResult{Valid: true}
 
 	// Valider le status
@@ -380,15 +477,98 @@ func (av *APIValidator) ValidateListJobsParams(statusParam, courseIDParam, limit
 		result.Errors = append(result.Errors, paginationResult.Errors...)
 	}
 
+	// Valider les filtres metadata
+	metadataResult := av.ValidateMetadataFilters(metadataParams)
+	if !metadataResult.Valid {
+		result.Valid = false
+		result.Errors = append(result.Errors, metadataResult.Errors...)
+	}
+
+	// Valider les filtres label
+	labels, labelResult := av.ValidateLabelFilters(labelParams)
+	if !labelResult.Valid {
+		result.Valid = false
+		result.Errors = append(result.Errors, labelResult.Errors...)
+	}
+
 	params := &ListJobsParams{
 		Status:     statusParam,
 		CourseID:   courseID,
+		Metadata:   metadataParams,
+		Labels:     labels,
 		Pagination: *pagination,
 	}
 
 	return params, result
 }
 
+// ValidateMetadataFilters valide les filtres metadata (clés sans le préfixe "meta.")
+func (av *APIValidator) ValidateMetadataFilters(filters map[string]string) *ValidationResult {
+	result := &ValidationResult{Valid: true}
+
+	if len(filters) > maxMetadataFilters {
+		result.AddError("metadata", fmt.Sprintf("%d filters", len(filters)),
+			fmt.Sprintf("too many metadata filters (max %d)", maxMetadataFilters), "TOO_MANY_METADATA_FILTERS")
+		return result
+	}
+
+	for key, value := range filters {
+		if !metadataKeyRegex.MatchString(key) {
+			result.AddError(MetadataFilterPrefix+key, key,
+				"metadata filter key must contain only letters, numbers, hyphens and underscores (max 100 characters)",
+				"INVALID_METADATA_KEY")
+			continue
+		}
+
+		if len(value) > 1000 {
+			result.AddError(MetadataFilterPrefix+key, key,
+				fmt.Sprintf("metadata filter value too long (max 1000 characters) for key: %s", key),
+				"VALUE_TOO_LONG")
+		}
+	}
+
+	return result
+}
+
+// ValidateLabelFilters valide les filtres label au format clé:valeur et les regroupe par clé
+func (av *APIValidator) ValidateLabelFilters(filters []string) (map[string]string, *ValidationResult) {
+	result := &ValidationResult{Valid: true}
+
+	if len(filters) == 0 {
+		return nil, result
+	}
+	if len(filters) > maxLabelFilters {
+		result.AddError(LabelFilterParam, fmt.Sprintf("%d filters", len(filters)),
+			fmt.Sprintf("too many label filters (max %d)", maxLabelFilters), "TOO_MANY_LABEL_FILTERS")
+		return nil, result
+	}
+
+	labels := make(map[string]string, len(filters))
+	for _, filter := range filters {
+		key, value, found := strings.Cut(filter, ":")
+		if !found {
+			result.AddError(LabelFilterParam, filter, "label filter must use the key:value format", "INVALID_LABEL_FILTER")
+			continue
+		}
+
+		labelResult := av.validationService.ValidateLabel(key, value)
+		if !labelResult.Valid {
+			result.Valid = false
+			result.Errors = append(result.Errors, labelResult.Errors...)
+			continue
+		}
+
+		if existing, exists := labels[key]; exists && existing != value {
+			result.AddError(LabelFilterParam, filter,
+				fmt.Sprintf("conflicting values for label %s", key), "INVALID_LABEL_FILTER")
+			continue
+		}
+		labels[key] = value
+	}
+
+	return labels, result
+}
+
 // ValidateWorkspaceListParams valide les paramètres de listing des workspaces
 func (av *APIValidator) ValidateWorkspaceListParams(statusParam, limitParam, offsetParam string) (*WorkspaceListParams, *ValidationResult) {
 	result := &ValidationResult{Valid: true}
@@ -400,9 +580,9 @@ func (av *APIValidator) ValidateWorkspaceListParams(statusParam, limitParam, off
 		result.Errors = append(result.Errors, paginationResult.Errors...)
 	}
 
-	// Valider le status optionnel (peut être "active", "idle", "stopped", etc.)
+	// Valider le status optionnel (état du workspace : active, idle ou completed)
 	if statusParam != "" {
-		validStatuses := []string{"active", "idle", "stopped", "busy"}
+		validStatuses := []string{"active", "idle", "completed"}
 		isValid := false
 		for _, validStatus := range validStatuses {
 			if statusParam == validStatus {
@@ -413,7 +593,7 @@ func (av *APIValidator) ValidateWorkspaceListParams(statusParam, limitParam, off
 
 		if !isValid {
 			result.AddError("status", statusParam,
-				"invalid workspace status (must be: active, idle, stopped, busy)",
+				"invalid workspace status (must be: active, idle, completed)",
 				"INVALID_WORKSPACE_STATUS")
 		}
 	}
@@ -492,7 +672,7 @@ func (av *APIValidator) SanitizeFilePath(filePath string) string {
 	cleanPath := strings.Join(cleanSegments, "/")
 
 	// Limiter la profondeur des dossiers
-	const maxDepth = 10
+	maxDepth := av.validationService.config.maxPathDepth()
 	if len(cleanSegments) > maxDepth {
 		cleanSegments = cleanSegments[len(cleanSegments)-maxDepth:]
 		cleanPath = strings.Join(cleanSegments, "/")
@@ -525,6 +705,12 @@ func (av *APIValidator) ValidateFilePath(filePath string) *ValidationResult {
 	normalizedPath := filepath.ToSlash(filePath)
 	segments := strings.Split(strings.TrimPrefix(normalizedPath, "/"), "/")
 
+	// Règle du dossier du fichier (nil = validation uniforme)
+	ruleDirectory, rule := av.validationService.config.directoryRule(normalizedPath)
+	if rule != nil && rule.Deny {
+		result.AddError("file_path", filePath, fmt.Sprintf("files are not allowed in directory %s", ruleDirectory), "DIRECTORY_NOT_ALLOWED")
+	}
+
 	// Vérifier chaque segment
 	directory := true
 	for i, segment := range segments {
@@ -537,7 +723,7 @@ func (av *APIValidator) ValidateFilePath(filePath string) *ValidationResult {
 		}
 
 		// Valider chaque segment comme un nom de fichier/dossier
-		segmentResult := av.validationService.ValidateFilename(segment, directory)
+		segmentResult := av.validationService.validateFilename(segment, directory, rule)
 		if !segmentResult.Valid {
 			result.Valid = false
 			for _, err := range segmentResult.Errors {
@@ -548,8 +734,18 @@ func (av *APIValidator) ValidateFilePath(filePath string) *ValidationResult {
 	}
 
 	// Vérifier la profondeur
-	if len(segments) > 10 {
-		result.AddError("file_path", filePath, "path too deep (max 10 levels)", "PATH_TOO_DEEP")
+	if maxDepth := av.validationService.config.maxPathDepth(); len(segments) > maxDepth {
+		result.AddError("file_path", filePath, fmt.Sprintf("path too deep (max %d levels)", maxDepth), "PATH_TOO_DEEP")
+	}
+
+	// Vérifier que la clé de stockage (sources/{job_id}/{chemin}) et celle de la variante
+	// compressée du résultat restent dans la limite du backend
+	storagePath := strings.TrimPrefix(normalizedPath, "/")
+	if maxLength := av.validationService.config.maxStorageKeyPathLength(); len(storagePath) > maxLength {
+		result.AddError("file_path", filePath,
+			fmt.Sprintf("path too long for a storage key: %d bytes once prefixed, backend limit is %d (max %d bytes for the path)",
+				len(storagePath)+MaxStorageKeyLength-maxLength, MaxStorageKeyLength, maxLength),
+			"STORAGE_KEY_TOO_LONG")
 	}
 
 	return result
@@ -0,0 +1,358 @@
+// internal/validation/archive.go - Extraction sûre des archives (.zip, .tar.gz)
+package validation
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultMaxArchiveExpansionRatio est le rapport maximal par défaut entre la taille
+// décompressée d'une archive de sources et sa taille
+const DefaultMaxArchiveExpansionRatio = 100
+
+// Formats d'archive acceptés
+const (
+	ArchiveFormatZip   = "zip"
+	ArchiveFormatTarGz = "tar.gz"
+)
+
+// tarEntryOverhead majore les octets d'une archive tar qui ne sont pas du contenu :
+// en-tête de 512 octets et remplissage de chaque entrée, dossiers, fin d'archive
+const tarEntryOverhead = 2 * 512
+
+// windowsVolumePattern reconnaît un chemin Windows absolu ("C:/...")
+var windowsVolumePattern = regexp.MustCompile(`^[A-Za-z]:`)
+
+// ArchiveEntry est un fichier extrait d'une archive
+type ArchiveEntry struct {
+	Path    string // Chemin dans l'archive ("assets/logo.png")
+	Content []byte
+}
+
+// ArchiveLimits borne l'extraction d'une archive contre les bombes de décompression.
+// Toutes les limites sont requises : une limite nulle refuse tout contenu.
+type ArchiveLimits struct {
+	MaxEntries        int   // Nombre max d'entrées hors dossiers, refusées comprises
+	MaxEntrySize      int64 // Taille décompressée max d'un fichier
+	MaxTotalSize      int64 // Taille de l'archive et taille décompressée totale max
+	MaxExpansionRatio int64 // Taille décompressée max, en multiple de la taille de l'archive
+}
+
+// errArchiveLimit interrompt l'extraction d'une archive qui dépasse une limite
+var errArchiveLimit = errors.New("archive limit exceeded")
+
+// maxArchiveExpansionRatio retourne le rapport d'expansion max configuré, ou la valeur par défaut
+func (c *ValidationConfig) maxArchiveExpansionRatio() int64 {
+	if c.MaxArchiveExpansionRatio <= 0 {
+		return DefaultMaxArchiveExpansionRatio
+	}
+	return int64(c.MaxArchiveExpansionRatio)
+}
+
+// archiveLimits retourne les limites d'extraction d'une archive de sources : celles d'un upload
+func (c *ValidationConfig) archiveLimits() ArchiveLimits {
+	return ArchiveLimits{
+		MaxEntries:        c.MaxFiles,
+		MaxEntrySize:      c.MaxFileSize,
+		MaxTotalSize:      c.MaxTotalSize,
+		MaxExpansionRatio: c.maxArchiveExpansionRatio(),
+	}
+}
+
+// ArchiveFormat retourne le format d'une archive d'après son nom (ArchiveFormatZip,
+// ArchiveFormatTarGz), ou "" pour un format non supporté
+func ArchiveFormat(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return ArchiveFormatZip
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return ArchiveFormatTarGz
+	}
+	return ""
+}
+
+// ExtractSourceArchive extrait les fichiers d'une archive de sources .zip ou .tar.gz avec
+// ExtractArchive, sous les limites d'upload (MaxFiles, MaxFileSize, MaxTotalSize,
+// MaxArchiveExpansionRatio), puis applique la politique des fichiers vides.
+func (vs *ValidationService) ExtractSourceArchive(header *multipart.FileHeader) ([]*ArchiveEntry, *ValidationResult) {
+	format := ArchiveFormat(header.Filename)
+	if format == "" {
+		result := &ValidationResult{Valid: true}
+		result.AddError("archive", header.Filename, "unsupported archive format (expected .zip, .tar.gz or .tgz)", "UNSUPPORTED_ARCHIVE_FORMAT")
+		return nil, result
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		result := &ValidationResult{Valid: true}
+		result.AddError("archive", header.Filename, fmt.Sprintf("failed to open archive: %v", err), "INVALID_ARCHIVE")
+		return nil, result
+	}
+	defer file.Close()
+
+	entries, result := ExtractArchive(file, header.Size, format, vs.config.archiveLimits())
+	for _, entry := range entries {
+		if len(entry.Content) == 0 && vs.config.RejectEmptyFiles &&
+			!vs.config.EmptyFileExtensions[strings.ToLower(path.Ext(entry.Path))] {
+			result.AddError("file_size", "0", fmt.Sprintf("file %v is empty", entry.Path), "EMPTY_FILE")
+		}
+	}
+
+	if !result.Valid {
+		return nil, result
+	}
+	return entries, result
+}
+
+// ExtractArchive extrait en mémoire les fichiers d'une archive zip ou tar.gz ; c'est le
+// point d'entrée de toute lecture d'archive dans le worker. Les tailles annoncées par
+// l'archive ne sont pas prises en compte : l'extraction s'arrête dès qu'une limite est
+// dépassée, et le flux tar décompressé est borné même pour les entrées ignorées. Les
+// dossiers et les métadonnées macOS (__MACOSX/, .DS_Store) sont ignorés ; les liens, les
+// fichiers spéciaux ou creux et les chemins absolus ou remontant hors de l'archive sont
+// refusés. En cas d'erreur, aucune entrée n'est retournée : rien n'a encore été écrit.
+func ExtractArchive(archive io.ReaderAt, size int64, format string, limits ArchiveLimits) ([]*ArchiveEntry, *ValidationResult) {
+	result := &ValidationResult{Valid: true}
+
+	if size > limits.MaxTotalSize {
+		result.AddError("archive", fmt.Sprintf("%d", size),
+			fmt.Sprintf("archive too large (max %d bytes)", limits.MaxTotalSize), "ARCHIVE_TOO_LARGE")
+		return nil, result
+	}
+
+	extractor := &archiveExtractor{
+		limits:       limits,
+		result:       result,
+		seen:         make(map[string]bool),
+		maxExpansion: size * limits.MaxExpansionRatio,
+	}
+
+	var err error
+	switch format {
+	case ArchiveFormatZip:
+		err = extractor.extractZip(archive, size)
+	case ArchiveFormatTarGz:
+		err = extractor.extractTarGz(io.NewSectionReader(archive, 0, size))
+	default:
+		result.AddError("archive", format, "unsupported archive format (expected .zip, .tar.gz or .tgz)", "UNSUPPORTED_ARCHIVE_FORMAT")
+		return nil, result
+	}
+	if err != nil && !errors.Is(err, errArchiveLimit) {
+		result.AddError("archive", "", fmt.Sprintf("invalid archive: %v", err), "INVALID_ARCHIVE")
+	}
+
+	if result.Valid && len(extractor.entries) == 0 {
+		result.AddError("archive", "", "archive contains no files", "EMPTY_ARCHIVE")
+	}
+	if !result.Valid {
+		return nil, result
+	}
+	return extractor.entries, result
+}
+
+// archiveExtractor accumule les fichiers d'une archive en vérifiant ses limites
+type archiveExtractor struct {
+	limits       ArchiveLimits
+	result       *ValidationResult
+	entries      []*ArchiveEntry
+	seen         map[string]bool
+	count        int   // Entrées lues, hors dossiers
+	total        int64 // Octets décompressés des fichiers extraits
+	maxExpansion int64 // Taille décompressée max selon le rapport d'expansion
+}
+
+// extractZip extrait une archive zip. Des entrées qui partagent les mêmes données
+// compressées (zip « superposé ») sont décompressées et comptées chacune.
+func (x *archiveExtractor) extractZip(file io.ReaderAt, size int64) error {
+	reader, err := zip.NewReader(file, size)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range reader.File {
+		if err := x.add(entry.Name, entry.Mode(), entry.Open); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractTarGz extrait une archive tar compressée en gzip. Le flux décompressé, en-têtes
+// compris, est borné : une entrée ignorée ou refusée est lue pour passer à la suivante.
+func (x *archiveExtractor) extractTarGz(file io.Reader) error {
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+
+	sizeLimit := x.limits.MaxTotalSize + int64(x.limits.MaxEntries+1)*tarEntryOverhead
+	stream := &boundedReader{reader: gzipReader, remaining: min(x.maxExpansion, sizeLimit)}
+	reader := tar.NewReader(stream)
+
+	for {
+		entry, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return x.checkStream(stream, x.maxExpansion < sizeLimit, err)
+		}
+
+		mode := entry.FileInfo().Mode()
+		switch {
+		case entry.Typeflag == tar.TypeXGlobalHeader:
+			continue // En-tête pax global (git archive), sans fichier
+		case entry.Typeflag == tar.TypeLink, entry.Typeflag == tar.TypeGNUSparse, isSparsePAX(entry.PAXRecords):
+			// Lien physique, ou fichier creux dont les trous ne sont pas dans le flux
+			mode = fs.ModeIrregular
+		}
+
+		open := func() (io.ReadCloser, error) { return io.NopCloser(reader), nil }
+		if err := x.add(entry.Name, mode, open); err != nil {
+			return x.checkStream(stream, x.maxExpansion < sizeLimit, err)
+		}
+	}
+}
+
+// isSparsePAX indique si les en-têtes PAX d'une entrée décrivent un fichier creux
+func isSparsePAX(records map[string]string) bool {
+	for key := range records {
+		if strings.HasPrefix(key, "GNU.sparse.") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkStream traduit le dépassement de la borne du flux décompressé en erreur de
+// validation, selon la limite qui fixe la borne (rapport d'expansion ou taille totale)
+func (x *archiveExtractor) checkStream(stream *boundedReader, expansionBound bool, err error) error {
+	if !stream.exceeded {
+		return err
+	}
+	if expansionBound {
+		x.addExpansionError()
+	} else {
+		x.addTotalSizeError()
+	}
+	return errArchiveLimit
+}
+
+// addExpansionError signale une archive dont la taille décompressée dépasse le rapport d'expansion
+func (x *archiveExtractor) addExpansionError() {
+	x.result.AddError("archive", "", fmt.Sprintf("archive expands beyond %d times its size",
+		x.limits.MaxExpansionRatio), "ARCHIVE_EXPANSION_TOO_LARGE")
+}
+
+// addTotalSizeError signale une archive dont la taille décompressée dépasse la taille totale max
+func (x *archiveExtractor) addTotalSizeError() {
+	x.result.AddError("archive", "", fmt.Sprintf("uncompressed archive too large (max %d bytes)",
+		x.limits.MaxTotalSize), "TOTAL_SIZE_TOO_LARGE")
+}
+
+// add extrait une entrée de l'archive. Une entrée refusée est signalée sans interrompre
+// l'extraction ; le dépassement d'une limite globale l'interrompt (errArchiveLimit).
+func (x *archiveExtractor) add(name string, mode fs.FileMode, open func() (io.ReadCloser, error)) error {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if mode.IsDir() || strings.HasPrefix(name, "__MACOSX/") || path.Base(name) == ".DS_Store" {
+		return nil
+	}
+
+	// Toute entrée hors dossier compte, même refusée, pour borner le travail et les erreurs
+	x.count++
+	if x.count > x.limits.MaxEntries {
+		x.result.AddError("archive", "", fmt.Sprintf("too many files in archive (max %d)", x.limits.MaxEntries), "TOO_MANY_FILES")
+		return errArchiveLimit
+	}
+
+	if !mode.IsRegular() {
+		x.result.AddError("archive", name, "only regular files are allowed in an archive", "UNSUPPORTED_ARCHIVE_ENTRY")
+		return nil
+	}
+	if !isSafeArchivePath(name) {
+		x.result.AddError("archive", name, "archive entry path escapes the extraction directory", "PATH_TRAVERSAL")
+		return nil
+	}
+
+	name = path.Clean(name)
+	if x.seen[name] {
+		x.result.AddError("archive", name, fmt.Sprintf("duplicate file %s in archive", name), "DUPLICATE_FILENAME")
+		return nil
+	}
+	x.seen[name] = true
+
+	reader, err := open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// Lire au plus un octet de plus que la limite, quelle que soit la taille annoncée
+	content, err := io.ReadAll(io.LimitReader(reader, x.limits.MaxEntrySize+1))
+	if err != nil {
+		return err
+	}
+
+	x.total += int64(len(content))
+	if x.total > x.limits.MaxTotalSize {
+		x.addTotalSizeError()
+		return errArchiveLimit
+	}
+	if x.total > x.maxExpansion {
+		x.addExpansionError()
+		return errArchiveLimit
+	}
+
+	if int64(len(content)) > x.limits.MaxEntrySize {
+		x.result.AddError("file_size", name,
+			fmt.Sprintf("file %v too large (max %d bytes)", name, x.limits.MaxEntrySize), "FILE_TOO_LARGE")
+		return nil
+	}
+
+	x.entries = append(x.entries, &ArchiveEntry{Path: name, Content: content})
+	return nil
+}
+
+// isSafeArchivePath indique si le chemin d'une entrée reste sous le dossier d'extraction :
+// ni absolu (y compris "C:"), ni remontant d'un dossier, ni octet nul
+func isSafeArchivePath(name string) bool {
+	return name != "" && !path.IsAbs(name) && !windowsVolumePattern.MatchString(name) &&
+		!strings.ContainsRune(name, 0) && !slices.Contains(strings.Split(name, "/"), "..")
+}
+
+// boundedReader lit au plus remaining octets ; au-delà, la lecture échoue
+type boundedReader struct {
+	reader    io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (r *boundedReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		// Vérifier qu'il reste des données avant de conclure au dépassement
+		var probe [1]byte
+		if n, _ := r.reader.Read(probe[:]); n > 0 {
+			r.exceeded = true
+			return 0, errArchiveLimit
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,299 @@
+// internal/validation/archive_test.go
+package validation
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"hash/crc32"
+	"mime/multipart"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// zipArchive construit une archive zip ; un chemin terminé par "/" est un dossier
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for name, content := range files {
+		entry, err := writer.Create(name)
+		require.NoError(t, err)
+		_, err = entry.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+// tarGzArchive construit une archive tar.gz à partir d'en-têtes et de contenus
+func tarGzArchive(t *testing.T, headers []*tar.Header, contents []string) []byte {
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	writer := tar.NewWriter(gzipWriter)
+	for i, header := range headers {
+		if header.Typeflag == tar.TypeReg {
+			header.Size = int64(len(contents[i]))
+			header.Mode = 0o644
+		}
+		require.NoError(t, writer.WriteHeader(header))
+		_, err := writer.Write([]byte(contents[i]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	require.NoError(t, gzipWriter.Close())
+	return buffer.Bytes()
+}
+
+// archiveFileHeader retourne l'en-tête multipart d'une archive uploadée
+func archiveFileHeader(t *testing.T, filename string, archive []byte) *multipart.FileHeader {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("archive", filename)
+	require.NoError(t, err)
+	_, err = part.Write(archive)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	form, err := multipart.NewReader(body, writer.Boundary()).ReadForm(32 << 20)
+	require.NoError(t, err)
+	return form.File["archive"][0]
+}
+
+func TestExtractSourceArchive(t *testing.T) {
+	extract := func(t *testing.T, config *ValidationConfig, filename string, archive []byte) ([]*ArchiveEntry, *ValidationResult) {
+		return NewValidationService(config).ExtractSourceArchive(archiveFileHeader(t, filename, archive))
+	}
+	codes := func(result *ValidationResult) []string {
+		var codes []string
+		for _, err := range result.Errors {
+			codes = append(codes, err.Code)
+		}
+		return codes
+	}
+	paths := func(entries []*ArchiveEntry) map[string]string {
+		files := make(map[string]string)
+		for _, entry := range entries {
+			files[entry.Path] = string(entry.Content)
+		}
+		return files
+	}
+
+	t.Run("Zip", func(t *testing.T) {
+		archive := zipArchive(t, map[string]string{
+			"slides.md":                 "# Slides",
+			"assets/":                   "",
+			"assets/style.css":          "body {}",
+			"__MACOSX/._slides.md":      "metadata",
+			"assets/.DS_Store":          "metadata",
+			"components/Counter.vue":    "<template></template>",
+			"./components/Unused.vue":   "<template></template>",
+			"components/sub/../Box.vue": "",
+		})
+		entries, result := extract(t, DefaultValidationConfig(), "course.zip", archive)
+		assert.Equal(t, []string{"PATH_TRAVERSAL"}, codes(result))
+		assert.Nil(t, entries)
+
+		archive = zipArchive(t, map[string]string{
+			"slides.md":               "# Slides",
+			"assets/":                 "",
+			"assets/style.css":        "body {}",
+			"__MACOSX/._slides.md":    "metadata",
+			"./components/Button.vue": "<template></template>",
+		})
+		entries, result = extract(t, DefaultValidationConfig(), "COURSE.ZIP", archive)
+		require.True(t, result.Valid, result.Errors)
+		assert.Equal(t, map[string]string{
+			"slides.md":             "# Slides",
+			"assets/style.css":      "body {}",
+			"components/Button.vue": "<template></template>",
+		}, paths(entries))
+	})
+
+	t.Run("Tar.gz", func(t *testing.T) {
+		archive := tarGzArchive(t, []*tar.Header{
+			{Typeflag: tar.TypeXGlobalHeader, Name: "pax_global_header", PAXRecords: map[string]string{"comment": "git"}},
+			{Typeflag: tar.TypeDir, Name: "./", Mode: 0o755},
+			{Typeflag: tar.TypeReg, Name: "./slides.md"},
+			{Typeflag: tar.TypeReg, Name: "public/logo.svg"},
+		}, []string{"", "", "# Slides", "<svg/>"})
+
+		entries, result := extract(t, DefaultValidationConfig(), "course.tgz", archive)
+		require.True(t, result.Valid, result.Errors)
+		assert.Equal(t, map[string]string{"slides.md": "# Slides", "public/logo.svg": "<svg/>"}, paths(entries))
+	})
+
+	t.Run("Links and traversal are rejected", func(t *testing.T) {
+		archive := tarGzArchive(t, []*tar.Header{
+			{Typeflag: tar.TypeSymlink, Name: "secret.md", Linkname: "/etc/passwd"},
+			{Typeflag: tar.TypeLink, Name: "copy.md", Linkname: "slides.md"},
+			{Typeflag: tar.TypeReg, Name: "../outside.md"},
+			{Typeflag: tar.TypeReg, Name: "/etc/cron.d/job.md"},
+			{Typeflag: tar.TypeReg, Name: "slides.md"},
+		}, []string{"", "", "x", "x", "# Slides"})
+
+		entries, result := extract(t, DefaultValidationConfig(), "course.tar.gz", archive)
+		assert.Nil(t, entries)
+		assert.Equal(t, []string{"UNSUPPORTED_ARCHIVE_ENTRY", "UNSUPPORTED_ARCHIVE_ENTRY", "PATH_TRAVERSAL", "PATH_TRAVERSAL"}, codes(result))
+	})
+
+	t.Run("Expansion ratio", func(t *testing.T) {
+		// Contenu très compressible : 1MB de zéros tient dans quelques kilo-octets
+		bomb := map[string]string{"slides.md": strings.Repeat("0", 1024*1024)}
+
+		_, result := extract(t, DefaultValidationConfig(), "bomb.zip", zipArchive(t, bomb))
+		assert.Equal(t, []string{"ARCHIVE_EXPANSION_TOO_LARGE"}, codes(result))
+
+		archive := tarGzArchive(t, []*tar.Header{{Typeflag: tar.TypeReg, Name: "slides.md"}}, []string{bomb["slides.md"]})
+		_, result = extract(t, DefaultValidationConfig(), "bomb.tar.gz", archive)
+		assert.Equal(t, []string{"ARCHIVE_EXPANSION_TOO_LARGE"}, codes(result))
+
+		// Un rapport plus grand accepte la même archive
+		config := DefaultValidationConfig()
+		config.MaxArchiveExpansionRatio = 10000
+		entries, result := extract(t, config, "bomb.zip", zipArchive(t, bomb))
+		require.True(t, result.Valid, result.Errors)
+		assert.Len(t, entries[0].Content, 1024*1024)
+	})
+
+	t.Run("Upload limits", func(t *testing.T) {
+		config := DefaultValidationConfig()
+		config.MaxFiles = 2
+		config.MaxFileSize = 4096
+		config.MaxTotalSize = 6000
+		config.MaxArchiveExpansionRatio = 10000
+
+		_, result := extract(t, config, "course.zip", zipArchive(t, map[string]string{"a.md": "a", "b.md": "b", "c.md": "c"}))
+		assert.Equal(t, []string{"TOO_MANY_FILES"}, codes(result))
+
+		_, result = extract(t, config, "course.zip", zipArchive(t, map[string]string{"a.md": strings.Repeat("a", 4097)}))
+		assert.Equal(t, []string{"FILE_TOO_LARGE"}, codes(result))
+
+		_, result = extract(t, config, "course.zip", zipArchive(t, map[string]string{
+			"a.md": strings.Repeat("a", 3500), "b.md": strings.Repeat("b", 3500),
+		}))
+		assert.Equal(t, []string{"TOTAL_SIZE_TOO_LARGE"}, codes(result))
+
+		// Flux tar borné même pour une entrée ignorée
+		archive := tarGzArchive(t, []*tar.Header{{Typeflag: tar.TypeReg, Name: "__MACOSX/._a.md"}}, []string{strings.Repeat("x", 16384)})
+		_, result = extract(t, config, "course.tgz", archive)
+		assert.Equal(t, []string{"TOTAL_SIZE_TOO_LARGE"}, codes(result))
+
+		config.RejectEmptyFiles = true
+		_, result = extract(t, config, "course.zip", zipArchive(t, map[string]string{"a.md": ""}))
+		assert.Equal(t, []string{"EMPTY_FILE"}, codes(result))
+	})
+
+	t.Run("Invalid archives", func(t *testing.T) {
+		_, result := extract(t, DefaultValidationConfig(), "course.rar", []byte("Rar!"))
+		assert.Equal(t, []string{"UNSUPPORTED_ARCHIVE_FORMAT"}, codes(result))
+
+		_, result = extract(t, DefaultValidationConfig(), "course.zip", []byte("not a zip"))
+		assert.Equal(t, []string{"INVALID_ARCHIVE"}, codes(result))
+
+		_, result = extract(t, DefaultValidationConfig(), "course.tar.gz", []byte("not gzip"))
+		assert.Equal(t, []string{"INVALID_ARCHIVE"}, codes(result))
+
+		_, result = extract(t, DefaultValidationConfig(), "course.zip", zipArchive(t, map[string]string{"assets/": ""}))
+		assert.Equal(t, []string{"EMPTY_ARCHIVE"}, codes(result))
+
+		config := DefaultValidationConfig()
+		config.MaxTotalSize = 16
+		_, result = extract(t, config, "course.zip", zipArchive(t, map[string]string{"slides.md": "# Slides"}))
+		assert.Equal(t, []string{"ARCHIVE_TOO_LARGE"}, codes(result))
+	})
+}
+
+func TestExtractArchiveMaliciousArchives(t *testing.T) {
+	limits := ArchiveLimits{MaxEntries: 10, MaxEntrySize: 64 * 1024, MaxTotalSize: 256 * 1024, MaxExpansionRatio: 100}
+	extract := func(format string, archive []byte) ([]*ArchiveEntry, *ValidationResult) {
+		return ExtractArchive(bytes.NewReader(archive), int64(len(archive)), format, limits)
+	}
+	firstCode := func(t *testing.T, result *ValidationResult) string {
+		require.False(t, result.Valid)
+		return result.Errors[0].Code
+	}
+
+	t.Run("Absolute and escaping paths", func(t *testing.T) {
+		for _, name := range []string{"C:/Windows/evil.md", "c:evil.md", "/etc/passwd.md", "a/../../evil.md", "..\\evil.md", "evil\x00.md"} {
+			entries, result := extract(ArchiveFormatZip, zipArchive(t, map[string]string{name: "# Evil"}))
+			assert.Nil(t, entries, name)
+			assert.Equal(t, "PATH_TRAVERSAL", firstCode(t, result), name)
+		}
+	})
+
+	t.Run("Declared sizes are not trusted", func(t *testing.T) {
+		// 1MB de zéros, annoncés comme 10 octets puis comme 1TB
+		var compressed bytes.Buffer
+		content := make([]byte, 1024*1024)
+		deflater, err := flate.NewWriter(&compressed, flate.BestCompression)
+		require.NoError(t, err)
+		_, err = deflater.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, deflater.Close())
+
+		lyingZip := func(declared uint64) []byte {
+			var buffer bytes.Buffer
+			writer := zip.NewWriter(&buffer)
+			entry, err := writer.CreateRaw(&zip.FileHeader{
+				Name:               "slides.md",
+				Method:             zip.Deflate,
+				CRC32:              crc32.ChecksumIEEE(content),
+				CompressedSize64:   uint64(compressed.Len()),
+				UncompressedSize64: declared,
+			})
+			require.NoError(t, err)
+			_, err = entry.Write(compressed.Bytes())
+			require.NoError(t, err)
+			require.NoError(t, writer.Close())
+			return buffer.Bytes()
+		}
+
+		entries, result := extract(ArchiveFormatZip, lyingZip(10))
+		assert.Nil(t, entries)
+		assert.Equal(t, "INVALID_ARCHIVE", firstCode(t, result))
+
+		local := limits
+		local.MaxExpansionRatio = 1 << 20
+		archive := lyingZip(1 << 40)
+		entries, result = ExtractArchive(bytes.NewReader(archive), int64(len(archive)), ArchiveFormatZip, local)
+		assert.Nil(t, entries)
+		assert.Equal(t, "FILE_TOO_LARGE", firstCode(t, result))
+	})
+
+	t.Run("Many small bombs", func(t *testing.T) {
+		// Chaque fichier respecte la taille max, pas leur somme
+		files := make(map[string]string)
+		for i := 0; i < 8; i++ {
+			files[strings.Repeat("f", i+1)+".md"] = strings.Repeat("0", 60*1024)
+		}
+		local := limits
+		local.MaxExpansionRatio = 1 << 20
+		archive := zipArchive(t, files)
+		entries, result := ExtractArchive(bytes.NewReader(archive), int64(len(archive)), ArchiveFormatZip, local)
+		assert.Nil(t, entries)
+		assert.Equal(t, "TOTAL_SIZE_TOO_LARGE", firstCode(t, result))
+	})
+
+	t.Run("Entry count includes rejected entries", func(t *testing.T) {
+		headers := make([]*tar.Header, 0, 20)
+		contents := make([]string, 0, 20)
+		for i := 0; i < 20; i++ {
+			headers = append(headers, &tar.Header{Typeflag: tar.TypeSymlink, Name: strings.Repeat("l", i+1), Linkname: "/etc/passwd"})
+			contents = append(contents, "")
+		}
+
+		_, result := extract(ArchiveFormatTarGz, tarGzArchive(t, headers, contents))
+		assert.Len(t, result.Errors, limits.MaxEntries+1)
+		assert.Equal(t, "TOO_MANY_FILES", result.Errors[limits.MaxEntries].Code)
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		_, result := extract("rar", []byte("Rar!"))
+		assert.Equal(t, "UNSUPPORTED_ARCHIVE_FORMAT", firstCode(t, result))
+	})
+}
@@ -0,0 +1,167 @@
+// internal/validation/callback_policy.go - Politique anti-SSRF pour les URLs de callback
+
+package validation
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// CallbackPolicy définit les cibles autorisées pour les callbacks (webhooks)
+type CallbackPolicy struct {
+	// AllowedHosts restreint les hôtes autorisés (vide = tous). Supporte "*.example.com".
+	AllowedHosts []string
+	// AllowPrivateNetworks autorise les IPs privées (RFC1918, ULA), loopback et link-local
+	AllowPrivateNetworks bool
+	// Resolver est utilisé pour résoudre les hôtes au moment de l'envoi
+	Resolver *net.Resolver
+}
+
+// DefaultCallbackPolicy retourne une politique bloquant les réseaux privés
+func DefaultCallbackPolicy() *CallbackPolicy {
+	return &CallbackPolicy{
+		AllowedHosts:         nil,
+		AllowPrivateNetworks: false,
+		Resolver:             net.DefaultResolver,
+	}
+}
+
+// IsHostAllowed vérifie si un hôte figure dans l'allowlist
+func (p *CallbackPolicy) IsHostAllowed(host string) bool {
+	if len(p.AllowedHosts) == 0 {
+		return true
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, allowed := range p.AllowedHosts {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if allowed == "" {
+			continue
+		}
+
+		if strings.HasPrefix(allowed, "*.") {
+			// "*.example.com" couvre les sous-domaines, pas example.com lui-même
+			if strings.HasSuffix(host, allowed[1:]) {
+				return true
+			}
+			continue
+		}
+
+		if host == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsIPAllowed vérifie qu'une IP n'appartient pas à une plage interne interdite
+func (p *CallbackPolicy) IsIPAllowed(ip net.IP) bool {
+	// Adresses jamais acceptables comme cible de callback
+	if ip.IsUnspecified() || ip.IsMulticast() {
+		return false
+	}
+
+	if p.AllowPrivateNetworks {
+		return true
+	}
+
+	// Loopback, RFC1918, IPv6 ULA, link-local (dont 169.254.169.254 des métadonnées cloud)
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+		return false
+	}
+
+	return true
+}
+
+// ValidateHost vérifie un hôte sans résolution DNS (allowlist + IP littérale)
+func (p *CallbackPolicy) ValidateHost(host string) *ValidationResult {
+	result := &ValidationResult{Valid: true}
+
+	if !p.IsHostAllowed(host) {
+		result.AddError("callback_url", host, "callback host is not in the allowed hosts list", "CALLBACK_HOST_NOT_ALLOWED")
+		return result
+	}
+
+	if ip := net.ParseIP(host); ip != nil && !p.IsIPAllowed(ip) {
+		result.AddError("callback_url", host, "callback URL targets a private or reserved address", "PRIVATE_ADDRESS_NOT_ALLOWED")
+	}
+
+	return result
+}
+
+// CheckCallbackURL résout l'hôte de l'URL et vérifie toutes ses adresses avant l'envoi.
+// La résolution peut changer entre cette vérification et la connexion (DNS rebinding) :
+// l'adresse réellement contactée est revérifiée par DialControl.
+func (p *CallbackPolicy) CheckCallbackURL(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid callback URL: %w", err)
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("unsupported callback URL scheme: %s", parsed.Scheme)
+	}
+
+	host := parsed.Hostname()
+	if result := p.ValidateHost(host); !result.Valid {
+		return result.Errors[0]
+	}
+
+	resolver := p.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve callback host %s: %w", host, err)
+	}
+
+	for _, addr := range addrs {
+		if !p.IsIPAllowed(addr.IP) {
+			return fmt.Errorf("callback host %s resolves to forbidden address %s", host, addr.IP)
+		}
+	}
+
+	return nil
+}
+
+// DialControl vérifie l'adresse effectivement contactée par une connexion sortante, après
+// résolution DNS. À utiliser comme Control d'un net.Dialer pour se prémunir du DNS rebinding.
+func (p *CallbackPolicy) DialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid callback dial address %s: %w", address, err)
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("callback dial address %s is not an IP", address)
+	}
+
+	if !p.IsIPAllowed(ip) {
+		return fmt.Errorf("callback connection to forbidden address %s", ip)
+	}
+
+	return nil
+}
+
+// Transport retourne un transport HTTP dont chaque connexion est vérifiée par DialControl.
+// Aucun proxy n'est utilisé : la politique doit s'appliquer à la cible réelle du callback.
+func (p *CallbackPolicy) Transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   p.DialControl,
+	}).DialContext
+	return transport
+}
@@ -0,0 +1,83 @@
+// internal/validation/content_patterns.go - Motifs de contenu refusés par extension
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ContentPattern est un motif refusé dans le contenu des fichiers d'une extension
+type ContentPattern struct {
+	Pattern *regexp.Regexp
+	Message string
+	Code    string
+}
+
+// deniedContentCode est le code d'erreur des motifs ajoutés par configuration
+const deniedContentCode = "DENIED_CONTENT_PATTERN"
+
+// DefaultContentPatterns retourne les motifs refusés intégrés, par extension
+func DefaultContentPatterns() map[string][]ContentPattern {
+	return map[string][]ContentPattern{
+		".js": {{
+			Pattern: regexp.MustCompile(`eval\(|Function\(|setTimeout\(|setInterval\(`),
+			Message: "JavaScript content contains potentially dangerous functions",
+			Code:    "DANGEROUS_JS_CONTENT",
+		}},
+		".html": {{
+			Pattern: regexp.MustCompile(`(?i)<script`),
+			Message: "HTML content contains script tags",
+			Code:    "SCRIPT_TAGS_NOT_ALLOWED",
+		}},
+		".md": {{
+			Pattern: regexp.MustCompile(`javascript:`),
+			Message: "Markdown content contains javascript: links",
+			Code:    "JAVASCRIPT_LINKS_NOT_ALLOWED",
+		}},
+	}
+}
+
+// ParseContentPatterns construit les motifs refusés : ceux intégrés, sauf pour les
+// extensions de disabledDefaults, complétés des expressions régulières de custom par
+// extension. Une extension ou une expression invalide est une erreur.
+func ParseContentPatterns(custom map[string][]string, disabledDefaults []string) (map[string][]ContentPattern, error) {
+	patterns := DefaultContentPatterns()
+
+	for _, ext := range disabledDefaults {
+		key, err := contentPatternExtension(ext)
+		if err != nil {
+			return nil, err
+		}
+		delete(patterns, key)
+	}
+
+	for ext, expressions := range custom {
+		key, err := contentPatternExtension(ext)
+		if err != nil {
+			return nil, err
+		}
+		for _, expression := range expressions {
+			pattern, err := regexp.Compile(expression)
+			if err != nil || expression == "" {
+				return nil, fmt.Errorf("invalid pattern %q for %s: %v", expression, key, err)
+			}
+			patterns[key] = append(patterns[key], ContentPattern{
+				Pattern: pattern,
+				Message: fmt.Sprintf("content matches denied pattern %q", expression),
+				Code:    deniedContentCode,
+			})
+		}
+	}
+
+	return patterns, nil
+}
+
+// contentPatternExtension normalise l'extension d'un motif (".js")
+func contentPatternExtension(ext string) (string, error) {
+	key := "." + strings.TrimPrefix(strings.ToLower(strings.TrimSpace(ext)), ".")
+	if len(key) < 2 || strings.ContainsAny(key[1:], "./\\ ") {
+		return "", fmt.Errorf("invalid extension %q", ext)
+	}
+	return key, nil
+}
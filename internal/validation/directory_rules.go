@@ -0,0 +1,109 @@
+// internal/validation/directory_rules.go - Règles de validation par dossier des sources
+package validation
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Politiques d'un dossier des sources
+const (
+	// DirectoryPolicyDeny refuse tout fichier du dossier
+	DirectoryPolicyDeny = "deny"
+	// DirectoryPolicyBinary accepte toute extension et tout type de contenu (assets bruts)
+	DirectoryPolicyBinary = "binary"
+)
+
+// DirectoryRule adapte la validation des fichiers d'un dossier des sources et de ses
+// sous-dossiers. Les autres vérifications (nom, profondeur, taille) restent appliquées.
+type DirectoryRule struct {
+	Deny       bool            // aucun fichier accepté
+	Binary     bool            // toute extension et tout type MIME, contenu binaire admis
+	Extensions map[string]bool // extensions acceptées en plus de AllowedExtensions
+}
+
+// allowsExtension indique si la règle accepte une extension refusée par défaut
+func (r *DirectoryRule) allowsExtension(ext string) bool {
+	if r == nil {
+		return false
+	}
+	return r.Binary || r.Extensions[ext]
+}
+
+// Policy retourne la politique de la règle sous la forme acceptée par ParseDirectoryRules
+func (r *DirectoryRule) Policy() string {
+	switch {
+	case r.Deny:
+		return DirectoryPolicyDeny
+	case r.Binary:
+		return DirectoryPolicyBinary
+	}
+
+	extensions := make([]string, 0, len(r.Extensions))
+	for ext := range r.Extensions {
+		extensions = append(extensions, ext)
+	}
+	sort.Strings(extensions)
+	return strings.Join(extensions, "|")
+}
+
+// ParseDirectoryRules construit les règles à partir des politiques par dossier :
+// "deny", "binary" ou une liste d'extensions séparées par "|" (".csv|.tsv")
+func ParseDirectoryRules(policies map[string]string) (map[string]*DirectoryRule, error) {
+	if len(policies) == 0 {
+		return nil, nil
+	}
+
+	rules := make(map[string]*DirectoryRule, len(policies))
+	for directory, policy := range policies {
+		key := normalizeRuleDirectory(directory)
+		if key == "" || slices.Contains(strings.Split(strings.ReplaceAll(directory, "\\", "/"), "/"), "..") {
+			return nil, fmt.Errorf("invalid directory %q", directory)
+		}
+
+		switch policy = strings.ToLower(strings.TrimSpace(policy)); policy {
+		case DirectoryPolicyDeny:
+			rules[key] = &DirectoryRule{Deny: true}
+		case DirectoryPolicyBinary:
+			rules[key] = &DirectoryRule{Binary: true}
+		default:
+			extensions := make(map[string]bool)
+			for _, ext := range strings.Split(policy, "|") {
+				ext = strings.TrimSpace(ext)
+				if len(ext) < 2 || !strings.HasPrefix(ext, ".") || strings.ContainsAny(ext[1:], "./") {
+					return nil, fmt.Errorf("invalid policy %q for directory %s: expected %s, %s or extensions like .csv|.tsv",
+						policy, directory, DirectoryPolicyDeny, DirectoryPolicyBinary)
+				}
+				extensions[ext] = true
+			}
+			rules[key] = &DirectoryRule{Extensions: extensions}
+		}
+	}
+
+	return rules, nil
+}
+
+// normalizeRuleDirectory ramène un dossier à la forme des chemins validés ("assets/img")
+func normalizeRuleDirectory(directory string) string {
+	return strings.Trim(path.Clean("/"+strings.ReplaceAll(strings.TrimSpace(directory), "\\", "/")), "/")
+}
+
+// directoryRule retourne la règle du dossier le plus profond contenant filePath, et ce
+// dossier (nil si aucune règle ne s'applique)
+func (c *ValidationConfig) directoryRule(filePath string) (string, *DirectoryRule) {
+	if len(c.DirectoryRules) == 0 {
+		return "", nil
+	}
+
+	directory := path.Dir(strings.Trim(strings.ReplaceAll(filePath, "\\", "/"), "/"))
+	for directory != "." && directory != "/" {
+		if rule, exists := c.DirectoryRules[directory]; exists {
+			return directory, rule
+		}
+		directory = path.Dir(directory)
+	}
+	return "", nil
+}
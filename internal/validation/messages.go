@@ -0,0 +1,192 @@
+// internal/validation/messages.go - Catalogues de messages des erreurs de validation
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale est la langue des messages écrits dans le code, utilisée sans catalogue
+// correspondant à Accept-Language
+const DefaultLocale = "en"
+
+// MessageCatalog associe une langue ("fr", "pt-br") à ses messages par code d'erreur.
+// Un message peut reprendre le champ, la valeur et le message d'origine de l'erreur avec
+// {field}, {value} et {message}. Un code absent du catalogue garde son message d'origine.
+type MessageCatalog map[string]map[string]string
+
+// DefaultMessageCatalog retourne les catalogues intégrés : les messages anglais sont ceux
+// du code, seul le français est fourni
+func DefaultMessageCatalog() MessageCatalog {
+	return MessageCatalog{
+		"fr": {
+			"REQUIRED":                     "le champ {field} est obligatoire",
+			"INVALID_UUID":                 "{field} doit être un UUID valide",
+			"INVALID_FORMAT":               "le format de {field} est invalide",
+			"PATH_TRAVERSAL":               "le chemin ne doit pas sortir du dossier (« .. » ou chemin absolu)",
+			"FORBIDDEN_CHAR":               "le nom contient un caractère interdit",
+			"CONTROL_CHARACTERS":           "le nom contient des caractères de contrôle",
+			"RESERVED_NAME":                "ce nom de fichier est réservé par le système",
+			"PATH_TOO_LONG":                "le chemin est trop long",
+			"PATH_TOO_DEEP":                "le chemin contient trop de dossiers imbriqués",
+			"FORBIDDEN_EXTENSION":          "cette extension de fichier n'est pas autorisée",
+			"NO_EXTENSION":                 "le fichier doit avoir une extension",
+			"FORBIDDEN_MIME_TYPE":          "ce type de fichier n'est pas autorisé",
+			"FILE_TOO_LARGE":               "le fichier dépasse la taille maximale autorisée",
+			"TOTAL_SIZE_TOO_LARGE":         "les fichiers dépassent la taille totale autorisée",
+			"TOO_MANY_FILES":               "trop de fichiers envoyés",
+			"NO_FILES":                     "aucun fichier envoyé",
+			"EMPTY_FILE":                   "le fichier est vide",
+			"DUPLICATE_FILENAME":           "ce fichier est envoyé plusieurs fois",
+			"DIRECTORY_NOT_ALLOWED":        "les fichiers ne sont pas autorisés dans ce dossier",
+			"DANGEROUS_JS_CONTENT":         "le JavaScript contient des fonctions potentiellement dangereuses (eval, Function, setTimeout...)",
+			"SCRIPT_TAGS_NOT_ALLOWED":      "les balises <script> ne sont pas autorisées dans le HTML",
+			"JAVASCRIPT_LINKS_NOT_ALLOWED": "les liens javascript: ne sont pas autorisés dans le Markdown",
+			"DENIED_CONTENT_PATTERN":       "le contenu du fichier contient un motif refusé",
+			"INVALID_ARCHIVE":              "l'archive est illisible",
+			"EMPTY_ARCHIVE":                "l'archive ne contient aucun fichier",
+			"INVALID_ENTRY_FILE":           "le fichier d'entrée est invalide",
+			"INVALID_OUTPUT_DIR":           "le dossier de sortie est invalide",
+			"INVALID_RESULT_PREFIX":        "la destination des résultats est invalide",
+			"INVALID_THEME":                "le thème est invalide",
+			"INVALID_URL":                  "l'URL est invalide",
+			"INVALID_METADATA":             "les métadonnées sont invalides",
+			"METADATA_TOO_LARGE":           "les métadonnées sont trop volumineuses",
+			"METADATA_TOO_DEEP":            "les métadonnées sont trop imbriquées",
+			"INVALID_STATUS":               "le statut demandé est invalide",
+			"INVALID_LIMIT":                "limit doit être un entier",
+			"NEGATIVE_LIMIT":               "limit ne peut pas être négatif",
+			"LIMIT_TOO_LARGE":              "limit est trop grand",
+			"INVALID_OFFSET":               "offset doit être un entier",
+			"NEGATIVE_OFFSET":              "offset ne peut pas être négatif",
+			"MESSAGE_TOO_LONG":             "le message est trop long",
+		},
+	}
+}
+
+// LoadMessageCatalog complète les catalogues intégrés avec un fichier JSON
+// ({"fr": {"PATH_TRAVERSAL": "..."}, "de": {...}}), dont les messages l'emportent.
+// Un chemin vide retourne les catalogues intégrés.
+func LoadMessageCatalog(path string) (MessageCatalog, error) {
+	catalog := DefaultMessageCatalog()
+	if path == "" {
+		return catalog, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var custom map[string]map[string]string
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, fmt.Errorf("invalid message catalog %s: %w", path, err)
+	}
+
+	for locale, messages := range custom {
+		key := normalizeLocale(locale)
+		if key == "" {
+			return nil, fmt.Errorf("invalid locale %q in message catalog %s", locale, path)
+		}
+		if catalog[key] == nil {
+			catalog[key] = make(map[string]string, len(messages))
+		}
+		for code, message := range messages {
+			catalog[key][code] = message
+		}
+	}
+	return catalog, nil
+}
+
+// Locales retourne les langues disponibles, triées, la langue par défaut comprise
+func (mc MessageCatalog) Locales() []string {
+	locales := []string{DefaultLocale}
+	for locale := range mc {
+		if locale != DefaultLocale {
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales[1:])
+	return locales
+}
+
+// Negotiate choisit la langue des messages d'après un en-tête Accept-Language
+// ("fr-CA,fr;q=0.9,en;q=0.8") : la langue acceptée de plus haute priorité disposant d'un
+// catalogue, ou sa langue principale ("fr" pour "fr-CA"). Sans correspondance, DefaultLocale.
+func (mc MessageCatalog) Negotiate(acceptLanguage string) string {
+	type accepted struct {
+		locale  string
+		quality float64
+	}
+
+	var candidates []accepted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		quality := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if locale := normalizeLocale(tag); locale != "" && quality > 0 {
+			candidates = append(candidates, accepted{locale: locale, quality: quality})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	for _, candidate := range candidates {
+		if candidate.locale == DefaultLocale || mc[candidate.locale] != nil {
+			return candidate.locale
+		}
+		primary, _, _ := strings.Cut(candidate.locale, "-")
+		if primary == DefaultLocale || mc[primary] != nil {
+			return primary
+		}
+	}
+	return DefaultLocale
+}
+
+// Localize retourne des copies des erreurs avec les messages de la langue ; les codes,
+// champs et valeurs sont inchangés
+func (mc MessageCatalog) Localize(errors []*ValidationError, locale string) []*ValidationError {
+	messages := mc[locale]
+	if len(messages) == 0 {
+		return errors
+	}
+
+	localized := make([]*ValidationError, len(errors))
+	for i, err := range errors {
+		copied := *err
+		if template, ok := messages[err.Code]; ok {
+			copied.Message = strings.NewReplacer(
+				"{field}", err.Field,
+				"{value}", err.Value,
+				"{message}", err.Message,
+			).Replace(template)
+		}
+		localized[i] = &copied
+	}
+	return localized
+}
+
+// normalizeLocale normalise une étiquette de langue ("fr_CA" -> "fr-ca"), vide si invalide
+func normalizeLocale(tag string) string {
+	locale := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	if locale == "" || locale == "*" || len(locale) > 35 {
+		return ""
+	}
+	for _, r := range locale {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return ""
+		}
+	}
+	return locale
+}
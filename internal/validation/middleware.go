@@ -5,6 +5,12 @@ import (
 	"fmt"
 	"log"
 	"mime/multipart"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
 
@@ -30,7 +36,7 @@ func ValidateRequest(validators ...RequestValidator) gin.HandlerFunc {
 			if result := validate(c, validator); !result.Valid {
 				c.JSON(400, gin.H{
 					"error":             "Validation failed",
-					"validation_errors": result.Errors,
+					"validation_errors": LocalizedErrors(c, result.Errors),
 				})
 				c.Abort()
 				return
@@ -41,6 +47,19 @@ func ValidateRequest(validators ...RequestValidator) gin.HandlerFunc {
 	}
 }
 
+// LocalizedErrors traduit les erreurs de validation dans la langue demandée par
+// Accept-Language et l'indique dans l'en-tête Content-Language de la réponse
+func LocalizedErrors(c *gin.Context, errors []*ValidationError) []*ValidationError {
+	validator := GetValidator(c)
+	if validator == nil {
+		return errors
+	}
+
+	locale, localized := validator.LocalizeErrors(errors, c.GetHeader("Accept-Language"))
+	c.Header("Content-Language", locale)
+	return localized
+}
+
 // GetValidator helper pour récupérer le validator du contexte (déjà existant dans middleware.go)
 func GetValidator(c *gin.Context) *APIValidator {
 	if validator, exists := c.Get("validator"); exists {
@@ -120,6 +139,73 @@ func ParseGenerationRequest() gin.HandlerFunc {
 	return ParseJSONRequest[models.GenerationRequest]()
 }
 
+// ParseBatchGenerationRequest parse une soumission groupée de jobs
+func ParseBatchGenerationRequest() gin.HandlerFunc {
+	return ParseJSONRequest[models.BatchGenerationRequest]()
+}
+
+// BatchItemValidation est le résultat de validation d'un élément d'un batch
+type BatchItemValidation struct {
+	Index   int
+	Request models.GenerationRequest
+	Errors  []*ValidationError
+}
+
+// ValidateBatchGenerationRequest valide la taille du batch puis chaque élément avec la
+// validation d'une requête unique. Un élément invalide n'invalide pas le batch : les
+// résultats par élément sont stockés dans validated_batch.
+func ValidateBatchGenerationRequest(c *gin.Context, v *APIValidator) *ValidationResult {
+	req, exists := c.Get("parsed_request")
+	if !exists {
+		return &ValidationResult{Valid: false, Errors: []*ValidationError{{
+			Field: "json", Value: "", Message: "JSON parsing failed", Code: "JSON_PARSE_ERROR",
+		}}}
+	}
+	batch := req.(models.BatchGenerationRequest)
+
+	result := v.validationService.ValidateBatchSize(len(batch.Jobs))
+	if !result.Valid {
+		return result
+	}
+
+	items := make([]BatchItemValidation, len(batch.Jobs))
+	seenJobIDs := make(map[uuid.UUID]bool)
+
+	for i, jobReq := range batch.Jobs {
+		itemResult := v.ValidateGenerationRequest(&jobReq)
+
+		// Champs obligatoires, vérifiés par le binding pour une requête unique
+		if jobReq.JobID == uuid.Nil {
+			itemResult.AddError("job_id", "", "job ID is required", "REQUIRED")
+		}
+		if jobReq.CourseID == uuid.Nil {
+			itemResult.AddError("course_id", "", "course ID is required", "REQUIRED")
+		}
+
+		if jobReq.JobID != uuid.Nil {
+			if seenJobIDs[jobReq.JobID] {
+				itemResult.AddError("job_id", jobReq.JobID.String(),
+					"job ID already used in this batch", "DUPLICATE_JOB_ID")
+			}
+			seenJobIDs[jobReq.JobID] = true
+		}
+
+		for _, err := range itemResult.Errors {
+			err.Field = fmt.Sprintf("jobs[%d].%s", i, err.Field)
+		}
+
+		items[i] = BatchItemValidation{
+			Index:   i,
+			Request: jobReq,
+			Errors:  itemResult.Errors,
+		}
+	}
+
+	c.Set("validated_batch", items)
+
+	return result
+}
+
 // CombineValidators combine plusieurs validators (tous doivent passer)
 func CombineValidators(validators ...RequestValidator) RequestValidator {
 	return func(c *gin.Context, v *APIValidator) *ValidationResult {
@@ -150,6 +236,35 @@ func ValidateFilenameParam(paramName string) RequestValidator {
 	}
 }
 
+// ValidateViewPathParam valide le chemin d'un fichier de résultat servi en prévisualisation
+// (paramètre catch-all). Un chemin vide ou un dossier désigne son index.html ; un chemin
+// sans extension (route de l'app Slidev comme /1) est validé segment par segment pour
+// refuser toute remontée d'arborescence.
+func ValidateViewPathParam(paramName string) RequestValidator {
+	return func(c *gin.Context, v *APIValidator) *ValidationResult {
+		viewPath := strings.TrimPrefix(c.Param(paramName), "/")
+
+		if viewPath == "" || strings.HasSuffix(viewPath, "/") {
+			viewPath += "index.html"
+		}
+
+		if path.Ext(viewPath) == "" {
+			result := v.ValidateFilePath(viewPath + "/index.html")
+			if result.Valid {
+				c.Set("validated_filepath", v.SanitizeFilePath(viewPath))
+			}
+			return result
+		}
+
+		result := v.ValidateFilePath(viewPath)
+		if result.Valid {
+			c.Set("validated_filepath", v.SanitizeFilePath(viewPath))
+		}
+
+		return result
+	}
+}
+
 // ValidateCourseIDParam valide un paramètre course_id depuis l'URL
 func ValidateCourseIDParam(paramName string) RequestValidator {
 	return func(c *gin.Context, v *APIValidator) *ValidationResult {
@@ -192,8 +307,14 @@ func ValidateFileUpload(c *gin.Context, v *APIValidator) *ValidationResult {
 		}
 	}
 
+	// Vérifier les limites globales avant de valider chaque fichier
+	if limitsResult := v.ValidateUploadLimits(files); !limitsResult.Valid {
+		return limitsResult
+	}
+
 	result := &ValidationResult{Valid: true}
 	var validFiles []*multipart.FileHeader
+	seenPaths := make(map[string]bool)
 
 	// Valider chaque fichier individuellement
 	for i, fileHeader := range files {
@@ -214,6 +335,14 @@ func ValidateFileUpload(c *gin.Context, v *APIValidator) *ValidationResult {
 		// Sanitiser le chemin
 		sanitizedPath := v.SanitizeFilePath(filePath)
 
+		// Deux fichiers de même nom dans des dossiers différents sont distincts
+		if seenPaths[sanitizedPath] {
+			result.AddError(fmt.Sprintf("files[%d].path", i), sanitizedPath,
+				"duplicate filename", "DUPLICATE_FILENAME")
+			continue
+		}
+		seenPaths[sanitizedPath] = true
+
 		// Valider le fichier avec le chemin sanitisé
 		fileResult := v.ValidateFileUpload([]*multipart.FileHeader{fileHeader})
 		if !fileResult.Valid {
@@ -240,6 +369,73 @@ func ValidateFileUpload(c *gin.Context, v *APIValidator) *ValidationResult {
 	return result
 }
 
+// ValidateSourceArchiveUpload valide l'archive de sources (champ "archive") et les fichiers
+// qu'elle contient, stockés avec leurs chemins sanitisés dans validated_archive_entries
+func ValidateSourceArchiveUpload(c *gin.Context, v *APIValidator) *ValidationResult {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return &ValidationResult{
+			Valid: false,
+			Errors: []*ValidationError{{
+				Field:   "archive",
+				Value:   "",
+				Message: "Failed to parse multipart form: " + err.Error(),
+				Code:    "MULTIPART_PARSE_ERROR",
+			}},
+		}
+	}
+
+	archives := form.File["archive"]
+	if len(archives) != 1 {
+		return &ValidationResult{
+			Valid: false,
+			Errors: []*ValidationError{{
+				Field:   "archive",
+				Value:   fmt.Sprintf("%d files", len(archives)),
+				Message: "Exactly one archive must be provided",
+				Code:    "ARCHIVE_REQUIRED",
+			}},
+		}
+	}
+
+	entries, result := v.ExtractSourceArchive(archives[0])
+	if !result.Valid {
+		return result
+	}
+
+	var validEntries []*ArchiveEntry
+	seenPaths := make(map[string]bool)
+
+	// Valider le chemin de chaque fichier extrait, comme pour un upload multipart
+	for _, entry := range entries {
+		pathResult := v.ValidateFilePath(entry.Path)
+		if !pathResult.Valid {
+			for _, err := range pathResult.Errors {
+				err.Field = "archive[" + entry.Path + "]." + err.Field
+				result.Errors = append(result.Errors, err)
+			}
+			result.Valid = false
+			continue
+		}
+
+		sanitizedPath := v.SanitizeFilePath(entry.Path)
+		if seenPaths[sanitizedPath] {
+			result.AddError("archive["+entry.Path+"]", sanitizedPath, "duplicate filename", "DUPLICATE_FILENAME")
+			continue
+		}
+		seenPaths[sanitizedPath] = true
+
+		validEntries = append(validEntries, &ArchiveEntry{Path: sanitizedPath, Content: entry.Content})
+		log.Printf("Validated archive file: %s -> %s", entry.Path, sanitizedPath)
+	}
+
+	if result.Valid {
+		c.Set("validated_archive_entries", validEntries)
+	}
+
+	return result
+}
+
 // ValidateStatusParam valide un paramètre status depuis l'URL ou query
 func ValidateStatusParam(paramName string) RequestValidator {
 	return func(c *gin.Context, v *APIValidator) *ValidationResult {
@@ -298,14 +494,29 @@ func ValidateListJobsParams(c *gin.Context, v *APIValidator) *ValidationResult {
 	limitParam := c.Query("limit")
 	offsetParam := c.Query("offset")
 
+	// Extraire les filtres metadata (?meta.key=value)
+	var metadataParams map[string]string
+	for name, values := range c.Request.URL.Query() {
+		if !strings.HasPrefix(name, MetadataFilterPrefix) || len(values) == 0 {
+			continue
+		}
+		if metadataParams == nil {
+			metadataParams = make(map[string]string)
+		}
+		metadataParams[strings.TrimPrefix(name, MetadataFilterPrefix)] = values[0]
+	}
+
 	// Utiliser la méthode du validator API
-	params, result := v.ValidateListJobsParams(statusParam, courseIDParam, limitParam, offsetParam)
+	params, result := v.ValidateListJobsParams(statusParam, courseIDParam, limitParam, offsetParam, metadataParams,
+		c.QueryArray(LabelFilterParam))
 
 	if result.Valid {
 		// Stocker les paramètres validés individuellement pour compatibilité
 		c.Set("validated_status", params.Status)
 		c.Set("validated_course_id", params.CourseID)
 		c.Set("validated_pagination", params.Pagination)
+		c.Set("validated_metadata_filters", params.Metadata)
+		c.Set("validated_label_filters", params.Labels)
 
 		// Stocker aussi l'objet complet
 		c.Set("validated_list_params", *params)
@@ -370,3 +581,381 @@ func ValidateFileListFormat(c *gin.Context, v *APIValidator) *ValidationResult {
 	c.Set("validated_format", format)
 	return &ValidationResult{Valid: true}
 }
+
+// ValidateOverwritePolicyParam valide la politique d'écrasement d'un upload (?overwrite=...)
+func ValidateOverwritePolicyParam(c *gin.Context, v *APIValidator) *ValidationResult {
+	policy := models.OverwritePolicy(c.DefaultQuery("overwrite", string(models.OverwriteReplace)))
+
+	switch policy {
+	case models.OverwriteReplace, models.OverwriteSkipExisting, models.OverwriteErrorOnExisting:
+	default:
+		return &ValidationResult{
+			Valid: false,
+			Errors: []*ValidationError{{
+				Field:   "overwrite",
+				Value:   string(policy),
+				Message: "Invalid overwrite policy. Must be 'replace', 'skip-existing' or 'error-on-existing'",
+				Code:    "INVALID_OVERWRITE_POLICY",
+			}},
+		}
+	}
+
+	c.Set("validated_overwrite_policy", policy)
+	return &ValidationResult{Valid: true}
+}
+
+// ExpectedFileCountHeader porte le nombre de fichiers que le client annonce pour un upload
+const ExpectedFileCountHeader = "X-Expected-File-Count"
+
+// ValidateExpectedFileCountHeader valide le nombre de fichiers annoncé par le client (optionnel),
+// stocké dans validated_expected_file_count (0 sans en-tête)
+func ValidateExpectedFileCountHeader(c *gin.Context, v *APIValidator) *ValidationResult {
+	value := c.GetHeader(ExpectedFileCountHeader)
+	expected := 0
+
+	if value != "" {
+		count, err := strconv.Atoi(value)
+		if err != nil || count <= 0 {
+			return &ValidationResult{
+				Valid: false,
+				Errors: []*ValidationError{{
+					Field:   ExpectedFileCountHeader,
+					Value:   value,
+					Message: "Expected file count must be a positive integer",
+					Code:    "INVALID_EXPECTED_FILE_COUNT",
+				}},
+			}
+		}
+		expected = count
+	}
+
+	c.Set("validated_expected_file_count", expected)
+	return &ValidationResult{Valid: true}
+}
+
+// ValidateUploadSessionQuery valide la session de suivi référencée par un upload
+// (?upload_session=, optionnelle), stockée dans validated_upload_session_id (uuid.Nil sans session)
+func ValidateUploadSessionQuery(c *gin.Context, v *APIValidator) *ValidationResult {
+	sessionID := uuid.Nil
+
+	if value := c.Query("upload_session"); value != "" {
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			return &ValidationResult{
+				Valid: false,
+				Errors: []*ValidationError{{
+					Field:   "upload_session",
+					Value:   value,
+					Message: "Upload session must be a valid UUID",
+					Code:    "INVALID_UPLOAD_SESSION",
+				}},
+			}
+		}
+		sessionID = parsed
+	}
+
+	c.Set("validated_upload_session_id", sessionID)
+	return &ValidationResult{Valid: true}
+}
+
+// ValidateUploadSessionIDParam valide l'identifiant de session d'upload d'un paramètre de chemin
+func ValidateUploadSessionIDParam(paramName string) RequestValidator {
+	return func(c *gin.Context, v *APIValidator) *ValidationResult {
+		value := c.Param(paramName)
+		sessionID, err := uuid.Parse(value)
+		if err != nil {
+			return &ValidationResult{
+				Valid: false,
+				Errors: []*ValidationError{{
+					Field:   paramName,
+					Value:   value,
+					Message: "Upload session ID must be a valid UUID",
+					Code:    "INVALID_UPLOAD_SESSION",
+				}},
+			}
+		}
+
+		c.Set("validated_upload_session_id", sessionID)
+		return &ValidationResult{Valid: true}
+	}
+}
+
+// ValidateEstimateRequest valide une demande d'estimation de build : fichiers sources
+// uploadés (multipart, mêmes règles que l'upload des sources) ou caractéristiques des
+// sources décrites en JSON, sans upload
+func ValidateEstimateRequest(c *gin.Context, v *APIValidator) *ValidationResult {
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		return ValidateFileUpload(c, v)
+	}
+
+	var req models.EstimateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return &ValidationResult{Valid: false, Errors: []*ValidationError{{
+			Field: "json", Value: "", Message: "JSON parsing failed: " + err.Error(), Code: "JSON_PARSE_ERROR",
+		}}}
+	}
+
+	result := &ValidationResult{Valid: true}
+	if req.SourceFileCount <= 0 {
+		result.AddError("source_file_count", fmt.Sprintf("%d", req.SourceFileCount),
+			"source_file_count must be positive", "INVALID_SOURCE_FILE_COUNT")
+	}
+	if req.SourceSizeBytes <= 0 {
+		result.AddError("source_size_bytes", fmt.Sprintf("%d", req.SourceSizeBytes),
+			"source_size_bytes must be positive", "INVALID_SOURCE_SIZE")
+	}
+	if len(req.Theme) > 255 {
+		result.AddError("theme", req.Theme[:50]+"...", "theme too long (max 255 characters)", "THEME_TOO_LONG")
+	}
+
+	if result.Valid {
+		c.Set("validated_estimate_request", req)
+	}
+	return result
+}
+
+// MaxMaintenanceMessageLength borne le message renvoyé aux soumissions refusées en maintenance
+const MaxMaintenanceMessageLength = 500
+
+// ValidateMaintenanceRequest valide la bascule du mode maintenance
+func ValidateMaintenanceRequest(c *gin.Context, v *APIValidator) *ValidationResult {
+	var req models.MaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return &ValidationResult{Valid: false, Errors: []*ValidationError{{
+			Field: "json", Value: "", Message: "JSON parsing failed: " + err.Error(), Code: "JSON_PARSE_ERROR",
+		}}}
+	}
+
+	result := &ValidationResult{Valid: true}
+	if req.Enabled == nil {
+		result.AddError("enabled", "", "enabled is required", "REQUIRED")
+	}
+	if utf8.RuneCountInString(req.Message) > MaxMaintenanceMessageLength {
+		result.AddError("message", string([]rune(req.Message)[:50])+"...",
+			fmt.Sprintf("message too long (max %d characters)", MaxMaintenanceMessageLength), "MESSAGE_TOO_LONG")
+	}
+
+	if result.Valid {
+		c.Set("validated_maintenance_request", req)
+	}
+	return result
+}
+
+// ValidateGenerationProfileRequest valide le profil de génération d'un cours avec les
+// règles des champs de même nom d'une requête de génération
+func ValidateGenerationProfileRequest(c *gin.Context, v *APIValidator) *ValidationResult {
+	var req models.GenerationProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return &ValidationResult{Valid: false, Errors: []*ValidationError{{
+			Field: "json", Value: "", Message: "JSON parsing failed: " + err.Error(), Code: "JSON_PARSE_ERROR",
+		}}}
+	}
+
+	result := v.ValidateGenerationProfileRequest(&req)
+	if result.Valid {
+		c.Set("validated_profile_request", req)
+	}
+	return result
+}
+
+// ResultVersionLatest désigne la version courante des résultats d'un cours
+const ResultVersionLatest = "latest"
+
+// ValidateResultDiffParams valide les versions comparées par le diff des résultats
+// (?from=<job_id>&to=<job_id>|latest). to vaut latest par défaut ; latest est stocké
+// comme uuid.Nil dans validated_diff_from et validated_diff_to.
+func ValidateResultDiffParams(c *gin.Context, v *APIValidator) *ValidationResult {
+	result := &ValidationResult{Valid: true}
+
+	versions := map[string]string{
+		"from": c.Query("from"),
+		"to":   c.DefaultQuery("to", ResultVersionLatest),
+	}
+	parsed := make(map[string]uuid.UUID, len(versions))
+
+	for param, version := range versions {
+		if version == "" {
+			result.AddError(param, "", param+" version is required", "REQUIRED")
+			continue
+		}
+		if version == ResultVersionLatest {
+			parsed[param] = uuid.Nil
+			continue
+		}
+
+		jobID, err := uuid.Parse(version)
+		if err != nil || jobID == uuid.Nil {
+			result.AddError(param, version, param+" must be a build job ID or 'latest'", "INVALID_VERSION")
+			continue
+		}
+		parsed[param] = jobID
+	}
+
+	if result.Valid {
+		c.Set("validated_diff_from", parsed["from"])
+		c.Set("validated_diff_to", parsed["to"])
+	}
+
+	return result
+}
+
+// themeNamePattern accepte un nom de thème Slidev (seriph) ou de paquet non scopé
+// (slidev-theme-penguin) ; un paquet scopé contiendrait un "/" incompatible avec le chemin
+var themeNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,99}$`)
+
+// themeVersionPattern accepte une version exacte (1.2.3, 1.2.3-beta.1), clé du cache des aperçus
+var themeVersionPattern = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?$`)
+
+// ValidateThemePreviewParams valide le thème (chemin), sa version et le format (query)
+// d'un aperçu de thème. Version vide = dernière version publiée, format png par défaut.
+func ValidateThemePreviewParams(c *gin.Context, v *APIValidator) *ValidationResult {
+	result := &ValidationResult{Valid: true}
+
+	theme := c.Param("theme")
+	if !themeNamePattern.MatchString(theme) {
+		result.AddError("theme", theme, "theme must be a Slidev theme name or an unscoped npm package name", "INVALID_THEME")
+	}
+
+	version := c.Query("version")
+	if version != "" && !themeVersionPattern.MatchString(version) {
+		result.AddError("version", version, "version must be an exact version (e.g. 1.2.3)", "INVALID_VERSION")
+	}
+
+	format := c.DefaultQuery("format", "png")
+	if format != "png" && format != "pdf" {
+		result.AddError("format", format, "Invalid format. Must be 'png' or 'pdf'", "INVALID_FORMAT")
+	}
+
+	if result.Valid {
+		c.Set("validated_theme", theme)
+		c.Set("validated_theme_version", version)
+		c.Set("validated_preview_format", format)
+	}
+
+	return result
+}
+
+// ValidateLogLevelParam valide le filtre de niveau des logs (?level=error|warning)
+func ValidateLogLevelParam(c *gin.Context, v *APIValidator) *ValidationResult {
+	level := c.DefaultQuery("level", "all")
+
+	validLevels := []string{"all", "warning", "error"}
+	isValid := false
+	for _, validLevel := range validLevels {
+		if level == validLevel {
+			isValid = true
+			break
+		}
+	}
+
+	if !isValid {
+		return &ValidationResult{
+			Valid: false,
+			Errors: []*ValidationError{{
+				Field:   "level",
+				Value:   level,
+				Message: "Invalid level. Must be 'all', 'warning' or 'error'",
+				Code:    "INVALID_LOG_LEVEL",
+			}},
+		}
+	}
+
+	c.Set("validated_log_level", level)
+	return &ValidationResult{Valid: true}
+}
+
+// ValidateLogFormatParam valide le format de sortie des logs (?format=text|jsonl).
+// Sans paramètre, les logs sont retournés dans leur format de stockage.
+func ValidateLogFormatParam(c *gin.Context, v *APIValidator) *ValidationResult {
+	format := c.Query("format")
+	if format != "" && format != "text" && format != "jsonl" {
+		return &ValidationResult{
+			Valid: false,
+			Errors: []*ValidationError{{
+				Field:   "format",
+				Value:   format,
+				Message: "Invalid format. Must be 'text' or 'jsonl'",
+				Code:    "INVALID_LOG_FORMAT",
+			}},
+		}
+	}
+
+	c.Set("validated_log_format", format)
+	return &ValidationResult{Valid: true}
+}
+
+// MaxSLOWindow borne la fenêtre du suivi de latence des jobs
+const MaxSLOWindow = 30 * 24 * time.Hour
+
+// ValidateSLOParams valide la fenêtre (?window=, durée Go comme 1h ou 168h) et le cours
+// (?course_id=) du suivi de latence. Fenêtre absente = 0, le handler applique sa valeur
+// par défaut.
+func ValidateSLOParams(c *gin.Context, v *APIValidator) *ValidationResult {
+	result := &ValidationResult{Valid: true}
+
+	var window time.Duration
+	if value := c.Query("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		switch {
+		case err != nil || parsed <= 0:
+			result.AddError("window", value, "window must be a positive duration (e.g. 1h, 168h)", "INVALID_WINDOW")
+		case parsed > MaxSLOWindow:
+			result.AddError("window", value, fmt.Sprintf("window too long (max %s)", MaxSLOWindow), "INVALID_WINDOW")
+		default:
+			window = parsed
+		}
+	}
+
+	var courseID *uuid.UUID
+	if value := c.Query("course_id"); value != "" {
+		parsed, courseResult := v.ValidateCourseIDParam(value)
+		if !courseResult.Valid {
+			result.Valid = false
+			result.Errors = append(result.Errors, courseResult.Errors...)
+		} else {
+			courseID = &parsed
+		}
+	}
+
+	if result.Valid {
+		c.Set("validated_slo_window", window)
+		c.Set("validated_course_id", courseID)
+	}
+	return result
+}
+
+// sourceHashPattern est le format d'une empreinte de sources (storage.SourceHash)
+var sourceHashPattern = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+// ValidateRebuildCheckParams valide le cours (?course_id=) et l'empreinte de ses sources
+// (?source_hash=, "sha256:<hex>") de la détection de changement des sources
+func ValidateRebuildCheckParams(c *gin.Context, v *APIValidator) *ValidationResult {
+	result := &ValidationResult{Valid: true}
+
+	var courseID uuid.UUID
+	if value := c.Query("course_id"); value == "" {
+		result.AddError("course_id", "", "course ID is required", "REQUIRED")
+	} else {
+		parsed, courseResult := v.ValidateCourseIDParam(value)
+		if !courseResult.Valid {
+			result.Valid = false
+			result.Errors = append(result.Errors, courseResult.Errors...)
+		} else {
+			courseID = parsed
+		}
+	}
+
+	sourceHash := strings.ToLower(c.Query("source_hash"))
+	switch {
+	case sourceHash == "":
+		result.AddError("source_hash", "", "source hash is required", "REQUIRED")
+	case !sourceHashPattern.MatchString(sourceHash):
+		result.AddError("source_hash", c.Query("source_hash"), "source hash must be sha256:<64 hex characters>", "INVALID_SOURCE_HASH")
+	}
+
+	if result.Valid {
+		c.Set("validated_course_id", courseID)
+		c.Set("validated_source_hash", sourceHash)
+	}
+	return result
+}
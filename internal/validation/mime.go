@@ -0,0 +1,120 @@
+// internal/validation/mime.go - Réconciliation des types MIME d'un fichier uploadé
+package validation
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// sniffLength est le nombre d'octets lus pour détecter le type d'un fichier
+const sniffLength = 512
+
+// extensionMimeTypes associe une extension autorisée aux types MIME qui lui correspondent,
+// y compris les variantes envoyées par les navigateurs ou détectées depuis le contenu
+var extensionMimeTypes = map[string][]string{
+	".md":      {"text/markdown", "text/x-markdown"},
+	".css":     {"text/css"},
+	".scss":    {"text/x-scss"},
+	".sass":    {"text/x-sass"},
+	".less":    {"text/x-less"},
+	".postcss": {"text/css"},
+	".js":      {"application/javascript", "text/javascript", "application/x-javascript"},
+	".json":    {"application/json"},
+	".png":     {"image/png"},
+	".jpg":     {"image/jpeg"},
+	".jpeg":    {"image/jpeg"},
+	".gif":     {"image/gif"},
+	".svg":     {"image/svg+xml"},
+	".woff":    {"font/woff", "application/font-woff", "application/x-font-woff"},
+	".woff2":   {"font/woff2"},
+	".ttf":     {"font/ttf", "font/sfnt", "application/x-font-ttf"},
+	".eot":     {"application/vnd.ms-fontobject"},
+	".ico":     {"image/x-icon", "image/vnd.microsoft.icon"},
+	".txt":     {"text/plain"},
+	".yml":     {"application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml"},
+	".yaml":    {"application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml"},
+	".vue":     {"text/x-vue"},
+	// video/mp2t : type MIME standard de l'extension .ts, souvent envoyé par les navigateurs
+	".ts":   {"application/typescript", "text/typescript", "video/mp2t"},
+	".html": {"text/html"},
+}
+
+// textExtensions sont les extensions de fichiers texte : tout contenu détecté comme texte
+// (text/plain, text/html, text/xml) leur correspond
+var textExtensions = map[string]bool{
+	".md": true, ".css": true, ".scss": true, ".sass": true, ".less": true, ".postcss": true,
+	".js": true, ".json": true, ".svg": true, ".txt": true, ".yml": true, ".yaml": true,
+	".vue": true, ".ts": true, ".html": true,
+}
+
+// genericMimeTypes ne renseignent pas sur le type réel du fichier
+var genericMimeTypes = map[string]bool{
+	"":                         true,
+	"application/octet-stream": true,
+	"binary/octet-stream":      true,
+	"application/unknown":      true,
+	"text/plain":               true,
+}
+
+// mediaType retourne le type principal d'un type MIME, sans paramètres et en minuscules
+func mediaType(contentType string) string {
+	if parsed, _, err := mime.ParseMediaType(contentType); err == nil {
+		return parsed
+	}
+	return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+}
+
+// sniffContentType détecte le type d'un fichier uploadé depuis ses premiers octets ; vide
+// si le contenu est illisible ou vide
+func sniffContentType(header *multipart.FileHeader) string {
+	if header.Size == 0 {
+		return ""
+	}
+	file, err := header.Open()
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	buf := make([]byte, sniffLength)
+	n, err := io.ReadFull(file, buf)
+	if n == 0 || (err != nil && err != io.ErrUnexpectedEOF && err != io.EOF) {
+		return ""
+	}
+	return mediaType(http.DetectContentType(buf[:n]))
+}
+
+// matchesExtension indique si un type MIME correspond à l'extension du fichier
+func matchesExtension(mediaType, ext string) bool {
+	if slices.Contains(extensionMimeTypes[ext], mediaType) {
+		return true
+	}
+	return textExtensions[ext] && strings.HasPrefix(mediaType, "text/")
+}
+
+// reconcileMimeType décide du type MIME d'un fichier à partir du type déclaré par le client,
+// du type détecté depuis le contenu et de l'extension. Le signal le plus spécifique l'emporte :
+// un type détecté précis (image, police, archive...) doit être autorisé ou correspondre à
+// l'extension ; sinon le fichier n'est refusé que si le type déclaré et le contenu contredisent
+// tous deux l'extension. Retourne le type refusé, vide si le fichier est accepté.
+func (vs *ValidationService) reconcileMimeType(declared, sniffed, ext string) string {
+	if !genericMimeTypes[sniffed] {
+		if matchesExtension(sniffed, ext) || vs.config.AllowedMimeTypes[sniffed] {
+			return ""
+		}
+		return sniffed
+	}
+
+	if genericMimeTypes[declared] || vs.config.AllowedMimeTypes[declared] || matchesExtension(declared, ext) {
+		return ""
+	}
+	// Un contenu texte confirme une extension de fichier texte malgré un type déclaré erroné
+	if sniffed == "text/plain" && textExtensions[ext] {
+		return ""
+	}
+	return declared
+}
@@ -0,0 +1,96 @@
+// internal/validation/mime_test.go
+package validation
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/textproto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// uploadedFileHeader construit un header multipart lisible, comme ceux reçus à l'upload
+func uploadedFileHeader(t *testing.T, filename, contentType string, content []byte) *multipart.FileHeader {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	partHeader := make(textproto.MIMEHeader)
+	partHeader.Set("Content-Disposition", `form-data; name="files"; filename="`+filename+`"`)
+	if contentType != "" {
+		partHeader.Set("Content-Type", contentType)
+	}
+	part, err := writer.CreatePart(partHeader)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = form.RemoveAll() })
+	require.Len(t, form.File["files"], 1)
+	return form.File["files"][0]
+}
+
+func TestValidateFileHeaderMimeReconciliation(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 32)...)
+	pdf := []byte("%PDF-1.7\n1 0 obj\n")
+	gzip := []byte{0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00}
+	markdown := []byte("# Titre\n\nContenu de la slide\n")
+
+	tests := []struct {
+		name        string
+		filename    string
+		contentType string
+		content     []byte
+		wantError   bool
+	}{
+		{"Declared type matches", "slides.md", "text/markdown", markdown, false},
+		{"Generic declared type, text content", "slides.md", "application/octet-stream", markdown, false},
+		{"No declared type", "slides.md", "", markdown, false},
+		{"Declared alias of the extension", "app.js", "text/javascript", []byte("export default {}\n"), false},
+		{"Extension type sent by browsers", "main.ts", "video/mp2t", []byte("export const a = 1\n"), false},
+		{"HTML-looking markdown", "slides.md", "text/html", []byte("<div>Intro</div>\n"), false},
+		{"Wrong declared type, text content", "theme.css", "application/x-msdownload", []byte("body { color: red; }\n"), false},
+		{"Generic declared type, image content", "logo.png", "application/octet-stream", png, false},
+		{"Image content, mislabelled as text", "logo.png", "text/plain", png, false},
+		{"Disallowed content behind allowed type", "slides.md", "text/markdown", pdf, true},
+		{"Disallowed content behind generic type", "logo.png", "application/octet-stream", gzip, true},
+		{"Disallowed declared type, binary content", "logo.png", "application/x-msdownload", []byte{0x00, 0x01, 0x02}, true},
+		{"Disallowed declared type, text content for binary extension", "font.woff", "application/x-msdownload", []byte("MZ text"), true},
+	}
+
+	service := NewValidationService(DefaultValidationConfig())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := service.ValidateFileHeader(uploadedFileHeader(t, tt.filename, tt.contentType, tt.content))
+
+			if !tt.wantError {
+				assert.True(t, result.Valid, "unexpected errors: %+v", result.Errors)
+				return
+			}
+			require.False(t, result.Valid)
+			require.Len(t, result.Errors, 1)
+			assert.Equal(t, "FORBIDDEN_MIME_TYPE", result.Errors[0].Code)
+		})
+	}
+
+	t.Run("Detected type is reported", func(t *testing.T) {
+		result := service.ValidateFileHeader(uploadedFileHeader(t, "slides.md", "text/markdown", pdf))
+		require.Len(t, result.Errors, 1)
+		assert.Equal(t, "application/pdf", result.Errors[0].Value)
+		assert.Contains(t, result.Errors[0].Message, "detected content type application/pdf")
+	})
+
+	t.Run("Directory rule skips the check", func(t *testing.T) {
+		config := DefaultValidationConfig()
+		rules, err := ParseDirectoryRules(map[string]string{"docs": ".pdf"})
+		require.NoError(t, err)
+		config.DirectoryRules = rules
+
+		header := uploadedFileHeader(t, "docs/guide.pdf", "application/pdf", pdf)
+		header.Filename = "guide.pdf"
+		assert.True(t, NewValidationService(config).ValidateFileHeader(header).Valid)
+	})
+}
@@ -3,25 +3,149 @@
 package validation
 
 import (
+	"encoding/json"
 	"fmt"
 	"mime/multipart"
+	neturl "net/url"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"unicode/utf8"
 
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 // ValidationConfig contient la configuration de validation
 type ValidationConfig struct {
-	MaxFileSize       int64           // Taille max par fichier (bytes)
-	MaxTotalSize      int64           // Taille max totale (bytes)
-	MaxFiles          int             // Nombre max de fichiers
-	AllowedExtensions map[string]bool // Extensions autorisées
-	MaxFilenameLength int             // Longueur max du nom de fichier
-	AllowedMimeTypes  map[string]bool // Types MIME autorisés
+	MaxFileSize        int64           // Taille max par fichier (bytes)
+	MaxTotalSize       int64           // Taille max totale (bytes)
+	MaxFiles           int             // Nombre max de fichiers
+	AllowedExtensions  map[string]bool // Extensions autorisées
+	MaxFilenameLength  int             // Longueur max du nom de fichier
+	AllowedMimeTypes   map[string]bool // Types MIME autorisés
+	CallbackPolicy     *CallbackPolicy // Politique anti-SSRF des callbacks
+	MaxBatchSize       int             // Nombre max de jobs par soumission groupée
+	MaxPathDepth       int             // Profondeur max d'un chemin de fichier (défaut: DefaultMaxPathDepth)
+	KeyNamespaceLength int             // Longueur du namespace ajouté devant les clés de stockage, "/" compris
+	MaxMetadataSize    int             // Taille max des métadonnées sérialisées en JSON (défaut: DefaultMaxMetadataSize)
+	MaxMetadataDepth   int             // Profondeur max d'imbrication des métadonnées (défaut: DefaultMaxMetadataDepth)
+
+	// RejectEmptyFiles refuse les fichiers vides à l'upload (EMPTY_FILE), sauf ceux dont
+	// l'extension figure dans EmptyFileExtensions (".gitkeep", ".css")
+	RejectEmptyFiles    bool
+	EmptyFileExtensions map[string]bool
+
+	// DirectoryRules adapte la validation des fichiers par dossier des sources ("assets",
+	// "assets/img"), le dossier le plus profond l'emportant (nil = validation uniforme)
+	DirectoryRules map[string]*DirectoryRule
+
+	// ContentPatterns sont les motifs refusés dans le contenu des fichiers, par extension
+	// (".js"), vérifiés par ValidateContentSafety (défaut: DefaultContentPatterns)
+	ContentPatterns map[string][]ContentPattern
+
+	// MaxArchiveExpansionRatio borne la taille décompressée d'une archive de sources à ce
+	// multiple de sa taille (défaut: DefaultMaxArchiveExpansionRatio)
+	MaxArchiveExpansionRatio int
+
+	// Messages sont les catalogues des messages d'erreur par langue, choisie avec
+	// Accept-Language (défaut: DefaultMessageCatalog)
+	Messages MessageCatalog
+}
+
+// DefaultMaxPathDepth est la profondeur de dossiers maximale par défaut d'un chemin de fichier
+const DefaultMaxPathDepth = 10
+
+const (
+	// DefaultMaxMetadataSize est la taille maximale par défaut des métadonnées d'un job en JSON
+	DefaultMaxMetadataSize = 64 * 1024
+	// DefaultMaxMetadataDepth est la profondeur d'imbrication maximale par défaut des
+	// métadonnées, l'objet metadata comptant pour un niveau
+	DefaultMaxMetadataDepth = 5
+)
+
+const (
+	// MaxStorageKeyLength est la longueur maximale d'une clé d'objet (limite S3 : 1024 octets)
+	MaxStorageKeyLength = 1024
+	// ObjectKeyPrefixLength est la longueur du préfixe "sources/{job_id}/" (ou
+	// "results/{course_id}/") ajouté par le storage devant le chemin d'un fichier
+	ObjectKeyPrefixLength = len("sources/") + 36 + len("/")
+	// CompressedVariantSuffixLength est la longueur du suffixe des variantes précompressées
+	// d'un résultat (.gz, .br), réservée dans la clé de chaque fichier
+	CompressedVariantSuffixLength = len(".gz")
+)
+
+// maxStorageKeyPathLength retourne la longueur max d'un chemin de fichier pour que sa clé
+// de stockage, préfixes et suffixe de variante compressée compris, tienne dans MaxStorageKeyLength
+func (c *ValidationConfig) maxStorageKeyPathLength() int {
+	return MaxStorageKeyLength - ObjectKeyPrefixLength - CompressedVariantSuffixLength - c.KeyNamespaceLength
+}
+
+// maxPathDepth retourne la profondeur max configurée, ou la valeur par défaut
+func (c *ValidationConfig) maxPathDepth() int {
+	if c.MaxPathDepth <= 0 {
+		return DefaultMaxPathDepth
+	}
+	return c.MaxPathDepth
+}
+
+// maxMetadataSize retourne la taille max configurée des métadonnées, ou la valeur par défaut
+func (c *ValidationConfig) maxMetadataSize() int {
+	if c.MaxMetadataSize <= 0 {
+		return DefaultMaxMetadataSize
+	}
+	return c.MaxMetadataSize
+}
+
+// maxMetadataDepth retourne la profondeur max configurée des métadonnées, ou la valeur par défaut
+func (c *ValidationConfig) maxMetadataDepth() int {
+	if c.MaxMetadataDepth <= 0 {
+		return DefaultMaxMetadataDepth
+	}
+	return c.MaxMetadataDepth
+}
+
+// UploadLimits retourne les types de fichiers et les limites appliqués à l'upload des
+// sources, pour que les clients valident avant d'envoyer
+func (c *ValidationConfig) UploadLimits() *models.UploadLimits {
+	limits := &models.UploadLimits{
+		AllowedExtensions:        sortedKeys(c.AllowedExtensions),
+		AllowedMimeTypes:         sortedKeys(c.AllowedMimeTypes),
+		MaxFileSize:              c.MaxFileSize,
+		MaxTotalSize:             c.MaxTotalSize,
+		MaxFiles:                 c.MaxFiles,
+		MaxPathDepth:             c.maxPathDepth(),
+		MaxFilenameLength:        c.MaxFilenameLength,
+		RejectEmptyFiles:         c.RejectEmptyFiles,
+		MaxArchiveExpansionRatio: int(c.maxArchiveExpansionRatio()),
+	}
+	if c.RejectEmptyFiles {
+		limits.EmptyFileExtensions = sortedKeys(c.EmptyFileExtensions)
+	}
+
+	if len(c.DirectoryRules) > 0 {
+		limits.DirectoryRules = make(map[string]string, len(c.DirectoryRules))
+		for directory, rule := range c.DirectoryRules {
+			limits.DirectoryRules[directory] = rule.Policy()
+		}
+	}
+
+	return limits
+}
+
+// sortedKeys retourne les clés actives d'un ensemble, triées
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key, enabled := range set {
+		if enabled {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 // DefaultValidationConfig retourne une configuration par défaut sécurisée
@@ -32,31 +156,38 @@ func DefaultValidationConfig() *ValidationConfig {
 		MaxFiles:          100,
 		MaxFilenameLength: 255,
 		AllowedExtensions: map[string]bool{
-			".md":    true, // Markdown
-			".css":   true, // Styles
-			".js":    true, // JavaScript
-			".json":  true, // Configuration
-			".png":   true, // Images
-			".jpg":   true,
-			".jpeg":  true,
-			".gif":   true,
-			".svg":   true,
-			".woff":  true, // Fonts
-			".woff2": true,
-			".ttf":   true,
-			".eot":   true,
-			".ico":   true, // Icon
-			".txt":   true, // Texte
-			".yml":   true, // YAML
-			".yaml":  true,
-			".vue":   true,
-			".ts":    true,
-			".html":  true,
+			".md":      true, // Markdown
+			".css":     true, // Styles
+			".scss":    true, // Styles préprocessés (sass)
+			".sass":    true,
+			".less":    true,
+			".postcss": true,
+			".js":      true, // JavaScript
+			".json":    true, // Configuration
+			".png":     true, // Images
+			".jpg":     true,
+			".jpeg":    true,
+			".gif":     true,
+			".svg":     true,
+			".woff":    true, // Fonts
+			".woff2":   true,
+			".ttf":     true,
+			".eot":     true,
+			".ico":     true, // Icon
+			".txt":     true, // Texte
+			".yml":     true, // YAML
+			".yaml":    true,
+			".vue":     true,
+			".ts":      true,
+			".html":    true,
 		},
 		AllowedMimeTypes: map[string]bool{
 			"text/plain":               true,
 			"text/markdown":            true,
 			"text/css":                 true,
+			"text/x-scss":              true,
+			"text/x-sass":              true,
+			"text/x-less":              true,
 			"application/javascript":   true,
 			"application/json":         true,
 			"image/png":                true,
@@ -69,12 +200,41 @@ func DefaultValidationConfig() *ValidationConfig {
 			"application/x-font-woff":  true,
 			"application/octet-stream": true, // Pour les fonts
 		},
+		CallbackPolicy:   DefaultCallbackPolicy(),
+		MaxBatchSize:     50,
+		MaxPathDepth:     DefaultMaxPathDepth,
+		MaxMetadataSize:  DefaultMaxMetadataSize,
+		MaxMetadataDepth: DefaultMaxMetadataDepth,
+		ContentPatterns:  DefaultContentPatterns(),
+		Messages:         DefaultMessageCatalog(),
 	}
 }
 
 // ValidationService gère la validation des entrées
 type ValidationService struct {
 	config *ValidationConfig
+
+	// filePaths retrouve le chemin complet des fichiers uploadés (nil = nom de base)
+	filePaths FilePathResolver
+}
+
+// FilePathResolver retrouve le chemin complet et sanitisé d'un fichier uploadé
+type FilePathResolver interface {
+	ExtractFilePathFromMultipart(fileHeader *multipart.FileHeader) string
+	SanitizeFilePath(filePath string) string
+}
+
+// SetFilePathResolver configure la résolution des chemins utilisée pour détecter les doublons
+func (vs *ValidationService) SetFilePathResolver(resolver FilePathResolver) {
+	vs.filePaths = resolver
+}
+
+// filePath retourne le chemin d'un fichier uploadé, ou son nom sans résolveur configuré
+func (vs *ValidationService) filePath(file *multipart.FileHeader) string {
+	if vs.filePaths == nil {
+		return file.Filename
+	}
+	return vs.filePaths.SanitizeFilePath(vs.filePaths.ExtractFilePathFromMultipart(file))
 }
 
 // NewValidationService crée un nouveau service de validation
@@ -153,6 +313,12 @@ func (vs *ValidationService) ValidateCourseID(courseID string) *ValidationResult
 
 // ValidateFilename valide un nom de fichier de manière robuste
 func (vs *ValidationService) ValidateFilename(filename string, directory bool) *ValidationResult {
+	return vs.validateFilename(filename, directory, nil)
+}
+
+// validateFilename valide un nom de fichier, rule (nil possible) élargissant les extensions
+// acceptées pour un fichier de son dossier
+func (vs *ValidationService) validateFilename(filename string, directory bool, rule *DirectoryRule) *ValidationResult {
 	result := &ValidationResult{Valid: true}
 
 	if filename == "" {
@@ -215,10 +381,10 @@ func (vs *ValidationService) ValidateFilename(filename string, directory bool) *
 	ext := strings.ToLower(filepath.Ext(filename))
 	if !directory {
 		if ext == "" {
-			if filename != "LICENSE" {
+			if filename != "LICENSE" && (rule == nil || !rule.Binary) {
 				result.AddError("filename", filename, "filename must have an extension", "NO_EXTENSION")
 			}
-		} else if !vs.config.AllowedExtensions[ext] {
+		} else if !vs.config.AllowedExtensions[ext] && !rule.allowsExtension(ext) {
 			result.AddError("filename", filename,
 				fmt.Sprintf("file extension %s not allowed", ext),
 				"FORBIDDEN_EXTENSION")
@@ -232,8 +398,12 @@ func (vs *ValidationService) ValidateFilename(filename string, directory bool) *
 func (vs *ValidationService) ValidateFileHeader(header *multipart.FileHeader) *ValidationResult {
 	result := &ValidationResult{Valid: true}
 
+	// Le nom du header est sans dossier : la règle est celle du chemin complet
+	pathSanitizer := &APIValidator{validationService: vs}
+	_, rule := vs.config.directoryRule(pathSanitizer.SanitizeFilePath(pathSanitizer.ExtractFilePathFromMultipart(header)))
+
 	// Valider le nom de fichier
-	filenameResult := vs.ValidateFilename(header.Filename, false)
+	filenameResult := vs.validateFilename(header.Filename, false, rule)
 	if !filenameResult.Valid {
 		result.Valid = false
 		result.Errors = append(result.Errors, filenameResult.Errors...)
@@ -246,21 +416,51 @@ func (vs *ValidationService) ValidateFileHeader(header *multipart.FileHeader) *V
 			"FILE_TOO_LARGE")
 	}
 
-	// Vérifier le type MIME si disponible
-	if len(header.Header["Content-Type"]) > 0 {
-		contentType := header.Header["Content-Type"][0]
-		// Extraire le type principal (avant les paramètres)
-		mainType := strings.Split(contentType, ";")[0]
-		if !vs.config.AllowedMimeTypes[mainType] {
-			result.AddError("content_type", contentType,
-				fmt.Sprintf("content type %s not allowed", mainType),
-				"FORBIDDEN_MIME_TYPE")
+	// Fichier vide : refusé seulement si configuré, sauf extension admise
+	if header.Size == 0 && vs.config.RejectEmptyFiles &&
+		!vs.config.EmptyFileExtensions[strings.ToLower(filepath.Ext(header.Filename))] {
+		result.AddError("file_size", "0",
+			fmt.Sprintf("file %v is empty", header.Filename),
+			"EMPTY_FILE")
+	}
+
+	// Vérifier le type MIME (déclaré, détecté et extension), sauf pour un type de fichier
+	// admis par le dossier
+	if ext := strings.ToLower(filepath.Ext(header.Filename)); !rule.allowsExtension(ext) {
+		declared := mediaType(header.Header.Get("Content-Type"))
+		sniffed := sniffContentType(header)
+		if rejected := vs.reconcileMimeType(declared, sniffed, ext); rejected != "" {
+			value := header.Header.Get("Content-Type")
+			message := fmt.Sprintf("content type %s not allowed", rejected)
+			if rejected == sniffed {
+				value = sniffed
+				message = fmt.Sprintf("detected content type %s not allowed for %s files", rejected, ext)
+			}
+			result.AddError("content_type", value, message, "FORBIDDEN_MIME_TYPE")
 		}
 	}
 
 	return result
 }
 
+// ValidateBatchSize vérifie le nombre de jobs d'une soumission groupée
+func (vs *ValidationService) ValidateBatchSize(count int) *ValidationResult {
+	result := &ValidationResult{Valid: true}
+
+	if count == 0 {
+		result.AddError("jobs", "", "no jobs provided", "EMPTY_BATCH")
+		return result
+	}
+
+	if vs.config.MaxBatchSize > 0 && count > vs.config.MaxBatchSize {
+		result.AddError("jobs", fmt.Sprintf("%d jobs", count),
+			fmt.Sprintf("too many jobs in batch (max %d)", vs.config.MaxBatchSize),
+			"BATCH_TOO_LARGE")
+	}
+
+	return result
+}
+
 // ValidateFiles valide un ensemble de fichiers
 func (vs *ValidationService) ValidateFiles(files []*multipart.FileHeader) *ValidationResult {
 	result := &ValidationResult{Valid: true}
@@ -271,15 +471,10 @@ func (vs *ValidationService) ValidateFiles(files []*multipart.FileHeader) *Valid
 		return result
 	}
 
-	if len(files) > vs.config.MaxFiles {
-		result.AddError("files", fmt.Sprintf("%d files", len(files)),
-			fmt.Sprintf("too many files (max %d)", vs.config.MaxFiles),
-			"TOO_MANY_FILES")
-	}
+	vs.checkUploadLimits(files, result)
 
-	// Vérifier la taille totale et valider chaque fichier
-	var totalSize int64
-	filenames := make(map[string]bool) // Détecter les doublons
+	// Valider chaque fichier
+	paths := make(map[string]bool) // Détecter les doublons sur le chemin complet
 
 	for i, file := range files {
 		// Valider le fichier individuel
@@ -293,24 +488,43 @@ func (vs *ValidationService) ValidateFiles(files []*multipart.FileHeader) *Valid
 			result.Errors = append(result.Errors, fileResult.Errors...)
 		}
 
-		// Vérifier les doublons
-		if filenames[file.Filename] {
-			result.AddError(fmt.Sprintf("files[%d].filename", i), file.Filename,
+		// Vérifier les doublons : docs/index.md et src/index.md sont des fichiers distincts
+		path := vs.filePath(file)
+		if paths[path] {
+			result.AddError(fmt.Sprintf("files[%d].filename", i), path,
 				"duplicate filename", "DUPLICATE_FILENAME")
 		}
-		filenames[file.Filename] = true
+		paths[path] = true
+	}
+
+	return result
+}
+
+// ValidateUploadLimits vérifie le nombre de fichiers et la taille totale d'un upload
+func (vs *ValidationService) ValidateUploadLimits(files []*multipart.FileHeader) *ValidationResult {
+	result := &ValidationResult{Valid: true}
+	vs.checkUploadLimits(files, result)
+	return result
+}
+
+// checkUploadLimits ajoute à result les erreurs de nombre de fichiers et de taille totale
+func (vs *ValidationService) checkUploadLimits(files []*multipart.FileHeader, result *ValidationResult) {
+	if len(files) > vs.config.MaxFiles {
+		result.AddError("files", fmt.Sprintf("%d files", len(files)),
+			fmt.Sprintf("too many files (max %d)", vs.config.MaxFiles),
+			"TOO_MANY_FILES")
+	}
 
+	var totalSize int64
+	for _, file := range files {
 		totalSize += file.Size
 	}
 
-	// Vérifier la taille totale
 	if totalSize > vs.config.MaxTotalSize {
 		result.AddError("total_size", fmt.Sprintf("%d", totalSize),
 			fmt.Sprintf("total size too large (max %d bytes)", vs.config.MaxTotalSize),
 			"TOTAL_SIZE_TOO_LARGE")
 	}
-
-	return result
 }
 
 // ValidateCallbackURL valide une URL de callback
@@ -343,6 +557,17 @@ func (vs *ValidationService) ValidateCallbackURL(url string) *ValidationResult {
 	// 	result.AddError("callback_url", url, "localhost URLs not allowed", "LOCALHOST_NOT_ALLOWED")
 	// }
 
+	// Appliquer la politique d'hôtes (la résolution DNS est faite à l'envoi)
+	if vs.config.CallbackPolicy != nil && result.Valid {
+		if parsed, err := neturl.Parse(url); err == nil {
+			hostResult := vs.config.CallbackPolicy.ValidateHost(parsed.Hostname())
+			if !hostResult.Valid {
+				result.Valid = false
+				result.Errors = append(result.Errors, hostResult.Errors...)
+			}
+		}
+	}
+
 	return result
 }
 
@@ -368,6 +593,252 @@ func (vs *ValidationService) ValidateSourcePath(path string) *ValidationResult {
 	return result
 }
 
+// ValidateEntryFile valide le fichier de slides explicite d'une requête (optionnel)
+func (vs *ValidationService) ValidateEntryFile(path string) *ValidationResult {
+	result := &ValidationResult{Valid: true}
+
+	if path == "" {
+		return result
+	}
+
+	if strings.Contains(path, "..") || strings.HasPrefix(path, "/") || strings.Contains(path, "\\") {
+		result.AddError("entry_file", path, "entry file must be a relative path inside the sources", "PATH_TRAVERSAL")
+	}
+
+	if !strings.EqualFold(filepath.Ext(path), ".md") {
+		result.AddError("entry_file", path, "entry file must be a markdown file (.md)", "INVALID_ENTRY_FILE")
+	}
+
+	if len(path) > 500 {
+		result.AddError("entry_file", path, "path too long (max 500 characters)", "PATH_TOO_LONG")
+	}
+
+	return result
+}
+
+// outputDirSegmentPattern restreint chaque segment du dossier de sortie d'un build
+// (pas de dossier caché, ni "." ou "..")
+var outputDirSegmentPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]*$`)
+
+// reservedOutputDirs ne peuvent pas recevoir le build : dépendances du cours et fichiers
+// statiques que Slidev copie dans la sortie
+var reservedOutputDirs = map[string]bool{"node_modules": true, "public": true}
+
+// IsSafeOutputDir indique si un dossier de sortie est un chemin relatif sûr du workspace
+func IsSafeOutputDir(dir string) bool {
+	if dir == "" || len(dir) > 200 {
+		return false
+	}
+	segments := strings.Split(dir, "/")
+	if reservedOutputDirs[segments[0]] {
+		return false
+	}
+	for _, segment := range segments {
+		if !outputDirSegmentPattern.MatchString(segment) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateOutputDir valide le dossier de sortie explicite d'une requête (optionnel)
+func (vs *ValidationService) ValidateOutputDir(dir string) *ValidationResult {
+	result := &ValidationResult{Valid: true}
+
+	if dir == "" {
+		return result
+	}
+
+	if strings.Contains(dir, "..") || strings.HasPrefix(dir, "/") || strings.Contains(dir, "\\") {
+		result.AddError("output_dir", dir, "output directory must be a relative path inside the workspace", "PATH_TRAVERSAL")
+		return result
+	}
+
+	if !IsSafeOutputDir(dir) {
+		result.AddError("output_dir", dir,
+			"output directory must be 1-200 characters of letters, digits, '.', '_', '-' and '/', without hidden, node_modules or public directories",
+			"INVALID_OUTPUT_DIR")
+	}
+
+	return result
+}
+
+// ValidateSourceRetention valide le sort des sources demandé par une requête (optionnel)
+func (vs *ValidationService) ValidateSourceRetention(retention models.SourceRetention) *ValidationResult {
+	result := &ValidationResult{Valid: true}
+
+	switch retention {
+	case "", models.SourceRetentionKeep, models.SourceRetentionDeleteOnSuccess:
+	default:
+		result.AddError("source_retention", string(retention),
+			"source retention must be 'keep' or 'delete-on-success'", "INVALID_SOURCE_RETENTION")
+	}
+
+	return result
+}
+
+// maxResultPrefixDepth est le nombre maximum de segments d'une destination de résultats
+const maxResultPrefixDepth = 10
+
+// ValidateResultPrefix valide la destination personnalisée des résultats d'une requête
+// (optionnelle) : chemin relatif de segments sans dossier caché, placé par le storage sous
+// published/{course_id}/ dans le namespace du déploiement
+func (vs *ValidationService) ValidateResultPrefix(prefix string) *ValidationResult {
+	result := &ValidationResult{Valid: true}
+
+	if prefix == "" {
+		return result
+	}
+
+	if strings.Contains(prefix, "..") || strings.HasPrefix(prefix, "/") || strings.Contains(prefix, "\\") {
+		result.AddError("result_prefix", prefix, "result prefix must be a relative path", "PATH_TRAVERSAL")
+		return result
+	}
+
+	segments := strings.Split(prefix, "/")
+	valid := len(prefix) <= 200 && len(segments) <= maxResultPrefixDepth
+	for _, segment := range segments {
+		valid = valid && outputDirSegmentPattern.MatchString(segment)
+	}
+	if !valid {
+		result.AddError("result_prefix", prefix,
+			fmt.Sprintf("result prefix must be 1-200 characters and at most %d segments of letters, digits, '.', '_' and '-' separated by '/', without hidden directories", maxResultPrefixDepth),
+			"INVALID_RESULT_PREFIX")
+	}
+
+	return result
+}
+
+// maxBuildFlags est le nombre maximum d'options de build par job
+const maxBuildFlags = 10
+
+// buildBasePattern restreint --base à un chemin d'URL absolu simple
+var buildBasePattern = regexp.MustCompile(`^/[A-Za-z0-9._~/-]{0,200}$`)
+
+// AllowedBuildFlags associe chaque option "slidev build" acceptée dans une requête au
+// format de sa valeur (nil = option sans valeur). --out est fixé par le worker, --watch
+// ne termine jamais et --theme pourrait déclencher une installation interactive.
+var AllowedBuildFlags = map[string]*regexp.Regexp{
+	"--download":      nil, // PDF téléchargeable (nécessite playwright-chromium)
+	"--inspect":       nil, // Rapport vite-plugin-inspect dans le build
+	"--without-notes": nil, // Build sans les notes du présentateur
+	"--base":          buildBasePattern,
+}
+
+// IsAllowedBuildFlag indique si une option de build ("--flag" ou "--flag=valeur") est autorisée
+func IsAllowedBuildFlag(flag string) bool {
+	name, value, hasValue := strings.Cut(flag, "=")
+	pattern, allowed := AllowedBuildFlags[name]
+	if !allowed {
+		return false
+	}
+	if pattern == nil {
+		return !hasValue
+	}
+	return hasValue && pattern.MatchString(value) && !strings.Contains(value, "..")
+}
+
+// maxLabels limite le nombre de labels d'un job
+const maxLabels = 20
+
+// labelKeyPattern restreint les clés de label (minuscules, chiffres, ".", "_", "-", max 63)
+var labelKeyPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9._-]{0,61}[a-z0-9])?$`)
+
+// labelValuePattern restreint les valeurs de label (lettres, chiffres, ".", "_", "-", max 63)
+var labelValuePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]{0,61}[A-Za-z0-9])?$`)
+
+// ValidateLabel valide une paire clé/valeur de label
+func (vs *ValidationService) ValidateLabel(key, value string) *ValidationResult {
+	result := &ValidationResult{Valid: true}
+
+	if !labelKeyPattern.MatchString(key) {
+		result.AddError("labels", key,
+			"label key must be 1-63 lowercase letters, digits, '.', '_' or '-', starting and ending with a letter or digit",
+			"INVALID_LABEL_KEY")
+	}
+	if !labelValuePattern.MatchString(value) {
+		result.AddError("labels."+key, value,
+			"label value must be 1-63 letters, digits, '.', '_' or '-', starting and ending with a letter or digit",
+			"INVALID_LABEL_VALUE")
+	}
+
+	return result
+}
+
+// ValidateLabels valide les labels d'une requête (optionnels)
+func (vs *ValidationService) ValidateLabels(labels map[string]string) *ValidationResult {
+	result := &ValidationResult{Valid: true}
+
+	if len(labels) > maxLabels {
+		result.AddError("labels", fmt.Sprintf("%d labels", len(labels)),
+			fmt.Sprintf("too many labels (max %d)", maxLabels), "TOO_MANY_LABELS")
+		return result
+	}
+
+	for key, value := range labels {
+		labelResult := vs.ValidateLabel(key, value)
+		if !labelResult.Valid {
+			result.Valid = false
+			result.Errors = append(result.Errors, labelResult.Errors...)
+		}
+	}
+
+	return result
+}
+
+// ValidateBuildFlags valide les options Slidev supplémentaires d'une requête (optionnelles)
+func (vs *ValidationService) ValidateBuildFlags(flags []string) *ValidationResult {
+	result := &ValidationResult{Valid: true}
+
+	if len(flags) > maxBuildFlags {
+		result.AddError("build_flags", fmt.Sprintf("%d flags", len(flags)),
+			fmt.Sprintf("too many build flags (max %d)", maxBuildFlags), "TOO_MANY_BUILD_FLAGS")
+		return result
+	}
+
+	for _, flag := range flags {
+		name, _, _ := strings.Cut(flag, "=")
+		if _, allowed := AllowedBuildFlags[name]; !allowed {
+			result.AddError("build_flags", flag,
+				"build flag not allowed (allowed: --download, --inspect, --without-notes, --base=<path>)", "BUILD_FLAG_NOT_ALLOWED")
+			continue
+		}
+		if !IsAllowedBuildFlag(flag) {
+			result.AddError("build_flags", flag, "invalid value for build flag "+name, "INVALID_BUILD_FLAG_VALUE")
+		}
+	}
+
+	return result
+}
+
+// MaxMatrixThemes est le nombre maximum de thèmes construits par un job en mode matrice
+const MaxMatrixThemes = 5
+
+// ValidateThemes valide les thèmes du mode matrice d'une requête (optionnels)
+func (vs *ValidationService) ValidateThemes(themes []string) *ValidationResult {
+	result := &ValidationResult{Valid: true}
+
+	if len(themes) > MaxMatrixThemes {
+		result.AddError("themes", fmt.Sprintf("%d themes", len(themes)),
+			fmt.Sprintf("too many themes (max %d)", MaxMatrixThemes), "TOO_MANY_THEMES")
+		return result
+	}
+
+	seen := make(map[string]bool, len(themes))
+	for _, theme := range themes {
+		if !themeNamePattern.MatchString(theme) {
+			result.AddError("themes", theme, "theme must be a Slidev theme name or an unscoped npm package name", "INVALID_THEME")
+			continue
+		}
+		if seen[theme] {
+			result.AddError("themes", theme, "duplicate theme", "DUPLICATE_THEME")
+		}
+		seen[theme] = true
+	}
+
+	return result
+}
+
 // ValidateMetadata valide les métadonnées
 func (vs *ValidationService) ValidateMetadata(metadata map[string]interface{}) *ValidationResult {
 	result := &ValidationResult{Valid: true}
@@ -382,23 +853,88 @@ func (vs *ValidationService) ValidateMetadata(metadata map[string]interface{}) *
 			"too many metadata keys (max 50)", "TOO_MANY_KEYS")
 	}
 
-	// Valider chaque clé et valeur
-	for key, value := range metadata {
-		// Valider la clé
+	// Les métadonnées sont stockées en JSONB : elles doivent être sérialisables
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		result.AddError("metadata", "", fmt.Sprintf("metadata must be JSON-serializable: %v", err), "INVALID_METADATA")
+		return result
+	}
+	if maxSize := vs.config.maxMetadataSize(); len(encoded) > maxSize {
+		result.AddError("metadata", fmt.Sprintf("%d bytes", len(encoded)),
+			fmt.Sprintf("metadata too large (max %d bytes as JSON)", maxSize), "METADATA_TOO_LARGE")
+		return result
+	}
+
+	// Parcours de la forme JSON, quel que soit le type Go des valeurs
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(encoded, &normalized); err != nil {
+		result.AddError("metadata", "", fmt.Sprintf("metadata must be a JSON object: %v", err), "INVALID_METADATA")
+		return result
+	}
+	vs.validateMetadataObject(result, "", normalized, 1)
+
+	// Clés de metadata interprétées par le worker
+	if value, exists := metadata[models.MetadataSPAFallback]; exists {
+		if _, isBool := value.(bool); !isBool {
+			result.AddError("metadata", models.MetadataSPAFallback,
+				fmt.Sprintf("metadata %s must be a boolean", models.MetadataSPAFallback),
+				"INVALID_TYPE")
+		}
+	}
+
+	return result
+}
+
+// validateMetadataObject valide les clés et les valeurs d'un objet de métadonnées situé au
+// niveau depth, path étant son chemin ("a.b") pour les messages d'erreur
+func (vs *ValidationService) validateMetadataObject(result *ValidationResult, path string, object map[string]interface{}, depth int) {
+	for key, value := range object {
 		if len(key) > 100 {
 			result.AddError("metadata", key,
 				fmt.Sprintf("metadata key too long (max 100 characters): %s", key),
 				"KEY_TOO_LONG")
 		}
 
-		// Valider la valeur (convertir en string pour la validation)
-		valueStr := fmt.Sprintf("%v", value)
-		if len(valueStr) > 1000 {
-			result.AddError("metadata", key,
-				fmt.Sprintf("metadata value too long (max 1000 characters) for key: %s", key),
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+		vs.validateMetadataValue(result, keyPath, value, depth)
+	}
+}
+
+// validateMetadataValue valide une valeur de métadonnée : chaîne bornée, et objets ou
+// tableaux dans la limite de profondeur
+func (vs *ValidationService) validateMetadataValue(result *ValidationResult, path string, value interface{}, depth int) {
+	switch v := value.(type) {
+	case string:
+		if len(v) > 1000 {
+			result.AddError("metadata", path,
+				fmt.Sprintf("metadata value too long (max 1000 characters) for key: %s", path),
 				"VALUE_TOO_LONG")
 		}
+	case map[string]interface{}:
+		if !vs.checkMetadataDepth(result, path, depth+1) {
+			return
+		}
+		vs.validateMetadataObject(result, path, v, depth+1)
+	case []interface{}:
+		if !vs.checkMetadataDepth(result, path, depth+1) {
+			return
+		}
+		for i, item := range v {
+			vs.validateMetadataValue(result, fmt.Sprintf("%s[%d]", path, i), item, depth+1)
+		}
 	}
+}
 
-	return result
+// checkMetadataDepth signale une valeur imbriquée au-delà de la profondeur maximale
+func (vs *ValidationService) checkMetadataDepth(result *ValidationResult, path string, depth int) bool {
+	if maxDepth := vs.config.maxMetadataDepth(); depth > maxDepth {
+		result.AddError("metadata", path,
+			fmt.Sprintf("metadata nested too deeply (max depth %d) for key: %s", maxDepth, path),
+			"METADATA_TOO_DEEP")
+		return false
+	}
+	return true
 }
@@ -3,12 +3,23 @@
 package validation
 
 import (
+	"context"
+	"fmt"
+	"math"
 	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
 	"net/textproto"
+	"os"
+	"path"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFilenameValidationSecurity(t *testing.T) {
@@ -59,6 +70,10 @@ func TestFilenameValidationSecurity(t *testing.T) {
 		// Valid files
 		{"valid markdown", "presentation.md", true, ""},
 		{"valid css", "styles.css", true, ""},
+		{"valid scss", "theme.scss", true, ""},
+		{"valid sass", "theme.sass", true, ""},
+		{"valid less", "theme.less", true, ""},
+		{"valid postcss", "theme.postcss", true, ""},
 		{"valid javascript", "script.js", true, ""},
 		{"valid image", "image.png", true, ""},
 		{"valid json", "config.json", true, ""},
@@ -150,6 +165,150 @@ func TestContentSafetyValidation(t *testing.T) {
 	}
 }
 
+func TestCustomContentPatterns(t *testing.T) {
+	codes := func(result *ValidationResult) []string {
+		var codes []string
+		for _, err := range result.Errors {
+			codes = append(codes, err.Code)
+		}
+		return codes
+	}
+
+	t.Run("Added patterns", func(t *testing.T) {
+		patterns, err := ParseContentPatterns(map[string][]string{
+			"js":   {`\bfetch\(`, `\bimport\(`},
+			".VUE": {`(?i)<iframe`},
+		}, nil)
+		require.NoError(t, err)
+
+		config := DefaultValidationConfig()
+		config.ContentPatterns = patterns
+		validator := NewAPIValidator(config)
+
+		assert.Equal(t, []string{"DENIED_CONTENT_PATTERN"}, codes(validator.ValidateContentSafety([]byte("fetch('/api')"), "app.js")))
+		assert.Equal(t, []string{"DENIED_CONTENT_PATTERN", "DENIED_CONTENT_PATTERN"},
+			codes(validator.ValidateContentSafety([]byte("import('x'); fetch('/api')"), "app.js")))
+		assert.Equal(t, []string{"DENIED_CONTENT_PATTERN"}, codes(validator.ValidateContentSafety([]byte("<IFRAME src=x>"), "Slide.vue")))
+		assert.True(t, validator.ValidateContentSafety([]byte("prefetch(list)"), "app.js").Valid)
+
+		// Les motifs intégrés restent appliqués
+		assert.Equal(t, []string{"DANGEROUS_JS_CONTENT"}, codes(validator.ValidateContentSafety([]byte("eval(1)"), "app.js")))
+	})
+
+	t.Run("Disabled defaults", func(t *testing.T) {
+		patterns, err := ParseContentPatterns(nil, []string{".html", "md"})
+		require.NoError(t, err)
+
+		config := DefaultValidationConfig()
+		config.ContentPatterns = patterns
+		validator := NewAPIValidator(config)
+
+		assert.True(t, validator.ValidateContentSafety([]byte("<script>run()</script>"), "page.html").Valid)
+		assert.True(t, validator.ValidateContentSafety([]byte("[x](javascript:void(0))"), "doc.md").Valid)
+		assert.False(t, validator.ValidateContentSafety([]byte("eval(1)"), "app.js").Valid)
+	})
+
+	t.Run("Invalid configuration", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			custom   map[string][]string
+			disabled []string
+		}{
+			"invalid regex":          {custom: map[string][]string{".js": {"fetch("}}},
+			"empty regex":            {custom: map[string][]string{".js": {""}}},
+			"missing extension":      {custom: map[string][]string{"": {"fetch"}}},
+			"invalid extension":      {custom: map[string][]string{"a/b": {"fetch"}}},
+			"invalid disabled entry": {disabled: []string{"."}},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := ParseContentPatterns(tc.custom, tc.disabled)
+				assert.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestValidationMessages(t *testing.T) {
+	validator := NewAPIValidator(DefaultValidationConfig())
+	result := &ValidationResult{Valid: true}
+	result.AddError("path", "../secret.md", "path traversal detected", "PATH_TRAVERSAL")
+	errors := result.Errors
+	english := errors[0].Message
+
+	t.Run("Negotiation", func(t *testing.T) {
+		catalog := MessageCatalog{"fr": {}, "pt-br": {}}
+		for header, expected := range map[string]string{
+			"":                          "en",
+			"fr":                        "fr",
+			"fr-CA,fr;q=0.9":            "fr",
+			"de, fr;q=0.5":              "fr",
+			"en;q=0.4, fr;q=0.8":        "fr",
+			"fr;q=0.2, en":              "en",
+			"pt_BR":                     "pt-br",
+			"pt":                        "en",
+			"*":                         "en",
+			"fr;q=0, de;q=invalid, it":  "en",
+			"EN-gb":                     "en",
+			"de-DE;q=0.9, pt-BR;q=0.95": "pt-br",
+		} {
+			assert.Equal(t, expected, catalog.Negotiate(header), header)
+		}
+	})
+
+	t.Run("Switching locales", func(t *testing.T) {
+		locale, localized := validator.LocalizeErrors(errors, "fr-FR,fr;q=0.9,en;q=0.8")
+		assert.Equal(t, "fr", locale)
+		assert.Equal(t, "PATH_TRAVERSAL", localized[0].Code)
+		assert.Contains(t, localized[0].Message, "le chemin ne doit pas sortir du dossier")
+
+		locale, localized = validator.LocalizeErrors(errors, "en-US")
+		assert.Equal(t, "en", locale)
+		assert.Equal(t, english, localized[0].Message)
+
+		// Les erreurs d'origine ne sont pas modifiées
+		assert.Equal(t, english, errors[0].Message)
+	})
+
+	t.Run("Deployment catalog", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "messages.json")
+		require.NoError(t, os.WriteFile(file, []byte(`{
+			"de": {"PATH_TRAVERSAL": "Pfad {value} verlässt den Ordner"},
+			"FR": {"PATH_TRAVERSAL": "chemin refusé ({message})"}
+		}`), 0o644))
+
+		catalog, err := LoadMessageCatalog(file)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"en", "de", "fr"}, catalog.Locales())
+
+		config := DefaultValidationConfig()
+		config.Messages = catalog
+		validator := NewAPIValidator(config)
+
+		_, localized := validator.LocalizeErrors(errors, "de")
+		assert.Equal(t, "Pfad ../secret.md verlässt den Ordner", localized[0].Message)
+		_, localized = validator.LocalizeErrors(errors, "fr")
+		assert.Equal(t, "chemin refusé ("+english+")", localized[0].Message)
+
+		// Les messages intégrés non remplacés restent disponibles
+		assert.Equal(t, DefaultMessageCatalog()["fr"]["EMPTY_FILE"], catalog["fr"]["EMPTY_FILE"])
+	})
+
+	t.Run("Invalid catalog", func(t *testing.T) {
+		dir := t.TempDir()
+		for name, content := range map[string]string{
+			"invalid json":   `{"fr": "message"}`,
+			"invalid locale": `{"f r": {"PATH_TRAVERSAL": "message"}}`,
+		} {
+			file := filepath.Join(dir, strings.ReplaceAll(name, " ", "-")+".json")
+			require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+			_, err := LoadMessageCatalog(file)
+			assert.Error(t, err, name)
+		}
+
+		_, err := LoadMessageCatalog(filepath.Join(dir, "missing.json"))
+		assert.Error(t, err)
+	})
+}
+
 func TestFileUploadValidation(t *testing.T) {
 	validator := NewValidationService(DefaultValidationConfig())
 
@@ -221,6 +380,34 @@ func TestFileUploadValidation(t *testing.T) {
 		}
 		assert.True(t, found, "Should have DUPLICATE_FILENAME error")
 	})
+
+	// Le routeur injecte l'APIValidator pour résoudre les chemins complets
+	pathValidator := NewValidationService(DefaultValidationConfig())
+	pathValidator.SetFilePathResolver(NewAPIValidatorWithService(pathValidator))
+
+	t.Run("Same filename in different directories", func(t *testing.T) {
+		files := []*multipart.FileHeader{
+			createTestFileHeaderWithPath("docs/index.md", "text/markdown", 1000),
+			createTestFileHeaderWithPath("src/index.md", "text/markdown", 1000),
+			createTestFileHeaderWithPath("index.md", "text/markdown", 1000),
+		}
+
+		result := pathValidator.ValidateFiles(files)
+		assert.True(t, result.Valid, "Same filename in different directories should pass: %v", result.Errors)
+	})
+
+	t.Run("Duplicate full paths", func(t *testing.T) {
+		files := []*multipart.FileHeader{
+			createTestFileHeaderWithPath("docs/index.md", "text/markdown", 1000),
+			createTestFileHeaderWithPath("./docs//index.md", "text/markdown", 1000),
+		}
+
+		result := pathValidator.ValidateFiles(files)
+		assert.False(t, result.Valid, "Same sanitized path should fail")
+		require.Len(t, result.Errors, 1)
+		assert.Equal(t, "DUPLICATE_FILENAME", result.Errors[0].Code)
+		assert.Equal(t, "docs/index.md", result.Errors[0].Value)
+	})
 }
 
 func TestURLValidation(t *testing.T) {
@@ -280,6 +467,562 @@ func TestURLValidation(t *testing.T) {
 	}
 }
 
+func TestEntryFileValidation(t *testing.T) {
+	validator := NewValidationService(DefaultValidationConfig())
+
+	testCases := []struct {
+		name  string
+		path  string
+		valid bool
+		code  string
+	}{
+		{"empty is optional", "", true, ""},
+		{"root file", "slides.md", true, ""},
+		{"nested file", "cours/presentation.md", true, ""},
+		{"path traversal", "../secrets.md", false, "PATH_TRAVERSAL"},
+		{"absolute path", "/etc/slides.md", false, "PATH_TRAVERSAL"},
+		{"not markdown", "slides.html", false, "INVALID_ENTRY_FILE"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := validator.ValidateEntryFile(tc.path)
+			assert.Equal(t, tc.valid, result.Valid)
+
+			if tc.code != "" {
+				require.NotEmpty(t, result.Errors)
+				assert.Equal(t, tc.code, result.Errors[0].Code)
+			}
+		})
+	}
+}
+
+func TestOutputDirValidation(t *testing.T) {
+	validator := NewValidationService(DefaultValidationConfig())
+
+	testCases := []struct {
+		name  string
+		dir   string
+		valid bool
+		code  string
+	}{
+		{"empty is optional", "", true, ""},
+		{"root directory", "build", true, ""},
+		{"nested directory", "site/public_html", true, ""},
+		{"path traversal", "../build", false, "PATH_TRAVERSAL"},
+		{"absolute path", "/tmp/build", false, "PATH_TRAVERSAL"},
+		{"backslash", "site\\build", false, "PATH_TRAVERSAL"},
+		{"hidden directory", ".output", false, "INVALID_OUTPUT_DIR"},
+		{"current directory", "./build", false, "INVALID_OUTPUT_DIR"},
+		{"trailing slash", "build/", false, "INVALID_OUTPUT_DIR"},
+		{"dependencies", "node_modules/out", false, "INVALID_OUTPUT_DIR"},
+		{"static files", "public", false, "INVALID_OUTPUT_DIR"},
+		{"shell characters", "build;rm", false, "INVALID_OUTPUT_DIR"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := validator.ValidateOutputDir(tc.dir)
+			assert.Equal(t, tc.valid, result.Valid)
+
+			if tc.code != "" {
+				require.NotEmpty(t, result.Errors)
+				assert.Equal(t, tc.code, result.Errors[0].Code)
+			}
+		})
+	}
+}
+
+func TestSourceRetentionValidation(t *testing.T) {
+	validator := NewValidationService(DefaultValidationConfig())
+
+	for _, retention := range []models.SourceRetention{"", models.SourceRetentionKeep, models.SourceRetentionDeleteOnSuccess} {
+		assert.True(t, validator.ValidateSourceRetention(retention).Valid, "retention %q", retention)
+	}
+
+	result := validator.ValidateSourceRetention("delete")
+	assert.False(t, result.Valid)
+	require.NotEmpty(t, result.Errors)
+	assert.Equal(t, "INVALID_SOURCE_RETENTION", result.Errors[0].Code)
+}
+
+func TestResultPrefixValidation(t *testing.T) {
+	validator := NewValidationService(DefaultValidationConfig())
+
+	testCases := []struct {
+		name   string
+		prefix string
+		valid  bool
+		code   string
+	}{
+		{"empty is optional", "", true, ""},
+		{"single segment", "intro-go", true, ""},
+		{"versioned layout", "acme/intro-go/v3.1", true, ""},
+		{"path traversal", "acme/../other", false, "PATH_TRAVERSAL"},
+		{"absolute path", "/acme/intro", false, "PATH_TRAVERSAL"},
+		{"backslash", "acme\\intro", false, "PATH_TRAVERSAL"},
+		{"hidden directory", "acme/.git", false, "INVALID_RESULT_PREFIX"},
+		{"trailing slash", "acme/intro/", false, "INVALID_RESULT_PREFIX"},
+		{"empty segment", "acme//intro", false, "INVALID_RESULT_PREFIX"},
+		{"spaces", "acme/intro go", false, "INVALID_RESULT_PREFIX"},
+		{"too deep", strings.Repeat("a/", 10) + "a", false, "INVALID_RESULT_PREFIX"},
+		{"too long", strings.Repeat("a", 201), false, "INVALID_RESULT_PREFIX"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := validator.ValidateResultPrefix(tc.prefix)
+			assert.Equal(t, tc.valid, result.Valid)
+
+			if tc.code != "" {
+				require.NotEmpty(t, result.Errors)
+				assert.Equal(t, tc.code, result.Errors[0].Code)
+			}
+		})
+	}
+}
+
+func TestBuildFlagsValidation(t *testing.T) {
+	validator := NewValidationService(DefaultValidationConfig())
+
+	testCases := []struct {
+		name  string
+		flags []string
+		valid bool
+		code  string
+	}{
+		{"no flags", nil, true, ""},
+		{"allowed flags", []string{"--download", "--inspect", "--without-notes"}, true, ""},
+		{"base path", []string{"--base=/cours/intro/"}, true, ""},
+		{"unknown flag", []string{"--watch"}, false, "BUILD_FLAG_NOT_ALLOWED"},
+		{"output override", []string{"--out=/etc"}, false, "BUILD_FLAG_NOT_ALLOWED"},
+		{"shell injection", []string{"--download; rm -rf /"}, false, "BUILD_FLAG_NOT_ALLOWED"},
+		{"short flag", []string{"-d"}, false, "BUILD_FLAG_NOT_ALLOWED"},
+		{"value on boolean flag", []string{"--download=true"}, false, "INVALID_BUILD_FLAG_VALUE"},
+		{"missing value", []string{"--base"}, false, "INVALID_BUILD_FLAG_VALUE"},
+		{"relative base", []string{"--base=cours"}, false, "INVALID_BUILD_FLAG_VALUE"},
+		{"base traversal", []string{"--base=/../admin/"}, false, "INVALID_BUILD_FLAG_VALUE"},
+		{"too many flags", make([]string, maxBuildFlags+1), false, "TOO_MANY_BUILD_FLAGS"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := validator.ValidateBuildFlags(tc.flags)
+			assert.Equal(t, tc.valid, result.Valid)
+
+			if tc.code != "" {
+				require.NotEmpty(t, result.Errors)
+				assert.Equal(t, tc.code, result.Errors[0].Code)
+			}
+		})
+	}
+}
+
+func TestThemesValidation(t *testing.T) {
+	validator := NewValidationService(DefaultValidationConfig())
+
+	testCases := []struct {
+		name   string
+		themes []string
+		valid  bool
+		code   string
+	}{
+		{"no themes", nil, true, ""},
+		{"official and community themes", []string{"seriph", "apple-basic", "slidev-theme-penguin"}, true, ""},
+		{"scoped package", []string{"@org/slidev-theme-x"}, false, "INVALID_THEME"},
+		{"path traversal", []string{"../seriph"}, false, "INVALID_THEME"},
+		{"empty name", []string{""}, false, "INVALID_THEME"},
+		{"duplicate", []string{"seriph", "seriph"}, false, "DUPLICATE_THEME"},
+		{"too many themes", []string{"a", "b", "c", "d", "e", "f"}, false, "TOO_MANY_THEMES"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := validator.ValidateThemes(tc.themes)
+			assert.Equal(t, tc.valid, result.Valid)
+
+			if tc.code != "" {
+				require.NotEmpty(t, result.Errors)
+				assert.Equal(t, tc.code, result.Errors[0].Code)
+			}
+		})
+	}
+}
+
+func TestSPAFallbackMetadataValidation(t *testing.T) {
+	validator := NewValidationService(DefaultValidationConfig())
+
+	assert.True(t, validator.ValidateMetadata(map[string]interface{}{"spa_fallback": false}).Valid)
+	assert.True(t, validator.ValidateMetadata(map[string]interface{}{"author": "alice"}).Valid)
+
+	result := validator.ValidateMetadata(map[string]interface{}{"spa_fallback": "no"})
+	assert.False(t, result.Valid)
+	require.NotEmpty(t, result.Errors)
+	assert.Equal(t, "INVALID_TYPE", result.Errors[0].Code)
+}
+
+func TestMetadataNestingAndSize(t *testing.T) {
+	validator := NewValidationService(DefaultValidationConfig())
+
+	// nested construit un objet imbriqué sur depth niveaux, metadata comprise
+	nested := func(depth int) map[string]interface{} {
+		var value interface{} = "leaf"
+		for i := 0; i < depth; i++ {
+			value = map[string]interface{}{"level": value}
+		}
+		return value.(map[string]interface{})
+	}
+
+	firstCode := func(t *testing.T, result *ValidationResult) string {
+		require.False(t, result.Valid)
+		require.NotEmpty(t, result.Errors)
+		return result.Errors[0].Code
+	}
+
+	t.Run("Nesting within the limit", func(t *testing.T) {
+		metadata := nested(DefaultMaxMetadataDepth)
+		metadata["tags"] = []interface{}{"slides", map[string]interface{}{"lang": "fr"}}
+		assert.True(t, validator.ValidateMetadata(metadata).Valid)
+	})
+
+	t.Run("Deeply nested objects", func(t *testing.T) {
+		result := validator.ValidateMetadata(nested(DefaultMaxMetadataDepth + 1))
+		assert.Equal(t, "METADATA_TOO_DEEP", firstCode(t, result))
+		assert.Contains(t, result.Errors[0].Value, "level.level")
+	})
+
+	t.Run("Deeply nested arrays", func(t *testing.T) {
+		var value interface{} = "leaf"
+		for i := 0; i < 100; i++ {
+			value = []interface{}{value}
+		}
+		result := validator.ValidateMetadata(map[string]interface{}{"matrix": value})
+		assert.Equal(t, "METADATA_TOO_DEEP", firstCode(t, result))
+		assert.Len(t, result.Errors, 1, "the walk stops at the first level beyond the limit")
+	})
+
+	t.Run("Long strings inside nested values", func(t *testing.T) {
+		result := validator.ValidateMetadata(map[string]interface{}{
+			"course": map[string]interface{}{"chapters": []interface{}{strings.Repeat("a", 1001)}},
+		})
+		assert.Equal(t, "VALUE_TOO_LONG", firstCode(t, result))
+		assert.Equal(t, "course.chapters[0]", result.Errors[0].Value)
+	})
+
+	t.Run("Long nested keys", func(t *testing.T) {
+		result := validator.ValidateMetadata(map[string]interface{}{
+			"course": map[string]interface{}{strings.Repeat("k", 101): true},
+		})
+		assert.Equal(t, "KEY_TOO_LONG", firstCode(t, result))
+	})
+
+	t.Run("Oversized metadata", func(t *testing.T) {
+		// Chaque chaîne respecte la limite, mais l'ensemble dépasse la taille maximale
+		chunks := make([]interface{}, 100)
+		for i := range chunks {
+			chunks[i] = strings.Repeat("x", 1000)
+		}
+		result := validator.ValidateMetadata(map[string]interface{}{"chunks": chunks})
+		assert.Equal(t, "METADATA_TOO_LARGE", firstCode(t, result))
+	})
+
+	t.Run("Configured limits", func(t *testing.T) {
+		config := DefaultValidationConfig()
+		config.MaxMetadataSize = 64
+		config.MaxMetadataDepth = 2
+		limited := NewValidationService(config)
+
+		assert.True(t, limited.ValidateMetadata(nested(2)).Valid)
+		assert.Equal(t, "METADATA_TOO_DEEP", firstCode(t, limited.ValidateMetadata(nested(3))))
+		assert.Equal(t, "METADATA_TOO_LARGE", firstCode(t, limited.ValidateMetadata(map[string]interface{}{"note": strings.Repeat("n", 64)})))
+	})
+
+	t.Run("Non JSON-serializable values", func(t *testing.T) {
+		for name, value := range map[string]interface{}{
+			"channel":  make(chan int),
+			"function": func() {},
+			"NaN":      math.NaN(),
+		} {
+			result := validator.ValidateMetadata(map[string]interface{}{"value": value})
+			assert.Equal(t, "INVALID_METADATA", firstCode(t, result), name)
+		}
+	})
+}
+
+func TestDirectoryRules(t *testing.T) {
+	codes := func(result *ValidationResult) []string {
+		var codes []string
+		for _, err := range result.Errors {
+			codes = append(codes, err.Code)
+		}
+		return codes
+	}
+
+	t.Run("Parsing", func(t *testing.T) {
+		rules, err := ParseDirectoryRules(map[string]string{
+			"/assets/": "binary", "scripts": "DENY", "data": ".csv|.tsv", "assets\\private": "deny",
+		})
+		require.NoError(t, err)
+		assert.True(t, rules["assets"].Binary)
+		assert.True(t, rules["scripts"].Deny)
+		assert.True(t, rules["assets/private"].Deny)
+		assert.Equal(t, map[string]bool{".csv": true, ".tsv": true}, rules["data"].Extensions)
+
+		rules, err = ParseDirectoryRules(nil)
+		require.NoError(t, err)
+		assert.Nil(t, rules)
+
+		for directory, policy := range map[string]string{
+			"assets": "binaries", "data": "csv", "docs": ".md|", "/": "deny", "../up": "deny",
+		} {
+			_, err := ParseDirectoryRules(map[string]string{directory: policy})
+			assert.Error(t, err, "%s=%s", directory, policy)
+		}
+	})
+
+	t.Run("Uniform validation by default", func(t *testing.T) {
+		validator := NewAPIValidator(nil)
+		assert.Equal(t, []string{"FORBIDDEN_EXTENSION"}, codes(validator.ValidateFilePath("assets/font.otf")))
+		assert.True(t, validator.ValidateFilePath("scripts/build.js").Valid)
+		assert.Equal(t, []string{"CONTROL_CHARACTERS"}, codes(validator.ValidateContentSafety([]byte{0x00, 0x01}, "assets/data.json")))
+	})
+
+	config := DefaultValidationConfig()
+	rules, err := ParseDirectoryRules(map[string]string{
+		"assets":         DirectoryPolicyBinary,
+		"assets/private": DirectoryPolicyDeny,
+		"scripts":        DirectoryPolicyDeny,
+		"data":           ".csv|.tsv",
+	})
+	require.NoError(t, err)
+	config.DirectoryRules = rules
+	validator := NewAPIValidator(config)
+
+	t.Run("File paths", func(t *testing.T) {
+		valid := []string{
+			"assets/font.otf", "assets/img/raw/photo.tiff", "assets/blob", "data/table.csv",
+			"scripts.md", "slides.md", "docs/scripts/notes.md",
+		}
+		for _, filePath := range valid {
+			assert.True(t, validator.ValidateFilePath(filePath).Valid, filePath)
+		}
+
+		assert.Equal(t, []string{"DIRECTORY_NOT_ALLOWED"}, codes(validator.ValidateFilePath("scripts/build.js")))
+		assert.Equal(t, []string{"DIRECTORY_NOT_ALLOWED"}, codes(validator.ValidateFilePath("assets/private/key.png")))
+		assert.Equal(t, []string{"FORBIDDEN_EXTENSION"}, codes(validator.ValidateFilePath("data/tool.exe")))
+		assert.Equal(t, []string{"FORBIDDEN_EXTENSION"}, codes(validator.ValidateFilePath("font.otf")))
+
+		// Les autres vérifications restent appliquées dans un dossier binaire
+		assert.Equal(t, []string{"FORBIDDEN_CHAR"}, codes(validator.ValidateFilePath("assets/a:b.bin")))
+	})
+
+	t.Run("Content", func(t *testing.T) {
+		binary := []byte{0x00, 0x01, 0x02}
+		assert.True(t, validator.ValidateContentSafety(binary, "assets/font.otf").Valid)
+		// Seuls le type MIME et l'extension sont libres : les motifs refusés s'appliquent
+		assert.Equal(t, []string{"DANGEROUS_JS_CONTENT"}, codes(validator.ValidateContentSafety([]byte("eval(1)"), "assets/vendor/lib.js")))
+		assert.Equal(t, []string{"CONTENT_TOO_LARGE"}, codes(validator.ValidateContentSafety(make([]byte, 50*1024*1024+1), "assets/video.bin")))
+		assert.Equal(t, []string{"CONTROL_CHARACTERS"}, codes(validator.ValidateContentSafety(binary, "data/table.csv")))
+		assert.Equal(t, []string{"DANGEROUS_JS_CONTENT"}, codes(validator.ValidateContentSafety([]byte("eval(1)"), "lib.js")))
+		assert.Equal(t, []string{"DIRECTORY_NOT_ALLOWED"}, codes(validator.ValidateContentSafety([]byte("echo"), "scripts/run.sh")))
+	})
+
+	t.Run("Uploaded files", func(t *testing.T) {
+		service := NewValidationService(config)
+
+		files := []*multipart.FileHeader{
+			createTestFileHeaderWithPath("assets/font.otf", "font/otf", 2048),
+			createTestFileHeaderWithPath("data/table.csv", "text/csv", 512),
+			createTestFileHeaderWithPath("slides.md", "text/markdown", 100),
+		}
+		assert.True(t, service.ValidateFiles(files).Valid)
+
+		result := service.ValidateFiles([]*multipart.FileHeader{createTestFileHeaderWithPath("font.otf", "font/otf", 2048)})
+		assert.Equal(t, []string{"FORBIDDEN_EXTENSION", "FORBIDDEN_MIME_TYPE"}, codes(result))
+	})
+}
+
+func TestMaxPathDepth(t *testing.T) {
+	deepPath := strings.Repeat("dir/", 10) + "slides.md"
+
+	t.Run("default", func(t *testing.T) {
+		validator := NewAPIValidator(nil)
+
+		assert.True(t, validator.ValidateFilePath(strings.Repeat("dir/", 9)+"slides.md").Valid)
+		result := validator.ValidateFilePath(deepPath)
+		assert.False(t, result.Valid)
+		require.NotEmpty(t, result.Errors)
+		assert.Equal(t, "PATH_TOO_DEEP", result.Errors[0].Code)
+		assert.Equal(t, DefaultMaxPathDepth, strings.Count(validator.SanitizeFilePath(deepPath), "/")+1)
+	})
+
+	t.Run("raised", func(t *testing.T) {
+		config := DefaultValidationConfig()
+		config.MaxPathDepth = 12
+		validator := NewAPIValidator(config)
+
+		assert.True(t, validator.ValidateFilePath(deepPath).Valid)
+		assert.Equal(t, deepPath, validator.SanitizeFilePath(deepPath))
+	})
+}
+
+func TestStorageKeyLength(t *testing.T) {
+	// Chemin de 976 octets : avec "sources/{job_id}/" (45 octets) et le suffixe ".gz" de la
+	// variante compressée, la clé fait 1024 octets
+	pathOfLength := func(length int) string {
+		directories := strings.Repeat(strings.Repeat("a", 200)+"/", 4)
+		return directories + strings.Repeat("b", length-len(directories)-len(".md")) + ".md"
+	}
+	maxPath := pathOfLength(MaxStorageKeyLength - ObjectKeyPrefixLength - CompressedVariantSuffixLength)
+	require.Len(t, maxPath, 976)
+
+	t.Run("key at the backend limit", func(t *testing.T) {
+		validator := NewAPIValidator(nil)
+		result := validator.ValidateFilePath(maxPath)
+		assert.True(t, result.Valid, "%v", result.Errors)
+	})
+
+	t.Run("key one byte over the limit", func(t *testing.T) {
+		validator := NewAPIValidator(nil)
+		result := validator.ValidateFilePath(pathOfLength(977))
+		assert.False(t, result.Valid)
+		require.Len(t, result.Errors, 1)
+		assert.Equal(t, "STORAGE_KEY_TOO_LONG", result.Errors[0].Code)
+		assert.Contains(t, result.Errors[0].Message, "1025 bytes once prefixed")
+	})
+
+	t.Run("deployment namespace shortens the limit", func(t *testing.T) {
+		config := DefaultValidationConfig()
+		config.KeyNamespaceLength = len("staging/")
+		validator := NewAPIValidator(config)
+
+		assert.False(t, validator.ValidateFilePath(maxPath).Valid)
+		assert.True(t, validator.ValidateFilePath(pathOfLength(976-len("staging/"))).Valid)
+	})
+}
+
+func TestLabelsValidation(t *testing.T) {
+	validator := NewValidationService(DefaultValidationConfig())
+
+	tooMany := make(map[string]string)
+	for i := 0; i <= maxLabels; i++ {
+		tooMany[fmt.Sprintf("key-%d", i)] = "value"
+	}
+
+	testCases := []struct {
+		name   string
+		labels map[string]string
+		valid  bool
+		code   string
+	}{
+		{"no labels", nil, true, ""},
+		{"valid labels", map[string]string{"env": "prod", "team.name": "Docs_FR", "pipeline-id": "42"}, true, ""},
+		{"uppercase key", map[string]string{"Env": "prod"}, false, "INVALID_LABEL_KEY"},
+		{"key with colon", map[string]string{"env:x": "prod"}, false, "INVALID_LABEL_KEY"},
+		{"empty value", map[string]string{"env": ""}, false, "INVALID_LABEL_VALUE"},
+		{"value with space", map[string]string{"env": "prod eu"}, false, "INVALID_LABEL_VALUE"},
+		{"value too long", map[string]string{"env": strings.Repeat("a", 64)}, false, "INVALID_LABEL_VALUE"},
+		{"too many labels", tooMany, false, "TOO_MANY_LABELS"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := validator.ValidateLabels(tc.labels)
+			assert.Equal(t, tc.valid, result.Valid)
+
+			if tc.code != "" {
+				require.NotEmpty(t, result.Errors)
+				assert.Equal(t, tc.code, result.Errors[0].Code)
+			}
+		})
+	}
+}
+
+func TestCallbackURLPolicy(t *testing.T) {
+	t.Run("private networks blocked by default", func(t *testing.T) {
+		validator := NewValidationService(DefaultValidationConfig())
+
+		testCases := []struct {
+			name  string
+			url   string
+			valid bool
+		}{
+			{"RFC1918 10/8", "http://10.0.0.5/webhook", false},
+			{"RFC1918 172.16/12", "http://172.16.3.4:8080/webhook", false},
+			{"RFC1918 192.168/16", "https://192.168.1.10/webhook", false},
+			{"link-local metadata", "http://169.254.169.254/latest/meta-data", false},
+			{"unspecified", "http://0.0.0.0/webhook", false},
+			{"public IP", "https://93.184.216.34/webhook", true},
+			{"public hostname", "https://example.com/webhook", true},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				result := validator.ValidateCallbackURL(tc.url)
+				assert.Equal(t, tc.valid, result.Valid, "URL: %s", tc.url)
+			})
+		}
+	})
+
+	t.Run("private networks allowed when configured", func(t *testing.T) {
+		config := DefaultValidationConfig()
+		config.CallbackPolicy.AllowPrivateNetworks = true
+		validator := NewValidationService(config)
+
+		result := validator.ValidateCallbackURL("http://10.0.0.5/webhook")
+		assert.True(t, result.Valid)
+	})
+
+	t.Run("allowlist", func(t *testing.T) {
+		config := DefaultValidationConfig()
+		config.CallbackPolicy.AllowedHosts = []string{"api.example.com", "*.hooks.example.org"}
+		validator := NewValidationService(config)
+
+		assert.True(t, validator.ValidateCallbackURL("https://api.example.com/webhook").Valid)
+		assert.True(t, validator.ValidateCallbackURL("https://a.hooks.example.org/webhook").Valid)
+
+		result := validator.ValidateCallbackURL("https://evil.example.net/webhook")
+		assert.False(t, result.Valid)
+		assert.Equal(t, "CALLBACK_HOST_NOT_ALLOWED", result.Errors[0].Code)
+
+		assert.False(t, validator.ValidateCallbackURL("https://hooks.example.org/webhook").Valid)
+	})
+
+	t.Run("send-time check rejects private literal", func(t *testing.T) {
+		policy := DefaultCallbackPolicy()
+		err := policy.CheckCallbackURL(context.Background(), "http://192.168.0.1/webhook")
+		assert.Error(t, err)
+
+		err = policy.CheckCallbackURL(context.Background(), "ftp://example.com/webhook")
+		assert.Error(t, err)
+
+		assert.Error(t, policy.CheckCallbackURL(context.Background(), "http://127.0.0.1/webhook"))
+	})
+
+	t.Run("dial-time check rejects forbidden addresses", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		policy := DefaultCallbackPolicy()
+		assert.Error(t, policy.DialControl("tcp", "169.254.169.254:80", nil))
+		assert.NoError(t, policy.DialControl("tcp", "93.184.216.34:443", nil))
+
+		// La connexion elle-même est refusée, quelle que soit la résolution préalable
+		client := &http.Client{Transport: policy.Transport()}
+		_, err := client.Get(server.URL)
+		assert.Error(t, err)
+
+		policy.AllowPrivateNetworks = true
+		client = &http.Client{Transport: policy.Transport()}
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
+
 // Helper function to create test file headers
 func createTestFileHeader(filename, contentType string, size int64) *multipart.FileHeader {
 	header := make(textproto.MIMEHeader)
@@ -293,6 +1036,14 @@ func createTestFileHeader(filename, contentType string, size int64) *multipart.F
 	}
 }
 
+// createTestFileHeaderWithPath reproduit un header multipart avec dossiers : le chemin
+// complet reste dans Content-Disposition, Filename ne garde que le nom de base
+func createTestFileHeaderWithPath(filePath, contentType string, size int64) *multipart.FileHeader {
+	header := createTestFileHeader(filePath, contentType, size)
+	header.Filename = path.Base(filePath)
+	return header
+}
+
 func BenchmarkFilenameValidation(b *testing.B) {
 	validator := NewValidationService(DefaultValidationConfig())
 	testFiles := []string{
@@ -309,3 +1060,41 @@ func BenchmarkFilenameValidation(b *testing.B) {
 		validator.ValidateFilename(filename, false)
 	}
 }
+
+func TestEmptyFiles(t *testing.T) {
+	files := []*multipart.FileHeader{
+		createTestFileHeader("slides.md", "text/markdown", 100),
+		createTestFileHeader("theme.css", "text/css", 0),
+		createTestFileHeaderWithPath("notes/empty.md", "text/markdown", 0),
+	}
+
+	t.Run("Accepted by default", func(t *testing.T) {
+		assert.True(t, NewValidationService(nil).ValidateFiles(files).Valid)
+	})
+
+	t.Run("Rejected when configured", func(t *testing.T) {
+		config := DefaultValidationConfig()
+		config.RejectEmptyFiles = true
+
+		result := NewValidationService(config).ValidateFiles(files)
+		require.False(t, result.Valid)
+		require.Len(t, result.Errors, 2)
+		assert.Equal(t, "files[1].file_size", result.Errors[0].Field)
+		assert.Equal(t, "EMPTY_FILE", result.Errors[0].Code)
+		assert.Equal(t, "files[2].file_size", result.Errors[1].Field)
+	})
+
+	t.Run("Allowed extensions", func(t *testing.T) {
+		config := DefaultValidationConfig()
+		config.RejectEmptyFiles = true
+		config.EmptyFileExtensions = map[string]bool{".css": true}
+
+		result := NewValidationService(config).ValidateFiles(files)
+		require.Len(t, result.Errors, 1)
+		assert.Equal(t, "files[2].file_size", result.Errors[0].Field)
+		assert.Equal(t, "EMPTY_FILE", result.Errors[0].Code)
+
+		config.EmptyFileExtensions[".md"] = true
+		assert.True(t, NewValidationService(config).ValidateFiles(files).Valid)
+	})
+}
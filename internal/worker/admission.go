@@ -0,0 +1,120 @@
+// internal/worker/admission.go - Admission des nouveaux jobs selon la charge de la file
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Modes de gestion d'une file en mémoire pleine
+const (
+	// QueueOverflowPersist accepte les jobs en base (pending) même quand la file en mémoire
+	// est pleine : le polling les met en file quand des places se libèrent
+	QueueOverflowPersist = "persist"
+	// QueueOverflowReject refuse les jobs qui ne tiennent pas dans la file en mémoire
+	QueueOverflowReject = "reject"
+)
+
+var (
+	// ErrQueueFull est retournée en mode reject quand la file en mémoire est pleine
+	ErrQueueFull = errors.New("job queue is full")
+	// ErrBacklogFull est retournée quand le backlog des jobs pending en base est plein
+	ErrBacklogFull = errors.New("pending job backlog is full")
+)
+
+// ReserveAdmission admet requested nouveaux jobs et les compte comme pending jusqu'à l'appel
+// de release, une fois les jobs enregistrés en base. En mode reject, tous les jobs pending
+// doivent tenir dans la file en mémoire ; en mode persist, seul le backlog (jobs pending en
+// base hors de la file en mémoire) est borné par MaxPendingBacklog. Les jobs pending sont
+// comptés pour toutes les instances, la file est celle de l'instance. En maintenance, aucun
+// job n'est admis (ErrMaintenance).
+//
+// Seules la vérification et la réservation sont sérialisées : les soumissions simultanées
+// ne dépassent pas les limites sans attendre la création des jobs des autres.
+func (p *WorkerPool) ReserveAdmission(ctx context.Context, requested int) (release func(), err error) {
+	p.admissionMu.Lock()
+	defer p.admissionMu.Unlock()
+
+	if err := p.admitJobs(ctx, requested); err != nil {
+		return nil, err
+	}
+	p.admitting += requested
+
+	return sync.OnceFunc(func() {
+		p.admissionMu.Lock()
+		p.admitting -= requested
+		p.admissionMu.Unlock()
+	}), nil
+}
+
+// admitJobs applique les limites d'admission en comptant les jobs réservés ; appelée sous admissionMu
+func (p *WorkerPool) admitJobs(ctx context.Context, requested int) error {
+	if p.inMaintenance() {
+		return ErrMaintenance
+	}
+
+	reject := p.overflowMode() == QueueOverflowReject
+	limit := p.config.MaxPendingBacklog
+	if !reject && limit <= 0 {
+		// Backlog illimité : pas besoin de compter les jobs pending
+		return nil
+	}
+
+	pending, err := p.jobService.CountPendingJobs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count pending jobs: %w", err)
+	}
+	p.recordBacklog(pending)
+	requested += p.admitting
+
+	if reject {
+		if _, capacity := p.queueUsage(); pending+requested > capacity {
+			return ErrQueueFull
+		}
+		return nil
+	}
+
+	if p.backlogSize()+requested > limit {
+		return ErrBacklogFull
+	}
+	return nil
+}
+
+// recordBacklog enregistre le backlog à partir du nombre de jobs pending en base : ceux qui
+// ne sont pas dans la file en mémoire de l'instance
+func (p *WorkerPool) recordBacklog(pending int) {
+	p.queuedMu.Lock()
+	backlog := max(pending-len(p.queued), 0)
+	p.queuedMu.Unlock()
+
+	p.backlog.Store(int64(backlog))
+}
+
+// backlogSize retourne le dernier backlog observé (au dernier poll ou à la dernière admission)
+func (p *WorkerPool) backlogSize() int {
+	return int(p.backlog.Load())
+}
+
+// queueUsage retourne le nombre de jobs dans la file en mémoire et sa capacité
+func (p *WorkerPool) queueUsage() (int, int) {
+	if p.workerQueues == nil {
+		return len(p.jobQueue), cap(p.jobQueue)
+	}
+
+	size, capacity := 0, 0
+	for _, queue := range p.workerQueues {
+		size += len(queue)
+		capacity += cap(queue)
+	}
+	return size, capacity
+}
+
+// overflowMode retourne le mode effectif de gestion d'une file pleine
+func (p *WorkerPool) overflowMode() string {
+	if p.config.QueueOverflowMode == QueueOverflowReject {
+		return QueueOverflowReject
+	}
+	return QueueOverflowPersist
+}
@@ -0,0 +1,124 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAdmissionPool crée un pool d'un worker (file de 2 jobs) avec pending jobs en base
+func newAdmissionPool(pending int, config *PoolConfig) *WorkerPool {
+	jobService := &MockJobService{jobs: map[uuid.UUID]*models.GenerationJob{}}
+	for i := 0; i < pending; i++ {
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New(), Status: models.StatusPending}
+		jobService.jobs[job.ID] = job
+	}
+
+	config.WorkerCount = 1
+	return NewWorkerPool(jobService, nil, config)
+}
+
+// admit réserve l'admission de requested jobs puis la rend, comme une soumission terminée
+func admit(ctx context.Context, pool *WorkerPool, requested int) error {
+	release, err := pool.ReserveAdmission(ctx, requested)
+	if err == nil {
+		release()
+	}
+	return err
+}
+
+func TestAdmission(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("persist mode keeps overflow in the backlog", func(t *testing.T) {
+		pool := newAdmissionPool(10, &PoolConfig{})
+		require.NoError(t, pool.pollPendingJobs(ctx))
+
+		stats := pool.GetStats()
+		assert.Equal(t, QueueOverflowPersist, stats.QueueOverflowMode)
+		assert.Equal(t, 2, stats.QueueSize)
+		assert.Equal(t, 2, stats.QueueCapacity)
+		assert.Equal(t, 8, stats.BacklogSize)
+		assert.Zero(t, stats.BacklogCapacity)
+
+		assert.NoError(t, admit(ctx, pool, 50))
+	})
+
+	t.Run("persist mode bounds the backlog", func(t *testing.T) {
+		pool := newAdmissionPool(10, &PoolConfig{MaxPendingBacklog: 9})
+		require.NoError(t, pool.pollPendingJobs(ctx))
+
+		assert.NoError(t, admit(ctx, pool, 1))
+		assert.ErrorIs(t, admit(ctx, pool, 2), ErrBacklogFull)
+		assert.Equal(t, 9, pool.GetStats().BacklogCapacity)
+	})
+
+	t.Run("reject mode requires room in the in-memory queue", func(t *testing.T) {
+		pool := newAdmissionPool(1, &PoolConfig{QueueOverflowMode: QueueOverflowReject})
+
+		assert.NoError(t, admit(ctx, pool, 1))
+		assert.ErrorIs(t, admit(ctx, pool, 2), ErrQueueFull)
+
+		pool = newAdmissionPool(2, &PoolConfig{QueueOverflowMode: QueueOverflowReject})
+		assert.ErrorIs(t, admit(ctx, pool, 1), ErrQueueFull)
+		assert.Equal(t, QueueOverflowReject, pool.GetStats().QueueOverflowMode)
+	})
+}
+
+func TestPollPendingJobsOldestFirst(t *testing.T) {
+	ctx := context.Background()
+	pool := newAdmissionPool(0, &PoolConfig{})
+	jobService := pool.jobService.(*MockJobService)
+
+	// Backlog plus grand qu'une page de ListJobs, soumis du plus ancien au plus récent
+	start := time.Now().Add(-time.Hour)
+	var ids []uuid.UUID
+	for i := 0; i < 150; i++ {
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New(), Status: models.StatusPending,
+			CreatedAt: start.Add(time.Duration(i) * time.Second)}
+		jobService.jobs[job.ID] = job
+		ids = append(ids, job.ID)
+	}
+
+	drain := func() []uuid.UUID {
+		var dispatched []uuid.UUID
+		for len(pool.jobQueue) > 0 {
+			dispatched = append(dispatched, (<-pool.jobQueue).ID)
+		}
+		return dispatched
+	}
+
+	require.NoError(t, pool.pollPendingJobs(ctx))
+	assert.Equal(t, 148, pool.GetStats().BacklogSize)
+	assert.ElementsMatch(t, ids[:2], drain())
+
+	// Les jobs déjà en file, toujours pending en base, ne masquent pas les suivants
+	require.NoError(t, pool.pollPendingJobs(ctx))
+	assert.ElementsMatch(t, ids[2:4], drain())
+}
+
+func TestReserveAdmission(t *testing.T) {
+	ctx := context.Background()
+	pool := newAdmissionPool(1, &PoolConfig{QueueOverflowMode: QueueOverflowReject})
+
+	// Un job réservé, pas encore en base, occupe la dernière place de la file
+	release, err := pool.ReserveAdmission(ctx, 1)
+	require.NoError(t, err)
+	_, err = pool.ReserveAdmission(ctx, 1)
+	assert.ErrorIs(t, err, ErrQueueFull)
+
+	// Libérer deux fois ne rend la place qu'une fois
+	release()
+	release()
+	second, err := pool.ReserveAdmission(ctx, 1)
+	require.NoError(t, err)
+	_, err = pool.ReserveAdmission(ctx, 1)
+	assert.ErrorIs(t, err, ErrQueueFull)
+	second()
+}
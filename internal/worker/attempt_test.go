@@ -0,0 +1,83 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessJobRecordsAttempts(t *testing.T) {
+	ctx := context.Background()
+	job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New(), Status: models.StatusPending}
+	jobService := &MockJobService{jobs: map[uuid.UUID]*models.GenerationJob{job.ID: job}}
+	storageService := storage.NewStorageService(&MockStorageBackend{})
+	require.NoError(t, storageService.UploadJobSource(ctx, job.ID, "slides.md", strings.NewReader("---\ntheme: seriph\n---\n# Cours\n")))
+
+	config := &PoolConfig{
+		WorkspaceBase:    t.TempDir(),
+		SlidevCommand:    fakeSlidev(t),
+		VersionCheckMode: VersionCheckOff,
+		CleanupWorkspace: true,
+		JobTimeout:       30 * time.Second,
+		DeniedThemes:     []string{"seriph"},
+	}
+	worker := NewWorker(1, jobService, storageService, config)
+
+	// Première tentative en échec (thème refusé)
+	worker.processJob(ctx, job)
+	require.Equal(t, models.StatusFailed, job.Status)
+	require.Len(t, job.Attempts, 1)
+	assert.Equal(t, 1, job.Attempts[0].Number)
+	assert.Equal(t, models.StatusFailed, job.Attempts[0].Status)
+	assert.Contains(t, job.Attempts[0].Error, "theme not allowed")
+	assert.False(t, job.Attempts[0].CompletedAt.Before(job.Attempts[0].StartedAt))
+
+	// Le job est remis en file après correction de la politique de thèmes
+	config.DeniedThemes = nil
+	worker = NewWorker(1, jobService, storageService, config)
+	job.Status = models.StatusPending
+	worker.processJob(ctx, job)
+
+	assert.Equal(t, models.StatusCompleted, job.Status)
+	assert.NotContains(t, job.Error, "theme not allowed", "the final status reflects the latest attempt")
+	assert.Equal(t, 2, job.AttemptCount)
+	require.Len(t, job.Attempts, 2)
+	assert.Equal(t, models.StatusCompleted, job.Attempts[1].Status)
+	assert.Empty(t, job.Attempts[1].Error)
+}
+
+func TestProcessJobEnqueuesCallback(t *testing.T) {
+	ctx := context.Background()
+	job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New(), Status: models.StatusPending,
+		CallbackURL: "https://hooks.example.com/ocf"}
+	jobService := &MockJobService{jobs: map[uuid.UUID]*models.GenerationJob{job.ID: job}}
+	storageService := storage.NewStorageService(&MockStorageBackend{})
+	require.NoError(t, storageService.UploadJobSource(ctx, job.ID, "slides.md", strings.NewReader("---\ntheme: seriph\n---\n# Cours\n")))
+
+	pool := NewWorkerPool(jobService, storageService, &PoolConfig{
+		WorkerCount:      1,
+		WorkspaceBase:    t.TempDir(),
+		SlidevCommand:    fakeSlidev(t),
+		VersionCheckMode: VersionCheckOff,
+		CleanupWorkspace: true,
+		JobTimeout:       30 * time.Second,
+		DeniedThemes:     []string{"seriph"},
+	})
+	notifier := jobs.NewCallbackNotifier(jobService, nil, nil)
+	pool.SetCallbackQueue(jobs.NewCallbackQueue(jobService, notifier, nil))
+
+	// Le worker met le callback en file sans l'envoyer lui-même
+	pool.workers[0].processJob(ctx, job)
+	require.Equal(t, models.StatusFailed, job.Status)
+	require.NotNil(t, job.CallbackNextAttemptAt)
+	assert.Zero(t, job.CallbackAttempts)
+	assert.Equal(t, 1, pool.GetStats().CallbackQueueDepth)
+}
@@ -0,0 +1,233 @@
+// internal/worker/build_cache.go - Cache Vite persistant entre les builds
+package worker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+)
+
+// Modes de cache de build Vite
+const (
+	// BuildCacheNone désactive le cache, chaque build repart de zéro
+	BuildCacheNone = "none"
+	// BuildCacheCourse conserve un cache par cours (meilleur taux de hit, plus de disque)
+	BuildCacheCourse = "course"
+	// BuildCacheShared conserve un cache unique pour tous les cours
+	BuildCacheShared = "shared"
+)
+
+// DefaultBuildCacheDir est le répertoire par défaut des caches de build
+const DefaultBuildCacheDir = "/tmp/ocf-build-cache"
+
+// viteCacheDir est le cacheDir par défaut de Vite, relatif au workspace
+const viteCacheDir = "node_modules/.vite"
+
+// buildCacheKeyFile contient l'empreinte des dépendances ayant produit le cache
+const buildCacheKeyFile = ".ocf-cache-key"
+
+// dependencyFiles sont les fichiers dont le contenu invalide le cache
+var dependencyFiles = []string{"package.json", "package-lock.json", "yarn.lock", "pnpm-lock.yaml"}
+
+// buildCacheLocks sérialise les accès à un même répertoire de cache entre les workers du processus
+var buildCacheLocks sync.Map
+
+// BuildCache restaure et sauvegarde le cache Vite d'un workspace.
+// Le build travaille toujours sur une copie privée : le cache partagé n'est lu
+// et remplacé que sous verrou, jamais modifié pendant un build.
+type BuildCache struct {
+	mode    string
+	baseDir string
+}
+
+// NewBuildCache crée un cache de build (mode vide = désactivé)
+func NewBuildCache(mode, baseDir string) *BuildCache {
+	if mode == "" {
+		mode = BuildCacheNone
+	}
+	if baseDir == "" {
+		baseDir = DefaultBuildCacheDir
+	}
+
+	return &BuildCache{
+		mode:    mode,
+		baseDir: baseDir,
+	}
+}
+
+// Enabled indique si le cache de build est actif
+func (bc *BuildCache) Enabled() bool {
+	return bc.mode == BuildCacheCourse || bc.mode == BuildCacheShared
+}
+
+// cachePath retourne le répertoire de cache utilisé pour un job
+func (bc *BuildCache) cachePath(job *models.GenerationJob) string {
+	if bc.mode == BuildCacheCourse {
+		return filepath.Join(bc.baseDir, "courses", job.CourseID.String())
+	}
+	return filepath.Join(bc.baseDir, "shared")
+}
+
+// lock verrouille un répertoire de cache et retourne la fonction de déverrouillage
+func (bc *BuildCache) lock(path string) func() {
+	value, _ := buildCacheLocks.LoadOrStore(path, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// cacheKey calcule l'empreinte des dépendances du workspace et des paquets du job. Les
+// sources n'y entrent pas : Vite réoptimise lui-même le pré-bundle quand l'empreinte de son
+// _metadata.json ne correspond plus ou qu'une source importe une nouvelle dépendance.
+func (bc *BuildCache) cacheKey(workspace *Workspace, job *models.GenerationJob) (string, error) {
+	hash := sha256.New()
+
+	for _, name := range dependencyFiles {
+		content, err := os.ReadFile(filepath.Join(workspace.GetPath(), name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		fmt.Fprintf(hash, "%s:%d:", name, len(content))
+		hash.Write(content)
+	}
+
+	packages := append([]string(nil), job.NpmPackages...)
+	sort.Strings(packages)
+	fmt.Fprintf(hash, "packages:%s", strings.Join(packages, ","))
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Restore copie le cache dans le workspace si les dépendances n'ont pas changé.
+// Retourne true en cas de hit.
+func (bc *BuildCache) Restore(workspace *Workspace, job *models.GenerationJob) (bool, error) {
+	if !bc.Enabled() {
+		return false, nil
+	}
+
+	key, err := bc.cacheKey(workspace, job)
+	if err != nil {
+		return false, err
+	}
+
+	path := bc.cachePath(job)
+	unlock := bc.lock(path)
+	defer unlock()
+
+	storedKey, err := os.ReadFile(filepath.Join(path, buildCacheKeyFile))
+	if err != nil {
+		// Pas encore de cache pour cette clé
+		return false, nil
+	}
+
+	if string(storedKey) != key {
+		log.Printf("Job %s: build cache invalidated, dependencies changed", job.ID)
+		return false, nil
+	}
+
+	if err := copyDirectory(path, filepath.Join(workspace.GetPath(), viteCacheDir)); err != nil {
+		return false, fmt.Errorf("failed to restore build cache: %w", err)
+	}
+
+	return true, nil
+}
+
+// Save remplace le cache par celui produit par le build du workspace
+func (bc *BuildCache) Save(workspace *Workspace, job *models.GenerationJob) error {
+	if !bc.Enabled() {
+		return nil
+	}
+
+	source := filepath.Join(workspace.GetPath(), viteCacheDir)
+	if _, err := os.Stat(source); err != nil {
+		// Le build n'a rien mis en cache
+		return nil
+	}
+
+	key, err := bc.cacheKey(workspace, job)
+	if err != nil {
+		return err
+	}
+
+	// Préparer la nouvelle version à côté puis la substituer sous verrou
+	path := bc.cachePath(job)
+	staging := path + ".tmp-" + job.ID.String()
+	defer os.RemoveAll(staging)
+
+	if err := copyDirectory(source, staging); err != nil {
+		return fmt.Errorf("failed to copy build cache: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(staging, buildCacheKeyFile), []byte(key), 0644); err != nil {
+		return fmt.Errorf("failed to write build cache key: %w", err)
+	}
+
+	unlock := bc.lock(path)
+	defer unlock()
+
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("failed to remove previous build cache: %w", err)
+	}
+	if err := os.Rename(staging, path); err != nil {
+		return fmt.Errorf("failed to replace build cache: %w", err)
+	}
+
+	return nil
+}
+
+// copyDirectory copie récursivement src vers dst, sans la clé de cache
+func copyDirectory(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, relPath)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if relPath == buildCacheKeyFile || !info.Mode().IsRegular() {
+			return nil
+		}
+
+		return copyRegularFile(path, target)
+	})
+}
+
+// copyRegularFile copie un fichier en créant les répertoires parents
+func copyRegularFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
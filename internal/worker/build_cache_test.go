@@ -0,0 +1,122 @@
+// internal/worker/build_cache_test.go
+package worker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCacheWorkspace crée un workspace avec un package.json donné
+func newCacheWorkspace(t *testing.T, basePath, packageJSON string) *Workspace {
+	workspace, err := NewWorkspace(basePath, uuid.New())
+	require.NoError(t, err)
+
+	require.NoError(t, workspace.WriteFile("package.json", strings.NewReader(packageJSON)))
+	return workspace
+}
+
+func TestBuildCache(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "ocf-build-cache-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	workspaces := filepath.Join(tempDir, "workspaces")
+	cache := NewBuildCache(BuildCacheCourse, filepath.Join(tempDir, "cache"))
+	courseID := uuid.New()
+	packageJSON := `{"dependencies": {"@slidev/cli": "^0.49.0"}}`
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		disabled := NewBuildCache("", "")
+		assert.False(t, disabled.Enabled())
+
+		hit, err := disabled.Restore(newCacheWorkspace(t, workspaces, packageJSON), &models.GenerationJob{})
+		require.NoError(t, err)
+		assert.False(t, hit)
+	})
+
+	t.Run("Miss then hit", func(t *testing.T) {
+		first := newCacheWorkspace(t, workspaces, packageJSON)
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: courseID}
+
+		hit, err := cache.Restore(first, job)
+		require.NoError(t, err)
+		assert.False(t, hit)
+
+		// Le build produit un cache Vite
+		require.NoError(t, first.WriteFile(viteCacheDir+"/deps/_metadata.json", strings.NewReader(`{"hash":"abc"}`)))
+		require.NoError(t, cache.Save(first, job))
+
+		second := newCacheWorkspace(t, workspaces, packageJSON)
+		hit, err = cache.Restore(second, &models.GenerationJob{ID: uuid.New(), CourseID: courseID})
+		require.NoError(t, err)
+		assert.True(t, hit)
+		assert.True(t, second.FileExists(viteCacheDir+"/deps/_metadata.json"))
+		assert.False(t, second.FileExists(viteCacheDir+"/"+buildCacheKeyFile))
+	})
+
+	t.Run("Force rebuild skips the cache", func(t *testing.T) {
+		runner := &SlidevRunner{buildCache: cache}
+		workspace := newCacheWorkspace(t, workspaces, packageJSON)
+
+		status := runner.restoreBuildCache(workspace, &models.GenerationJob{ID: uuid.New(), CourseID: courseID, ForceRebuild: true})
+		assert.Equal(t, "skipped (force rebuild)", status)
+		assert.False(t, workspace.FileExists(viteCacheDir+"/deps/_metadata.json"))
+
+		assert.Equal(t, "hit", runner.restoreBuildCache(workspace, &models.GenerationJob{ID: uuid.New(), CourseID: courseID}))
+	})
+
+	t.Run("Invalidated when dependencies change", func(t *testing.T) {
+		changed := newCacheWorkspace(t, workspaces, `{"dependencies": {"@slidev/cli": "^51.0.0"}}`)
+
+		hit, err := cache.Restore(changed, &models.GenerationJob{ID: uuid.New(), CourseID: courseID})
+		require.NoError(t, err)
+		assert.False(t, hit)
+
+		hit, err = cache.Restore(newCacheWorkspace(t, workspaces, packageJSON),
+			&models.GenerationJob{ID: uuid.New(), CourseID: courseID, NpmPackages: []string{"sass"}})
+		require.NoError(t, err)
+		assert.False(t, hit)
+	})
+
+	t.Run("Course caches are isolated", func(t *testing.T) {
+		hit, err := cache.Restore(newCacheWorkspace(t, workspaces, packageJSON),
+			&models.GenerationJob{ID: uuid.New(), CourseID: uuid.New()})
+		require.NoError(t, err)
+		assert.False(t, hit)
+	})
+
+	t.Run("Concurrent builds on a shared cache", func(t *testing.T) {
+		shared := NewBuildCache(BuildCacheShared, filepath.Join(tempDir, "shared-cache"))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				workspace := newCacheWorkspace(t, workspaces, packageJSON)
+				job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New()}
+
+				_, err := shared.Restore(workspace, job)
+				assert.NoError(t, err)
+				assert.NoError(t, workspace.WriteFile(viteCacheDir+"/deps/chunk.js", strings.NewReader("export {}")))
+				assert.NoError(t, shared.Save(workspace, job))
+			}()
+		}
+		wg.Wait()
+
+		hit, err := shared.Restore(newCacheWorkspace(t, workspaces, packageJSON),
+			&models.GenerationJob{ID: uuid.New(), CourseID: uuid.New()})
+		require.NoError(t, err)
+		assert.True(t, hit)
+	})
+}
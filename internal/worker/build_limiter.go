@@ -0,0 +1,101 @@
+// internal/worker/build_limiter.go - Limitation des builds Slidev simultanés
+package worker
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+)
+
+// DefaultMaxNpmProcesses retourne la limite par défaut des installations npm simultanées
+// de l'instance, tous jobs confondus
+func DefaultMaxNpmProcesses() int {
+	return runtime.NumCPU()
+}
+
+// DefaultMaxConcurrentBuilds retourne la limite par défaut des builds simultanés :
+// un build node/Vite par cœur, au-delà le débit n'augmente plus mais la mémoire si
+func DefaultMaxConcurrentBuilds() int {
+	return runtime.NumCPU()
+}
+
+// BuildLimiter borne le nombre de builds Slidev actifs, indépendamment du nombre de
+// workers : les workers en surplus téléchargent et uploadent pendant que d'autres buildent.
+// Le même mécanisme borne les processus npm de l'instance (NewNpmProcessLimiter).
+type BuildLimiter struct {
+	slots   chan struct{}
+	active  atomic.Int64
+	waiting atomic.Int64
+}
+
+// NewBuildLimiter crée un limiteur (limit <= 0 = DefaultMaxConcurrentBuilds)
+func NewBuildLimiter(limit int) *BuildLimiter {
+	if limit <= 0 {
+		limit = DefaultMaxConcurrentBuilds()
+	}
+	return &BuildLimiter{slots: make(chan struct{}, limit)}
+}
+
+// NewNpmProcessLimiter crée la limite des processus npm simultanés de l'instance
+// (limit <= 0 = DefaultMaxNpmProcesses)
+func NewNpmProcessLimiter(limit int) *BuildLimiter {
+	if limit <= 0 {
+		limit = DefaultMaxNpmProcesses()
+	}
+	return &BuildLimiter{slots: make(chan struct{}, limit)}
+}
+
+// Acquire attend un créneau de build et retourne la fonction de libération.
+// Un limiteur nil n'impose aucune limite.
+func (l *BuildLimiter) Acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	l.waiting.Add(1)
+	select {
+	case l.slots <- struct{}{}:
+		l.waiting.Add(-1)
+	case <-ctx.Done():
+		l.waiting.Add(-1)
+		return nil, ctx.Err()
+	}
+
+	l.active.Add(1)
+	var released atomic.Bool
+	return func() {
+		if released.CompareAndSwap(false, true) {
+			l.active.Add(-1)
+			<-l.slots
+		}
+	}, nil
+}
+
+// Available indique si un créneau est libre sans attendre
+func (l *BuildLimiter) Available() bool {
+	return l == nil || len(l.slots) < cap(l.slots)
+}
+
+// Limit retourne le nombre maximum de builds simultanés
+func (l *BuildLimiter) Limit() int {
+	if l == nil {
+		return 0
+	}
+	return cap(l.slots)
+}
+
+// Active retourne le nombre de builds en cours
+func (l *BuildLimiter) Active() int {
+	if l == nil {
+		return 0
+	}
+	return int(l.active.Load())
+}
+
+// Waiting retourne le nombre de jobs en attente d'un créneau de build
+func (l *BuildLimiter) Waiting() int {
+	if l == nil {
+		return 0
+	}
+	return int(l.waiting.Load())
+}
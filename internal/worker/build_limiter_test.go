@@ -0,0 +1,129 @@
+// internal/worker/build_limiter_test.go
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildLimiter(t *testing.T) {
+	t.Run("Never exceeds the limit", func(t *testing.T) {
+		limiter := NewBuildLimiter(2)
+
+		var mu sync.Mutex
+		active, peak := 0, 0
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				release, err := limiter.Acquire(context.Background())
+				require.NoError(t, err)
+				defer release()
+
+				mu.Lock()
+				active++
+				peak = max(peak, active)
+				mu.Unlock()
+
+				time.Sleep(5 * time.Millisecond)
+
+				mu.Lock()
+				active--
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 2, peak)
+		assert.Zero(t, limiter.Active())
+		assert.Zero(t, limiter.Waiting())
+	})
+
+	t.Run("Waiting job is cancelled with its context", func(t *testing.T) {
+		limiter := NewBuildLimiter(1)
+		release, err := limiter.Acquire(context.Background())
+		require.NoError(t, err)
+		assert.False(t, limiter.Available())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = limiter.Acquire(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Zero(t, limiter.Waiting())
+
+		// Une double libération ne rend pas deux créneaux
+		release()
+		release()
+		assert.Zero(t, limiter.Active())
+		assert.True(t, limiter.Available())
+	})
+
+	t.Run("Default limit", func(t *testing.T) {
+		assert.Equal(t, DefaultMaxConcurrentBuilds(), NewBuildLimiter(0).Limit())
+	})
+
+	t.Run("Shared by the pool workers and reported in stats", func(t *testing.T) {
+		pool := NewWorkerPool(nil, nil, &PoolConfig{WorkerCount: 4, MaxBuilds: 1})
+		for _, worker := range pool.workers {
+			assert.Same(t, pool.buildLimiter, worker.processor.slidevRunner.buildLimiter)
+		}
+
+		release, err := pool.buildLimiter.Acquire(context.Background())
+		require.NoError(t, err)
+		defer release()
+
+		stats := pool.GetStats()
+		assert.Equal(t, 1, stats.ActiveBuilds)
+		assert.Equal(t, 1, stats.MaxBuilds)
+		assert.Equal(t, 4, stats.WorkerCount)
+	})
+}
+
+func TestNpmProcessLimiter(t *testing.T) {
+	t.Run("Default limit", func(t *testing.T) {
+		assert.Equal(t, DefaultMaxNpmProcesses(), NewNpmProcessLimiter(0).Limit())
+	})
+
+	t.Run("Shared by every npm manager of the pool and reported in stats", func(t *testing.T) {
+		pool := NewWorkerPool(nil, nil, &PoolConfig{WorkerCount: 3, MaxNpmProcesses: 1})
+		for _, worker := range pool.workers {
+			assert.Same(t, pool.npmLimiter, worker.processor.slidevRunner.npmPackageManager.processLimiter)
+		}
+		assert.Same(t, pool.npmLimiter, pool.themePreviewer.runner.npmPackageManager.processLimiter)
+
+		release, err := pool.npmLimiter.Acquire(context.Background())
+		require.NoError(t, err)
+		defer release()
+
+		stats := pool.GetStats()
+		assert.Equal(t, 1, stats.ActiveNpmProcesses)
+		assert.Equal(t, 1, stats.MaxNpmProcesses)
+	})
+
+	t.Run("Install waits for a free slot", func(t *testing.T) {
+		manager := NewNpmPackageManager(t.TempDir())
+		manager.SetProcessLimiter(NewNpmProcessLimiter(1))
+
+		release, err := manager.processLimiter.Acquire(context.Background())
+		require.NoError(t, err)
+		defer release()
+
+		workspace, err := NewWorkspace(t.TempDir(), uuid.New())
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		result, err := manager.InstallNpmPackage(ctx, workspace, "sass")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Contains(t, result.Error, "npm process slot")
+		assert.ErrorIs(t, manager.NpmInstall(ctx, workspace), context.DeadlineExceeded)
+	})
+}
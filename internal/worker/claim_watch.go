@@ -0,0 +1,68 @@
+// internal/worker/claim_watch.go - Suivi du démarrage des jobs pour leurs créateurs
+package worker
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ClaimWatchers prévient les requêtes en attente qu'un job a été réservé par un worker
+// de cette instance. Un job réservé par une autre instance n'est pas signalé : les
+// observateurs s'appuient alors sur leur propre délai.
+type ClaimWatchers struct {
+	mu       sync.Mutex
+	watchers map[uuid.UUID]map[chan struct{}]struct{}
+}
+
+// NewClaimWatchers crée un suivi de réservation vide
+func NewClaimWatchers() *ClaimWatchers {
+	return &ClaimWatchers{watchers: make(map[uuid.UUID]map[chan struct{}]struct{})}
+}
+
+// Watch retourne un canal fermé à la réservation du job et la fonction qui arrête la
+// surveillance, à appeler dans tous les cas
+func (cw *ClaimWatchers) Watch(jobID uuid.UUID) (<-chan struct{}, func()) {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	claimed := make(chan struct{})
+	if cw.watchers[jobID] == nil {
+		cw.watchers[jobID] = make(map[chan struct{}]struct{})
+	}
+	cw.watchers[jobID][claimed] = struct{}{}
+
+	stop := func() {
+		cw.mu.Lock()
+		defer cw.mu.Unlock()
+
+		if _, watching := cw.watchers[jobID][claimed]; !watching {
+			return // Déjà signalé
+		}
+		delete(cw.watchers[jobID], claimed)
+		if len(cw.watchers[jobID]) == 0 {
+			delete(cw.watchers, jobID)
+		}
+	}
+
+	return claimed, stop
+}
+
+// Claimed signale la réservation d'un job à ses observateurs
+func (cw *ClaimWatchers) Claimed(jobID uuid.UUID) {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	for claimed := range cw.watchers[jobID] {
+		close(claimed)
+	}
+	delete(cw.watchers, jobID)
+}
+
+// Count retourne le nombre de jobs surveillés
+func (cw *ClaimWatchers) Count() int {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	return len(cw.watchers)
+}
@@ -0,0 +1,53 @@
+// internal/worker/claim_watch_test.go
+package worker
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaimWatchers(t *testing.T) {
+	t.Run("Claim closes every watcher of the job", func(t *testing.T) {
+		watchers := NewClaimWatchers()
+		jobID := uuid.New()
+
+		first, stopFirst := watchers.Watch(jobID)
+		second, stopSecond := watchers.Watch(jobID)
+		other, stopOther := watchers.Watch(uuid.New())
+		defer stopOther()
+
+		watchers.Claimed(jobID)
+
+		assert.True(t, isClosed(first))
+		assert.True(t, isClosed(second))
+		assert.False(t, isClosed(other))
+		assert.Equal(t, 1, watchers.Count())
+
+		// Arrêter une surveillance déjà signalée est sans effet
+		stopFirst()
+		stopSecond()
+	})
+
+	t.Run("Stopped watcher is not signalled", func(t *testing.T) {
+		watchers := NewClaimWatchers()
+		jobID := uuid.New()
+
+		claimed, stop := watchers.Watch(jobID)
+		stop()
+		assert.Zero(t, watchers.Count())
+
+		watchers.Claimed(jobID)
+		assert.False(t, isClosed(claimed))
+	})
+}
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,116 @@
+// internal/worker/deck_size.go - Avertissements sur la taille d'un deck
+package worker
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+)
+
+// slideFrontmatterKey reconnaît la première ligne du frontmatter d'une slide ("layout: cover")
+var slideFrontmatterKey = regexp.MustCompile(`^[A-Za-z_][\w-]*\s*:`)
+
+// countSlides compte les slides d'un fichier Markdown Slidev, séparées par des lignes "---".
+// Le frontmatter d'une slide ("---", "layout: cover", "---") n'en ouvre qu'une, et les
+// séparateurs des blocs de code sont ignorés. Les slides importées (src:) ne sont pas comptées.
+func countSlides(content string) int {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	slides := 0
+	newSlide := true // Le prochain contenu ouvre une slide
+	fence := ""
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+
+		if fence != "" {
+			if strings.HasPrefix(line, fence) {
+				fence = ""
+			}
+			continue
+		}
+
+		if line == "---" {
+			newSlide = true
+			if end := frontmatterEnd(lines, i); end > 0 {
+				// Frontmatter : la slide commence, le "---" fermant n'est pas un séparateur
+				slides++
+				newSlide = false
+				i = end
+			}
+			continue
+		}
+
+		if line == "" {
+			continue
+		}
+		if newSlide {
+			slides++
+			newSlide = false
+		}
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			fence = line[:3]
+		}
+	}
+	return slides
+}
+
+// frontmatterEnd retourne l'index du "---" fermant le frontmatter ouvert par le séparateur
+// lines[start], ou 0 si ce séparateur n'ouvre pas de frontmatter. Le bloc doit commencer par
+// une clé, ne contenir que des lignes YAML (clés, lignes indentées, éléments de liste, lignes
+// vides) et être fermé : "Note: ..." suivi de texte est le contenu d'une slide.
+func frontmatterEnd(lines []string, start int) int {
+	if start+1 >= len(lines) || !slideFrontmatterKey.MatchString(lines[start+1]) {
+		return 0
+	}
+	for i := start + 2; i < len(lines); i++ {
+		line := lines[i]
+		switch trimmed := strings.TrimSpace(line); {
+		case trimmed == "---":
+			return i
+		case trimmed == "", slideFrontmatterKey.MatchString(line),
+			strings.HasPrefix(line, " "), strings.HasPrefix(line, "\t"), strings.HasPrefix(trimmed, "- "):
+		default:
+			return 0
+		}
+	}
+	return 0
+}
+
+// countDeckSlides compte les slides du fichier de slides du job ; 0 s'il est illisible
+func (p *JobProcessor) countDeckSlides(job *models.GenerationJob, workspace *Workspace) int {
+	slideFile, err := resolveSlideFile(workspace, job, p.config.SlideFiles)
+	if err != nil {
+		return 0
+	}
+	reader, err := workspace.ReadFile(slideFile)
+	if err != nil {
+		log.Printf("Job %s: failed to read %s to count slides: %v", job.ID, slideFile, err)
+		return 0
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		log.Printf("Job %s: failed to read %s to count slides: %v", job.ID, slideFile, err)
+		return 0
+	}
+	return countSlides(string(content))
+}
+
+// deckSizeWarnings retourne les avertissements d'un build dépassant les seuils de nombre de
+// slides ou de taille des résultats (0 = seuil désactivé). Ils ne font pas échouer le build.
+func (p *JobProcessor) deckSizeWarnings(stats *models.BuildStats) []string {
+	var warnings []string
+	if threshold := p.config.SlideCountWarning; threshold > 0 && stats.SlideCount > threshold {
+		warnings = append(warnings, fmt.Sprintf(
+			"Deck has %d slides, above the warning threshold of %d: consider splitting it", stats.SlideCount, threshold))
+	}
+	if threshold := p.config.OutputSizeWarning; threshold > 0 && stats.ResultSizeBytes > threshold {
+		warnings = append(warnings, fmt.Sprintf(
+			"Build output is %d bytes, above the warning threshold of %d bytes: check for large assets",
+			stats.ResultSizeBytes, threshold))
+	}
+	return warnings
+}
@@ -0,0 +1,113 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountSlides(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+	}{
+		{"Empty file", "\n\n", 0},
+		{"Single slide", "# Title\n\nWelcome", 1},
+		{"Headmatter then slides", "---\ntheme: default\ntitle: Cours\n---\n\n# One\n\n---\n\n# Two\n", 2},
+		{"Slide frontmatter", "# One\n\n---\nlayout: cover\nclass: text-center\n---\n\n# Two\n\n---\n\n# Three", 3},
+		{"Frontmatter only slide", "# One\n\n---\nsrc: ./part.md\n---\n", 2},
+		{"Separators in code blocks", "# One\n\n```yaml\n---\nkey: value\n---\n```\n\n---\n\n# Two\n\n~~~md\n---\n~~~", 2},
+		{"Trailing separator", "# One\n\n---\n\n# Two\n\n---\n\n", 2},
+		{"Windows line endings", "# One\r\n\r\n---\r\n\r\n# Two\r\n", 2},
+		{"Frontmatter with nested values", "# One\n\n---\nlayout: image\nimage:\n  url: ./cover.png\nclasses:\n- dark\n---\n\n# Two", 2},
+		{"Key-like line opening a slide", "# One\n\n---\nNote: this slide has no frontmatter\n\nSome text\n\n---\n\n# Three", 3},
+		{"Unclosed frontmatter-like block", "# One\n\n---\nNote: remember\nTip: practice", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, countSlides(tt.content))
+		})
+	}
+}
+
+func TestDeckSizeWarnings(t *testing.T) {
+	processor := &JobProcessor{config: &PoolConfig{SlideCountWarning: 3, OutputSizeWarning: 1000}}
+
+	// Au seuil : pas d'avertissement
+	assert.Empty(t, processor.deckSizeWarnings(&models.BuildStats{SlideCount: 3, ResultSizeBytes: 1000}))
+
+	// Au-delà du seuil
+	warnings := processor.deckSizeWarnings(&models.BuildStats{SlideCount: 4, ResultSizeBytes: 1000})
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "Deck has 4 slides, above the warning threshold of 3")
+
+	warnings = processor.deckSizeWarnings(&models.BuildStats{SlideCount: 3, ResultSizeBytes: 1001})
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "Build output is 1001 bytes, above the warning threshold of 1000 bytes")
+
+	// Seuils désactivés
+	processor = &JobProcessor{config: &PoolConfig{}}
+	assert.Empty(t, processor.deckSizeWarnings(&models.BuildStats{SlideCount: 5000, ResultSizeBytes: 1 << 40}))
+}
+
+func TestProcessJobDeckSizeWarnings(t *testing.T) {
+	fakeSlidev(t)
+
+	slidev := filepath.Join(t.TempDir(), "slidev")
+	script := "#!/bin/sh\nmkdir -p dist\n" +
+		"printf '<!DOCTYPE html><html><head><title>Cours</title></head><body>Deck built from the job sources, padded to a realistic size.</body></html>' > dist/index.html\n"
+	require.NoError(t, os.WriteFile(slidev, []byte(script), 0o755))
+
+	run := func(t *testing.T, slideCountWarning int) *models.GenerationJob {
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New()}
+		jobService := &MockJobService{jobs: map[uuid.UUID]*models.GenerationJob{job.ID: job}}
+		storageService := storage.NewStorageService(&MockStorageBackend{})
+
+		ctx := context.Background()
+		slides := "---\ntheme: default\n---\n# One\n\n---\n\n# Two\n\n---\nlayout: end\n---\n# Three\n"
+		require.NoError(t, storageService.UploadJobSource(ctx, job.ID, "slides.md", strings.NewReader(slides)))
+
+		processor := NewJobProcessor(jobService, storageService, &PoolConfig{
+			WorkspaceBase:     t.TempDir(),
+			SlidevCommand:     slidev,
+			VersionCheckMode:  VersionCheckOff,
+			CleanupWorkspace:  true,
+			JobTimeout:        30 * time.Second,
+			SlideCountWarning: slideCountWarning,
+		})
+		result := processor.ProcessJob(ctx, job)
+		require.True(t, result.Success, "job error: %v", result.Error)
+
+		// L'empreinte des sources est celle que le client calcule à partir des checksums d'upload
+		checksum, err := storage.Checksum(strings.NewReader(slides))
+		require.NoError(t, err)
+		assert.Equal(t, storage.SourceHash(map[string]string{"slides.md": checksum}), job.SourceHash)
+		return job
+	}
+
+	t.Run("At the threshold", func(t *testing.T) {
+		job := run(t, 3)
+		assert.Equal(t, 3, job.SlideCount)
+		assert.Empty(t, job.Warnings)
+	})
+
+	t.Run("Above the threshold", func(t *testing.T) {
+		job := run(t, 2)
+		assert.Equal(t, models.StatusCompleted, job.Status)
+		require.Len(t, job.Warnings, 1)
+		assert.Contains(t, job.Warnings[0], "Deck has 3 slides")
+		assert.Equal(t, []string(job.Warnings), job.ToResponse().Warnings)
+	})
+}
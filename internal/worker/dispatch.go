@@ -0,0 +1,106 @@
+// internal/worker/dispatch.go - Répartition des jobs entre les workers
+package worker
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"log"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// Modes de répartition des jobs
+const (
+	// DispatchShared place les jobs dans une file unique, pris par le premier worker libre
+	DispatchShared = "shared"
+	// DispatchCourseAffinity envoie les jobs d'un même cours au même worker pour garder ses caches chauds
+	DispatchCourseAffinity = "course"
+)
+
+// DefaultAffinityQueueThreshold est le nombre de jobs en attente chez le worker affin
+// au-delà duquel un worker inactif prend le job à sa place
+const DefaultAffinityQueueThreshold = 1
+
+// workerQueueCapacity est la capacité de la file de chaque worker en mode affinité
+const workerQueueCapacity = 2
+
+// affineWorker retourne le worker associé à un cours par hachage de rendez-vous :
+// chaque cours garde son worker tant que celui-ci existe, et changer le nombre de
+// workers ne redistribue que les cours des workers ajoutés ou retirés.
+func affineWorker(courseID uuid.UUID, workerCount int) int {
+	best := 0
+	var bestScore uint64
+
+	for i := 0; i < workerCount; i++ {
+		hash := fnv.New64a()
+		hash.Write(courseID[:])
+		var index [8]byte
+		binary.BigEndian.PutUint64(index[:], uint64(i))
+		hash.Write(index[:])
+
+		if score := hash.Sum64(); i == 0 || score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	return best
+}
+
+// dispatchJob place un job dans une file de worker, retourne false si toutes sont pleines
+func (p *WorkerPool) dispatchJob(job *models.GenerationJob) bool {
+	if p.workerQueues == nil {
+		select {
+		case p.jobQueue <- job:
+			return true
+		default:
+			return false
+		}
+	}
+
+	return p.dispatchAffine(job)
+}
+
+// dispatchAffine envoie le job au worker de son cours, sauf si celui-ci a déjà trop
+// de jobs en attente et qu'un autre worker est inactif
+func (p *WorkerPool) dispatchAffine(job *models.GenerationJob) bool {
+	preferred := affineWorker(job.CourseID, len(p.workers))
+	queue := p.workerQueues[preferred]
+
+	if p.workerAvailable(preferred) || len(queue) < p.config.AffinityQueueThreshold {
+		select {
+		case queue <- job:
+			return true
+		default:
+		}
+	}
+
+	// Worker affin saturé : un worker inactif prend le job, au prix d'un cache froid
+	for i := range p.workers {
+		if i == preferred || !p.workerAvailable(i) {
+			continue
+		}
+		select {
+		case p.workerQueues[i] <- job:
+			log.Printf("Job %s (course %s) sent to idle worker %d instead of busy worker %d",
+				job.ID, job.CourseID, i, preferred)
+			return true
+		default:
+		}
+	}
+
+	// Aucun worker libre : attendre le worker affin si sa file le permet
+	select {
+	case queue <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// workerAvailable indique si un worker est inactif et sans job en attente
+func (p *WorkerPool) workerAvailable(index int) bool {
+	status, _ := p.workers[index].getState()
+	return status == "idle" && len(p.workerQueues[index]) == 0
+}
@@ -0,0 +1,109 @@
+// internal/worker/dispatch_test.go
+package worker
+
+import (
+	"testing"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAffinityPool crée un pool en mode affinité sans démarrer ses workers
+func newAffinityPool(workerCount, threshold int) *WorkerPool {
+	return NewWorkerPool(nil, nil, &PoolConfig{
+		WorkerCount:            workerCount,
+		DispatchMode:           DispatchCourseAffinity,
+		AffinityQueueThreshold: threshold,
+	})
+}
+
+func TestAffineWorker(t *testing.T) {
+	t.Run("Stable for a course", func(t *testing.T) {
+		courseID := uuid.New()
+		first := affineWorker(courseID, 4)
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, first, affineWorker(courseID, 4))
+		}
+	})
+
+	t.Run("Spreads courses across workers", func(t *testing.T) {
+		used := make(map[int]int)
+		for i := 0; i < 200; i++ {
+			used[affineWorker(uuid.New(), 4)]++
+		}
+		assert.Len(t, used, 4)
+	})
+
+	t.Run("Adding a worker only moves courses to it", func(t *testing.T) {
+		for i := 0; i < 200; i++ {
+			courseID := uuid.New()
+			after := affineWorker(courseID, 5)
+			if after != 4 {
+				assert.Equal(t, affineWorker(courseID, 4), after)
+			}
+		}
+	})
+}
+
+func TestDispatchCourseAffinity(t *testing.T) {
+	newJob := func(courseID uuid.UUID) *models.GenerationJob {
+		return &models.GenerationJob{ID: uuid.New(), CourseID: courseID}
+	}
+
+	t.Run("Same course goes to the same worker", func(t *testing.T) {
+		pool := newAffinityPool(3, 2)
+		courseID := uuid.New()
+		preferred := affineWorker(courseID, 3)
+
+		require.True(t, pool.dispatchJob(newJob(courseID)))
+		require.True(t, pool.dispatchJob(newJob(courseID)))
+
+		assert.Len(t, pool.workerQueues[preferred], 2)
+		stats := pool.GetStats()
+		assert.Equal(t, DispatchCourseAffinity, stats.DispatchMode)
+		assert.Equal(t, 2, stats.QueueSize)
+		assert.Equal(t, 2, stats.Workers[preferred].QueueSize)
+	})
+
+	t.Run("Idle worker takes over when the affine worker is saturated", func(t *testing.T) {
+		pool := newAffinityPool(2, 1)
+		courseID := uuid.New()
+		preferred := affineWorker(courseID, 2)
+		other := 1 - preferred
+
+		pool.workers[preferred].setState("busy", uuid.New())
+		require.True(t, pool.dispatchJob(newJob(courseID)))
+		assert.Len(t, pool.workerQueues[preferred], 1, "one job may wait for the affine worker")
+
+		require.True(t, pool.dispatchJob(newJob(courseID)))
+		assert.Len(t, pool.workerQueues[other], 1, "next job falls back to the idle worker")
+	})
+
+	t.Run("Waits for the affine worker when nobody is idle", func(t *testing.T) {
+		pool := newAffinityPool(2, 0)
+		courseID := uuid.New()
+		preferred := affineWorker(courseID, 2)
+
+		for _, worker := range pool.workers {
+			worker.setState("busy", uuid.New())
+		}
+
+		require.True(t, pool.dispatchJob(newJob(courseID)))
+		require.True(t, pool.dispatchJob(newJob(courseID)))
+		assert.Len(t, pool.workerQueues[preferred], workerQueueCapacity)
+
+		assert.False(t, pool.dispatchJob(newJob(courseID)), "full queue is retried at next poll")
+	})
+
+	t.Run("Shared mode keeps a single queue", func(t *testing.T) {
+		pool := NewWorkerPool(nil, nil, &PoolConfig{WorkerCount: 2})
+		assert.Nil(t, pool.workerQueues)
+
+		require.True(t, pool.dispatchJob(newJob(uuid.New())))
+		assert.Len(t, pool.jobQueue, 1)
+		assert.Equal(t, DispatchShared, pool.GetStats().DispatchMode)
+	})
+}
@@ -0,0 +1,174 @@
+// internal/worker/log_stream.go - Diffusion en direct des logs de build
+package worker
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// DefaultLogReplayLines est le nombre de lignes rejouées à un client qui se connecte en cours de build
+const DefaultLogReplayLines = 100
+
+// logSubscriberBuffer est la capacité du canal d'un abonné ; un client trop lent perd des lignes
+const logSubscriberBuffer = 256
+
+// LogStreams diffuse les logs des builds en cours vers les abonnés (flux SSE).
+// Chaque job garde ses dernières lignes dans un buffer circulaire borné, rejoué
+// aux nouveaux abonnés avant le suivi en direct. Le flux d'un job existe de Open à
+// Complete, ou tant qu'un abonné l'attend.
+type LogStreams struct {
+	replayLines int
+
+	mu      sync.Mutex
+	streams map[uuid.UUID]*logStream
+}
+
+// logStream contient le buffer de rejeu et les abonnés d'un job
+type logStream struct {
+	buffer      []string
+	start       int // Index de la plus ancienne ligne
+	size        int
+	subscribers map[chan string]struct{}
+	open        bool // Ouvert par le worker qui traite le job, jusqu'à Complete
+}
+
+// NewLogStreams crée un diffuseur de logs (replayLines <= 0 = DefaultLogReplayLines)
+func NewLogStreams(replayLines int) *LogStreams {
+	if replayLines <= 0 {
+		replayLines = DefaultLogReplayLines
+	}
+
+	return &LogStreams{
+		replayLines: replayLines,
+		streams:     make(map[uuid.UUID]*logStream),
+	}
+}
+
+// getOrCreate retourne le flux d'un job en le créant si besoin (appelé sous verrou)
+func (ls *LogStreams) getOrCreate(jobID uuid.UUID) *logStream {
+	stream, exists := ls.streams[jobID]
+	if !exists {
+		stream = &logStream{
+			buffer:      make([]string, ls.replayLines),
+			subscribers: make(map[chan string]struct{}),
+		}
+		ls.streams[jobID] = stream
+	}
+	return stream
+}
+
+// Open ouvre le flux d'un job au début de son traitement
+func (ls *LogStreams) Open(jobID uuid.UUID) {
+	if ls == nil {
+		return
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	ls.getOrCreate(jobID).open = true
+}
+
+// Publish ajoute une ligne au buffer du job et la transmet aux abonnés. Une ligne
+// publiée sans flux (après Complete) est ignorée plutôt que de recréer un flux que
+// plus rien ne libérerait.
+func (ls *LogStreams) Publish(jobID uuid.UUID, line string) {
+	if ls == nil {
+		return
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	stream, exists := ls.streams[jobID]
+	if !exists {
+		return
+	}
+
+	capacity := len(stream.buffer)
+	if stream.size < capacity {
+		stream.buffer[(stream.start+stream.size)%capacity] = line
+		stream.size++
+	} else {
+		stream.buffer[stream.start] = line
+		stream.start = (stream.start + 1) % capacity
+	}
+
+	for subscriber := range stream.subscribers {
+		select {
+		case subscriber <- line:
+		default:
+			// Abonné trop lent, la ligne est perdue pour lui
+		}
+	}
+}
+
+// Subscribe abonne un client aux logs d'un job. Retourne les lignes à rejouer, le canal
+// des lignes suivantes (fermé à la fin du job) et la fonction de désabonnement.
+func (ls *LogStreams) Subscribe(jobID uuid.UUID) ([]string, <-chan string, func()) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	stream := ls.getOrCreate(jobID)
+
+	replay := make([]string, stream.size)
+	for i := 0; i < stream.size; i++ {
+		replay[i] = stream.buffer[(stream.start+i)%len(stream.buffer)]
+	}
+
+	lines := make(chan string, logSubscriberBuffer)
+	stream.subscribers[lines] = struct{}{}
+
+	unsubscribe := func() {
+		ls.mu.Lock()
+		defer ls.mu.Unlock()
+
+		current, exists := ls.streams[jobID]
+		if !exists || current != stream {
+			return // Job terminé entre-temps, canal déjà fermé
+		}
+		if _, subscribed := stream.subscribers[lines]; !subscribed {
+			return
+		}
+
+		delete(stream.subscribers, lines)
+		close(lines)
+
+		// Un flux que le worker n'a pas ouvert (job pas encore démarré ou déjà terminé)
+		// ne survit pas à son dernier abonné
+		if len(stream.subscribers) == 0 && !stream.open {
+			delete(ls.streams, jobID)
+		}
+	}
+
+	return replay, lines, unsubscribe
+}
+
+// Complete termine le flux d'un job : les abonnés sont notifiés par la fermeture
+// de leur canal et le buffer est libéré.
+func (ls *LogStreams) Complete(jobID uuid.UUID) {
+	if ls == nil {
+		return
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	stream, exists := ls.streams[jobID]
+	if !exists {
+		return
+	}
+
+	for subscriber := range stream.subscribers {
+		close(subscriber)
+	}
+	delete(ls.streams, jobID)
+}
+
+// ActiveStreams retourne le nombre de jobs dont le buffer est en mémoire
+func (ls *LogStreams) ActiveStreams() int {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return len(ls.streams)
+}
@@ -0,0 +1,141 @@
+// internal/worker/log_stream_test.go
+package worker
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogStreams(t *testing.T) {
+	t.Run("Replay is bounded and ordered", func(t *testing.T) {
+		streams := NewLogStreams(3)
+		jobID := uuid.New()
+		streams.Open(jobID)
+
+		for i := 1; i <= 5; i++ {
+			streams.Publish(jobID, fmt.Sprintf("line %d", i))
+		}
+
+		replay, _, unsubscribe := streams.Subscribe(jobID)
+		defer unsubscribe()
+		assert.Equal(t, []string{"line 3", "line 4", "line 5"}, replay)
+	})
+
+	t.Run("Live lines follow the replay", func(t *testing.T) {
+		streams := NewLogStreams(10)
+		jobID := uuid.New()
+		streams.Open(jobID)
+		streams.Publish(jobID, "before")
+
+		replay, lines, unsubscribe := streams.Subscribe(jobID)
+		defer unsubscribe()
+		assert.Equal(t, []string{"before"}, replay)
+
+		streams.Publish(jobID, "after")
+		assert.Equal(t, "after", <-lines)
+	})
+
+	t.Run("Complete closes subscribers and frees the buffer", func(t *testing.T) {
+		streams := NewLogStreams(10)
+		jobID := uuid.New()
+		streams.Open(jobID)
+		streams.Publish(jobID, "line")
+
+		_, lines, unsubscribe := streams.Subscribe(jobID)
+		streams.Complete(jobID)
+
+		_, open := <-lines
+		assert.False(t, open)
+		assert.Zero(t, streams.ActiveStreams())
+
+		// Le désabonnement après la fin du job ne referme pas le canal
+		assert.NotPanics(t, unsubscribe)
+	})
+
+	t.Run("Unsubscribing before any output leaves no stream", func(t *testing.T) {
+		streams := NewLogStreams(10)
+		_, _, unsubscribe := streams.Subscribe(uuid.New())
+		unsubscribe()
+		unsubscribe()
+		assert.Zero(t, streams.ActiveStreams())
+	})
+
+	t.Run("Lines published after Complete are dropped", func(t *testing.T) {
+		streams := NewLogStreams(10)
+		jobID := uuid.New()
+		streams.Open(jobID)
+		streams.Complete(jobID)
+
+		streams.Publish(jobID, "late line")
+		assert.Zero(t, streams.ActiveStreams())
+
+		// Un abonné arrivé après la fin ne garde pas le flux en mémoire une fois parti
+		replay, _, unsubscribe := streams.Subscribe(jobID)
+		assert.Empty(t, replay)
+		streams.Publish(jobID, "later line")
+		unsubscribe()
+		assert.Zero(t, streams.ActiveStreams())
+	})
+
+	t.Run("Nil streams are ignored", func(t *testing.T) {
+		var streams *LogStreams
+		assert.NotPanics(t, func() {
+			streams.Open(uuid.New())
+			streams.Publish(uuid.New(), "line")
+			streams.Complete(uuid.New())
+		})
+	})
+
+	t.Run("Concurrent publishers and subscribers", func(t *testing.T) {
+		streams := NewLogStreams(50)
+		jobID := uuid.New()
+		streams.Open(jobID)
+
+		var wg sync.WaitGroup
+		for p := 0; p < 4; p++ {
+			wg.Add(1)
+			go func(p int) {
+				defer wg.Done()
+				for i := 0; i < 200; i++ {
+					streams.Publish(jobID, fmt.Sprintf("publisher %d line %d", p, i))
+				}
+			}(p)
+		}
+
+		for s := 0; s < 4; s++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				replay, lines, unsubscribe := streams.Subscribe(jobID)
+				defer unsubscribe()
+				assert.LessOrEqual(t, len(replay), 50)
+				for i := 0; i < 10; i++ {
+					select {
+					case <-lines:
+					case <-time.After(10 * time.Millisecond):
+					}
+				}
+			}()
+		}
+
+		wg.Wait()
+		streams.Complete(jobID)
+		assert.Zero(t, streams.ActiveStreams())
+	})
+
+	t.Run("Shared by the pool workers", func(t *testing.T) {
+		pool := NewWorkerPool(nil, nil, &PoolConfig{WorkerCount: 2, LogReplayLines: 5})
+		require.Len(t, pool.workers, 2)
+
+		for _, worker := range pool.workers {
+			assert.Same(t, pool.logStreams, worker.processor.slidevRunner.logStreams)
+		}
+		assert.Equal(t, 5, pool.logStreams.replayLines)
+	})
+}
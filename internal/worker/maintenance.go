@@ -0,0 +1,105 @@
+// internal/worker/maintenance.go - Mode maintenance d'une instance
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+)
+
+// ErrMaintenance est retournée à l'admission des jobs quand l'instance est en maintenance
+var ErrMaintenance = errors.New("worker is in maintenance mode")
+
+// DefaultMaintenanceMessage est le message renvoyé aux soumissions refusées sans message configuré
+const DefaultMaintenanceMessage = "The worker is paused for maintenance, retry later"
+
+// maintenanceMode est l'état du mode maintenance : il survit à l'arrêt et au redémarrage du
+// pool, pas à celui du processus
+type maintenanceMode struct {
+	mu      sync.RWMutex
+	enabled bool
+	message string
+	since   time.Time
+	resume  chan struct{} // Fermé à la sortie du mode maintenance
+}
+
+// SetMaintenance active ou désactive le mode maintenance. En maintenance, les soumissions de
+// jobs sont refusées (ErrMaintenance) et aucun job n'est sorti de la file : les jobs en cours
+// se terminent, les jobs en attente restent pending jusqu'à la reprise.
+func (p *WorkerPool) SetMaintenance(enabled bool, message string) models.MaintenanceStatus {
+	m := &p.maintenance
+	m.mu.Lock()
+	switch {
+	case enabled && !m.enabled:
+		m.enabled = true
+		m.since = time.Now()
+		m.resume = make(chan struct{})
+		log.Printf("Maintenance mode enabled: new jobs are rejected, in-flight jobs finish")
+	case !enabled && m.enabled:
+		m.enabled = false
+		close(m.resume)
+		m.resume = nil
+		log.Printf("Maintenance mode disabled after %v: resuming job processing", time.Since(m.since).Round(time.Second))
+	}
+	m.message = ""
+	if enabled {
+		m.message = message
+		if m.message == "" {
+			m.message = DefaultMaintenanceMessage
+		}
+	}
+	m.mu.Unlock()
+
+	return p.Maintenance()
+}
+
+// Maintenance retourne l'état du mode maintenance et l'avancement du drainage
+func (p *WorkerPool) Maintenance() models.MaintenanceStatus {
+	m := &p.maintenance
+	m.mu.RLock()
+	status := models.MaintenanceStatus{Enabled: m.enabled, Message: m.message}
+	if m.enabled {
+		since := m.since
+		status.Since = &since
+	}
+	m.mu.RUnlock()
+
+	for _, worker := range p.workers {
+		if worker.GetStats().Status == "busy" {
+			status.ActiveJobs++
+		}
+	}
+	status.Drained = status.Enabled && status.ActiveJobs == 0
+	return status
+}
+
+// inMaintenance indique si l'instance est en maintenance
+func (p *WorkerPool) inMaintenance() bool {
+	p.maintenance.mu.RLock()
+	defer p.maintenance.mu.RUnlock()
+	return p.maintenance.enabled
+}
+
+// waitMaintenanceEnd bloque un worker tant que l'instance est en maintenance ; retourne
+// false si le pool s'arrête entre-temps
+func (p *WorkerPool) waitMaintenanceEnd(ctx context.Context) bool {
+	p.maintenance.mu.RLock()
+	resume := p.maintenance.resume
+	p.maintenance.mu.RUnlock()
+
+	if resume == nil {
+		return true
+	}
+	select {
+	case <-resume:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-p.stopCh:
+		return false
+	}
+}
@@ -0,0 +1,116 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenance(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Rejects new jobs until disabled", func(t *testing.T) {
+		pool := newAdmissionPool(0, &PoolConfig{})
+
+		status := pool.SetMaintenance(true, "")
+		assert.True(t, status.Enabled)
+		assert.Equal(t, DefaultMaintenanceMessage, status.Message)
+		require.NotNil(t, status.Since)
+		assert.True(t, status.Drained)
+		assert.ErrorIs(t, admit(ctx, pool, 1), ErrMaintenance)
+
+		// Le message peut changer sans réinitialiser le début de la maintenance
+		updated := pool.SetMaintenance(true, "Upgrade in progress")
+		assert.Equal(t, "Upgrade in progress", updated.Message)
+		assert.Equal(t, *status.Since, *updated.Since)
+
+		status = pool.SetMaintenance(false, "ignored")
+		assert.False(t, status.Enabled)
+		assert.Empty(t, status.Message)
+		assert.Nil(t, status.Since)
+		assert.False(t, status.Drained)
+		assert.NoError(t, admit(ctx, pool, 1))
+	})
+
+	t.Run("Pending jobs are not dispatched", func(t *testing.T) {
+		pool := newAdmissionPool(3, &PoolConfig{})
+		pool.SetMaintenance(true, "")
+
+		require.NoError(t, pool.pollPendingJobs(ctx))
+		assert.Zero(t, pool.GetStats().QueueSize)
+		assert.Equal(t, 3, pool.GetStats().BacklogSize)
+
+		pool.SetMaintenance(false, "")
+		require.NoError(t, pool.pollPendingJobs(ctx))
+		assert.Equal(t, 2, pool.GetStats().QueueSize)
+	})
+
+	newPool := func(t *testing.T) (*WorkerPool, *models.GenerationJob) {
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New(), Status: models.StatusPending}
+		jobService := &MockJobService{jobs: map[uuid.UUID]*models.GenerationJob{job.ID: job}}
+		pool := NewWorkerPool(jobService, storage.NewStorageService(&MockStorageBackend{}), &PoolConfig{
+			WorkerCount:   1,
+			PollInterval:  time.Hour,
+			JobTimeout:    30 * time.Second,
+			WorkspaceBase: t.TempDir(),
+		})
+		return pool, job
+	}
+
+	t.Run("Queued jobs wait for the end of maintenance", func(t *testing.T) {
+		pool, job := newPool(t)
+		pool.SetMaintenance(true, "")
+		require.NoError(t, pool.Start(ctx))
+		defer pool.Stop()
+
+		// Job mis en file avant la maintenance
+		claimed, stopWatch := pool.WatchJobClaim(job.ID)
+		defer stopWatch()
+		require.True(t, pool.markQueued(job))
+		require.True(t, pool.dispatchJob(job))
+
+		select {
+		case <-claimed:
+			t.Fatal("job claimed during maintenance")
+		case <-time.After(200 * time.Millisecond):
+		}
+		assert.True(t, pool.Maintenance().Drained)
+
+		pool.SetMaintenance(false, "")
+		select {
+		case <-claimed:
+		case <-time.After(2 * time.Second):
+			t.Fatal("job not claimed after maintenance")
+		}
+	})
+
+	t.Run("Stopping during maintenance leaves queued jobs pending", func(t *testing.T) {
+		pool, job := newPool(t)
+		pool.SetMaintenance(true, "")
+		require.NoError(t, pool.Start(ctx))
+
+		require.True(t, pool.markQueued(job))
+		require.True(t, pool.dispatchJob(job))
+		time.Sleep(50 * time.Millisecond)
+
+		stopped := make(chan struct{})
+		go func() {
+			pool.Stop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(2 * time.Second):
+			t.Fatal("pool did not stop during maintenance")
+		}
+		assert.Equal(t, models.StatusPending, job.Status)
+		assert.True(t, pool.Maintenance().Enabled)
+	})
+}
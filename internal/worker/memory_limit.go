@@ -0,0 +1,36 @@
+// internal/worker/memory_limit.go - Limite mémoire des sous-processus de build
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// DefaultBuildCgroupDir est le cgroup v2 parent sous lequel chaque job reçoit le sien
+const DefaultBuildCgroupDir = "/sys/fs/cgroup/ocf-worker"
+
+// errMemoryLimitUnsupported est retournée hors Linux, où les cgroups n'existent pas
+var errMemoryLimitUnsupported = errors.New("build memory limits are only supported on Linux")
+
+// MemoryLimit borne la mémoire de tous les sous-processus npm et Slidev d'un job.
+// Une limite nil n'impose rien : toutes les méthodes sont sans effet.
+type MemoryLimit struct {
+	dir   string   // Cgroup du job
+	fd    *os.File // Descripteur du cgroup, passé aux commandes via SysProcAttr
+	limit int64    // Limite en octets
+}
+
+// outOfMemoryError décrit un sous-processus tué pour avoir dépassé la limite
+func (m *MemoryLimit) outOfMemoryError(step string) error {
+	return fmt.Errorf("out of memory: %s exceeded the %d MB memory limit, reduce the course size or raise BUILD_MEMORY_LIMIT_MB",
+		step, m.limit>>20)
+}
+
+// Limit retourne la limite en octets (0 si aucune)
+func (m *MemoryLimit) Limit() int64 {
+	if m == nil {
+		return 0
+	}
+	return m.limit
+}
@@ -0,0 +1,154 @@
+//go:build linux
+
+// internal/worker/memory_limit_linux.go - Limite mémoire par cgroup v2
+package worker
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NewMemoryLimit crée le cgroup d'un job sous parentDir, borné à limit octets.
+// Le parent doit exister, être délégué à l'utilisateur du worker et avoir le
+// contrôleur memory activé (le worker tente de l'activer lui-même).
+func NewMemoryLimit(parentDir string, jobID uuid.UUID, limit int64) (*MemoryLimit, error) {
+	// Activer le contrôleur memory pour les cgroups enfants, s'il ne l'est pas déjà
+	_ = os.WriteFile(filepath.Join(parentDir, "cgroup.subtree_control"), []byte("+memory"), 0)
+
+	dir := filepath.Join(parentDir, "job-"+jobID.String())
+	if err := os.Mkdir(dir, 0755); err != nil && !os.IsExist(err) {
+		return nil, fmt.Errorf("failed to create cgroup %s: %w", dir, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "memory.max"), []byte(strconv.FormatInt(limit, 10)), 0); err != nil {
+		os.Remove(dir)
+		return nil, fmt.Errorf("failed to set memory.max on %s: %w", dir, err)
+	}
+	// Sans swap, la limite est franche ; absent si le swap n'est pas comptabilisé
+	_ = os.WriteFile(filepath.Join(dir, "memory.swap.max"), []byte("0"), 0)
+
+	fd, err := os.Open(dir)
+	if err != nil {
+		os.Remove(dir)
+		return nil, fmt.Errorf("failed to open cgroup %s: %w", dir, err)
+	}
+
+	return &MemoryLimit{dir: dir, fd: fd, limit: limit}, nil
+}
+
+// apply démarre la commande directement dans le cgroup du job
+func (m *MemoryLimit) apply(cmd *exec.Cmd) {
+	if m == nil {
+		return
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(m.fd.Fd())
+}
+
+// oomKills retourne le nombre de processus tués par le noyau pour dépassement de la limite
+func (m *MemoryLimit) oomKills() int {
+	if m == nil {
+		return 0
+	}
+
+	file, err := os.Open(filepath.Join(m.dir, "memory.events"))
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if count, found := strings.CutPrefix(scanner.Text(), "oom_kill "); found {
+			n, _ := strconv.Atoi(count)
+			return n
+		}
+	}
+	return 0
+}
+
+// cgroupReleaseTimeout borne l'attente de la sortie des processus d'un cgroup avant sa suppression
+const cgroupReleaseTimeout = 5 * time.Second
+
+// Release supprime le cgroup du job. Les processus encore attachés (enfants détachés de
+// npm ou de Slidev) sont tués : tant qu'il en reste, la suppression échoue avec EBUSY.
+func (m *MemoryLimit) Release() error {
+	if m == nil {
+		return nil
+	}
+	m.fd.Close()
+
+	if err := m.killProcesses(); err != nil {
+		log.Printf("Failed to kill the remaining processes of cgroup %s: %v", m.dir, err)
+	}
+
+	deadline := time.Now().Add(cgroupReleaseTimeout)
+	for {
+		err := os.Remove(m.dir)
+		if err == nil || os.IsNotExist(err) {
+			return nil
+		}
+		if !errors.Is(err, syscall.EBUSY) || time.Now().After(deadline) {
+			return fmt.Errorf("failed to remove cgroup %s: %w", m.dir, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// killProcesses tue les processus du cgroup : par cgroup.kill (Linux 5.14+), sinon par
+// SIGKILL sur chaque PID de cgroup.procs
+func (m *MemoryLimit) killProcesses() error {
+	pids, err := m.processes()
+	if err != nil || len(pids) == 0 {
+		return err
+	}
+
+	// Sans O_CREATE : le fichier n'existe que sur un vrai cgroup v2 récent
+	if kill, err := os.OpenFile(filepath.Join(m.dir, "cgroup.kill"), os.O_WRONLY, 0); err == nil {
+		_, err = kill.WriteString("1")
+		kill.Close()
+		if err == nil {
+			return nil
+		}
+	}
+
+	for _, pid := range pids {
+		if err := syscall.Kill(pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return fmt.Errorf("failed to kill process %d: %w", pid, err)
+		}
+	}
+	return nil
+}
+
+// processes retourne les PID attachés au cgroup
+func (m *MemoryLimit) processes() ([]int, error) {
+	data, err := os.ReadFile(filepath.Join(m.dir, "cgroup.procs"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cgroup.procs: %w", err)
+	}
+
+	var pids []int
+	for _, field := range strings.Fields(string(data)) {
+		if pid, err := strconv.Atoi(field); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
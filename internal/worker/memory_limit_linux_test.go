@@ -0,0 +1,81 @@
+//go:build linux
+
+// internal/worker/memory_limit_linux_test.go
+package worker
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLimit(t *testing.T) {
+	t.Run("Creates a bounded cgroup per job", func(t *testing.T) {
+		// Un répertoire ordinaire tient lieu de cgroup parent
+		parentDir := t.TempDir()
+		jobID := uuid.New()
+
+		limit, err := NewMemoryLimit(parentDir, jobID, 512<<20)
+		require.NoError(t, err)
+		defer limit.fd.Close()
+
+		maxMemory, err := os.ReadFile(filepath.Join(parentDir, "job-"+jobID.String(), "memory.max"))
+		require.NoError(t, err)
+		assert.Equal(t, "536870912", string(maxMemory))
+
+		cmd := exec.Command("true")
+		limit.apply(cmd)
+		require.NotNil(t, cmd.SysProcAttr)
+		assert.True(t, cmd.SysProcAttr.UseCgroupFD)
+		assert.Equal(t, int(limit.fd.Fd()), cmd.SysProcAttr.CgroupFD)
+
+		assert.Zero(t, limit.oomKills())
+		require.NoError(t, os.WriteFile(filepath.Join(limit.dir, "memory.events"),
+			[]byte("low 0\nhigh 0\nmax 3\noom 1\noom_kill 1\n"), 0644))
+		assert.Equal(t, 1, limit.oomKills())
+		assert.Contains(t, limit.outOfMemoryError("slidev build").Error(), "out of memory: slidev build exceeded the 512 MB memory limit")
+	})
+
+	t.Run("Release kills the remaining processes", func(t *testing.T) {
+		limit, err := NewMemoryLimit(t.TempDir(), uuid.New(), 512<<20)
+		require.NoError(t, err)
+
+		// Processus détaché resté dans le cgroup après la fin du build
+		sleeper := exec.Command("sleep", "60")
+		require.NoError(t, sleeper.Start())
+		require.NoError(t, os.WriteFile(filepath.Join(limit.dir, "cgroup.procs"),
+			[]byte(strconv.Itoa(sleeper.Process.Pid)+"\n"), 0644))
+
+		// Le faux cgroup contient des fichiers ordinaires : sa suppression échoue et le dit
+		err = limit.Release()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to remove cgroup")
+
+		waitErr := sleeper.Wait()
+		var exitErr *exec.ExitError
+		require.ErrorAs(t, waitErr, &exitErr)
+		assert.Equal(t, syscall.SIGKILL, exitErr.Sys().(syscall.WaitStatus).Signal())
+	})
+
+	t.Run("Missing parent cgroup", func(t *testing.T) {
+		_, err := NewMemoryLimit(filepath.Join(t.TempDir(), "missing"), uuid.New(), 512<<20)
+		assert.Error(t, err)
+	})
+
+	t.Run("Nil limit is a no-op", func(t *testing.T) {
+		var limit *MemoryLimit
+		cmd := exec.Command("true")
+		limit.apply(cmd)
+		assert.Nil(t, cmd.SysProcAttr)
+		assert.Zero(t, limit.oomKills())
+		assert.Zero(t, limit.Limit())
+		assert.NoError(t, limit.Release())
+	})
+}
@@ -0,0 +1,24 @@
+//go:build !linux
+
+// internal/worker/memory_limit_other.go - Pas de limite mémoire hors Linux
+package worker
+
+import (
+	"os/exec"
+
+	"github.com/google/uuid"
+)
+
+// NewMemoryLimit n'est pas supporté hors Linux
+func NewMemoryLimit(parentDir string, jobID uuid.UUID, limit int64) (*MemoryLimit, error) {
+	return nil, errMemoryLimitUnsupported
+}
+
+// apply est sans effet hors Linux
+func (m *MemoryLimit) apply(cmd *exec.Cmd) {}
+
+// oomKills retourne toujours 0 hors Linux
+func (m *MemoryLimit) oomKills() int { return 0 }
+
+// Release est sans effet hors Linux
+func (m *MemoryLimit) Release() error { return nil }
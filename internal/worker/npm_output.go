@@ -0,0 +1,104 @@
+// internal/worker/npm_output.go - Classement de la sortie des installations npm
+package worker
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+)
+
+// maxNpmSummaryMessages borne le nombre de messages d'avertissement ou d'erreur conservés
+const maxNpmSummaryMessages = 10
+
+// capturedLinePrefix est le préfixe ajouté par safeOutputCapture ("[15:04:05] STDERR: ")
+var capturedLinePrefix = regexp.MustCompile(`^(?:\[\d{2}:\d{2}:\d{2}\] )?(?:STDOUT|STDERR): `)
+
+// Préfixes de npm (ERR!/WARN avant npm 10, error/warn ensuite) et de yarn classic
+var (
+	npmErrorPrefix   = regexp.MustCompile(`^(?:npm (?:ERR!|error\b)|error\b):? ?`)
+	npmWarningPrefix = regexp.MustCompile(`^(?:npm (?:WARN|warn\b)|warning\b):? ?`)
+	npmDeprecation   = regexp.MustCompile(`(?i)^deprecated\b|: deprecated\b`)
+)
+
+// npmLineKind est la catégorie d'une ligne de sortie npm
+type npmLineKind int
+
+const (
+	npmLineOther npmLineKind = iota
+	npmLineWarning
+	npmLineError
+)
+
+// classifyNpmLine retourne la catégorie d'une ligne capturée et son message sans préfixe
+func classifyNpmLine(line string) (npmLineKind, string) {
+	line = strings.TrimSpace(capturedLinePrefix.ReplaceAllString(line, ""))
+
+	if prefix := npmErrorPrefix.FindString(line); prefix != "" {
+		return npmLineError, strings.TrimSpace(line[len(prefix):])
+	}
+	if prefix := npmWarningPrefix.FindString(line); prefix != "" {
+		return npmLineWarning, strings.TrimSpace(line[len(prefix):])
+	}
+	return npmLineOther, line
+}
+
+// summarizeNpmOutput compte les avertissements et les erreurs de la sortie d'une installation.
+// Un avertissement répété (relances) n'est compté qu'une fois ; les lignes d'erreur consécutives
+// (code, détail, chemin du log) forment une seule erreur, résumée par sa première ligne.
+func summarizeNpmOutput(logs []string) *models.NpmOutputSummary {
+	summary := &models.NpmOutputSummary{}
+	seenWarnings := make(map[string]bool)
+	inError := false
+
+	for _, line := range logs {
+		kind, message := classifyNpmLine(line)
+		switch kind {
+		case npmLineWarning:
+			inError = false
+			if message == "" || seenWarnings[message] {
+				continue
+			}
+			seenWarnings[message] = true
+			summary.Warnings++
+			if npmDeprecation.MatchString(message) {
+				summary.Deprecations++
+			}
+			if len(summary.WarningMessages) < maxNpmSummaryMessages {
+				summary.WarningMessages = append(summary.WarningMessages, message)
+			}
+		case npmLineError:
+			if inError || message == "" {
+				continue
+			}
+			inError = true
+			summary.Errors++
+			if len(summary.ErrorMessages) < maxNpmSummaryMessages {
+				summary.ErrorMessages = append(summary.ErrorMessages, message)
+			}
+		default:
+			inError = false
+		}
+	}
+
+	summary.Text = npmSummaryText(summary)
+	return summary
+}
+
+// npmSummaryText formate un résumé lisible : "3 warnings (2 deprecations), 1 error"
+func npmSummaryText(summary *models.NpmOutputSummary) string {
+	warnings := plural(summary.Warnings, "warning")
+	if summary.Deprecations > 0 {
+		warnings += fmt.Sprintf(" (%s)", plural(summary.Deprecations, "deprecation"))
+	}
+	return warnings + ", " + plural(summary.Errors, "error")
+}
+
+// plural accorde un nom avec son nombre
+func plural(count int, noun string) string {
+	if count == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
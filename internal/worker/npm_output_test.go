@@ -0,0 +1,112 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeNpmOutput(t *testing.T) {
+	t.Run("npm 10 failed install", func(t *testing.T) {
+		logs := []string{
+			"Starting installation of package: slidev-theme-missing",
+			"[10:15:01] STDERR: npm warn deprecated inflight@1.0.6: This module is not supported, and leaks memory.",
+			"[10:15:01] STDERR: npm warn deprecated glob@7.2.3: Glob versions prior to v9 are no longer supported",
+			"[10:15:02] STDERR: npm warn config production Use `--omit=dev` instead.",
+			"[10:15:03] STDERR: npm error code E404",
+			"[10:15:03] STDERR: npm error 404 Not Found - GET https://registry.npmjs.org/slidev-theme-missing - Not found",
+			"[10:15:03] STDERR: npm error 404",
+			"[10:15:03] STDERR: npm error A complete log of this run can be found in: /root/.npm/_logs/debug-0.log",
+			"ERROR: exit status 1",
+		}
+
+		summary := summarizeNpmOutput(logs)
+		assert.Equal(t, 3, summary.Warnings)
+		assert.Equal(t, 2, summary.Deprecations)
+		assert.Equal(t, 1, summary.Errors)
+		assert.Equal(t, []string{"code E404"}, summary.ErrorMessages)
+		assert.Equal(t, "deprecated inflight@1.0.6: This module is not supported, and leaks memory.", summary.WarningMessages[0])
+		assert.Equal(t, "3 warnings (2 deprecations), 1 error", summary.Text)
+	})
+
+	t.Run("npm 6 output with retried attempts", func(t *testing.T) {
+		logs := []string{
+			"Attempt 1/2",
+			"[10:15:01] STDERR: npm WARN deprecated request@2.88.2: request has been deprecated",
+			"[10:15:02] STDERR: npm ERR! code ECONNRESET",
+			"[10:15:02] STDERR: npm ERR! network aborted",
+			"Retryable npm failure (ECONNRESET), retrying in 2s",
+			"Attempt 2/2",
+			"[10:15:05] STDERR: npm WARN deprecated request@2.88.2: request has been deprecated",
+			"[10:15:06] STDOUT: added 12 packages in 3s",
+		}
+
+		summary := summarizeNpmOutput(logs)
+		assert.Equal(t, 1, summary.Warnings, "repeated warnings are counted once")
+		assert.Equal(t, 1, summary.Deprecations)
+		assert.Equal(t, 1, summary.Errors)
+		assert.Equal(t, "1 warning (1 deprecation), 1 error", summary.Text)
+	})
+
+	t.Run("yarn classic output", func(t *testing.T) {
+		logs := []string{
+			"[10:15:01] STDERR: warning slidev-theme-old > vue@2.7.0: Vue 2 has reached EOL and is no longer actively maintained.",
+			"[10:15:01] STDERR: warning package.json: No license field",
+			"[10:15:02] STDERR: error An unexpected error occurred: \"https://registry.yarnpkg.com/slidev-theme-x: Not found\".",
+		}
+
+		summary := summarizeNpmOutput(logs)
+		assert.Equal(t, 2, summary.Warnings)
+		assert.Equal(t, 1, summary.Errors)
+		assert.Equal(t, "2 warnings, 1 error", summary.Text)
+	})
+
+	t.Run("Clean install", func(t *testing.T) {
+		summary := summarizeNpmOutput([]string{"[10:15:06] STDOUT: added 1 package in 2s", "[10:15:06] STDOUT: warnings: none"})
+		assert.Zero(t, summary.Warnings)
+		assert.Zero(t, summary.Errors)
+		assert.Equal(t, "0 warnings, 0 errors", summary.Text)
+	})
+
+	t.Run("Messages are capped", func(t *testing.T) {
+		var logs []string
+		for i := 0; i < 2*maxNpmSummaryMessages; i++ {
+			logs = append(logs, "STDERR: npm warn deprecated pkg-"+string(rune('a'+i))+"@1.0.0: unmaintained")
+		}
+
+		summary := summarizeNpmOutput(logs)
+		assert.Equal(t, 2*maxNpmSummaryMessages, summary.Warnings)
+		assert.Len(t, summary.WarningMessages, maxNpmSummaryMessages)
+	})
+}
+
+func TestInstallNpmPackageOutputSummary(t *testing.T) {
+	binDir := t.TempDir()
+	script := `#!/bin/sh
+echo "npm warn deprecated inflight@1.0.6: This module is not supported" >&2
+echo "npm warn deprecated glob@7.2.3: Glob versions prior to v9 are no longer supported" >&2
+echo "added 1 package"
+`
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "npm"), []byte(script), 0o755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	workspace, err := NewWorkspace(t.TempDir(), uuid.New())
+	require.NoError(t, err)
+	defer workspace.Cleanup()
+
+	result, err := NewNpmPackageManager(t.TempDir()).InstallNpmPackage(context.Background(), workspace, "slidev-theme-penguin")
+	require.NoError(t, err)
+	require.NotNil(t, result.Output)
+	assert.Equal(t, "2 warnings (2 deprecations), 0 errors", result.Output.Text)
+	assert.Len(t, result.Logs, 5, "full output is kept")
+
+	lines := npmInstallLogLines(result)
+	require.Len(t, lines, 3)
+	assert.Equal(t, "Package slidev-theme-penguin installed: 2 warnings (2 deprecations), 0 errors", lines[0])
+	assert.Equal(t, "  npm warn: deprecated inflight@1.0.6: This module is not supported", lines[1])
+}
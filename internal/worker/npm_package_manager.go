@@ -9,6 +9,9 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,6 +22,46 @@ import (
 type NpmPackageManager struct {
 	workspaceBase string
 	npmCommand    string
+	cacheMode     string
+
+	// Relances d'une installation après un échec transitoire du registre (réseau, 5xx)
+	retries      int
+	retryBackoff time.Duration
+
+	// Processus npm simultanés, partagé par tous les jobs de l'instance (nil = sans limite)
+	processLimiter *BuildLimiter
+}
+
+// Modes de cache NPM
+const (
+	// NpmCacheShared partage un cache unique entre tous les jobs (meilleure réutilisation)
+	NpmCacheShared = "shared"
+	// NpmCacheWorkspace isole le cache dans le workspace du job, supprimé avec lui
+	NpmCacheWorkspace = "workspace"
+)
+
+// npmInstallTimeout borne une installation de paquet, relances comprises
+const npmInstallTimeout = 3 * time.Minute
+
+// Relances par défaut des installations de paquets
+const (
+	DefaultNpmInstallRetries      = 2
+	DefaultNpmInstallRetryBackoff = 2 * time.Second
+	maxNpmInstallRetryBackoff     = 30 * time.Second
+)
+
+// SharedNpmCacheDir est le répertoire du cache NPM partagé entre les jobs
+const SharedNpmCacheDir = "/tmp/npm-cache"
+
+// workspaceNpmCacheDir est le répertoire du cache NPM isolé, relatif au workspace
+const workspaceNpmCacheDir = ".npm-cache"
+
+// npmCacheDir retourne le répertoire de cache NPM à utiliser pour un workspace
+func npmCacheDir(cacheMode string, workspace *Workspace) string {
+	if cacheMode == NpmCacheWorkspace && workspace != nil {
+		return filepath.Join(workspace.GetPath(), workspaceNpmCacheDir)
+	}
+	return SharedNpmCacheDir
 }
 
 // NewNpmPackageManager crée un nouveau gestionnaire de thèmes
@@ -28,10 +71,33 @@ func NewNpmPackageManager(workspaceBase string) *NpmPackageManager {
 	return &NpmPackageManager{
 		workspaceBase: workspaceBase,
 		npmCommand:    npmCmd,
+		cacheMode:     NpmCacheShared,
 	}
 }
 
-// InstallNpmPackage installe un paquet NPM
+// SetCacheMode définit le mode de cache NPM (NpmCacheShared ou NpmCacheWorkspace)
+func (tm *NpmPackageManager) SetCacheMode(cacheMode string) {
+	tm.cacheMode = cacheMode
+}
+
+// SetRetryPolicy définit le nombre de relances après un échec transitoire du registre npm
+// et le délai avant la première, doublé à chaque relance (0 relance = une seule tentative)
+func (tm *NpmPackageManager) SetRetryPolicy(retries int, backoff time.Duration) {
+	if retries < 0 {
+		retries = 0
+	}
+	tm.retries = retries
+	tm.retryBackoff = backoff
+}
+
+// SetProcessLimiter définit la limite des processus npm simultanés, partagée avec les
+// autres gestionnaires de l'instance
+func (tm *NpmPackageManager) SetProcessLimiter(limiter *BuildLimiter) {
+	tm.processLimiter = limiter
+}
+
+// InstallNpmPackage installe un paquet NPM. Les échecs transitoires du registre (réseau,
+// erreurs 5xx) sont relancés avec un délai exponentiel, dans la limite de npmInstallTimeout.
 func (tm *NpmPackageManager) InstallNpmPackage(ctx context.Context, workspace *Workspace, npmPackage string) (*models.NpmPackageInstallResult, error) {
 	startTime := time.Now()
 	result := &models.NpmPackageInstallResult{
@@ -39,6 +105,7 @@ func (tm *NpmPackageManager) InstallNpmPackage(ctx context.Context, workspace *W
 		Success: false,
 		Logs:    []string{},
 	}
+	defer func() { result.Output = summarizeNpmOutput(result.Logs) }()
 
 	// Validation des entrées
 	if npmPackage == "" {
@@ -50,33 +117,52 @@ func (tm *NpmPackageManager) InstallNpmPackage(ctx context.Context, workspace *W
 	result.Logs = append(result.Logs, fmt.Sprintf("Starting installation of package: %s", npmPackage))
 
 	// Créer un contexte avec timeout si pas déjà présent
-	installCtx, cancel := context.WithTimeout(ctx, 3*time.Minute)
+	installCtx, cancel := context.WithTimeout(ctx, npmInstallTimeout)
 	defer cancel()
 
-	// Préparer la commande d'installation
-	cmd := tm.prepareInstallCommand(installCtx, workspace, npmPackage)
+	backoff := tm.retryBackoff
+	for attempt := 1; ; attempt++ {
+		result.Attempts = attempt
+		if tm.retries > 0 {
+			result.Logs = append(result.Logs, fmt.Sprintf("Attempt %d/%d", attempt, tm.retries+1))
+		}
 
-	// Configurer la gestion des erreurs et des pipes
-	if err := tm.setupCommandPipes(cmd, result); err != nil {
-		result.Error = fmt.Sprintf("Failed to setup command pipes: %v", err)
-		return result, err
-	}
+		attemptLogs := len(result.Logs)
+		err := tm.installAttempt(installCtx, workspace, npmPackage, result)
+		if err == nil {
+			break
+		}
 
-	// Démarrer la commande
-	if err := cmd.Start(); err != nil {
-		result.Error = fmt.Sprintf("Failed to start installation command: %v", err)
-		return result, err
-	}
+		reason, retryable := retryableNpmFailure(result.Logs[attemptLogs:])
+		if !retryable || attempt > tm.retries || installCtx.Err() != nil {
+			result.Duration = int64(time.Since(startTime))
+			return result, err
+		}
 
-	// Gérer l'installation de manière robuste
-	if err := tm.handleInstallation(installCtx, cmd, result); err != nil {
-		// La commande a échoué, mais on a des logs utiles
-		result.Duration = int64(time.Since(startTime))
-		return result, err
+		// Ne pas commencer une relance que le timeout d'installation interromprait
+		if deadline, ok := installCtx.Deadline(); ok && time.Until(deadline) < backoff {
+			result.Logs = append(result.Logs, fmt.Sprintf("Not retrying %s: install timeout reached", reason))
+			result.Duration = int64(time.Since(startTime))
+			return result, err
+		}
+
+		result.Logs = append(result.Logs, fmt.Sprintf("Retryable npm failure (%s), retrying in %v", reason, backoff))
+		log.Printf("Install of %s failed (%s), retrying in %v", npmPackage, reason, backoff)
+
+		select {
+		case <-time.After(backoff):
+		case <-installCtx.Done():
+			result.Duration = int64(time.Since(startTime))
+			return result, err
+		}
+		if backoff *= 2; backoff > maxNpmInstallRetryBackoff {
+			backoff = maxNpmInstallRetryBackoff
+		}
 	}
 
 	// Finaliser l'installation
 	result.Duration = int64(time.Since(startTime))
+	result.Error = ""
 	result.Installed = true
 	result.Success = result.Installed
 
@@ -91,9 +177,77 @@ func (tm *NpmPackageManager) InstallNpmPackage(ctx context.Context, workspace *W
 	return result, nil
 }
 
+// installAttempt exécute une tentative d'installation d'un paquet
+func (tm *NpmPackageManager) installAttempt(ctx context.Context, workspace *Workspace, npmPackage string, result *models.NpmPackageInstallResult) error {
+	// Attendre un créneau npm : le processus et ses enfants comptent dans la limite de l'instance
+	release, err := tm.processLimiter.Acquire(ctx)
+	if err != nil {
+		result.Error = fmt.Sprintf("Timed out waiting for an npm process slot: %v", err)
+		return err
+	}
+	defer release()
+
+	// Préparer la commande d'installation
+	cmd := tm.prepareInstallCommand(ctx, workspace, npmPackage)
+
+	// Configurer la gestion des erreurs et des pipes
+	if err := tm.setupCommandPipes(cmd, result); err != nil {
+		result.Error = fmt.Sprintf("Failed to setup command pipes: %v", err)
+		return err
+	}
+
+	// Démarrer la commande
+	oomKillsBefore := workspace.memoryLimit.oomKills()
+	if err := cmd.Start(); err != nil {
+		result.Error = fmt.Sprintf("Failed to start installation command: %v", err)
+		return err
+	}
+
+	// Gérer l'installation de manière robuste
+	if err := tm.handleInstallation(ctx, cmd, result); err != nil {
+		// La commande a échoué, mais on a des logs utiles
+		if workspace.memoryLimit.oomKills() > oomKillsBefore {
+			err = workspace.memoryLimit.outOfMemoryError("npm install of " + npmPackage)
+			result.Error = err.Error()
+		}
+		return err
+	}
+
+	return nil
+}
+
+// npmDeterministicCodes sont les codes d'erreur npm qu'une relance ne corrige pas
+var npmDeterministicCodes = regexp.MustCompile(`\b(E404|ETARGET|E401|E403|EINVALIDPACKAGENAME|ERESOLVE|EINTEGRITY)\b`)
+
+// npmRetryableCodes sont les codes d'erreur npm d'un échec réseau ou d'une indisponibilité du registre
+var npmRetryableCodes = regexp.MustCompile(`\b(ECONNRESET|ECONNREFUSED|ETIMEDOUT|ESOCKETTIMEDOUT|EAI_AGAIN|ENOTFOUND|EPIPE|E429|E5\d\d)\b`)
+
+// retryableNpmFailure indique si la sortie d'une installation échouée relève d'un échec
+// transitoire du registre, et lequel. Un paquet ou une version introuvable n'est jamais relancé.
+func retryableNpmFailure(logs []string) (string, bool) {
+	var retryable string
+	for _, line := range logs {
+		if npmDeterministicCodes.MatchString(line) {
+			return "", false
+		}
+		if code := npmRetryableCodes.FindString(line); code != "" && retryable == "" {
+			retryable = code
+		}
+	}
+	return retryable, retryable != ""
+}
+
 func (tm *NpmPackageManager) NpmInstall(ctx context.Context, workspace *Workspace) error {
+	release, err := tm.processLimiter.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("npm install not started: %w", err)
+	}
+	defer release()
+
 	cmd := exec.CommandContext(ctx, "npm", "install")
 	cmd.Dir = workspace.GetPath()
+	cmd.Env = tm.buildInstallEnvironment(workspace)
+	workspace.memoryLimit.apply(cmd)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("npm install failed: %v\nOutput: %s", err, output)
@@ -111,7 +265,15 @@ func (tm *NpmPackageManager) prepareInstallCommand(ctx context.Context, workspac
 	}
 
 	cmd.Dir = workspace.GetPath()
-	cmd.Env = tm.buildInstallEnvironment()
+	cmd.Env = tm.buildInstallEnvironment(workspace)
+	workspace.memoryLimit.apply(cmd)
+
+	// À l'annulation, tuer tout l'arbre de processus : un enfant encore vivant
+	// garderait les pipes ouverts et bloquerait cmd.Wait
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd)
+	}
 
 	return cmd
 }
@@ -154,25 +316,32 @@ func (tm *NpmPackageManager) handleInstallation(ctx context.Context, cmd *exec.C
 	done := make(chan struct{})
 	captureCtx, captureCancel := context.WithCancel(ctx)
 
+	// Les pipes de cette commande : result.Pipes change à chaque tentative
+	pipes := result.Pipes
+
 	// WaitGroup pour attendre que toutes les goroutines se terminent
 	var wg sync.WaitGroup
 
 	// Démarrer la capture des logs
+	var outputWG sync.WaitGroup
 	wg.Add(2)
+	outputWG.Add(2)
 	go func() {
 		defer wg.Done()
-		tm.safeOutputCapture(captureCtx, result.Pipes.Stdout, "STDOUT", logsChan, errChan)
+		defer outputWG.Done()
+		tm.safeOutputCapture(captureCtx, pipes.Stdout, "STDOUT", logsChan, errChan)
 	}()
 	go func() {
 		defer wg.Done()
-		tm.safeOutputCapture(captureCtx, result.Pipes.Stderr, "STDERR", logsChan, errChan)
+		defer outputWG.Done()
+		tm.safeOutputCapture(captureCtx, pipes.Stderr, "STDERR", logsChan, errChan)
 	}()
 
 	// Gérer stdin de manière sécurisée
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		tm.safeInputHandler(captureCtx, result.Pipes.Stdin, errChan)
+		tm.safeInputHandler(captureCtx, pipes.Stdin, errChan)
 	}()
 
 	// Collecter les logs
@@ -204,16 +373,17 @@ func (tm *NpmPackageManager) handleInstallation(ctx context.Context, cmd *exec.C
 	// Attendre la fin de la commande avec gestion du contexte
 	cmdDone := make(chan error, 1)
 	go func() {
+		// Lire toute la sortie avant Wait, qui ferme les pipes : les dernières lignes
+		// (dont le code d'erreur npm) seraient sinon perdues
+		outputWG.Wait()
 		cmdDone <- cmd.Wait()
 	}()
 
 	var cmdErr error
 	select {
 	case <-ctx.Done():
-		// Context annulé - tuer le processus
-		if cmd.Process != nil {
-			_ = cmd.Process.Kill()
-		}
+		// Context annulé - tuer le processus et ses enfants
+		_ = killProcessGroup(cmd)
 		// Attendre que la commande se termine
 		<-cmdDone
 		cmdErr = ctx.Err()
@@ -336,7 +506,7 @@ func (tm *NpmPackageManager) safeInputHandler(ctx context.Context, stdin io.Writ
 }
 
 // buildInstallEnvironment construit l'environnement pour l'installation - VERSION SÉCURISÉE
-func (tm *NpmPackageManager) buildInstallEnvironment() []string {
+func (tm *NpmPackageManager) buildInstallEnvironment(workspace *Workspace) []string {
 	env := os.Environ()
 
 	// Variables pour éviter les prompts interactifs
@@ -351,6 +521,7 @@ func (tm *NpmPackageManager) buildInstallEnvironment() []string {
 		// Limiter les ressources
 		"NPM_CONFIG_MAXSOCKETS=5",
 		"NPM_CONFIG_TIMEOUT=300000", // 5 minutes
+		"NPM_CONFIG_CACHE=" + npmCacheDir(tm.cacheMode, workspace),
 	}
 
 	return append(env, secureEnvVars...)
@@ -384,3 +555,48 @@ func (tm *NpmPackageManager) AutoInstallNpmPackages(ctx context.Context, workspa
 	wg.Wait()
 	return results, nil
 }
+
+// stylePreprocessorPackages associe les extensions de styles aux préprocesseurs requis par Vite
+// (.postcss est géré nativement par Vite et ne nécessite pas de paquet)
+var stylePreprocessorPackages = map[string]string{
+	".scss": "sass",
+	".sass": "sass",
+	".less": "less",
+}
+
+// DetectStylePreprocessors retourne les paquets de préprocesseurs nécessaires aux sources
+// du workspace et qui ne sont pas encore installés dans node_modules
+func (tm *NpmPackageManager) DetectStylePreprocessors(workspace *Workspace) ([]string, error) {
+	needed := make(map[string]bool)
+
+	err := filepath.Walk(workspace.GetPath(), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			// Ne pas inspecter les dépendances ni le build
+			if info.Name() == "node_modules" || info.Name() == "dist" || info.Name() == workspaceNpmCacheDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if pkg, exists := stylePreprocessorPackages[strings.ToLower(filepath.Ext(path))]; exists {
+			needed[pkg] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan workspace for style preprocessors: %w", err)
+	}
+
+	var packages []string
+	for pkg := range needed {
+		if !workspace.DirExists(filepath.Join("node_modules", pkg)) {
+			packages = append(packages, pkg)
+		}
+	}
+
+	return packages, nil
+}
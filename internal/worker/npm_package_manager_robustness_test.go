@@ -5,6 +5,9 @@ package worker
 import (
 	"context"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -191,6 +194,123 @@ func TestNpmPackageManagerErrorHandling(t *testing.T) {
 }
 
 // BenchmarkThemeInstallation benchmark l'installation de thèmes
+func TestRetryableNpmFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		logs      []string
+		reason    string
+		retryable bool
+	}{
+		{"Registry unavailable", []string{"STDERR: npm error code E503", "STDERR: npm error 503 Service Unavailable"}, "E503", true},
+		{"Connection reset", []string{"STDERR: npm ERR! code ECONNRESET"}, "ECONNRESET", true},
+		{"DNS failure", []string{"STDERR: npm error code EAI_AGAIN"}, "EAI_AGAIN", true},
+		{"Rate limited", []string{"STDERR: npm error code E429"}, "E429", true},
+		{"Package not found", []string{"STDERR: npm error code E404", "STDERR: npm error 404 Not Found"}, "", false},
+		{"Version not found", []string{"STDERR: npm error code ETARGET"}, "", false},
+		{"Not found wins over network noise", []string{"STDERR: npm warn ECONNRESET retrying", "STDERR: npm error code E404"}, "", false},
+		{"Unknown failure", []string{"STDERR: npm error code ELIFECYCLE"}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, retryable := retryableNpmFailure(tt.logs)
+			assert.Equal(t, tt.retryable, retryable)
+			assert.Equal(t, tt.reason, reason)
+		})
+	}
+}
+
+// fakeNpm place sur le PATH un npm qui échoue avec le code donné pendant les failures
+// premiers appels, puis réussit. Retourne une fonction comptant les appels.
+func fakeNpm(t *testing.T, failures int, code string) func() int {
+	binDir := t.TempDir()
+	counter := filepath.Join(binDir, "calls")
+
+	script := `#!/bin/sh
+calls=$(cat "$FAKE_NPM_COUNTER" 2>/dev/null || echo 0)
+calls=$((calls + 1))
+echo "$calls" > "$FAKE_NPM_COUNTER"
+if [ "$calls" -le "$FAKE_NPM_FAILURES" ]; then
+  echo "npm error code $FAKE_NPM_CODE" >&2
+  exit 1
+fi
+echo "added 1 package"
+`
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "npm"), []byte(script), 0o755))
+
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("FAKE_NPM_COUNTER", counter)
+	t.Setenv("FAKE_NPM_FAILURES", strconv.Itoa(failures))
+	t.Setenv("FAKE_NPM_CODE", code)
+
+	return func() int {
+		data, err := os.ReadFile(counter)
+		if err != nil {
+			return 0
+		}
+		calls, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+		return calls
+	}
+}
+
+func TestInstallNpmPackageRetry(t *testing.T) {
+	install := func(t *testing.T, retries int) (*models.NpmPackageInstallResult, error) {
+		manager := NewNpmPackageManager(t.TempDir())
+		manager.SetRetryPolicy(retries, 10*time.Millisecond)
+
+		workspace, err := NewWorkspace(t.TempDir(), uuid.New())
+		require.NoError(t, err)
+		defer workspace.Cleanup()
+
+		return manager.InstallNpmPackage(context.Background(), workspace, "slidev-theme-flaky")
+	}
+
+	t.Run("Transient failures are retried", func(t *testing.T) {
+		calls := fakeNpm(t, 2, "E503")
+
+		result, err := install(t, 2)
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Empty(t, result.Error)
+		assert.Equal(t, 3, result.Attempts)
+		assert.Equal(t, 3, calls())
+
+		logs := strings.Join(result.Logs, "\n")
+		assert.Contains(t, logs, "Attempt 3/3")
+		assert.Contains(t, logs, "Retryable npm failure (E503), retrying in 10ms")
+		assert.Contains(t, logs, "Retryable npm failure (E503), retrying in 20ms")
+	})
+
+	t.Run("Retries are capped", func(t *testing.T) {
+		calls := fakeNpm(t, 5, "ECONNRESET")
+
+		result, err := install(t, 1)
+		require.Error(t, err)
+		assert.False(t, result.Success)
+		assert.Equal(t, 2, result.Attempts)
+		assert.Equal(t, 2, calls())
+	})
+
+	t.Run("Package not found is not retried", func(t *testing.T) {
+		calls := fakeNpm(t, 1, "E404")
+
+		result, err := install(t, 2)
+		require.Error(t, err)
+		assert.Equal(t, 1, result.Attempts)
+		assert.Equal(t, 1, calls())
+	})
+
+	t.Run("No retry by default", func(t *testing.T) {
+		calls := fakeNpm(t, 1, "E503")
+
+		result, err := install(t, 0)
+		require.Error(t, err)
+		assert.Equal(t, 1, result.Attempts)
+		assert.Equal(t, 1, calls())
+		assert.NotContains(t, strings.Join(result.Logs, "\n"), "Attempt 1/1")
+	})
+}
+
 func BenchmarkThemeInstallation(b *testing.B) {
 	tempDir, err := os.MkdirTemp("", "theme-bench-*")
 	require.NoError(b, err)
@@ -217,3 +337,51 @@ func BenchmarkThemeInstallation(b *testing.B) {
 		workspace.Cleanup()
 	}
 }
+
+// BenchmarkNpmCacheModes compare le cache partagé et le cache isolé par workspace
+// sur des installations concurrentes (nécessite npm et un accès au registry)
+func BenchmarkNpmCacheModes(b *testing.B) {
+	if _, err := exec.LookPath("npm"); err != nil {
+		b.Skip("npm not available")
+	}
+
+	const concurrentJobs = 3
+
+	for _, cacheMode := range []string{NpmCacheShared, NpmCacheWorkspace} {
+		b.Run(cacheMode, func(b *testing.B) {
+			tempDir, err := os.MkdirTemp("", "npm-cache-bench-*")
+			require.NoError(b, err)
+			defer os.RemoveAll(tempDir)
+
+			manager := NewNpmPackageManager(tempDir)
+			manager.SetCacheMode(cacheMode)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var wg sync.WaitGroup
+				for j := 0; j < concurrentJobs; j++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+
+						workspace, err := NewWorkspace(tempDir, uuid.New())
+						if err != nil {
+							return
+						}
+						defer workspace.Cleanup()
+
+						packageJSON := `{"name": "test", "version": "1.0.0"}`
+						_ = workspace.WriteFile("package.json", strings.NewReader(packageJSON))
+
+						ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+						defer cancel()
+
+						// Installation (peut échouer, on mesure juste les performances)
+						_, _ = manager.InstallNpmPackage(ctx, workspace, "@slidev/theme-default")
+					}()
+				}
+				wg.Wait()
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+// internal/worker/package_detection.go - Détection des paquets npm requis par un deck
+package worker
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
+)
+
+// MissingPackages sont les paquets npm qu'un deck référence sans qu'ils soient installés
+type MissingPackages struct {
+	Themes   []string // Thème déclaré par l'en-tête des slides (theme:)
+	Packages []string // Addons (addons:) et paquets importés par les sources
+}
+
+// maxScannedSourceSize limite la taille d'un fichier source analysé pour ses imports
+const maxScannedSourceSize = 1024 * 1024
+
+// importScannedExtensions sont les sources dont les imports sont analysés ; dans les
+// fichiers Markdown, seuls les blocs <script> comptent (pas les exemples de code)
+var importScannedExtensions = map[string]bool{
+	".vue": true, ".ts": true, ".js": true, ".mts": true, ".mjs": true, ".md": true,
+}
+
+// importSkippedDirs ne contiennent pas de sources du deck : extraits de code affichés
+// (<<< @/snippets/...) et fichiers statiques copiés tels quels
+var importSkippedDirs = map[string]bool{"snippets": true, "public": true}
+
+// importPattern reconnaît les imports statiques (import x from 'pkg', import 'pkg')
+var importPattern = regexp.MustCompile(`(?m)(?:^|[;\s])import\s+(?:[\w$*{},\s]+?\s+from\s+)?['"]([^'"\n]+)['"]`)
+
+// npmPackageNamePattern restreint les paquets détectés aux noms npm valides
+var npmPackageNamePattern = regexp.MustCompile(`^(@[a-z0-9][a-z0-9._~-]*/)?[a-z0-9][a-z0-9._~-]*$`)
+
+// slidevProvidedPackages sont fournis par Slidev : les installer dans le cours créerait
+// des doublons (deux instances de vue)
+var slidevProvidedPackages = map[string]bool{
+	"vue": true, "vue-router": true, "vite": true, "unocss": true, "shiki": true,
+}
+
+// nodeBuiltinModules sont les modules intégrés à Node, importables sans préfixe "node:"
+var nodeBuiltinModules = map[string]bool{
+	"assert": true, "buffer": true, "child_process": true, "crypto": true, "dns": true,
+	"events": true, "fs": true, "http": true, "https": true, "module": true, "net": true,
+	"os": true, "path": true, "perf_hooks": true, "process": true, "querystring": true,
+	"readline": true, "stream": true, "timers": true, "tls": true, "url": true, "util": true,
+	"vm": true, "worker_threads": true, "zlib": true,
+}
+
+// slidevProvidedScopes sont les scopes npm fournis par Slidev
+var slidevProvidedScopes = []string{"@slidev/", "@vueuse/", "@unocss/", "@shikijs/", "@iconify/"}
+
+// DetectMissingPackages retourne les paquets npm référencés par le deck et absents de
+// node_modules : le thème des slides d'une part, les addons de l'en-tête et les paquets
+// importés par les sources (.vue, .ts, .js, blocs <script> du Markdown) d'autre part.
+// Le thème par défaut, fourni avec Slidev, et les thèmes locaux ne sont pas retournés.
+func (tm *NpmPackageManager) DetectMissingPackages(workspace *Workspace, slideFile string) (*MissingPackages, error) {
+	missing := &MissingPackages{}
+
+	content, err := os.ReadFile(filepath.Join(workspace.GetPath(), slideFile))
+	if err != nil {
+		return missing, fmt.Errorf("failed to read %s: %w", slideFile, err)
+	}
+
+	theme := jobs.DetectTheme(bytes.NewReader(content))
+	if theme != jobs.DefaultTheme && !isLocalTheme(theme) {
+		if themePackage := ThemePackageName(theme); !isInstalledPackage(workspace, themePackage) {
+			missing.Themes = append(missing.Themes, themePackage)
+		}
+	}
+
+	needed := make(map[string]bool)
+	for _, addon := range detectAddons(bytes.NewReader(content)) {
+		if !isLocalTheme(addon) {
+			needed[AddonPackageName(addon)] = true
+		}
+	}
+
+	err = filepath.Walk(workspace.GetPath(), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if info.Name() == "node_modules" || info.Name() == "dist" || info.Name() == workspaceNpmCacheDir ||
+				importSkippedDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		ext := strings.ToLower(filepath.Ext(path))
+		if !importScannedExtensions[ext] || info.Size() > maxScannedSourceSize {
+			return nil
+		}
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if ext == ".md" {
+			source = markdownScripts(source)
+		}
+		for _, npmPackage := range importedPackages(source) {
+			needed[npmPackage] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return missing, fmt.Errorf("failed to scan workspace for imports: %w", err)
+	}
+
+	for npmPackage := range needed {
+		if npmPackageNamePattern.MatchString(npmPackage) && !isInstalledPackage(workspace, npmPackage) {
+			missing.Packages = append(missing.Packages, npmPackage)
+		}
+	}
+	sort.Strings(missing.Packages)
+
+	return missing, nil
+}
+
+// AddonPackageName retourne le paquet npm d'un addon selon la convention de Slidev :
+// slidev-addon-<nom>, sauf pour un nom scopé ou déjà préfixé
+func AddonPackageName(addon string) string {
+	if strings.HasPrefix(addon, "@") || strings.HasPrefix(addon, "slidev-addon-") {
+		return addon
+	}
+	return "slidev-addon-" + addon
+}
+
+// isInstalledPackage indique si un paquet est présent dans node_modules
+func isInstalledPackage(workspace *Workspace, npmPackage string) bool {
+	return workspace.DirExists(filepath.Join("node_modules", npmPackageName(npmPackage)))
+}
+
+// detectAddons lit les addons déclarés dans l'en-tête YAML d'un fichier de slides, en
+// liste en ligne (addons: [a, b]) ou en bloc (addons: puis "- a")
+func detectAddons(slides io.Reader) []string {
+	scanner := bufio.NewScanner(slides)
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "---" {
+		return nil
+	}
+
+	var addons []string
+	inList := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "---" {
+			break
+		}
+		if comment := strings.Index(line, " #"); comment >= 0 {
+			line = line[:comment]
+		}
+
+		if inList {
+			item, isItem := strings.CutPrefix(strings.TrimSpace(line), "- ")
+			if isItem {
+				addons = appendAddon(addons, item)
+				continue
+			}
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			inList = false
+		}
+
+		value, found := strings.CutPrefix(line, "addons:")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			inList = true
+			continue
+		}
+		for _, item := range strings.Split(strings.Trim(value, "[]"), ",") {
+			addons = appendAddon(addons, item)
+		}
+	}
+
+	return addons
+}
+
+// appendAddon ajoute un addon YAML (éventuellement entre guillemets) s'il est non vide
+func appendAddon(addons []string, item string) []string {
+	if item = strings.Trim(strings.TrimSpace(item), `"'`); item != "" {
+		addons = append(addons, item)
+	}
+	return addons
+}
+
+// markdownScripts extrait les blocs <script> d'un fichier Markdown, hors blocs de code
+func markdownScripts(source []byte) []byte {
+	var scripts bytes.Buffer
+	inFence, inScript := false, false
+
+	for _, line := range bytes.Split(source, []byte("\n")) {
+		trimmed := bytes.TrimSpace(line)
+		switch {
+		case inScript:
+			if bytes.Contains(line, []byte("</script>")) {
+				inScript = false
+				continue
+			}
+			scripts.Write(line)
+			scripts.WriteByte('\n')
+		case bytes.HasPrefix(trimmed, []byte("```")) || bytes.HasPrefix(trimmed, []byte("~~~")):
+			inFence = !inFence
+		case !inFence && bytes.HasPrefix(trimmed, []byte("<script")):
+			if bytes.Contains(line, []byte("</script>")) {
+				// Bloc sur une ligne
+				scripts.Write(line)
+				scripts.WriteByte('\n')
+				continue
+			}
+			inScript = true
+		}
+	}
+
+	return scripts.Bytes()
+}
+
+// importedPackages retourne les paquets npm importés par une source, hors chemins
+// relatifs, alias, modules virtuels ou intégrés et paquets fournis par Slidev
+func importedPackages(source []byte) []string {
+	var packages []string
+	for _, match := range importPattern.FindAllSubmatch(source, -1) {
+		if npmPackage := importedPackageName(string(match[1])); npmPackage != "" {
+			packages = append(packages, npmPackage)
+		}
+	}
+	return packages
+}
+
+// importedPackageName retourne le paquet d'un import ("@scope/pkg/sub" -> "@scope/pkg"),
+// ou "" si l'import ne désigne pas un paquet à installer
+func importedPackageName(specifier string) string {
+	if strings.HasPrefix(specifier, ".") || strings.HasPrefix(specifier, "/") ||
+		strings.HasPrefix(specifier, "~") || strings.HasPrefix(specifier, "#") ||
+		strings.HasPrefix(specifier, "@/") || strings.Contains(specifier, ":") {
+		return ""
+	}
+
+	parts := strings.SplitN(specifier, "/", 3)
+	name := parts[0]
+	if strings.HasPrefix(name, "@") {
+		if len(parts) < 2 {
+			return ""
+		}
+		name += "/" + parts[1]
+	}
+
+	if slidevProvidedPackages[name] || nodeBuiltinModules[name] {
+		return ""
+	}
+	for _, scope := range slidevProvidedScopes {
+		if strings.HasPrefix(name, scope) {
+			return ""
+		}
+	}
+	return name
+}
@@ -0,0 +1,132 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectMissingPackages(t *testing.T) {
+	manager := NewNpmPackageManager(t.TempDir())
+
+	newWorkspace := func(t *testing.T, files map[string]string) *Workspace {
+		workspace, err := NewWorkspace(t.TempDir(), uuid.New())
+		require.NoError(t, err)
+		for name, content := range files {
+			require.NoError(t, workspace.WriteFile(name, strings.NewReader(content)))
+		}
+		return workspace
+	}
+
+	t.Run("Themes, addons and imports", func(t *testing.T) {
+		slides := "---\ntheme: seriph\naddons:\n  - qrcode\n  - '@org/slidev-addon-quiz' # quiz\n  - ./local-addon\ntitle: Cours\n---\n" +
+			"# Cours\n\n<script setup>\nimport confetti from 'canvas-confetti'\nimport { ref } from 'vue'\n</script>\n\n" +
+			"```ts\nimport lodash from 'lodash'\n```\n"
+		workspace := newWorkspace(t, map[string]string{
+			"slides.md": slides,
+			"components/Chart.vue": "<script setup lang=\"ts\">\nimport { Chart } from 'chart.js/auto'\n" +
+				"import { useNav } from '@slidev/client'\nimport Local from './Local.vue'\nimport '@fontsource/inter/400.css'\n</script>\n",
+			"vite.config.ts":                           "import { resolve } from 'path'\nimport { defineConfig } from 'vite'\nimport Icons from 'unplugin-icons/vite'\n",
+			"setup/main.ts":                            "import 'virtual:uno.css'\nimport { x } from 'node:fs'\nimport type { Y } from '~/types'\n",
+			"snippets/example.ts":                      "import express from 'express'\n",
+			"node_modules/already/index.js":            "import hidden from 'hidden-dependency'\n",
+			"node_modules/unplugin-icons/package.json": "{}",
+		})
+
+		missing, err := manager.DetectMissingPackages(workspace, "slides.md")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"@slidev/theme-seriph"}, missing.Themes)
+		assert.Equal(t, []string{
+			"@fontsource/inter", "@org/slidev-addon-quiz", "canvas-confetti", "chart.js", "slidev-addon-qrcode",
+		}, missing.Packages)
+	})
+
+	t.Run("Inline addons and installed theme", func(t *testing.T) {
+		workspace := newWorkspace(t, map[string]string{
+			"slides.md": "---\ntheme: slidev-theme-custom\naddons: [\"slidev-addon-excalidraw\", tldraw]\n---\n# Cours\n",
+			"node_modules/slidev-theme-custom/package.json": "{}",
+			"node_modules/slidev-addon-tldraw/package.json": "{}",
+		})
+
+		missing, err := manager.DetectMissingPackages(workspace, "slides.md")
+		require.NoError(t, err)
+		assert.Empty(t, missing.Themes)
+		assert.Equal(t, []string{"slidev-addon-excalidraw"}, missing.Packages)
+	})
+
+	t.Run("Default and local themes", func(t *testing.T) {
+		for _, slides := range []string{"# Sans en-tête\n", "---\ntheme: default\n---\n", "---\ntheme: ./theme\n---\n"} {
+			missing, err := manager.DetectMissingPackages(newWorkspace(t, map[string]string{"slides.md": slides}), "slides.md")
+			require.NoError(t, err)
+			assert.Empty(t, missing.Themes, slides)
+			assert.Empty(t, missing.Packages, slides)
+		}
+	})
+
+	t.Run("Missing slide file", func(t *testing.T) {
+		missing, err := manager.DetectMissingPackages(newWorkspace(t, nil), "slides.md")
+		assert.Error(t, err)
+		require.NotNil(t, missing)
+		assert.Empty(t, missing.Packages)
+	})
+}
+
+func TestInstallNpmPackagesRequiredByDeck(t *testing.T) {
+	fakeSlidev(t)
+	// npm de test qui enregistre les paquets installés un par un
+	installed := filepath.Join(t.TempDir(), "installed")
+	npm := "#!/bin/sh\nif [ \"$1\" = \"install\" ] && [ -n \"$2\" ]; then echo \"$2\" >> " + installed + "; fi\necho \"added 1 package\"\n"
+	binDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "npm"), []byte(npm), 0o755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	workspace, err := NewWorkspace(t.TempDir(), uuid.New())
+	require.NoError(t, err)
+	require.NoError(t, workspace.WriteFile("slides.md", strings.NewReader("---\ntheme: seriph\naddons:\n  - qrcode\n---\n# Cours\n")))
+	job := &models.GenerationJob{ID: uuid.New()}
+
+	read := func() []string {
+		content, err := os.ReadFile(installed)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		require.NoError(t, err)
+		return strings.Fields(string(content))
+	}
+
+	// Par défaut, seul le thème des slides est installé
+	runner := NewSlidevRunner(&PoolConfig{WorkspaceBase: t.TempDir()})
+	_, err = runner.InstallNpmPackages(context.Background(), workspace, job, "slides.md", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"@slidev/theme-seriph"}, read())
+
+	// Sur option, les addons et paquets importés aussi
+	require.NoError(t, os.Remove(installed))
+	runner = NewSlidevRunner(&PoolConfig{WorkspaceBase: t.TempDir(), InstallDeckPackages: true})
+	_, err = runner.InstallNpmPackages(context.Background(), workspace, job, "slides.md", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"@slidev/theme-seriph", "slidev-addon-qrcode"}, read())
+
+	// Un thème imposé remplace celui des slides
+	require.NoError(t, os.Remove(installed))
+	_, err = runner.InstallNpmPackages(context.Background(), workspace, job, "slides.md", "apple-basic")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"slidev-addon-qrcode"}, read())
+
+	// Un paquet de thème importé par les sources est soumis à la politique de thèmes
+	require.NoError(t, os.Remove(installed))
+	require.NoError(t, workspace.WriteFile("components/Cover.vue",
+		strings.NewReader("<script setup>\nimport 'slidev-theme-penguin/styles'\n</script>\n")))
+	runner = NewSlidevRunner(&PoolConfig{WorkspaceBase: t.TempDir(), InstallDeckPackages: true, DeniedThemes: []string{"penguin"}})
+	_, err = runner.InstallNpmPackages(context.Background(), workspace, job, "slides.md", "apple-basic")
+	assert.ErrorIs(t, err, ErrThemeNotAllowed)
+	assert.Empty(t, read())
+}
@@ -3,14 +3,18 @@ package worker
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
 	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
 	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
 )
 
 // WorkerPool gère un pool de workers pour traiter les jobs de génération
@@ -19,7 +23,21 @@ type WorkerPool struct {
 	storageService *storage.StorageService
 	config         *PoolConfig
 	workers        []*Worker
+	logStreams     *LogStreams
+	buildLimiter   *BuildLimiter
+	npmLimiter     *BuildLimiter
 	jobQueue       chan *models.GenerationJob
+	workerQueues   []chan *models.GenerationJob // Files par worker (mode affinité uniquement)
+	queued         map[uuid.UUID]queuedJob      // Jobs en file, pas encore réservés par un worker
+	queuedSeq      uint64                       // Ordre de mise en file, protégé par queuedMu
+	queuedMu       sync.Mutex
+	backlog        atomic.Int64 // Jobs pending en base hors de la file en mémoire, au dernier relevé
+	admitting      int          // Jobs admis pas encore enregistrés en base, protégé par admissionMu
+	admissionMu    sync.Mutex
+	claimWatchers  *ClaimWatchers
+	themePreviewer *ThemePreviewer
+	callbackQueue  *jobs.CallbackQueue
+	maintenance    maintenanceMode
 	stopCh         chan struct{}
 	wg             sync.WaitGroup
 	running        bool
@@ -34,8 +52,98 @@ type PoolConfig struct {
 	WorkspaceBase    string        // Répertoire de base pour les workspaces
 	SlidevCommand    string        // Commande Slidev (par défaut "npx @slidev/cli")
 	CleanupWorkspace bool          // Nettoyer les workspaces après traitement
+	NpmCacheMode     string        // Cache NPM: "shared" (réutilisation) ou "workspace" (isolation par job)
+	BuildCacheMode   string        // Cache Vite: "none", "course" (par cours) ou "shared"
+	BuildCacheDir    string        // Répertoire des caches Vite persistants
+	SlideFiles       []string      // Fichiers de slides candidats, par ordre de priorité
+	LogReplayLines   int           // Lignes de log rejouées à la connexion d'un flux en direct
+	LogFormat        string        // Format des logs stockés: "text" (défaut) ou "jsonl"
+	MaxBuilds        int           // Builds Slidev simultanés (0 = un par cœur), indépendant de WorkerCount
+	MaxNpmProcesses  int           // Installations npm simultanées de l'instance, tous jobs confondus (0 = une par cœur)
+	BuildMemoryLimit int64         // Mémoire max des processus npm/Slidev d'un job en octets (0 = sans limite, Linux)
+	BuildCgroupDir   string        // Cgroup v2 parent des cgroups de jobs (défaut DefaultBuildCgroupDir)
+	VersionCheckMode string        // Versions Node/Slidev du package.json: "warn" (défaut), "strict" ou "off"
+	NonZeroExitMode  string        // Code de sortie non nul de slidev build: "strict" (défaut) ou "validate-output"
+	DispatchMode     string        // Répartition: "shared" (file unique) ou "course" (affinité par cours)
+
+	// Sources après un build réussi: "keep" (défaut) ou "delete-on-success", sauf choix du job
+	SourceRetention models.SourceRetention
+
+	// AffinityQueueThreshold est le nombre de jobs en attente chez le worker affin au-delà
+	// duquel un worker inactif prend le job (mode "course" uniquement)
+	AffinityQueueThreshold int
+
+	// StatsIncludeDependencies compte node_modules et .npm-cache dans la taille des workspaces
+	StatsIncludeDependencies bool
+
+	// CleanupProtectedStatuses sont les statuts de job dont le nettoyage des anciens workspaces
+	// conserve le workspace (vide = DefaultCleanupProtectedStatuses)
+	CleanupProtectedStatuses []models.JobStatus
+
+	// StorageNamespacePerClient lit et écrit les fichiers d'un job dans le namespace de son client
+	StorageNamespacePerClient bool
+
+	// OrphanGracePeriod est l'ancienneté à partir de laquelle un job pending trouvé au
+	// démarrage est considéré comme perdu avec la file d'une instance arrêtée
+	// (0 = DefaultOrphanGracePeriod)
+	OrphanGracePeriod time.Duration
+
+	// ResultCompression liste les encodages de précompression des résultats appliqués à
+	// tous les builds (vide = seulement les jobs demandant compress_results, en gzip)
+	ResultCompression []string
+
+	// NpmInstallRetries est le nombre de relances d'une installation de paquet après un échec
+	// transitoire du registre, espacées à partir de NpmInstallRetryBackoff (doublé à chaque relance)
+	NpmInstallRetries      int
+	NpmInstallRetryBackoff time.Duration
+
+	// AllowedThemes limite les thèmes installables par les builds (vide = tous) ;
+	// DeniedThemes interdit des thèmes. Noms Slidev ou paquets npm.
+	AllowedThemes []string
+	DeniedThemes  []string
+
+	// InstallDeckPackages installe les addons et les paquets importés par les sources du deck
+	// absents de node_modules (désactivé par défaut : le deck choisirait le code installé)
+	InstallDeckPackages bool
+
+	// SourceDownloadTimeout borne le téléchargement des sources d'un job, à l'intérieur de
+	// JobTimeout, pour qu'un storage lent ne consomme pas le temps du build (0 = JobTimeout seul)
+	SourceDownloadTimeout time.Duration
+
+	// QueueOverflowMode décide du sort des jobs soumis quand la file en mémoire est pleine :
+	// "persist" (défaut) les garde pending en base, "reject" les refuse
+	QueueOverflowMode string
+
+	// MaxPendingBacklog borne, en mode persist, le nombre de jobs pending en base hors de la
+	// file en mémoire (0 = sans limite)
+	MaxPendingBacklog int
+
+	// SlideCountWarning et OutputSizeWarning (octets) sont les seuils au-delà desquels un
+	// build réussi porte un avertissement de taille du deck (0 = désactivé)
+	SlideCountWarning int
+	OutputSizeWarning int64
+
+	// SrcIncludeCheckMode traite les slides importées (src:) absentes des sources ou
+	// circulaires : "warn" (défaut) les signale dans les logs, "strict" fait échouer le job
+	// avant le build, "off" désactive la vérification
+	SrcIncludeCheckMode string
+
+	// WorkspaceBases répartit les workspaces sur plusieurs répertoires (un par disque par
+	// exemple) : le worker i crée les siens dans WorkspaceBases[i % len]. Vide = WorkspaceBase.
+	WorkspaceBases []string
+}
+
+// workspaceBases retourne les répertoires de base des workspaces des workers
+func (c *PoolConfig) workspaceBases() []string {
+	if len(c.WorkspaceBases) > 0 {
+		return c.WorkspaceBases
+	}
+	return []string{c.WorkspaceBase}
 }
 
+// DefaultOrphanGracePeriod est le délai par défaut avant de considérer un job pending comme orphelin
+const DefaultOrphanGracePeriod = 30 * time.Second
+
 // DefaultPoolConfig retourne une configuration par défaut avec chemin sécurisé
 func DefaultPoolConfig() *PoolConfig {
 	// Détecter l'environnement pour choisir le bon workspace base
@@ -45,12 +153,28 @@ func DefaultPoolConfig() *PoolConfig {
 	}
 
 	return &PoolConfig{
-		WorkerCount:      3,
-		PollInterval:     5 * time.Second,
-		JobTimeout:       30 * time.Minute,
-		WorkspaceBase:    workspaceBase,
-		SlidevCommand:    "npx @slidev/cli",
-		CleanupWorkspace: true,
+		WorkerCount:            3,
+		PollInterval:           5 * time.Second,
+		JobTimeout:             30 * time.Minute,
+		WorkspaceBase:          workspaceBase,
+		SlidevCommand:          "npx @slidev/cli",
+		CleanupWorkspace:       true,
+		NpmCacheMode:           NpmCacheShared,
+		BuildCacheMode:         BuildCacheNone,
+		BuildCacheDir:          DefaultBuildCacheDir,
+		SlideFiles:             DefaultSlideFiles,
+		LogReplayLines:         DefaultLogReplayLines,
+		DispatchMode:           DispatchShared,
+		VersionCheckMode:       VersionCheckWarn,
+		NonZeroExitMode:        NonZeroExitStrict,
+		SourceRetention:        models.SourceRetentionKeep,
+		AffinityQueueThreshold: DefaultAffinityQueueThreshold,
+		OrphanGracePeriod:      DefaultOrphanGracePeriod,
+		NpmInstallRetries:      DefaultNpmInstallRetries,
+		NpmInstallRetryBackoff: DefaultNpmInstallRetryBackoff,
+		QueueOverflowMode:      QueueOverflowPersist,
+		SourceDownloadTimeout:  DefaultSourceDownloadTimeout,
+		SrcIncludeCheckMode:    SrcIncludeCheckWarn,
 	}
 }
 
@@ -69,18 +193,74 @@ func NewWorkerPool(
 		storageService: storageService,
 		config:         config,
 		jobQueue:       make(chan *models.GenerationJob, config.WorkerCount*2),
+		queued:         make(map[uuid.UUID]queuedJob),
 		stopCh:         make(chan struct{}),
+		logStreams:     NewLogStreams(config.LogReplayLines),
+		claimWatchers:  NewClaimWatchers(),
+		buildLimiter:   NewBuildLimiter(config.MaxBuilds),
+		npmLimiter:     NewNpmProcessLimiter(config.MaxNpmProcesses),
 	}
 
-	// Créer les workers
+	// Les aperçus de thèmes partagent les créneaux de build et les processus npm des jobs ; leurs
+	// workspaces temporaires vont dans le premier répertoire de base, couvert par le nettoyage
+	bases := config.workspaceBases()
+	previewRunner := NewSlidevRunner(config)
+	previewRunner.setWorkspaceBase(bases[0])
+	previewRunner.buildLimiter = pool.buildLimiter
+	previewRunner.npmPackageManager.SetProcessLimiter(pool.npmLimiter)
+	pool.themePreviewer = NewThemePreviewer(previewRunner, storageService)
+
+	// Créer les workers, qui partagent le même diffuseur de logs et les mêmes créneaux de build
+	// et se répartissent les répertoires de workspaces à tour de rôle
 	for i := 0; i < config.WorkerCount; i++ {
 		worker := NewWorker(i, jobService, storageService, config)
+		worker.processor.setWorkspaceBase(bases[i%len(bases)])
+		worker.processor.slidevRunner.logStreams = pool.logStreams
+		worker.processor.slidevRunner.buildLimiter = pool.buildLimiter
+		worker.processor.slidevRunner.npmPackageManager.SetProcessLimiter(pool.npmLimiter)
+		worker.onClaimed = pool.unmarkQueued
+		worker.onStarted = pool.claimWatchers.Claimed
+		worker.waitMaintenanceEnd = pool.waitMaintenanceEnd
 		pool.workers = append(pool.workers, worker)
+
+		if config.DispatchMode == DispatchCourseAffinity {
+			pool.workerQueues = append(pool.workerQueues, make(chan *models.GenerationJob, workerQueueCapacity))
+		}
 	}
 
 	return pool
 }
 
+// SetCallbackQueue configure la file de livraison des callbacks de fin de job, démarrée
+// indépendamment du pool (à appeler avant Start)
+func (p *WorkerPool) SetCallbackQueue(queue *jobs.CallbackQueue) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.callbackQueue = queue
+	for _, worker := range p.workers {
+		worker.callbacks = queue
+	}
+}
+
+// SubscribeJobLogs abonne un client aux logs en direct d'un job. Retourne les dernières
+// lignes à rejouer, le canal des lignes suivantes (fermé à la fin du job) et la fonction
+// de désabonnement à appeler à la déconnexion du client.
+func (p *WorkerPool) SubscribeJobLogs(jobID uuid.UUID) ([]string, <-chan string, func()) {
+	return p.logStreams.Subscribe(jobID)
+}
+
+// WatchJobClaim signale la réservation d'un job par un worker de cette instance : le canal
+// est fermé à la réservation. La fonction retournée arrête la surveillance.
+func (p *WorkerPool) WatchJobClaim(jobID uuid.UUID) (<-chan struct{}, func()) {
+	return p.claimWatchers.Watch(jobID)
+}
+
+// PreviewTheme retourne l'aperçu d'un thème Slidev (version vide = dernière version publiée)
+func (p *WorkerPool) PreviewTheme(ctx context.Context, theme, version, format string) (*ThemePreview, error) {
+	return p.themePreviewer.Preview(ctx, theme, version, format)
+}
+
 // Start démarre le pool de workers
 func (p *WorkerPool) Start(ctx context.Context) error {
 	p.mu.Lock()
@@ -90,15 +270,20 @@ func (p *WorkerPool) Start(ctx context.Context) error {
 		return nil
 	}
 
-	log.Printf("Starting worker pool with %d workers", p.config.WorkerCount)
+	log.Printf("Starting worker pool with %d workers (dispatch: %s)", p.config.WorkerCount, p.dispatchMode())
 
-	// Démarrer les workers
+	// Démarrer les workers, chacun sur sa propre file en mode affinité
 	for i, worker := range p.workers {
+		queue := p.jobQueue
+		if p.workerQueues != nil {
+			queue = p.workerQueues[i]
+		}
+
 		p.wg.Add(1)
-		go func(workerID int, w *Worker) {
+		go func(w *Worker, queue <-chan *models.GenerationJob) {
 			defer p.wg.Done()
-			w.Start(ctx, p.jobQueue)
-		}(i, worker)
+			w.Start(ctx, queue)
+		}(worker, queue)
 		log.Printf("Worker %d started", i)
 	}
 
@@ -129,8 +314,11 @@ func (p *WorkerPool) Stop() error {
 	// Signaler l'arrêt
 	close(p.stopCh)
 
-	// Fermer la queue des jobs
+	// Fermer les queues des jobs
 	close(p.jobQueue)
+	for _, queue := range p.workerQueues {
+		close(queue)
+	}
 
 	// Attendre que tous les workers se terminent
 	p.wg.Wait()
@@ -148,6 +336,9 @@ func (p *WorkerPool) runJobPoller(ctx context.Context) {
 
 	log.Printf("Job poller started (interval: %v)", p.config.PollInterval)
 
+	// Les jobs pending d'une file en mémoire perdue au redémarrage sont repris sans attendre le premier poll
+	p.recoverOrphanedJobs(ctx)
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -166,25 +357,45 @@ func (p *WorkerPool) runJobPoller(ctx context.Context) {
 
 // pollPendingJobs récupère les jobs pending et les envoie aux workers
 func (p *WorkerPool) pollPendingJobs(ctx context.Context) error {
-	// Récupérer les jobs pending
-	pendingJobs, err := p.jobService.ListJobs(ctx, string(models.StatusPending), nil)
+	pending, err := p.jobService.CountPendingJobs(ctx)
 	if err != nil {
 		return err
 	}
 
-	if len(pendingJobs) == 0 {
+	// Les jobs restés pending après la répartition forment le backlog
+	defer func() { p.recordBacklog(pending) }()
+
+	if pending == 0 {
 		return nil // Pas de jobs pending
 	}
 
+	// En maintenance, les jobs pending attendent la reprise
+	if p.inMaintenance() {
+		return nil
+	}
+
+	// Les plus anciens d'abord, pas plus que la file ne peut en recevoir
+	pendingJobs, err := p.listDispatchableJobs(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pendingJobs) == 0 {
+		return nil // File pleine
+	}
+
 	log.Printf("Found %d pending jobs", len(pendingJobs))
 
-	// Envoyer les jobs aux workers (non-bloquant)
+	// Envoyer les jobs aux workers (non-bloquant), sauf ceux déjà en file
 	for _, job := range pendingJobs {
-		select {
-		case p.jobQueue <- job:
+		if !p.markQueued(job) {
+			continue
+		}
+
+		if p.dispatchJob(job) {
 			log.Printf("Job %s queued for processing", job.ID)
-		default:
+		} else {
 			// Queue pleine, on reessaiera au prochain poll
+			p.unmarkQueued(job.ID)
 			log.Printf("Job queue full, job %s will be retried", job.ID)
 		}
 	}
@@ -192,45 +403,203 @@ func (p *WorkerPool) pollPendingJobs(ctx context.Context) error {
 	return nil
 }
 
+// recoverOrphanedJobs remet en file, au démarrage, les jobs soumis depuis plus de
+// OrphanGracePeriod et toujours pending : leur file en mémoire a été perdue avec l'instance
+// qui les avait reçus. Les jobs plus récents peuvent encore être dans la file d'une autre instance et
+// sont laissés au polling ; la réservation atomique (ClaimJob) évite tout double traitement.
+func (p *WorkerPool) recoverOrphanedJobs(ctx context.Context) {
+	if p.inMaintenance() {
+		return
+	}
+
+	pendingJobs, err := p.listDispatchableJobs(ctx)
+	if err != nil {
+		log.Printf("Error listing pending jobs for recovery: %v", err)
+		return
+	}
+
+	gracePeriod := p.config.OrphanGracePeriod
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultOrphanGracePeriod
+	}
+	// L'ancienneté part de la soumission : un log ajouté au job ne le rajeunit pas
+	cutoff := time.Now().Add(-gracePeriod)
+	recovered := 0
+
+	for _, job := range pendingJobs {
+		if job.CreatedAt.After(cutoff) || !p.markQueued(job) {
+			continue
+		}
+
+		if !p.dispatchJob(job) {
+			// Queue pleine, le polling le reprendra
+			p.unmarkQueued(job.ID)
+			continue
+		}
+
+		recovered++
+		logEntry := fmt.Sprintf("Orphaned job re-queued at worker startup (pending since %s)", job.CreatedAt.Format(time.RFC3339))
+		if err := p.jobService.AddJobLog(ctx, job.ID, logEntry); err != nil {
+			log.Printf("Job %s: failed to add recovery log: %v", job.ID, err)
+		}
+	}
+
+	if recovered > 0 {
+		log.Printf("Recovered %d orphaned pending jobs", recovered)
+	}
+}
+
+// listDispatchableJobs retourne les plus anciens jobs pending, autant que la file peut en
+// recevoir. Les jobs déjà en file de l'instance sont encore pending en base : ils sont
+// ajoutés à la limite pour ne pas masquer les suivants.
+func (p *WorkerPool) listDispatchableJobs(ctx context.Context) ([]*models.GenerationJob, error) {
+	size, capacity := p.queueUsage()
+	if size >= capacity {
+		return nil, nil
+	}
+
+	p.queuedMu.Lock()
+	queued := len(p.queued)
+	p.queuedMu.Unlock()
+
+	return p.jobService.ListPendingJobs(ctx, capacity-size+queued)
+}
+
+// markQueued enregistre un job comme en file, retourne false s'il y est déjà
+func (p *WorkerPool) markQueued(job *models.GenerationJob) bool {
+	p.queuedMu.Lock()
+	defer p.queuedMu.Unlock()
+
+	if _, exists := p.queued[job.ID]; exists {
+		return false
+	}
+	p.queuedSeq++
+	p.queued[job.ID] = queuedJob{courseID: job.CourseID, queuedAt: time.Now(), seq: p.queuedSeq}
+	return true
+}
+
+// unmarkQueued retire un job des jobs en file (réservé par un worker ou non envoyé)
+func (p *WorkerPool) unmarkQueued(jobID uuid.UUID) {
+	p.queuedMu.Lock()
+	defer p.queuedMu.Unlock()
+
+	delete(p.queued, jobID)
+}
+
 // GetStats retourne les statistiques du pool
 func (p *WorkerPool) GetStats() PoolStats {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
 	stats := PoolStats{
-		WorkerCount:   len(p.workers),
-		QueueSize:     len(p.jobQueue),
-		QueueCapacity: cap(p.jobQueue),
-		Running:       p.running,
+		WorkerCount:         len(p.workers),
+		Running:             p.running,
+		DispatchMode:        p.dispatchMode(),
+		ActiveBuilds:        p.buildLimiter.Active(),
+		WaitingBuilds:       p.buildLimiter.Waiting(),
+		MaxBuilds:           p.buildLimiter.Limit(),
+		ActiveNpmProcesses:  p.npmLimiter.Active(),
+		WaitingNpmProcesses: p.npmLimiter.Waiting(),
+		MaxNpmProcesses:     p.npmLimiter.Limit(),
+		QueueOverflowMode:   p.overflowMode(),
+		BacklogSize:         p.backlogSize(),
+		BacklogCapacity:     p.config.MaxPendingBacklog,
+	}
+	stats.QueueSize, stats.QueueCapacity = p.queueUsage()
+	if p.callbackQueue != nil {
+		stats.CallbackQueueDepth = p.callbackQueue.Depth()
 	}
 
 	// Ajouter les stats des workers individuels
 	for i, worker := range p.workers {
 		workerStats := worker.GetStats()
-		stats.Workers = append(stats.Workers, WorkerStats{
-			ID:           i,
-			Status:       workerStats.Status,
-			CurrentJobID: workerStats.CurrentJobID,
-			JobsTotal:    workerStats.JobsTotal,
-			JobsSuccess:  workerStats.JobsSuccess,
-			JobsFailed:   workerStats.JobsFailed,
-		})
+		entry := WorkerStats{
+			ID:            i,
+			Status:        workerStats.Status,
+			CurrentJobID:  workerStats.CurrentJobID,
+			JobsTotal:     workerStats.JobsTotal,
+			JobsSuccess:   workerStats.JobsSuccess,
+			JobsFailed:    workerStats.JobsFailed,
+			WorkspaceBase: worker.processor.workspaceBase,
+		}
+		if p.workerQueues != nil {
+			entry.QueueSize = len(p.workerQueues[i])
+		}
+		stats.Workers = append(stats.Workers, entry)
 	}
 
 	return stats
 }
 
+// GetWorkspaceInfo retourne les informations du workspace d'un job, quel que soit son répertoire de base
+func (p *WorkerPool) GetWorkspaceInfo(jobID uuid.UUID) (WorkspaceInfo, error) {
+	manager := &WorkspaceManager{basePaths: p.config.workspaceBases()}
+	manager.SetStatsOptions(WorkspaceStatsOptions{
+		IncludeDependencies: p.config.StatsIncludeDependencies,
+	})
+
+	return manager.GetWorkspaceInfo(jobID)
+}
+
+// PurgeWorkspaceNodeModules supprime node_modules du workspace d'un job et retourne l'espace libéré
+func (p *WorkerPool) PurgeWorkspaceNodeModules(jobID uuid.UUID) (int64, error) {
+	manager := &WorkspaceManager{basePaths: p.config.workspaceBases()}
+	return manager.RemoveWorkspaceDirectory(jobID, "node_modules")
+}
+
+// CleanupOldWorkspaces supprime les workspaces non modifiés depuis maxAge dans tous les
+// répertoires de base, sauf ceux des jobs dont le statut est protégé (en file ou en cours par défaut)
+func (p *WorkerPool) CleanupOldWorkspaces(ctx context.Context, maxAge time.Duration) (int, error) {
+	manager := &WorkspaceManager{basePaths: p.config.workspaceBases()}
+	manager.SetCleanupProtection(p.jobService, p.config.CleanupProtectedStatuses)
+
+	return manager.CleanupOldWorkspaces(ctx, time.Now().Add(-maxAge).Unix())
+}
+
+// ListWorkspaces liste les workspaces de tous les répertoires de base
+func (p *WorkerPool) ListWorkspaces() ([]WorkspaceInfo, error) {
+	manager := &WorkspaceManager{basePaths: p.config.workspaceBases()}
+	manager.SetStatsOptions(WorkspaceStatsOptions{
+		IncludeDependencies: p.config.StatsIncludeDependencies,
+	})
+
+	return manager.ListWorkspaces()
+}
+
 func (p *WorkerPool) GetConfig() *PoolConfig {
 	return p.config
 }
 
+// dispatchMode retourne le mode de répartition effectif du pool
+func (p *WorkerPool) dispatchMode() string {
+	if p.workerQueues != nil {
+		return DispatchCourseAffinity
+	}
+	return DispatchShared
+}
+
 // PoolStats contient les statistiques du pool
 type PoolStats struct {
-	WorkerCount   int           `json:"worker_count"`
-	QueueSize     int           `json:"queue_size"`
-	QueueCapacity int           `json:"queue_capacity"`
-	Running       bool          `json:"running"`
-	Workers       []WorkerStats `json:"workers"`
+	WorkerCount         int           `json:"worker_count"`
+	QueueSize           int           `json:"queue_size"`
+	QueueCapacity       int           `json:"queue_capacity"`
+	Running             bool          `json:"running"`
+	Workers             []WorkerStats `json:"workers"`
+	DispatchMode        string        `json:"dispatch_mode"`
+	ActiveBuilds        int           `json:"active_builds"`
+	WaitingBuilds       int           `json:"waiting_builds"`
+	MaxBuilds           int           `json:"max_builds"`
+	ActiveNpmProcesses  int           `json:"active_npm_processes"`
+	WaitingNpmProcesses int           `json:"waiting_npm_processes"`
+	MaxNpmProcesses     int           `json:"max_npm_processes"`
+
+	QueueOverflowMode string `json:"queue_overflow_mode"`
+	BacklogSize       int    `json:"backlog_size"`     // Jobs pending en base hors de la file en mémoire
+	BacklogCapacity   int    `json:"backlog_capacity"` // 0 = sans limite
+
+	// CallbackQueueDepth est le nombre de callbacks en attente de livraison ou de relance,
+	// toutes instances confondues, au dernier relevé de la file
+	CallbackQueueDepth int `json:"callback_queue_depth"`
 }
 
 // WorkerStats contient les statistiques d'un worker
@@ -241,4 +610,8 @@ type WorkerStats struct {
 	JobsTotal    int64  `json:"jobs_total"`
 	JobsSuccess  int64  `json:"jobs_success"`
 	JobsFailed   int64  `json:"jobs_failed"`
+	QueueSize    int    `json:"queue_size,omitempty"` // Jobs en attente (mode affinité)
+
+	// WorkspaceBase est le répertoire où le worker crée ses workspaces
+	WorkspaceBase string `json:"workspace_base"`
 }
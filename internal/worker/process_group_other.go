@@ -0,0 +1,19 @@
+//go:build !unix
+
+// internal/worker/process_group_other.go - Pas de groupes de processus hors Unix
+package worker
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup est sans effet hors Unix
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup tue seulement la commande hors Unix
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
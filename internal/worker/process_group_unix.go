@@ -0,0 +1,26 @@
+//go:build unix
+
+// internal/worker/process_group_unix.go - Arrêt des arbres de processus npm
+package worker
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup place la commande dans son propre groupe de processus
+// pour pouvoir arrêter aussi les processus qu'elle lance (scripts postinstall, node)
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// killProcessGroup tue la commande et tous les processus de son groupe
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,96 @@
+// internal/worker/profile.go - Application du profil de génération du cours à un job
+package worker
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+)
+
+// resolveGenerationProfile retourne le job avec ses options effectives : les options du
+// profil de génération du cours complètent celles que la requête ne précise pas. Le job
+// d'origine n'est pas modifié ; applied liste les options reprises du profil.
+func (p *JobProcessor) resolveGenerationProfile(ctx context.Context, job *models.GenerationJob) (*models.GenerationJob, []string) {
+	profile, err := p.jobService.GetGenerationProfile(ctx, job.CourseID)
+	if err != nil {
+		if !jobs.IsProfileNotFound(err) {
+			log.Printf("Job %s: failed to load generation profile, building with request options only: %v", job.ID, err)
+		}
+		return job, nil
+	}
+	return applyGenerationProfile(job, profile)
+}
+
+// applyGenerationProfile complète les options d'un job avec celles d'un profil : la requête
+// l'emporte champ par champ, les options de build sont fusionnées par nom (une option de la
+// requête remplace l'option de même nom du profil) et une option booléenne absente de la
+// requête prend la valeur du profil ; un false explicite de la requête l'emporte. Les options
+// booléennes du job retourné sont toujours renseignées.
+func applyGenerationProfile(job *models.GenerationJob, profile *models.GenerationProfile) (*models.GenerationJob, []string) {
+	resolved := *job
+	var applied []string
+
+	if resolved.EntryFile == "" && profile.EntryFile != "" {
+		resolved.EntryFile = profile.EntryFile
+		applied = append(applied, "entry_file")
+	}
+	if resolved.OutputDir == "" && profile.OutputDir != "" {
+		resolved.OutputDir = profile.OutputDir
+		applied = append(applied, "output_dir")
+	}
+	if flags := mergeBuildFlags(profile.BuildFlags, resolved.BuildFlags); len(flags) > len(resolved.BuildFlags) {
+		resolved.BuildFlags = flags
+		applied = append(applied, "build_flags")
+	}
+	if len(resolved.Themes) == 0 && len(profile.Themes) > 0 {
+		resolved.Themes = append(models.StringSlice{}, profile.Themes...)
+		applied = append(applied, "themes")
+	}
+	if inheritBool(&resolved.CheckLinks, profile.CheckLinks) {
+		applied = append(applied, "check_links")
+	}
+	if inheritBool(&resolved.CompressResults, profile.CompressResults) {
+		applied = append(applied, "compress_results")
+	}
+	if inheritBool(&resolved.Thumbnail, profile.Thumbnail) {
+		applied = append(applied, "thumbnail")
+	}
+	if resolved.SourceRetention == "" && profile.SourceRetention != "" {
+		resolved.SourceRetention = profile.SourceRetention
+		applied = append(applied, "source_retention")
+	}
+
+	return &resolved, applied
+}
+
+// inheritBool donne à une option booléenne absente de la requête la valeur du profil et
+// indique si le profil l'a activée
+func inheritBool(option **bool, profileValue bool) bool {
+	if *option != nil {
+		return false
+	}
+	*option = &profileValue
+	return profileValue
+}
+
+// mergeBuildFlags ajoute aux options de la requête celles du profil dont le nom (partie avant
+// "=") n'est pas déjà donné par la requête
+func mergeBuildFlags(profileFlags, requestFlags []string) models.StringSlice {
+	requested := make(map[string]bool, len(requestFlags))
+	for _, flag := range requestFlags {
+		name, _, _ := strings.Cut(flag, "=")
+		requested[name] = true
+	}
+
+	var merged models.StringSlice
+	for _, flag := range profileFlags {
+		name, _, _ := strings.Cut(flag, "=")
+		if !requested[name] {
+			merged = append(merged, flag)
+		}
+	}
+	return append(merged, requestFlags...)
+}
@@ -0,0 +1,103 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyGenerationProfile(t *testing.T) {
+	enabled, disabled := true, false
+	profile := &models.GenerationProfile{
+		EntryFile:       "cours/slides.md",
+		OutputDir:       "build",
+		BuildFlags:      models.StringSlice{"--base=/cours/intro/", "--download"},
+		Themes:          models.StringSlice{"seriph"},
+		CompressResults: true,
+		SourceRetention: models.SourceRetentionDeleteOnSuccess,
+	}
+
+	t.Run("Profile fills missing options", func(t *testing.T) {
+		job := &models.GenerationJob{ID: uuid.New()}
+
+		resolved, applied := applyGenerationProfile(job, profile)
+		assert.Equal(t, "cours/slides.md", resolved.EntryFile)
+		assert.Equal(t, "build", resolved.OutputDir)
+		assert.Equal(t, models.StringSlice{"--base=/cours/intro/", "--download"}, resolved.BuildFlags)
+		assert.Equal(t, models.StringSlice{"seriph"}, resolved.Themes)
+		assert.Equal(t, &enabled, resolved.CompressResults)
+		// Les options booléennes absentes du profil sont résolues à false
+		assert.Equal(t, &disabled, resolved.CheckLinks)
+		assert.Equal(t, &disabled, resolved.Thumbnail)
+		assert.Equal(t, models.SourceRetentionDeleteOnSuccess, resolved.SourceRetention)
+		assert.Equal(t, []string{"entry_file", "output_dir", "build_flags", "themes", "compress_results", "source_retention"}, applied)
+
+		// Le job d'origine n'est pas modifié
+		assert.Empty(t, job.EntryFile)
+		assert.Empty(t, job.BuildFlags)
+		assert.Nil(t, job.CompressResults)
+	})
+
+	t.Run("Request overrides profile", func(t *testing.T) {
+		job := &models.GenerationJob{
+			ID:              uuid.New(),
+			EntryFile:       "autre.md",
+			OutputDir:       "dist",
+			BuildFlags:      models.StringSlice{"--base=/autre/", "--without-notes"},
+			Themes:          models.StringSlice{"apple-basic", "default"},
+			SourceRetention: models.SourceRetentionKeep,
+		}
+
+		resolved, applied := applyGenerationProfile(job, profile)
+		assert.Equal(t, "autre.md", resolved.EntryFile)
+		assert.Equal(t, "dist", resolved.OutputDir)
+		// --base de la requête remplace celui du profil, --download est repris
+		assert.Equal(t, models.StringSlice{"--download", "--base=/autre/", "--without-notes"}, resolved.BuildFlags)
+		assert.Equal(t, models.StringSlice{"apple-basic", "default"}, resolved.Themes)
+		assert.Equal(t, models.SourceRetentionKeep, resolved.SourceRetention)
+		assert.Equal(t, []string{"build_flags", "compress_results"}, applied)
+	})
+
+	t.Run("Explicit false overrides profile", func(t *testing.T) {
+		job := &models.GenerationJob{ID: uuid.New(), CompressResults: &disabled}
+
+		resolved, applied := applyGenerationProfile(job, profile)
+		assert.Equal(t, &disabled, resolved.CompressResults)
+		assert.NotContains(t, applied, "compress_results")
+		assert.False(t, resolved.ToResponse().CompressResults)
+	})
+
+	t.Run("Empty profile changes nothing", func(t *testing.T) {
+		job := &models.GenerationJob{ID: uuid.New(), BuildFlags: models.StringSlice{"--download"}}
+
+		resolved, applied := applyGenerationProfile(job, &models.GenerationProfile{})
+		assert.Empty(t, applied)
+		assert.Equal(t, job.BuildFlags, resolved.BuildFlags)
+	})
+}
+
+func TestResolveGenerationProfile(t *testing.T) {
+	ctx := context.Background()
+	jobService := &MockJobService{}
+	processor := NewJobProcessor(jobService, nil, &PoolConfig{})
+	courseID := uuid.New()
+	job := &models.GenerationJob{ID: uuid.New(), CourseID: courseID}
+
+	// Sans profil, le job est traité tel quel
+	resolved, applied := processor.resolveGenerationProfile(ctx, job)
+	assert.Same(t, job, resolved)
+	assert.Empty(t, applied)
+
+	_, err := jobService.SetGenerationProfile(ctx, courseID, &models.GenerationProfileRequest{Thumbnail: true})
+	require.NoError(t, err)
+
+	resolved, applied = processor.resolveGenerationProfile(ctx, job)
+	assert.True(t, models.BoolValue(resolved.Thumbnail))
+	assert.Equal(t, []string{"thumbnail"}, applied)
+	assert.Nil(t, job.Thumbnail)
+}
@@ -0,0 +1,58 @@
+// internal/worker/queue_snapshot.go - Contenu de la file en mémoire, pour le debug des backlogs
+package worker
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// queuedJob décrit un job en file, de sa mise en file à sa réservation par un worker
+type queuedJob struct {
+	courseID uuid.UUID
+	queuedAt time.Time
+	seq      uint64
+}
+
+// QueueSnapshot retourne les jobs en file de l'instance dans l'ordre de mise en file, sans
+// les retirer de la file, à partir de offset (limit <= 0 = tous). Retourne aussi le nombre
+// total de jobs en file et le backlog : les jobs pending en base qui n'y sont pas encore.
+func (p *WorkerPool) QueueSnapshot(offset, limit int) models.QueueSnapshot {
+	p.queuedMu.Lock()
+	entries := make([]models.QueuedJob, 0, len(p.queued))
+	seqs := make(map[uuid.UUID]uint64, len(p.queued))
+	for jobID, queued := range p.queued {
+		entries = append(entries, models.QueuedJob{
+			JobID:    jobID,
+			CourseID: queued.courseID,
+			QueuedAt: queued.queuedAt,
+		})
+		seqs[jobID] = queued.seq
+	}
+	p.queuedMu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return seqs[entries[i].JobID] < seqs[entries[j].JobID]
+	})
+	for i := range entries {
+		entries[i].Position = i + 1
+	}
+
+	snapshot := models.QueueSnapshot{
+		TotalCount: len(entries),
+		Offset:     offset,
+		Limit:      limit,
+		Backlog:    p.backlogSize(),
+	}
+	start := min(max(offset, 0), len(entries))
+	end := len(entries)
+	if limit > 0 {
+		end = min(start+limit, len(entries))
+	}
+	snapshot.Jobs = entries[start:end]
+	snapshot.Count = len(snapshot.Jobs)
+	return snapshot
+}
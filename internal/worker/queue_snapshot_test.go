@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueSnapshot(t *testing.T) {
+	pool := NewWorkerPool(&MockJobService{}, nil, &PoolConfig{WorkerCount: 2, WorkspaceBase: t.TempDir()})
+
+	var queued []*models.GenerationJob
+	for i := 0; i < 3; i++ {
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New()}
+		require.True(t, pool.markQueued(job))
+		require.True(t, pool.dispatchJob(job))
+		queued = append(queued, job)
+	}
+
+	t.Run("Jobs in enqueue order", func(t *testing.T) {
+		snapshot := pool.QueueSnapshot(0, 0)
+		assert.Equal(t, 3, snapshot.TotalCount)
+		require.Len(t, snapshot.Jobs, 3)
+		for i, job := range queued {
+			assert.Equal(t, i+1, snapshot.Jobs[i].Position)
+			assert.Equal(t, job.ID, snapshot.Jobs[i].JobID)
+			assert.Equal(t, job.CourseID, snapshot.Jobs[i].CourseID)
+			assert.False(t, snapshot.Jobs[i].QueuedAt.IsZero())
+		}
+
+		// La lecture ne consomme pas la file
+		assert.Len(t, pool.jobQueue, 3)
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		snapshot := pool.QueueSnapshot(1, 1)
+		assert.Equal(t, 3, snapshot.TotalCount)
+		assert.Equal(t, 1, snapshot.Count)
+		require.Len(t, snapshot.Jobs, 1)
+		assert.Equal(t, 2, snapshot.Jobs[0].Position)
+		assert.Equal(t, queued[1].ID, snapshot.Jobs[0].JobID)
+
+		assert.Empty(t, pool.QueueSnapshot(10, 5).Jobs)
+	})
+
+	t.Run("Claimed jobs leave the snapshot", func(t *testing.T) {
+		pool.unmarkQueued((<-pool.jobQueue).ID)
+
+		snapshot := pool.QueueSnapshot(0, 0)
+		require.Len(t, snapshot.Jobs, 2)
+		assert.Equal(t, queued[1].ID, snapshot.Jobs[0].JobID)
+		assert.Equal(t, 1, snapshot.Jobs[0].Position)
+	})
+}
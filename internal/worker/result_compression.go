@@ -0,0 +1,149 @@
+// internal/worker/result_compression.go - Précompression des résultats après le build
+package worker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+)
+
+// resultEncodings retourne les encodages de précompression à appliquer aux résultats d'un
+// job : ceux configurés pour tous les builds, sinon DefaultResultEncoding si le job le demande
+func (p *JobProcessor) resultEncodings(job *models.GenerationJob) []*storage.ResultEncoding {
+	names := p.config.ResultCompression
+	if len(names) == 0 && models.BoolValue(job.CompressResults) {
+		names = []string{storage.DefaultResultEncoding}
+	}
+
+	var encodings []*storage.ResultEncoding
+	for _, name := range names {
+		encoding, ok := storage.ResultEncodingByName(name)
+		if !ok {
+			log.Printf("Job %s: unsupported result compression %q ignored", job.ID, name)
+			continue
+		}
+		encodings = append(encodings, encoding)
+	}
+	return encodings
+}
+
+// uploadCompressedVariants upload les variantes compressées d'un résultat texte et retourne
+// les encodages produits. Une variante qui ne réduit pas la taille n'est pas conservée.
+func (p *JobProcessor) uploadCompressedVariants(ctx context.Context, job *models.GenerationJob, workspace *Workspace,
+	fullPath, relativePath string, size int64, encodings []*storage.ResultEncoding) ([]string, error) {
+	if !compressesResult(relativePath, size, encodings) {
+		return nil, nil
+	}
+
+	var produced []string
+	for _, encoding := range encodings {
+		var compressed bytes.Buffer
+		if err := compressResult(workspace, fullPath, relativePath, encoding, &compressed); err != nil {
+			return nil, err
+		}
+		if int64(compressed.Len()) >= size {
+			continue
+		}
+
+		variant := relativePath + encoding.Suffix
+		if err := p.storageService.UploadResultSized(ctx, job.CourseID, variant, &compressed, int64(compressed.Len())); err != nil {
+			return nil, fmt.Errorf("failed to upload result file %s: %w", variant, err)
+		}
+		produced = append(produced, encoding.Name)
+	}
+
+	return produced, nil
+}
+
+// compressedVariantSizes retourne la taille des variantes compressées qu'uploadCompressedVariants
+// conservera pour un résultat, par chemin de variante, sans les stocker
+func compressedVariantSizes(workspace *Workspace, fullPath, relativePath string, size int64,
+	encodings []*storage.ResultEncoding) (map[string]int64, error) {
+	if !compressesResult(relativePath, size, encodings) {
+		return nil, nil
+	}
+
+	sizes := make(map[string]int64, len(encodings))
+	for _, encoding := range encodings {
+		counter := &countingWriter{}
+		if err := compressResult(workspace, fullPath, relativePath, encoding, counter); err != nil {
+			return nil, err
+		}
+		if counter.n < size {
+			sizes[relativePath+encoding.Suffix] = counter.n
+		}
+	}
+	return sizes, nil
+}
+
+// compressesResult indique si un résultat reçoit des variantes compressées
+func compressesResult(relativePath string, size int64, encodings []*storage.ResultEncoding) bool {
+	return len(encodings) > 0 && size >= storage.MinCompressedResultSize && storage.IsCompressibleResult(relativePath)
+}
+
+// compressResult écrit dans w un résultat compressé avec un encodage
+func compressResult(workspace *Workspace, fullPath, relativePath string, encoding *storage.ResultEncoding, w io.Writer) error {
+	reader, err := workspace.ReadFile(fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to read result file %s: %w", relativePath, err)
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	if err := encoding.Compress(w, reader); err != nil {
+		return fmt.Errorf("failed to compress result file %s with %s: %w", relativePath, encoding.Name, err)
+	}
+	return nil
+}
+
+// variantPaths retourne les chemins des variantes compressées d'une entrée du manifeste
+func variantPaths(entry models.ManifestEntry) []string {
+	var paths []string
+	for _, name := range entry.Encodings {
+		if encoding, ok := storage.ResultEncodingByName(name); ok {
+			paths = append(paths, entry.Path+encoding.Suffix)
+		}
+	}
+	return paths
+}
+
+// removeStaleVariants supprime les variantes compressées d'un build précédent dont
+// l'original vient d'être reconstruit sans elles : elles seraient servies à sa place.
+func (p *JobProcessor) removeStaleVariants(ctx context.Context, job *models.GenerationJob, manifest *models.ResultManifest) error {
+	rebuilt := make(map[string]bool, len(manifest.Files))
+	current := make(map[string]bool)
+	for _, entry := range manifest.Files {
+		rebuilt[entry.Path] = true
+		for _, variant := range variantPaths(entry) {
+			current[variant] = true
+		}
+	}
+
+	results, err := p.storageService.ListResults(ctx, job.CourseID)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		result = filepath.ToSlash(result)
+		if current[result] || rebuilt[result] {
+			continue
+		}
+		original, ok := storage.ResultVariantOriginal(result)
+		if !ok || !rebuilt[original] {
+			continue
+		}
+		if err := p.storageService.DeleteResult(ctx, job.CourseID, result); err != nil {
+			return fmt.Errorf("failed to delete stale result %s: %w", result, err)
+		}
+	}
+
+	return nil
+}
@@ -4,50 +4,100 @@ package worker
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/url"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/Open-Course-Factory/ocf-worker/internal/validation"
 	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
 )
 
+// DefaultSlideFiles sont les fichiers de slides recherchés quand le job n'en impose pas
+var DefaultSlideFiles = []string{"slides.md", "index.md", "README.md"}
+
 // SlidevRunner exécute les commandes Slidev
 type SlidevRunner struct {
 	config            *PoolConfig
 	npmPackageManager *NpmPackageManager
+	buildCache        *BuildCache
+	logStreams        *LogStreams   // Diffusion en direct des logs (nil = désactivée)
+	buildLimiter      *BuildLimiter // Builds simultanés, partagé par le pool (nil = sans limite)
+	themePolicy       *ThemePolicy  // Thèmes autorisés (nil = tous)
+
+	// workspaceBase est le répertoire des workspaces créés par le runner (défaut config.WorkspaceBase)
+	workspaceBase string
 }
 
+// Traitement d'un code de sortie non nul de slidev build
+const (
+	// NonZeroExitStrict fait échouer tout build terminé par un code de sortie non nul
+	NonZeroExitStrict = "strict"
+	// NonZeroExitValidateOutput valide tout de même la sortie : un build valide réussit
+	// avec avertissement
+	NonZeroExitValidateOutput = "validate-output"
+)
+
 // SlidevResult contient le résultat de l'exécution Slidev
 type SlidevResult struct {
-	Success    bool
-	ExitCode   int
-	Logs       []string
-	Duration   time.Duration
-	OutputPath string
+	Success     bool
+	ExitCode    int // Code de sortie de slidev build, non nul si WithWarnings
+	Logs        []string
+	Duration    time.Duration
+	OutputPath  string
+	EntryPoints []string // Pages HTML de premier niveau du build, index.html en premier
+
+	// WithWarnings indique un build réussi malgré un code de sortie non nul
+	// (mode NonZeroExitValidateOutput)
+	WithWarnings bool
 }
 
 // NewSlidevRunner crée un nouveau runner Slidev
 func NewSlidevRunner(config *PoolConfig) *SlidevRunner {
+	npmPackageManager := NewNpmPackageManager(config.WorkspaceBase)
+	if config.NpmCacheMode != "" {
+		npmPackageManager.SetCacheMode(config.NpmCacheMode)
+	}
+	npmPackageManager.SetRetryPolicy(config.NpmInstallRetries, config.NpmInstallRetryBackoff)
+
 	return &SlidevRunner{
 		config:            config,
-		npmPackageManager: NewNpmPackageManager(config.WorkspaceBase),
+		npmPackageManager: npmPackageManager,
+		buildCache:        NewBuildCache(config.BuildCacheMode, config.BuildCacheDir),
+		themePolicy:       NewThemePolicy(config.AllowedThemes, config.DeniedThemes),
+		workspaceBase:     config.WorkspaceBase,
 	}
 }
 
-func (sr *SlidevRunner) InstallNpmPackages(ctx context.Context, workspace *Workspace, job *models.GenerationJob) error {
+// setWorkspaceBase assigne au runner le répertoire de base de ses workspaces
+func (sr *SlidevRunner) setWorkspaceBase(base string) {
+	sr.workspaceBase = base
+	sr.npmPackageManager.workspaceBase = base
+}
+
+// InstallNpmPackages installe les dépendances du cours, les paquets demandés par le job,
+// le thème du deck s'il manque (et ses addons et paquets importés si InstallDeckPackages),
+// et les préprocesseurs de styles ; retourne le résultat de chaque installation de paquet.
+// Un thème imposé (forcedTheme) remplace celui des slides, qui n'est pas installé.
+func (sr *SlidevRunner) InstallNpmPackages(ctx context.Context, workspace *Workspace, job *models.GenerationJob, slideFile, forcedTheme string) ([]*models.NpmPackageInstallResult, error) {
 	log.Printf("Job %s: Installing packages...", job.ID)
 
 	// Auto-installer les packages
 	results, err := sr.npmPackageManager.AutoInstallNpmPackages(ctx, workspace)
 	if err != nil {
-		return fmt.Errorf("failed to auto-install packages: %w", err)
+		return results, fmt.Errorf("failed to auto-install packages: %w", err)
 	}
 
 	// Installer les package spécifiés
@@ -55,9 +105,46 @@ func (sr *SlidevRunner) InstallNpmPackages(ctx context.Context, workspace *Works
 		individualPackageResults, individualErr := sr.npmPackageManager.InstallNpmPackage(ctx, workspace, npmPackage)
 		results = append(results, individualPackageResults)
 		if individualErr != nil {
-			return fmt.Errorf("failed to install packages: %w", err)
+			return results, fmt.Errorf("failed to install packages: %w", individualErr)
+		}
+
+	}
+
+	// Installer le thème absent de node_modules, et sur option les addons et paquets importés
+	missing, err := sr.npmPackageManager.DetectMissingPackages(workspace, slideFile)
+	if err != nil {
+		log.Printf("Job %s: Failed to detect required packages: %v", job.ID, err)
+	}
+	var required []string
+	if forcedTheme == "" {
+		required = append(required, missing.Themes...)
+	}
+	if sr.config.InstallDeckPackages {
+		required = append(required, missing.Packages...)
+	} else if len(missing.Packages) > 0 {
+		log.Printf("Job %s: Not installing packages referenced by the deck (INSTALL_DECK_PACKAGES disabled): %v",
+			job.ID, missing.Packages)
+	}
+	for _, npmPackage := range required {
+		if err := sr.themePolicy.CheckPackage(npmPackage); err != nil {
+			return results, err
 		}
+	}
+	for _, npmPackage := range required {
+		log.Printf("Job %s: Installing required package: %s", job.ID, npmPackage)
+		requiredResult, _ := sr.npmPackageManager.InstallNpmPackage(ctx, workspace, npmPackage)
+		results = append(results, requiredResult)
+	}
 
+	// Installer les préprocesseurs de styles requis par les sources (.scss, .sass, .less)
+	preprocessors, err := sr.npmPackageManager.DetectStylePreprocessors(workspace)
+	if err != nil {
+		log.Printf("Job %s: Failed to detect style preprocessors: %v", job.ID, err)
+	}
+	for _, npmPackage := range preprocessors {
+		log.Printf("Job %s: Installing style preprocessor: %s", job.ID, npmPackage)
+		preprocessorResult, _ := sr.npmPackageManager.InstallNpmPackage(ctx, workspace, npmPackage)
+		results = append(results, preprocessorResult)
 	}
 
 	// Vérifier les résultats
@@ -67,10 +154,10 @@ func (sr *SlidevRunner) InstallNpmPackages(ctx context.Context, workspace *Works
 	for _, result := range results {
 		if result.Success {
 			successPackages = append(successPackages, result.Package)
-			log.Printf("Job %s: Successfully installed package: %s", job.ID, result.Package)
+			log.Printf("Job %s: Successfully installed package: %s (%s)", job.ID, result.Package, result.Output.Text)
 		} else {
 			failedPackages = append(failedPackages, result.Package)
-			log.Printf("Job %s: Failed to install package: %s - %s", job.ID, result.Package, result.Error)
+			log.Printf("Job %s: Failed to install package: %s - %s (%s)", job.ID, result.Package, result.Error, result.Output.Text)
 		}
 	}
 
@@ -79,28 +166,132 @@ func (sr *SlidevRunner) InstallNpmPackages(ctx context.Context, workspace *Works
 	}
 
 	if len(failedPackages) > 0 {
-		return fmt.Errorf("Failed to install %d packages: %v", len(failedPackages), failedPackages)
+		return results, fmt.Errorf("Failed to install %d packages: %v", len(failedPackages), failedPackages)
 	}
 
-	return nil
+	return results, nil
+}
+
+// npmInstallLogLines résume l'installation d'un paquet pour les logs du job : une ligne
+// de décompte puis les erreurs et avertissements relevés (la sortie complète reste dans
+// result.Logs)
+func npmInstallLogLines(result *models.NpmPackageInstallResult) []string {
+	if result == nil || result.Output == nil {
+		return nil
+	}
+
+	status := "installed"
+	if !result.Success {
+		status = "failed"
+	}
+	lines := []string{fmt.Sprintf("Package %s %s: %s", result.Package, status, result.Output.Text)}
+	for _, message := range result.Output.ErrorMessages {
+		lines = append(lines, "  npm error: "+message)
+	}
+	for _, message := range result.Output.WarningMessages {
+		lines = append(lines, "  npm warn: "+message)
+	}
+	return lines
 }
 
 // Build exécute `slidev build` dans le workspace avec validation améliorée
 func (sr *SlidevRunner) Build(ctx context.Context, workspace *Workspace, job *models.GenerationJob) (*SlidevResult, error) {
+	return sr.build(ctx, workspace, job, &SlidevBuildOptions{})
+}
+
+// build exécute `slidev build` avec les options du job complétées par celles de l'appelant
+func (sr *SlidevRunner) build(ctx context.Context, workspace *Workspace, job *models.GenerationJob, options *SlidevBuildOptions) (*SlidevResult, error) {
 	startTime := time.Now()
 	result := &SlidevResult{
 		Success: false,
 		Logs:    []string{},
 	}
 
+	// Attendre un créneau : l'installation et le build node/Vite sont les étapes gourmandes
+	if !sr.buildLimiter.Available() {
+		log.Printf("Job %s: Waiting for a build slot (%d/%d builds active)",
+			job.ID, sr.buildLimiter.Active(), sr.buildLimiter.Limit())
+		result.Logs = append(result.Logs, "Waiting for a build slot...")
+	}
+	release, err := sr.buildLimiter.Acquire(ctx)
+	if err != nil {
+		result.Logs = append(result.Logs, fmt.Sprintf("ERROR: Cancelled while waiting for a build slot: %v", err))
+		return result, fmt.Errorf("cancelled while waiting for a build slot: %w", err)
+	}
+	defer release()
+
+	// Borner la mémoire de l'installation et du build
+	if sr.config.BuildMemoryLimit > 0 {
+		limit, err := sr.newMemoryLimit(job)
+		if err != nil {
+			log.Printf("Job %s: WARNING: build memory limit not applied: %v", job.ID, err)
+			result.Logs = append(result.Logs, fmt.Sprintf("WARNING: Build memory limit not applied: %v", err))
+		} else {
+			workspace.memoryLimit = limit
+			defer func() {
+				workspace.memoryLimit = nil
+				if err := limit.Release(); err != nil {
+					log.Printf("Job %s: %v", job.ID, err)
+				}
+			}()
+			result.Logs = append(result.Logs, fmt.Sprintf("Build memory limit: %d MB", limit.Limit()>>20))
+		}
+	}
+
 	// Vérifier les prérequis
-	if err := sr.checkPrerequisites(ctx, workspace, job); err != nil {
+	slideFile, err := sr.checkPrerequisites(ctx, workspace, job)
+	if err != nil {
 		result.Logs = append(result.Logs, fmt.Sprintf("ERROR: Prerequisites check failed: %v", err))
 		return result, fmt.Errorf("prerequisites check failed: %w", err)
 	}
+	result.Logs = append(result.Logs, fmt.Sprintf("Slide file: %s", slideFile))
+
+	// Refuser un thème interdit avant d'installer quoi que ce soit
+	if err := sr.checkThemePolicy(workspace, job, slideFile, options.Theme); err != nil {
+		result.Logs = append(result.Logs, fmt.Sprintf("ERROR: %v", err))
+		return result, err
+	}
+
+	// Slides importées par src: présentes dans les sources et sans import circulaire
+	if sr.config.SrcIncludeCheckMode != SrcIncludeCheckOff {
+		problems := checkSrcIncludes(workspace, slideFile)
+		if len(problems) > 0 && sr.config.SrcIncludeCheckMode == SrcIncludeCheckStrict {
+			for _, problem := range problems {
+				result.Logs = append(result.Logs, "ERROR: "+problem)
+			}
+			return result, fmt.Errorf("broken src includes: %s", strings.Join(problems, "; "))
+		}
+		for _, problem := range problems {
+			log.Printf("Job %s: WARNING: %s", job.ID, problem)
+			result.Logs = append(result.Logs, "WARNING: "+problem)
+		}
+	}
+
+	// Versions de Node et Slidev exigées par le package.json du cours
+	if sr.config.VersionCheckMode != VersionCheckOff {
+		problems, err := sr.checkVersionRequirements(ctx, workspace)
+		if err != nil {
+			log.Printf("Job %s: Version requirements not checked: %v", job.ID, err)
+			result.Logs = append(result.Logs, fmt.Sprintf("WARNING: Version requirements not checked: %v", err))
+		}
+		if len(problems) > 0 && sr.config.VersionCheckMode == VersionCheckStrict {
+			for _, problem := range problems {
+				result.Logs = append(result.Logs, "ERROR: "+problem)
+			}
+			return result, fmt.Errorf("version requirements not met: %s", strings.Join(problems, "; "))
+		}
+		for _, problem := range problems {
+			log.Printf("Job %s: WARNING: %s", job.ID, problem)
+			result.Logs = append(result.Logs, "WARNING: "+problem)
+		}
+	}
 
 	result.Logs = append(result.Logs, "Checking and installing missing packagess...")
-	if err := sr.InstallNpmPackages(ctx, workspace, job); err != nil {
+	installResults, err := sr.InstallNpmPackages(ctx, workspace, job, slideFile, options.Theme)
+	for _, installResult := range installResults {
+		result.Logs = append(result.Logs, npmInstallLogLines(installResult)...)
+	}
+	if err != nil {
 		result.Logs = append(result.Logs, fmt.Sprintf("WARNING: Package installation failed: %v", err))
 		// On continue quand même, car les thèmes peuvent être optionnels
 		log.Printf("Job %s: Package installation failed but continuing: %v", job.ID, err)
@@ -108,8 +299,38 @@ func (sr *SlidevRunner) Build(ctx context.Context, workspace *Workspace, job *mo
 		result.Logs = append(result.Logs, "Package installation completed successfully")
 	}
 
+	// Un thème imposé est installé avant le build : Slidev proposerait sinon de l'installer
+	if options.Theme != "" {
+		themePackage := ThemePackageName(options.Theme)
+		result.Logs = append(result.Logs, fmt.Sprintf("Theme: %s (%s)", options.Theme, themePackage))
+		installResult, err := sr.npmPackageManager.InstallNpmPackage(ctx, workspace, themePackage)
+		result.Logs = append(result.Logs, npmInstallLogLines(installResult)...)
+		if err != nil || !installResult.Success {
+			if err == nil {
+				err = errors.New(installResult.Error)
+			}
+			result.Logs = append(result.Logs, fmt.Sprintf("ERROR: Theme installation failed: %v", err))
+			return result, fmt.Errorf("failed to install theme %s: %w", themePackage, err)
+		}
+	}
+
+	// Restaurer le cache Vite des builds précédents
+	cacheStatus := sr.restoreBuildCache(workspace, job)
+	if sr.buildCache.Enabled() {
+		result.Logs = append(result.Logs, fmt.Sprintf("Build cache: %s", cacheStatus))
+	}
+
+	// Options de build du job, revérifiées contre la liste autorisée
+	buildFlags, rejected := filterBuildFlags(append(append([]string{}, job.BuildFlags...), options.flags()...))
+	for _, flag := range rejected {
+		result.Logs = append(result.Logs, fmt.Sprintf("WARNING: Build flag %q is not allowed, ignored", flag))
+	}
+	if options.Theme != "" {
+		buildFlags = append(buildFlags, "--theme", options.Theme)
+	}
+
 	// Préparer la commande Slidev
-	cmd := sr.prepareBuildCommand(ctx, workspace)
+	cmd := sr.prepareBuildCommand(ctx, workspace, slideFile, buildFlags)
 
 	// Configurer la capture des logs
 	stdout, err := cmd.StdoutPipe()
@@ -142,19 +363,31 @@ func (sr *SlidevRunner) Build(ctx context.Context, workspace *Workspace, job *mo
 		}
 	}()
 
+	oomKillsBefore := workspace.memoryLimit.oomKills()
 	if err := cmd.Start(); err != nil {
 		return result, fmt.Errorf("failed to start slidev command: %w", err)
 	}
 
 	// Capturer les logs en temps réel
 	logChan := make(chan string, 100)
-	go sr.captureOutput(stdout, "STDOUT", logChan)
-	go sr.captureOutput(stderr, "STDERR", logChan)
+	var outputWG sync.WaitGroup
+	outputWG.Add(2)
+	go func() {
+		defer outputWG.Done()
+		sr.captureOutput(stdout, "STDOUT", logChan)
+	}()
+	go func() {
+		defer outputWG.Done()
+		sr.captureOutput(stderr, "STDERR", logChan)
+	}()
 
 	// Collecter les logs
+	logsCollected := make(chan struct{})
 	go func() {
+		defer close(logsCollected)
 		for logLine := range logChan {
 			result.Logs = append(result.Logs, logLine)
+			sr.logStreams.Publish(job.ID, logLine)
 
 			// Optionnel: détecter le progress depuis les logs Slidev
 			if progress := sr.parseProgress(logLine); progress > 0 {
@@ -167,6 +400,8 @@ func (sr *SlidevRunner) Build(ctx context.Context, workspace *Workspace, job *mo
 	// Attendre la fin de la commande avec timeout
 	done := make(chan error, 1)
 	go func() {
+		// Lire toute la sortie avant Wait, qui ferme les pipes
+		outputWG.Wait()
 		done <- cmd.Wait()
 	}()
 
@@ -182,8 +417,9 @@ func (sr *SlidevRunner) Build(ctx context.Context, workspace *Workspace, job *mo
 		return result, fmt.Errorf("slidev build timeout or cancelled")
 
 	case err := <-done:
-		// Commande terminée
+		// Commande terminée : toute la sortie a été capturée
 		close(logChan)
+		<-logsCollected
 
 		if err != nil {
 			if exitError, ok := err.(*exec.ExitError); ok {
@@ -191,65 +427,152 @@ func (sr *SlidevRunner) Build(ctx context.Context, workspace *Workspace, job *mo
 			} else {
 				result.ExitCode = 1
 			}
-			result.Logs = append(result.Logs, fmt.Sprintf("ERROR: Slidev build failed: %v", err))
+			// Processus tué par le noyau : rapporter la limite plutôt qu'un code de sortie obscur
+			if workspace.memoryLimit.oomKills() > oomKillsBefore {
+				result.Logs = append(result.Logs, fmt.Sprintf("ERROR: Slidev build failed: %v", err))
+				oomErr := workspace.memoryLimit.outOfMemoryError("slidev build")
+				result.Logs = append(result.Logs, "ERROR: "+oomErr.Error())
+				return result, oomErr
+			}
 
-			// Afficher le contenu du workspace pour debug
-			sr.debugWorkspaceState(workspace, result)
+			// Slidev sort parfois en erreur sur de simples avertissements : la sortie décide
+			if sr.config.NonZeroExitMode != NonZeroExitValidateOutput {
+				result.Logs = append(result.Logs, fmt.Sprintf("ERROR: Slidev build failed: %v", err))
 
-			return result, fmt.Errorf("slidev build failed with exit code %d: %w", result.ExitCode, err)
+				// Afficher le contenu du workspace pour debug
+				sr.debugWorkspaceState(workspace, result)
+
+				return result, fmt.Errorf("slidev build failed with exit code %d: %w", result.ExitCode, err)
+			}
+			result.WithWarnings = true
+			result.Logs = append(result.Logs, fmt.Sprintf("WARNING: Slidev build exited with code %d, validating its output", result.ExitCode))
 		}
 
-		result.ExitCode = 0
 		result.Success = true
 		result.Duration = time.Since(startTime)
 		result.OutputPath = workspace.GetDistPath()
 
-		log.Printf("Job %s: Slidev build completed successfully in %v", job.ID, result.Duration)
+		log.Printf("Job %s: Slidev build completed in %v with exit code %d (build cache: %s)", job.ID, result.Duration, result.ExitCode, cacheStatus)
 
 		// Vérifier que les fichiers de sortie existent
-		if err := sr.validateOutput(workspace); err != nil {
+		entryPoints, err := sr.validateOutput(workspace)
+		if err != nil {
 			result.Success = false
+			result.WithWarnings = false
 			result.Logs = append(result.Logs, fmt.Sprintf("ERROR: Output validation failed: %v", err))
 
 			// Debug détaillé en cas d'échec de validation
 			sr.debugWorkspaceState(workspace, result)
 
+			if result.ExitCode != 0 {
+				return result, fmt.Errorf("slidev build failed with exit code %d and no valid output: %w", result.ExitCode, err)
+			}
 			return result, fmt.Errorf("slidev output validation failed: %w", err)
 		}
+		result.EntryPoints = entryPoints
+
+		// Mettre à jour le cache Vite pour les prochains builds
+		if err := sr.buildCache.Save(workspace, job); err != nil {
+			log.Printf("Job %s: Failed to save build cache: %v", job.ID, err)
+		}
+		if len(entryPoints) > 1 {
+			result.Logs = append(result.Logs, fmt.Sprintf("Found %d HTML entry points: %v", len(entryPoints), entryPoints))
+		}
+
+		// Diagnostic des polices : avertissement uniquement, le build reste valide
+		for _, warning := range sr.verifyFonts(workspace) {
+			log.Printf("Job %s: WARNING: %s", job.ID, warning)
+			result.Logs = append(result.Logs, "WARNING: "+warning)
+		}
+
+		// Références vers des assets absents du build, sur demande du job
+		if models.BoolValue(job.CheckLinks) {
+			warnings := sr.verifyLinks(workspace)
+			for _, warning := range warnings {
+				log.Printf("Job %s: WARNING: %s", job.ID, warning)
+				result.Logs = append(result.Logs, "WARNING: "+warning)
+			}
+			if len(warnings) == 0 {
+				result.Logs = append(result.Logs, "Link check: no broken asset references found")
+			}
+		}
 
+		if result.WithWarnings {
+			result.Logs = append(result.Logs, fmt.Sprintf("SUCCESS: Slidev build completed with warnings (exit code %d) in %v", result.ExitCode, result.Duration))
+			return result, nil
+		}
 		result.Logs = append(result.Logs, fmt.Sprintf("SUCCESS: Slidev build completed in %v", result.Duration))
 		return result, nil
 	}
 }
 
-// checkPrerequisites vérifie que tous les prérequis sont présents
-func (sr *SlidevRunner) checkPrerequisites(ctx context.Context, workspace *Workspace, job *models.GenerationJob) error {
-	// Vérifier qu'il y a au moins un fichier de slides
-	slideFiles := []string{"slides.md", "index.md", "README.md"}
-	hasSlideFile := false
+// slideFileCandidates retourne les fichiers de slides configurés, ou ceux par défaut
+func slideFileCandidates(configured []string) []string {
+	if len(configured) == 0 {
+		return DefaultSlideFiles
+	}
+	return configured
+}
+
+// restoreBuildCache restaure le cache Vite des builds précédents et retourne son statut.
+// Un rebuild forcé part de zéro : le cache sera remplacé par celui de ce build.
+func (sr *SlidevRunner) restoreBuildCache(workspace *Workspace, job *models.GenerationJob) string {
+	if !sr.buildCache.Enabled() {
+		return "disabled"
+	}
+	if job.ForceRebuild {
+		return "skipped (force rebuild)"
+	}
 
-	for _, file := range slideFiles {
+	hit, err := sr.buildCache.Restore(workspace, job)
+	if err != nil {
+		log.Printf("Job %s: Failed to restore build cache: %v", job.ID, err)
+	}
+	if hit {
+		return "hit"
+	}
+	return "miss"
+}
+
+// resolveSlideFile détermine le fichier de slides à construire.
+// Un entry_file explicite prime sur la détection et doit exister dans les sources.
+func resolveSlideFile(workspace *Workspace, job *models.GenerationJob, candidates []string) (string, error) {
+	if job.EntryFile != "" {
+		if !workspace.FileExists(job.EntryFile) {
+			return "", fmt.Errorf("entry file %s not found in sources", job.EntryFile)
+		}
+		return job.EntryFile, nil
+	}
+
+	candidates = slideFileCandidates(candidates)
+	for _, file := range candidates {
 		if workspace.FileExists(file) {
-			hasSlideFile = true
-			log.Printf("Job %s: Found slide file: %s", job.ID, file)
-			break
+			return file, nil
 		}
 	}
 
-	if !hasSlideFile {
-		return fmt.Errorf("no slide file found (checked: %v)", slideFiles)
+	return "", fmt.Errorf("no slide file found (checked: %v)", candidates)
+}
+
+// checkPrerequisites vérifie que tous les prérequis sont présents et retourne le fichier de slides
+func (sr *SlidevRunner) checkPrerequisites(ctx context.Context, workspace *Workspace, job *models.GenerationJob) (string, error) {
+	// Vérifier qu'il y a un fichier de slides
+	slideFile, err := resolveSlideFile(workspace, job, sr.config.SlideFiles)
+	if err != nil {
+		return "", err
 	}
+	log.Printf("Job %s: Found slide file: %s", job.ID, slideFile)
 
 	// Vérifier que Slidev est disponible
 	cmd := exec.CommandContext(ctx, "npx", "@slidev/cli", "--version")
 	if output, err := cmd.Output(); err != nil {
-		return fmt.Errorf("slidev not available: %w", err)
+		return "", fmt.Errorf("slidev not available: %w", err)
 	} else {
 		version := strings.TrimSpace(string(output))
 		log.Printf("Job %s: Using Slidev version: %s", job.ID, version)
 	}
 
-	return nil
+	return slideFile, nil
 }
 
 // debugWorkspaceState affiche l'état détaillé du workspace pour debug
@@ -270,6 +593,9 @@ func (sr *SlidevRunner) debugWorkspaceState(workspace *Workspace, result *Slidev
 
 	// Vérifier les répertoires de sortie possibles
 	outputDirs := []string{"dist", "build", "output", "_output", ".slidev", "node_modules"}
+	if !slices.Contains(outputDirs, workspace.GetDistPath()) {
+		outputDirs = append([]string{workspace.GetDistPath()}, outputDirs...)
+	}
 	for _, dir := range outputDirs {
 		if workspace.DirExists(dir) {
 			result.Logs = append(result.Logs, fmt.Sprintf("Found directory: %s/", dir))
@@ -295,17 +621,34 @@ func min(a, b int) int {
 	return b
 }
 
+// filterBuildFlags sépare les options de build autorisées des autres
+func filterBuildFlags(flags []string) (allowed, rejected []string) {
+	for _, flag := range flags {
+		if validation.IsAllowedBuildFlag(flag) {
+			allowed = append(allowed, flag)
+		} else {
+			rejected = append(rejected, flag)
+		}
+	}
+	return allowed, rejected
+}
+
 // prepareBuildCommand prépare la commande Slidev build avec le bon répertoire de sortie
-func (sr *SlidevRunner) prepareBuildCommand(ctx context.Context, workspace *Workspace) *exec.Cmd {
+// et les options supplémentaires du job (déjà filtrées)
+func (sr *SlidevRunner) prepareBuildCommand(ctx context.Context, workspace *Workspace, slideFile string, buildFlags []string) *exec.Cmd {
 	// Détecter la commande Slidev à utiliser
 	slidevCmd := sr.detectSlidevCommand()
 
 	// Arguments pour la build avec répertoire de sortie explicite
-	args := []string{"build", "--out", "./dist"}
-
-	// Vérifier s'il y a un fichier de configuration spécifique
-	if workspace.FileExists("slidev.config.js") || workspace.FileExists("slidev.config.ts") {
-		log.Printf("Found Slidev config file in workspace")
+	args := []string{"build", slideFile, "--out", "./" + workspace.GetDistPath()}
+	args = append(args, buildFlags...)
+
+	// La base du fichier de configuration du cours s'applique si le job n'en impose pas
+	if !slices.ContainsFunc(buildFlags, func(flag string) bool { return strings.HasPrefix(flag, "--base=") }) {
+		if base, configFile := configuredBuildBase(workspace); base != "" {
+			log.Printf("Using base %s from %s", base, configFile)
+			args = append(args, "--base="+base)
+		}
 	}
 
 	// Créer la commande
@@ -323,11 +666,61 @@ func (sr *SlidevRunner) prepareBuildCommand(ctx context.Context, workspace *Work
 	cmd.Dir = workspace.GetPath()
 
 	// Définir les variables d'environnement
-	cmd.Env = sr.buildEnvironment()
+	cmd.Env = sr.buildEnvironment(workspace)
+	workspace.memoryLimit.apply(cmd)
 
 	return cmd
 }
 
+// buildConfigFiles sont les fichiers de configuration lus pour la base du build, par priorité
+var buildConfigFiles = []string{
+	"slidev.config.ts", "slidev.config.js", "slidev.config.mts", "slidev.config.mjs",
+	"vite.config.ts", "vite.config.js", "vite.config.mts", "vite.config.mjs",
+}
+
+// maxBuildConfigSize limite la taille d'un fichier de configuration analysé
+const maxBuildConfigSize = 256 * 1024
+
+// configBasePattern repère une base littérale hors commentaire de ligne (base: '/cours/')
+var configBasePattern = regexp.MustCompile(`(?m)^[^/\n]*?(?:^|[^\w$.])base\s*:\s*['"]([^'"\n]*)['"]`)
+
+// configuredBuildBase retourne la base déclarée par le premier fichier de configuration
+// du cours qui en définit une, et ce fichier. Seules les valeurs littérales sont
+// reconnues ; une base refusée par la validation de --base est ignorée.
+func configuredBuildBase(workspace *Workspace) (string, string) {
+	for _, name := range buildConfigFiles {
+		size, err := workspace.GetFileSize(name)
+		if err != nil || size > maxBuildConfigSize {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(workspace.GetPath(), name))
+		if err != nil {
+			continue
+		}
+
+		match := configBasePattern.FindSubmatch(content)
+		if match == nil {
+			continue
+		}
+		base := string(match[1])
+		if !validation.IsAllowedBuildFlag("--base=" + base) {
+			log.Printf("Ignoring invalid base %q from %s", base, name)
+			continue
+		}
+		return base, name
+	}
+	return "", ""
+}
+
+// newMemoryLimit crée le cgroup borné en mémoire d'un job
+func (sr *SlidevRunner) newMemoryLimit(job *models.GenerationJob) (*MemoryLimit, error) {
+	parentDir := sr.config.BuildCgroupDir
+	if parentDir == "" {
+		parentDir = DefaultBuildCgroupDir
+	}
+	return NewMemoryLimit(parentDir, job.ID, sr.config.BuildMemoryLimit)
+}
+
 // detectSlidevCommand détecte la meilleure commande Slidev à utiliser
 func (sr *SlidevRunner) detectSlidevCommand() string {
 	// Utiliser la configuration si définie
@@ -363,15 +756,15 @@ func (sr *SlidevRunner) commandExists(cmd string) bool {
 }
 
 // buildEnvironment construit l'environnement pour la commande Slidev
-func (sr *SlidevRunner) buildEnvironment() []string {
+func (sr *SlidevRunner) buildEnvironment(workspace *Workspace) []string {
 	env := os.Environ()
 
 	// Ajouter des variables spécifiques à Slidev
 	env = append(env, "NODE_ENV=production")
 	env = append(env, "SLIDEV_BUILD=true")
 
-	// Configurer le cache NPM dans le workspace pour éviter les conflits
-	env = append(env, "NPM_CONFIG_CACHE=/tmp/npm-cache")
+	// Cache NPM partagé, ou isolé dans le workspace selon NpmCacheMode
+	env = append(env, "NPM_CONFIG_CACHE="+npmCacheDir(sr.config.NpmCacheMode, workspace))
 
 	return env
 }
@@ -437,7 +830,8 @@ func (sr *SlidevRunner) parseProgress(logLine string) int {
 }
 
 // validateOutput vérifie que les fichiers de sortie ont été générés correctement
-func (sr *SlidevRunner) validateOutput(workspace *Workspace) error {
+// et retourne les points d'entrée HTML du build (index.html en premier)
+func (sr *SlidevRunner) validateOutput(workspace *Workspace) ([]string, error) {
 	distPath := workspace.GetDistPath()
 
 	// Vérifier que le répertoire dist existe
@@ -446,16 +840,22 @@ func (sr *SlidevRunner) validateOutput(workspace *Workspace) error {
 		workspaceFiles, _ := workspace.ListAllFiles(".")
 		log.Printf("Workspace contents: %v", workspaceFiles)
 
-		// Vérifier les répertoires alternatifs que Slidev pourrait créer
-		altPaths := []string{"build", "output", "_output", ".slidev/dist"}
+		// Vérifier les répertoires alternatifs que Slidev pourrait créer (dont dist si le
+		// job attend un autre dossier)
+		var altPaths []string
+		for _, altPath := range []string{DefaultOutputDir, "build", "output", "_output", ".slidev/dist"} {
+			if altPath != distPath {
+				altPaths = append(altPaths, altPath)
+			}
+		}
 		for _, altPath := range altPaths {
 			if workspace.DirExists(altPath) {
 				log.Printf("Found alternative output directory: %s", altPath)
-				// Copier vers dist/ pour uniformiser
+				// Copier vers le dossier de sortie pour uniformiser
 				if err := sr.moveToDistDirectory(workspace, altPath); err != nil {
-					log.Printf("Failed to move %s to dist: %v", altPath, err)
+					log.Printf("Failed to move %s to %s: %v", altPath, distPath, err)
 				} else {
-					log.Printf("Moved %s to dist directory", altPath)
+					log.Printf("Moved %s to %s directory", altPath, distPath)
 					break
 				}
 			}
@@ -463,7 +863,7 @@ func (sr *SlidevRunner) validateOutput(workspace *Workspace) error {
 
 		// Vérifier à nouveau
 		if !workspace.DirExists(distPath) {
-			return fmt.Errorf("dist directory not found: %s (tried alternatives: %v)", distPath, altPaths)
+			return nil, fmt.Errorf("output directory not found: %s (tried alternatives: %v)", distPath, altPaths)
 		}
 	}
 
@@ -478,26 +878,269 @@ func (sr *SlidevRunner) validateOutput(workspace *Workspace) error {
 			// Lister le contenu de dist pour debug
 			distFiles, _ := workspace.ListFiles(distPath)
 			log.Printf("Dist directory contents: %v", distFiles)
-			return fmt.Errorf("required output file not found: %s", file)
+			return nil, fmt.Errorf("required output file not found: %s", file)
 		}
 	}
 
 	// Vérifier que index.html n'est pas vide
 	indexPath := fmt.Sprintf("%s/index.html", distPath)
 	if size, err := workspace.GetFileSize(indexPath); err != nil {
-		return fmt.Errorf("failed to check index.html size: %w", err)
+		return nil, fmt.Errorf("failed to check index.html size: %w", err)
 	} else if size < 100 {
-		return fmt.Errorf("index.html is too small (%d bytes), build may have failed", size)
+		return nil, fmt.Errorf("index.html is too small (%d bytes), build may have failed", size)
 	}
 
-	log.Printf("Output validation successful - found all required files")
-	return nil
+	entryPoints, err := sr.listEntryPoints(workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Output validation successful - found all required files, entry points: %v", entryPoints)
+	return entryPoints, nil
+}
+
+// listEntryPoints catalogue les fichiers HTML de premier niveau de dist/.
+// index.html reste le point d'entrée principal et figure toujours en tête.
+func (sr *SlidevRunner) listEntryPoints(workspace *Workspace) ([]string, error) {
+	distFiles, err := workspace.ListFiles(workspace.GetDistPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dist entry points: %w", err)
+	}
+
+	entryPoints := []string{"index.html"}
+	var others []string
+	for _, file := range distFiles {
+		if !strings.EqualFold(filepath.Ext(file), ".html") {
+			continue
+		}
+		// 404.html est la page de repli SPA générée par Slidev, pas une entrée
+		if file == "index.html" || file == "404.html" {
+			continue
+		}
+		others = append(others, file)
+	}
+	sort.Strings(others)
+
+	return append(entryPoints, others...), nil
+}
+
+// fontsDirectory est le répertoire des sources où les cours déposent leurs polices
+const fontsDirectory = "assets/fonts"
+
+// fontExtensions liste les extensions de polices vérifiées après le build
+var fontExtensions = map[string]bool{
+	".woff2": true,
+	".woff":  true,
+	".ttf":   true,
+	".otf":   true,
+}
+
+// verifyFonts vérifie que les polices uploadées dans assets/fonts/ sont référencées
+// par la sortie du build et retourne un avertissement pour chaque police non embarquée
+func (sr *SlidevRunner) verifyFonts(workspace *Workspace) []string {
+	if !workspace.DirExists(fontsDirectory) {
+		return nil
+	}
+
+	sourceFiles, err := workspace.ListAllFiles(fontsDirectory)
+	if err != nil {
+		return []string{fmt.Sprintf("failed to list fonts directory: %v", err)}
+	}
+
+	distPath := workspace.GetDistPath()
+	distFiles, err := workspace.ListAllFiles(distPath)
+	if err != nil {
+		return []string{fmt.Sprintf("failed to list dist directory for font verification: %v", err)}
+	}
+
+	// Contenu des fichiers pouvant référencer une police (CSS, JS, HTML)
+	var references []string
+	for _, file := range distFiles {
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".css", ".js", ".mjs", ".html":
+			content, err := os.ReadFile(filepath.Join(workspace.GetPath(), distPath, file))
+			if err == nil {
+				references = append(references, string(content))
+			}
+		}
+	}
+
+	var warnings []string
+	for _, font := range sourceFiles {
+		ext := strings.ToLower(filepath.Ext(font))
+		if !fontExtensions[ext] {
+			continue
+		}
+
+		if !isFontReferenced(filepath.Base(font), distFiles, references) {
+			warnings = append(warnings, fmt.Sprintf(
+				"font %s/%s was uploaded but is not referenced in the build output, check the @font-face url()",
+				fontsDirectory, font))
+		}
+	}
+
+	return warnings
+}
+
+// isFontReferenced indique si une police apparaît dans les références du build,
+// sous son nom d'origine ou sous le nom hashé produit par Vite (ex: Inter-a1b2c3.woff2)
+func isFontReferenced(fontName string, distFiles []string, references []string) bool {
+	ext := filepath.Ext(fontName)
+	stem := strings.TrimSuffix(fontName, ext)
+
+	candidates := []string{fontName}
+	for _, file := range distFiles {
+		name := filepath.Base(file)
+		if name != fontName && strings.EqualFold(filepath.Ext(name), ext) && strings.HasPrefix(name, stem+"-") {
+			candidates = append(candidates, name)
+		}
+	}
+
+	for _, content := range references {
+		for _, candidate := range candidates {
+			if strings.Contains(content, candidate) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// maxLinkWarnings limite le nombre de références cassées rapportées par build
+const maxLinkWarnings = 20
+
+var (
+	// htmlAssetTagPattern repère les balises HTML chargeant un asset
+	htmlAssetTagPattern = regexp.MustCompile(`(?is)<(?:img|script|link|source|video|audio|iframe|embed)\b[^>]*>`)
+	// htmlAssetAttrPattern extrait les attributs src, href et poster d'une balise
+	htmlAssetAttrPattern = regexp.MustCompile(`(?is)\s(?:src|href|poster)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	// cssURLPattern extrait les url(...) et @import d'une feuille de style
+	cssURLPattern = regexp.MustCompile(`url\(\s*(?:"([^"]*)"|'([^']*)'|([^)'"\s]+))\s*\)|@import\s+(?:"([^"]*)"|'([^']*)')`)
+	// urlSchemePattern détecte les URLs avec schéma (http:, data:, mailto:...)
+	urlSchemePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+)
+
+// verifyLinks recherche dans les fichiers HTML et CSS du build les références vers des
+// assets absents de dist/ et retourne un avertissement pour chacune
+func (sr *SlidevRunner) verifyLinks(workspace *Workspace) []string {
+	distPath := workspace.GetDistPath()
+	distFiles, err := workspace.ListAllFiles(distPath)
+	if err != nil {
+		return []string{fmt.Sprintf("failed to list dist directory for link verification: %v", err)}
+	}
+
+	available := make(map[string]bool, len(distFiles))
+	for _, file := range distFiles {
+		available[filepath.ToSlash(file)] = true
+	}
+
+	var warnings []string
+	broken := 0
+	for _, file := range distFiles {
+		var refs []string
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".html":
+			content, err := os.ReadFile(filepath.Join(workspace.GetPath(), distPath, file))
+			if err != nil {
+				continue
+			}
+			for _, tag := range htmlAssetTagPattern.FindAllString(string(content), -1) {
+				refs = append(refs, submatches(htmlAssetAttrPattern, tag)...)
+			}
+		case ".css":
+			content, err := os.ReadFile(filepath.Join(workspace.GetPath(), distPath, file))
+			if err != nil {
+				continue
+			}
+			refs = submatches(cssURLPattern, string(content))
+		default:
+			continue
+		}
+
+		from := filepath.ToSlash(file)
+		seen := make(map[string]bool)
+		for _, ref := range refs {
+			target, ok := resolveAssetReference(from, ref)
+			if !ok || seen[target] || assetAvailable(target, available) {
+				continue
+			}
+			seen[target] = true
+
+			broken++
+			if broken <= maxLinkWarnings {
+				warnings = append(warnings, fmt.Sprintf(
+					"broken reference in %s: %s not found in build output", from, ref))
+			}
+		}
+	}
+
+	if broken > maxLinkWarnings {
+		warnings = append(warnings, fmt.Sprintf("%d more broken references not listed", broken-maxLinkWarnings))
+	}
+
+	return warnings
+}
+
+// submatches retourne le premier groupe non vide de chaque correspondance
+func submatches(pattern *regexp.Regexp, content string) []string {
+	var values []string
+	for _, match := range pattern.FindAllStringSubmatch(content, -1) {
+		for _, group := range match[1:] {
+			if group != "" {
+				values = append(values, group)
+				break
+			}
+		}
+	}
+	return values
+}
+
+// resolveAssetReference convertit une référence en chemin relatif à dist/.
+// Les URLs externes, data:, les ancres et les références vides sont ignorées.
+func resolveAssetReference(from, ref string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") || strings.HasPrefix(ref, "//") || urlSchemePattern.MatchString(ref) {
+		return "", false
+	}
+
+	if i := strings.IndexAny(ref, "?#"); i >= 0 {
+		ref = ref[:i]
+	}
+	if unescaped, err := url.PathUnescape(ref); err == nil {
+		ref = unescaped
+	}
+	if ref == "" || strings.HasSuffix(ref, "/") {
+		return "", false
+	}
+
+	if strings.HasPrefix(ref, "/") {
+		return path.Clean(strings.TrimPrefix(ref, "/")), true
+	}
+	return path.Join(path.Dir(from), ref), true
 }
 
-// moveToDistDirectory déplace un répertoire alternatif vers dist/
+// assetAvailable indique si un asset existe dans dist/. Un chemin absolu peut inclure
+// la base publique du déploiement (--base) : ses premiers segments sont alors ignorés.
+func assetAvailable(target string, available map[string]bool) bool {
+	for candidate := target; candidate != ""; {
+		if available[candidate] {
+			return true
+		}
+		i := strings.Index(candidate, "/")
+		if i < 0 {
+			break
+		}
+		candidate = candidate[i+1:]
+	}
+	return false
+}
+
+// moveToDistDirectory déplace un répertoire alternatif vers le dossier de sortie
 func (sr *SlidevRunner) moveToDistDirectory(workspace *Workspace, srcDir string) error {
-	// Créer le répertoire dist
-	if err := workspace.CreateDirectory("dist"); err != nil {
+	// Créer le répertoire de sortie
+	distPath := workspace.GetDistPath()
+	if err := workspace.CreateDirectory(distPath); err != nil {
 		return err
 	}
 
@@ -510,7 +1153,7 @@ func (sr *SlidevRunner) moveToDistDirectory(workspace *Workspace, srcDir string)
 	// Copier chaque fichier
 	for _, file := range files {
 		srcPath := fmt.Sprintf("%s/%s", srcDir, file)
-		dstPath := fmt.Sprintf("dist/%s", file)
+		dstPath := fmt.Sprintf("%s/%s", distPath, file)
 
 		if err := workspace.CopyFile(srcPath, dstPath); err != nil {
 			return fmt.Errorf("failed to copy %s to %s: %w", srcPath, dstPath, err)
@@ -574,6 +1217,12 @@ func (sr *SlidevRunner) InstallDependencies(ctx context.Context, workspace *Work
 
 	log.Printf("Found package.json, installing dependencies")
 
+	release, err := sr.npmPackageManager.processLimiter.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("dependency installation not started: %w", err)
+	}
+	defer release()
+
 	// Choisir la commande d'installation
 	var cmd *exec.Cmd
 	if sr.commandExists("yarn") && workspace.FileExists("yarn.lock") {
@@ -583,7 +1232,7 @@ func (sr *SlidevRunner) InstallDependencies(ctx context.Context, workspace *Work
 	}
 
 	cmd.Dir = workspace.GetPath()
-	cmd.Env = sr.buildEnvironment()
+	cmd.Env = sr.buildEnvironment(workspace)
 
 	// Capturer la sortie
 	output, err := cmd.CombinedOutput()
@@ -597,12 +1246,36 @@ func (sr *SlidevRunner) InstallDependencies(ctx context.Context, workspace *Work
 
 // SlidevBuildOptions contient les options pour la build Slidev
 type SlidevBuildOptions struct {
-	Output  string            // Répertoire de sortie (par défaut: dist)
+	Output  string            // Répertoire de sortie (seul dist est pris en charge)
 	Base    string            // Base URL
-	Options map[string]string // Options additionnelles
+	Theme   string            // Thème imposé au deck (--theme), installé avant le build
+	Options map[string]string // Options additionnelles (--clé=valeur, "" = sans valeur)
 	Export  *ExportOptions    // Options d'export (PDF, etc.)
 }
 
+// flags retourne les options de build correspondantes, à filtrer par la liste autorisée
+func (o *SlidevBuildOptions) flags() []string {
+	var flags []string
+	if o.Base != "" {
+		flags = append(flags, "--base="+o.Base)
+	}
+
+	names := make([]string, 0, len(o.Options))
+	for name := range o.Options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		flag := "--" + strings.TrimPrefix(name, "--")
+		if value := o.Options[name]; value != "" {
+			flag += "=" + value
+		}
+		flags = append(flags, flag)
+	}
+
+	return flags
+}
+
 // ExportOptions contient les options d'export
 type ExportOptions struct {
 	Format     string // pdf, png, md
@@ -617,18 +1290,49 @@ func (sr *SlidevRunner) BuildWithOptions(ctx context.Context, workspace *Workspa
 		return sr.Build(ctx, workspace, job)
 	}
 
-	// TODO: Implémenter le support des options avancées
-	// Pour l'instant, on utilise la build standard
-	return sr.Build(ctx, workspace, job)
+	// Le worker lit les résultats dans dist et exporte séparément (Export)
+	if options.Output != "" && filepath.Clean(options.Output) != workspace.GetDistPath() {
+		return &SlidevResult{Logs: []string{}}, fmt.Errorf("unsupported output directory %q (only %s)", options.Output, workspace.GetDistPath())
+	}
+	if options.Export != nil {
+		return &SlidevResult{Logs: []string{}}, fmt.Errorf("export options are not supported by build, use Export")
+	}
+
+	return sr.build(ctx, workspace, job, options)
 }
 
 // ExportToPDF exporte la présentation en PDF
 func (sr *SlidevRunner) ExportToPDF(ctx context.Context, workspace *Workspace, job *models.GenerationJob, outputFile string) error {
-	// Préparer la commande d'export PDF
-	args := []string{"export", "--format", "pdf"}
+	if err := sr.Export(ctx, workspace, "", &ExportOptions{Format: "pdf", Output: outputFile}); err != nil {
+		return err
+	}
+
+	log.Printf("Job %s: PDF export completed successfully", job.ID)
+	return nil
+}
 
-	if outputFile != "" {
-		args = append(args, "--output", outputFile)
+// Export exporte les slides du workspace avec "slidev export" (pdf, png ou md).
+// slideFile vide laisse Slidev choisir le fichier de slides par défaut.
+func (sr *SlidevRunner) Export(ctx context.Context, workspace *Workspace, slideFile string, options *ExportOptions) error {
+	args := []string{"export"}
+	if slideFile != "" {
+		args = append(args, slideFile)
+	}
+
+	format := options.Format
+	if format == "" {
+		format = "pdf"
+	}
+	args = append(args, "--format", format)
+
+	if options.Output != "" {
+		args = append(args, "--output", options.Output)
+	}
+	if options.Range != "" {
+		args = append(args, "--range", options.Range)
+	}
+	if options.WithClicks {
+		args = append(args, "--with-clicks")
 	}
 
 	slidevCmd := sr.detectSlidevCommand()
@@ -642,15 +1346,15 @@ func (sr *SlidevRunner) ExportToPDF(ctx context.Context, workspace *Workspace, j
 	}
 
 	cmd.Dir = workspace.GetPath()
-	cmd.Env = sr.buildEnvironment()
+	cmd.Env = sr.buildEnvironment(workspace)
+	workspace.memoryLimit.apply(cmd)
 
 	// Exécuter la commande
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("PDF export failed: %w\nOutput: %s", err, string(output))
+		return fmt.Errorf("%s export failed: %w\nOutput: %s", strings.ToUpper(format), err, string(output))
 	}
 
-	log.Printf("Job %s: PDF export completed successfully", job.ID)
 	return nil
 }
 
@@ -658,7 +1362,7 @@ func (sr *SlidevRunner) ExportToPDF(ctx context.Context, workspace *Workspace, j
 func (sr *SlidevRunner) GetBuildInfo() map[string]interface{} {
 	return map[string]interface{}{
 		"slidev_command":    sr.config.SlidevCommand,
-		"workspace_base":    sr.config.WorkspaceBase,
+		"workspace_base":    sr.workspaceBase,
 		"job_timeout":       sr.config.JobTimeout.String(),
 		"cleanup_workspace": sr.config.CleanupWorkspace,
 		"node_available":    sr.commandExists("node"),
@@ -0,0 +1,141 @@
+// internal/worker/src_includes.go - Vérification des slides importées (src:) avant le build
+package worker
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Modes de vérification des slides importées par src:
+const (
+	// SrcIncludeCheckOff désactive la vérification
+	SrcIncludeCheckOff = "off"
+	// SrcIncludeCheckWarn signale les imports cassés dans les logs du job sans bloquer le build
+	SrcIncludeCheckWarn = "warn"
+	// SrcIncludeCheckStrict fait échouer le job avant le build si un import est cassé
+	SrcIncludeCheckStrict = "strict"
+)
+
+// srcIncludeKey extrait la valeur de la clé src: d'un frontmatter de slide
+var srcIncludeKey = regexp.MustCompile(`^src\s*:\s*(.*)$`)
+
+// parseSrcIncludes retourne les fichiers importés par les frontmatters (src:) d'un fichier
+// Markdown Slidev, dans l'ordre. Les blocs de code sont ignorés.
+func parseSrcIncludes(content string) []string {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	var includes []string
+	fence := ""
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+
+		if fence != "" {
+			if strings.HasPrefix(line, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			fence = line[:3]
+			continue
+		}
+		if line != "---" || i+1 >= len(lines) || !slideFrontmatterKey.MatchString(lines[i+1]) {
+			continue
+		}
+
+		// Frontmatter : jusqu'au "---" fermant
+		for i++; i < len(lines) && strings.TrimSpace(lines[i]) != "---"; i++ {
+			if match := srcIncludeKey.FindStringSubmatch(strings.TrimSpace(lines[i])); match != nil {
+				if target := srcIncludeTarget(match[1]); target != "" {
+					includes = append(includes, target)
+				}
+			}
+		}
+	}
+	return includes
+}
+
+// srcIncludeTarget retourne le fichier d'une valeur src:, sans guillemets, commentaire YAML
+// ni sélection de slides ("./pages/intro.md#2-4")
+func srcIncludeTarget(value string) string {
+	if comment := strings.Index(value, " #"); comment >= 0 {
+		value = value[:comment]
+	}
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+	value, _, _ = strings.Cut(value, "#")
+	return strings.TrimSpace(value)
+}
+
+// resolveSrcInclude résout un import relativement au fichier qui le déclare ; un chemin
+// absolu part de la racine des sources. Retourne false si le chemin sort des sources.
+func resolveSrcInclude(from, target string) (string, bool) {
+	var resolved string
+	if strings.HasPrefix(target, "/") {
+		resolved = path.Clean(strings.TrimPrefix(target, "/"))
+	} else {
+		resolved = path.Join(path.Dir(from), target)
+	}
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return "", false
+	}
+	return resolved, true
+}
+
+// checkSrcIncludes suit les imports src: depuis le fichier de slides et retourne les
+// problèmes trouvés : fichier importé absent des sources, chemin hors des sources ou
+// imports circulaires. Chaque fichier n'est analysé qu'une fois.
+func checkSrcIncludes(workspace *Workspace, slideFile string) []string {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var problems []string
+	var stack []string
+
+	var visit func(file string)
+	visit = func(file string) {
+		state[file] = visiting
+		stack = append(stack, file)
+		defer func() {
+			stack = stack[:len(stack)-1]
+			state[file] = visited
+		}()
+
+		reader, err := workspace.ReadFile(file)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: cannot be read: %v", file, err))
+			return
+		}
+		content, err := io.ReadAll(reader)
+		if closer, ok := reader.(io.Closer); ok {
+			closer.Close()
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: cannot be read: %v", file, err))
+			return
+		}
+
+		for _, target := range parseSrcIncludes(string(content)) {
+			resolved, ok := resolveSrcInclude(file, target)
+			switch {
+			case !ok:
+				problems = append(problems, fmt.Sprintf("%s: src include %s points outside the sources", file, target))
+			case !workspace.FileExists(resolved):
+				problems = append(problems, fmt.Sprintf("%s: src include %s not found in sources (%s)", file, target, resolved))
+			case state[resolved] == visiting:
+				cycle := append([]string{}, stack[slices.Index(stack, resolved):]...)
+				problems = append(problems, fmt.Sprintf("circular src include: %s -> %s", strings.Join(cycle, " -> "), resolved))
+			case state[resolved] == 0:
+				visit(resolved)
+			}
+		}
+	}
+
+	visit(path.Clean(slideFile))
+	return problems
+}
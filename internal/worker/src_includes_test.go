@@ -0,0 +1,158 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSrcIncludes(t *testing.T) {
+	content := strings.Join([]string{
+		"---",
+		"theme: default",
+		"---",
+		"# Introduction",
+		"",
+		"---",
+		"src: ./pages/intro.md",
+		"---",
+		"",
+		"---",
+		"layout: cover",
+		`src: "/shared/outro.md#2-4" # slides 2 à 4`,
+		"---",
+		"",
+		"```yaml",
+		"---",
+		"src: ./in-code.md",
+		"---",
+		"```",
+		"",
+		"---",
+		"",
+		"src: ./not-frontmatter.md",
+	}, "\n")
+
+	assert.Equal(t, []string{"./pages/intro.md", "/shared/outro.md"}, parseSrcIncludes(content))
+	assert.Empty(t, parseSrcIncludes("# Deck sans import\n"))
+}
+
+func TestCheckSrcIncludes(t *testing.T) {
+	setup := func(t *testing.T, files map[string]string) *Workspace {
+		workspace, err := NewWorkspace(t.TempDir(), uuid.New())
+		require.NoError(t, err)
+		for name, content := range files {
+			require.NoError(t, workspace.WriteFile(name, strings.NewReader(content)))
+		}
+		return workspace
+	}
+	include := func(target string) string {
+		return "# Slide\n\n---\nsrc: " + target + "\n---\n"
+	}
+
+	t.Run("Valid nested includes", func(t *testing.T) {
+		workspace := setup(t, map[string]string{
+			"slides.md":        include("./pages/intro.md"),
+			"pages/intro.md":   include("../shared/common.md"),
+			"shared/common.md": include("/shared/footer.md"),
+			"shared/footer.md": "# Fin\n",
+			// Un fichier non importé par le deck n'est pas analysé
+			"pages/unused.md": include("./missing.md"),
+		})
+		assert.Empty(t, checkSrcIncludes(workspace, "slides.md"))
+	})
+
+	t.Run("Missing include", func(t *testing.T) {
+		workspace := setup(t, map[string]string{
+			"slides.md":      include("./pages/intro.md"),
+			"pages/intro.md": include("./chapitre-2.md"),
+		})
+		assert.Equal(t, []string{
+			"pages/intro.md: src include ./chapitre-2.md not found in sources (pages/chapitre-2.md)",
+		}, checkSrcIncludes(workspace, "slides.md"))
+	})
+
+	t.Run("Circular include", func(t *testing.T) {
+		workspace := setup(t, map[string]string{
+			"slides.md":  include("./pages/a.md"),
+			"pages/a.md": include("./b.md"),
+			"pages/b.md": include("../slides.md"),
+		})
+		assert.Equal(t, []string{
+			"circular src include: slides.md -> pages/a.md -> pages/b.md -> slides.md",
+		}, checkSrcIncludes(workspace, "slides.md"))
+	})
+
+	t.Run("Self include", func(t *testing.T) {
+		workspace := setup(t, map[string]string{"slides.md": include("./slides.md")})
+		assert.Equal(t, []string{"circular src include: slides.md -> slides.md"}, checkSrcIncludes(workspace, "slides.md"))
+	})
+
+	t.Run("Shared include is not a cycle", func(t *testing.T) {
+		workspace := setup(t, map[string]string{
+			"slides.md": include("./a.md") + include("./b.md"),
+			"a.md":      include("./common.md"),
+			"b.md":      include("./common.md"),
+			"common.md": "# Commun\n",
+		})
+		assert.Empty(t, checkSrcIncludes(workspace, "slides.md"))
+	})
+
+	t.Run("Include outside the sources", func(t *testing.T) {
+		workspace := setup(t, map[string]string{"slides.md": include("../../etc/passwd")})
+		assert.Equal(t, []string{"slides.md: src include ../../etc/passwd points outside the sources"},
+			checkSrcIncludes(workspace, "slides.md"))
+	})
+}
+
+func TestBuildSrcIncludes(t *testing.T) {
+	fakeSlidev(t)
+
+	slidev := filepath.Join(t.TempDir(), "slidev")
+	script := "#!/bin/sh\nmkdir -p dist\n" +
+		"printf '<!DOCTYPE html><html><head><title>Cours</title></head><body>Deck built with its imported slides, padded to a realistic size.</body></html>' > dist/index.html\n"
+	require.NoError(t, os.WriteFile(slidev, []byte(script), 0o755))
+
+	build := func(t *testing.T, mode string) (*SlidevResult, error) {
+		workspace, err := NewWorkspace(t.TempDir(), uuid.New())
+		require.NoError(t, err)
+		require.NoError(t, workspace.WriteFile("slides.md", strings.NewReader("# Cours\n\n---\nsrc: ./pages/missing.md\n---\n")))
+
+		runner := NewSlidevRunner(&PoolConfig{
+			SlidevCommand:       slidev,
+			VersionCheckMode:    VersionCheckOff,
+			SrcIncludeCheckMode: mode,
+		})
+		return runner.Build(context.Background(), workspace, &models.GenerationJob{ID: uuid.New()})
+	}
+	const problem = "slides.md: src include ./pages/missing.md not found in sources (pages/missing.md)"
+
+	t.Run("Strict", func(t *testing.T) {
+		result, err := build(t, SrcIncludeCheckStrict)
+		require.Error(t, err)
+		assert.Equal(t, "broken src includes: "+problem, err.Error())
+		assert.Contains(t, result.Logs, "ERROR: "+problem)
+		assert.False(t, result.Success)
+	})
+
+	t.Run("Warn", func(t *testing.T) {
+		result, err := build(t, SrcIncludeCheckWarn)
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Contains(t, result.Logs, "WARNING: "+problem)
+	})
+
+	t.Run("Off", func(t *testing.T) {
+		result, err := build(t, SrcIncludeCheckOff)
+		require.NoError(t, err)
+		assert.NotContains(t, result.Logs, "WARNING: "+problem)
+	})
+}
@@ -0,0 +1,122 @@
+// internal/worker/theme_matrix.go - Build d'un même deck avec plusieurs thèmes
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+)
+
+// buildThemeMatrix construit une variante du deck par thème du job à partir des sources
+// déjà téléchargées, et publie chacune dans results/{course_id}/theme-<nom>/. Un thème en
+// échec n'arrête pas les suivants ; retourne nil si aucun n'a pu être construit (statut du
+// job déjà mis à jour).
+func (p *JobProcessor) buildThemeMatrix(ctx context.Context, job *models.GenerationJob, workspace *Workspace, result *JobResult) *models.ResultManifest {
+	manifest := newResultManifest(job)
+	themeResults := make([]models.ThemeBuildResult, 0, len(job.Themes))
+	var entryPoints []string
+	succeeded := 0
+
+	for i, theme := range job.Themes {
+		dir := models.ThemeResultDir(theme)
+		themeResult := models.ThemeBuildResult{Theme: theme, Path: dir + "/"}
+
+		log.Printf("Job %s: Running Slidev build for theme %s (%d/%d)", job.ID, theme, i+1, len(job.Themes))
+		result.LogOutput = append(result.LogOutput, fmt.Sprintf("=== Theme %s (%d/%d) ===", theme, i+1, len(job.Themes)))
+
+		if err := p.buildThemeVariant(ctx, job, workspace, theme, dir, manifest, &themeResult, result); err != nil {
+			log.Printf("Job %s: Theme %s failed: %v", job.ID, theme, err)
+			themeResult.Error = err.Error()
+			result.LogOutput = append(result.LogOutput, fmt.Sprintf("ERROR: Theme %s failed: %v", theme, err))
+		} else {
+			themeResult.Success = true
+			entryPoints = append(entryPoints, themeResult.EntryPoints...)
+			succeeded++
+		}
+		themeResults = append(themeResults, themeResult)
+
+		progress := 40 + 50*(i+1)/len(job.Themes)
+		message := fmt.Sprintf("Theme %s built (%d/%d)", theme, i+1, len(job.Themes))
+		if !themeResult.Success {
+			message = fmt.Sprintf("Theme %s failed (%d/%d)", theme, i+1, len(job.Themes))
+		}
+		if errUpdate := p.updateJobStatus(ctx, job.ID, models.StatusProcessing, progress, message); errUpdate != nil {
+			log.Printf("Job %s: update failed: %v", job.ID, errUpdate)
+		}
+		result.Progress = progress
+	}
+
+	if err := p.jobService.SetJobThemeResults(ctx, job.ID, themeResults); err != nil {
+		log.Printf("Job %s: failed to record theme results: %v", job.ID, err)
+	}
+
+	if succeeded == 0 {
+		result.Error = fmt.Errorf("all %d theme builds failed", len(job.Themes))
+		if errUpdate := p.updateJobStatus(ctx, job.ID, models.StatusFailed, result.Progress, result.Error.Error()); errUpdate != nil {
+			log.Printf("Job %s: update failed: %v", job.ID, errUpdate)
+		}
+		if errSave := p.saveJobLogs(ctx, job.ID, result.LogOutput); errSave != nil {
+			log.Printf("Failed to save logs for job %s: %v", job.ID, errSave)
+		}
+		return nil
+	}
+
+	// Points d'entrée préfixés par le dossier de leur thème
+	if err := p.jobService.SetJobEntryPoints(ctx, job.ID, entryPoints); err != nil {
+		log.Printf("Job %s: failed to record entry points: %v", job.ID, err)
+	}
+
+	if err := p.saveResultManifest(ctx, job, manifest); err != nil {
+		result.Error = fmt.Errorf("failed to upload results: %w", err)
+		if errUpdate := p.updateJobStatus(ctx, job.ID, models.StatusFailed, 90, result.Error.Error()); errUpdate != nil {
+			log.Printf("Job %s: update failed: %v", job.ID, errUpdate)
+		}
+		return nil
+	}
+
+	log.Printf("Job %s: Built %d/%d themes", job.ID, succeeded, len(job.Themes))
+	if errUpdate := p.updateJobStatus(ctx, job.ID, models.StatusProcessing, 90,
+		fmt.Sprintf("Results uploaded (%d/%d themes)", succeeded, len(job.Themes))); errUpdate != nil {
+		log.Printf("Job %s: update failed: %v", job.ID, errUpdate)
+	}
+
+	return manifest
+}
+
+// buildThemeVariant construit le deck avec un thème et upload le résultat sous dir
+func (p *JobProcessor) buildThemeVariant(ctx context.Context, job *models.GenerationJob, workspace *Workspace,
+	theme, dir string, manifest *models.ResultManifest, themeResult *models.ThemeBuildResult, result *JobResult) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Chaque variante repart d'un dist vide : aucun fichier du thème précédent n'est publié
+	if _, err := workspace.RemoveDirectory(workspace.GetDistPath()); err != nil {
+		return fmt.Errorf("failed to clear previous build output: %w", err)
+	}
+
+	slidevResult, err := p.slidevRunner.BuildWithOptions(ctx, workspace, job, &SlidevBuildOptions{Theme: theme})
+	if slidevResult != nil {
+		result.LogOutput = append(result.LogOutput, slidevResult.Logs...)
+	}
+	if err != nil {
+		return fmt.Errorf("slidev build failed: %w", err)
+	}
+
+	filesBefore, sizeBefore := len(manifest.Files), manifest.TotalSize
+	if err := p.uploadResultFiles(ctx, job, workspace, dir, manifest); err != nil {
+		// Une variante incomplète n'apparaît pas dans le manifeste
+		manifest.Files, manifest.TotalSize = manifest.Files[:filesBefore], sizeBefore
+		return fmt.Errorf("failed to upload results: %w", err)
+	}
+	themeResult.FileCount = len(manifest.Files) - filesBefore
+
+	for _, entryPoint := range slidevResult.EntryPoints {
+		themeResult.EntryPoints = append(themeResult.EntryPoints, path.Join(dir, entryPoint))
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package worker
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSlidev installe sur le PATH un npm et un npx factices et retourne une commande
+// "slidev build" qui écrit un index.html portant le thème demandé (le thème "broken" échoue)
+func fakeSlidev(t *testing.T) string {
+	binDir := t.TempDir()
+
+	npm := "#!/bin/sh\necho \"added 1 package\"\n"
+	npx := "#!/bin/sh\necho \"0.50.0\"\n"
+	slidev := `#!/bin/sh
+theme=""
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --theme) theme="$2"; shift ;;
+  esac
+  shift
+done
+if [ "$theme" = "broken" ]; then
+  echo "theme broken not found" >&2
+  exit 1
+fi
+mkdir -p dist
+printf '<!DOCTYPE html><html><head><title>%s</title></head><body>Deck built with the %s theme, padded to a realistic size.</body></html>' "$theme" "$theme" > dist/index.html
+`
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "npm"), []byte(npm), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "npx"), []byte(npx), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "slidev"), []byte(slidev), 0o755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	return filepath.Join(binDir, "slidev")
+}
+
+func TestProcessJobThemeMatrix(t *testing.T) {
+	slidevCommand := fakeSlidev(t)
+
+	run := func(t *testing.T, themes ...string) (*JobResult, *models.GenerationJob, *storage.StorageService) {
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New(), Themes: themes}
+		jobService := &MockJobService{jobs: map[uuid.UUID]*models.GenerationJob{job.ID: job}}
+		storageService := storage.NewStorageService(&MockStorageBackend{})
+
+		ctx := context.Background()
+		require.NoError(t, storageService.UploadJobSource(ctx, job.ID, "slides.md", strings.NewReader("---\ntheme: default\n---\n# Cours\n")))
+
+		processor := NewJobProcessor(jobService, storageService, &PoolConfig{
+			WorkspaceBase:    t.TempDir(),
+			SlidevCommand:    slidevCommand,
+			VersionCheckMode: VersionCheckOff,
+			CleanupWorkspace: true,
+			JobTimeout:       30 * time.Second,
+		})
+		return processor.ProcessJob(ctx, job), job, storageService
+	}
+
+	t.Run("one variant per theme", func(t *testing.T) {
+		result, job, storageService := run(t, "seriph", "broken", "apple-basic")
+		require.True(t, result.Success, "job error: %v", result.Error)
+		assert.Equal(t, models.StatusCompleted, job.Status)
+
+		require.Len(t, job.ThemeResults, 3)
+		assert.True(t, job.ThemeResults[0].Success)
+		assert.Equal(t, "theme-seriph/", job.ThemeResults[0].Path)
+		assert.Equal(t, []string{"theme-seriph/index.html"}, job.ThemeResults[0].EntryPoints)
+		assert.Equal(t, 1, job.ThemeResults[0].FileCount)
+		assert.False(t, job.ThemeResults[1].Success)
+		assert.Contains(t, job.ThemeResults[1].Error, "slidev build failed")
+		assert.True(t, job.ThemeResults[2].Success)
+
+		assert.Equal(t, []string{"theme-seriph/index.html", "theme-apple-basic/index.html"}, []string(job.EntryPoints))
+
+		results, err := storageService.ListResults(context.Background(), job.CourseID)
+		require.NoError(t, err)
+		sort.Strings(results)
+		assert.Equal(t, []string{"theme-apple-basic/index.html", "theme-seriph/index.html"}, results)
+
+		reader, err := storageService.DownloadResult(context.Background(), job.CourseID, "theme-apple-basic/index.html")
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "apple-basic theme")
+
+		manifest, err := storageService.GetResultManifest(context.Background(), job.CourseID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, manifest.FileCount)
+	})
+
+	t.Run("all themes failed", func(t *testing.T) {
+		result, job, _ := run(t, "broken")
+		assert.False(t, result.Success)
+		assert.Equal(t, models.StatusFailed, job.Status)
+		assert.Contains(t, job.Error, "all 1 theme builds failed")
+		require.Len(t, job.ThemeResults, 1)
+		assert.False(t, job.ThemeResults[0].Success)
+	})
+}
@@ -0,0 +1,156 @@
+// internal/worker/theme_policy.go - Thèmes autorisés dans les builds
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+)
+
+// ErrThemeNotAllowed est retournée quand un job utilise un thème interdit par la politique
+var ErrThemeNotAllowed = errors.New("theme not allowed")
+
+// ThemePolicy restreint les thèmes Slidev installés par les builds. Les thèmes sont comparés
+// par paquet npm : "seriph" et "@slidev/theme-seriph" désignent le même thème. Un thème
+// local (chemin relatif ou absolu) n'est dans aucune liste : il est refusé par une liste
+// de thèmes autorisés, et seulement signalé par une liste de thèmes interdits.
+type ThemePolicy struct {
+	allowed map[string]bool // Vide = tous les thèmes non interdits
+	denied  map[string]bool
+}
+
+// NewThemePolicy crée une politique de thèmes ; retourne nil si aucune liste n'est donnée
+func NewThemePolicy(allowed, denied []string) *ThemePolicy {
+	if len(allowed) == 0 && len(denied) == 0 {
+		return nil
+	}
+
+	return &ThemePolicy{
+		allowed: themePackageSet(allowed),
+		denied:  themePackageSet(denied),
+	}
+}
+
+// themePackageSet indexe des thèmes par paquet npm
+func themePackageSet(themes []string) map[string]bool {
+	set := make(map[string]bool, len(themes))
+	for _, theme := range themes {
+		if theme = strings.TrimSpace(theme); theme != "" {
+			set[ThemePackageName(theme)] = true
+		}
+	}
+	return set
+}
+
+// Check vérifie qu'un thème peut être installé. Une politique nil autorise tout.
+func (tp *ThemePolicy) Check(theme string) error {
+	if tp == nil || theme == "" {
+		return nil
+	}
+
+	if isLocalTheme(theme) {
+		if len(tp.allowed) > 0 {
+			return fmt.Errorf("%w: local theme %s is not in the allowed themes (%s)",
+				ErrThemeNotAllowed, theme, strings.Join(tp.allowedThemes(), ", "))
+		}
+		log.Printf("Theme policy: local theme %s is built from the course sources", theme)
+		return nil
+	}
+
+	themePackage := ThemePackageName(theme)
+	if tp.denied[themePackage] {
+		return fmt.Errorf("%w: %s (%s) is denied by the worker theme policy", ErrThemeNotAllowed, theme, themePackage)
+	}
+	if len(tp.allowed) > 0 && !tp.allowed[themePackage] {
+		return fmt.Errorf("%w: %s (%s) is not in the allowed themes (%s)",
+			ErrThemeNotAllowed, theme, themePackage, strings.Join(tp.allowedThemes(), ", "))
+	}
+	return nil
+}
+
+// CheckPackage vérifie un paquet npm demandé par un job s'il s'agit d'un thème Slidev
+func (tp *ThemePolicy) CheckPackage(npmPackage string) error {
+	name := npmPackageName(npmPackage)
+	if !isThemePackage(name) {
+		return nil
+	}
+	return tp.Check(name)
+}
+
+// allowedThemes retourne les paquets autorisés triés, pour les messages d'erreur
+func (tp *ThemePolicy) allowedThemes() []string {
+	themes := make([]string, 0, len(tp.allowed))
+	for theme := range tp.allowed {
+		themes = append(themes, theme)
+	}
+	sort.Strings(themes)
+	return themes
+}
+
+// isLocalTheme indique si un thème désigne un dossier du cours plutôt qu'un paquet npm
+func isLocalTheme(theme string) bool {
+	return strings.HasPrefix(theme, "./") || strings.HasPrefix(theme, "../") || strings.HasPrefix(theme, "/")
+}
+
+// isThemePackage indique si un paquet npm suit la convention de nommage des thèmes Slidev
+func isThemePackage(name string) bool {
+	if scope, rest, scoped := strings.Cut(name, "/"); scoped && strings.HasPrefix(scope, "@") {
+		return strings.HasPrefix(rest, "theme-") || strings.HasPrefix(rest, "slidev-theme-")
+	}
+	return strings.HasPrefix(name, "slidev-theme-")
+}
+
+// npmPackageName retire la version d'une spécification de paquet (nom@version)
+func npmPackageName(npmPackage string) string {
+	if at := strings.LastIndex(npmPackage, "@"); at > 0 {
+		return npmPackage[:at]
+	}
+	return npmPackage
+}
+
+// checkThemePolicy vérifie les thèmes qu'un build installerait : le thème imposé (ou, à
+// défaut, celui du fichier de slides), les paquets de thèmes demandés par le job et ceux
+// déclarés dans le package.json du cours
+func (sr *SlidevRunner) checkThemePolicy(workspace *Workspace, job *models.GenerationJob, slideFile, forcedTheme string) error {
+	if sr.themePolicy == nil {
+		return nil
+	}
+
+	theme := forcedTheme
+	if theme == "" {
+		reader, err := workspace.ReadFile(slideFile)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", slideFile, err)
+		}
+		if closer, ok := reader.(io.Closer); ok {
+			defer closer.Close()
+		}
+		theme = jobs.DetectTheme(reader)
+	}
+	if err := sr.themePolicy.Check(theme); err != nil {
+		return err
+	}
+
+	for _, npmPackage := range job.NpmPackages {
+		if err := sr.themePolicy.CheckPackage(npmPackage); err != nil {
+			return err
+		}
+	}
+
+	requirements, err := readCourseRequirements(workspace)
+	if err != nil {
+		return err
+	}
+	for _, npmPackage := range requirements.Packages {
+		if err := sr.themePolicy.CheckPackage(npmPackage); err != nil {
+			return fmt.Errorf("package.json: %w", err)
+		}
+	}
+	return nil
+}
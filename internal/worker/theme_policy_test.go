@@ -0,0 +1,154 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThemePolicy(t *testing.T) {
+	t.Run("No policy allows every theme", func(t *testing.T) {
+		policy := NewThemePolicy(nil, nil)
+		assert.Nil(t, policy)
+		assert.NoError(t, policy.Check("anything"))
+	})
+
+	t.Run("Allowlist", func(t *testing.T) {
+		policy := NewThemePolicy([]string{"default", "@slidev/theme-seriph", " penguin "}, nil)
+
+		assert.NoError(t, policy.Check("seriph"))
+		assert.NoError(t, policy.Check("@slidev/theme-default"))
+		assert.NoError(t, policy.Check("slidev-theme-penguin"))
+		assert.ErrorIs(t, policy.Check("./themes/custom"), ErrThemeNotAllowed, "local themes are not in the allowlist")
+
+		err := policy.Check("apple-basic")
+		assert.ErrorIs(t, err, ErrThemeNotAllowed)
+		assert.Contains(t, err.Error(), "@slidev/theme-apple-basic")
+		assert.Contains(t, err.Error(), "@slidev/theme-default, @slidev/theme-seriph, slidev-theme-penguin")
+	})
+
+	t.Run("Denylist", func(t *testing.T) {
+		policy := NewThemePolicy(nil, []string{"slidev-theme-penguin"})
+
+		assert.NoError(t, policy.Check("seriph"))
+		assert.NoError(t, policy.Check("./themes/custom"), "local themes are only flagged")
+		err := policy.Check("penguin")
+		assert.ErrorIs(t, err, ErrThemeNotAllowed)
+		assert.Contains(t, err.Error(), "denied")
+	})
+
+	t.Run("Denylist wins over allowlist", func(t *testing.T) {
+		policy := NewThemePolicy([]string{"seriph"}, []string{"@slidev/theme-seriph"})
+		assert.ErrorIs(t, policy.Check("seriph"), ErrThemeNotAllowed)
+	})
+
+	t.Run("Theme preview", func(t *testing.T) {
+		runner := NewSlidevRunner(&PoolConfig{WorkspaceBase: t.TempDir(), DeniedThemes: []string{"seriph"}})
+		previewer := NewThemePreviewer(runner, storage.NewStorageService(&MockStorageBackend{}))
+
+		_, err := previewer.Preview(context.Background(), "seriph", "", ThemePreviewPNG)
+		assert.ErrorIs(t, err, ErrThemeNotAllowed)
+	})
+
+	t.Run("Theme packages requested by the job", func(t *testing.T) {
+		policy := NewThemePolicy(nil, []string{"penguin", "@acme/theme-corporate"})
+
+		assert.ErrorIs(t, policy.CheckPackage("slidev-theme-penguin@1.2.0"), ErrThemeNotAllowed)
+		assert.ErrorIs(t, policy.CheckPackage("@acme/theme-corporate@^2"), ErrThemeNotAllowed)
+		assert.NoError(t, policy.CheckPackage("penguin"), "not a theme package")
+		assert.NoError(t, policy.CheckPackage("@slidev/cli@0.50.0"))
+	})
+}
+
+func TestProcessJobThemePolicy(t *testing.T) {
+	slidevCommand := fakeSlidev(t)
+
+	run := func(t *testing.T, policy *PoolConfig, job *models.GenerationJob) *JobResult {
+		jobService := &MockJobService{jobs: map[uuid.UUID]*models.GenerationJob{job.ID: job}}
+		storageService := storage.NewStorageService(&MockStorageBackend{})
+
+		ctx := context.Background()
+		require.NoError(t, storageService.UploadJobSource(ctx, job.ID, "slides.md", strings.NewReader("---\ntheme: seriph\n---\n# Cours\n")))
+
+		policy.WorkspaceBase = t.TempDir()
+		policy.SlidevCommand = slidevCommand
+		policy.VersionCheckMode = VersionCheckOff
+		policy.CleanupWorkspace = true
+		policy.JobTimeout = 30 * time.Second
+		return NewJobProcessor(jobService, storageService, policy).ProcessJob(ctx, job)
+	}
+
+	t.Run("Allowed frontmatter theme", func(t *testing.T) {
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New()}
+		result := run(t, &PoolConfig{AllowedThemes: []string{"default", "seriph"}}, job)
+		assert.True(t, result.Success, "job error: %v", result.Error)
+	})
+
+	t.Run("Denied frontmatter theme fails the job", func(t *testing.T) {
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New()}
+		result := run(t, &PoolConfig{DeniedThemes: []string{"seriph"}}, job)
+		require.False(t, result.Success)
+		assert.ErrorIs(t, result.Error, ErrThemeNotAllowed)
+		assert.Equal(t, models.StatusFailed, job.Status)
+		assert.Contains(t, job.Error, "theme not allowed: seriph (@slidev/theme-seriph)")
+	})
+
+	t.Run("Theme package requested by the job", func(t *testing.T) {
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New(), NpmPackages: []string{"slidev-theme-penguin"}}
+		result := run(t, &PoolConfig{AllowedThemes: []string{"seriph"}}, job)
+		require.False(t, result.Success)
+		assert.Contains(t, job.Error, "slidev-theme-penguin")
+	})
+
+	t.Run("Theme package declared in package.json", func(t *testing.T) {
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New()}
+		jobService := &MockJobService{jobs: map[uuid.UUID]*models.GenerationJob{job.ID: job}}
+		storageService := storage.NewStorageService(&MockStorageBackend{})
+
+		ctx := context.Background()
+		require.NoError(t, storageService.UploadJobSource(ctx, job.ID, "slides.md", strings.NewReader("---\ntheme: seriph\n---\n# Cours\n")))
+		require.NoError(t, storageService.UploadJobSource(ctx, job.ID, "package.json",
+			strings.NewReader(`{"devDependencies": {"slidev-theme-penguin": "^1.0.0"}}`)))
+
+		result := NewJobProcessor(jobService, storageService, &PoolConfig{
+			WorkspaceBase:    t.TempDir(),
+			SlidevCommand:    slidevCommand,
+			VersionCheckMode: VersionCheckOff,
+			CleanupWorkspace: true,
+			JobTimeout:       30 * time.Second,
+			AllowedThemes:    []string{"seriph"},
+		}).ProcessJob(ctx, job)
+		require.False(t, result.Success)
+		assert.ErrorIs(t, result.Error, ErrThemeNotAllowed)
+		assert.Contains(t, job.Error, "package.json")
+		assert.Contains(t, job.Error, "slidev-theme-penguin")
+	})
+
+	t.Run("Local theme with an allowlist", func(t *testing.T) {
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New(), Themes: []string{"./themes/custom"}}
+		result := run(t, &PoolConfig{AllowedThemes: []string{"seriph"}}, job)
+		require.False(t, result.Success)
+
+		require.Len(t, job.ThemeResults, 1)
+		assert.False(t, job.ThemeResults[0].Success)
+		assert.Contains(t, job.ThemeResults[0].Error, "local theme ./themes/custom")
+	})
+
+	t.Run("Matrix variants use the forced theme", func(t *testing.T) {
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New(), Themes: []string{"seriph", "apple-basic"}}
+		result := run(t, &PoolConfig{AllowedThemes: []string{"apple-basic"}}, job)
+		require.True(t, result.Success, "job error: %v", result.Error)
+
+		require.Len(t, job.ThemeResults, 2)
+		assert.False(t, job.ThemeResults[0].Success)
+		assert.Contains(t, job.ThemeResults[0].Error, "theme not allowed")
+		assert.True(t, job.ThemeResults[1].Success)
+	})
+}
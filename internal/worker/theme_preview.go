@@ -0,0 +1,281 @@
+// internal/worker/theme_preview.go - Aperçu des thèmes Slidev avant leur adoption
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// Formats d'aperçu de thème
+const (
+	ThemePreviewPNG = "png"
+	ThemePreviewPDF = "pdf"
+)
+
+// themePreviewTimeout borne la résolution, l'installation et l'export d'un aperçu
+const themePreviewTimeout = 5 * time.Minute
+
+// ErrThemeNotFound est retournée quand le paquet ou la version du thème n'existe pas sur npm
+var ErrThemeNotFound = errors.New("theme not found")
+
+// officialThemes sont les thèmes publiés sous @slidev/theme-<nom>
+var officialThemes = map[string]bool{
+	"default": true, "seriph": true, "apple-basic": true, "bricks": true, "shibainu": true,
+}
+
+// ThemePackageName retourne le paquet npm d'un thème selon la convention de Slidev :
+// @slidev/theme-<nom> pour les thèmes officiels, slidev-theme-<nom> sinon
+func ThemePackageName(theme string) string {
+	switch {
+	case strings.HasPrefix(theme, "@"), strings.HasPrefix(theme, "slidev-theme-"):
+		return theme
+	case officialThemes[theme]:
+		return "@slidev/theme-" + theme
+	default:
+		return "slidev-theme-" + theme
+	}
+}
+
+// ThemePreview est l'aperçu de la première slide d'un deck d'exemple construit avec un thème
+type ThemePreview struct {
+	Package string // Paquet npm du thème
+	Version string // Version exacte du thème
+	Format  string // ThemePreviewPNG ou ThemePreviewPDF
+	Data    []byte
+	Cached  bool // Servi depuis le cache, sans build
+}
+
+// ThemePreviewer génère les aperçus de thèmes et les met en cache par version dans le storage.
+// Les aperçus d'une même version demandés simultanément ne sont générés qu'une fois.
+type ThemePreviewer struct {
+	runner         *SlidevRunner
+	storageService *storage.StorageService
+
+	mu       sync.Mutex
+	inflight map[string]chan struct{}
+
+	// resolveVersion et render sont remplacés dans les tests (npm et Slidev indisponibles)
+	resolveVersion func(ctx context.Context, themePackage, version string) (string, error)
+	render         func(ctx context.Context, themePackage, version, format string) ([]byte, error)
+}
+
+// NewThemePreviewer crée un générateur d'aperçus utilisant le runner Slidev donné
+func NewThemePreviewer(runner *SlidevRunner, storageService *storage.StorageService) *ThemePreviewer {
+	previewer := &ThemePreviewer{
+		runner:         runner,
+		storageService: storageService,
+		inflight:       make(map[string]chan struct{}),
+	}
+	previewer.resolveVersion = previewer.resolveNpmVersion
+	previewer.render = previewer.renderPreview
+	return previewer
+}
+
+// Preview retourne l'aperçu d'un thème dans une version exacte ou, version vide, dans sa
+// dernière version publiée. L'aperçu est généré au premier appel puis servi depuis le cache.
+func (tp *ThemePreviewer) Preview(ctx context.Context, theme, version, format string) (*ThemePreview, error) {
+	if err := tp.runner.themePolicy.Check(theme); err != nil {
+		return nil, err
+	}
+
+	themePackage := ThemePackageName(theme)
+	if version == "" {
+		version = "latest"
+	}
+
+	resolved, err := tp.resolveVersion(ctx, themePackage, version)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &ThemePreview{Package: themePackage, Version: resolved, Format: format}
+	key := themePackage + "@" + resolved + "." + format
+
+	for {
+		data, err := tp.storageService.GetThemePreview(ctx, themePackage, resolved, format)
+		if err == nil {
+			preview.Data = data
+			preview.Cached = true
+			return preview, nil
+		}
+		if !errors.Is(err, storage.ErrThemePreviewNotFound) {
+			return nil, fmt.Errorf("failed to read theme preview cache: %w", err)
+		}
+
+		tp.mu.Lock()
+		done, rendering := tp.inflight[key]
+		if !rendering {
+			done = make(chan struct{})
+			tp.inflight[key] = done
+		}
+		tp.mu.Unlock()
+
+		if !rendering {
+			break
+		}
+
+		// Un autre appel génère cet aperçu : relire le cache à la fin de sa génération
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	defer func() {
+		tp.mu.Lock()
+		close(tp.inflight[key])
+		delete(tp.inflight, key)
+		tp.mu.Unlock()
+	}()
+
+	// La génération va à son terme même si le client abandonne : elle alimente le cache
+	renderCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), themePreviewTimeout)
+	defer cancel()
+
+	data, err := tp.render(renderCtx, themePackage, resolved, format)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tp.storageService.SaveThemePreview(renderCtx, themePackage, resolved, format, data); err != nil {
+		log.Printf("Theme preview %s@%s not cached: %v", themePackage, resolved, err)
+	}
+
+	preview.Data = data
+	return preview, nil
+}
+
+// resolveNpmVersion retourne la version exacte publiée d'un thème (npm view)
+func (tp *ThemePreviewer) resolveNpmVersion(ctx context.Context, themePackage, version string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "npm", "view", themePackage+"@"+version, "version")
+	cmd.Env = tp.runner.npmPackageManager.buildInstallEnvironment(nil)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(output), "E404") {
+			return "", fmt.Errorf("%w: %s", ErrThemeNotFound, themePackage)
+		}
+		return "", fmt.Errorf("failed to resolve %s@%s: %w\nOutput: %s", themePackage, version, err, string(output))
+	}
+
+	resolved := strings.TrimSpace(string(output))
+	if resolved == "" {
+		return "", fmt.Errorf("%w: %s@%s", ErrThemeNotFound, themePackage, version)
+	}
+	return resolved, nil
+}
+
+// renderPreview construit un deck d'exemple avec le thème dans un workspace temporaire et
+// exporte sa première slide. Il occupe un créneau de build comme un job.
+func (tp *ThemePreviewer) renderPreview(ctx context.Context, themePackage, version, format string) ([]byte, error) {
+	release, err := tp.runner.buildLimiter.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cancelled while waiting for a build slot: %w", err)
+	}
+	defer release()
+
+	workspace, err := NewWorkspace(tp.runner.workspaceBase, uuid.New())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := workspace.Cleanup(); err != nil {
+			log.Printf("Theme preview %s@%s: %v", themePackage, version, err)
+		}
+	}()
+
+	if err := workspace.WriteFile("package.json", strings.NewReader(themePreviewPackageJSON)); err != nil {
+		return nil, fmt.Errorf("failed to create package.json: %w", err)
+	}
+	slides := fmt.Sprintf(themePreviewSlides, themePackage, themePackage, version)
+	if err := workspace.WriteFile("slides.md", strings.NewReader(slides)); err != nil {
+		return nil, fmt.Errorf("failed to create slides.md: %w", err)
+	}
+
+	// Le thème et le navigateur d'export passent par le cache NPM des builds
+	for _, npmPackage := range []string{themePackage + "@" + version, "playwright-chromium"} {
+		if result, err := tp.runner.npmPackageManager.InstallNpmPackage(ctx, workspace, npmPackage); err != nil {
+			return nil, fmt.Errorf("failed to install %s: %w (%s)", npmPackage, err, result.Error)
+		}
+	}
+
+	output := "preview"
+	if format == ThemePreviewPDF {
+		output = "preview.pdf"
+	}
+	options := &ExportOptions{Format: format, Output: output, Range: "1"}
+	if err := tp.runner.Export(ctx, workspace, "slides.md", options); err != nil {
+		return nil, err
+	}
+
+	exported, err := findExportedFile(workspace, output, format)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(exported)
+}
+
+// findExportedFile retourne le fichier produit par slidev export : le PDF lui-même, ou la
+// première image du répertoire de sortie en PNG
+func findExportedFile(workspace *Workspace, output, format string) (string, error) {
+	path := filepath.Join(workspace.GetPath(), output)
+	if format == ThemePreviewPDF || workspace.FileExists(output+".png") {
+		if format != ThemePreviewPDF {
+			path += ".png"
+		}
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("export produced no %s file: %w", format, err)
+		}
+		return path, nil
+	}
+
+	images, err := filepath.Glob(filepath.Join(path, "*.png"))
+	if err != nil || len(images) == 0 {
+		return "", fmt.Errorf("export produced no png file in %s", output)
+	}
+	sort.Strings(images)
+	return images[0], nil
+}
+
+// themePreviewPackageJSON est le package.json du deck d'exemple
+const themePreviewPackageJSON = `{
+  "name": "ocf-theme-preview",
+  "version": "1.0.0",
+  "private": true,
+  "type": "module"
+}`
+
+// themePreviewSlides est le deck d'exemple : une slide de titre représentative du thème
+const themePreviewSlides = `---
+theme: %s
+title: Theme preview
+---
+
+# Theme preview
+
+%s@%s
+
+---
+
+# Content
+
+- First point
+- Second point
+`
@@ -0,0 +1,101 @@
+// internal/worker/theme_preview_test.go
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage/filesystem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThemePackageName(t *testing.T) {
+	assert.Equal(t, "@slidev/theme-seriph", ThemePackageName("seriph"))
+	assert.Equal(t, "@slidev/theme-default", ThemePackageName("default"))
+	assert.Equal(t, "slidev-theme-penguin", ThemePackageName("penguin"))
+	assert.Equal(t, "slidev-theme-penguin", ThemePackageName("slidev-theme-penguin"))
+	assert.Equal(t, "@org/slidev-theme-ocf", ThemePackageName("@org/slidev-theme-ocf"))
+}
+
+func TestThemePreviewer(t *testing.T) {
+	newPreviewer := func(t *testing.T) (*ThemePreviewer, *atomic.Int32) {
+		backend, err := filesystem.NewFilesystemStorage(t.TempDir())
+		require.NoError(t, err)
+
+		previewer := NewThemePreviewer(NewSlidevRunner(&PoolConfig{WorkspaceBase: t.TempDir()}), storage.NewStorageService(backend))
+		previewer.resolveVersion = func(ctx context.Context, themePackage, version string) (string, error) {
+			if themePackage == "slidev-theme-missing" {
+				return "", fmt.Errorf("%w: %s", ErrThemeNotFound, themePackage)
+			}
+			if version == "latest" {
+				return "2.0.0", nil
+			}
+			return version, nil
+		}
+
+		renders := &atomic.Int32{}
+		previewer.render = func(ctx context.Context, themePackage, version, format string) ([]byte, error) {
+			renders.Add(1)
+			time.Sleep(20 * time.Millisecond)
+			return []byte(themePackage + "@" + version + "." + format), nil
+		}
+		return previewer, renders
+	}
+
+	t.Run("Preview is cached per version", func(t *testing.T) {
+		previewer, renders := newPreviewer(t)
+		ctx := context.Background()
+
+		first, err := previewer.Preview(ctx, "seriph", "", ThemePreviewPNG)
+		require.NoError(t, err)
+		assert.False(t, first.Cached)
+		assert.Equal(t, "@slidev/theme-seriph", first.Package)
+		assert.Equal(t, "2.0.0", first.Version)
+		assert.Equal(t, "@slidev/theme-seriph@2.0.0.png", string(first.Data))
+
+		second, err := previewer.Preview(ctx, "seriph", "2.0.0", ThemePreviewPNG)
+		require.NoError(t, err)
+		assert.True(t, second.Cached)
+		assert.Equal(t, first.Data, second.Data)
+
+		// Autre version ou autre format : nouvel aperçu
+		_, err = previewer.Preview(ctx, "seriph", "1.0.0", ThemePreviewPNG)
+		require.NoError(t, err)
+		_, err = previewer.Preview(ctx, "seriph", "2.0.0", ThemePreviewPDF)
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), renders.Load())
+	})
+
+	t.Run("Concurrent requests render once", func(t *testing.T) {
+		previewer, renders := newPreviewer(t)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				preview, err := previewer.Preview(context.Background(), "bricks", "", ThemePreviewPNG)
+				assert.NoError(t, err)
+				assert.Equal(t, "@slidev/theme-bricks@2.0.0.png", string(preview.Data))
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), renders.Load())
+	})
+
+	t.Run("Unknown theme", func(t *testing.T) {
+		previewer, renders := newPreviewer(t)
+
+		_, err := previewer.Preview(context.Background(), "missing", "", ThemePreviewPNG)
+		assert.ErrorIs(t, err, ErrThemeNotFound)
+		assert.Zero(t, renders.Load())
+	})
+}
@@ -0,0 +1,65 @@
+// internal/worker/thumbnail.go - Miniature de la première slide pour les catalogues de cours
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+)
+
+// ThumbnailFile est le chemin de la miniature dans les résultats d'un cours
+const ThumbnailFile = "thumbnail.png"
+
+// thumbnailExportDir reçoit l'export PNG dans le workspace, hors de dist
+const thumbnailExportDir = ".thumbnail"
+
+// GenerateThumbnail exporte la première slide du deck en PNG dans dist/thumbnail.png, publiée
+// avec les résultats. L'export lance un navigateur : il occupe un créneau de build comme un job.
+func (sr *SlidevRunner) GenerateThumbnail(ctx context.Context, workspace *Workspace, job *models.GenerationJob) ([]string, error) {
+	var logs []string
+
+	release, err := sr.buildLimiter.Acquire(ctx)
+	if err != nil {
+		return logs, fmt.Errorf("cancelled while waiting for a build slot: %w", err)
+	}
+	defer release()
+
+	slideFile, err := resolveSlideFile(workspace, job, sr.config.SlideFiles)
+	if err != nil {
+		return logs, err
+	}
+
+	// slidev export pilote Chromium via Playwright, absent des dépendances d'un deck en général
+	if !workspace.DirExists(filepath.Join("node_modules", "playwright-chromium")) {
+		result, err := sr.npmPackageManager.InstallNpmPackage(ctx, workspace, "playwright-chromium")
+		logs = append(logs, npmInstallLogLines(result)...)
+		if err != nil {
+			return logs, fmt.Errorf("failed to install playwright-chromium: %w", err)
+		}
+	}
+
+	options := &ExportOptions{Format: ThemePreviewPNG, Output: thumbnailExportDir, Range: "1"}
+	if err := sr.Export(ctx, workspace, slideFile, options); err != nil {
+		return logs, err
+	}
+
+	exported, err := findExportedFile(workspace, thumbnailExportDir, ThemePreviewPNG)
+	if err != nil {
+		return logs, err
+	}
+	file, err := os.Open(exported)
+	if err != nil {
+		return logs, fmt.Errorf("failed to read exported thumbnail: %w", err)
+	}
+	defer file.Close()
+
+	if err := workspace.WriteFile(filepath.Join(workspace.GetDistPath(), ThumbnailFile), file); err != nil {
+		return logs, fmt.Errorf("failed to store thumbnail: %w", err)
+	}
+
+	logs = append(logs, fmt.Sprintf("Thumbnail: first slide of %s exported to %s", slideFile, ThumbnailFile))
+	return logs, nil
+}
@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSlidevExport crée un slidev de test qui build dist/index.html et exporte chaque slide
+// demandée en PNG dans le répertoire --output (échec si failExport)
+func fakeSlidevExport(t *testing.T, failExport bool) string {
+	fakeSlidev(t) // npm et npx de test dans le PATH
+
+	script := `#!/bin/sh
+if [ "$1" = "export" ]; then
+  [ "$FAIL_EXPORT" = "1" ] && { echo "browserType.launch: Executable doesn't exist" >&2; exit 1; }
+  output=""
+  while [ $# -gt 0 ]; do
+    case "$1" in
+      --output) output="$2"; shift ;;
+    esac
+    shift
+  done
+  mkdir -p "$output"
+  printf 'PNG slide 1' > "$output/1.png"
+  exit 0
+fi
+mkdir -p dist
+printf '<!DOCTYPE html><html><head><title>Cours</title></head><body>Deck built for the thumbnail test, padded to a realistic size.</body></html>' > dist/index.html
+`
+	if failExport {
+		script = strings.Replace(script, "#!/bin/sh\n", "#!/bin/sh\nFAIL_EXPORT=1\n", 1)
+	}
+
+	path := filepath.Join(t.TempDir(), "slidev")
+	require.NoError(t, os.WriteFile(path, []byte(script), 0o755))
+	return path
+}
+
+func TestProcessJobThumbnail(t *testing.T) {
+	run := func(t *testing.T, failExport bool) (*JobResult, *models.GenerationJob, *storage.StorageService) {
+		enabled := true
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New(), Thumbnail: &enabled}
+		jobService := &MockJobService{jobs: map[uuid.UUID]*models.GenerationJob{job.ID: job}}
+		storageService := storage.NewStorageService(&MockStorageBackend{})
+
+		ctx := context.Background()
+		require.NoError(t, storageService.UploadJobSource(ctx, job.ID, "slides.md", strings.NewReader("# Cours\n")))
+
+		processor := NewJobProcessor(jobService, storageService, &PoolConfig{
+			WorkspaceBase:    t.TempDir(),
+			SlidevCommand:    fakeSlidevExport(t, failExport),
+			VersionCheckMode: VersionCheckOff,
+			CleanupWorkspace: true,
+			JobTimeout:       30 * time.Second,
+		})
+		return processor.ProcessJob(ctx, job), job, storageService
+	}
+
+	t.Run("First slide published with the results", func(t *testing.T) {
+		result, job, storageService := run(t, false)
+		require.True(t, result.Success, "job error: %v", result.Error)
+
+		assert.Equal(t, ThumbnailFile, job.ThumbnailPath)
+		assert.Equal(t, "/api/v1/storage/courses/"+job.CourseID.String()+"/results/thumbnail.png", job.ToResponse().ThumbnailURL)
+
+		reader, err := storageService.DownloadResult(context.Background(), job.CourseID, ThumbnailFile)
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "PNG slide 1", string(data))
+	})
+
+	t.Run("Export failure does not fail the job", func(t *testing.T) {
+		result, job, _ := run(t, true)
+		require.True(t, result.Success, "job error: %v", result.Error)
+
+		assert.Empty(t, job.ThumbnailPath)
+		assert.Empty(t, job.ToResponse().ThumbnailURL)
+		assert.Contains(t, strings.Join(result.LogOutput, "\n"), "WARNING: Thumbnail not generated: PNG export failed")
+	})
+}
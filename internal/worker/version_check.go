@@ -0,0 +1,303 @@
+// internal/worker/version_check.go - Vérification des versions Node/Slidev exigées par un cours
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Modes de vérification des versions exigées par le package.json du cours
+const (
+	// VersionCheckOff désactive la vérification
+	VersionCheckOff = "off"
+	// VersionCheckWarn signale les incompatibilités dans les logs du job sans bloquer le build
+	VersionCheckWarn = "warn"
+	// VersionCheckStrict fait échouer le job avant le build en cas d'incompatibilité
+	VersionCheckStrict = "strict"
+)
+
+// slidevPackage est le paquet dont la version installée est comparée à celle du cours
+const slidevPackage = "@slidev/cli"
+
+// installedVersionPattern extrait une version x.y.z d'une sortie de commande (v20.11.0, @slidev/cli/0.48.0...)
+var installedVersionPattern = regexp.MustCompile(`(\d+)\.(\d+)\.(\d+)`)
+
+// courseRequirements contient les versions déclarées dans le package.json d'un cours
+type courseRequirements struct {
+	Node   string // engines.node
+	Slidev string // version de @slidev/cli dans dependencies ou devDependencies
+
+	Packages []string // paquets de dependencies et devDependencies, triés
+}
+
+// readCourseRequirements lit les exigences de version du package.json du workspace
+func readCourseRequirements(workspace *Workspace) (*courseRequirements, error) {
+	if !workspace.FileExists("package.json") {
+		return &courseRequirements{}, nil
+	}
+
+	reader, err := workspace.ReadFile("package.json")
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	var manifest struct {
+		Engines         map[string]string `json:"engines"`
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.NewDecoder(reader).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid package.json: %w", err)
+	}
+
+	requirements := &courseRequirements{Node: manifest.Engines["node"]}
+	if constraint, ok := manifest.Dependencies[slidevPackage]; ok {
+		requirements.Slidev = constraint
+	} else {
+		requirements.Slidev = manifest.DevDependencies[slidevPackage]
+	}
+
+	for _, dependencies := range []map[string]string{manifest.Dependencies, manifest.DevDependencies} {
+		for name := range dependencies {
+			requirements.Packages = append(requirements.Packages, name)
+		}
+	}
+	sort.Strings(requirements.Packages)
+
+	return requirements, nil
+}
+
+// check compare les versions installées aux exigences du cours et retourne un message
+// par incompatibilité. Une version installée vide ou une contrainte non reconnue
+// (tag npm, chemin, URL git) n'est pas vérifiée.
+func (r *courseRequirements) check(nodeVersion, slidevVersion string) []string {
+	var problems []string
+
+	if satisfied, ok := satisfiesConstraint(nodeVersion, r.Node); ok && !satisfied {
+		problems = append(problems, fmt.Sprintf(
+			"course requires Node %s (package.json engines.node) but the worker runs Node %s: relax engines.node or use a worker image with a compatible Node",
+			r.Node, nodeVersion))
+	}
+
+	if satisfied, ok := satisfiesConstraint(slidevVersion, r.Slidev); ok && !satisfied {
+		problems = append(problems, fmt.Sprintf(
+			"course requires %s %s (package.json dependencies) but the worker provides %s: align the course dependency with the worker or update the worker's Slidev",
+			slidevPackage, r.Slidev, slidevVersion))
+	}
+
+	return problems
+}
+
+// checkVersionRequirements vérifie les versions de Node et Slidev exigées par le cours.
+// Les versions installées ne sont lues que si le cours déclare une contrainte.
+func (sr *SlidevRunner) checkVersionRequirements(ctx context.Context, workspace *Workspace) ([]string, error) {
+	requirements, err := readCourseRequirements(workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodeVersion, slidevVersion string
+	if requirements.Node != "" {
+		if nodeVersion, err = sr.GetNodeVersion(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if requirements.Slidev != "" {
+		if slidevVersion, err = sr.GetSlidevVersion(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return requirements.check(installedVersion(nodeVersion), installedVersion(slidevVersion)), nil
+}
+
+// GetNodeVersion retourne la version de Node.js installée
+func (sr *SlidevRunner) GetNodeVersion(ctx context.Context) (string, error) {
+	output, err := exec.CommandContext(ctx, "node", "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get Node.js version: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// installedVersion normalise la sortie d'une commande --version en x.y.z
+func installedVersion(output string) string {
+	return installedVersionPattern.FindString(output)
+}
+
+// semver est une version majeure.mineure.patch
+type semver [3]int
+
+func (v semver) compare(other semver) int {
+	for i := range v {
+		if v[i] != other[i] {
+			if v[i] < other[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// satisfiesConstraint indique si une version respecte une contrainte npm (^, ~, >=, <,
+// x-ranges, intervalles "a - b" et alternatives "||"). ok est faux si la version est
+// vide ou si la contrainte n'est pas une plage de versions reconnue.
+func satisfiesConstraint(version, constraint string) (satisfied bool, ok bool) {
+	installed, parts, valid := parsePartialVersion(version)
+	if !valid || parts < 3 || strings.TrimSpace(constraint) == "" {
+		return false, false
+	}
+
+	for _, alternative := range strings.Split(constraint, "||") {
+		matches, valid := matchRange(installed, strings.TrimSpace(alternative))
+		if !valid {
+			return false, false
+		}
+		if matches {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// matchRange évalue une plage sans alternative : comparateurs séparés par des espaces
+func matchRange(v semver, rangeExpr string) (bool, bool) {
+	if low, high, found := strings.Cut(rangeExpr, " - "); found {
+		return matchRange(v, ">="+strings.TrimSpace(low)+" <="+strings.TrimSpace(high))
+	}
+
+	// Autoriser un espace entre l'opérateur et la version (">= 18")
+	fields := strings.Fields(rangeExpr)
+	var comparators []string
+	for i := 0; i < len(fields); i++ {
+		if strings.Trim(fields[i], "<>=^~") == "" && i+1 < len(fields) {
+			comparators = append(comparators, fields[i]+fields[i+1])
+			i++
+			continue
+		}
+		comparators = append(comparators, fields[i])
+	}
+
+	for _, comparator := range comparators {
+		matches, valid := matchComparator(v, comparator)
+		if !valid {
+			return false, false
+		}
+		if !matches {
+			return false, true
+		}
+	}
+	return true, true
+}
+
+// matchComparator évalue un comparateur unique (ex: ^1.2.0, >=18, 0.48.x)
+func matchComparator(v semver, comparator string) (bool, bool) {
+	operator := ""
+	for _, candidate := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(comparator, candidate) {
+			operator = candidate
+			break
+		}
+	}
+
+	target, parts, valid := parsePartialVersion(strings.TrimPrefix(comparator, operator))
+	if !valid {
+		return false, false
+	}
+	if parts == 0 {
+		// "*", "x" ou vide : toute version
+		return true, true
+	}
+
+	// upper est la première version exclue par la partie fixée de la cible (1.2 → 1.3.0)
+	upper := target
+	upper[parts-1]++
+	for i := parts; i < 3; i++ {
+		upper[i] = 0
+	}
+
+	switch operator {
+	case ">=":
+		return v.compare(target) >= 0, true
+	case ">":
+		if parts < 3 {
+			return v.compare(upper) >= 0, true
+		}
+		return v.compare(target) > 0, true
+	case "<":
+		return v.compare(target) < 0, true
+	case "<=":
+		if parts < 3 {
+			return v.compare(upper) < 0, true
+		}
+		return v.compare(target) <= 0, true
+	case "^":
+		// Le premier segment non nul est fixé (^0.48.1 → <0.49.0)
+		caret := semver{}
+		switch {
+		case target[0] > 0 || parts == 1:
+			caret[0] = target[0] + 1
+		case target[1] > 0 || parts == 2:
+			caret[1] = target[1] + 1
+		default:
+			caret[2] = target[2] + 1
+		}
+		return v.compare(target) >= 0 && v.compare(caret) < 0, true
+	case "~":
+		tilde := semver{target[0] + 1, 0, 0}
+		if parts > 1 {
+			tilde = semver{target[0], target[1] + 1, 0}
+		}
+		return v.compare(target) >= 0 && v.compare(tilde) < 0, true
+	default:
+		// Version exacte, ou x-range si partielle (18 ou 18.x → >=18.0.0 <19.0.0)
+		if parts == 3 {
+			return v.compare(target) == 0, true
+		}
+		return v.compare(target) >= 0 && v.compare(upper) < 0, true
+	}
+}
+
+// parsePartialVersion analyse une version éventuellement partielle (1, 1.2, 1.x, v1.2.3-beta.1).
+// parts est le nombre de segments fixés avant le premier joker.
+func parsePartialVersion(s string) (semver, int, bool) {
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "="), "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+
+	var v semver
+	if s == "" || s == "*" || s == "x" || s == "X" {
+		return v, 0, true
+	}
+
+	segments := strings.Split(s, ".")
+	if len(segments) > 3 {
+		return v, 0, false
+	}
+
+	parts := 0
+	for i, segment := range segments {
+		if segment == "x" || segment == "X" || segment == "*" {
+			break
+		}
+		n, err := strconv.Atoi(segment)
+		if err != nil || n < 0 {
+			return v, 0, false
+		}
+		v[i] = n
+		parts++
+	}
+	return v, parts, true
+}
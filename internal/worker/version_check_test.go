@@ -0,0 +1,100 @@
+// internal/worker/version_check_test.go
+package worker
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSatisfiesConstraint(t *testing.T) {
+	tests := []struct {
+		version    string
+		constraint string
+		satisfied  bool
+	}{
+		{"20.11.0", ">=18", true},
+		{"16.20.2", ">=18", false},
+		{"20.11.0", ">= 18.0.0 <21", true},
+		{"21.0.0", ">=18.0.0 <21", false},
+		{"20.11.0", "^18.0.0 || ^20.0.0", true},
+		{"19.0.0", "^18.0.0 || ^20.0.0", false},
+		{"0.48.9", "^0.48.0", true},
+		{"0.49.0", "^0.48.0", false},
+		{"0.0.4", "^0.0.3", false},
+		{"1.2.9", "~1.2.3", true},
+		{"1.3.0", "~1.2.3", false},
+		{"1.9.0", "~1", true},
+		{"20.1.0", "20.x", true},
+		{"21.0.0", "20", false},
+		{"0.48.0", "0.48.0", true},
+		{"0.48.1", "=0.48.0", false},
+		{"18.5.0", "18 - 20", true},
+		{"21.0.0", "18 - 20", false},
+		{"20.0.0", ">19", true},
+		{"19.9.0", ">19", false},
+		{"19.9.0", "<=19", true},
+		{"0.48.0", "*", true},
+		{"0.48.0-beta.1", "^0.48.0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version+" "+tt.constraint, func(t *testing.T) {
+			satisfied, ok := satisfiesConstraint(tt.version, tt.constraint)
+			require.True(t, ok)
+			assert.Equal(t, tt.satisfied, satisfied)
+		})
+	}
+
+	t.Run("Unrecognized constraints are not checked", func(t *testing.T) {
+		for _, constraint := range []string{"latest", "file:../cli", "github:slidevjs/slidev", "workspace:*", ""} {
+			_, ok := satisfiesConstraint("0.48.0", constraint)
+			assert.False(t, ok, constraint)
+		}
+		_, ok := satisfiesConstraint("", ">=18")
+		assert.False(t, ok)
+	})
+}
+
+func TestCourseRequirements(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "ocf-worker-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	workspace, err := NewWorkspace(tempDir, uuid.New())
+	require.NoError(t, err)
+
+	t.Run("No package.json", func(t *testing.T) {
+		requirements, err := readCourseRequirements(workspace)
+		require.NoError(t, err)
+		assert.Empty(t, requirements.check("20.11.0", "0.48.0"))
+	})
+
+	t.Run("Engines and Slidev dependency", func(t *testing.T) {
+		require.NoError(t, workspace.WriteFile("package.json", strings.NewReader(
+			`{"engines":{"node":">=22"},"devDependencies":{"@slidev/cli":"^51.0.0"}}`)))
+
+		requirements, err := readCourseRequirements(workspace)
+		require.NoError(t, err)
+		assert.Equal(t, ">=22", requirements.Node)
+		assert.Equal(t, "^51.0.0", requirements.Slidev)
+
+		problems := requirements.check(installedVersion("v20.11.0"), installedVersion("@slidev/cli/0.48.0 linux-x64"))
+		require.Len(t, problems, 2)
+		assert.Contains(t, problems[0], "course requires Node >=22 (package.json engines.node) but the worker runs Node 20.11.0")
+		assert.Contains(t, problems[1], "course requires @slidev/cli ^51.0.0 (package.json dependencies) but the worker provides 0.48.0")
+
+		assert.Empty(t, requirements.check("22.1.0", "51.2.0"))
+	})
+
+	t.Run("Invalid package.json", func(t *testing.T) {
+		require.NoError(t, workspace.WriteFile("package.json", strings.NewReader(`{"engines":`)))
+		_, err := readCourseRequirements(workspace)
+		assert.Error(t, err)
+	})
+
+}
@@ -3,9 +3,16 @@ package worker
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"mime"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -25,6 +32,13 @@ type Worker struct {
 	storageService *storage.StorageService
 	config         *PoolConfig
 	processor      *JobProcessor
+	callbacks      *jobs.CallbackQueue
+	onClaimed      func(jobID uuid.UUID) // Appelé quand un job sorti de la file est réservé
+	onStarted      func(jobID uuid.UUID) // Appelé quand la réservation a réussi, avant le traitement
+
+	// waitMaintenanceEnd bloque avant la réservation d'un job tant que l'instance est en
+	// maintenance ; false si le pool s'arrête entre-temps
+	waitMaintenanceEnd func(ctx context.Context) bool
 
 	// État du worker - protégé par mutex
 	mu           sync.RWMutex
@@ -74,19 +88,46 @@ func (w *Worker) getState() (string, uuid.UUID) {
 
 // processJob traite un job individuel - VERSION CORRIGÉE
 func (w *Worker) processJob(ctx context.Context, job *models.GenerationJob) {
+	// Réserver le job : il a pu être mis en file deux fois ou pris par une autre instance
+	claimed, err := w.jobService.ClaimJob(ctx, job.ID)
+	if w.onClaimed != nil {
+		w.onClaimed(job.ID)
+	}
+	if err != nil {
+		log.Printf("Worker %d: failed to claim job %s, it will be retried: %v", w.id, job.ID, err)
+		return
+	}
+	if !claimed {
+		log.Printf("Worker %d: job %s is no longer pending, skipping", w.id, job.ID)
+		return
+	}
+	if w.onStarted != nil {
+		w.onStarted(job.ID)
+	}
+	if w.callbacks != nil {
+		w.callbacks.NotifyStarted(job)
+	}
+
 	// Mise à jour atomique de l'état
 	w.setState("busy", job.ID)
 	atomic.AddInt64(&w.jobsTotal, 1)
 
 	log.Printf("Worker %d processing job %s (course: %s)", w.id, job.ID, job.CourseID)
 
+	// Ouvrir le flux de logs en direct, fermé par Complete à la fin du traitement
+	w.processor.slidevRunner.logStreams.Open(job.ID)
+
 	// Créer un contexte avec timeout pour le job
 	jobCtx, cancel := context.WithTimeout(ctx, w.config.JobTimeout)
 	defer cancel()
 
 	// Traiter le job
+	attemptStart := time.Now()
 	result := w.processor.ProcessJob(jobCtx, job)
 
+	// Fermer le flux de logs en direct, le statut final est déjà enregistré
+	w.processor.slidevRunner.logStreams.Complete(job.ID)
+
 	// Mettre à jour les statistiques
 	if result.Success {
 		atomic.AddInt64(&w.jobsSuccess, 1)
@@ -96,10 +137,50 @@ func (w *Worker) processJob(ctx context.Context, job *models.GenerationJob) {
 		log.Printf("Worker %d failed job %s: %v", w.id, job.ID, result.Error)
 	}
 
+	// Ajouter la tentative à l'historique du job avant de notifier le client
+	w.recordAttempt(ctx, job, attemptStart, result)
+
+	// Mettre en file le callback du client s'il est configuré
+	if w.callbacks != nil && job.CallbackURL != "" {
+		if err := w.callbacks.Enqueue(ctx, job.ID); err != nil {
+			log.Printf("Worker %d: failed to enqueue callback for job %s: %v", w.id, job.ID, err)
+		}
+	}
+
 	// Nettoyer l'état du worker - atomique
 	w.setState("idle", uuid.Nil)
 }
 
+// recordAttempt enregistre la tentative dans l'historique du job, avec le statut final
+// enregistré par le processeur (le résultat du traitement sinon)
+func (w *Worker) recordAttempt(ctx context.Context, job *models.GenerationJob, startedAt time.Time, result *JobResult) {
+	completedAt := time.Now()
+	attempt := models.JobAttempt{
+		Status:      models.StatusCompleted,
+		StartedAt:   startedAt,
+		CompletedAt: completedAt,
+		DurationMs:  completedAt.Sub(startedAt).Milliseconds(),
+	}
+	if !result.Success {
+		attempt.Status = models.StatusFailed
+		if result.Error != nil {
+			attempt.Error = result.Error.Error()
+		}
+	}
+
+	if finalJob, err := w.jobService.GetJob(ctx, job.ID); err == nil && finalJob.Status != models.StatusProcessing {
+		attempt.Status = finalJob.Status
+		// Un job terminé garde son dernier message de progression dans Error
+		if finalJob.Status != models.StatusCompleted && finalJob.Error != "" {
+			attempt.Error = finalJob.Error
+		}
+	}
+
+	if err := w.jobService.RecordJobAttempt(ctx, job.ID, attempt); err != nil {
+		log.Printf("Worker %d: failed to record attempt for job %s: %v", w.id, job.ID, err)
+	}
+}
+
 // GetStats retourne les statistiques du worker - VERSION CORRIGÉE
 func (w *Worker) GetStats() WorkerStatsInternal {
 	// Récupérer l'état de manière thread-safe
@@ -136,6 +217,14 @@ func (w *Worker) Start(ctx context.Context, jobQueue <-chan *models.GenerationJo
 				return
 			}
 
+			// En maintenance, le job sorti de la file n'est pas réservé : il reste pending
+			if w.waitMaintenanceEnd != nil && !w.waitMaintenanceEnd(ctx) {
+				if w.onClaimed != nil {
+					w.onClaimed(job.ID)
+				}
+				continue
+			}
+
 			w.processJob(ctx, job)
 		}
 	}
@@ -157,6 +246,13 @@ type JobResult struct {
 	Duration  time.Duration
 	Progress  int
 	LogOutput []string
+
+	// Preparation décrit ce que la préparation de l'environnement Slidev a trouvé ou créé
+	Preparation *SlidevPreparation
+
+	// BuildWarnings sont les avertissements du build Slidev (code de sortie non nul accepté),
+	// enregistrés avec ceux de taille du deck
+	BuildWarnings []string
 }
 
 // JobProcessor traite les jobs de génération
@@ -165,6 +261,9 @@ type JobProcessor struct {
 	storageService *storage.StorageService
 	config         *PoolConfig
 	slidevRunner   *SlidevRunner
+
+	// workspaceBase est le répertoire des workspaces des jobs traités (défaut config.WorkspaceBase)
+	workspaceBase string
 }
 
 // NewJobProcessor crée un nouveau processeur de jobs
@@ -178,9 +277,16 @@ func NewJobProcessor(
 		storageService: storageService,
 		config:         config,
 		slidevRunner:   NewSlidevRunner(config),
+		workspaceBase:  config.WorkspaceBase,
 	}
 }
 
+// setWorkspaceBase assigne au processeur le répertoire de base des workspaces de ses jobs
+func (p *JobProcessor) setWorkspaceBase(base string) {
+	p.workspaceBase = base
+	p.slidevRunner.setWorkspaceBase(base)
+}
+
 // ProcessJob traite un job de génération complet avec debug amélioré
 func (p *JobProcessor) ProcessJob(ctx context.Context, job *models.GenerationJob) *JobResult {
 	startTime := time.Now()
@@ -189,8 +295,33 @@ func (p *JobProcessor) ProcessJob(ctx context.Context, job *models.GenerationJob
 		Progress: 0,
 	}
 
+	// Options effectives : le profil de génération du cours complète la requête
+	job, applied := p.resolveGenerationProfile(ctx, job)
+	if len(applied) > 0 {
+		log.Printf("Job %s: generation profile of course %s applied (%s)", job.ID, job.CourseID, strings.Join(applied, ", "))
+		result.LogOutput = append(result.LogOutput,
+			fmt.Sprintf("Generation profile applied: %s", strings.Join(applied, ", ")))
+
+		// Le job expose les options réellement utilisées par le build
+		if err := p.jobService.SetJobOptions(ctx, job.ID, job); err != nil {
+			log.Printf("Job %s: failed to record effective build options: %v", job.ID, err)
+		}
+	}
+
+	// Fichiers du job dans le namespace de son client, comme à l'upload
+	if p.config.StorageNamespacePerClient {
+		if namespace := storage.ClientNamespace(job.ClientID); namespace != "" {
+			ctx = storage.WithNamespace(ctx, namespace)
+		}
+	}
+
+	// Résultats écrits à la destination demandée par le job
+	if job.ResultPrefix != "" {
+		ctx = storage.WithResultPrefix(ctx, job.ResultPrefix)
+	}
+
 	// Créer un workspace isolé pour ce job
-	workspace, err := NewWorkspace(p.config.WorkspaceBase, job.ID)
+	workspace, err := NewWorkspace(p.workspaceBase, job.ID)
 	if err != nil {
 		result.Error = fmt.Errorf("failed to create workspace: %w", err)
 		if errUpdate := p.updateJobStatus(ctx, job.ID, models.StatusFailed, 0, result.Error.Error()); errUpdate != nil {
@@ -208,6 +339,15 @@ func (p *JobProcessor) ProcessJob(ctx context.Context, job *models.GenerationJob
 		}()
 	}
 
+	// Dossier de sortie demandé par le job, revérifié avant de le passer à Slidev
+	if err := workspace.SetOutputDir(job.OutputDir); err != nil {
+		result.Error = err
+		if errUpdate := p.updateJobStatus(ctx, job.ID, models.StatusFailed, 0, result.Error.Error()); errUpdate != nil {
+			log.Printf("Job %s: update failed: %v", job.ID, errUpdate)
+		}
+		return result
+	}
+
 	// Marquer le job comme en cours de traitement
 	if err := p.updateJobStatus(ctx, job.ID, models.StatusProcessing, 10, ""); err != nil {
 		result.Error = fmt.Errorf("failed to update job status: %w", err)
@@ -216,7 +356,8 @@ func (p *JobProcessor) ProcessJob(ctx context.Context, job *models.GenerationJob
 
 	// Étape 1: Télécharger les sources
 	log.Printf("Job %s: Downloading sources", job.ID)
-	if err := p.downloadSources(ctx, job, workspace); err != nil {
+	buildStats, err := p.downloadSources(ctx, job, workspace)
+	if err != nil {
 		result.Error = fmt.Errorf("failed to download sources: %w", err)
 		if errUpdate := p.updateJobStatus(ctx, job.ID, models.StatusFailed, 20, result.Error.Error()); errUpdate != nil {
 			log.Printf("Job %s: update failed: %v", job.ID, errUpdate)
@@ -231,16 +372,109 @@ func (p *JobProcessor) ProcessJob(ctx context.Context, job *models.GenerationJob
 
 	// Étape 2: Préparer l'environnement Slidev
 	log.Printf("Job %s: Preparing Slidev environment", job.ID)
-	if err := p.prepareSlidevEnvironment(ctx, job, workspace); err != nil {
+	preparation, err := p.prepareSlidevEnvironment(ctx, job, workspace)
+	if err != nil {
 		log.Printf("Job %s: Slidev preparation failed (non-fatal): %v", job.ID, err)
 	}
+	result.Preparation = preparation
+	result.LogOutput = append(result.LogOutput, preparation.LogLines()...)
+	if err := p.jobService.SetJobPreparation(ctx, job.ID, preparation.toModel()); err != nil {
+		log.Printf("Job %s: failed to record preparation: %v", job.ID, err)
+	}
+	if preparation.SlideFileCreated {
+		// Visible dans le statut du job : le deck construit n'est pas celui de l'utilisateur
+		if err := p.jobService.AddJobLog(ctx, job.ID, preparation.slideFileLogLine()); err != nil {
+			log.Printf("Job %s: failed to add preparation log: %v", job.ID, err)
+		}
+	}
+
+	// Nombre de slides, comparé au seuil d'avertissement une fois le build terminé
+	buildStats.SlideCount = p.countDeckSlides(job, workspace)
+	log.Printf("Job %s: Deck has %d slides", job.ID, buildStats.SlideCount)
 
 	if errUpdate := p.updateJobStatus(ctx, job.ID, models.StatusProcessing, 40, "Environment prepared"); errUpdate != nil {
 		log.Printf("Job %s: update failed: %v", job.ID, errUpdate)
 	}
 
-	//
+	// Étapes 3 et 4 : build et upload, une fois par thème en mode matrice
+	var manifest *models.ResultManifest
+	if len(job.Themes) > 0 {
+		manifest = p.buildThemeMatrix(ctx, job, workspace, result)
+	} else {
+		manifest = p.buildResults(ctx, job, workspace, result)
+	}
+	if manifest == nil {
+		return result
+	}
+
+	// Un rebuild forcé ne laisse que les fichiers du nouveau build
+	if job.ForceRebuild {
+		if err := p.pruneStaleResults(ctx, job, manifest); err != nil {
+			log.Printf("Job %s: failed to remove stale results: %v", job.ID, err)
+		}
+	}
+
+	// Enregistrer les caractéristiques du build pour estimer les prochains
+	buildStats.ResultSizeBytes = manifest.TotalSize
+	buildStats.Theme = p.detectTheme(workspace, job)
+	buildStats.Warnings = append(p.deckSizeWarnings(buildStats), result.BuildWarnings...)
+	if preparation.SlideFileCreated {
+		// Un build réussi n'a pas de diagnostic : le deck de remplacement est signalé ici
+		buildStats.Warnings = append(buildStats.Warnings, fmt.Sprintf(
+			"Sources contain no slide file: the build is the placeholder %s created by the worker", preparation.SlideFile))
+	}
+	for _, warning := range buildStats.Warnings {
+		log.Printf("Job %s: WARNING: %s", job.ID, warning)
+		result.LogOutput = append(result.LogOutput, "WARNING: "+warning)
+	}
+	if err := p.jobService.SetJobBuildStats(ctx, job.ID, buildStats); err != nil {
+		log.Printf("Job %s: failed to record build stats: %v", job.ID, err)
+	}
+
+	// Étape 5: Sauvegarder les logs
+	if len(result.LogOutput) > 0 {
+		if err := p.saveJobLogs(ctx, job.ID, result.LogOutput); err != nil {
+			log.Printf("Failed to save logs for job %s: %v", job.ID, err)
+		}
+	}
+
+	// Marquer le job comme terminé
+	if err := p.updateJobStatus(ctx, job.ID, models.StatusCompleted, 100, ""); err != nil {
+		log.Printf("Failed to update final job status for %s: %v", job.ID, err)
+	}
+
+	// Les sources ne sont supprimées qu'une fois le job terminé : un échec antérieur les conserve
+	if p.sourceRetention(job) == models.SourceRetentionDeleteOnSuccess {
+		deleted, err := p.storageService.DeleteJobSources(ctx, job.ID)
+		if err != nil {
+			log.Printf("Job %s: failed to delete sources: %v", job.ID, err)
+		} else {
+			log.Printf("Job %s: deleted %d source files after successful build", job.ID, deleted)
+		}
+	}
+
+	result.Success = true
+	result.Progress = 100
+	result.Duration = time.Since(startTime)
 
+	log.Printf("Job %s completed successfully in %v", job.ID, result.Duration)
+	return result
+}
+
+// sourceRetention retourne le sort des sources d'un job : son choix, sinon celui de l'instance
+func (p *JobProcessor) sourceRetention(job *models.GenerationJob) models.SourceRetention {
+	if job.SourceRetention != "" {
+		return job.SourceRetention
+	}
+	if p.config.SourceRetention != "" {
+		return p.config.SourceRetention
+	}
+	return models.SourceRetentionKeep
+}
+
+// buildResults construit le deck et publie ses résultats ; retourne nil en cas d'échec
+// (statut du job déjà mis à jour)
+func (p *JobProcessor) buildResults(ctx context.Context, job *models.GenerationJob, workspace *Workspace, result *JobResult) *models.ResultManifest {
 	// Étape 3: Exécuter Slidev build
 	log.Printf("Job %s: Running Slidev build", job.ID)
 	slidevResult, err := p.slidevRunner.Build(ctx, workspace, job)
@@ -260,18 +494,44 @@ func (p *JobProcessor) ProcessJob(ctx context.Context, job *models.GenerationJob
 
 		// Debug: lister le contenu du workspace
 		p.debugWorkspaceContents(workspace, job.ID)
-		return result
+		return nil
 	}
 
-	if errUpdate := p.updateJobStatus(ctx, job.ID, models.StatusProcessing, 70, "Slidev build completed"); errUpdate != nil {
+	buildMessage := "Slidev build completed"
+	if slidevResult.WithWarnings {
+		buildMessage = fmt.Sprintf("Slidev build completed with warnings (exit code %d)", slidevResult.ExitCode)
+		result.BuildWarnings = append(result.BuildWarnings, fmt.Sprintf(
+			"Slidev build exited with code %d: its output was validated and published (SLIDEV_NONZERO_EXIT_MODE=validate-output)",
+			slidevResult.ExitCode))
+	}
+	if errUpdate := p.updateJobStatus(ctx, job.ID, models.StatusProcessing, 70, buildMessage); errUpdate != nil {
 		log.Printf("Job %s: update failed: %v", job.ID, errUpdate)
 	}
 	result.Progress = 70
 	result.LogOutput = append(result.LogOutput, slidevResult.Logs...)
 
+	// Enregistrer les points d'entrée HTML pour que l'UI puisse lier chacun
+	if err := p.jobService.SetJobEntryPoints(ctx, job.ID, slidevResult.EntryPoints); err != nil {
+		log.Printf("Job %s: failed to record entry points: %v", job.ID, err)
+	}
+
+	// Miniature de la première slide, publiée avec les résultats ; son échec ne fait pas échouer le job
+	thumbnail := false
+	if models.BoolValue(job.Thumbnail) {
+		thumbnailLogs, err := p.slidevRunner.GenerateThumbnail(ctx, workspace, job)
+		result.LogOutput = append(result.LogOutput, thumbnailLogs...)
+		if err != nil {
+			log.Printf("Job %s: thumbnail not generated: %v", job.ID, err)
+			result.LogOutput = append(result.LogOutput, fmt.Sprintf("WARNING: Thumbnail not generated: %v", err))
+		} else {
+			thumbnail = true
+		}
+	}
+
 	// Étape 4: Upload des résultats
 	log.Printf("Job %s: Uploading results", job.ID)
-	if err := p.uploadResults(ctx, job, workspace); err != nil {
+	manifest, err := p.uploadResults(ctx, job, workspace)
+	if err != nil {
 		result.Error = fmt.Errorf("failed to upload results: %w", err)
 		if errUpdate := p.updateJobStatus(ctx, job.ID, models.StatusFailed, 80, result.Error.Error()); errUpdate != nil {
 			log.Printf("Job %s: update failed: %v", job.ID, errUpdate)
@@ -279,35 +539,65 @@ func (p *JobProcessor) ProcessJob(ctx context.Context, job *models.GenerationJob
 
 		// Debug: lister le contenu du workspace
 		p.debugWorkspaceContents(workspace, job.ID)
-		return result
+		return nil
 	}
 
 	if errUpdate := p.updateJobStatus(ctx, job.ID, models.StatusProcessing, 90, "Results uploaded"); errUpdate != nil {
 		log.Printf("Job %s: update failed: %v", job.ID, errUpdate)
 	}
 
-	// Étape 5: Sauvegarder les logs
-	if len(result.LogOutput) > 0 {
-		if err := p.saveJobLogs(ctx, job.ID, result.LogOutput); err != nil {
-			log.Printf("Failed to save logs for job %s: %v", job.ID, err)
+	if thumbnail {
+		if err := p.jobService.SetJobThumbnail(ctx, job.ID, ThumbnailFile); err != nil {
+			log.Printf("Job %s: failed to record thumbnail: %v", job.ID, err)
 		}
 	}
 
-	// Marquer le job comme terminé
-	if err := p.updateJobStatus(ctx, job.ID, models.StatusCompleted, 100, ""); err != nil {
-		log.Printf("Failed to update final job status for %s: %v", job.ID, err)
+	return manifest
+}
+
+// SlidevPreparation est le résultat de la préparation de l'environnement Slidev : fichiers
+// trouvés dans les sources et fichiers créés par défaut par le worker
+type SlidevPreparation struct {
+	PackageJSONCreated bool   // package.json absent des sources, créé par le worker
+	SlideFile          string // Fichier de slides du build ("" si introuvable)
+	SlideFileCreated   bool   // Aucun fichier de slides dans les sources : deck par défaut créé
+}
+
+// LogLines décrit la préparation pour les logs du job, package.json puis fichier de slides
+func (sp *SlidevPreparation) LogLines() []string {
+	packageJSON := "Environment: package.json found in sources"
+	if sp.PackageJSONCreated {
+		packageJSON = "Environment: package.json missing from sources, default package.json created"
 	}
+	return []string{packageJSON, sp.slideFileLogLine()}
+}
 
-	result.Success = true
-	result.Progress = 100
-	result.Duration = time.Since(startTime)
+// toModel retourne la préparation telle qu'enregistrée sur le job
+func (sp *SlidevPreparation) toModel() *models.JobPreparation {
+	return &models.JobPreparation{
+		PackageJSONCreated: sp.PackageJSONCreated,
+		SlideFile:          sp.SlideFile,
+		SlideFileCreated:   sp.SlideFileCreated,
+	}
+}
 
-	log.Printf("Job %s completed successfully in %v", job.ID, result.Duration)
-	return result
+// slideFileLogLine décrit le fichier de slides trouvé ou créé
+func (sp *SlidevPreparation) slideFileLogLine() string {
+	switch {
+	case sp.SlideFileCreated:
+		return fmt.Sprintf("Environment: sources contain no slide file, default %s created with placeholder content", sp.SlideFile)
+	case sp.SlideFile != "":
+		return fmt.Sprintf("Environment: slide file %s found in sources", sp.SlideFile)
+	default:
+		return "Environment: slide file not found in sources"
+	}
 }
 
-// prepareSlidevEnvironment prépare l'environnement Slidev dans le workspace
-func (p *JobProcessor) prepareSlidevEnvironment(ctx context.Context, job *models.GenerationJob, workspace *Workspace) error {
+// prepareSlidevEnvironment prépare l'environnement Slidev dans le workspace et retourne ce
+// qu'elle a trouvé et créé, y compris en cas d'erreur
+func (p *JobProcessor) prepareSlidevEnvironment(ctx context.Context, job *models.GenerationJob, workspace *Workspace) (*SlidevPreparation, error) {
+	preparation := &SlidevPreparation{}
+
 	// S'il n'y a pas de package.json, en créer un basique
 	if !workspace.FileExists("package.json") {
 		log.Printf("Job %s: Creating basic package.json", job.ID)
@@ -322,23 +612,26 @@ func (p *JobProcessor) prepareSlidevEnvironment(ctx context.Context, job *models
   }
 }`
 		if err := workspace.WriteFile("package.json", strings.NewReader(packageJSON)); err != nil {
-			return fmt.Errorf("failed to create package.json: %w", err)
+			return preparation, fmt.Errorf("failed to create package.json: %w", err)
 		}
+		preparation.PackageJSONCreated = true
 	}
 
 	// Vérifier qu'il y a un fichier de slides principal
-	slideFiles := []string{"slides.md", "index.md", "README.md"}
-	hasSlideFile := false
-	for _, file := range slideFiles {
-		if workspace.FileExists(file) {
-			hasSlideFile = true
-			break
-		}
+	slideFile, err := resolveSlideFile(workspace, job, p.config.SlideFiles)
+	if err == nil {
+		preparation.SlideFile = slideFile
+		return preparation, nil
+	}
+
+	// Un entry_file explicite manquant est une erreur, pas un cas à compléter
+	if job.EntryFile != "" {
+		return preparation, err
 	}
 
-	if !hasSlideFile {
-		log.Printf("Job %s: No slide file found, creating basic slides.md", job.ID)
-		basicSlides := `---
+	slideFile = slideFileCandidates(p.config.SlideFiles)[0]
+	log.Printf("Job %s: No slide file found, creating basic %s", job.ID, slideFile)
+	basicSlides := `---
 theme: default
 title: OCF Generated Course
 ---
@@ -353,12 +646,13 @@ Welcome to your generated course!
 
 This course was generated by OCF Worker.
 `
-		if err := workspace.WriteFile("slides.md", strings.NewReader(basicSlides)); err != nil {
-			return fmt.Errorf("failed to create basic slides.md: %w", err)
-		}
+	if err := workspace.WriteFile(slideFile, strings.NewReader(basicSlides)); err != nil {
+		return preparation, fmt.Errorf("failed to create basic %s: %w", slideFile, err)
 	}
+	preparation.SlideFile = slideFile
+	preparation.SlideFileCreated = true
 
-	return nil
+	return preparation, nil
 }
 
 // debugWorkspaceContents affiche le contenu du workspace pour debug
@@ -379,6 +673,9 @@ func (p *JobProcessor) debugWorkspaceContents(workspace *Workspace, jobID uuid.U
 
 	// Vérifier spécifiquement les répertoires de sortie possibles
 	outputDirs := []string{"dist", "build", "output", "_output", ".slidev"}
+	if !slices.Contains(outputDirs, workspace.GetDistPath()) {
+		outputDirs = append([]string{workspace.GetDistPath()}, outputDirs...)
+	}
 	for _, dir := range outputDirs {
 		if workspace.DirExists(dir) {
 			log.Printf("  Found output directory: %s", dir)
@@ -391,20 +688,82 @@ func (p *JobProcessor) debugWorkspaceContents(workspace *Workspace, jobID uuid.U
 	}
 }
 
-// downloadSources télécharge les fichiers sources dans le workspace
-func (p *JobProcessor) downloadSources(ctx context.Context, job *models.GenerationJob, workspace *Workspace) error {
+// DefaultSourceDownloadTimeout est la durée max par défaut du téléchargement des sources d'un job
+const DefaultSourceDownloadTimeout = 5 * time.Minute
+
+// ErrSourceDownloadTimeout est retournée quand le téléchargement des sources dépasse SourceDownloadTimeout
+var ErrSourceDownloadTimeout = errors.New("source download timed out")
+
+// ErrOutputDirContainsSources est retournée quand l'output_dir d'un job contient des sources,
+// que le build écraserait
+var ErrOutputDirContainsSources = errors.New("output directory contains source files")
+
+// downloadSources télécharge les fichiers sources dans le workspace et retourne leur nombre et taille.
+// Le téléchargement a son propre timeout (SourceDownloadTimeout), en plus de celui du job.
+func (p *JobProcessor) downloadSources(ctx context.Context, job *models.GenerationJob, workspace *Workspace) (*models.BuildStats, error) {
+	downloadCtx := ctx
+	if timeout := p.config.SourceDownloadTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		downloadCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	stats, err := p.fetchSources(downloadCtx, job, workspace)
+	if err != nil {
+		// Distinguer le timeout du téléchargement de celui du job
+		if ctx.Err() == nil && errors.Is(downloadCtx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w after %v (%d/%d files, %d bytes downloaded): %w",
+				ErrSourceDownloadTimeout, p.config.SourceDownloadTimeout,
+				stats.downloadedFiles, stats.SourceFileCount, stats.SourceSizeBytes, err)
+		}
+		return nil, err
+	}
+	return &stats.BuildStats, nil
+}
+
+// sourcesInDir retourne les fichiers sources placés sous dir ("" = aucun)
+func sourcesInDir(sourceFiles []string, dir string) []string {
+	if dir == "" {
+		return nil
+	}
+	var inDir []string
+	for _, filePath := range sourceFiles {
+		filePath = filepath.ToSlash(filePath)
+		if filePath == dir || strings.HasPrefix(filePath, dir+"/") {
+			inDir = append(inDir, filePath)
+		}
+	}
+	return inDir
+}
+
+// sourceDownloadStats suit l'avancement d'un téléchargement de sources
+type sourceDownloadStats struct {
+	models.BuildStats
+	downloadedFiles int
+}
+
+// fetchSources télécharge les sources ; les statistiques sont retournées même en cas d'échec
+func (p *JobProcessor) fetchSources(ctx context.Context, job *models.GenerationJob, workspace *Workspace) (*sourceDownloadStats, error) {
+	stats := &sourceDownloadStats{}
+
 	// Lister les fichiers sources (peut inclure des chemins avec dossiers)
 	sourceFiles, err := p.storageService.ListJobSources(ctx, job.ID)
 	if err != nil {
-		return fmt.Errorf("failed to list source files: %w", err)
+		return stats, fmt.Errorf("failed to list source files: %w", err)
 	}
 
 	if len(sourceFiles) == 0 {
-		return fmt.Errorf("no source files found for job %s", job.ID)
+		return stats, fmt.Errorf("no source files found for job %s", job.ID)
 	}
 
 	log.Printf("Job %s: Found %d source files with paths", job.ID, len(sourceFiles))
 
+	// Un output_dir explicite ne doit pas désigner un dossier des sources
+	if overwritten := sourcesInDir(sourceFiles, job.OutputDir); len(overwritten) > 0 {
+		return stats, fmt.Errorf("%w: %q holds %d source files (%s), choose another output_dir",
+			ErrOutputDirContainsSources, job.OutputDir, len(overwritten), overwritten[0])
+	}
+
 	// Organiser les fichiers par dossier pour un meilleur logging
 	dirMap := make(map[string][]string)
 	for _, filePath := range sourceFiles {
@@ -420,20 +779,33 @@ func (p *JobProcessor) downloadSources(ctx context.Context, job *models.Generati
 		log.Printf("Job %s: Directory '%s' contains %d files: %v", job.ID, dir, len(files), files)
 	}
 
-	// Télécharger chaque fichier en préservant la structure
+	// Télécharger chaque fichier en préservant la structure, en calculant son empreinte au passage
+	stats.SourceFileCount = len(sourceFiles)
+	checksums := make(map[string]string, len(sourceFiles))
 	for _, filePath := range sourceFiles {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
 		reader, err := p.storageService.DownloadJobSource(ctx, job.ID, filePath)
 		if err != nil {
-			return fmt.Errorf("failed to download source file %s: %w", filePath, err)
+			return stats, fmt.Errorf("failed to download source file %s: %w", filePath, err)
 		}
 
 		// WriteFile va automatiquement créer les dossiers parents
-		if err := workspace.WriteFile(filePath, reader); err != nil {
-			return fmt.Errorf("failed to write source file %s to workspace: %w", filePath, err)
+		hasher := sha256.New()
+		if err := workspace.WriteFile(filePath, io.TeeReader(reader, hasher)); err != nil {
+			return stats, fmt.Errorf("failed to write source file %s to workspace: %w", filePath, err)
+		}
+		checksums[filePath] = "sha256:" + hex.EncodeToString(hasher.Sum(nil))
+		if size, err := workspace.GetFileSize(filePath); err == nil {
+			stats.SourceSizeBytes += size
 		}
+		stats.downloadedFiles++
 
 		log.Printf("Job %s: Downloaded and placed source file %s", job.ID, filePath)
 	}
+	stats.SourceHash = storage.SourceHash(checksums)
 
 	// Vérifier la structure créée dans le workspace
 	if err := p.verifyWorkspaceStructure(workspace, job.ID); err != nil {
@@ -441,7 +813,25 @@ func (p *JobProcessor) downloadSources(ctx context.Context, job *models.Generati
 		// Ne pas échouer le job pour cela, juste logger un warning
 	}
 
-	return nil
+	return stats, nil
+}
+
+// detectTheme retourne le thème déclaré par le fichier de slides du job
+func (p *JobProcessor) detectTheme(workspace *Workspace, job *models.GenerationJob) string {
+	slideFile, err := resolveSlideFile(workspace, job, p.config.SlideFiles)
+	if err != nil {
+		return ""
+	}
+
+	reader, err := workspace.ReadFile(slideFile)
+	if err != nil {
+		return ""
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	return jobs.DetectTheme(reader)
 }
 
 // verifyWorkspaceStructure vérifie que la structure de dossiers a été correctement créée
@@ -471,8 +861,32 @@ func (p *JobProcessor) verifyWorkspaceStructure(workspace *Workspace, jobID uuid
 	return nil
 }
 
-// uploadResults upload les résultats générés vers le storage
-func (p *JobProcessor) uploadResults(ctx context.Context, job *models.GenerationJob, workspace *Workspace) error {
+// uploadResults upload les résultats générés vers le storage et retourne leur manifeste
+func (p *JobProcessor) uploadResults(ctx context.Context, job *models.GenerationJob, workspace *Workspace) (*models.ResultManifest, error) {
+	manifest := newResultManifest(job)
+	if err := p.uploadResultFiles(ctx, job, workspace, "", manifest); err != nil {
+		return nil, err
+	}
+	if err := p.saveResultManifest(ctx, job, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// newResultManifest crée le manifeste vide des résultats d'un job
+func newResultManifest(job *models.GenerationJob) *models.ResultManifest {
+	return &models.ResultManifest{
+		CourseID:    job.CourseID,
+		JobID:       job.ID,
+		GeneratedAt: time.Now(),
+		Files:       []models.ManifestEntry{},
+		SPAFallback: models.SPAFallbackFromMetadata(job.Metadata),
+	}
+}
+
+// uploadResultFiles upload le contenu de dist sous le dossier prefix des résultats du cours
+// ("" = racine) et l'ajoute au manifeste
+func (p *JobProcessor) uploadResultFiles(ctx context.Context, job *models.GenerationJob, workspace *Workspace, prefix string, manifest *models.ResultManifest) error {
 	distPath := workspace.GetDistPath()
 
 	// Lister tous les fichiers générés (y compris dans les sous-dossiers)
@@ -501,33 +915,164 @@ func (p *JobProcessor) uploadResults(ctx context.Context, job *models.Generation
 		log.Printf("Job %s: Result directory '%s' contains %d files: %v", job.ID, dir, len(files), files)
 	}
 
+	encodings := p.resultEncodings(job)
+
+	// Refuser le build avant d'écrire le premier fichier si le cours dépasserait son quota
+	if err := p.checkResultQuota(ctx, job, workspace, prefix, resultFiles, encodings); err != nil {
+		return err
+	}
+
 	// Upload chaque fichier de résultat en préservant la structure
-	for _, relativePath := range resultFiles {
-		fullPath := fmt.Sprintf("%s/%s", distPath, relativePath)
+	for _, distFile := range resultFiles {
+		fullPath := filepath.Join(distPath, distFile)
+		relativePath := resultKey(prefix, distFile)
+
+		size, err := workspace.GetFileSize(fullPath)
+		if err != nil {
+			return fmt.Errorf("failed to stat result file %s: %w", relativePath, err)
+		}
 		reader, err := workspace.ReadFile(fullPath)
 		if err != nil {
 			return fmt.Errorf("failed to read result file %s: %w", relativePath, err)
 		}
 
+		// Calculer l'empreinte et la taille pendant l'upload
+		hasher := sha256.New()
+		counter := &countingWriter{}
+		teeReader := io.TeeReader(reader, io.MultiWriter(hasher, counter))
+
 		// UploadResult va maintenant préserver la structure de dossiers
-		if err := p.storageService.UploadResult(ctx, job.CourseID, relativePath, reader); err != nil {
+		if err := p.storageService.UploadResultSized(ctx, job.CourseID, relativePath, teeReader, size); err != nil {
 			return fmt.Errorf("failed to upload result file %s: %w", relativePath, err)
 		}
 
+		// Variantes précompressées servies aux clients qui les acceptent
+		variants, err := p.uploadCompressedVariants(ctx, job, workspace, fullPath, relativePath, size, encodings)
+		if err != nil {
+			return err
+		}
+
+		manifest.Files = append(manifest.Files, models.ManifestEntry{
+			Path:        relativePath,
+			Size:        counter.n,
+			ContentType: resultContentType(relativePath),
+			Hash:        "sha256:" + hex.EncodeToString(hasher.Sum(nil)),
+			JobID:       job.ID,
+			Encodings:   variants,
+		})
+		manifest.TotalSize += counter.n
+
 		log.Printf("Job %s: Uploaded result file %s", job.ID, relativePath)
 	}
 
 	return nil
 }
 
-// saveJobLogs sauvegarde les logs du job
-func (p *JobProcessor) saveJobLogs(ctx context.Context, jobID uuid.UUID, logs []string) error {
-	logContent := ""
-	for _, line := range logs {
-		logContent += line + "\n"
+// resultKey retourne le chemin d'un fichier de dist dans les résultats du cours : chemin
+// relatif à dist, sous le dossier prefix, toujours séparé par "/" quel que soit l'OS
+func resultKey(prefix, distFile string) string {
+	return path.Join(prefix, filepath.ToSlash(distFile))
+}
+
+// checkResultQuota vérifie que les fichiers de dist et leurs variantes compressées tiennent
+// dans le quota de résultats du cours. Les variantes sont compressées une première fois ici
+// pour connaître leur taille, seulement quand le cours a un quota.
+func (p *JobProcessor) checkResultQuota(ctx context.Context, job *models.GenerationJob, workspace *Workspace, prefix string,
+	resultFiles []string, encodings []*storage.ResultEncoding) error {
+	if p.storageService.ResultQuota(job.CourseID) <= 0 {
+		return nil
+	}
+
+	files := make(map[string]int64, len(resultFiles))
+	for _, distFile := range resultFiles {
+		fullPath := filepath.Join(workspace.GetDistPath(), distFile)
+		size, err := workspace.GetFileSize(fullPath)
+		if err != nil {
+			return fmt.Errorf("failed to stat result file %s: %w", distFile, err)
+		}
+		key := resultKey(prefix, distFile)
+		files[key] = size
+
+		variants, err := compressedVariantSizes(workspace, fullPath, key, size, encodings)
+		if err != nil {
+			return err
+		}
+		for variant, variantSize := range variants {
+			files[variant] = variantSize
+		}
 	}
 
-	return p.storageService.SaveJobLog(ctx, jobID, logContent)
+	return p.storageService.CheckResultQuota(ctx, job.CourseID, files)
+}
+
+// saveResultManifest enregistre le manifeste des résultats uploadés
+func (p *JobProcessor) saveResultManifest(ctx context.Context, job *models.GenerationJob, manifest *models.ResultManifest) error {
+	// Une variante d'un build précédent ne doit pas masquer le fichier reconstruit
+	if err := p.removeStaleVariants(ctx, job, manifest); err != nil {
+		log.Printf("Job %s: failed to remove stale compressed results: %v", job.ID, err)
+	}
+
+	manifest.FileCount = len(manifest.Files)
+	if err := p.storageService.SaveResultManifest(ctx, manifest); err != nil {
+		return fmt.Errorf("failed to save result manifest: %w", err)
+	}
+
+	log.Printf("Job %s: Saved result manifest (%d files, %d bytes)", job.ID, manifest.FileCount, manifest.TotalSize)
+	return nil
+}
+
+// pruneStaleResults supprime les résultats du cours absents du manifeste du build.
+// Le manifeste est déjà à jour : un client qui le suit ne voit jamais de fichier manquant.
+func (p *JobProcessor) pruneStaleResults(ctx context.Context, job *models.GenerationJob, manifest *models.ResultManifest) error {
+	current := make(map[string]bool, len(manifest.Files))
+	for _, entry := range manifest.Files {
+		current[entry.Path] = true
+		for _, variant := range variantPaths(entry) {
+			current[variant] = true
+		}
+	}
+
+	results, err := p.storageService.ListResults(ctx, job.CourseID)
+	if err != nil {
+		return err
+	}
+
+	removed := 0
+	for _, result := range results {
+		if current[filepath.ToSlash(result)] {
+			continue
+		}
+		if err := p.storageService.DeleteResult(ctx, job.CourseID, result); err != nil {
+			return fmt.Errorf("failed to delete stale result %s: %w", result, err)
+		}
+		removed++
+	}
+
+	log.Printf("Job %s: Removed %d stale result files", job.ID, removed)
+	return nil
+}
+
+// countingWriter compte les octets écrits
+type countingWriter struct {
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	cw.n += int64(len(p))
+	return len(p), nil
+}
+
+// resultContentType détermine le type MIME d'un fichier généré
+func resultContentType(path string) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(path)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// saveJobLogs sauvegarde les logs du job dans le format configuré
+func (p *JobProcessor) saveJobLogs(ctx context.Context, jobID uuid.UUID, logs []string) error {
+	return p.storageService.SaveJobLogLines(ctx, jobID, logs, p.config.LogFormat)
 }
 
 // updateJobStatus met à jour le statut d'un job
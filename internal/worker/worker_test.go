@@ -2,20 +2,26 @@
 package worker
 
 import (
+	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
 	"github.com/Open-Course-Factory/ocf-worker/internal/storage"
 	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 )
 
 func TestWorkspace(t *testing.T) {
@@ -93,6 +99,13 @@ func TestWorkspace(t *testing.T) {
 
 		assert.False(t, workspace.FileExists("../malicious.txt"))
 		assert.False(t, workspace.FileExists("/absolute/path.txt"))
+
+		err = workspace.WriteFile("subdir/../../malicious.txt", strings.NewReader("bad"))
+		assert.Error(t, err)
+
+		// ".." n'est refusé que comme segment de chemin
+		require.NoError(t, workspace.WriteFile("assets/vendor..shared.js", strings.NewReader("ok")))
+		assert.True(t, workspace.FileExists("assets/vendor..shared.js"))
 	})
 
 	t.Run("Workspace Info", func(t *testing.T) {
@@ -111,6 +124,228 @@ func TestWorkspace(t *testing.T) {
 	})
 }
 
+func TestWorkspaceInfoDependencies(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "ocf-worker-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	jobID := uuid.New()
+	workspace, err := NewWorkspace(tempDir, jobID)
+	require.NoError(t, err)
+
+	require.NoError(t, workspace.WriteFile("slides.md", strings.NewReader(strings.Repeat("s", 100))))
+	require.NoError(t, workspace.WriteFile("dist/index.html", strings.NewReader(strings.Repeat("d", 200))))
+	require.NoError(t, workspace.WriteFile("node_modules/sass/sass.js", strings.NewReader(strings.Repeat("n", 1000))))
+	require.NoError(t, workspace.WriteFile(".npm-cache/_cacache/index", strings.NewReader(strings.Repeat("c", 500))))
+
+	t.Run("Dependencies excluded by default", func(t *testing.T) {
+		info := workspace.GetWorkspaceInfo()
+		assert.Equal(t, int64(300), info.SizeBytes)
+		assert.Equal(t, int64(100), info.SourceBytes)
+		assert.Equal(t, int64(200), info.DistBytes)
+		assert.Equal(t, int64(1500), info.DependencyBytes)
+		assert.Equal(t, 2, info.DependencyFileCount)
+		assert.Equal(t, 2, info.FileCount)
+	})
+
+	t.Run("Dependencies included", func(t *testing.T) {
+		info := workspace.GetWorkspaceInfoWithOptions(WorkspaceStatsOptions{IncludeDependencies: true})
+		assert.Equal(t, int64(1800), info.SizeBytes)
+		assert.Equal(t, 4, info.FileCount)
+	})
+
+	t.Run("Remove directory", func(t *testing.T) {
+		for _, invalid := range []string{"", ".", "../other", "/etc", "node_modules/../.."} {
+			_, err := workspace.RemoveDirectory(invalid)
+			assert.Error(t, err, "should refuse %q", invalid)
+		}
+
+		_, err := workspace.RemoveDirectory("slides.md")
+		assert.Error(t, err)
+
+		freed, err := workspace.RemoveDirectory("missing")
+		require.NoError(t, err)
+		assert.Zero(t, freed)
+	})
+
+	t.Run("Manager statistics", func(t *testing.T) {
+		manager, err := NewWorkspaceManager(tempDir)
+		require.NoError(t, err)
+
+		stats, err := manager.GetWorkspaceStats()
+		require.NoError(t, err)
+		assert.Equal(t, int64(300), stats.TotalSizeBytes)
+		assert.Equal(t, int64(1500), stats.TotalDependencyBytes)
+
+		_, err = manager.GetWorkspaceInfo(uuid.New())
+		assert.ErrorIs(t, err, ErrWorkspaceNotFound)
+	})
+}
+
+// unavailableJobService simule une base de jobs injoignable
+type unavailableJobService struct {
+	MockJobService
+}
+
+func (m *unavailableJobService) GetJob(ctx context.Context, id uuid.UUID) (*models.GenerationJob, error) {
+	return nil, fmt.Errorf("connection refused")
+}
+
+func TestCleanupOldWorkspaces(t *testing.T) {
+	ctx := context.Background()
+	old := time.Now().Add(-48 * time.Hour)
+	cutoff := time.Now().Add(-24 * time.Hour).Unix()
+
+	// setup crée un workspace par statut (et un pour un job supprimé), modifiés il y a 48h
+	setup := func(t *testing.T) (string, *MockJobService, map[string]uuid.UUID) {
+		baseDir := t.TempDir()
+		jobService := &MockJobService{jobs: map[uuid.UUID]*models.GenerationJob{}}
+		ids := map[string]uuid.UUID{}
+
+		for _, name := range []string{"pending", "processing", "completed", "failed", "deleted"} {
+			jobID := uuid.New()
+			ids[name] = jobID
+			if name != "deleted" {
+				jobService.jobs[jobID] = &models.GenerationJob{ID: jobID, Status: models.JobStatus(name)}
+			}
+
+			workspace, err := NewWorkspace(baseDir, jobID)
+			require.NoError(t, err)
+			require.NoError(t, os.Chtimes(workspace.GetPath(), old, old))
+		}
+		return baseDir, jobService, ids
+	}
+
+	remaining := func(t *testing.T, baseDir string, ids map[string]uuid.UUID) []string {
+		var names []string
+		for name, jobID := range ids {
+			if _, err := os.Stat(filepath.Join(baseDir, jobID.String())); err == nil {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	t.Run("Active jobs keep their workspace", func(t *testing.T) {
+		baseDir, jobService, ids := setup(t)
+		manager, err := NewWorkspaceManager(baseDir)
+		require.NoError(t, err)
+		manager.SetCleanupProtection(jobService, nil)
+
+		cleaned, err := manager.CleanupOldWorkspaces(ctx, cutoff)
+		require.NoError(t, err)
+		assert.Equal(t, 3, cleaned)
+		assert.Equal(t, []string{"pending", "processing"}, remaining(t, baseDir, ids))
+	})
+
+	t.Run("Configured statuses", func(t *testing.T) {
+		baseDir, jobService, ids := setup(t)
+		manager, err := NewWorkspaceManager(baseDir)
+		require.NoError(t, err)
+		manager.SetCleanupProtection(jobService, []models.JobStatus{models.StatusProcessing, models.StatusFailed})
+
+		cleaned, err := manager.CleanupOldWorkspaces(ctx, cutoff)
+		require.NoError(t, err)
+		assert.Equal(t, 3, cleaned)
+		assert.Equal(t, []string{"failed", "processing"}, remaining(t, baseDir, ids))
+	})
+
+	t.Run("Age only without job service", func(t *testing.T) {
+		baseDir, _, ids := setup(t)
+		manager, err := NewWorkspaceManager(baseDir)
+		require.NoError(t, err)
+
+		cleaned, err := manager.CleanupOldWorkspaces(ctx, cutoff)
+		require.NoError(t, err)
+		assert.Equal(t, 5, cleaned)
+		assert.Empty(t, remaining(t, baseDir, ids))
+	})
+
+	t.Run("Unknown status keeps the workspace", func(t *testing.T) {
+		baseDir, _, ids := setup(t)
+		manager, err := NewWorkspaceManager(baseDir)
+		require.NoError(t, err)
+		manager.SetCleanupProtection(&unavailableJobService{}, nil)
+
+		cleaned, err := manager.CleanupOldWorkspaces(ctx, cutoff)
+		require.NoError(t, err)
+		assert.Zero(t, cleaned)
+		assert.Len(t, remaining(t, baseDir, ids), 5)
+	})
+
+	t.Run("Pool cleanup of a running build", func(t *testing.T) {
+		baseDir, jobService, ids := setup(t)
+		pool := NewWorkerPool(jobService, storage.NewStorageService(&MockStorageBackend{}), &PoolConfig{
+			WorkerCount:   1,
+			WorkspaceBase: baseDir,
+		})
+
+		// Un build long écrit dans un sous-dossier : la date du workspace ne change pas
+		require.NoError(t, os.MkdirAll(filepath.Join(baseDir, ids["processing"].String(), "dist", "assets"), 0o755))
+		require.NoError(t, os.Chtimes(filepath.Join(baseDir, ids["processing"].String()), old, old))
+
+		cleaned, err := pool.CleanupOldWorkspaces(ctx, 24*time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, 3, cleaned)
+		assert.Contains(t, remaining(t, baseDir, ids), "processing")
+	})
+}
+
+func TestWorkspaceBases(t *testing.T) {
+	ctx := context.Background()
+	bases := []string{filepath.Join(t.TempDir(), "disk1"), filepath.Join(t.TempDir(), "disk2")}
+	jobService := &MockJobService{jobs: map[uuid.UUID]*models.GenerationJob{}}
+	pool := NewWorkerPool(jobService, storage.NewStorageService(&MockStorageBackend{}), &PoolConfig{
+		WorkerCount:    3,
+		WorkspaceBase:  t.TempDir(),
+		WorkspaceBases: bases,
+	})
+
+	t.Run("Round-robin assignment", func(t *testing.T) {
+		stats := pool.GetStats()
+		require.Len(t, stats.Workers, 3)
+		assert.Equal(t, bases[0], stats.Workers[0].WorkspaceBase)
+		assert.Equal(t, bases[1], stats.Workers[1].WorkspaceBase)
+		assert.Equal(t, bases[0], stats.Workers[2].WorkspaceBase)
+
+		// Le runner Slidev du worker et celui des aperçus de thèmes suivent les répertoires assignés
+		assert.Equal(t, bases[1], pool.workers[1].processor.slidevRunner.GetBuildInfo()["workspace_base"])
+		assert.Equal(t, bases[0], pool.themePreviewer.runner.workspaceBase)
+	})
+
+	old := time.Now().Add(-48 * time.Hour)
+	var jobIDs []uuid.UUID
+	for _, base := range bases {
+		jobID := uuid.New()
+		jobIDs = append(jobIDs, jobID)
+		workspace, err := NewWorkspace(base, jobID)
+		require.NoError(t, err)
+		require.NoError(t, workspace.WriteFile("slides.md", strings.NewReader("# Cours")))
+		require.NoError(t, os.Chtimes(workspace.GetPath(), old, old))
+	}
+
+	t.Run("Listing and lookup cover every base", func(t *testing.T) {
+		workspaces, err := pool.ListWorkspaces()
+		require.NoError(t, err)
+		assert.Len(t, workspaces, 2)
+
+		info, err := pool.GetWorkspaceInfo(jobIDs[1])
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(bases[1], jobIDs[1].String()), info.Path)
+	})
+
+	t.Run("Cleanup covers every base", func(t *testing.T) {
+		cleaned, err := pool.CleanupOldWorkspaces(ctx, 24*time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, 2, cleaned)
+
+		workspaces, err := pool.ListWorkspaces()
+		require.NoError(t, err)
+		assert.Empty(t, workspaces)
+	})
+}
+
 func TestSlidevRunner(t *testing.T) {
 	config := &PoolConfig{
 		SlidevCommand: "echo", // Utiliser echo pour simuler Slidev
@@ -126,7 +361,7 @@ func TestSlidevRunner(t *testing.T) {
 	})
 
 	t.Run("Environment Building", func(t *testing.T) {
-		env := runner.buildEnvironment()
+		env := runner.buildEnvironment(nil)
 		assert.NotEmpty(t, env)
 
 		// Vérifier que les variables spécifiques sont présentes
@@ -138,6 +373,81 @@ func TestSlidevRunner(t *testing.T) {
 			}
 		}
 		assert.True(t, found, "NODE_ENV=production should be in environment")
+		assert.Contains(t, env, "NPM_CONFIG_CACHE="+SharedNpmCacheDir)
+	})
+
+	t.Run("Workspace NPM Cache", func(t *testing.T) {
+		tempDir, err := os.MkdirTemp("", "ocf-worker-test-*")
+		require.NoError(t, err)
+		defer os.RemoveAll(tempDir)
+
+		workspace, err := NewWorkspace(tempDir, uuid.New())
+		require.NoError(t, err)
+
+		isolatedRunner := NewSlidevRunner(&PoolConfig{
+			SlidevCommand: "echo",
+			NpmCacheMode:  NpmCacheWorkspace,
+		})
+
+		cacheDir := filepath.Join(workspace.GetPath(), workspaceNpmCacheDir)
+		assert.Contains(t, isolatedRunner.buildEnvironment(workspace), "NPM_CONFIG_CACHE="+cacheDir)
+		assert.Contains(t, isolatedRunner.npmPackageManager.buildInstallEnvironment(workspace), "NPM_CONFIG_CACHE="+cacheDir)
+
+		// Le cache isolé est supprimé avec le workspace
+		require.NoError(t, workspace.WriteFile(workspaceNpmCacheDir+"/_cacache/index", strings.NewReader("entry")))
+		require.NoError(t, workspace.Cleanup())
+		_, err = os.Stat(cacheDir)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Build Flags", func(t *testing.T) {
+		tempDir, err := os.MkdirTemp("", "ocf-worker-test-*")
+		require.NoError(t, err)
+		defer os.RemoveAll(tempDir)
+
+		workspace, err := NewWorkspace(tempDir, uuid.New())
+		require.NoError(t, err)
+
+		allowed, rejected := filterBuildFlags([]string{"--download", "--watch", "--base=/cours/", "--out=/tmp"})
+		assert.Equal(t, []string{"--download", "--base=/cours/"}, allowed)
+		assert.Equal(t, []string{"--watch", "--out=/tmp"}, rejected)
+
+		cmd := runner.prepareBuildCommand(context.Background(), workspace, "slides.md", allowed)
+		assert.Equal(t, []string{"echo", "build", "slides.md", "--out", "./dist", "--download", "--base=/cours/"}, cmd.Args)
+	})
+
+	t.Run("Build Base From Config", func(t *testing.T) {
+		tempDir, err := os.MkdirTemp("", "ocf-worker-test-*")
+		require.NoError(t, err)
+		defer os.RemoveAll(tempDir)
+
+		workspace, err := NewWorkspace(tempDir, uuid.New())
+		require.NoError(t, err)
+
+		// Sans configuration, pas de base
+		cmd := runner.prepareBuildCommand(context.Background(), workspace, "slides.md", nil)
+		assert.Equal(t, []string{"echo", "build", "slides.md", "--out", "./dist"}, cmd.Args)
+
+		viteConfig := "import { defineConfig } from 'vite'\n\nexport default defineConfig({\n" +
+			"  // base: '/commented/',\n  plugins: [],\n  base: \"/vite/\",\n})\n"
+		require.NoError(t, workspace.WriteFile("vite.config.ts", strings.NewReader(viteConfig)))
+		cmd = runner.prepareBuildCommand(context.Background(), workspace, "slides.md", []string{"--download"})
+		assert.Equal(t, []string{"echo", "build", "slides.md", "--out", "./dist", "--download", "--base=/vite/"}, cmd.Args)
+
+		// slidev.config.* est prioritaire sur vite.config.*
+		require.NoError(t, workspace.WriteFile("slidev.config.js", strings.NewReader("export default {\n  base: '/cours/intro/',\n}\n")))
+		cmd = runner.prepareBuildCommand(context.Background(), workspace, "slides.md", nil)
+		assert.Equal(t, []string{"echo", "build", "slides.md", "--out", "./dist", "--base=/cours/intro/"}, cmd.Args)
+
+		// La base de la requête l'emporte sur celle de la configuration
+		cmd = runner.prepareBuildCommand(context.Background(), workspace, "slides.md", []string{"--base=/request/"})
+		assert.Equal(t, []string{"echo", "build", "slides.md", "--out", "./dist", "--base=/request/"}, cmd.Args)
+
+		// Une base invalide est ignorée au profit du fichier suivant
+		require.NoError(t, workspace.WriteFile("slidev.config.js", strings.NewReader("export default { base: 'https://evil.example/' }\n")))
+		base, configFile := configuredBuildBase(workspace)
+		assert.Equal(t, "/vite/", base)
+		assert.Equal(t, "vite.config.ts", configFile)
 	})
 
 	t.Run("Progress Parsing", func(t *testing.T) {
@@ -166,6 +476,538 @@ func TestSlidevRunner(t *testing.T) {
 	})
 }
 
+func TestDetectStylePreprocessors(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "ocf-worker-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	workspace, err := NewWorkspace(tempDir, uuid.New())
+	require.NoError(t, err)
+
+	manager := NewNpmPackageManager(tempDir)
+
+	t.Run("No preprocessor needed", func(t *testing.T) {
+		require.NoError(t, workspace.WriteFile("styles/main.css", strings.NewReader("body {}")))
+		require.NoError(t, workspace.WriteFile("styles/base.postcss", strings.NewReader("body {}")))
+
+		packages, err := manager.DetectStylePreprocessors(workspace)
+		require.NoError(t, err)
+		assert.Empty(t, packages)
+	})
+
+	t.Run("Sources require sass and less", func(t *testing.T) {
+		require.NoError(t, workspace.WriteFile("styles/theme.scss", strings.NewReader("$a: 1;")))
+		require.NoError(t, workspace.WriteFile("styles/extra.less", strings.NewReader("@a: 1;")))
+		// Les fichiers des dépendances ne doivent pas être pris en compte
+		require.NoError(t, workspace.WriteFile("node_modules/some-lib/mixins.sass", strings.NewReader("$a: 1")))
+
+		packages, err := manager.DetectStylePreprocessors(workspace)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"sass", "less"}, packages)
+	})
+
+	t.Run("Already installed preprocessor is skipped", func(t *testing.T) {
+		require.NoError(t, workspace.WriteFile("node_modules/sass/package.json", strings.NewReader("{}")))
+
+		packages, err := manager.DetectStylePreprocessors(workspace)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"less"}, packages)
+	})
+}
+
+func TestVerifyFonts(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "ocf-worker-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	workspace, err := NewWorkspace(tempDir, uuid.New())
+	require.NoError(t, err)
+
+	runner := NewSlidevRunner(DefaultPoolConfig())
+
+	t.Run("No fonts directory", func(t *testing.T) {
+		assert.Empty(t, runner.verifyFonts(workspace))
+	})
+
+	require.NoError(t, workspace.WriteFile("assets/fonts/Inter.woff2", strings.NewReader("woff2")))
+	require.NoError(t, workspace.WriteFile("assets/fonts/Title.ttf", strings.NewReader("ttf")))
+	require.NoError(t, workspace.WriteFile("assets/fonts/Unused.otf", strings.NewReader("otf")))
+	require.NoError(t, workspace.WriteFile("assets/fonts/LICENSE.txt", strings.NewReader("OFL")))
+
+	// Inter est hashé par Vite, Title est copié tel quel, Unused n'est jamais référencé
+	require.NoError(t, workspace.WriteFile("dist/index.html", strings.NewReader(`<link rel="stylesheet" href="/assets/index.css">`)))
+	require.NoError(t, workspace.WriteFile("dist/assets/Inter-a1b2c3d4.woff2", strings.NewReader("woff2")))
+	require.NoError(t, workspace.WriteFile("dist/assets/index.css", strings.NewReader(
+		`@font-face{font-family:Inter;src:url(/assets/Inter-a1b2c3d4.woff2)}`+
+			`@font-face{font-family:Title;src:url(/fonts/Title.ttf)}`)))
+
+	warnings := runner.verifyFonts(workspace)
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "assets/fonts/Unused.otf")
+}
+
+func TestVerifyLinks(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "ocf-worker-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	workspace, err := NewWorkspace(tempDir, uuid.New())
+	require.NoError(t, err)
+
+	runner := NewSlidevRunner(DefaultPoolConfig())
+
+	require.NoError(t, workspace.WriteFile("dist/index.html", strings.NewReader(
+		`<link rel="icon" href="https://cdn.example.com/favicon.png">`+
+			`<link rel="stylesheet" href="/assets/index.css?v=1">`+
+			`<script type="module" src="/assets/index.js"></script>`+
+			`<img src="data:image/png;base64,AAAA"><img src="images/logo.png">`+
+			`<img src='/assets/missing.png'><img src="/assets/missing.png">`+
+			`<a href="/2">slide 2</a>`)))
+	require.NoError(t, workspace.WriteFile("dist/assets/index.js", strings.NewReader("js")))
+	require.NoError(t, workspace.WriteFile("dist/assets/index.css", strings.NewReader(
+		`@font-face{src:url("./Inter.woff2")}body{background:url(../images/logo.png)}`+
+			`.a{background:url('bg.jpg')}`)))
+	require.NoError(t, workspace.WriteFile("dist/images/logo.png", strings.NewReader("png")))
+
+	warnings := runner.verifyLinks(workspace)
+	require.Len(t, warnings, 3)
+	assert.Contains(t, warnings, "broken reference in index.html: /assets/missing.png not found in build output")
+	assert.Contains(t, warnings, "broken reference in assets/index.css: ./Inter.woff2 not found in build output")
+	assert.Contains(t, warnings, "broken reference in assets/index.css: bg.jpg not found in build output")
+
+	t.Run("Public base path", func(t *testing.T) {
+		assert.True(t, assetAvailable("course/assets/index.js", map[string]bool{"assets/index.js": true}))
+	})
+}
+
+func TestValidateOutputEntryPoints(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "ocf-worker-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	workspace, err := NewWorkspace(tempDir, uuid.New())
+	require.NoError(t, err)
+
+	runner := NewSlidevRunner(&PoolConfig{})
+	page := strings.NewReader(strings.Repeat("<!-- slidev -->", 10))
+
+	t.Run("Missing index.html", func(t *testing.T) {
+		require.NoError(t, workspace.WriteFile("dist/speaker.html", strings.NewReader("<html></html>")))
+
+		_, err := runner.validateOutput(workspace)
+		assert.Error(t, err)
+	})
+
+	t.Run("Multiple entries", func(t *testing.T) {
+		require.NoError(t, workspace.WriteFile("dist/index.html", page))
+		require.NoError(t, workspace.WriteFile("dist/overview.html", strings.NewReader("<html></html>")))
+		require.NoError(t, workspace.WriteFile("dist/404.html", strings.NewReader("<html></html>")))
+		require.NoError(t, workspace.WriteFile("dist/assets/nested.html", strings.NewReader("<html></html>")))
+		require.NoError(t, workspace.WriteFile("dist/assets/index.js", strings.NewReader("console.log()")))
+
+		entryPoints, err := runner.validateOutput(workspace)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"index.html", "overview.html", "speaker.html"}, entryPoints)
+	})
+}
+
+func TestBuildNonZeroExit(t *testing.T) {
+	fakeSlidev(t)
+
+	// slidev sort en erreur après avoir produit (ou non) un build
+	slidev := filepath.Join(t.TempDir(), "slidev")
+	script := "#!/bin/sh\nif [ -f produce ]; then\n  mkdir -p dist\n" +
+		"  printf '<!DOCTYPE html><html><head><title>Cours</title></head><body>Deck built despite warnings, padded to a realistic size.</body></html>' > dist/index.html\n" +
+		"fi\necho \"warning treated as error\" >&2\nexit 1\n"
+	require.NoError(t, os.WriteFile(slidev, []byte(script), 0o755))
+
+	build := func(t *testing.T, mode string, produce bool) (*SlidevResult, error) {
+		workspace, err := NewWorkspace(t.TempDir(), uuid.New())
+		require.NoError(t, err)
+		require.NoError(t, workspace.WriteFile("slides.md", strings.NewReader("---\ntheme: default\n---\n# Cours\n")))
+		if produce {
+			require.NoError(t, workspace.WriteFile("produce", strings.NewReader("")))
+		}
+
+		runner := NewSlidevRunner(&PoolConfig{
+			SlidevCommand:    slidev,
+			VersionCheckMode: VersionCheckOff,
+			NonZeroExitMode:  mode,
+		})
+		return runner.Build(context.Background(), workspace, &models.GenerationJob{ID: uuid.New()})
+	}
+
+	t.Run("Strict", func(t *testing.T) {
+		result, err := build(t, NonZeroExitStrict, true)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "exit code 1")
+		assert.False(t, result.Success)
+		assert.Equal(t, 1, result.ExitCode)
+	})
+
+	t.Run("Validate output", func(t *testing.T) {
+		result, err := build(t, NonZeroExitValidateOutput, true)
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.True(t, result.WithWarnings)
+		assert.Equal(t, 1, result.ExitCode)
+		assert.Equal(t, []string{"index.html"}, result.EntryPoints)
+		assert.Contains(t, result.Logs[len(result.Logs)-1], "completed with warnings (exit code 1)")
+	})
+
+	t.Run("Exit code kept in job warnings", func(t *testing.T) {
+		ctx := context.Background()
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New()}
+		jobService := &MockJobService{jobs: map[uuid.UUID]*models.GenerationJob{job.ID: job}}
+		storageService := storage.NewStorageService(&MockStorageBackend{})
+		require.NoError(t, storageService.UploadJobSource(ctx, job.ID, "slides.md", strings.NewReader("# Cours\n")))
+		require.NoError(t, storageService.UploadJobSource(ctx, job.ID, "produce", strings.NewReader("")))
+
+		processor := NewJobProcessor(jobService, storageService, &PoolConfig{
+			WorkspaceBase:    t.TempDir(),
+			SlidevCommand:    slidev,
+			VersionCheckMode: VersionCheckOff,
+			NonZeroExitMode:  NonZeroExitValidateOutput,
+			CleanupWorkspace: true,
+			JobTimeout:       30 * time.Second,
+		})
+		result := processor.ProcessJob(ctx, job)
+		require.True(t, result.Success, "job error: %v", result.Error)
+		require.Len(t, job.Warnings, 1)
+		assert.Contains(t, job.Warnings[0], "Slidev build exited with code 1")
+	})
+
+	t.Run("Validate output without output", func(t *testing.T) {
+		result, err := build(t, NonZeroExitValidateOutput, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "exit code 1")
+		assert.False(t, result.Success)
+		assert.False(t, result.WithWarnings)
+	})
+}
+
+func TestProcessJobOutputDir(t *testing.T) {
+	fakeSlidev(t)
+
+	// slidev écrit dans --out, ou dans dist s'il ignore l'option (fichier ignore-out)
+	slidev := filepath.Join(t.TempDir(), "slidev")
+	script := "#!/bin/sh\nout=dist\nwhile [ $# -gt 0 ]; do\n  case \"$1\" in\n    --out) out=\"$2\"; shift ;;\n  esac\n  shift\ndone\n" +
+		"[ -f ignore-out ] && out=dist\nmkdir -p \"$out\"\n" +
+		"printf '<!DOCTYPE html><html><head><title>Cours</title></head><body>Deck built into %s, padded to a realistic size.</body></html>' \"$out\" > \"$out/index.html\"\n"
+	require.NoError(t, os.WriteFile(slidev, []byte(script), 0o755))
+
+	run := func(t *testing.T, outputDir string, ignoreOut bool, extraSources ...string) (*JobResult, *models.GenerationJob, *storage.StorageService) {
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New(), OutputDir: outputDir}
+		jobService := &MockJobService{jobs: map[uuid.UUID]*models.GenerationJob{job.ID: job}}
+		storageService := storage.NewStorageService(&MockStorageBackend{})
+
+		ctx := context.Background()
+		require.NoError(t, storageService.UploadJobSource(ctx, job.ID, "slides.md", strings.NewReader("---\ntheme: default\n---\n# Cours\n")))
+		if ignoreOut {
+			require.NoError(t, storageService.UploadJobSource(ctx, job.ID, "ignore-out", strings.NewReader("")))
+		}
+		for _, source := range extraSources {
+			require.NoError(t, storageService.UploadJobSourceWithPath(ctx, job.ID, source, strings.NewReader("# Source\n")))
+		}
+
+		processor := NewJobProcessor(jobService, storageService, &PoolConfig{
+			WorkspaceBase:    t.TempDir(),
+			SlidevCommand:    slidev,
+			VersionCheckMode: VersionCheckOff,
+			CleanupWorkspace: true,
+			JobTimeout:       30 * time.Second,
+		})
+		return processor.ProcessJob(ctx, job), job, storageService
+	}
+
+	resultContent := func(t *testing.T, storageService *storage.StorageService, courseID uuid.UUID) string {
+		reader, err := storageService.DownloadResult(context.Background(), courseID, "index.html")
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("Default dist", func(t *testing.T) {
+		result, job, storageService := run(t, "", false)
+		require.True(t, result.Success, "job error: %v", result.Error)
+		assert.Contains(t, resultContent(t, storageService, job.CourseID), "built into ./dist")
+	})
+
+	t.Run("Custom output directory", func(t *testing.T) {
+		result, job, storageService := run(t, "site/html", false)
+		require.True(t, result.Success, "job error: %v", result.Error)
+		assert.Equal(t, models.StatusCompleted, job.Status)
+		assert.Equal(t, []string{"index.html"}, []string(job.EntryPoints))
+		assert.Contains(t, resultContent(t, storageService, job.CourseID), "built into ./site/html")
+	})
+
+	t.Run("Fallback to dist", func(t *testing.T) {
+		result, job, storageService := run(t, "site", true)
+		require.True(t, result.Success, "job error: %v", result.Error)
+		assert.Contains(t, resultContent(t, storageService, job.CourseID), "built into dist")
+	})
+
+	t.Run("Unsafe output directory", func(t *testing.T) {
+		result, job, _ := run(t, "../escape", false)
+		assert.False(t, result.Success)
+		assert.Equal(t, models.StatusFailed, job.Status)
+		assert.Contains(t, job.Error, "invalid output directory")
+	})
+
+	t.Run("Output directory holding sources", func(t *testing.T) {
+		result, job, _ := run(t, "pages", false, "pages/intro.md")
+		assert.False(t, result.Success)
+		assert.ErrorIs(t, result.Error, ErrOutputDirContainsSources)
+		assert.Equal(t, models.StatusFailed, job.Status)
+		assert.Contains(t, job.Error, `"pages" holds 1 source files (pages/intro.md)`)
+	})
+
+	t.Run("Output directory sharing a prefix with sources", func(t *testing.T) {
+		result, _, _ := run(t, "site", false, "site-assets/logo.md")
+		require.True(t, result.Success, "job error: %v", result.Error)
+	})
+}
+
+func TestProcessJobSourceRetention(t *testing.T) {
+	fakeSlidev(t)
+
+	// slidev échoue si le cours contient un fichier fail
+	slidev := filepath.Join(t.TempDir(), "slidev")
+	script := "#!/bin/sh\n[ -f fail ] && echo 'build failed' >&2 && exit 1\nmkdir -p dist\n" +
+		"printf '<!DOCTYPE html><html><head><title>Cours</title></head><body>Deck built from the job sources, padded to a realistic size.</body></html>' > dist/index.html\n"
+	require.NoError(t, os.WriteFile(slidev, []byte(script), 0o755))
+
+	run := func(t *testing.T, jobRetention, poolRetention models.SourceRetention, fail bool) (*JobResult, *models.GenerationJob, *storage.StorageService) {
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New(), SourceRetention: jobRetention}
+		jobService := &MockJobService{jobs: map[uuid.UUID]*models.GenerationJob{job.ID: job}}
+		storageService := storage.NewStorageService(&MockStorageBackend{})
+
+		ctx := context.Background()
+		require.NoError(t, storageService.UploadJobSource(ctx, job.ID, "slides.md", strings.NewReader("---\ntheme: default\n---\n# Cours\n")))
+		require.NoError(t, storageService.UploadJobSource(ctx, job.ID, "images/logo.png", strings.NewReader("png")))
+		if fail {
+			require.NoError(t, storageService.UploadJobSource(ctx, job.ID, "fail", strings.NewReader("")))
+		}
+
+		processor := NewJobProcessor(jobService, storageService, &PoolConfig{
+			WorkspaceBase:    t.TempDir(),
+			SlidevCommand:    slidev,
+			VersionCheckMode: VersionCheckOff,
+			CleanupWorkspace: true,
+			JobTimeout:       30 * time.Second,
+			SourceRetention:  poolRetention,
+		})
+		return processor.ProcessJob(ctx, job), job, storageService
+	}
+
+	sources := func(t *testing.T, storageService *storage.StorageService, jobID uuid.UUID) []string {
+		files, err := storageService.ListJobSources(context.Background(), jobID)
+		require.NoError(t, err)
+		return files
+	}
+
+	t.Run("Sources kept by default", func(t *testing.T) {
+		result, job, storageService := run(t, "", "", false)
+		require.True(t, result.Success, "job error: %v", result.Error)
+		assert.Len(t, sources(t, storageService, job.ID), 2)
+	})
+
+	t.Run("Sources deleted on success", func(t *testing.T) {
+		result, job, storageService := run(t, models.SourceRetentionDeleteOnSuccess, "", false)
+		require.True(t, result.Success, "job error: %v", result.Error)
+		assert.Equal(t, models.StatusCompleted, job.Status)
+		assert.Empty(t, sources(t, storageService, job.ID))
+
+		// Les résultats et les logs restent disponibles
+		results, err := storageService.ListResults(context.Background(), job.CourseID)
+		require.NoError(t, err)
+		assert.Contains(t, results, "index.html")
+		logs, err := storageService.GetJobLog(context.Background(), job.ID)
+		require.NoError(t, err)
+		assert.NotEmpty(t, logs)
+	})
+
+	t.Run("Sources kept on failure", func(t *testing.T) {
+		result, job, storageService := run(t, models.SourceRetentionDeleteOnSuccess, "", true)
+		assert.False(t, result.Success)
+		assert.Equal(t, models.StatusFailed, job.Status)
+		assert.Len(t, sources(t, storageService, job.ID), 3)
+	})
+
+	t.Run("Deployment default and request override", func(t *testing.T) {
+		result, job, storageService := run(t, "", models.SourceRetentionDeleteOnSuccess, false)
+		require.True(t, result.Success, "job error: %v", result.Error)
+		assert.Empty(t, sources(t, storageService, job.ID))
+
+		result, job, storageService = run(t, models.SourceRetentionKeep, models.SourceRetentionDeleteOnSuccess, false)
+		require.True(t, result.Success, "job error: %v", result.Error)
+		assert.Len(t, sources(t, storageService, job.ID), 2)
+	})
+}
+
+func TestProcessJobResultPrefix(t *testing.T) {
+	fakeSlidev(t)
+
+	slidev := filepath.Join(t.TempDir(), "slidev")
+	script := "#!/bin/sh\nmkdir -p dist/assets\n" +
+		"printf '<!DOCTYPE html><html><head><title>Cours</title></head><body>Deck built from the job sources, padded to a realistic size.</body></html>' > dist/index.html\n" +
+		"printf 'run()' > dist/assets/app.js\n"
+	require.NoError(t, os.WriteFile(slidev, []byte(script), 0o755))
+
+	job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New(), ResultPrefix: "acme/intro/v3"}
+	jobService := &MockJobService{jobs: map[uuid.UUID]*models.GenerationJob{job.ID: job}}
+	backend := &MockStorageBackend{}
+	storageService := storage.NewStorageService(backend)
+
+	ctx := context.Background()
+	require.NoError(t, storageService.UploadJobSource(ctx, job.ID, "slides.md", strings.NewReader("---\ntheme: default\n---\n# Cours\n")))
+
+	processor := NewJobProcessor(jobService, storageService, &PoolConfig{
+		WorkspaceBase:    t.TempDir(),
+		SlidevCommand:    slidev,
+		VersionCheckMode: VersionCheckOff,
+		CleanupWorkspace: true,
+		JobTimeout:       30 * time.Second,
+	})
+	result := processor.ProcessJob(ctx, job)
+	require.True(t, result.Success, "job error: %v", result.Error)
+
+	published := "published/" + job.CourseID.String() + "/acme/intro/v3/"
+	assert.Contains(t, backend.files, published+"index.html")
+	assert.Contains(t, backend.files, published+"assets/app.js")
+	for key := range backend.files {
+		assert.False(t, strings.HasPrefix(key, "results/"), "unexpected course result %s", key)
+	}
+
+	// Les résultats sont retrouvés à la destination du job
+	files, err := storageService.ListResults(storage.WithResultPrefix(ctx, job.ResultPrefix), job.CourseID)
+	require.NoError(t, err)
+	assert.Contains(t, files, "assets/app.js")
+}
+
+func TestResolveSlideFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "ocf-worker-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	workspace, err := NewWorkspace(tempDir, uuid.New())
+	require.NoError(t, err)
+
+	job := &models.GenerationJob{ID: uuid.New()}
+
+	t.Run("No slide file", func(t *testing.T) {
+		_, err := resolveSlideFile(workspace, job, nil)
+		assert.Error(t, err)
+	})
+
+	require.NoError(t, workspace.WriteFile("README.md", strings.NewReader("# Readme")))
+	require.NoError(t, workspace.WriteFile("deck.md", strings.NewReader("# Deck")))
+	require.NoError(t, workspace.WriteFile("cours/presentation.md", strings.NewReader("# Cours")))
+
+	t.Run("Default candidates", func(t *testing.T) {
+		slideFile, err := resolveSlideFile(workspace, job, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "README.md", slideFile)
+	})
+
+	t.Run("Configured candidates", func(t *testing.T) {
+		slideFile, err := resolveSlideFile(workspace, job, []string{"deck.md", "README.md"})
+		require.NoError(t, err)
+		assert.Equal(t, "deck.md", slideFile)
+	})
+
+	t.Run("Explicit entry file", func(t *testing.T) {
+		explicit := &models.GenerationJob{ID: uuid.New(), EntryFile: "cours/presentation.md"}
+		slideFile, err := resolveSlideFile(workspace, explicit, []string{"deck.md"})
+		require.NoError(t, err)
+		assert.Equal(t, "cours/presentation.md", slideFile)
+	})
+
+	t.Run("Missing explicit entry file", func(t *testing.T) {
+		missing := &models.GenerationJob{ID: uuid.New(), EntryFile: "absent.md"}
+		_, err := resolveSlideFile(workspace, missing, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "absent.md")
+	})
+}
+
+func TestPrepareSlidevEnvironment(t *testing.T) {
+	ctx := context.Background()
+	processor := NewJobProcessor(&MockJobService{}, nil, &PoolConfig{})
+	job := &models.GenerationJob{ID: uuid.New()}
+
+	t.Run("Existing files are detected", func(t *testing.T) {
+		workspace, err := NewWorkspace(t.TempDir(), uuid.New())
+		require.NoError(t, err)
+		require.NoError(t, workspace.WriteFile("package.json", strings.NewReader(`{"name":"cours"}`)))
+		require.NoError(t, workspace.WriteFile("slides.md", strings.NewReader("# Cours")))
+
+		preparation, err := processor.prepareSlidevEnvironment(ctx, job, workspace)
+		require.NoError(t, err)
+		assert.Equal(t, &SlidevPreparation{SlideFile: "slides.md"}, preparation)
+		assert.Equal(t, []string{
+			"Environment: package.json found in sources",
+			"Environment: slide file slides.md found in sources",
+		}, preparation.LogLines())
+	})
+
+	t.Run("Missing files are created", func(t *testing.T) {
+		workspace, err := NewWorkspace(t.TempDir(), uuid.New())
+		require.NoError(t, err)
+		require.NoError(t, workspace.WriteFile("images/logo.png", strings.NewReader("png")))
+
+		preparation, err := processor.prepareSlidevEnvironment(ctx, job, workspace)
+		require.NoError(t, err)
+		assert.Equal(t, &SlidevPreparation{PackageJSONCreated: true, SlideFile: "slides.md", SlideFileCreated: true}, preparation)
+		assert.True(t, workspace.FileExists("package.json"))
+		assert.True(t, workspace.FileExists("slides.md"))
+		assert.Equal(t, []string{
+			"Environment: package.json missing from sources, default package.json created",
+			"Environment: sources contain no slide file, default slides.md created with placeholder content",
+		}, preparation.LogLines())
+	})
+
+	t.Run("Missing explicit entry file is not replaced", func(t *testing.T) {
+		workspace, err := NewWorkspace(t.TempDir(), uuid.New())
+		require.NoError(t, err)
+
+		explicit := &models.GenerationJob{ID: uuid.New(), EntryFile: "cours/slides.md"}
+		preparation, err := processor.prepareSlidevEnvironment(ctx, explicit, workspace)
+		require.Error(t, err)
+		assert.False(t, preparation.SlideFileCreated)
+		assert.Contains(t, preparation.LogLines(), "Environment: slide file not found in sources")
+		assert.False(t, workspace.FileExists("slides.md"))
+	})
+}
+
+func TestProcessJobRecordsPreparation(t *testing.T) {
+	slidevCommand := fakeSlidev(t)
+	ctx := context.Background()
+
+	job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New()}
+	jobService := &MockJobService{jobs: map[uuid.UUID]*models.GenerationJob{job.ID: job}}
+	storageService := storage.NewStorageService(&MockStorageBackend{})
+	require.NoError(t, storageService.UploadJobSource(ctx, job.ID, "notes.txt", strings.NewReader("Pas de slides")))
+
+	processor := NewJobProcessor(jobService, storageService, &PoolConfig{
+		WorkspaceBase:    t.TempDir(),
+		SlidevCommand:    slidevCommand,
+		VersionCheckMode: VersionCheckOff,
+		CleanupWorkspace: true,
+		JobTimeout:       30 * time.Second,
+	})
+	result := processor.ProcessJob(ctx, job)
+	require.True(t, result.Success, "job error: %v", result.Error)
+
+	// Le deck de remplacement reste visible sur le job réussi, qui n'a pas de diagnostic
+	expected := &models.JobPreparation{PackageJSONCreated: true, SlideFile: "slides.md", SlideFileCreated: true}
+	assert.Equal(t, expected, job.Preparation)
+	assert.Equal(t, expected, job.ToResponse().Preparation)
+	require.Len(t, job.Warnings, 1)
+	assert.Contains(t, job.Warnings[0], "placeholder slides.md")
+}
+
 func TestWorkerPool(t *testing.T) {
 	// Mock job service pour les tests
 	mockJobService := &MockJobService{}
@@ -200,9 +1042,55 @@ func TestWorkerPool(t *testing.T) {
 	// des mocks plus sophistiqués pour éviter les side effects
 }
 
+func TestRecoverOrphanedJobs(t *testing.T) {
+	ctx := context.Background()
+	mockJobService := &MockJobService{jobs: make(map[uuid.UUID]*models.GenerationJob)}
+	addJob := func(status models.JobStatus, age time.Duration) *models.GenerationJob {
+		submittedAt := time.Now().Add(-age)
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New(), Status: status,
+			CreatedAt: submittedAt, UpdatedAt: submittedAt}
+		mockJobService.jobs[job.ID] = job
+		return job
+	}
+
+	orphaned := addJob(models.StatusPending, time.Hour)
+	recent := addJob(models.StatusPending, time.Second)
+	addJob(models.StatusProcessing, time.Hour)
+
+	// Un job récemment modifié (log ajouté) reste orphelin s'il a été soumis avant le délai
+	orphaned.UpdatedAt = time.Now()
+
+	pool := NewWorkerPool(mockJobService, nil, &PoolConfig{WorkerCount: 2, OrphanGracePeriod: time.Minute})
+
+	pool.recoverOrphanedJobs(ctx)
+	require.Len(t, pool.jobQueue, 1, "only the job pending for longer than the grace period is recovered")
+	assert.Equal(t, orphaned.ID, (<-pool.jobQueue).ID)
+
+	// Le polling régulier reprend les autres jobs pending sans remettre en file ceux qui y sont
+	pool.markQueued(orphaned)
+	require.NoError(t, pool.pollPendingJobs(ctx))
+	require.Len(t, pool.jobQueue, 1)
+	assert.Equal(t, recent.ID, (<-pool.jobQueue).ID)
+	require.NoError(t, pool.pollPendingJobs(ctx))
+	assert.Empty(t, pool.jobQueue, "queued jobs are not dispatched twice")
+
+	t.Run("A job is processed once", func(t *testing.T) {
+		worker := pool.workers[0]
+		orphaned.Status = models.StatusCompleted
+
+		worker.processJob(ctx, orphaned)
+		assert.Zero(t, worker.GetStats().JobsTotal, "a job that is no longer pending is skipped")
+
+		// Le job sorti de la file n'est plus compté comme en file par le pool
+		_, stillQueued := pool.queued[orphaned.ID]
+		assert.False(t, stillQueued)
+	})
+}
+
 // MockJobService implémente JobService pour les tests
 type MockJobService struct {
-	jobs map[uuid.UUID]*models.GenerationJob
+	jobs     map[uuid.UUID]*models.GenerationJob
+	profiles map[uuid.UUID]*models.GenerationProfile
 }
 
 func (m *MockJobService) CreateJob(ctx context.Context, req *models.GenerationRequest) (*models.GenerationJob, error) {
@@ -230,7 +1118,7 @@ func (m *MockJobService) GetJob(ctx context.Context, id uuid.UUID) (*models.Gene
 		return job, nil
 	}
 
-	return nil, fmt.Errorf("job not found")
+	return nil, fmt.Errorf("job not found: %w", gorm.ErrRecordNotFound)
 }
 
 func (m *MockJobService) ListJobs(ctx context.Context, status string, courseID *uuid.UUID) ([]*models.GenerationJob, error) {
@@ -245,6 +1133,224 @@ func (m *MockJobService) ListJobs(ctx context.Context, status string, courseID *
 	return result, nil
 }
 
+func (m *MockJobService) SearchJobs(ctx context.Context, filters jobs.JobFilters) ([]*models.GenerationJob, error) {
+	return m.ListJobs(ctx, filters.Status, filters.CourseID)
+}
+
+func (m *MockJobService) GetLatestBuild(ctx context.Context, courseID uuid.UUID, clientID string) (*models.GenerationJob, error) {
+	var latest *models.GenerationJob
+	for _, job := range m.jobs {
+		if job.CourseID == courseID && job.Status == models.StatusCompleted && job.ResultPrefix == "" && job.CompletedAt != nil &&
+			(latest == nil || job.CompletedAt.After(*latest.CompletedAt)) {
+			latest = job
+		}
+	}
+	return latest, nil
+}
+
+func (m *MockJobService) CountActiveJobsByClient(ctx context.Context, clientID string) (int, error) {
+	count := 0
+	for _, job := range m.jobs {
+		if job.ClientID == clientID && job.IsActive() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MockJobService) CountPendingJobs(ctx context.Context) (int, error) {
+	count := 0
+	for _, job := range m.jobs {
+		if job.Status == models.StatusPending {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MockJobService) ListPendingJobs(ctx context.Context, limit int) ([]*models.GenerationJob, error) {
+	var pending []*models.GenerationJob
+	for _, job := range m.jobs {
+		if job.Status == models.StatusPending {
+			pending = append(pending, job)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].CreatedAt.Before(pending[j].CreatedAt) })
+	if len(pending) > limit {
+		pending = pending[:limit]
+	}
+	return pending, nil
+}
+
+func (m *MockJobService) RecordCallbackAttempt(ctx context.Context, id uuid.UUID, deliveryErr error, retryAt *time.Time) (*models.GenerationJob, error) {
+	job, exists := m.jobs[id]
+	if !exists {
+		return nil, fmt.Errorf("job not found")
+	}
+
+	job.CallbackAttempts++
+	job.CallbackDelivered = deliveryErr == nil
+	job.CallbackNextAttemptAt = retryAt
+	if deliveryErr != nil {
+		job.CallbackLastError = deliveryErr.Error()
+	}
+	return job, nil
+}
+
+func (m *MockJobService) ScheduleCallback(ctx context.Context, id uuid.UUID, at *time.Time) error {
+	job, exists := m.jobs[id]
+	if !exists {
+		return fmt.Errorf("job not found")
+	}
+	job.CallbackNextAttemptAt = at
+	return nil
+}
+
+func (m *MockJobService) ClaimDueCallbacks(ctx context.Context, limit int, lease time.Duration) ([]*models.GenerationJob, error) {
+	return nil, nil
+}
+
+func (m *MockJobService) CountPendingCallbacks(ctx context.Context) (int, error) {
+	count := 0
+	for _, job := range m.jobs {
+		if job.CallbackNextAttemptAt != nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MockJobService) GetGenerationProfile(ctx context.Context, courseID uuid.UUID) (*models.GenerationProfile, error) {
+	if profile, exists := m.profiles[courseID]; exists {
+		return profile, nil
+	}
+	return nil, fmt.Errorf("generation profile not found: %w", gorm.ErrRecordNotFound)
+}
+
+func (m *MockJobService) SetGenerationProfile(ctx context.Context, courseID uuid.UUID, req *models.GenerationProfileRequest) (*models.GenerationProfile, error) {
+	if m.profiles == nil {
+		m.profiles = make(map[uuid.UUID]*models.GenerationProfile)
+	}
+	profile := &models.GenerationProfile{
+		CourseID:        courseID,
+		EntryFile:       req.EntryFile,
+		OutputDir:       req.OutputDir,
+		BuildFlags:      req.BuildFlags,
+		Themes:          req.Themes,
+		CheckLinks:      req.CheckLinks,
+		CompressResults: req.CompressResults,
+		Thumbnail:       req.Thumbnail,
+		SourceRetention: req.SourceRetention,
+	}
+	m.profiles[courseID] = profile
+	return profile, nil
+}
+
+func (m *MockJobService) DeleteGenerationProfile(ctx context.Context, courseID uuid.UUID) (bool, error) {
+	_, exists := m.profiles[courseID]
+	delete(m.profiles, courseID)
+	return exists, nil
+}
+
+func (m *MockJobService) SetJobEntryPoints(ctx context.Context, id uuid.UUID, entryPoints []string) error {
+	job, exists := m.jobs[id]
+	if !exists {
+		return fmt.Errorf("job not found")
+	}
+
+	job.EntryPoints = entryPoints
+	return nil
+}
+
+func (m *MockJobService) SetJobThumbnail(ctx context.Context, id uuid.UUID, path string) error {
+	job, exists := m.jobs[id]
+	if !exists {
+		return fmt.Errorf("job not found")
+	}
+
+	job.ThumbnailPath = path
+	return nil
+}
+
+func (m *MockJobService) SetJobOptions(ctx context.Context, id uuid.UUID, options *models.GenerationJob) error {
+	job, exists := m.jobs[id]
+	if !exists {
+		return fmt.Errorf("job not found")
+	}
+
+	job.EntryFile = options.EntryFile
+	job.OutputDir = options.OutputDir
+	job.BuildFlags = options.BuildFlags
+	job.Themes = options.Themes
+	job.CheckLinks = options.CheckLinks
+	job.CompressResults = options.CompressResults
+	job.Thumbnail = options.Thumbnail
+	job.SourceRetention = options.SourceRetention
+	return nil
+}
+
+func (m *MockJobService) SetJobPreparation(ctx context.Context, id uuid.UUID, preparation *models.JobPreparation) error {
+	job, exists := m.jobs[id]
+	if !exists {
+		return fmt.Errorf("job not found")
+	}
+
+	job.Preparation = preparation
+	return nil
+}
+
+func (m *MockJobService) SetJobBuildStats(ctx context.Context, id uuid.UUID, stats *models.BuildStats) error {
+	job, exists := m.jobs[id]
+	if !exists {
+		return fmt.Errorf("job not found")
+	}
+
+	job.SourceFileCount = stats.SourceFileCount
+	job.SourceSizeBytes = stats.SourceSizeBytes
+	job.ResultSizeBytes = stats.ResultSizeBytes
+	job.Theme = stats.Theme
+	job.SlideCount = stats.SlideCount
+	job.Warnings = stats.Warnings
+	job.SourceHash = stats.SourceHash
+	return nil
+}
+
+func (m *MockJobService) SetJobThemeResults(ctx context.Context, id uuid.UUID, results []models.ThemeBuildResult) error {
+	job, exists := m.jobs[id]
+	if !exists {
+		return fmt.Errorf("job not found")
+	}
+
+	job.ThemeResults = results
+	return nil
+}
+
+func (m *MockJobService) RecordJobAttempt(ctx context.Context, id uuid.UUID, attempt models.JobAttempt) error {
+	job, exists := m.jobs[id]
+	if !exists {
+		return fmt.Errorf("job not found")
+	}
+
+	attempts, closed := job.Attempts.Close(attempt)
+	if !closed {
+		job.AttemptCount++
+		attempt.Number = job.AttemptCount
+		attempts = job.Attempts.Append(attempt)
+	}
+	job.Attempts = attempts
+	return nil
+}
+
+func (m *MockJobService) GetLatencySLO(ctx context.Context, filters jobs.SLOFilters) (*models.LatencySLO, error) {
+	// Mock implementation
+	return &models.LatencySLO{}, nil
+}
+
+func (m *MockJobService) EstimateBuild(ctx context.Context, req *models.EstimateRequest) (*models.BuildEstimate, error) {
+	// Mock implementation
+	return &models.BuildEstimate{Basis: "none", Confidence: models.EstimateConfidenceNone}, nil
+}
+
 func (m *MockJobService) UpdateJobStatus(ctx context.Context, id uuid.UUID, status models.JobStatus, progress int, errorMsg string) error {
 	if m.jobs == nil {
 		m.jobs = make(map[uuid.UUID]*models.GenerationJob)
@@ -262,6 +1368,36 @@ func (m *MockJobService) UpdateJobStatus(ctx context.Context, id uuid.UUID, stat
 	return fmt.Errorf("job not found")
 }
 
+func (m *MockJobService) ClaimJob(ctx context.Context, id uuid.UUID) (bool, error) {
+	job, exists := m.jobs[id]
+	if !exists {
+		return false, fmt.Errorf("job not found")
+	}
+	if job.Status != models.StatusPending {
+		return false, nil
+	}
+
+	job.Status = models.StatusProcessing
+	job.Error = ""
+	job.AttemptCount++
+	job.Attempts = job.Attempts.Open(job.AttemptCount, time.Now())
+	return true, nil
+}
+
+func (m *MockJobService) CancelPendingJob(ctx context.Context, id uuid.UUID, reason string) (bool, error) {
+	job, exists := m.jobs[id]
+	if !exists {
+		return false, fmt.Errorf("job not found")
+	}
+	if job.Status != models.StatusPending {
+		return false, nil
+	}
+
+	job.Status = models.StatusFailed
+	job.Error = reason
+	return true, nil
+}
+
 func (m *MockJobService) AddJobLog(ctx context.Context, id uuid.UUID, logEntry string) error {
 	// Mock implementation
 	return nil
@@ -340,6 +1476,347 @@ func (m *MockStorageBackend) GetURL(ctx context.Context, path string) (string, e
 	return "http://mock-storage/" + path, nil
 }
 
+func TestUploadResultsManifest(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "ocf-worker-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New()}
+	workspace, err := NewWorkspace(tempDir, job.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, workspace.WriteFile("dist/index.html", strings.NewReader("<html>hello</html>")))
+	require.NoError(t, workspace.WriteFile("dist/assets/app.js", strings.NewReader("console.log(1)")))
+
+	storageService := storage.NewStorageService(&MockStorageBackend{})
+	processor := NewJobProcessor(&MockJobService{}, storageService, &PoolConfig{WorkspaceBase: tempDir})
+
+	uploaded, err := processor.uploadResults(context.Background(), job, workspace)
+	require.NoError(t, err)
+
+	manifest, err := storageService.GetResultManifest(context.Background(), job.CourseID)
+	require.NoError(t, err)
+	assert.Equal(t, uploaded.TotalSize, manifest.TotalSize)
+
+	assert.Equal(t, job.ID, manifest.JobID)
+	assert.Equal(t, 2, manifest.FileCount)
+	assert.Equal(t, int64(len("<html>hello</html>")+len("console.log(1)")), manifest.TotalSize)
+
+	entries := make(map[string]models.ManifestEntry)
+	for _, entry := range manifest.Files {
+		entries[entry.Path] = entry
+	}
+
+	require.Contains(t, entries, "index.html")
+	require.Contains(t, entries, "assets/app.js")
+	assert.Contains(t, entries["index.html"].ContentType, "text/html")
+	assert.Equal(t, "sha256:", entries["index.html"].Hash[:7])
+	assert.Equal(t, job.ID, entries["assets/app.js"].JobID)
+}
+
+func TestUploadResultsNestedDist(t *testing.T) {
+	distFiles := []string{
+		"index.html",
+		"404.html",
+		"assets/index-a1b2c3.js",
+		"assets/slidev/fonts/inter/inter-latin-400.woff2",
+		"assets/slidev/monaco/editor/editor.worker-d4e5f6.js",
+		"assets/vendor..shared-789abc.js",
+		".vite/manifest.json",
+	}
+
+	upload := func(t *testing.T, outputDir, prefix string) (*MockStorageBackend, *models.GenerationJob, *models.ResultManifest) {
+		tempDir := t.TempDir()
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New()}
+		workspace, err := NewWorkspace(tempDir, job.ID)
+		require.NoError(t, err)
+		require.NoError(t, workspace.SetOutputDir(outputDir))
+		for _, file := range distFiles {
+			require.NoError(t, workspace.WriteFile(path.Join(workspace.GetDistPath(), file), strings.NewReader("content of "+file)))
+		}
+
+		backend := &MockStorageBackend{}
+		processor := NewJobProcessor(&MockJobService{}, storage.NewStorageService(backend), &PoolConfig{WorkspaceBase: tempDir})
+		manifest := newResultManifest(job)
+		require.NoError(t, processor.uploadResultFiles(context.Background(), job, workspace, prefix, manifest))
+		return backend, job, manifest
+	}
+
+	for _, tc := range []struct {
+		name      string
+		outputDir string
+		prefix    string
+	}{
+		{"Default dist", "", ""},
+		{"Nested output directory", "site/public_html", ""},
+		{"Theme prefix", "", "themes/seriph"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			backend, job, manifest := upload(t, tc.outputDir, tc.prefix)
+
+			// Chaque fichier de dist est uploadé à results/{course_id}/[prefix/]<chemin dans dist>
+			var expected, manifestPaths []string
+			for _, file := range distFiles {
+				key := path.Join(tc.prefix, file)
+				expected = append(expected, fmt.Sprintf("results/%s/%s", job.CourseID, key))
+				manifestPaths = append(manifestPaths, key)
+
+				content, ok := backend.files[fmt.Sprintf("results/%s/%s", job.CourseID, key)]
+				require.True(t, ok, "missing result %s", key)
+				assert.Equal(t, "content of "+file, string(content))
+			}
+
+			var uploaded, entries []string
+			for key := range backend.files {
+				uploaded = append(uploaded, key)
+			}
+			for _, entry := range manifest.Files {
+				entries = append(entries, entry.Path)
+			}
+			assert.ElementsMatch(t, expected, uploaded)
+			assert.ElementsMatch(t, manifestPaths, entries)
+		})
+	}
+}
+
+func TestUploadResultsCompression(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "ocf-worker-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	ctx := context.Background()
+	courseID := uuid.New()
+	storageService := storage.NewStorageService(&MockStorageBackend{})
+	page := "<html>" + strings.Repeat("<p>slide</p>", 200) + "</html>"
+
+	build := func(t *testing.T, config *PoolConfig, job *models.GenerationJob) *models.ResultManifest {
+		workspace, err := NewWorkspace(tempDir, job.ID)
+		require.NoError(t, err)
+		require.NoError(t, workspace.WriteFile("dist/index.html", strings.NewReader(page)))
+		require.NoError(t, workspace.WriteFile("dist/assets/small.js", strings.NewReader("console.log(1)")))
+		require.NoError(t, workspace.WriteFile("dist/logo.png", strings.NewReader(strings.Repeat("x", 2048))))
+
+		config.WorkspaceBase = tempDir
+		manifest, err := NewJobProcessor(&MockJobService{}, storageService, config).uploadResults(ctx, job, workspace)
+		require.NoError(t, err)
+		return manifest
+	}
+
+	t.Run("Requested by the job", func(t *testing.T) {
+		enabled := true
+		manifest := build(t, &PoolConfig{}, &models.GenerationJob{ID: uuid.New(), CourseID: courseID, CompressResults: &enabled})
+
+		results, err := storageService.ListResults(ctx, courseID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"index.html", "index.html.gz", "assets/small.js", "logo.png"}, results,
+			"small and binary files are not compressed")
+
+		for _, entry := range manifest.Files {
+			if entry.Path == "index.html" {
+				assert.Equal(t, []string{"gzip"}, entry.Encodings)
+			} else {
+				assert.Empty(t, entry.Encodings, entry.Path)
+			}
+		}
+		assert.Equal(t, 3, manifest.FileCount, "variants are not listed as files")
+
+		reader, err := storageService.DownloadResult(ctx, courseID, "index.html.gz")
+		require.NoError(t, err)
+		gzipReader, err := gzip.NewReader(reader)
+		require.NoError(t, err)
+		content, err := io.ReadAll(gzipReader)
+		require.NoError(t, err)
+		assert.Equal(t, page, string(content))
+	})
+
+	t.Run("Rebuild without compression removes stale variants", func(t *testing.T) {
+		build(t, &PoolConfig{}, &models.GenerationJob{ID: uuid.New(), CourseID: courseID})
+
+		results, err := storageService.ListResults(ctx, courseID)
+		require.NoError(t, err)
+		assert.NotContains(t, results, "index.html.gz")
+	})
+
+	t.Run("Configured for all builds", func(t *testing.T) {
+		build(t, &PoolConfig{ResultCompression: []string{"gzip", "br"}}, &models.GenerationJob{ID: uuid.New(), CourseID: courseID})
+
+		results, err := storageService.ListResults(ctx, courseID)
+		require.NoError(t, err)
+		assert.Contains(t, results, "index.html.gz")
+		assert.NotContains(t, results, "index.html.br", "unsupported encodings are ignored")
+	})
+}
+
+func TestUploadResultsQuota(t *testing.T) {
+	ctx := context.Background()
+	job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New()}
+	storageService := storage.NewStorageService(&MockStorageBackend{})
+	require.NoError(t, storageService.UploadResult(ctx, job.CourseID, "old/index.html", strings.NewReader(strings.Repeat("x", 600))))
+
+	workspace, err := NewWorkspace(t.TempDir(), job.ID)
+	require.NoError(t, err)
+	require.NoError(t, workspace.WriteFile("dist/index.html", strings.NewReader(strings.Repeat("x", 500))))
+
+	processor := NewJobProcessor(&MockJobService{}, storageService, &PoolConfig{})
+
+	storageService.SetResultQuota(1000, nil)
+	_, err = processor.uploadResults(ctx, job, workspace)
+	require.ErrorIs(t, err, storage.ErrResultQuotaExceeded)
+
+	results, err := storageService.ListResults(ctx, job.CourseID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old/index.html"}, results, "nothing is uploaded past the quota")
+
+	storageService.SetResultQuota(1100, nil)
+	_, err = processor.uploadResults(ctx, job, workspace)
+	require.NoError(t, err)
+}
+
+func TestUploadResultsQuotaCountsVariants(t *testing.T) {
+	ctx := context.Background()
+	job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New()}
+	storageService := storage.NewStorageService(&MockStorageBackend{})
+
+	workspace, err := NewWorkspace(t.TempDir(), job.ID)
+	require.NoError(t, err)
+	require.NoError(t, workspace.WriteFile("dist/index.html", strings.NewReader(strings.Repeat("x", 2000))))
+
+	processor := NewJobProcessor(&MockJobService{}, storageService, &PoolConfig{ResultCompression: []string{"gzip"}})
+
+	// index.html tient seul dans le quota, pas avec sa variante index.html.gz
+	storageService.SetResultQuota(2000, nil)
+	_, err = processor.uploadResults(ctx, job, workspace)
+	require.ErrorIs(t, err, storage.ErrResultQuotaExceeded)
+
+	storageService.SetResultQuota(2100, nil)
+	_, err = processor.uploadResults(ctx, job, workspace)
+	require.NoError(t, err)
+
+	results, err := storageService.ListResults(ctx, job.CourseID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"index.html", "index.html.gz"}, results)
+
+	// Un rebuild remplace les deux fichiers : ils ne sont comptés qu'une fois
+	_, err = processor.uploadResults(ctx, job, workspace)
+	require.NoError(t, err)
+}
+
+// slowStorageBackend bloque le téléchargement des fichiers de slowPrefix jusqu'à l'annulation
+type slowStorageBackend struct {
+	*MockStorageBackend
+	slowPrefix string
+}
+
+func (s *slowStorageBackend) Download(ctx context.Context, path string) (io.Reader, error) {
+	if strings.Contains(path, s.slowPrefix) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return s.MockStorageBackend.Download(ctx, path)
+}
+
+func TestDownloadSourcesTimeout(t *testing.T) {
+	job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New()}
+	storageService := storage.NewStorageService(&slowStorageBackend{MockStorageBackend: &MockStorageBackend{}, slowPrefix: "videos/"})
+	require.NoError(t, storageService.UploadJobSource(context.Background(), job.ID, "a-slides.md", strings.NewReader("# Cours")))
+	require.NoError(t, storageService.UploadJobSourceWithPath(context.Background(), job.ID, "videos/intro.mp4", strings.NewReader("mp4")))
+
+	download := func(t *testing.T, ctx context.Context, timeout time.Duration) error {
+		workspace, err := NewWorkspace(t.TempDir(), job.ID)
+		require.NoError(t, err)
+		processor := NewJobProcessor(&MockJobService{}, storageService, &PoolConfig{SourceDownloadTimeout: timeout})
+		_, err = processor.downloadSources(ctx, job, workspace)
+		return err
+	}
+
+	t.Run("Download phase timeout", func(t *testing.T) {
+		err := download(t, context.Background(), 50*time.Millisecond)
+		require.ErrorIs(t, err, ErrSourceDownloadTimeout)
+		// L'ordre du listing n'est pas garanti : le fichier lent peut être le premier
+		assert.Regexp(t, `source download timed out after 50ms \((0/2 files, 0|1/2 files, 7) bytes downloaded\)`, err.Error())
+	})
+
+	t.Run("Job timeout is not reported as a download timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		err := download(t, ctx, time.Minute)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrSourceDownloadTimeout)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestPruneStaleResults(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "ocf-worker-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	ctx := context.Background()
+	courseID := uuid.New()
+	storageService := storage.NewStorageService(&MockStorageBackend{})
+	processor := NewJobProcessor(&MockJobService{}, storageService, &PoolConfig{WorkspaceBase: tempDir})
+
+	// Résultats d'un build précédent, dont une page supprimée depuis
+	for _, name := range []string{"index.html", "old-chapter.html", "assets/old.js"} {
+		require.NoError(t, storageService.UploadResult(ctx, courseID, name, strings.NewReader("old")))
+	}
+
+	job := &models.GenerationJob{ID: uuid.New(), CourseID: courseID, ForceRebuild: true}
+	workspace, err := NewWorkspace(tempDir, job.ID)
+	require.NoError(t, err)
+	require.NoError(t, workspace.WriteFile("dist/index.html", strings.NewReader("<html>new</html>")))
+	require.NoError(t, workspace.WriteFile("dist/assets/app.js", strings.NewReader("console.log(2)")))
+
+	manifest, err := processor.uploadResults(ctx, job, workspace)
+	require.NoError(t, err)
+	require.NoError(t, processor.pruneStaleResults(ctx, job, manifest))
+
+	results, err := storageService.ListResults(ctx, courseID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"index.html", "assets/app.js"}, results)
+}
+
+func TestProcessJobForceRebuild(t *testing.T) {
+	slidev := fakeSlidev(t)
+	ctx := context.Background()
+	courseID := uuid.New()
+	storageService := storage.NewStorageService(&MockStorageBackend{})
+	jobService := &MockJobService{jobs: map[uuid.UUID]*models.GenerationJob{}}
+	processor := NewJobProcessor(jobService, storageService, &PoolConfig{
+		WorkspaceBase:    t.TempDir(),
+		SlidevCommand:    slidev,
+		VersionCheckMode: VersionCheckOff,
+		CleanupWorkspace: true,
+		JobTimeout:       30 * time.Second,
+	})
+
+	// Build identique au précédent : mêmes sources pour le même cours
+	build := func(t *testing.T, forceRebuild bool) {
+		job := &models.GenerationJob{ID: uuid.New(), CourseID: courseID, ForceRebuild: forceRebuild}
+		jobService.jobs[job.ID] = job
+		require.NoError(t, storageService.UploadJobSource(ctx, job.ID, "slides.md", strings.NewReader("---\ntheme: default\n---\n# Cours\n")))
+
+		result := processor.ProcessJob(ctx, job)
+		require.True(t, result.Success, "job error: %v", result.Error)
+		assert.Equal(t, models.StatusCompleted, job.Status)
+	}
+
+	// Page d'une version précédente du cours, absente des nouveaux builds
+	require.NoError(t, storageService.UploadResult(ctx, courseID, "old-chapter.html", strings.NewReader("old")))
+
+	build(t, false)
+	results, err := storageService.ListResults(ctx, courseID)
+	require.NoError(t, err)
+	assert.Contains(t, results, "old-chapter.html", "a normal build keeps the existing results")
+
+	build(t, true)
+	results, err = storageService.ListResults(ctx, courseID)
+	require.NoError(t, err)
+	assert.Contains(t, results, "index.html")
+	assert.NotContains(t, results, "old-chapter.html", "a forced rebuild must not reuse the previous results")
+}
+
 func TestIntegrationWorkflow(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
@@ -395,8 +1872,9 @@ This is a test presentation.
 
 	// Tester la validation de sortie
 	runner := NewSlidevRunner(&PoolConfig{})
-	err = runner.validateOutput(workspace)
+	entryPoints, err := runner.validateOutput(workspace)
 	assert.NoError(t, err)
+	assert.Equal(t, []string{"index.html"}, entryPoints)
 
 	// Cleanup
 	err = workspace.Cleanup()
@@ -2,12 +2,20 @@
 package worker
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/internal/jobs"
+	"github.com/Open-Course-Factory/ocf-worker/internal/validation"
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
 
 	"github.com/google/uuid"
 )
@@ -18,6 +26,12 @@ type Workspace struct {
 	basePath string
 	path     string
 	distPath string
+
+	// outputDir est le dossier de sortie du build, relatif au workspace ("" = DefaultOutputDir)
+	outputDir string
+
+	// memoryLimit borne les sous-processus lancés dans le workspace (nil = sans limite)
+	memoryLimit *MemoryLimit
 }
 
 // NewWorkspace crée un nouveau workspace pour un job avec gestion des permissions
@@ -96,6 +110,7 @@ func (wm *WorkspaceManager) GetWorkspaceStats() (WorkspaceStats, error) {
 	for _, ws := range workspaces {
 		stats.TotalSizeBytes += ws.SizeBytes
 		stats.TotalFileCount += ws.FileCount
+		stats.TotalDependencyBytes += ws.DependencyBytes
 
 		if ws.DistExists {
 			stats.WorkspacesWithDist++
@@ -107,19 +122,39 @@ func (wm *WorkspaceManager) GetWorkspaceStats() (WorkspaceStats, error) {
 
 // WorkspaceStats contient des statistiques sur les workspaces
 type WorkspaceStats struct {
-	TotalWorkspaces    int   `json:"total_workspaces"`
-	WorkspacesWithDist int   `json:"workspaces_with_dist"`
-	TotalSizeBytes     int64 `json:"total_size_bytes"`
-	TotalFileCount     int   `json:"total_file_count"`
+	TotalWorkspaces      int   `json:"total_workspaces"`
+	WorkspacesWithDist   int   `json:"workspaces_with_dist"`
+	TotalSizeBytes       int64 `json:"total_size_bytes"`
+	TotalFileCount       int   `json:"total_file_count"`
+	TotalDependencyBytes int64 `json:"total_dependency_bytes"`
 }
 
 func (w *Workspace) GetPath() string {
 	return w.path
 }
 
+// DefaultOutputDir est le dossier de sortie des builds sans output_dir
+const DefaultOutputDir = "dist"
+
+// SetOutputDir choisit le dossier de sortie du build ("" = DefaultOutputDir)
+func (w *Workspace) SetOutputDir(dir string) error {
+	if dir == "" {
+		dir = DefaultOutputDir
+	}
+	if !validation.IsSafeOutputDir(dir) {
+		return fmt.Errorf("invalid output directory %q", dir)
+	}
+	w.outputDir = dir
+	w.distPath = filepath.Join(w.path, filepath.FromSlash(dir))
+	return nil
+}
+
 // GetDistPath retourne le chemin du répertoire de sortie
 func (w *Workspace) GetDistPath() string {
-	return "dist" // Chemin relatif au workspace
+	if w.outputDir != "" {
+		return w.outputDir
+	}
+	return DefaultOutputDir // Chemin relatif au workspace
 }
 
 // GetAbsDistPath retourne le chemin absolu du répertoire de sortie
@@ -127,10 +162,19 @@ func (w *Workspace) GetAbsDistPath() string {
 	return w.distPath
 }
 
+// isSafeWorkspacePath vérifie qu'un chemin relatif reste dans le workspace : ni absolu, ni
+// segment ".." (un nom de fichier comme "chunk..js" est accepté)
+func isSafeWorkspacePath(name string) bool {
+	if strings.HasPrefix(name, "/") || filepath.IsAbs(name) {
+		return false
+	}
+	return !slices.Contains(strings.Split(filepath.ToSlash(name), "/"), "..")
+}
+
 // WriteFile écrit un fichier dans le workspace avec gestion d'erreurs améliorée
 func (w *Workspace) WriteFile(filename string, reader io.Reader) error {
 	// Sécurité: éviter les chemins qui remontent dans l'arborescence
-	if strings.Contains(filename, "..") || strings.HasPrefix(filename, "/") {
+	if !isSafeWorkspacePath(filename) {
 		return fmt.Errorf("invalid filename: %s", filename)
 	}
 
@@ -160,7 +204,7 @@ func (w *Workspace) WriteFile(filename string, reader io.Reader) error {
 // ReadFile lit un fichier depuis le workspace
 func (w *Workspace) ReadFile(filename string) (io.Reader, error) {
 	// Sécurité: éviter les chemins qui remontent dans l'arborescence
-	if strings.Contains(filename, "..") || strings.HasPrefix(filename, "/") {
+	if !isSafeWorkspacePath(filename) {
 		return nil, fmt.Errorf("invalid filename: %s", filename)
 	}
 
@@ -176,7 +220,7 @@ func (w *Workspace) ReadFile(filename string) (io.Reader, error) {
 
 // FileExists vérifie si un fichier existe dans le workspace
 func (w *Workspace) FileExists(filename string) bool {
-	if strings.Contains(filename, "..") || strings.HasPrefix(filename, "/") {
+	if !isSafeWorkspacePath(filename) {
 		return false
 	}
 
@@ -187,7 +231,7 @@ func (w *Workspace) FileExists(filename string) bool {
 
 // DirExists vérifie si un répertoire existe dans le workspace
 func (w *Workspace) DirExists(dirname string) bool {
-	if strings.Contains(dirname, "..") || strings.HasPrefix(dirname, "/") {
+	if !isSafeWorkspacePath(dirname) {
 		return false
 	}
 
@@ -198,7 +242,7 @@ func (w *Workspace) DirExists(dirname string) bool {
 
 // GetFileSize retourne la taille d'un fichier
 func (w *Workspace) GetFileSize(filename string) (int64, error) {
-	if strings.Contains(filename, "..") || strings.HasPrefix(filename, "/") {
+	if !isSafeWorkspacePath(filename) {
 		return 0, fmt.Errorf("invalid filename: %s", filename)
 	}
 
@@ -213,7 +257,7 @@ func (w *Workspace) GetFileSize(filename string) (int64, error) {
 
 // ListFiles liste les fichiers dans un répertoire du workspace
 func (w *Workspace) ListFiles(dirname string) ([]string, error) {
-	if strings.Contains(dirname, "..") || strings.HasPrefix(dirname, "/") {
+	if !isSafeWorkspacePath(dirname) {
 		return nil, fmt.Errorf("invalid directory name: %s", dirname)
 	}
 
@@ -236,7 +280,7 @@ func (w *Workspace) ListFiles(dirname string) ([]string, error) {
 
 // ListAllFiles liste récursivement tous les fichiers dans un répertoire
 func (w *Workspace) ListAllFiles(dirname string) ([]string, error) {
-	if strings.Contains(dirname, "..") || strings.HasPrefix(dirname, "/") {
+	if !isSafeWorkspacePath(dirname) {
 		return nil, fmt.Errorf("invalid directory name: %s", dirname)
 	}
 
@@ -269,7 +313,7 @@ func (w *Workspace) ListAllFiles(dirname string) ([]string, error) {
 
 // CreateDirectory crée un répertoire dans le workspace
 func (w *Workspace) CreateDirectory(dirname string) error {
-	if strings.Contains(dirname, "..") || strings.HasPrefix(dirname, "/") {
+	if !isSafeWorkspacePath(dirname) {
 		return fmt.Errorf("invalid directory name: %s", dirname)
 	}
 
@@ -280,8 +324,7 @@ func (w *Workspace) CreateDirectory(dirname string) error {
 // CopyFile copie un fichier à l'intérieur du workspace
 func (w *Workspace) CopyFile(src, dst string) error {
 	// Validation des chemins
-	if strings.Contains(src, "..") || strings.HasPrefix(src, "/") ||
-		strings.Contains(dst, "..") || strings.HasPrefix(dst, "/") {
+	if !isSafeWorkspacePath(src) || !isSafeWorkspacePath(dst) {
 		return fmt.Errorf("invalid file paths: %s -> %s", src, dst)
 	}
 
@@ -315,8 +358,32 @@ func (w *Workspace) CopyFile(src, dst string) error {
 	return nil
 }
 
-// GetWorkspaceInfo retourne des informations sur le workspace
+// dependencyDirs sont les répertoires de dépendances, comptés à part dans les statistiques
+var dependencyDirs = map[string]bool{
+	"node_modules":       true,
+	workspaceNpmCacheDir: true,
+}
+
+// isDependencyPath indique si un chemin relatif au workspace appartient aux dépendances
+func isDependencyPath(relPath string) bool {
+	top := strings.SplitN(filepath.ToSlash(relPath), "/", 2)[0]
+	return dependencyDirs[top]
+}
+
+// WorkspaceStatsOptions configure le calcul des statistiques d'un workspace
+type WorkspaceStatsOptions struct {
+	// IncludeDependencies compte node_modules et .npm-cache dans SizeBytes et FileCount.
+	// Ils sont toujours reportés séparément dans DependencyBytes et DependencyFileCount.
+	IncludeDependencies bool
+}
+
+// GetWorkspaceInfo retourne des informations sur le workspace, hors dépendances
 func (w *Workspace) GetWorkspaceInfo() WorkspaceInfo {
+	return w.GetWorkspaceInfoWithOptions(WorkspaceStatsOptions{})
+}
+
+// GetWorkspaceInfoWithOptions retourne des informations sur le workspace
+func (w *Workspace) GetWorkspaceInfoWithOptions(options WorkspaceStatsOptions) WorkspaceInfo {
 	info := WorkspaceInfo{
 		JobID:    w.jobID.String(),
 		Path:     w.path,
@@ -324,15 +391,31 @@ func (w *Workspace) GetWorkspaceInfo() WorkspaceInfo {
 		Exists:   w.DirExists("."),
 	}
 
-	// Calculer la taille utilisée
-	if size, err := w.calculateSize("."); err == nil {
-		info.SizeBytes = size
+	if stat, err := os.Stat(w.path); err == nil {
+		info.ModifiedAt = stat.ModTime()
+	}
+
+	// Calculer la taille utilisée par catégorie
+	if usage, err := w.calculateUsage(); err == nil {
+		info.SourceBytes = usage.sourceBytes
+		info.DistBytes = usage.distBytes
+		info.DependencyBytes = usage.dependencyBytes
+		info.DependencyFileCount = usage.dependencyFiles
+		info.SizeBytes = usage.sourceBytes + usage.distBytes
+		if options.IncludeDependencies {
+			info.SizeBytes += usage.dependencyBytes
+		}
 	}
 
-	// Compter les fichiers
+	// Lister les fichiers
 	if files, err := w.ListAllFiles("."); err == nil {
-		info.FileCount = len(files)
-		info.Files = files
+		for _, file := range files {
+			if !options.IncludeDependencies && isDependencyPath(file) {
+				continue
+			}
+			info.Files = append(info.Files, file)
+		}
+		info.FileCount = len(info.Files)
 	}
 
 	// Vérifier si dist existe
@@ -347,22 +430,44 @@ func (w *Workspace) GetWorkspaceInfo() WorkspaceInfo {
 	return info
 }
 
-// calculateSize calcule la taille totale d'un répertoire
-func (w *Workspace) calculateSize(dirname string) (int64, error) {
-	dirPath := filepath.Join(w.path, dirname)
-	var size int64
+// workspaceUsage contient la répartition de l'espace disque d'un workspace
+type workspaceUsage struct {
+	sourceBytes     int64
+	distBytes       int64
+	dependencyBytes int64
+	dependencyFiles int
+}
 
-	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+// calculateUsage calcule la taille du workspace répartie entre sources, dist et dépendances
+func (w *Workspace) calculateUsage() (workspaceUsage, error) {
+	var usage workspaceUsage
+
+	err := filepath.Walk(w.path, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() {
-			size += info.Size()
+		if info.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(w.path, path)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case isDependencyPath(relPath):
+			usage.dependencyBytes += info.Size()
+			usage.dependencyFiles++
+		case strings.HasPrefix(filepath.ToSlash(relPath), "dist/"):
+			usage.distBytes += info.Size()
+		default:
+			usage.sourceBytes += info.Size()
 		}
 		return nil
 	})
 
-	return size, err
+	return usage, err
 }
 
 // Cleanup supprime le workspace et tous ses fichiers avec vérifications de sécurité
@@ -385,62 +490,198 @@ func (w *Workspace) Cleanup() error {
 	return nil
 }
 
+// RemoveDirectory supprime un sous-répertoire du workspace et retourne l'espace libéré.
+// Le reste du workspace (sources, logs) est conservé.
+func (w *Workspace) RemoveDirectory(dirname string) (int64, error) {
+	dirname = filepath.Clean(dirname)
+	if dirname == "." || !isSafeWorkspacePath(dirname) {
+		return 0, fmt.Errorf("invalid directory name: %s", dirname)
+	}
+
+	// Mêmes vérifications de sécurité que Cleanup
+	if w.path == "" || w.path == "/" {
+		return 0, fmt.Errorf("invalid workspace path: %s", w.path)
+	}
+	if !strings.Contains(w.path, w.jobID.String()) {
+		return 0, fmt.Errorf("workspace path doesn't contain job ID, refusing removal: %s", w.path)
+	}
+
+	dirPath := filepath.Join(w.path, dirname)
+	info, err := os.Lstat(dirPath)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat %s: %w", dirPath, err)
+	}
+	if !info.IsDir() {
+		return 0, fmt.Errorf("%s is not a directory", dirname)
+	}
+
+	var freed int64
+	err = filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			freed += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute size of %s: %w", dirPath, err)
+	}
+
+	log.Printf("Removing %s from workspace for job %s (%d bytes)", dirname, w.jobID, freed)
+
+	if err := os.RemoveAll(dirPath); err != nil {
+		return 0, fmt.Errorf("failed to remove %s: %w", dirPath, err)
+	}
+
+	return freed, nil
+}
+
 // WorkspaceInfo contient des informations sur un workspace
 type WorkspaceInfo struct {
-	JobID         string   `json:"job_id"`
-	Path          string   `json:"path"`
-	DistPath      string   `json:"dist_path"`
-	Exists        bool     `json:"exists"`
-	SizeBytes     int64    `json:"size_bytes"`
-	FileCount     int      `json:"file_count"`
-	Files         []string `json:"files,omitempty"`
-	DistExists    bool     `json:"dist_exists"`
-	DistFileCount int      `json:"dist_file_count"`
-	DistFiles     []string `json:"dist_files,omitempty"`
-}
-
-// WorkspaceManager gère les workspaces globalement
+	JobID               string    `json:"job_id"`
+	Path                string    `json:"path"`
+	DistPath            string    `json:"dist_path"`
+	Exists              bool      `json:"exists"`
+	SizeBytes           int64     `json:"size_bytes"`
+	FileCount           int       `json:"file_count"`
+	Files               []string  `json:"files,omitempty"`
+	DistExists          bool      `json:"dist_exists"`
+	DistFileCount       int       `json:"dist_file_count"`
+	DistFiles           []string  `json:"dist_files,omitempty"`
+	SourceBytes         int64     `json:"source_bytes"`
+	DistBytes           int64     `json:"dist_bytes"`
+	DependencyBytes     int64     `json:"dependency_bytes"`
+	DependencyFileCount int       `json:"dependency_file_count"`
+	ModifiedAt          time.Time `json:"modified_at"`
+}
+
+// ErrWorkspaceNotFound est retournée quand aucun workspace n'existe pour un job
+var ErrWorkspaceNotFound = errors.New("workspace not found")
+
+// DefaultCleanupProtectedStatuses retourne les statuts de job dont le workspace n'est pas
+// supprimé par le nettoyage : le job est en file ou en cours de build
+func DefaultCleanupProtectedStatuses() []models.JobStatus {
+	return []models.JobStatus{models.StatusPending, models.StatusProcessing}
+}
+
+// WorkspaceManager gère les workspaces globalement, répartis sur un ou plusieurs
+// répertoires de base
 type WorkspaceManager struct {
-	basePath string
+	basePaths    []string
+	statsOptions WorkspaceStatsOptions
+
+	// jobService permet au nettoyage de conserver les workspaces des jobs dont le statut
+	// est dans protectedStatuses (nil = nettoyage sur l'âge seul)
+	jobService        jobs.JobService
+	protectedStatuses []models.JobStatus
 }
 
-// NewWorkspaceManager crée un nouveau gestionnaire de workspaces
-func NewWorkspaceManager(basePath string) (*WorkspaceManager, error) {
-	// S'assurer que le répertoire de base est accessible
-	if err := ensureBaseDirectory(basePath); err != nil {
-		return nil, fmt.Errorf("failed to initialize workspace manager: %w", err)
+// NewWorkspaceManager crée un nouveau gestionnaire des workspaces des répertoires de base
+func NewWorkspaceManager(basePaths ...string) (*WorkspaceManager, error) {
+	// S'assurer que les répertoires de base sont accessibles
+	for _, basePath := range basePaths {
+		if err := ensureBaseDirectory(basePath); err != nil {
+			return nil, fmt.Errorf("failed to initialize workspace manager: %w", err)
+		}
 	}
 
 	return &WorkspaceManager{
-		basePath: basePath,
+		basePaths: basePaths,
 	}, nil
 }
 
-// ListWorkspaces liste tous les workspaces existants
-func (wm *WorkspaceManager) ListWorkspaces() ([]WorkspaceInfo, error) {
-	entries, err := os.ReadDir(wm.basePath)
+// SetStatsOptions configure le calcul des statistiques des workspaces
+func (wm *WorkspaceManager) SetStatsOptions(options WorkspaceStatsOptions) {
+	wm.statsOptions = options
+}
+
+// SetCleanupProtection fait vérifier au nettoyage le statut du job de chaque workspace :
+// ceux des jobs dont le statut est dans statuses sont conservés (vide = statuts par défaut)
+func (wm *WorkspaceManager) SetCleanupProtection(jobService jobs.JobService, statuses []models.JobStatus) {
+	if len(statuses) == 0 {
+		statuses = DefaultCleanupProtectedStatuses()
+	}
+	wm.jobService = jobService
+	wm.protectedStatuses = statuses
+}
+
+// findWorkspace retourne le workspace d'un job dans le premier répertoire de base qui le contient
+func (wm *WorkspaceManager) findWorkspace(jobID uuid.UUID) (*Workspace, error) {
+	for _, basePath := range wm.basePaths {
+		workspacePath := filepath.Join(basePath, jobID.String())
+		if stat, err := os.Stat(workspacePath); err == nil && stat.IsDir() {
+			return &Workspace{
+				jobID:    jobID,
+				basePath: basePath,
+				path:     workspacePath,
+				distPath: filepath.Join(workspacePath, "dist"),
+			}, nil
+		}
+	}
+	return nil, ErrWorkspaceNotFound
+}
+
+// GetWorkspaceInfo retourne les informations du workspace d'un job
+func (wm *WorkspaceManager) GetWorkspaceInfo(jobID uuid.UUID) (WorkspaceInfo, error) {
+	workspace, err := wm.findWorkspace(jobID)
+	if err != nil {
+		return WorkspaceInfo{}, err
+	}
+
+	return workspace.GetWorkspaceInfoWithOptions(wm.statsOptions), nil
+}
+
+// RemoveWorkspaceDirectory supprime un sous-répertoire du workspace d'un job
+func (wm *WorkspaceManager) RemoveWorkspaceDirectory(jobID uuid.UUID, dirname string) (int64, error) {
+	workspace, err := wm.findWorkspace(jobID)
+	if err != nil {
+		return 0, err
+	}
+
+	return workspace.RemoveDirectory(dirname)
+}
+
+// readBaseDirectory liste les entrées d'un répertoire de base (aucune s'il n'existe pas)
+func readBaseDirectory(basePath string) ([]os.DirEntry, error) {
+	entries, err := os.ReadDir(basePath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return []WorkspaceInfo{}, nil
+			return nil, nil
 		}
 		return nil, fmt.Errorf("failed to read workspace directory: %w", err)
 	}
+	return entries, nil
+}
 
-	var workspaces []WorkspaceInfo
+// ListWorkspaces liste tous les workspaces existants, tous répertoires de base confondus
+func (wm *WorkspaceManager) ListWorkspaces() ([]WorkspaceInfo, error) {
+	workspaces := []WorkspaceInfo{}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			// Vérifier si c'est un UUID valide (workspace de job)
-			if jobID, err := uuid.Parse(entry.Name()); err == nil {
-				workspace := &Workspace{
-					jobID:    jobID,
-					basePath: wm.basePath,
-					path:     filepath.Join(wm.basePath, entry.Name()),
-					distPath: filepath.Join(wm.basePath, entry.Name(), "dist"),
-				}
+	for _, basePath := range wm.basePaths {
+		entries, err := readBaseDirectory(basePath)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() {
+				// Vérifier si c'est un UUID valide (workspace de job)
+				if jobID, err := uuid.Parse(entry.Name()); err == nil {
+					workspace := &Workspace{
+						jobID:    jobID,
+						basePath: basePath,
+						path:     filepath.Join(basePath, entry.Name()),
+						distPath: filepath.Join(basePath, entry.Name(), "dist"),
+					}
 
-				info := workspace.GetWorkspaceInfo()
-				workspaces = append(workspaces, info)
+					info := workspace.GetWorkspaceInfoWithOptions(wm.statsOptions)
+					workspaces = append(workspaces, info)
+				}
 			}
 		}
 	}
@@ -448,34 +689,36 @@ func (wm *WorkspaceManager) ListWorkspaces() ([]WorkspaceInfo, error) {
 	return workspaces, nil
 }
 
-// CleanupOldWorkspaces supprime les workspaces plus anciens qu'une durée donnée
-func (wm *WorkspaceManager) CleanupOldWorkspaces(maxAge int64) (int, error) {
-	entries, err := os.ReadDir(wm.basePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return 0, nil
-		}
-		return 0, fmt.Errorf("failed to read workspace directory: %w", err)
-	}
-
+// CleanupOldWorkspaces supprime les workspaces modifiés avant maxAge (timestamp Unix), dans
+// tous les répertoires de base. La date de modification du répertoire ne suit pas un build
+// en cours : avec une protection configurée, les workspaces des jobs actifs sont conservés
+// quel que soit leur âge.
+func (wm *WorkspaceManager) CleanupOldWorkspaces(ctx context.Context, maxAge int64) (int, error) {
 	cleaned := 0
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			// Vérifier si c'est un UUID valide
-			if jobID, err := uuid.Parse(entry.Name()); err == nil {
-				workspacePath := filepath.Join(wm.basePath, entry.Name())
-
-				// Vérifier l'âge du workspace
-				if info, err := entry.Info(); err == nil {
-					if info.ModTime().Unix() < maxAge {
-						workspace := &Workspace{
-							jobID: jobID,
-							path:  workspacePath,
-						}
+	for _, basePath := range wm.basePaths {
+		entries, err := readBaseDirectory(basePath)
+		if err != nil {
+			return cleaned, err
+		}
 
-						if err := workspace.Cleanup(); err == nil {
-							cleaned++
+		for _, entry := range entries {
+			if entry.IsDir() {
+				// Vérifier si c'est un UUID valide
+				if jobID, err := uuid.Parse(entry.Name()); err == nil {
+					workspacePath := filepath.Join(basePath, entry.Name())
+
+					// Vérifier l'âge du workspace
+					if info, err := entry.Info(); err == nil {
+						if info.ModTime().Unix() < maxAge && !wm.isCleanupProtected(ctx, jobID) {
+							workspace := &Workspace{
+								jobID: jobID,
+								path:  workspacePath,
+							}
+
+							if err := workspace.Cleanup(); err == nil {
+								cleaned++
+							}
 						}
 					}
 				}
@@ -486,4 +729,27 @@ func (wm *WorkspaceManager) CleanupOldWorkspaces(maxAge int64) (int, error) {
 	return cleaned, nil
 }
 
+// isCleanupProtected indique si le workspace d'un job doit survivre au nettoyage. Un job
+// supprimé ne protège pas son workspace ; un statut illisible le conserve par prudence.
+func (wm *WorkspaceManager) isCleanupProtected(ctx context.Context, jobID uuid.UUID) bool {
+	if wm.jobService == nil {
+		return false
+	}
+
+	job, err := wm.jobService.GetJob(ctx, jobID)
+	if err != nil {
+		if jobs.IsJobNotFound(err) {
+			return false
+		}
+		log.Printf("Keeping workspace for job %s: status unavailable: %v", jobID, err)
+		return true
+	}
+
+	if slices.Contains(wm.protectedStatuses, job.Status) {
+		log.Printf("Keeping workspace for job %s: job is %s", jobID, job.Status)
+		return true
+	}
+	return false
+}
+
 // Get
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // ArchiveResponse représente la réponse de création d'archive
 // @Description Résultat de la création d'une archive de résultats
@@ -30,3 +34,20 @@ type ArchiveInfo struct {
 	SizeMB       float64 `json:"size_mb" example:"2.0"`
 	CreationTime string  `json:"creation_time" example:"250ms"`
 } // @name ArchiveInfo
+
+// JobBundleVersion est la version du format des bundles de job
+const JobBundleVersion = "1"
+
+// JobBundleMetadata décrit le contenu d'un bundle de job (fichier bundle.json)
+// @Description Métadonnées d'un bundle de diagnostic d'un job
+type JobBundleMetadata struct {
+	BundleVersion   string       `json:"bundle_version" example:"1"`
+	GeneratedAt     time.Time    `json:"generated_at" example:"2025-01-17T10:30:00Z"`
+	Job             *JobResponse `json:"job"`
+	Sources         []string     `json:"sources" example:"slides.md,assets/logo.png"`
+	HasStorageLog   bool         `json:"has_storage_log" example:"true"`
+	IncludesResults bool         `json:"includes_results" example:"false"`
+	Results         []string     `json:"results,omitempty" example:"index.html"`
+	// ResultsJobID est le job ayant produit les résultats du cours (ils peuvent provenir d'un build plus récent)
+	ResultsJobID *uuid.UUID `json:"results_job_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440001"`
+} // @name JobBundleMetadata
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// BatchItemStatus est le résultat de la soumission d'un élément d'un batch
+type BatchItemStatus string
+
+const (
+	BatchItemCreated         BatchItemStatus = "created"
+	BatchItemValidationError BatchItemStatus = "validation_error"
+	BatchItemError           BatchItemStatus = "error"
+)
+
+// BatchGenerationRequest regroupe plusieurs demandes de génération
+// @Description Soumission groupée de jobs de génération
+type BatchGenerationRequest struct {
+	Jobs []GenerationRequest `json:"jobs"`
+} // @name BatchGenerationRequest
+
+// BatchItemResult décrit le sort d'un élément du batch
+// @Description Résultat de la soumission d'un job du batch
+type BatchItemResult struct {
+	Index            int               `json:"index" example:"0"`
+	JobID            uuid.UUID         `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Status           BatchItemStatus   `json:"status" example:"created" enums:"created,validation_error,error"`
+	Job              *JobResponse      `json:"job,omitempty"`
+	Error            string            `json:"error,omitempty"`
+	ValidationErrors []ValidationError `json:"validation_errors,omitempty"`
+} // @name BatchItemResult
+
+// BatchGenerationResponse est la réponse d'une soumission groupée
+// @Description Résultats élément par élément d'une soumission groupée
+type BatchGenerationResponse struct {
+	Created int               `json:"created" example:"2"`
+	Failed  int               `json:"failed" example:"1"`
+	Results []BatchItemResult `json:"results"`
+} // @name BatchGenerationResponse
@@ -0,0 +1,47 @@
+package models
+
+// Catégories de diagnostic d'un job en échec
+const (
+	DiagnosisDownloadTimeout  = "download_timeout"
+	DiagnosisTimeout          = "timeout"
+	DiagnosisOutOfMemory      = "out_of_memory"
+	DiagnosisMissingSlideFile = "missing_slide_file"
+	DiagnosisBrokenInclude    = "broken_include"
+	DiagnosisThemeInstall     = "theme_install"
+	DiagnosisNpmError         = "npm_error"
+	DiagnosisOutputValidation = "output_validation"
+	DiagnosisResultQuota      = "result_quota"
+	DiagnosisStorageError     = "storage_error"
+	DiagnosisDefaultSlideFile = "default_slide_file"
+	DiagnosisBuildError       = "build_error"
+	DiagnosisUnknown          = "unknown"
+)
+
+// Sources de la preuve d'un diagnostic
+const (
+	DiagnosisSourceError         = "job_error"      // Erreur enregistrée sur le job
+	DiagnosisSourceJobLogs       = "job_logs"       // Logs d'avancement du job
+	DiagnosisSourceGenerationLog = "generation_log" // Sortie de Slidev et npm
+)
+
+// DiagnosisFinding est une cause d'échec reconnue dans l'état ou les logs d'un job
+// @Description Cause d'échec reconnue, avec la ligne qui l'a révélée et les corrections suggérées
+type DiagnosisFinding struct {
+	Category    string   `json:"category" example:"missing_slide_file" enums:"download_timeout,timeout,out_of_memory,missing_slide_file,broken_include,theme_install,npm_error,output_validation,result_quota,storage_error,default_slide_file,build_error"`
+	Summary     string   `json:"summary" example:"No slide file was found in the job sources"`
+	Suggestions []string `json:"suggestions"`
+	Evidence    string   `json:"evidence" example:"no slide file found (checked: [slides.md])"`
+	Source      string   `json:"source" example:"job_error" enums:"job_error,job_logs,generation_log"`
+} // @name DiagnosisFinding
+
+// JobDiagnosis est l'analyse de l'échec d'un job
+// @Description Diagnostic d'un job en échec : cause principale et toutes les causes reconnues
+type JobDiagnosis struct {
+	JobID       string             `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Status      JobStatus          `json:"status" example:"failed"`
+	Error       string             `json:"error,omitempty" example:"failed to download sources: no slide file found (checked: [slides.md])"`
+	Category    string             `json:"category" example:"missing_slide_file"`
+	Summary     string             `json:"summary" example:"No slide file was found in the job sources"`
+	Suggestions []string           `json:"suggestions"`
+	Findings    []DiagnosisFinding `json:"findings"`
+} // @name JobDiagnosis
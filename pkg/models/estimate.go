@@ -0,0 +1,46 @@
+package models
+
+// Niveaux de confiance d'une estimation de build
+const (
+	EstimateConfidenceHigh   = "high"
+	EstimateConfidenceMedium = "medium"
+	EstimateConfidenceLow    = "low"
+	EstimateConfidenceNone   = "none"
+)
+
+// BuildStats contient les caractéristiques mesurées d'un build terminé
+type BuildStats struct {
+	SourceFileCount int
+	SourceSizeBytes int64
+	ResultSizeBytes int64
+	Theme           string
+	SlideCount      int
+
+	// SourceHash est l'empreinte des sources du build (voir storage.SourceHash)
+	SourceHash string
+
+	// Warnings sont les avertissements de taille du deck, sans effet sur le build
+	Warnings []string
+}
+
+// EstimateRequest décrit les sources d'un cours sans les uploader
+// @Description Caractéristiques des sources d'un cours pour estimer son build
+type EstimateRequest struct {
+	SourceFileCount int    `json:"source_file_count" example:"12"`
+	SourceSizeBytes int64  `json:"source_size_bytes" example:"524288"`
+	Theme           string `json:"theme,omitempty" example:"seriph"`
+} // @name EstimateRequest
+
+// BuildEstimate est l'estimation de la durée et de la taille d'un build
+// @Description Estimation d'un build à partir des builds terminés comparables
+type BuildEstimate struct {
+	SourceFileCount          int     `json:"source_file_count" example:"12"`
+	SourceSizeBytes          int64   `json:"source_size_bytes" example:"524288"`
+	Theme                    string  `json:"theme,omitempty" example:"seriph"`
+	EstimatedDurationSeconds float64 `json:"estimated_duration_seconds" example:"74.5"`
+	EstimatedDuration        string  `json:"estimated_duration" example:"1m15s"`
+	EstimatedResultSizeBytes int64   `json:"estimated_result_size_bytes" example:"3145728"`
+	SampleSize               int64   `json:"sample_size" example:"42"`
+	Basis                    string  `json:"basis" example:"theme_and_size" enums:"theme_and_size,size,theme,all,none"`
+	Confidence               string  `json:"confidence" example:"high" enums:"high,medium,low,none"`
+} // @name BuildEstimate
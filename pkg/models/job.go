@@ -20,6 +20,17 @@ const (
 	StatusTimeout    JobStatus = "timeout"
 )
 
+// SourceRetention définit le sort des sources d'un job après un build réussi
+type SourceRetention string
+
+const (
+	// SourceRetentionKeep conserve les sources jusqu'au nettoyage explicite du job (défaut)
+	SourceRetentionKeep SourceRetention = "keep"
+	// SourceRetentionDeleteOnSuccess supprime les sources une fois le job terminé avec
+	// succès ; les résultats et les logs sont conservés
+	SourceRetentionDeleteOnSuccess SourceRetention = "delete-on-success"
+)
+
 // JSON type for PostgreSQL compatibility
 type JSON map[string]interface{}
 
@@ -88,6 +99,40 @@ func (ss *StringSlice) Scan(value interface{}) error {
 	return json.Unmarshal(bytes, ss)
 }
 
+// StringMap type for PostgreSQL JSON objects with string values
+type StringMap map[string]string
+
+func (sm StringMap) Value() (driver.Value, error) {
+	if sm == nil {
+		return json.Marshal(map[string]string{})
+	}
+	return json.Marshal(map[string]string(sm))
+}
+
+func (sm *StringMap) Scan(value interface{}) error {
+	if value == nil {
+		*sm = StringMap{}
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into StringMap", value)
+	}
+
+	if len(bytes) == 0 {
+		*sm = StringMap{}
+		return nil
+	}
+
+	return json.Unmarshal(bytes, sm)
+}
+
 // GenerationJob est le modèle principal pour la base de données
 type GenerationJob struct {
 	ID          uuid.UUID   `json:"id" gorm:"type:uuid;primary_key"`
@@ -96,15 +141,82 @@ type GenerationJob struct {
 	Progress    int         `json:"progress" gorm:"default:0;check:progress >= 0 AND progress <= 100"`
 	SourcePath  string      `json:"source_path" gorm:"type:text;not null"`
 	ResultPath  string      `json:"result_path" gorm:"type:text"`
+	EntryPoints StringSlice `json:"entry_points" gorm:"type:jsonb;default:'[]'"`
+	EntryFile   string      `json:"entry_file,omitempty" gorm:"type:text"`
+	OutputDir   string      `json:"output_dir,omitempty" gorm:"type:text"`
+	CheckLinks  *bool       `json:"check_links,omitempty"`
 	CallbackURL string      `json:"callback_url" gorm:"type:text"`
 	NpmPackages StringSlice `json:"npm_packages" gorm:"type:jsonb;default:'[]'"`
 	Error       string      `json:"error,omitempty" gorm:"type:text"`
 	Logs        StringSlice `json:"logs" gorm:"type:jsonb;default:'[]'"`
-	Metadata    JSON        `json:"metadata" gorm:"type:jsonb;default:'{}'"`
+	Metadata    JSON        `json:"metadata" gorm:"type:jsonb;default:'{}';index:idx_generation_jobs_metadata,type:gin"`
+	ClientID    string      `json:"client_id,omitempty" gorm:"type:varchar(255);index"`
 	CreatedAt   time.Time   `json:"created_at" gorm:"index"`
 	UpdatedAt   time.Time   `json:"updated_at"`
 	StartedAt   *time.Time  `json:"started_at,omitempty" gorm:"index"`
 	CompletedAt *time.Time  `json:"completed_at,omitempty" gorm:"index"`
+
+	// État de livraison du callback ; CallbackNextAttemptAt est l'échéance de la livraison
+	// en file (nil = aucune livraison en attente)
+	CallbackDelivered     bool       `json:"callback_delivered" gorm:"default:false"`
+	CallbackAttempts      int        `json:"callback_attempts" gorm:"default:0"`
+	CallbackLastError     string     `json:"callback_last_error,omitempty" gorm:"type:text"`
+	CallbackLastAttemptAt *time.Time `json:"callback_last_attempt_at,omitempty"`
+	CallbackNextAttemptAt *time.Time `json:"callback_next_attempt_at,omitempty" gorm:"index"`
+
+	// ForceRebuild ignore le cache de build et retire les résultats absents du nouveau build
+	ForceRebuild bool `json:"force_rebuild" gorm:"default:false"`
+
+	// BuildFlags sont les options Slidev supplémentaires (liste autorisée) du build
+	BuildFlags StringSlice `json:"build_flags" gorm:"type:jsonb;default:'[]'"`
+
+	// Labels sont les étiquettes clé/valeur d'organisation du job (index GIN pour le filtrage)
+	Labels StringMap `json:"labels" gorm:"type:jsonb;default:'{}';index:idx_generation_jobs_labels,type:gin"`
+
+	// CompressResults précompresse les résultats texte en variantes .gz. Comme CheckLinks et
+	// Thumbnail, nil signifie que la requête ne l'a pas précisé (profil du cours, sinon désactivé) ;
+	// les options effectives du build remplacent celles de la requête au démarrage du job
+	CompressResults *bool `json:"compress_results,omitempty"`
+
+	// Themes construit une variante du deck par thème (mode matrice) dans theme-<nom>/
+	Themes StringSlice `json:"themes" gorm:"type:jsonb;default:'[]'"`
+
+	// ThemeResults est le résultat du build de chaque thème du mode matrice
+	ThemeResults ThemeBuildResults `json:"theme_results" gorm:"type:jsonb;default:'[]'"`
+
+	// Thumbnail demande une miniature de la première slide ; ThumbnailPath est son chemin dans
+	// les résultats du cours une fois générée
+	Thumbnail     *bool  `json:"thumbnail,omitempty"`
+	ThumbnailPath string `json:"thumbnail_path,omitempty" gorm:"type:text"`
+
+	// SourceRetention est le sort des sources après un build réussi (vide = politique du worker)
+	SourceRetention SourceRetention `json:"source_retention,omitempty" gorm:"type:varchar(20)"`
+
+	// ResultPrefix remplace la destination results/{course_id}/ des résultats par
+	// published/{course_id}/<prefix>/ (vide = résultats du cours)
+	ResultPrefix string `json:"result_prefix,omitempty" gorm:"type:varchar(255)"`
+
+	// AttemptCount compte les traitements du job ; Attempts garde les derniers (MaxJobAttemptHistory)
+	AttemptCount int         `json:"attempt_count" gorm:"default:0"`
+	Attempts     JobAttempts `json:"attempts" gorm:"type:jsonb;default:'[]'"`
+
+	// Caractéristiques du build, base des estimations des prochains jobs
+	SourceFileCount int    `json:"source_file_count,omitempty" gorm:"default:0"`
+	SourceSizeBytes int64  `json:"source_size_bytes,omitempty" gorm:"default:0"`
+	ResultSizeBytes int64  `json:"result_size_bytes,omitempty" gorm:"default:0"`
+	Theme           string `json:"theme,omitempty" gorm:"type:varchar(255);index"`
+	SlideCount      int    `json:"slide_count,omitempty" gorm:"default:0"`
+
+	// SourceHash est l'empreinte des sources du build, comparée par GET /jobs/should-rebuild
+	SourceHash string `json:"source_hash,omitempty" gorm:"type:varchar(71)"`
+
+	// Warnings sont les avertissements d'un build réussi (deck trop long, résultats volumineux,
+	// deck de remplacement, code de sortie non nul accepté)
+	Warnings StringSlice `json:"warnings" gorm:"type:jsonb;default:'[]'"`
+
+	// Preparation est ce que la préparation de l'environnement Slidev a trouvé ou créé
+	// (nil tant que le job n'a pas atteint cette étape)
+	Preparation *JobPreparation `json:"preparation,omitempty" gorm:"type:jsonb"`
 }
 
 // TableName spécifie le nom de la table
@@ -128,6 +240,9 @@ func (j *GenerationJob) BeforeCreate(tx *gorm.DB) error {
 	if j.Metadata == nil {
 		j.Metadata = JSON{}
 	}
+	if j.Labels == nil {
+		j.Labels = StringMap{}
+	}
 
 	return nil
 }
@@ -147,48 +262,197 @@ type GenerationRequest struct {
 	CallbackURL string                 `json:"callback_url,omitempty"`
 	Packages    []string               `json:"packages,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+
+	// EntryFile force le fichier de slides à construire (sinon détection automatique)
+	EntryFile string `json:"entry_file,omitempty" example:"cours/presentation.md"`
+
+	// OutputDir est le dossier de sortie du build dans le workspace, pour les cours dont
+	// l'outillage produit ailleurs que dans dist (chemin relatif, défaut: dist)
+	OutputDir string `json:"output_dir,omitempty" example:"build"`
+
+	// SourceRetention choisit pour ce job le sort des sources après un build réussi :
+	// "keep" ou "delete-on-success" (vide = politique du worker, SOURCE_RETENTION)
+	SourceRetention SourceRetention `json:"source_retention,omitempty" example:"delete-on-success" enums:"keep,delete-on-success"`
+
+	// ResultPrefix écrit les résultats sous published/{course_id}/<prefix>/ au lieu des résultats
+	// du cours, pour une disposition imposée par un hébergement externe (segments [A-Za-z0-9._-]
+	// séparés par "/")
+	ResultPrefix string `json:"result_prefix,omitempty" example:"acme/intro-go/v3"`
+
+	// CheckLinks active la recherche des assets référencés mais absents du build ; absent, la
+	// valeur du profil de génération du cours s'applique, false la désactive même si le profil l'active
+	CheckLinks *bool `json:"check_links,omitempty" example:"true"`
+
+	// ForceRebuild reconstruit sans réutiliser le cache de build (build plus lent) et
+	// retire des résultats du cours les fichiers que le nouveau build ne produit plus
+	ForceRebuild bool `json:"force_rebuild,omitempty" example:"false"`
+
+	// BuildFlags ajoute des options à "slidev build", parmi --download, --inspect,
+	// --without-notes et --base=<chemin> ; toute autre option est refusée
+	BuildFlags []string `json:"build_flags,omitempty" example:"--download,--base=/cours/intro/"`
+
+	// Labels organisent les jobs (environnement, équipe, pipeline) et se filtrent avec
+	// ?label=clé:valeur ; contrairement aux metadata, clés et valeurs suivent un format contraint
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// CompressResults précompresse les résultats HTML/CSS/JS (variantes .gz servies avec
+	// Content-Encoding aux clients qui les acceptent), même si le worker ne le fait pas par défaut ;
+	// absent, la valeur du profil du cours s'applique
+	CompressResults *bool `json:"compress_results,omitempty" example:"true"`
+
+	// CancelOnDisconnect garde la requête de création ouverte jusqu'au démarrage du job ;
+	// si le client se déconnecte avant, le job encore pending est annulé
+	CancelOnDisconnect bool `json:"cancel_on_disconnect,omitempty" example:"true"`
+
+	// Themes construit le deck une fois par thème à partir des mêmes sources (mode matrice) :
+	// chaque variante est publiée dans results/{course_id}/theme-<nom>/ ; le job réussit si au
+	// moins un thème est construit, le détail est dans theme_results
+	Themes []string `json:"themes,omitempty" example:"seriph,apple-basic"`
+
+	// Thumbnail exporte la première slide en PNG après le build, publiée dans
+	// results/{course_id}/thumbnail.png (thumbnail_url du job) ; ignoré en mode matrice ;
+	// absent, la valeur du profil du cours s'applique
+	Thumbnail *bool `json:"thumbnail,omitempty" example:"true"`
+
+	// ClientID identifie le client soumetteur, renseigné par l'API (jamais par le body)
+	ClientID string `json:"-" swaggerignore:"true"`
 } // @name GenerationRequest
 
 // JobResponse représente la réponse contenant les détails d'un job
 // @Description Détails complets d'un job de génération
 type JobResponse struct {
-	ID          uuid.UUID              `json:"id"`
-	CourseID    uuid.UUID              `json:"course_id"`
-	Status      JobStatus              `json:"status"`
-	Progress    int                    `json:"progress"`
-	SourcePath  string                 `json:"source_path"`
-	ResultPath  string                 `json:"result_path,omitempty"`
-	CallbackURL string                 `json:"callback_url,omitempty"`
-	Error       string                 `json:"error,omitempty"`
-	Logs        []string               `json:"logs,omitempty"`
-	Metadata    map[string]interface{} `json:"metadata,omitempty"`
-	CreatedAt   time.Time              `json:"created_at"`
-	UpdatedAt   time.Time              `json:"updated_at"`
-	StartedAt   *time.Time             `json:"started_at,omitempty"`
-	CompletedAt *time.Time             `json:"completed_at,omitempty"`
+	ID          uuid.UUID               `json:"id"`
+	CourseID    uuid.UUID               `json:"course_id"`
+	Status      JobStatus               `json:"status"`
+	Progress    int                     `json:"progress"`
+	SourcePath  string                  `json:"source_path"`
+	ResultPath  string                  `json:"result_path,omitempty"`
+	EntryPoints []string                `json:"entry_points,omitempty" example:"index.html,speaker.html"`
+	EntryFile   string                  `json:"entry_file,omitempty" example:"slides.md"`
+	OutputDir   string                  `json:"output_dir,omitempty" example:"build"`
+	CheckLinks  bool                    `json:"check_links,omitempty"`
+	CallbackURL string                  `json:"callback_url,omitempty"`
+	Error       string                  `json:"error,omitempty"`
+	Logs        []string                `json:"logs,omitempty"`
+	Metadata    map[string]interface{}  `json:"metadata,omitempty"`
+	Callback    *CallbackDeliveryStatus `json:"callback,omitempty"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+	StartedAt   *time.Time              `json:"started_at,omitempty"`
+	CompletedAt *time.Time              `json:"completed_at,omitempty"`
+
+	ForceRebuild bool     `json:"force_rebuild,omitempty"`
+	BuildFlags   []string `json:"build_flags,omitempty" example:"--download"`
+
+	Labels map[string]string `json:"labels,omitempty"`
+
+	CompressResults bool `json:"compress_results,omitempty"`
+
+	Themes       []string           `json:"themes,omitempty" example:"seriph,apple-basic"`
+	ThemeResults []ThemeBuildResult `json:"theme_results,omitempty"`
+
+	// ThumbnailURL est la route de téléchargement de la miniature de la première slide
+	Thumbnail    bool   `json:"thumbnail,omitempty"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty" example:"/api/v1/storage/courses/550e8400-e29b-41d4-a716-446655440001/results/thumbnail.png"`
+
+	SourceRetention SourceRetention `json:"source_retention,omitempty" example:"delete-on-success"`
+
+	// ResultPrefix est la destination personnalisée des résultats, ResultsURL la route qui les liste
+	ResultPrefix string `json:"result_prefix,omitempty" example:"acme/intro-go/v3"`
+	ResultsURL   string `json:"results_url,omitempty" example:"/api/v1/storage/jobs/550e8400-e29b-41d4-a716-446655440000/results"`
+
+	// AttemptCount compte les traitements du job, Attempts détaille les derniers (le plus récent en dernier)
+	AttemptCount int          `json:"attempt_count" example:"2"`
+	Attempts     []JobAttempt `json:"attempts,omitempty"`
+
+	// SlideCount est le nombre de slides du deck, Warnings les avertissements du build réussi
+	SlideCount int      `json:"slide_count,omitempty" example:"42"`
+	Warnings   []string `json:"warnings,omitempty" example:"Deck has 250 slides, above the warning threshold of 200: consider splitting it"`
+
+	// SourceHash est l'empreinte des sources d'un build réussi
+	SourceHash string `json:"source_hash,omitempty" example:"sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+
+	// Preparation indique les fichiers trouvés dans les sources ou créés par le worker avant le
+	// build, dont un deck de remplacement (slide_file_created)
+	Preparation *JobPreparation `json:"preparation,omitempty"`
 } // @name JobResponse
 
+// CallbackDeliveryStatus représente l'état de livraison du callback d'un job
+// @Description État de livraison du webhook de fin de job
+type CallbackDeliveryStatus struct {
+	Delivered     bool       `json:"delivered" example:"false"`
+	Attempts      int        `json:"attempts" example:"2"`
+	LastError     string     `json:"last_error,omitempty" example:"callback returned status 503"`
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty" example:"2025-01-15T10:35:00Z"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" example:"2025-01-15T10:36:20Z"`
+} // @name CallbackDeliveryStatus
+
 // ToResponse convertit un GenerationJob en JobResponse
 func (j *GenerationJob) ToResponse() *JobResponse {
 	// Convertir les types personnalisés en types standard
 	logs := []string(j.Logs)
 	metadata := map[string]interface{}(j.Metadata)
 
+	// L'état du callback n'est exposé que si un callback est configuré
+	var callback *CallbackDeliveryStatus
+	if j.CallbackURL != "" {
+		callback = &CallbackDeliveryStatus{
+			Delivered:     j.CallbackDelivered,
+			Attempts:      j.CallbackAttempts,
+			LastError:     j.CallbackLastError,
+			LastAttemptAt: j.CallbackLastAttemptAt,
+			NextAttemptAt: j.CallbackNextAttemptAt,
+		}
+	}
+
+	var thumbnailURL, resultsURL string
+	if j.ResultPrefix != "" {
+		resultsURL = fmt.Sprintf("/api/v1/storage/jobs/%s/results", j.ID)
+	}
+	if j.ThumbnailPath != "" {
+		thumbnailURL = fmt.Sprintf("/api/v1/storage/courses/%s/results/%s", j.CourseID, j.ThumbnailPath)
+		if resultsURL != "" {
+			thumbnailURL = resultsURL + "/" + j.ThumbnailPath
+		}
+	}
+
 	return &JobResponse{
-		ID:          j.ID,
-		CourseID:    j.CourseID,
-		Status:      j.Status,
-		Progress:    j.Progress,
-		SourcePath:  j.SourcePath,
-		ResultPath:  j.ResultPath,
-		CallbackURL: j.CallbackURL,
-		Error:       j.Error,
-		Logs:        logs,
-		Metadata:    metadata,
-		CreatedAt:   j.CreatedAt,
-		UpdatedAt:   j.UpdatedAt,
-		StartedAt:   j.StartedAt,
-		CompletedAt: j.CompletedAt,
+		ID:              j.ID,
+		CourseID:        j.CourseID,
+		Status:          j.Status,
+		Progress:        j.Progress,
+		SourcePath:      j.SourcePath,
+		ResultPath:      j.ResultPath,
+		EntryPoints:     []string(j.EntryPoints),
+		EntryFile:       j.EntryFile,
+		OutputDir:       j.OutputDir,
+		CheckLinks:      BoolValue(j.CheckLinks),
+		CallbackURL:     j.CallbackURL,
+		Error:           j.Error,
+		Logs:            logs,
+		Metadata:        metadata,
+		Callback:        callback,
+		CreatedAt:       j.CreatedAt,
+		UpdatedAt:       j.UpdatedAt,
+		StartedAt:       j.StartedAt,
+		CompletedAt:     j.CompletedAt,
+		ForceRebuild:    j.ForceRebuild,
+		BuildFlags:      []string(j.BuildFlags),
+		Labels:          map[string]string(j.Labels),
+		CompressResults: BoolValue(j.CompressResults),
+		Themes:          []string(j.Themes),
+		ThemeResults:    []ThemeBuildResult(j.ThemeResults),
+		Thumbnail:       BoolValue(j.Thumbnail),
+		ThumbnailURL:    thumbnailURL,
+		SourceRetention: j.SourceRetention,
+		ResultPrefix:    j.ResultPrefix,
+		ResultsURL:      resultsURL,
+		AttemptCount:    j.AttemptCount,
+		Attempts:        []JobAttempt(j.Attempts),
+		SlideCount:      j.SlideCount,
+		Warnings:        []string(j.Warnings),
+		SourceHash:      j.SourceHash,
+		Preparation:     j.Preparation,
 	}
 }
 
@@ -202,6 +466,11 @@ type JobListResponse struct {
 	PageSize   int           `json:"page_size,omitempty" example:"25"`
 } // @name JobListResponse
 
+// BoolValue retourne la valeur d'une option booléenne facultative (false si elle est absente)
+func BoolValue(b *bool) bool {
+	return b != nil && *b
+}
+
 // IsTerminal retourne true si le job est dans un état final
 func (j *GenerationJob) IsTerminal() bool {
 	return j.Status == StatusCompleted || j.Status == StatusFailed || j.Status == StatusTimeout
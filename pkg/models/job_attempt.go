@@ -0,0 +1,104 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// MaxJobAttemptHistory est le nombre de tentatives conservées dans l'historique d'un job ;
+// les plus anciennes sont retirées, AttemptCount continue de les compter
+const MaxJobAttemptHistory = 10
+
+// InterruptedAttemptError est l'erreur d'une tentative dont le worker s'est arrêté en cours
+// de traitement, constatée quand le job est réservé à nouveau
+const InterruptedAttemptError = "attempt interrupted before completion"
+
+// JobAttempt est le résultat d'un traitement d'un job par un worker
+// @Description Tentative de traitement d'un job (un job remis en file en a plusieurs)
+type JobAttempt struct {
+	Number      int       `json:"number" example:"1"`
+	Status      JobStatus `json:"status" example:"failed" enums:"processing,completed,failed,timeout"`
+	Error       string    `json:"error,omitempty" example:"slidev build failed: exit status 1"`
+	StartedAt   time.Time `json:"started_at" example:"2025-01-15T10:30:00Z"`
+	CompletedAt time.Time `json:"completed_at" example:"2025-01-15T10:32:10Z"`
+	DurationMs  int64     `json:"duration_ms" example:"130000"`
+} // @name JobAttempt
+
+// JobAttempts type for PostgreSQL JSON arrays of job attempts
+type JobAttempts []JobAttempt
+
+// Append ajoute une tentative en ne gardant que les MaxJobAttemptHistory dernières
+func (ja JobAttempts) Append(attempt JobAttempt) JobAttempts {
+	attempts := append(ja, attempt)
+	if len(attempts) > MaxJobAttemptHistory {
+		attempts = attempts[len(attempts)-MaxJobAttemptHistory:]
+	}
+	return attempts
+}
+
+// Open ajoute une tentative en cours, démarrée à startedAt. Une tentative restée en cours,
+// dont le worker s'est arrêté, est d'abord clôturée en échec.
+func (ja JobAttempts) Open(number int, startedAt time.Time) JobAttempts {
+	attempts := append(JobAttempts(nil), ja...)
+	for i := range attempts {
+		if attempts[i].Status == StatusProcessing {
+			attempts[i].Status = StatusFailed
+			attempts[i].Error = InterruptedAttemptError
+			attempts[i].CompletedAt = startedAt
+			attempts[i].DurationMs = startedAt.Sub(attempts[i].StartedAt).Milliseconds()
+		}
+	}
+	return attempts.Append(JobAttempt{Number: number, Status: StatusProcessing, StartedAt: startedAt})
+}
+
+// Close clôture la tentative en cours avec le résultat attempt, en gardant son numéro et son
+// début. Retourne false si aucune tentative n'est en cours.
+func (ja JobAttempts) Close(attempt JobAttempt) (JobAttempts, bool) {
+	for i := len(ja) - 1; i >= 0; i-- {
+		if ja[i].Status != StatusProcessing {
+			continue
+		}
+		attempts := append(JobAttempts(nil), ja...)
+		attempt.Number = ja[i].Number
+		attempt.StartedAt = ja[i].StartedAt
+		if !attempt.CompletedAt.IsZero() {
+			attempt.DurationMs = attempt.CompletedAt.Sub(attempt.StartedAt).Milliseconds()
+		}
+		attempts[i] = attempt
+		return attempts, true
+	}
+	return ja, false
+}
+
+func (ja JobAttempts) Value() (driver.Value, error) {
+	if ja == nil {
+		return json.Marshal([]JobAttempt{})
+	}
+	return json.Marshal([]JobAttempt(ja))
+}
+
+func (ja *JobAttempts) Scan(value interface{}) error {
+	if value == nil {
+		*ja = JobAttempts{}
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into JobAttempts", value)
+	}
+
+	if len(bytes) == 0 {
+		*ja = JobAttempts{}
+		return nil
+	}
+
+	return json.Unmarshal(bytes, ja)
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ResultManifest décrit l'ensemble des fichiers générés pour un cours
+// @Description Manifeste des artefacts générés pour un cours (permet une synchronisation incrémentale)
+type ResultManifest struct {
+	CourseID    uuid.UUID       `json:"course_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	JobID       uuid.UUID       `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	GeneratedAt time.Time       `json:"generated_at" example:"2025-01-15T10:35:00Z"`
+	FileCount   int             `json:"file_count" example:"12"`
+	TotalSize   int64           `json:"total_size" example:"1048576"`
+	Files       []ManifestEntry `json:"files"`
+
+	// SPAFallback indique si la prévisualisation sert index.html pour les routes sans
+	// extension introuvables (absent = activé)
+	SPAFallback *bool `json:"spa_fallback,omitempty" example:"true"`
+
+	// ResultPrefix est la destination personnalisée des fichiers (published/{course_id}/<prefix>/),
+	// absente pour les résultats du cours
+	ResultPrefix string `json:"result_prefix,omitempty" example:"acme/intro-go/v3"`
+} // @name ResultManifest
+
+// MetadataSPAFallback est la clé de metadata d'un job (booléen) qui active ou désactive
+// le repli sur index.html de la prévisualisation du cours
+const MetadataSPAFallback = "spa_fallback"
+
+// SPAFallbackFromMetadata lit le réglage de repli SPA dans la metadata d'un job
+// (nil si la clé est absente ou n'est pas un booléen)
+func SPAFallbackFromMetadata(metadata JSON) *bool {
+	enabled, ok := metadata[MetadataSPAFallback].(bool)
+	if !ok {
+		return nil
+	}
+	return &enabled
+}
+
+// SPAFallbackEnabled indique si le repli sur index.html est actif pour le cours.
+// Il l'est par défaut, y compris sans manifeste.
+func (m *ResultManifest) SPAFallbackEnabled() bool {
+	return m == nil || m.SPAFallback == nil || *m.SPAFallback
+}
+
+// ManifestEntry décrit un fichier généré
+// @Description Fichier généré avec sa taille, son type et son empreinte
+type ManifestEntry struct {
+	Path        string    `json:"path" example:"assets/index-abc123.js"`
+	Size        int64     `json:"size" example:"20480"`
+	ContentType string    `json:"content_type" example:"application/javascript"`
+	Hash        string    `json:"hash" example:"sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	JobID       uuid.UUID `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	URL         string    `json:"url,omitempty" example:"https://worker.example.com/api/v1/storage/courses/550e8400-e29b-41d4-a716-446655440000/results/assets/index-abc123.js"`
+
+	// Encodings liste les variantes précompressées stockées à côté du fichier (index.html.gz)
+	Encodings []string `json:"encodings,omitempty" example:"gzip"`
+} // @name ManifestEntry
+
+// ResultDiff décrit les fichiers ajoutés, supprimés et modifiés entre deux builds d'un cours
+// @Description Différences entre les manifestes de deux builds d'un cours
+type ResultDiff struct {
+	CourseID       uuid.UUID    `json:"course_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	From           uuid.UUID    `json:"from" example:"550e8400-e29b-41d4-a716-446655440001"`
+	To             uuid.UUID    `json:"to" example:"550e8400-e29b-41d4-a716-446655440002"`
+	Added          []FileChange `json:"added"`
+	Removed        []FileChange `json:"removed"`
+	Changed        []FileChange `json:"changed"`
+	UnchangedCount int          `json:"unchanged_count" example:"9"`
+	SizeDelta      int64        `json:"size_delta" example:"-2048"`
+} // @name ResultDiff
+
+// FileChange décrit l'évolution d'un fichier généré entre deux builds.
+// Les champs old_* sont vides pour un ajout, les champs new_* pour une suppression.
+// @Description Fichier ajouté, supprimé ou modifié entre deux builds
+type FileChange struct {
+	Path      string `json:"path" example:"assets/index-abc123.js"`
+	OldHash   string `json:"old_hash,omitempty" example:"sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"`
+	NewHash   string `json:"new_hash,omitempty" example:"sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	OldSize   int64  `json:"old_size" example:"22528"`
+	NewSize   int64  `json:"new_size" example:"20480"`
+	SizeDelta int64  `json:"size_delta" example:"-2048"`
+} // @name FileChange
+
+// DiffManifests compare les manifestes de deux builds d'un cours. Un fichier est modifié
+// quand son empreinte change ; les listes sont triées par chemin.
+func DiffManifests(from, to *ResultManifest) *ResultDiff {
+	diff := &ResultDiff{
+		CourseID:  to.CourseID,
+		From:      from.JobID,
+		To:        to.JobID,
+		Added:     []FileChange{},
+		Removed:   []FileChange{},
+		Changed:   []FileChange{},
+		SizeDelta: to.TotalSize - from.TotalSize,
+	}
+
+	previous := make(map[string]ManifestEntry, len(from.Files))
+	for _, entry := range from.Files {
+		previous[entry.Path] = entry
+	}
+
+	for _, entry := range to.Files {
+		old, found := previous[entry.Path]
+		delete(previous, entry.Path)
+
+		switch {
+		case !found:
+			diff.Added = append(diff.Added, FileChange{
+				Path: entry.Path, NewHash: entry.Hash, NewSize: entry.Size, SizeDelta: entry.Size,
+			})
+		case old.Hash != entry.Hash:
+			diff.Changed = append(diff.Changed, FileChange{
+				Path: entry.Path, OldHash: old.Hash, NewHash: entry.Hash,
+				OldSize: old.Size, NewSize: entry.Size, SizeDelta: entry.Size - old.Size,
+			})
+		default:
+			diff.UnchangedCount++
+		}
+	}
+
+	for _, old := range previous {
+		diff.Removed = append(diff.Removed, FileChange{
+			Path: old.Path, OldHash: old.Hash, OldSize: old.Size, SizeDelta: -old.Size,
+		})
+	}
+
+	for _, changes := range [][]FileChange{diff.Added, diff.Removed, diff.Changed} {
+		sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	}
+
+	return diff
+}
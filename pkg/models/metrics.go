@@ -53,3 +53,27 @@ type DatabaseMetrics struct {
 	ConnectionsUsed int     `json:"connections_used" example:"5"`
 	ConnectionsMax  int     `json:"connections_max" example:"20"`
 } // @name DatabaseMetrics
+
+// StorageOperationStats contient les mesures de latence d'une opération de stockage
+// @Description Latence cumulée d'une opération du backend de stockage depuis le démarrage
+type StorageOperationStats struct {
+	Count         int64   `json:"count" example:"1520"`
+	Errors        int64   `json:"errors" example:"2"`
+	SlowCount     int64   `json:"slow_count" example:"5"`
+	AverageTimeMs float64 `json:"average_time_ms" example:"42.7"`
+	MaxTimeMs     float64 `json:"max_time_ms" example:"2310.5"`
+} // @name StorageOperationStats
+
+// StorageTransferStats contient les octets transférés vers et depuis le stockage
+// @Description Octets des sources et résultats uploadés et téléchargés, depuis le démarrage ou par fenêtre
+type StorageTransferStats struct {
+	UploadedBytes   int64      `json:"uploaded_bytes" example:"52428800"`
+	DownloadedBytes int64      `json:"downloaded_bytes" example:"1073741824"`
+	Since           time.Time  `json:"since" example:"2025-01-17T10:00:00Z"`
+	Until           *time.Time `json:"until,omitempty" example:"2025-01-17T11:00:00Z"`
+
+	// Window est la durée des fenêtres de comptage (absent = cumul depuis le démarrage) ;
+	// Previous la fenêtre terminée la plus récente
+	Window   string                `json:"window,omitempty" example:"1h0m0s"`
+	Previous *StorageTransferStats `json:"previous,omitempty"`
+} // @name StorageTransferStats
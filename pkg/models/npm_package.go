@@ -14,8 +14,24 @@ type NpmPackageInstallResult struct {
 	ExitCode  int           `json:"exit_code,omitempty" example:"0"`
 	Pipes     *InstallPipes `json:"-"` // Non exporté
 
+	// Attempts compte les tentatives d'installation, relances comprises
+	Attempts int `json:"attempts,omitempty" example:"1"`
+
+	// Output résume les avertissements et erreurs de npm ; Logs garde la sortie complète
+	Output *NpmOutputSummary `json:"output,omitempty"`
 } // @name ThemeInstallResult
 
+// NpmOutputSummary classe la sortie d'une installation npm ou yarn
+// @Description Avertissements et erreurs relevés dans la sortie d'une installation
+type NpmOutputSummary struct {
+	Warnings        int      `json:"warnings" example:"3"`     // Avertissements distincts, dépréciations comprises
+	Deprecations    int      `json:"deprecations" example:"2"` // Paquets dépréciés parmi les avertissements
+	Errors          int      `json:"errors" example:"1"`       // Erreurs (un bloc de lignes d'erreur consécutives par erreur)
+	WarningMessages []string `json:"warning_messages,omitempty" example:"deprecated inflight@1.0.6: This module is not supported"`
+	ErrorMessages   []string `json:"error_messages,omitempty" example:"code E404"`
+	Text            string   `json:"text" example:"3 warnings (2 deprecations), 1 error"`
+} // @name NpmOutputSummary
+
 // installPipes structure pour gérer les pipes de manière centralisée
 type InstallPipes struct {
 	Stdout io.ReadCloser
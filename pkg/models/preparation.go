@@ -0,0 +1,43 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// JobPreparation décrit ce que la préparation de l'environnement Slidev a trouvé dans les
+// sources ou créé par défaut avant le build
+// @Description Fichiers trouvés ou créés par le worker avant le build
+type JobPreparation struct {
+	// PackageJSONCreated indique un package.json absent des sources, créé par le worker
+	PackageJSONCreated bool `json:"package_json_created" example:"false"`
+	// SlideFile est le fichier de slides du build (vide si introuvable)
+	SlideFile string `json:"slide_file,omitempty" example:"slides.md"`
+	// SlideFileCreated indique des sources sans fichier de slides : le deck construit est le
+	// deck de remplacement du worker
+	SlideFileCreated bool `json:"slide_file_created" example:"false"`
+} // @name JobPreparation
+
+func (jp JobPreparation) Value() (driver.Value, error) {
+	return json.Marshal(jp)
+}
+
+func (jp *JobPreparation) Scan(value interface{}) error {
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into JobPreparation", value)
+	}
+
+	if len(bytes) == 0 {
+		*jp = JobPreparation{}
+		return nil
+	}
+
+	return json.Unmarshal(bytes, jp)
+}
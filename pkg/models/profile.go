@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GenerationProfile est le profil de génération d'un cours : des options de build par défaut,
+// appliquées à chaque job du cours qui ne les précise pas
+// @Description Options de build par défaut d'un cours
+type GenerationProfile struct {
+	CourseID        uuid.UUID       `json:"course_id" gorm:"type:uuid;primary_key" example:"550e8400-e29b-41d4-a716-446655440001"`
+	EntryFile       string          `json:"entry_file,omitempty" gorm:"type:text" example:"cours/presentation.md"`
+	OutputDir       string          `json:"output_dir,omitempty" gorm:"type:text" example:"build"`
+	BuildFlags      StringSlice     `json:"build_flags" gorm:"type:jsonb;default:'[]'" example:"--base=/cours/intro/"`
+	Themes          StringSlice     `json:"themes" gorm:"type:jsonb;default:'[]'" example:"seriph"`
+	CheckLinks      bool            `json:"check_links" gorm:"default:false" example:"true"`
+	CompressResults bool            `json:"compress_results" gorm:"default:false" example:"true"`
+	Thumbnail       bool            `json:"thumbnail" gorm:"default:false" example:"false"`
+	SourceRetention SourceRetention `json:"source_retention,omitempty" gorm:"type:varchar(20)" example:"keep" enums:"keep,delete-on-success"`
+	CreatedAt       time.Time       `json:"created_at" example:"2025-01-15T10:30:00Z"`
+	UpdatedAt       time.Time       `json:"updated_at" example:"2025-01-15T10:30:00Z"`
+} // @name GenerationProfile
+
+// TableName spécifie le nom de la table
+func (GenerationProfile) TableName() string {
+	return "generation_profiles"
+}
+
+// GenerationProfileRequest remplace le profil de génération d'un cours. Les champs ont le
+// sens et les contraintes des champs de même nom de GenerationRequest.
+// @Description Options de build par défaut d'un cours
+type GenerationProfileRequest struct {
+	EntryFile       string          `json:"entry_file,omitempty" example:"cours/presentation.md"`
+	OutputDir       string          `json:"output_dir,omitempty" example:"build"`
+	BuildFlags      []string        `json:"build_flags,omitempty" example:"--base=/cours/intro/"`
+	Themes          []string        `json:"themes,omitempty" example:"seriph"`
+	CheckLinks      bool            `json:"check_links,omitempty" example:"true"`
+	CompressResults bool            `json:"compress_results,omitempty" example:"true"`
+	Thumbnail       bool            `json:"thumbnail,omitempty" example:"false"`
+	SourceRetention SourceRetention `json:"source_retention,omitempty" example:"keep" enums:"keep,delete-on-success"`
+} // @name GenerationProfileRequest
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Raisons de la décision de rebuild
+const (
+	// RebuildReasonNoPreviousBuild : le cours n'a aucun build réussi
+	RebuildReasonNoPreviousBuild = "no_previous_build"
+	// RebuildReasonUnknownSourceHash : le dernier build n'a pas enregistré l'empreinte de ses sources
+	RebuildReasonUnknownSourceHash = "unknown_source_hash"
+	// RebuildReasonSourcesChanged : les sources diffèrent de celles du dernier build
+	RebuildReasonSourcesChanged = "sources_changed"
+	// RebuildReasonResultsMissing : les sources n'ont pas changé mais les résultats n'existent plus
+	RebuildReasonResultsMissing = "results_missing"
+	// RebuildReasonUpToDate : les résultats du dernier build correspondent aux sources
+	RebuildReasonUpToDate = "up_to_date"
+)
+
+// RebuildCheck indique si les sources d'un cours ont changé depuis son dernier build réussi
+// @Description Comparaison d'une empreinte de sources avec celle du dernier build réussi du cours
+type RebuildCheck struct {
+	CourseID       uuid.UUID  `json:"course_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	SourceHash     string     `json:"source_hash" example:"sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	ShouldRebuild  bool       `json:"should_rebuild" example:"false"`
+	Reason         string     `json:"reason" example:"up_to_date" enums:"no_previous_build,unknown_source_hash,sources_changed,results_missing,up_to_date"`
+	LastJobID      *uuid.UUID `json:"last_job_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+	LastBuildAt    *time.Time `json:"last_build_at,omitempty" example:"2025-01-15T10:35:00Z"`
+	LastSourceHash string     `json:"last_source_hash,omitempty" example:"sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	ResultsExist   bool       `json:"results_exist" example:"true"`
+} // @name RebuildCheck
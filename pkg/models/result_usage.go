@@ -0,0 +1,11 @@
+package models
+
+// CourseResultUsage décrit l'espace occupé par les résultats d'un cours et son quota
+// @Description Espace de stockage occupé par les résultats d'un cours, comparé à son quota
+type CourseResultUsage struct {
+	CourseID     string  `json:"course_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	FileCount    int     `json:"file_count" example:"42"`
+	UsedBytes    int64   `json:"used_bytes" example:"5242880"`
+	QuotaBytes   int64   `json:"quota_bytes" example:"104857600"` // 0 = sans limite
+	UsagePercent float64 `json:"usage_percent,omitempty" example:"5.0"`
+} // @name CourseResultUsage
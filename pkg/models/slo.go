@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LatencySLO décrit la latence de bout en bout des jobs terminés sur une fenêtre glissante
+// @Description Latence des jobs (création → fin) et part des jobs réussis sous chaque objectif
+type LatencySLO struct {
+	Window        string          `json:"window" example:"24h0m0s"`
+	WindowSeconds float64         `json:"window_seconds" example:"86400"`
+	Since         time.Time       `json:"since" example:"2024-01-01T12:00:00Z"`
+	CourseID      *uuid.UUID      `json:"course_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440001"`
+	FinishedJobs  int64           `json:"finished_jobs" example:"120"`
+	CompletedJobs int64           `json:"completed_jobs" example:"114"`
+	FailedJobs    int64           `json:"failed_jobs" example:"6"`
+	Latency       LatencySummary  `json:"latency_seconds"`
+	Targets       []LatencyTarget `json:"targets"`
+} // @name LatencySLO
+
+// LatencySummary contient les percentiles de latence des jobs réussis, en secondes
+// @Description Percentiles de latence des jobs réussis (secondes, 0 sans job réussi)
+type LatencySummary struct {
+	P50 float64 `json:"p50" example:"48.2"`
+	P95 float64 `json:"p95" example:"131.7"`
+	P99 float64 `json:"p99" example:"204"`
+} // @name LatencySummary
+
+// LatencyTarget est la part des jobs terminés ayant réussi en moins d'une durée cible
+// @Description Objectif de latence : jobs réussis sous la cible, rapportés aux jobs terminés
+type LatencyTarget struct {
+	Target        string  `json:"target" example:"2m0s"`
+	TargetSeconds float64 `json:"target_seconds" example:"120"`
+	WithinTarget  int64   `json:"within_target" example:"110"`
+	Ratio         float64 `json:"ratio" example:"0.9167"`
+} // @name LatencyTarget
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// ThemeResultPrefix précède le nom du thème dans le dossier de résultats d'une variante
+const ThemeResultPrefix = "theme-"
+
+// ThemeResultDir retourne le dossier des résultats d'une variante du mode matrice
+// (relatif à results/{course_id}/)
+func ThemeResultDir(theme string) string {
+	return ThemeResultPrefix + theme
+}
+
+// ThemeBuildResult est le résultat du build d'une variante de thème
+// @Description Résultat du build d'un thème en mode matrice
+type ThemeBuildResult struct {
+	Theme       string   `json:"theme" example:"seriph"`
+	Success     bool     `json:"success" example:"true"`
+	Path        string   `json:"path" example:"theme-seriph/"`
+	EntryPoints []string `json:"entry_points,omitempty" example:"theme-seriph/index.html"`
+	FileCount   int      `json:"file_count,omitempty" example:"12"`
+	Error       string   `json:"error,omitempty" example:"slidev build failed with exit code 1"`
+} // @name ThemeBuildResult
+
+// ThemeBuildResults type for PostgreSQL JSON arrays of theme build results
+type ThemeBuildResults []ThemeBuildResult
+
+func (tr ThemeBuildResults) Value() (driver.Value, error) {
+	if tr == nil {
+		return json.Marshal([]ThemeBuildResult{})
+	}
+	return json.Marshal([]ThemeBuildResult(tr))
+}
+
+func (tr *ThemeBuildResults) Scan(value interface{}) error {
+	if value == nil {
+		*tr = ThemeBuildResults{}
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into ThemeBuildResults", value)
+	}
+
+	if len(bytes) == 0 {
+		*tr = ThemeBuildResults{}
+		return nil
+	}
+
+	return json.Unmarshal(bytes, tr)
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookSchemaVersion est la version courante du format des payloads de webhook.
+// Elle n'est incrémentée que pour des changements incompatibles (champ retiré ou renommé) ;
+// l'ajout de champs reste compatible et ne change pas la version.
+const WebhookSchemaVersion = "1"
+
+// WebhookSchemaVersionLegacy désigne l'ancien format des callbacks : le JobResponse du job
+// terminé, sans enveloppe. C'est le format par défaut, pour les récepteurs existants.
+const WebhookSchemaVersionLegacy = "0"
+
+// WebhookEventType identifie le type d'événement notifié
+type WebhookEventType string
+
+const (
+	WebhookEventJobStarted   WebhookEventType = "job.started"
+	WebhookEventJobCompleted WebhookEventType = "job.completed"
+	WebhookEventJobFailed    WebhookEventType = "job.failed"
+	WebhookEventJobTimeout   WebhookEventType = "job.timeout"
+)
+
+// WebhookPayload est l'enveloppe stable envoyée aux URLs de callback
+// @Description Notification de webhook versionnée (signée via les en-têtes X-OCF-Signature)
+type WebhookPayload struct {
+	SchemaVersion string           `json:"schema_version" example:"1"`
+	EventID       uuid.UUID        `json:"event_id" example:"550e8400-e29b-41d4-a716-446655440010"`
+	EventType     WebhookEventType `json:"event_type" example:"job.completed"`
+	OccurredAt    time.Time        `json:"occurred_at" example:"2025-01-15T10:35:00Z"`
+	SentAt        time.Time        `json:"sent_at" example:"2025-01-15T10:35:01Z"`
+	JobID         uuid.UUID        `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	CourseID      uuid.UUID        `json:"course_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Job           *JobResponse     `json:"job"`
+} // @name WebhookPayload
+
+// WebhookEventForStatus retourne le type d'événement correspondant au statut d'un job
+func WebhookEventForStatus(status JobStatus) WebhookEventType {
+	switch status {
+	case StatusProcessing:
+		return WebhookEventJobStarted
+	case StatusFailed:
+		return WebhookEventJobFailed
+	case StatusTimeout:
+		return WebhookEventJobTimeout
+	default:
+		return WebhookEventJobCompleted
+	}
+}
+
+// NewWebhookPayload construit le payload de webhook d'un job terminé
+func NewWebhookPayload(job *GenerationJob) *WebhookPayload {
+	now := time.Now().UTC()
+
+	occurredAt := now
+	if job.CompletedAt != nil {
+		occurredAt = job.CompletedAt.UTC()
+	}
+
+	return newWebhookPayload(job, occurredAt, now)
+}
+
+// NewJobStartedPayload construit le payload de l'événement job.started d'un job réservé
+// à startedAt (le job lu avant la réservation est encore en attente)
+func NewJobStartedPayload(job *GenerationJob, startedAt time.Time) *WebhookPayload {
+	started := *job
+	started.Status = StatusProcessing
+	started.StartedAt = &startedAt
+
+	return newWebhookPayload(&started, startedAt.UTC(), time.Now().UTC())
+}
+
+func newWebhookPayload(job *GenerationJob, occurredAt, now time.Time) *WebhookPayload {
+	return &WebhookPayload{
+		SchemaVersion: WebhookSchemaVersion,
+		EventID:       uuid.New(),
+		EventType:     WebhookEventForStatus(job.Status),
+		OccurredAt:    occurredAt,
+		SentAt:        now,
+		JobID:         job.ID,
+		CourseID:      job.CourseID,
+		Job:           job.ToResponse(),
+	}
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // WorkerStats représente les statistiques du pool de workers
 // @Description Statistiques détaillées du pool de workers
@@ -53,14 +57,33 @@ type WorkerPerformance struct {
 	TotalJobsFailed     int64   `json:"total_jobs_failed" example:"60"`
 } // @name WorkerPerformance
 
+// MaintenanceRequest active ou désactive le mode maintenance d'une instance
+// @Description Bascule du mode maintenance
+type MaintenanceRequest struct {
+	Enabled *bool  `json:"enabled" example:"true"`
+	Message string `json:"message,omitempty" example:"Mise à jour du worker, reprise vers 14h"`
+} // @name MaintenanceRequest
+
+// MaintenanceStatus décrit le mode maintenance d'une instance et l'avancement du drainage
+// @Description État du mode maintenance : drained indique qu'aucun job n'est plus en cours
+type MaintenanceStatus struct {
+	Enabled    bool       `json:"enabled" example:"true"`
+	Message    string     `json:"message,omitempty" example:"Mise à jour du worker, reprise vers 14h"`
+	Since      *time.Time `json:"since,omitempty" example:"2025-01-17T10:30:00Z"`
+	ActiveJobs int        `json:"active_jobs" example:"1"`
+	Drained    bool       `json:"drained" example:"false"`
+} // @name MaintenanceStatus
+
 // WorkerHealthResponse représente l'état de santé du système de workers
 // @Description État de santé détaillé du système de workers
 type WorkerHealthResponse struct {
-	Status     string           `json:"status" example:"healthy" enums:"healthy,degraded,unhealthy"`
+	Status     string           `json:"status" example:"healthy" enums:"healthy,degraded,unhealthy,maintenance"`
 	WorkerPool WorkerPoolHealth `json:"worker_pool"`
 	Issues     []string         `json:"issues,omitempty" example:"1 worker is overloaded"`
 	Timestamp  time.Time        `json:"timestamp" example:"2025-01-17T10:30:00Z"`
 	Uptime     string           `json:"uptime" example:"24h30m15s"`
+
+	Maintenance *MaintenanceStatus `json:"maintenance,omitempty"`
 } // @name WorkerHealthResponse
 
 // WorkerPoolHealth contient les métriques de santé du pool
@@ -74,3 +97,23 @@ type WorkerPoolHealth struct {
 	QueueUsage    float64 `json:"queue_usage_percent" example:"15.0"`
 	OverloadRisk  bool    `json:"overload_risk" example:"false"`
 } // @name WorkerPoolHealth
+
+// QueuedJob décrit un job dans la file en mémoire d'une instance
+// @Description Job en attente d'un worker, dans l'ordre de mise en file
+type QueuedJob struct {
+	Position int       `json:"position" example:"1"`
+	JobID    uuid.UUID `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	CourseID uuid.UUID `json:"course_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	QueuedAt time.Time `json:"queued_at" example:"2025-01-17T10:30:00Z"`
+} // @name QueuedJob
+
+// QueueSnapshot est le contenu paginé de la file en mémoire d'une instance
+// @Description Jobs en file de l'instance ; backlog compte les jobs pending en base hors de la file
+type QueueSnapshot struct {
+	Jobs       []QueuedJob `json:"jobs"`
+	Count      int         `json:"count" example:"20"`
+	TotalCount int         `json:"total_count" example:"42"`
+	Offset     int         `json:"offset" example:"0"`
+	Limit      int         `json:"limit" example:"100"`
+	Backlog    int         `json:"backlog" example:"0"`
+} // @name QueueSnapshot
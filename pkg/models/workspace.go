@@ -17,6 +17,7 @@ type WorkspaceInfo struct {
 	DistExists    bool     `json:"dist_exists" example:"true"`
 	DistFileCount int      `json:"dist_file_count" example:"12"`
 	DistFiles     []string `json:"dist_files,omitempty" example:"index.html,assets/style.css,assets/script.js"`
+	Status        string   `json:"status,omitempty" example:"completed" enums:"active,idle,completed"`
 } // @name WorkspaceInfo
 
 // WorkspaceListResponse représente la liste des workspaces
@@ -68,6 +69,9 @@ type StorageUsage struct {
 	DistMB      float64 `json:"dist_mb,omitempty" example:"20.0"`
 	OtherBytes  int64   `json:"other_bytes" example:"29360128"`
 	OtherMB     float64 `json:"other_mb" example:"28.0"`
+	// Dépendances (node_modules, .npm-cache), incluses dans TotalBytes selon la configuration
+	DependencyBytes int64   `json:"dependency_bytes" example:"157286400"`
+	DependencyMB    float64 `json:"dependency_mb" example:"150.0"`
 } // @name StorageUsage
 
 // FileDistribution représente la répartition des fichiers
@@ -113,6 +117,15 @@ type WorkspaceCleanupResponse struct {
 	Error        string  `json:"error,omitempty"`
 } // @name WorkspaceCleanupResponse
 
+// WorkspacePurgeResponse représente le résultat de la purge des dépendances d'un workspace
+// @Description Résultat de la suppression de node_modules d'un workspace conservé
+type WorkspacePurgeResponse struct {
+	JobID        string  `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Directory    string  `json:"directory" example:"node_modules"`
+	BytesFreed   int64   `json:"bytes_freed" example:"157286400"`
+	BytesFreedMB float64 `json:"bytes_freed_mb" example:"150.0"`
+} // @name WorkspacePurgeResponse
+
 // WorkspaceCleanupBatchResponse représente le résultat de nettoyage en lot
 // @Description Résultat du nettoyage automatique des anciens workspaces
 type WorkspaceCleanupBatchResponse struct {
@@ -144,8 +157,38 @@ type StorageInfo struct {
 	Endpoints   map[string]string `json:"endpoints"`
 	Status      string            `json:"status" example:"healthy"`
 	Capacity    *StorageCapacity  `json:"capacity,omitempty"`
+
+	// Operations contient la latence par opération (upload, download, list, delete)
+	Operations    map[string]StorageOperationStats `json:"operations,omitempty"`
+	SlowThreshold string                           `json:"slow_threshold,omitempty" example:"1s"`
+
+	// Transfer contient les octets des sources et résultats uploadés et téléchargés
+	Transfer *StorageTransferStats `json:"transfer,omitempty"`
 } // @name StorageInfo
 
+// UploadLimits décrit les fichiers acceptés à l'upload des sources, d'après la
+// configuration de validation active
+// @Description Types de fichiers et limites appliqués à l'upload des sources
+type UploadLimits struct {
+	AllowedExtensions []string `json:"allowed_extensions" example:".md,.css,.png"`
+	AllowedMimeTypes  []string `json:"allowed_mime_types" example:"text/markdown,text/css,image/png"`
+	MaxFileSize       int64    `json:"max_file_size" example:"10485760"`
+	MaxTotalSize      int64    `json:"max_total_size" example:"52428800"`
+	MaxFiles          int      `json:"max_files" example:"100"`
+	MaxPathDepth      int      `json:"max_path_depth" example:"10"`
+	MaxFilenameLength int      `json:"max_filename_length" example:"255"`
+	// RejectEmptyFiles indique que les fichiers vides sont refusés, sauf ceux dont
+	// l'extension figure dans EmptyFileExtensions
+	RejectEmptyFiles    bool     `json:"reject_empty_files" example:"false"`
+	EmptyFileExtensions []string `json:"empty_file_extensions,omitempty" example:".gitkeep,.css"`
+	// DirectoryRules associe un dossier à sa politique : "deny", "binary" ou des
+	// extensions supplémentaires (".csv|.tsv")
+	DirectoryRules map[string]string `json:"directory_rules,omitempty"`
+	// MaxArchiveExpansionRatio borne la taille décompressée d'une archive de sources
+	// (POST .../sources/archive) à ce multiple de sa taille
+	MaxArchiveExpansionRatio int `json:"max_archive_expansion_ratio" example:"100"`
+} // @name UploadLimits
+
 // StorageCapacity représente la capacité de stockage
 // @Description Informations sur la capacité de stockage
 type StorageCapacity struct {
@@ -155,13 +198,31 @@ type StorageCapacity struct {
 	Usage     float64 `json:"usage_percent" example:"25.0"`
 } // @name StorageCapacity
 
+// OverwritePolicy définit le traitement des fichiers sources déjà présents lors d'un upload
+type OverwritePolicy string
+
+const (
+	// OverwriteReplace écrase les fichiers existants (comportement par défaut)
+	OverwriteReplace OverwritePolicy = "replace"
+	// OverwriteSkipExisting conserve les fichiers existants et n'écrit que les nouveaux
+	OverwriteSkipExisting OverwritePolicy = "skip-existing"
+	// OverwriteErrorOnExisting refuse tout l'upload si un fichier existe déjà
+	OverwriteErrorOnExisting OverwritePolicy = "error-on-existing"
+)
+
 // FileUploadResponse représente la réponse d'upload de fichiers
 // @Description Réponse après upload de fichiers
 type FileUploadResponse struct {
-	Message string   `json:"message" example:"files uploaded successfully"`
-	JobID   string   `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440001"`
-	Count   int      `json:"count" example:"3"`
-	Files   []string `json:"files,omitempty" example:"slides.md,theme.css,config.json"`
+	Message   string   `json:"message" example:"files uploaded successfully"`
+	JobID     string   `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Count     int      `json:"count" example:"3"`
+	Files     []string `json:"files,omitempty" example:"slides.md,theme.css,config.json"`
+	Skipped   []string `json:"skipped,omitempty" example:"assets/logo.png"`
+	Overwrite string   `json:"overwrite" example:"replace" enums:"replace,skip-existing,error-on-existing"`
+	// Checksums associe chaque fichier uploadé à son empreinte SHA-256
+	Checksums map[string]string `json:"checksums,omitempty"`
+	// UploadSession est la session de suivi de l'upload (?upload_session=)
+	UploadSession string `json:"upload_session,omitempty" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
 } // @name FileUploadResponse
 
 // FileListResponse représente la liste de fichiers
@@ -171,4 +232,97 @@ type FileListResponse struct {
 	CourseID string   `json:"course_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440002"`
 	Files    []string `json:"files" example:"index.html,assets/style.css,assets/script.js"`
 	Count    int      `json:"count" example:"3"`
+	// URLs associe chaque fichier de résultat à son URL de téléchargement
+	URLs map[string]string `json:"urls,omitempty"`
+	// ResultPrefix est la destination personnalisée des résultats d'un job listés
+	ResultPrefix string `json:"result_prefix,omitempty" example:"acme/intro-go/v3"`
+	// Checksums associe chaque fichier source à son empreinte SHA-256 (checksum=true)
+	Checksums map[string]string `json:"checksums,omitempty"`
 } // @name FileListResponse
+
+// SourceSummary résume les fichiers sources d'un job sans les télécharger
+// @Description Nombre et taille des fichiers sources d'un job, au total et par extension
+type SourceSummary struct {
+	JobID     string `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Count     int    `json:"count" example:"3"`
+	TotalSize int64  `json:"total_size" example:"20480"`
+	// Extensions détaille les fichiers par extension en minuscules (".md"), "none" sans extension
+	Extensions map[string]ExtensionSummary `json:"extensions"`
+} // @name SourceSummary
+
+// ExtensionSummary compte les fichiers sources d'une extension
+type ExtensionSummary struct {
+	Count int   `json:"count" example:"2"`
+	Size  int64 `json:"size" example:"4096"`
+} // @name ExtensionSummary
+
+// Types de problèmes détectés dans l'arborescence des sources
+const (
+	// SourceIssueInvalidPath : chemin refusé par la validation des uploads
+	SourceIssueInvalidPath = "invalid_path"
+	// SourceIssueOrphaned : entrée sans fichier (marqueur de dossier d'un backend S3)
+	SourceIssueOrphaned = "orphaned"
+)
+
+// Actions de réparation des sources
+const (
+	SourceRepairRenamed = "renamed"
+	SourceRepairDeleted = "deleted"
+	SourceRepairSkipped = "skipped"
+)
+
+// SourceTreeIssue décrit une entrée problématique des sources d'un job
+type SourceTreeIssue struct {
+	Path   string   `json:"path" example:"../notes.md"`
+	Type   string   `json:"type" example:"invalid_path" enums:"invalid_path,orphaned"`
+	Errors []string `json:"errors,omitempty"`
+	// SuggestedPath est le chemin sanitisé proposé pour un chemin invalide
+	SuggestedPath string `json:"suggested_path,omitempty" example:"notes.md"`
+	// Action est la réparation appliquée (POST .../sources/repair) ; Reason explique un skipped
+	Action string `json:"action,omitempty" example:"renamed" enums:"renamed,deleted,skipped"`
+	Reason string `json:"reason,omitempty"`
+} // @name SourceTreeIssue
+
+// SourceTreeReport est le rapport de vérification de l'arborescence des sources d'un job
+// @Description Entrées invalides ou orphelines des sources d'un job, et réparations appliquées
+type SourceTreeReport struct {
+	JobID    string            `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Checked  int               `json:"checked" example:"12"`
+	Valid    bool              `json:"valid" example:"false"`
+	Repair   bool              `json:"repair" example:"true"`
+	Repaired int               `json:"repaired" example:"1"`
+	Issues   []SourceTreeIssue `json:"issues"`
+} // @name SourceTreeReport
+
+// UploadSessionStatus est l'état d'une session de suivi d'upload
+type UploadSessionStatus string
+
+const (
+	// UploadSessionPending : session créée, upload pas encore reçu
+	UploadSessionPending UploadSessionStatus = "pending"
+	// UploadSessionUploading : fichiers en cours d'écriture dans le storage
+	UploadSessionUploading UploadSessionStatus = "uploading"
+	// UploadSessionCompleted : upload terminé avec succès
+	UploadSessionCompleted UploadSessionStatus = "completed"
+	// UploadSessionFailed : upload refusé ou en erreur
+	UploadSessionFailed UploadSessionStatus = "failed"
+)
+
+// UploadProgress décrit l'avancement d'un upload de sources suivi par une session
+// @Description Nombre de fichiers d'un upload déjà écrits dans le storage
+type UploadProgress struct {
+	SessionID   string              `json:"session_id" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	JobID       string              `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Status      UploadSessionStatus `json:"status" example:"uploading" enums:"pending,uploading,completed,failed"`
+	TotalFiles  int                 `json:"total_files" example:"40"`
+	StoredFiles int                 `json:"stored_files" example:"12"`
+	FailedFiles int                 `json:"failed_files" example:"0"`
+	TotalBytes  int64               `json:"total_bytes" example:"10485760"`
+	StoredBytes int64               `json:"stored_bytes" example:"3145728"`
+	Error       string              `json:"error,omitempty" example:"upload rejected with status 400"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+	CompletedAt *time.Time          `json:"completed_at,omitempty"`
+	// ExpiresAt est la date à laquelle la session sera oubliée
+	ExpiresAt time.Time `json:"expires_at"`
+} // @name UploadProgress
@@ -2,7 +2,9 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"io"
+	"time"
 )
 
 // Storage définit l'interface pour le stockage de fichiers
@@ -26,6 +28,85 @@ type Storage interface {
 	GetURL(ctx context.Context, path string) (string, error)
 }
 
+// SizedUploader est implémentée par les backends qui tirent parti d'une taille connue à
+// l'avance (Content-Length, choix entre upload simple et multipart sans bufferiser)
+type SizedUploader interface {
+	// UploadSized upload exactement size octets lus depuis data
+	UploadSized(ctx context.Context, path string, data io.Reader, size int64) error
+}
+
+// UploadWithSize utilise UploadSized si le backend le supporte, sinon Upload.
+// Une taille négative signifie qu'elle est inconnue.
+func UploadWithSize(ctx context.Context, s Storage, path string, data io.Reader, size int64) error {
+	if sized, ok := s.(SizedUploader); ok && size >= 0 {
+		return sized.UploadSized(ctx, path, data, size)
+	}
+	return s.Upload(ctx, path, data)
+}
+
+// MetadataStorage est implémentée par les backends qui conservent des métadonnées
+// clé/valeur avec chaque objet (x-amz-meta-* pour S3)
+type MetadataStorage interface {
+	// UploadWithMetadata upload un objet et ses métadonnées (size < 0 = taille inconnue)
+	UploadWithMetadata(ctx context.Context, path string, data io.Reader, size int64, metadata map[string]string) error
+
+	// GetMetadata retourne les métadonnées d'un objet (vide s'il n'en a pas)
+	GetMetadata(ctx context.Context, path string) (map[string]string, error)
+}
+
+// ErrMetadataNotSupported est retournée par GetMetadata quand le backend ne conserve pas de métadonnées
+var ErrMetadataNotSupported = errors.New("storage backend does not support object metadata")
+
+// UploadWithMetadata utilise UploadWithMetadata si le backend le supporte, sinon
+// UploadWithSize : les métadonnées sont alors perdues.
+func UploadWithMetadata(ctx context.Context, s Storage, path string, data io.Reader, size int64, metadata map[string]string) error {
+	if withMetadata, ok := s.(MetadataStorage); ok {
+		return withMetadata.UploadWithMetadata(ctx, path, data, size, metadata)
+	}
+	return UploadWithSize(ctx, s, path, data, size)
+}
+
+// GetMetadata lit les métadonnées d'un objet, ou ErrMetadataNotSupported
+func GetMetadata(ctx context.Context, s Storage, path string) (map[string]string, error) {
+	if withMetadata, ok := s.(MetadataStorage); ok {
+		return withMetadata.GetMetadata(ctx, path)
+	}
+	return nil, ErrMetadataNotSupported
+}
+
+// ObjectInfo décrit un objet stocké
+type ObjectInfo struct {
+	Size int64 // Taille en octets
+}
+
+// StatStorage est implémentée par les backends capables de décrire un objet sans le
+// télécharger (os.Stat, HeadObject)
+type StatStorage interface {
+	// Stat retourne les informations d'un objet
+	Stat(ctx context.Context, path string) (*ObjectInfo, error)
+}
+
+// Stat utilise Stat si le backend le supporte, sinon télécharge l'objet pour en mesurer la taille
+func Stat(ctx context.Context, s Storage, path string) (*ObjectInfo, error) {
+	if stater, ok := s.(StatStorage); ok {
+		return stater.Stat(ctx, path)
+	}
+
+	reader, err := s.Download(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	size, err := io.Copy(io.Discard, reader)
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectInfo{Size: size}, nil
+}
+
 // StorageConfig contient la configuration du storage
 type StorageConfig struct {
 	Type         string // "filesystem" ou "garage"
@@ -36,4 +117,10 @@ type StorageConfig struct {
 	SecretKey    string
 	Bucket       string
 	Region       string
+
+	// SlowOpThreshold déclenche un log pour toute opération plus lente (0 = défaut)
+	SlowOpThreshold time.Duration
+
+	// PresignExpiry est la durée de validité des URLs présignées S3 (0 = 1 heure)
+	PresignExpiry time.Duration
 }
@@ -0,0 +1,140 @@
+// Package webhook signe et vérifie les callbacks envoyés par ocf-worker.
+//
+// Côté récepteur :
+//
+//	payload, err := webhook.ParseRequest(r, []byte(secret), webhook.DefaultTolerance)
+//	if err != nil {
+//		http.Error(w, err.Error(), http.StatusUnauthorized)
+//		return
+//	}
+//
+// La signature porte sur "{timestamp}.{body}" : un payload rejoué hors de la
+// fenêtre de tolérance est refusé même si sa signature est valide.
+//
+// ParseRequest décode l'enveloppe versionnée (CALLBACK_SCHEMA_VERSION=1) ; Verify
+// vérifie aussi la signature des callbacks à l'ancien format.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+)
+
+// En-têtes HTTP des callbacks
+const (
+	HeaderSignature          = "X-OCF-Signature"
+	HeaderSignatureAlgorithm = "X-OCF-Signature-Algorithm"
+	HeaderTimestamp          = "X-OCF-Timestamp"
+	HeaderSchemaVersion      = "X-OCF-Schema-Version"
+	HeaderEvent              = "X-OCF-Event"
+)
+
+// AlgorithmHMACSHA256 est le seul algorithme de signature supporté
+const AlgorithmHMACSHA256 = "hmac-sha256"
+
+// DefaultTolerance est l'écart maximal accepté entre l'horodatage signé et l'heure locale
+const DefaultTolerance = 5 * time.Minute
+
+// maxPayloadSize limite la taille des payloads lus par ParseRequest
+const maxPayloadSize = 1 << 20
+
+var (
+	// ErrMissingSignature est retournée quand la requête n'est pas signée
+	ErrMissingSignature = errors.New("webhook signature missing")
+	// ErrUnsupportedAlgorithm est retournée pour un algorithme de signature inconnu
+	ErrUnsupportedAlgorithm = errors.New("unsupported webhook signature algorithm")
+	// ErrInvalidSignature est retournée quand la signature ne correspond pas au payload
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrTimestampOutOfTolerance est retournée pour un payload trop ancien ou daté du futur
+	ErrTimestampOutOfTolerance = errors.New("webhook timestamp outside tolerance")
+	// ErrUnsupportedSchemaVersion est retournée pour une version de payload non supportée
+	ErrUnsupportedSchemaVersion = errors.New("unsupported webhook schema version")
+)
+
+// Sign calcule la signature HMAC-SHA256 (hexadécimale) d'un payload horodaté
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SetHeaders signe le payload et renseigne les en-têtes de la requête de callback
+func SetHeaders(header http.Header, secret []byte, payload *models.WebhookPayload, body []byte) {
+	header.Set(HeaderSchemaVersion, payload.SchemaVersion)
+	header.Set(HeaderEvent, string(payload.EventType))
+
+	if len(secret) == 0 {
+		return
+	}
+
+	timestamp := payload.SentAt.Unix()
+	header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	header.Set(HeaderSignatureAlgorithm, AlgorithmHMACSHA256)
+	header.Set(HeaderSignature, Sign(secret, timestamp, body))
+}
+
+// Verify vérifie la signature et l'horodatage d'un callback.
+// Une tolérance nulle désactive la vérification de l'horodatage.
+func Verify(secret []byte, header http.Header, body []byte, tolerance time.Duration) error {
+	signature := header.Get(HeaderSignature)
+	if signature == "" {
+		return ErrMissingSignature
+	}
+
+	if algorithm := header.Get(HeaderSignatureAlgorithm); algorithm != AlgorithmHMACSHA256 {
+		return fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, algorithm)
+	}
+
+	timestamp, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp", ErrInvalidSignature)
+	}
+
+	expected := Sign(secret, timestamp, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrInvalidSignature
+	}
+
+	if tolerance > 0 {
+		age := time.Since(time.Unix(timestamp, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrTimestampOutOfTolerance
+		}
+	}
+
+	return nil
+}
+
+// ParseRequest vérifie un callback reçu et décode son payload
+func ParseRequest(r *http.Request, secret []byte, tolerance time.Duration) (*models.WebhookPayload, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPayloadSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook body: %w", err)
+	}
+
+	if err := Verify(secret, r.Header, body, tolerance); err != nil {
+		return nil, err
+	}
+
+	var payload models.WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook payload: %w", err)
+	}
+
+	if payload.SchemaVersion != models.WebhookSchemaVersion {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedSchemaVersion, payload.SchemaVersion)
+	}
+
+	return &payload, nil
+}
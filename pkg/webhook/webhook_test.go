@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signedRequest construit une requête de callback signée comme le ferait le worker
+func signedRequest(t *testing.T, secret []byte, payload *models.WebhookPayload) *http.Request {
+	body, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+	SetHeaders(req.Header, secret, payload, body)
+	return req
+}
+
+func TestParseRequest(t *testing.T) {
+	secret := []byte("s3cr3t")
+	job := &models.GenerationJob{ID: uuid.New(), CourseID: uuid.New(), Status: models.StatusFailed}
+
+	t.Run("Valid signature", func(t *testing.T) {
+		req := signedRequest(t, secret, models.NewWebhookPayload(job))
+		assert.Equal(t, "1", req.Header.Get(HeaderSchemaVersion))
+		assert.Equal(t, AlgorithmHMACSHA256, req.Header.Get(HeaderSignatureAlgorithm))
+
+		payload, err := ParseRequest(req, secret, DefaultTolerance)
+		require.NoError(t, err)
+		assert.Equal(t, models.WebhookEventJobFailed, payload.EventType)
+		assert.Equal(t, job.ID, payload.JobID)
+		assert.Equal(t, job.CourseID, payload.CourseID)
+		require.NotNil(t, payload.Job)
+		assert.Equal(t, models.StatusFailed, payload.Job.Status)
+	})
+
+	t.Run("Wrong secret", func(t *testing.T) {
+		req := signedRequest(t, secret, models.NewWebhookPayload(job))
+		_, err := ParseRequest(req, []byte("other"), DefaultTolerance)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("Tampered body", func(t *testing.T) {
+		payload := models.NewWebhookPayload(job)
+		body, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(bytes.Replace(body, []byte("failed"), []byte("completed"), -1)))
+		SetHeaders(req.Header, secret, payload, body)
+
+		_, err = ParseRequest(req, secret, DefaultTolerance)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("Unsigned", func(t *testing.T) {
+		req := signedRequest(t, nil, models.NewWebhookPayload(job))
+		_, err := ParseRequest(req, secret, DefaultTolerance)
+		assert.ErrorIs(t, err, ErrMissingSignature)
+	})
+
+	t.Run("Replayed outside tolerance", func(t *testing.T) {
+		payload := models.NewWebhookPayload(job)
+		payload.SentAt = time.Now().Add(-time.Hour)
+
+		_, err := ParseRequest(signedRequest(t, secret, payload), secret, DefaultTolerance)
+		assert.ErrorIs(t, err, ErrTimestampOutOfTolerance)
+	})
+
+	t.Run("Unknown schema version", func(t *testing.T) {
+		payload := models.NewWebhookPayload(job)
+		payload.SchemaVersion = "2"
+
+		_, err := ParseRequest(signedRequest(t, secret, payload), secret, DefaultTolerance)
+		assert.ErrorIs(t, err, ErrUnsupportedSchemaVersion)
+	})
+
+	t.Run("Unknown algorithm", func(t *testing.T) {
+		req := signedRequest(t, secret, models.NewWebhookPayload(job))
+		req.Header.Set(HeaderSignatureAlgorithm, "md5")
+
+		_, err := ParseRequest(req, secret, DefaultTolerance)
+		assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+	})
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"schema_version":"1"}`)
+	timestamp := time.Now().Unix()
+
+	header := http.Header{}
+	header.Set(HeaderSignature, Sign([]byte("key"), timestamp, body))
+	header.Set(HeaderSignatureAlgorithm, AlgorithmHMACSHA256)
+	header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+
+	assert.NoError(t, Verify([]byte("key"), header, body, DefaultTolerance))
+	assert.NotEqual(t, Sign([]byte("key"), timestamp+1, body), header.Get(HeaderSignature))
+}